@@ -0,0 +1,100 @@
+// jobworker ประมวลผลคิวงานเบื้องหลัง (ตาราง jobs) และสร้างงานตาม job_schedules
+// รันได้หลาย instance พร้อมกัน งานแต่ละชิ้นจะถูกจองด้วย FOR UPDATE SKIP LOCKED
+// (cmd/worker เป็นแพ็กเกจ helper อัปโหลด S3 ไม่ใช่ตัว worker)
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"myapp/database"
+	"myapp/jobs"
+	bookingServices "myapp/modules/barberbooking/services"
+	coreServices "myapp/modules/core/services"
+	notificationModels "myapp/modules/notification/models"
+	notificationServices "myapp/modules/notification/services"
+	posServices "myapp/modules/pos/services"
+	webhookModels "myapp/modules/webhook/models"
+	webhookServices "myapp/modules/webhook/services"
+)
+
+const (
+	jobPurgeFinished        = "jobs.purge_finished"
+	jobPurgeTelegramUpdates = "telegram.purge_updates"
+)
+
+type purgeFinishedPayload struct {
+	OlderThanHours int `json:"older_than_hours"`
+}
+
+func main() {
+	database.ConnectDB()
+	if err := database.DB.AutoMigrate(
+		&jobs.Job{},
+		&jobs.JobSchedule{},
+		&notificationModels.NotificationChannelConfig{},
+		&notificationModels.Notification{},
+		&webhookModels.WebhookEndpoint{},
+		&webhookModels.WebhookDelivery{},
+	); err != nil {
+		log.Fatalf("❌ failed to migrate job tables: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	worker := jobs.NewWorker(database.DB, fmt.Sprintf("%s-%d", hostname, os.Getpid()))
+	if n, err := strconv.Atoi(os.Getenv("JOB_WORKER_CONCURRENCY")); err == nil && n > 0 {
+		worker.Concurrency = n
+	}
+
+	worker.Register(jobPurgeFinished, jobs.Typed(func(ctx context.Context, p purgeFinishedPayload) error {
+		n, err := jobs.PurgeFinished(database.DB.WithContext(ctx), time.Duration(p.OlderThanHours)*time.Hour)
+		if err == nil && n > 0 {
+			log.Printf("jobs: purged %d finished jobs", n)
+		}
+		return err
+	}))
+	worker.Register(jobPurgeTelegramUpdates, jobs.Typed(func(ctx context.Context, p purgeFinishedPayload) error {
+		_, err := coreServices.PurgeTelegramUpdates(database.DB.WithContext(ctx), time.Duration(p.OlderThanHours)*time.Hour)
+		return err
+	}))
+	notificationServices.NewNotificationService(database.DB, notificationServices.DefaultChannels()...).RegisterJobs(worker)
+	webhookServices.NewWebhookService(database.DB).RegisterJobs(worker)
+	bookingServices.RegisterReminderJobs(worker, database.DB)
+	bookingServices.RegisterAppointmentStatusJobs(worker, database.DB)
+	// งานปิดนัดอัตโนมัติ publish slot event ให้ server ผ่าน NOTIFY
+	if err := bookingServices.UsePostgresSlotEvents(context.Background(), database.DB); err != nil {
+		log.Fatalf("❌ failed to start slot events: %v", err)
+	}
+	posServices.NewGatewayService(database.DB, posServices.DefaultPaymentProviders()...).RegisterJobs(worker)
+
+	if _, err := jobs.RegisterSchedule(database.DB, "purge-finished-jobs", "30 3 * * *", jobPurgeFinished,
+		purgeFinishedPayload{OlderThanHours: 7 * 24}); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := jobs.RegisterSchedule(database.DB, "purge-telegram-updates", "45 3 * * *", jobPurgeTelegramUpdates,
+		purgeFinishedPayload{OlderThanHours: 48}); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	// นัดที่พนักงานลืมอัปเดต: CONFIRMED เลยเวลา → NO_SHOW, IN_SERVICE เลยเวลาจบ → COMPLETED (grace ตาม setting ของสาขา)
+	if _, err := jobs.RegisterSchedule(database.DB, "appointment-auto-status", "* * * * *", bookingServices.JobAppointmentAutoStatus,
+		struct{}{}); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := jobs.RegisterSchedule(database.DB, "reconcile-gateway-payments", "*/5 * * * *", posServices.JobReconcileGatewayPayments,
+		struct{}{}); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("🚀 job worker %s started", worker.ID)
+	worker.Run(ctx)
+	log.Println("job worker stopped")
+}
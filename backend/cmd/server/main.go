@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 	// "github.com/joho/godotenv"
 
@@ -24,6 +26,7 @@ import (
 	aws "myapp/cmd/worker"
 
 	"myapp/database"
+	"myapp/jobs"
 
 	bookingControllers "myapp/modules/barberbooking/controllers"
 	_ "myapp/modules/barberbooking/docs" // registers as "barberbooking"
@@ -36,6 +39,22 @@ import (
 	coreModels "myapp/modules/core/models"
 	coreRoutes "myapp/modules/core/routes"
 	coreServices "myapp/modules/core/services"
+	inventoryControllers "myapp/modules/inventory/controllers"
+	inventoryModels "myapp/modules/inventory/models"
+	inventoryRoutes "myapp/modules/inventory/routes"
+	inventoryServices "myapp/modules/inventory/services"
+	notificationControllers "myapp/modules/notification/controllers"
+	notificationModels "myapp/modules/notification/models"
+	notificationRoutes "myapp/modules/notification/routes"
+	notificationServices "myapp/modules/notification/services"
+	posControllers "myapp/modules/pos/controllers"
+	posModels "myapp/modules/pos/models"
+	posRoutes "myapp/modules/pos/routes"
+	posServices "myapp/modules/pos/services"
+	webhookControllers "myapp/modules/webhook/controllers"
+	webhookModels "myapp/modules/webhook/models"
+	webhookRoutes "myapp/modules/webhook/routes"
+	webhookServices "myapp/modules/webhook/services"
 	"myapp/seeds"
 )
 
@@ -56,7 +75,10 @@ func main() {
 	}))
 	app.Use(recover.New())
 	app.Use(helmet.New())
-	app.Use(compress.New()) //บีบอัด response เพื่อลดขนาด
+	app.Use(compress.New(compress.Config{
+		// SSE ต้อง flush ทีละ event จึงห้ามบีบอัด
+		Next: func(c *fiber.Ctx) bool { return strings.HasSuffix(c.Path(), "/events") },
+	})) //บีบอัด response เพื่อลดขนาด
 
 	// Connect & migrate
 	database.ConnectDB()
@@ -74,6 +96,10 @@ func main() {
 		&coreModels.User{},
 		&coreModels.TenantUser{},
 		&coreModels.TenantModule{},
+		&coreModels.TelegramLink{},
+		&coreModels.TelegramLinkCode{},
+		&coreModels.TelegramBot{},
+		&coreModels.TelegramUpdate{},
 
 		// Booking module
 		&bookingModels.Customer{},
@@ -86,6 +112,41 @@ func main() {
 		&bookingModels.AppointmentReview{},
 		&bookingModels.BarberWorkload{},
 		&bookingModels.AppointmentLock{},
+		&bookingModels.BranchBookingSetting{},
+		&bookingModels.AppointmentItem{},
+		&bookingModels.AppointmentSeries{},
+		&bookingModels.WaitlistEntry{},
+		&bookingModels.BarberWorkingHour{},
+		&bookingModels.BarberWorkingDayOverride{},
+		&bookingModels.ReminderSetting{},
+
+		// Notification module
+		&notificationModels.NotificationChannelConfig{},
+		&notificationModels.Notification{},
+
+		// Webhook module
+		&webhookModels.WebhookEndpoint{},
+		&webhookModels.WebhookDelivery{},
+
+		// POS module
+		&posModels.Sale{},
+		&posModels.SaleItem{},
+		&posModels.Payment{},
+		&posModels.CashShift{},
+		&posModels.CashMovement{},
+		&posModels.PromptPayAccount{},
+		&posModels.PromptPayCharge{},
+		&posModels.GatewayPayment{},
+		&posModels.GatewayEvent{},
+
+		// Inventory module
+		&inventoryModels.Product{},
+		&inventoryModels.StockMovement{},
+		&inventoryModels.StockThreshold{},
+
+		// คิวงานเบื้องหลัง (ประมวลผลโดย cmd/jobworker)
+		&jobs.Job{},
+		&jobs.JobSchedule{},
 	)
 
 	// 1) Seed Tenants → เพื่อให้มี tenant ใช้ใน Role, Branch, User
@@ -191,7 +252,11 @@ func main() {
 	authSvc := coreServices.NewAuthService(database.DB, logSvc)
 	coreControllers.InitAuthHandler(authSvc, logSvc)
 
-	telegramService := coreServices.NewTelegramService()
+	// webhook ของบอทกลางปฏิเสธทุก request ถ้าไม่มี secret จึงหยุดตั้งแต่ตอนเริ่มแทนที่จะพังเงียบๆ
+	if os.Getenv("TELEGRAM_TOKEN") != "" && os.Getenv("TELEGRAM_WEBHOOK_SECRET") == "" {
+		log.Fatal("❌ TELEGRAM_WEBHOOK_SECRET is required when TELEGRAM_TOKEN is set")
+	}
+	telegramService := coreServices.NewTelegramService(database.DB)
 	telegramController := coreControllers.NewTelegramController(telegramService)
 
 	coreGroup := app.Group("/api/v1/core")
@@ -219,23 +284,46 @@ func main() {
 	workingHourService := bookingServices.NewWorkingHourService(database.DB)
 	workingHourController := bookingControllers.NewWorkingHourController(workingHourService)
 
+	barberScheduleService := bookingServices.NewBarberScheduleService(database.DB)
+	barberScheduleController := bookingControllers.NewBarberScheduleController(barberScheduleService)
+
 	workingDayOverrideService := bookingServices.NewWorkingDayOverrideService(database.DB)
 	workingDayOverrideController := bookingControllers.NewWorkingDayOverrideController(workingDayOverrideService)
 
 	barberWorkloadService := bookingServices.NewBarberWorkloadService(database.DB)
 	barberWorkloadController := bookingControllers.NewBarberWorkloadController(barberWorkloadService)
 
-	calendarService := bookingServices.NewCalendarService(database.DB, workingHourService, workingDayOverrideService)
+	calendarService := bookingServices.NewCalendarService(database.DB)
 	calendarController := bookingControllers.NewCalendarController(calendarService)
 
 	apppointmentStatusLogService := bookingServices.NewAppointmentStatusLogService(database.DB)
 	appointmentService := bookingServices.NewAppointmentService(database.DB, apppointmentStatusLogService)
 	appointmentController := bookingControllers.NewAppointmentController(appointmentService)
+	// บอท Telegram: /today, /next, /week, /cancel และปุ่มยืนยัน/ปฏิเสธนัด
+	telegramService.SetCommandHandler(bookingServices.NewTelegramBookingBot(database.DB, appointmentService))
+	appointmentSeriesService := bookingServices.NewAppointmentSeriesService(database.DB, appointmentService)
+	appointmentSeriesController := bookingControllers.NewAppointmentSeriesController(appointmentSeriesService)
+	waitlistService := bookingServices.NewWaitlistService(database.DB, appointmentService)
+	waitlistController := bookingControllers.NewWaitlistController(waitlistService)
 
 	appointmentStatusLogController := bookingControllers.NewAppointmentStatusLogController(apppointmentStatusLogService)
 
 	apppointmentLockService := bookingServices.NewAppointmentLockService(database.DB)
 	apppointmentLockController := bookingControllers.NewAppointmentLockController(apppointmentLockService)
+	// ปิด lock ที่หมดอายุเป็นระยะ ให้ตารางคงสถานะถูกต้องแม้ไม่มีใครเรียก cleanup
+	bookingServices.StartLockSweeper(context.Background(), apppointmentLockService, time.Minute)
+
+	// กระจาย slot event ผ่าน Postgres LISTEN/NOTIFY ให้ทุก instance และ event จาก jobworker
+	if err := bookingServices.UsePostgresSlotEvents(context.Background(), database.DB); err != nil {
+		log.Fatalf("❌ failed to start slot events: %v", err)
+	}
+	slotEventController := bookingControllers.NewSlotEventController(bookingServices.NewSlotEventStreamService(database.DB, bookingServices.SlotEvents))
+
+	branchBookingSettingService := bookingServices.NewBranchBookingSettingService(database.DB)
+	branchBookingSettingController := bookingControllers.NewBranchBookingSettingController(branchBookingSettingService)
+
+	reminderSettingService := bookingServices.NewReminderSettingService(database.DB)
+	reminderSettingController := bookingControllers.NewReminderSettingController(reminderSettingService)
 
 
 
@@ -243,6 +331,8 @@ func main() {
 
 	// Register routes
 	bookingRoutes.RegisterAppointmentLockRoute(bookingGroup, apppointmentLockController)
+	bookingRoutes.RegisterSlotEventRoute(bookingGroup, slotEventController)
+	bookingRoutes.RegisterAppointmentSeriesRoute(bookingGroup, appointmentSeriesController)
 	bookingRoutes.RegisterAppointmentRoute(bookingGroup, appointmentController)
 	bookingRoutes.RegisterWorkingDayOverrideRoutes(bookingGroup, workingDayOverrideController)
 	bookingRoutes.RegisterServiceRoutes(bookingGroup, serviceController)
@@ -250,12 +340,50 @@ func main() {
 	bookingRoutes.RegisterBarberRoutes(bookingGroup, barberController)
 
 	bookingRoutes.RegisterUnavailabilityRoute(bookingGroup, unavailabilityController)
+	bookingRoutes.RegisterBarberScheduleRoute(bookingGroup, barberScheduleController)
 	bookingRoutes.RegisterWorkingHourRoute(bookingGroup, *workingHourController)
 
 	bookingRoutes.RegisterBarberWorkloadRoute(bookingGroup, *barberWorkloadController)
 
 	bookingRoutes.RegisterAppointmentStatusLogRoute(bookingGroup, appointmentStatusLogController)
 	bookingRoutes.RegisterCalendarRoute(bookingGroup, calendarController)
+	bookingRoutes.RegisterBranchBookingSettingRoute(bookingGroup, branchBookingSettingController)
+	bookingRoutes.RegisterReminderSettingRoute(bookingGroup, reminderSettingController)
+	bookingRoutes.RegisterWaitlistRoute(bookingGroup, waitlistController)
+
+	// === Notification Module ===
+	notificationService := notificationServices.NewNotificationService(database.DB, notificationServices.DefaultChannels()...)
+	notificationController := notificationControllers.NewNotificationController(notificationService)
+	notificationGroup := app.Group("/api/v1/notifications")
+	notificationRoutes.RegisterNotificationRoutes(notificationGroup, notificationController)
+
+	// === Webhook Module: ส่ง event การจองไปยังระบบของ tenant ===
+	webhookService := webhookServices.NewWebhookService(database.DB)
+	webhookController := webhookControllers.NewWebhookController(webhookService)
+	webhookGroup := app.Group("/api/v1/webhooks")
+	webhookRoutes.RegisterWebhookRoutes(webhookGroup, webhookController)
+
+	// === POS Module: บิลขายหน้าร้าน (tenant ต้องเปิด module pos) ===
+	saleService := posServices.NewSaleService(database.DB)
+	saleController := posControllers.NewSaleController(saleService)
+	posGroup := app.Group("/api/v1/pos")
+	posRoutes.RegisterSaleRoutes(posGroup, database.DB, saleController)
+	shiftService := posServices.NewShiftService(database.DB)
+	shiftController := posControllers.NewShiftController(shiftService)
+	posRoutes.RegisterShiftRoutes(posGroup, database.DB, shiftController)
+	slipVerifier := posServices.NewSignedSlipVerifier(os.Getenv("PROMPTPAY_SLIP_WEBHOOK_SECRET"))
+	promptPayService := posServices.NewPromptPayService(database.DB, slipVerifier)
+	promptPayController := posControllers.NewPromptPayController(promptPayService)
+	posRoutes.RegisterPromptPayRoutes(posGroup, database.DB, promptPayController)
+	gatewayService := posServices.NewGatewayService(database.DB, posServices.DefaultPaymentProviders()...)
+	gatewayController := posControllers.NewGatewayController(gatewayService)
+	posRoutes.RegisterGatewayRoutes(posGroup, database.DB, gatewayController)
+
+	// === Inventory Module: สินค้าและสต๊อก (tenant ต้องเปิด module inventory) ===
+	inventoryService := inventoryServices.NewInventoryService(database.DB)
+	inventoryController := inventoryControllers.NewInventoryController(inventoryService)
+	inventoryGroup := app.Group("/api/v1/inventory")
+	inventoryRoutes.RegisterInventoryRoutes(inventoryGroup, database.DB, inventoryController)
 
 	for _, r := range app.GetRoutes() {
 		fmt.Printf("%-6s %s\n", r.Method, r.Path)
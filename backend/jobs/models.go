@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"time"
+)
+
+type JobStatus string
+
+const (
+	StatusPending JobStatus = "PENDING" // รอรัน (รวมที่รอ retry)
+	StatusRunning JobStatus = "RUNNING" // worker จองไปแล้ว
+	StatusDone    JobStatus = "DONE"
+	StatusDead    JobStatus = "DEAD" // ล้มเหลวครบจำนวนครั้ง หรือไม่มี handler (dead-letter)
+)
+
+// Job งานที่รอ worker ประมวลผล (ตาราง jobs)
+type Job struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Type        string    `gorm:"type:varchar(100);not null;index" json:"type"`
+	Payload     string    `gorm:"type:jsonb;not null;default:'{}'" json:"payload"`
+	Status      JobStatus `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"`
+	Attempts    int       `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int       `gorm:"not null;default:5" json:"max_attempts"`
+	RunAt       time.Time `gorm:"not null;index" json:"run_at"`
+
+	// กันงานซ้ำ เช่น รอบของ schedule หรือการแจ้งเตือนของนัดเดียวกัน
+	UniqueKey *string `gorm:"type:varchar(200);uniqueIndex" json:"unique_key,omitempty"`
+
+	LockedBy   string     `gorm:"type:varchar(100)" json:"locked_by,omitempty"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	LastError  string     `gorm:"type:text" json:"last_error,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobSchedule งานที่รันซ้ำตาม cron (นาที ชั่วโมง วัน เดือน วันในสัปดาห์) ตามเวลาท้องถิ่นของ worker
+type JobSchedule struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Name      string     `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Cron      string     `gorm:"type:varchar(100);not null" json:"cron"`
+	Type      string     `gorm:"type:varchar(100);not null" json:"type"`
+	Payload   string     `gorm:"type:jsonb;not null;default:'{}'" json:"payload"`
+	Enabled   bool       `gorm:"not null;default:true" json:"enabled"`
+	NextRunAt time.Time  `gorm:"not null;index" json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
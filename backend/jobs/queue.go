@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultMaxAttempts = 5
+
+type enqueueOptions struct {
+	runAt       time.Time
+	maxAttempts int
+	uniqueKey   string
+}
+
+type EnqueueOption func(*enqueueOptions)
+
+// RunAt กำหนดเวลาที่งานจะเริ่มรันได้ (ค่าเริ่มต้นคือทันที)
+func RunAt(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) { o.runAt = t }
+}
+
+// MaxAttempts จำนวนครั้งสูงสุดก่อนย้ายงานไปเป็น DEAD
+func MaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// UniqueKey ถ้ามีงานที่ key ซ้ำอยู่แล้ว จะไม่สร้างงานใหม่ (Enqueue คืน nil, nil)
+func UniqueKey(key string) EnqueueOption {
+	return func(o *enqueueOptions) { o.uniqueKey = key }
+}
+
+// Enqueue เพิ่มงานลงคิวผ่าน tx ที่ส่งเข้ามา
+// ถ้าส่ง transaction ของ service มา งานจะถูก commit/rollback พร้อมกับข้อมูลของ service
+func Enqueue(tx *gorm.DB, jobType string, payload any, opts ...EnqueueOption) (*Job, error) {
+	if jobType == "" {
+		return nil, fmt.Errorf("invalid job type: must not be empty")
+	}
+	o := enqueueOptions{runAt: time.Now(), maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxAttempts < 1 {
+		return nil, fmt.Errorf("invalid max attempts: must be at least 1")
+	}
+
+	raw, err := encodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := Job{
+		Type:        jobType,
+		Payload:     raw,
+		Status:      StatusPending,
+		MaxAttempts: o.maxAttempts,
+		RunAt:       o.runAt,
+	}
+	if o.uniqueKey != "" {
+		job.UniqueKey = &o.uniqueKey
+	}
+
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&job)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to enqueue job %s: %w", jobType, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+func encodePayload(payload any) (string, error) {
+	switch p := payload.(type) {
+	case nil:
+		return "{}", nil
+	case json.RawMessage:
+		return string(p), nil
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("invalid job payload: %w", err)
+	}
+	return string(b), nil
+}
+
+// PurgeFinished ลบงาน DONE ที่จบไปนานกว่า olderThan (งาน DEAD เก็บไว้ให้ตรวจสอบ)
+func PurgeFinished(tx *gorm.DB, olderThan time.Duration) (int64, error) {
+	res := tx.Where("status = ? AND finished_at < ?", StatusDone, time.Now().Add(-olderThan)).Delete(&Job{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to purge finished jobs: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// likeEscaper ให้ % และ _ ใน prefix เป็นตัวอักษรธรรมดา (ใช้คู่กับ ESCAPE '\')
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// CancelPending ลบงานที่ยังไม่เริ่มรันซึ่ง UniqueKey ขึ้นต้นด้วย prefix (เช่น reminder ของนัดที่ถูกยกเลิก)
+// งานที่กำลังรันอยู่ไม่ถูกแตะ handler ต้องตรวจสถานะข้อมูลต้นทางเองอีกครั้ง
+func CancelPending(tx *gorm.DB, uniqueKeyPrefix string) (int64, error) {
+	if uniqueKeyPrefix == "" {
+		return 0, fmt.Errorf("invalid unique key prefix: must not be empty")
+	}
+	res := tx.Where(`status = ? AND unique_key LIKE ? ESCAPE '\'`, StatusPending, likeEscaper.Replace(uniqueKeyPrefix)+"%").
+		Delete(&Job{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to cancel pending jobs: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RegisterSchedule สร้างหรืออัปเดต schedule ตามชื่อ (เรียกตอน worker เริ่มทำงานได้ทุกครั้ง)
+// ถ้า cron เปลี่ยน จะคำนวณเวลารอบถัดไปใหม่ ส่วนสถานะ enabled คงตามที่ตั้งไว้ในฐานข้อมูล
+func RegisterSchedule(db *gorm.DB, name, cron, jobType string, payload any) (*JobSchedule, error) {
+	sched, err := ParseSchedule(cron)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := encodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var out JobSchedule
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", name).
+			First(&out).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			out = JobSchedule{
+				Name:      name,
+				Cron:      cron,
+				Type:      jobType,
+				Payload:   raw,
+				Enabled:   true,
+				NextRunAt: sched.Next(time.Now()),
+			}
+			return tx.Create(&out).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if out.Cron != cron {
+			out.NextRunAt = sched.Next(time.Now())
+		}
+		out.Cron = cron
+		out.Type = jobType
+		out.Payload = raw
+		return tx.Save(&out).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schedule %s: %w", name, err)
+	}
+	return &out, nil
+}
+
+// EnqueueDueSchedules สร้างงานของ schedule ที่ถึงเวลา รอบที่พลาดไประหว่าง worker หยุดจะรวมเป็นงานเดียว
+// UniqueKey ของแต่ละรอบกันไม่ให้หลาย worker สร้างงานซ้ำ
+func (w *Worker) EnqueueDueSchedules(ctx context.Context) (int, error) {
+	now := w.Now()
+	enqueued := 0
+	err := w.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var due []JobSchedule
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("enabled = ? AND next_run_at <= ?", true, now).
+			Find(&due).Error; err != nil {
+			return fmt.Errorf("failed to fetch due schedules: %w", err)
+		}
+
+		for _, s := range due {
+			sched, err := ParseSchedule(s.Cron)
+			if err != nil {
+				log.Printf("⚠️ jobs: schedule %s disabled: %v", s.Name, err)
+				if err := tx.Model(&s).Update("enabled", false).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			key := fmt.Sprintf("schedule:%s:%d", s.Name, s.NextRunAt.Unix())
+			job, err := Enqueue(tx, s.Type, json.RawMessage(s.Payload), UniqueKey(key))
+			if err != nil {
+				return err
+			}
+			if job != nil {
+				enqueued++
+			}
+
+			if err := tx.Model(&s).Updates(map[string]interface{}{
+				"last_run_at": now,
+				"next_run_at": sched.Next(now),
+			}).Error; err != nil {
+				return fmt.Errorf("failed to advance schedule %s: %w", s.Name, err)
+			}
+		}
+		return nil
+	})
+	return enqueued, err
+}
@@ -0,0 +1,270 @@
+package jobsTest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"myapp/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupJobsDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// :memory: แยกฐานข้อมูลต่อ connection
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&jobs.Job{}, &jobs.JobSchedule{}))
+	return db
+}
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestWorker(db *gorm.DB, c *clock) *jobs.Worker {
+	w := jobs.NewWorker(db, "test-worker")
+	w.Now = c.Now
+	w.Backoff = func(attempt int) time.Duration { return time.Duration(attempt) * time.Minute }
+	return w
+}
+
+func reload(t *testing.T, db *gorm.DB, id uint) jobs.Job {
+	var j jobs.Job
+	require.NoError(t, db.First(&j, id).Error)
+	return j
+}
+
+type greetPayload struct {
+	Name string `json:"name"`
+}
+
+func TestJobs_EnqueueAndRun(t *testing.T) {
+	db := setupJobsDB(t)
+	c := &clock{now: time.Now().Add(time.Second)}
+	w := newTestWorker(db, c)
+	ctx := context.Background()
+
+	var got []string
+	w.Register("greet", jobs.Typed(func(_ context.Context, p greetPayload) error {
+		got = append(got, p.Name)
+		return nil
+	}))
+
+	t.Run("Enqueue in rolled back transaction is discarded", func(t *testing.T) {
+		_ = db.Transaction(func(tx *gorm.DB) error {
+			_, err := jobs.Enqueue(tx, "greet", greetPayload{Name: "rolled back"})
+			require.NoError(t, err)
+			return errors.New("rollback")
+		})
+		var count int64
+		db.Model(&jobs.Job{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Runs due jobs and marks them done", func(t *testing.T) {
+		job, err := jobs.Enqueue(db, "greet", greetPayload{Name: "somchai"})
+		require.NoError(t, err)
+		later, err := jobs.Enqueue(db, "greet", greetPayload{Name: "later"}, jobs.RunAt(c.now.Add(time.Hour)))
+		require.NoError(t, err)
+
+		n, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"somchai"}, got)
+
+		done := reload(t, db, job.ID)
+		assert.Equal(t, jobs.StatusDone, done.Status)
+		assert.Equal(t, 1, done.Attempts)
+		assert.NotNil(t, done.FinishedAt)
+		assert.Equal(t, jobs.StatusPending, reload(t, db, later.ID).Status)
+	})
+
+	t.Run("Unique key skips duplicates", func(t *testing.T) {
+		first, err := jobs.Enqueue(db, "greet", greetPayload{Name: "a"}, jobs.UniqueKey("greet:a"))
+		require.NoError(t, err)
+		require.NotNil(t, first)
+		dup, err := jobs.Enqueue(db, "greet", greetPayload{Name: "a"}, jobs.UniqueKey("greet:a"))
+		require.NoError(t, err)
+		assert.Nil(t, dup)
+	})
+
+	t.Run("CancelPending matches the prefix literally", func(t *testing.T) {
+		exact, err := jobs.Enqueue(db, "greet", nil, jobs.UniqueKey("cancel:1_%:x"))
+		require.NoError(t, err)
+		wildcard, err := jobs.Enqueue(db, "greet", nil, jobs.UniqueKey("cancel:12345:x"))
+		require.NoError(t, err)
+
+		n, err := jobs.CancelPending(db, "cancel:1_%:")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		var left int64
+		require.NoError(t, db.Model(&jobs.Job{}).Where("id IN ?", []uint{exact.ID, wildcard.ID}).Count(&left).Error)
+		assert.EqualValues(t, 1, left)
+		assert.Equal(t, jobs.StatusPending, reload(t, db, wildcard.ID).Status)
+	})
+}
+
+func TestJobs_RetryAndDeadLetter(t *testing.T) {
+	db := setupJobsDB(t)
+	c := &clock{now: time.Now().Add(time.Second)}
+	w := newTestWorker(db, c)
+	ctx := context.Background()
+
+	calls := 0
+	w.Register("flaky", func(context.Context, *jobs.Job) error {
+		calls++
+		return errors.New("upstream unavailable")
+	})
+	w.Register("broken", func(context.Context, *jobs.Job) error {
+		panic("boom")
+	})
+
+	t.Run("Failed job is retried with backoff then dead-lettered", func(t *testing.T) {
+		job, err := jobs.Enqueue(db, "flaky", nil, jobs.MaxAttempts(2))
+		require.NoError(t, err)
+
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+		j := reload(t, db, job.ID)
+		assert.Equal(t, jobs.StatusPending, j.Status)
+		assert.Equal(t, 1, j.Attempts)
+		assert.Equal(t, "upstream unavailable", j.LastError)
+		assert.WithinDuration(t, c.now.Add(time.Minute), j.RunAt, time.Second)
+
+		// ยังไม่ถึงเวลา retry
+		n, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		c.now = c.now.Add(2 * time.Minute)
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+		j = reload(t, db, job.ID)
+		assert.Equal(t, jobs.StatusDead, j.Status)
+		assert.Equal(t, 2, j.Attempts)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Unknown type and bad payload go straight to dead", func(t *testing.T) {
+		unknown, err := jobs.Enqueue(db, "nobody-handles-this", nil)
+		require.NoError(t, err)
+		w.Register("typed", jobs.Typed(func(context.Context, greetPayload) error { return nil }))
+		bad, err := jobs.Enqueue(db, "typed", []int{1, 2})
+		require.NoError(t, err)
+
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, jobs.StatusDead, reload(t, db, unknown.ID).Status)
+		assert.Contains(t, reload(t, db, unknown.ID).LastError, "no handler registered")
+		assert.Equal(t, jobs.StatusDead, reload(t, db, bad.ID).Status)
+	})
+
+	t.Run("Panicking handler is retried", func(t *testing.T) {
+		job, err := jobs.Enqueue(db, "broken", nil)
+		require.NoError(t, err)
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+		j := reload(t, db, job.ID)
+		assert.Equal(t, jobs.StatusPending, j.Status)
+		assert.Contains(t, j.LastError, "panic: boom")
+	})
+
+	t.Run("Job with expired lease is requeued", func(t *testing.T) {
+		job, err := jobs.Enqueue(db, "flaky", nil, jobs.RunAt(c.now.Add(time.Hour)))
+		require.NoError(t, err)
+		stale := c.now.Add(-time.Hour)
+		require.NoError(t, db.Model(&jobs.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status": jobs.StatusRunning, "locked_by": "crashed-worker", "locked_at": stale, "attempts": 1,
+		}).Error)
+
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+		j := reload(t, db, job.ID)
+		// ถูกคืนเข้าคิวแล้วรันใหม่ในรอบเดียวกัน (ล้มเหลวอีกครั้ง จึงรอ backoff)
+		assert.Equal(t, jobs.StatusPending, j.Status)
+		assert.Equal(t, 2, j.Attempts)
+		assert.Empty(t, j.LockedBy)
+	})
+}
+
+func TestJobs_Schedules(t *testing.T) {
+	db := setupJobsDB(t)
+	loc := time.UTC
+	c := &clock{now: time.Date(2025, 1, 6, 8, 59, 30, 0, loc)}
+	w := newTestWorker(db, c)
+	ctx := context.Background()
+
+	s, err := jobs.RegisterSchedule(db, "hourly-report", "0 * * * *", "report", greetPayload{Name: "report"})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(s).Update("next_run_at", time.Date(2025, 1, 6, 9, 0, 0, 0, loc)).Error)
+
+	n, err := w.EnqueueDueSchedules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// worker หยุดไป 3 ชั่วโมง: รอบที่พลาดรวมเป็นงานเดียว
+	c.now = time.Date(2025, 1, 6, 12, 10, 0, 0, loc)
+	n, err = w.EnqueueDueSchedules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var saved jobs.JobSchedule
+	require.NoError(t, db.First(&saved, s.ID).Error)
+	assert.True(t, saved.NextRunAt.Equal(time.Date(2025, 1, 6, 13, 0, 0, 0, loc)))
+
+	var queued []jobs.Job
+	require.NoError(t, db.Where("type = ?", "report").Find(&queued).Error)
+	require.Len(t, queued, 1)
+	assert.JSONEq(t, `{"name":"report"}`, queued[0].Payload)
+
+	// register ซ้ำด้วย cron เดิมไม่เลื่อนรอบถัดไป
+	again, err := jobs.RegisterSchedule(db, "hourly-report", "0 * * * *", "report", nil)
+	require.NoError(t, err)
+	assert.True(t, again.NextRunAt.Equal(saved.NextRunAt))
+
+	_, err = jobs.RegisterSchedule(db, "bad", "61 * * * *", "report", nil)
+	assert.Error(t, err)
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	at := func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, time.UTC) }
+
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", at(2025, 1, 1, 10, 0).Add(30 * time.Second), at(2025, 1, 1, 10, 1)},
+		{"*/15 * * * *", at(2025, 1, 1, 10, 1), at(2025, 1, 1, 10, 15)},
+		{"30 9 * * *", at(2025, 1, 1, 10, 0), at(2025, 1, 2, 9, 30)},
+		{"0 8-18/2 * * *", at(2025, 1, 1, 9, 0), at(2025, 1, 1, 10, 0)},
+		{"0 9 * * 1-5", at(2025, 1, 3, 10, 0), at(2025, 1, 6, 9, 0)}, // ศุกร์ -> จันทร์
+		{"0 0 1 * *", at(2025, 1, 15, 0, 0), at(2025, 2, 1, 0, 0)},
+		{"0 0 29 2 *", at(2025, 1, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"0 12 * * 7", at(2025, 1, 1, 0, 0), at(2025, 1, 5, 12, 0)}, // 7 = อาทิตย์
+		{"0 0 13 * 5", at(2025, 1, 1, 0, 0), at(2025, 1, 3, 0, 0)},  // วันที่ 13 หรือวันศุกร์
+		{"0,30 6 * 3 *", at(2025, 1, 1, 0, 0), at(2025, 3, 1, 6, 0)},
+	}
+	for _, tc := range cases {
+		s, err := jobs.ParseSchedule(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.want, s.Next(tc.from), tc.expr)
+	}
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := jobs.ParseSchedule(bad)
+		assert.Error(t, err, bad)
+	}
+
+	s, err := jobs.ParseSchedule("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(at(2025, 1, 1, 0, 0)).IsZero())
+}
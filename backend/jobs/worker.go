@@ -0,0 +1,277 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Handler ประมวลผลงานหนึ่งชิ้น คืน error เพื่อให้ retry (หรือ Permanent(err) เพื่อย้ายไป DEAD ทันที)
+type Handler func(ctx context.Context, job *Job) error
+
+// Typed แปลง payload JSON เป็น T ก่อนเรียก fn
+// payload ที่ decode ไม่ได้ถือเป็น error ถาวร ไม่ต้อง retry
+func Typed[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload for %s: %w", job.Type, err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent ทำเครื่องหมายว่า error นี้ retry ไปก็ไม่สำเร็จ
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// DefaultBackoff 30s, 1m, 2m, ... สูงสุด 1 ชั่วโมง
+func DefaultBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := 30 * time.Second
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
+
+type Worker struct {
+	DB *gorm.DB
+	ID string
+
+	PollInterval time.Duration
+	BatchSize    int
+	Concurrency  int
+	// งานที่ RUNNING นานกว่านี้ถือว่า worker ตายกลางทาง และจะถูกนำกลับเข้าคิว
+	LeaseTimeout time.Duration
+	Backoff      func(attempt int) time.Duration
+	Now          func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewWorker(db *gorm.DB, id string) *Worker {
+	return &Worker{
+		DB:           db,
+		ID:           id,
+		PollInterval: time.Second,
+		BatchSize:    10,
+		Concurrency:  4,
+		LeaseTimeout: 10 * time.Minute,
+		Backoff:      DefaultBackoff,
+		Now:          time.Now,
+		handlers:     map[string]Handler{},
+	}
+}
+
+// Register ผูก handler กับประเภทงาน (เรียกซ้ำจะแทนที่ของเดิม)
+func (w *Worker) Register(jobType string, h Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = h
+}
+
+func (w *Worker) handler(jobType string) (Handler, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	h, ok := w.handlers[jobType]
+	return h, ok
+}
+
+// Run วนดึงงานจนกว่า ctx จะถูกยกเลิก งานที่กำลังรันอยู่จะทำจนจบก่อนคืนค่า
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.EnqueueDueSchedules(ctx); err != nil {
+			log.Printf("⚠️ jobs: enqueue schedules failed: %v", err)
+		}
+		for ctx.Err() == nil {
+			n, err := w.RunOnce(ctx)
+			if err != nil {
+				log.Printf("⚠️ jobs: run failed: %v", err)
+				break
+			}
+			// batch ไม่เต็ม แปลว่าคิวว่างแล้ว รอรอบถัดไป
+			if n < w.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce จองงานที่ถึงเวลาหนึ่ง batch แล้วประมวลผลจนเสร็จ คืนจำนวนงานที่จองได้
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	if err := w.requeueStale(ctx); err != nil {
+		return 0, err
+	}
+
+	claimed, err := w.claim(ctx)
+	if err != nil || len(claimed) == 0 {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, max(w.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i := range claimed {
+		job := &claimed[i]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.finish(job, w.execute(ctx, job))
+		}()
+	}
+	wg.Wait()
+	return len(claimed), nil
+}
+
+// claim ใช้ FOR UPDATE SKIP LOCKED ให้หลาย worker ดึงงานพร้อมกันได้โดยไม่ได้งานซ้ำ
+func (w *Worker) claim(ctx context.Context) ([]Job, error) {
+	now := w.Now()
+	var claimed []Job
+	err := w.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", StatusPending, now).
+			Order("run_at, id").
+			Limit(w.BatchSize).
+			Find(&claimed).Error; err != nil {
+			return fmt.Errorf("failed to fetch pending jobs: %w", err)
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(claimed))
+		for _, j := range claimed {
+			ids = append(ids, j.ID)
+		}
+		if err := tx.Model(&Job{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":    StatusRunning,
+				"locked_by": w.ID,
+				"locked_at": now,
+				"attempts":  gorm.Expr("attempts + 1"),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to claim jobs: %w", err)
+		}
+		for i := range claimed {
+			claimed[i].Status = StatusRunning
+			claimed[i].LockedBy = w.ID
+			claimed[i].LockedAt = &now
+			claimed[i].Attempts++
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func (w *Worker) execute(ctx context.Context, job *Job) (err error) {
+	h, ok := w.handler(job.Type)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for job type %s", job.Type))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h(ctx, job)
+}
+
+// finish บันทึกผล: สำเร็จ -> DONE, ล้มเหลว -> PENDING พร้อม backoff หรือ DEAD เมื่อครบจำนวนครั้ง
+// อัปเดตเฉพาะงานที่ยังเป็นของ worker นี้ (เผื่อถูก requeue ไปแล้วเพราะ lease หมด)
+func (w *Worker) finish(job *Job, runErr error) {
+	now := w.Now()
+	updates := map[string]interface{}{
+		"locked_by": "",
+		"locked_at": nil,
+	}
+
+	var perm permanentError
+	switch {
+	case runErr == nil:
+		updates["status"] = StatusDone
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case errors.As(runErr, &perm) || job.Attempts >= job.MaxAttempts:
+		updates["status"] = StatusDead
+		updates["finished_at"] = now
+		updates["last_error"] = runErr.Error()
+		log.Printf("❌ jobs: %s #%d dead after %d attempt(s): %v", job.Type, job.ID, job.Attempts, runErr)
+	default:
+		updates["status"] = StatusPending
+		updates["run_at"] = now.Add(w.Backoff(job.Attempts))
+		updates["last_error"] = runErr.Error()
+	}
+
+	// ไม่ใช้ ctx ของ worker เพื่อให้บันทึกผลได้แม้ถูกสั่งหยุดระหว่างรัน
+	if err := w.DB.Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, w.ID).
+		Updates(updates).Error; err != nil {
+		log.Printf("⚠️ jobs: failed to record result of %s #%d: %v", job.Type, job.ID, err)
+	}
+}
+
+// requeueStale คืนงานที่ lease หมดกลับเข้าคิว (หรือ DEAD ถ้าครบจำนวนครั้งแล้ว)
+func (w *Worker) requeueStale(ctx context.Context) error {
+	now := w.Now()
+	cutoff := now.Add(-w.LeaseTimeout)
+	db := w.DB.WithContext(ctx).Model(&Job{}).Where("status = ? AND locked_at < ?", StatusRunning, cutoff)
+
+	if err := db.Session(&gorm.Session{}).
+		Where("attempts >= max_attempts").
+		Updates(map[string]interface{}{
+			"status":      StatusDead,
+			"locked_by":   "",
+			"locked_at":   nil,
+			"finished_at": now,
+			"last_error":  "lease expired",
+		}).Error; err != nil {
+		return fmt.Errorf("failed to dead-letter stale jobs: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).
+		Updates(map[string]interface{}{
+			"status":     StatusPending,
+			"locked_by":  "",
+			"locked_at":  nil,
+			"run_at":     now,
+			"last_error": "lease expired",
+		}).Error; err != nil {
+		return fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS branch_booking_settings (
+  id                       SERIAL PRIMARY KEY,
+  tenant_id                INT NOT NULL,
+  branch_id                INT NOT NULL,                 -- ไม่มี FK เพราะอยู่อีก module
+
+  assignment_strategy      VARCHAR(20) NOT NULL DEFAULT 'LEAST_LOADED',
+  last_assigned_barber_id  INT,                          -- ใช้กับ ROUND_ROBIN
+
+  created_at               TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at               TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_branch_booking_settings_branch UNIQUE (branch_id),
+  CONSTRAINT chk_branch_booking_settings_strategy
+    CHECK (assignment_strategy IN ('LEAST_LOADED', 'ROUND_ROBIN', 'HIGHEST_RATED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_branch_booking_settings_tenant ON branch_booking_settings(tenant_id);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS appointment_items (
+  id              SERIAL PRIMARY KEY,
+  appointment_id  INT NOT NULL,
+  service_id      INT NOT NULL,
+
+  position        INT NOT NULL DEFAULT 0,      -- ลำดับการให้บริการ
+  duration        INT NOT NULL,                -- นาที (snapshot ตอนจอง)
+  price           NUMERIC(10,2) NOT NULL,      -- ราคา (snapshot ตอนจอง)
+
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT fk_appointment_items_appointment
+    FOREIGN KEY (appointment_id)
+    REFERENCES appointments(id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_appointment_items_service
+    FOREIGN KEY (service_id)
+    REFERENCES services(id)
+    ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_items_appointment ON appointment_items(appointment_id, position);
+CREATE INDEX IF NOT EXISTS idx_appointment_items_service     ON appointment_items(service_id);
+
+-- ย้ายนัดหมายเดิม (service เดียว) ให้มี line item 1 รายการ
+INSERT INTO appointment_items (appointment_id, service_id, position, duration, price)
+SELECT a.id, a.service_id, 0, s.duration, s.price
+FROM appointments a
+JOIN services s ON s.id = a.service_id
+WHERE NOT EXISTS (SELECT 1 FROM appointment_items i WHERE i.appointment_id = a.id);
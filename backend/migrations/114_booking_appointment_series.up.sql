@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS appointment_series (
+  id           SERIAL PRIMARY KEY,
+  tenant_id    INT NOT NULL,
+  branch_id    INT NOT NULL,
+  customer_id  INT NOT NULL,
+  barber_id    INT,
+  service_id   INT NOT NULL,
+
+  frequency    VARCHAR(10) NOT NULL,           -- WEEKLY | MONTHLY
+  repeat_interval INT NOT NULL DEFAULT 1,        -- ทุก N สัปดาห์/เดือน
+  count        INT,                            -- จำนวนครั้ง (อย่างใดอย่างหนึ่งกับ until)
+  until        TIMESTAMPTZ,
+  start_time   TIMESTAMPTZ NOT NULL,
+  notes        TEXT,
+
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  deleted_at   TIMESTAMPTZ,
+
+  CONSTRAINT chk_appointment_series_frequency CHECK (frequency IN ('WEEKLY', 'MONTHLY')),
+  CONSTRAINT chk_appointment_series_interval  CHECK (repeat_interval > 0),
+  CONSTRAINT chk_appointment_series_end       CHECK (count IS NOT NULL OR until IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_series_tenant   ON appointment_series(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_appointment_series_customer ON appointment_series(customer_id);
+
+ALTER TABLE appointments
+  ADD COLUMN IF NOT EXISTS series_id INT REFERENCES appointment_series(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_appointments_series ON appointments(series_id, start_time);
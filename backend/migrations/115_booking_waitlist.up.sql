@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+  id              SERIAL PRIMARY KEY,
+  tenant_id       INT NOT NULL,
+  branch_id       INT NOT NULL,
+  customer_id     INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+  barber_id       INT REFERENCES barbers(id) ON DELETE SET NULL,   -- NULL = ช่างคนไหนก็ได้
+  service_id      INT NOT NULL REFERENCES services(id) ON DELETE RESTRICT,
+
+  desired_start   TIMESTAMPTZ NOT NULL,
+  desired_end     TIMESTAMPTZ NOT NULL,
+
+  status          VARCHAR(20) NOT NULL DEFAULT 'WAITING',
+  lock_id         INT REFERENCES appointment_locks(id) ON DELETE SET NULL,
+  offered_at      TIMESTAMPTZ,
+  appointment_id  INT REFERENCES appointments(id) ON DELETE SET NULL,
+
+  notes           TEXT,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  deleted_at      TIMESTAMPTZ,
+
+  CONSTRAINT chk_waitlist_entries_window CHECK (desired_end > desired_start),
+  CONSTRAINT chk_waitlist_entries_status
+    CHECK (status IN ('WAITING', 'OFFERED', 'PROMOTED', 'CANCELLED'))
+);
+
+-- ใช้จับคู่แบบ FIFO ต่อสาขา
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_match
+  ON waitlist_entries(branch_id, status, created_at)
+  WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_customer ON waitlist_entries(customer_id);
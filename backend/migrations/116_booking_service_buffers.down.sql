@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_appointment_locks_barber_block;
+ALTER TABLE appointment_locks
+  DROP COLUMN IF EXISTS block_start,
+  DROP COLUMN IF EXISTS block_end;
+
+DROP INDEX IF EXISTS idx_appointments_barber_block;
+ALTER TABLE appointments
+  DROP COLUMN IF EXISTS block_start,
+  DROP COLUMN IF EXISTS block_end;
+
+ALTER TABLE services DROP CONSTRAINT IF EXISTS chk_services_buffers;
+ALTER TABLE services
+  DROP COLUMN IF EXISTS buffer_before,
+  DROP COLUMN IF EXISTS buffer_after;
@@ -0,0 +1,25 @@
+ALTER TABLE services
+  ADD COLUMN IF NOT EXISTS buffer_before INT NOT NULL DEFAULT 0,   -- นาทีเตรียมตัวก่อนเริ่ม
+  ADD COLUMN IF NOT EXISTS buffer_after  INT NOT NULL DEFAULT 0;   -- นาทีทำความสะอาดหลังจบ
+
+ALTER TABLE services
+  ADD CONSTRAINT chk_services_buffers CHECK (buffer_before >= 0 AND buffer_after >= 0);
+
+-- ช่วงที่ช่างไม่ว่างจริง (เวลานัด + buffer) ใช้ตรวจ overlap
+ALTER TABLE appointments
+  ADD COLUMN IF NOT EXISTS block_start TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS block_end   TIMESTAMPTZ;
+UPDATE appointments SET block_start = start_time, block_end = end_time WHERE block_start IS NULL;
+ALTER TABLE appointments
+  ALTER COLUMN block_start SET NOT NULL,
+  ALTER COLUMN block_end   SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_appointments_barber_block ON appointments(barber_id, block_start, block_end);
+
+ALTER TABLE appointment_locks
+  ADD COLUMN IF NOT EXISTS block_start TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS block_end   TIMESTAMPTZ;
+UPDATE appointment_locks SET block_start = start_time, block_end = end_time WHERE block_start IS NULL;
+ALTER TABLE appointment_locks
+  ALTER COLUMN block_start SET NOT NULL,
+  ALTER COLUMN block_end   SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_appointment_locks_barber_block ON appointment_locks(barber_id, block_start, block_end);
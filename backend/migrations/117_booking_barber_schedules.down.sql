@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS barber_working_day_overrides;
+DROP TABLE IF EXISTS barber_working_hours;
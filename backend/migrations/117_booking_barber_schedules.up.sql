@@ -0,0 +1,38 @@
+-- ตารางงานรายสัปดาห์ของช่าง (ไม่มีแถว = ทำงานตามเวลาทำการของสาขา)
+CREATE TABLE IF NOT EXISTS barber_working_hours (
+  id          SERIAL PRIMARY KEY,
+  tenant_id   INT NOT NULL,
+  barber_id   INT NOT NULL REFERENCES barbers(id) ON DELETE CASCADE,
+  weekday     INT NOT NULL,
+  start_time  TIME NOT NULL,
+  end_time    TIME NOT NULL,
+  is_off      BOOLEAN NOT NULL DEFAULT FALSE,
+
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_barber_working_hours_weekday CHECK (weekday BETWEEN 0 AND 6),
+  CONSTRAINT chk_barber_working_hours_range CHECK (is_off OR start_time < end_time)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bwh_barber_weekday ON barber_working_hours(barber_id, weekday);
+CREATE INDEX IF NOT EXISTS idx_barber_working_hours_tenant ON barber_working_hours(tenant_id);
+
+-- เวลางานของช่างเฉพาะวัน มีผลเหนือตารางรายสัปดาห์
+CREATE TABLE IF NOT EXISTS barber_working_day_overrides (
+  id          SERIAL PRIMARY KEY,
+  tenant_id   INT NOT NULL,
+  barber_id   INT NOT NULL REFERENCES barbers(id) ON DELETE CASCADE,
+  work_date   DATE NOT NULL,
+  start_time  TIME NOT NULL,
+  end_time    TIME NOT NULL,
+  is_off      BOOLEAN NOT NULL DEFAULT FALSE,
+
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_barber_working_day_overrides_range CHECK (is_off OR start_time < end_time)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bwdo_barber_date ON barber_working_day_overrides(barber_id, work_date);
+CREATE INDEX IF NOT EXISTS idx_barber_working_day_overrides_tenant ON barber_working_day_overrides(tenant_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_unavailabilities_repeat;
+ALTER TABLE unavailabilities DROP CONSTRAINT IF EXISTS chk_unavailabilities_time_range;
+ALTER TABLE unavailabilities
+  DROP COLUMN IF EXISTS start_time,
+  DROP COLUMN IF EXISTS end_time,
+  DROP COLUMN IF EXISTS repeat_weekdays,
+  DROP COLUMN IF EXISTS repeat_until;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_unavailability ON unavailabilities (date, barber_id, branch_id);
@@ -0,0 +1,16 @@
+-- ปิดเป็นช่วงเวลา (พัก/ครึ่งวัน) และทำซ้ำรายสัปดาห์
+ALTER TABLE unavailabilities
+  ADD COLUMN IF NOT EXISTS start_time      TIME,
+  ADD COLUMN IF NOT EXISTS end_time        TIME,
+  ADD COLUMN IF NOT EXISTS repeat_weekdays VARCHAR(20),   -- เช่น '1,2,3,4,5'
+  ADD COLUMN IF NOT EXISTS repeat_until    DATE;
+
+ALTER TABLE unavailabilities
+  ADD CONSTRAINT chk_unavailabilities_time_range CHECK (
+    (start_time IS NULL AND end_time IS NULL) OR
+    (start_time IS NOT NULL AND end_time IS NOT NULL AND start_time < end_time)
+  );
+
+-- หลายช่วงในวันเดียวกันได้แล้ว (เช่น พักเช้า + พักบ่าย)
+DROP INDEX IF EXISTS uq_unavailability;
+CREATE INDEX IF NOT EXISTS idx_unavailabilities_repeat ON unavailabilities(date, repeat_until) WHERE COALESCE(repeat_weekdays, '') <> '';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_appointment_locks_appointment_id;
+DROP INDEX IF EXISTS uq_appointments_lock_id;
+
+ALTER TABLE appointment_locks DROP COLUMN IF EXISTS appointment_id;
+ALTER TABLE appointments DROP COLUMN IF EXISTS lock_id;
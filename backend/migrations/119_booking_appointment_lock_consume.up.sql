@@ -0,0 +1,10 @@
+-- ผูกนัดกับ lock ที่ถูกใช้จอง (lock ถูกปิดใน transaction เดียวกับการสร้างนัด)
+ALTER TABLE appointments
+  ADD COLUMN IF NOT EXISTS lock_id INT;
+
+ALTER TABLE appointment_locks
+  ADD COLUMN IF NOT EXISTS appointment_id INT;
+
+-- lock หนึ่งใช้จองได้ครั้งเดียว
+CREATE UNIQUE INDEX IF NOT EXISTS uq_appointments_lock_id ON appointments(lock_id) WHERE lock_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_appointment_locks_appointment_id ON appointment_locks(appointment_id);
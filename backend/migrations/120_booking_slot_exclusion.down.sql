@@ -0,0 +1,2 @@
+ALTER TABLE appointment_locks DROP CONSTRAINT IF EXISTS excl_appointment_locks_barber_block;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_barber_block;
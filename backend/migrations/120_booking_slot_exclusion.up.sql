@@ -0,0 +1,45 @@
+-- กันจองช่วงเวลาซ้อนในระดับฐานข้อมูล: ช่างคนเดียวกันห้ามมีช่วง block ทับกัน
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- ข้อมูลเดิมที่ซ้อนกันอยู่แล้วจะทำให้สร้าง constraint ไม่ได้: หยุด migration พร้อมรายการคู่นัดที่ทับกัน
+-- ให้เลื่อน/ยกเลิกนัดเหล่านั้นด้วยมือ (ไม่ยกเลิกนัดของลูกค้าอัตโนมัติ) แล้วรัน migration ใหม่
+DO $$
+DECLARE
+  conflicts text;
+BEGIN
+  SELECT string_agg(format('%s/%s', a.id, b.id), ', ') INTO conflicts
+  FROM appointments a
+  JOIN appointments b
+    ON b.barber_id = a.barber_id AND b.id > a.id
+   AND b.block_start < a.block_end AND b.block_end > a.block_start
+  WHERE a.status IN ('PENDING', 'CONFIRMED', 'IN_SERVICE') AND a.deleted_at IS NULL
+    AND b.status IN ('PENDING', 'CONFIRMED', 'IN_SERVICE') AND b.deleted_at IS NULL;
+  IF conflicts IS NOT NULL THEN
+    RAISE EXCEPTION 'overlapping appointments (id pairs: %) must be rescheduled or cancelled before adding excl_appointments_barber_block', conflicts;
+  END IF;
+END;
+$$;
+
+-- นัดที่ยังกันเวลาช่างอยู่ (ดู blocksSlot)
+ALTER TABLE appointments
+  ADD CONSTRAINT excl_appointments_barber_block
+  EXCLUDE USING gist (
+    barber_id WITH =,
+    tstzrange(block_start, block_end, '[)') WITH &&
+  ) WHERE (status IN ('PENDING', 'CONFIRMED', 'IN_SERVICE') AND deleted_at IS NULL);
+
+-- lock ที่ยัง active (lock หมดอายุจะถูกปิดก่อนสร้าง lock ใหม่ทับ และโดย sweeper)
+UPDATE appointment_locks SET is_active = FALSE WHERE is_active AND expires_at <= now();
+-- lock ที่ยัง active แต่ทับกันอยู่แล้ว: คง lock ที่สร้างก่อนไว้ ปิดตัวที่มาทีหลัง (lock อายุสั้น ลูกค้าจองใหม่ได้)
+UPDATE appointment_locks l SET is_active = FALSE
+WHERE l.is_active AND EXISTS (
+  SELECT 1 FROM appointment_locks o
+  WHERE o.is_active AND o.barber_id = l.barber_id AND o.id < l.id
+    AND o.block_start < l.block_end AND o.block_end > l.block_start
+);
+ALTER TABLE appointment_locks
+  ADD CONSTRAINT excl_appointment_locks_barber_block
+  EXCLUDE USING gist (
+    barber_id WITH =,
+    tstzrange(block_start, block_end, '[)') WITH &&
+  ) WHERE (is_active);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_appointments_status_end;
+DROP INDEX IF EXISTS idx_appointments_status_start;
+
+ALTER TABLE appointment_status_logs DROP COLUMN IF EXISTS changed_by_system;
+
+ALTER TABLE branch_booking_settings DROP CONSTRAINT IF EXISTS chk_branch_booking_settings_grace;
+ALTER TABLE branch_booking_settings
+  DROP COLUMN IF EXISTS auto_no_show_enabled,
+  DROP COLUMN IF EXISTS no_show_grace_minutes,
+  DROP COLUMN IF EXISTS auto_complete_enabled,
+  DROP COLUMN IF EXISTS auto_complete_grace_minutes;
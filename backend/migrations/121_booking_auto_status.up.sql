@@ -0,0 +1,17 @@
+-- ปิดนัดอัตโนมัติ: CONFIRMED เลยเวลาเริ่ม → NO_SHOW, IN_SERVICE เลยเวลาจบ → COMPLETED
+ALTER TABLE branch_booking_settings
+  ADD COLUMN IF NOT EXISTS auto_no_show_enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN IF NOT EXISTS no_show_grace_minutes       INT     NOT NULL DEFAULT 15,
+  ADD COLUMN IF NOT EXISTS auto_complete_enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN IF NOT EXISTS auto_complete_grace_minutes INT     NOT NULL DEFAULT 30;
+
+ALTER TABLE branch_booking_settings
+  ADD CONSTRAINT chk_branch_booking_settings_grace
+  CHECK (no_show_grace_minutes >= 0 AND auto_complete_grace_minutes >= 0);
+
+-- log ที่ระบบเปลี่ยนสถานะเอง (ไม่มี user/customer)
+ALTER TABLE appointment_status_logs
+  ADD COLUMN IF NOT EXISTS changed_by_system BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_appointments_status_start ON appointments(status, start_time);
+CREATE INDEX IF NOT EXISTS idx_appointments_status_end ON appointments(status, end_time);
@@ -0,0 +1,45 @@
+-- คิวงานเบื้องหลัง (cmd/jobworker ดึงงานด้วย FOR UPDATE SKIP LOCKED)
+CREATE TABLE IF NOT EXISTS jobs (
+  id            SERIAL PRIMARY KEY,
+  type          VARCHAR(100) NOT NULL,
+  payload       JSONB        NOT NULL DEFAULT '{}',
+  status        VARCHAR(20)  NOT NULL DEFAULT 'PENDING',
+  attempts      INT          NOT NULL DEFAULT 0,
+  max_attempts  INT          NOT NULL DEFAULT 5,
+  run_at        TIMESTAMPTZ  NOT NULL DEFAULT now(),
+  unique_key    VARCHAR(200),
+
+  locked_by     VARCHAR(100),
+  locked_at     TIMESTAMPTZ,
+  last_error    TEXT,
+  finished_at   TIMESTAMPTZ,
+
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_jobs_status CHECK (status IN ('PENDING', 'RUNNING', 'DONE', 'DEAD')),
+  CONSTRAINT uq_jobs_unique_key UNIQUE (unique_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs(type);
+-- ใช้ตอน worker ดึงงานที่ถึงเวลา
+CREATE INDEX IF NOT EXISTS idx_jobs_pending_run_at ON jobs(run_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_jobs_running_locked_at ON jobs(locked_at) WHERE status = 'RUNNING';
+
+CREATE TABLE IF NOT EXISTS job_schedules (
+  id           SERIAL PRIMARY KEY,
+  name         VARCHAR(100) NOT NULL,
+  cron         VARCHAR(100) NOT NULL,
+  type         VARCHAR(100) NOT NULL,
+  payload      JSONB        NOT NULL DEFAULT '{}',
+  enabled      BOOLEAN      NOT NULL DEFAULT TRUE,
+  next_run_at  TIMESTAMPTZ  NOT NULL,
+  last_run_at  TIMESTAMPTZ,
+
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_job_schedules_name UNIQUE (name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_schedules_next_run_at ON job_schedules(next_run_at) WHERE enabled;
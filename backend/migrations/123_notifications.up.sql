@@ -0,0 +1,38 @@
+-- ช่องทางแจ้งเตือนของแต่ละ tenant (telegram, email, sms)
+CREATE TABLE IF NOT EXISTS notification_channel_configs (
+  id          SERIAL PRIMARY KEY,
+  tenant_id   INT          NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  channel     VARCHAR(20)  NOT NULL,
+  enabled     BOOLEAN      NOT NULL DEFAULT TRUE,
+  settings    JSONB        NOT NULL DEFAULT '{}',
+  created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_notification_channel CHECK (channel IN ('telegram', 'email', 'sms')),
+  CONSTRAINT idx_notification_channel_tenant UNIQUE (tenant_id, channel)
+);
+
+-- outbox: สร้างพร้อมข้อมูลต้นทาง แล้ว job notification.deliver เป็นผู้ส่ง
+CREATE TABLE IF NOT EXISTS notifications (
+  id          SERIAL PRIMARY KEY,
+  tenant_id   INT          NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  channel     VARCHAR(20)  NOT NULL,
+  event       VARCHAR(100) NOT NULL,
+  recipient   TEXT         NOT NULL,
+  subject     TEXT,
+  body        TEXT         NOT NULL,
+  status      VARCHAR(20)  NOT NULL DEFAULT 'PENDING',
+  attempts    INT          NOT NULL DEFAULT 0,
+  last_error  TEXT,
+  dedup_key   VARCHAR(200),
+  sent_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_notifications_status CHECK (status IN ('PENDING', 'SENT', 'FAILED')),
+  CONSTRAINT uq_notifications_dedup_key UNIQUE (dedup_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_tenant_id ON notifications(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
+CREATE INDEX IF NOT EXISTS idx_notifications_event ON notifications(event);
@@ -0,0 +1,12 @@
+-- เวลาแจ้งเตือนลูกค้าก่อนถึงนัด (นาทีก่อนเวลาเริ่ม) ต่อ tenant
+CREATE TABLE IF NOT EXISTS reminder_settings (
+  id               SERIAL PRIMARY KEY,
+  tenant_id        INT         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  enabled          BOOLEAN     NOT NULL DEFAULT TRUE,
+  offsets_minutes  JSONB       NOT NULL DEFAULT '[1440, 120]',
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_reminder_settings_tenant UNIQUE (tenant_id),
+  CONSTRAINT chk_reminder_offsets_array CHECK (jsonb_typeof(offsets_minutes) = 'array')
+);
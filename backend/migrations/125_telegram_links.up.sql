@@ -0,0 +1,26 @@
+-- ผูก Telegram chat กับผู้ใช้ (บอทคำสั่งสำหรับช่างและผู้ดูแลสาขา)
+CREATE TABLE IF NOT EXISTS telegram_links (
+  id          SERIAL PRIMARY KEY,
+  user_id     INT         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  chat_id     BIGINT      NOT NULL,
+  username    TEXT,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_telegram_links_user UNIQUE (user_id),
+  CONSTRAINT uq_telegram_links_chat UNIQUE (chat_id)
+);
+
+-- รหัสใช้ครั้งเดียว เก็บเฉพาะ sha256 ของรหัส
+CREATE TABLE IF NOT EXISTS telegram_link_codes (
+  id          SERIAL PRIMARY KEY,
+  user_id     INT         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash   VARCHAR(64) NOT NULL,
+  expires_at  TIMESTAMPTZ NOT NULL,
+  used_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_telegram_link_codes_hash UNIQUE (code_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_telegram_link_codes_user_id ON telegram_link_codes(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS telegram_updates;
+DROP TABLE IF EXISTS telegram_bots;
@@ -0,0 +1,25 @@
+-- บอท Telegram ของแต่ละร้าน: token เข้ารหัสด้วย APP_ENCRYPTION_KEY, webhook secret เก็บเป็น sha256
+CREATE TABLE IF NOT EXISTS telegram_bots (
+  id                   SERIAL PRIMARY KEY,
+  tenant_id            INT         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  bot_username         TEXT,
+  token_encrypted      TEXT        NOT NULL,
+  webhook_secret_hash  VARCHAR(64) NOT NULL,
+  enabled              BOOLEAN     NOT NULL DEFAULT TRUE,
+  created_at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_telegram_bots_tenant UNIQUE (tenant_id)
+);
+
+-- update_id ที่ประมวลผลแล้ว (bot_id = 0 คือบอทกลางของระบบ)
+CREATE TABLE IF NOT EXISTS telegram_updates (
+  id          SERIAL PRIMARY KEY,
+  bot_id      INT         NOT NULL,
+  update_id   BIGINT      NOT NULL,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT uq_telegram_updates_bot_update UNIQUE (bot_id, update_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_telegram_updates_created_at ON telegram_updates(created_at);
@@ -0,0 +1,43 @@
+-- ปลายทาง webhook ของ tenant (ระบบบัญชี, CRM ฯลฯ) secret เข้ารหัสด้วย APP_ENCRYPTION_KEY
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+  id                SERIAL PRIMARY KEY,
+  tenant_id         INT         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  url               TEXT        NOT NULL,
+  description       TEXT,
+  events            JSONB       NOT NULL DEFAULT '[]',
+  secret_encrypted  TEXT        NOT NULL,
+  enabled           BOOLEAN     NOT NULL DEFAULT TRUE,
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  deleted_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_tenant_id ON webhook_endpoints(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_deleted_at ON webhook_endpoints(deleted_at);
+
+-- delivery log: หนึ่งแถวต่อ event ต่อ endpoint, job webhook.deliver เป็นผู้ส่งและ retry
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id               SERIAL PRIMARY KEY,
+  tenant_id        INT          NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  endpoint_id      INT          NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+  event_id         VARCHAR(40)  NOT NULL,
+  event            VARCHAR(100) NOT NULL,
+  payload          JSONB        NOT NULL,
+  status           VARCHAR(20)  NOT NULL DEFAULT 'PENDING',
+  attempts         INT          NOT NULL DEFAULT 0,
+  response_status  INT,
+  response_body    TEXT,
+  last_error       TEXT,
+  last_attempt_at  TIMESTAMPTZ,
+  delivered_at     TIMESTAMPTZ,
+  created_at       TIMESTAMPTZ  NOT NULL DEFAULT now(),
+  updated_at       TIMESTAMPTZ  NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_id ON webhook_deliveries(tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries(event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(event);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status);
@@ -0,0 +1,81 @@
+-- บิลขายหน้าร้าน (POS) ยอดเงินเป็นบาท ทศนิยม 2 ตำแหน่ง
+CREATE TABLE IF NOT EXISTS sales (
+  id              SERIAL PRIMARY KEY,
+  tenant_id       INT           NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id       INT           NOT NULL REFERENCES branches(id),
+  appointment_id  INT           REFERENCES appointments(id) ON DELETE SET NULL,
+  customer_id     INT           REFERENCES customers(id) ON DELETE SET NULL,
+  cashier_id      INT           REFERENCES users(id) ON DELETE SET NULL,
+  status          VARCHAR(10)   NOT NULL DEFAULT 'OPEN',
+  subtotal        NUMERIC(12,2) NOT NULL DEFAULT 0,
+  discount        NUMERIC(12,2) NOT NULL DEFAULT 0,
+  total           NUMERIC(12,2) NOT NULL DEFAULT 0,
+  paid_amount     NUMERIC(12,2) NOT NULL DEFAULT 0,
+  change_amount   NUMERIC(12,2) NOT NULL DEFAULT 0,
+  refunded_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+  notes           TEXT,
+  paid_at         TIMESTAMPTZ,
+  voided_at       TIMESTAMPTZ,
+  void_reason     TEXT,
+  created_at      TIMESTAMPTZ   NOT NULL DEFAULT now(),
+  updated_at      TIMESTAMPTZ   NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_sales_status CHECK (status IN ('OPEN', 'PAID', 'VOID')),
+  CONSTRAINT chk_sales_amounts CHECK (subtotal >= 0 AND discount >= 0 AND total >= 0 AND paid_amount >= 0 AND change_amount >= 0 AND refunded_amount >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_tenant_id ON sales(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_sales_branch_id ON sales(branch_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sales_customer_id ON sales(customer_id);
+CREATE INDEX IF NOT EXISTS idx_sales_cashier_id ON sales(cashier_id);
+CREATE INDEX IF NOT EXISTS idx_sales_status ON sales(status);
+CREATE INDEX IF NOT EXISTS idx_sales_created_at ON sales(created_at);
+-- หนึ่งนัดมีบิลที่ยังไม่ VOID ได้ใบเดียว
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sales_appointment_active ON sales(appointment_id) WHERE status <> 'VOID';
+
+CREATE TABLE IF NOT EXISTS sale_items (
+  id          SERIAL PRIMARY KEY,
+  sale_id     INT           NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+  item_type   VARCHAR(10)   NOT NULL,
+  service_id  INT           REFERENCES services(id) ON DELETE SET NULL,
+  product_id  INT,
+  barber_id   INT           REFERENCES barbers(id) ON DELETE SET NULL,
+  name        VARCHAR(200)  NOT NULL,
+  quantity    INT           NOT NULL,
+  unit_price  NUMERIC(12,2) NOT NULL,
+  discount    NUMERIC(12,2) NOT NULL DEFAULT 0,
+  line_total  NUMERIC(12,2) NOT NULL,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ   NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_sale_items_type CHECK (item_type IN ('SERVICE', 'PRODUCT')),
+  CONSTRAINT chk_sale_items_quantity CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale_id ON sale_items(sale_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_service_id ON sale_items(service_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_product_id ON sale_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_barber_id ON sale_items(barber_id);
+
+-- การรับชำระ หนึ่งบิลมีได้หลายรายการ (split payment)
+-- kind = REFUND คือเงินที่คืนลูกค้า (ตอน VOID บิลที่รับเงินแล้ว) amount เป็นบวกเสมอ
+CREATE TABLE IF NOT EXISTS payments (
+  id           SERIAL PRIMARY KEY,
+  tenant_id    INT           NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  sale_id      INT           NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+  kind         VARCHAR(10)   NOT NULL DEFAULT 'PAYMENT',
+  method       VARCHAR(20)   NOT NULL,
+  amount       NUMERIC(12,2) NOT NULL,
+  reference    VARCHAR(100),
+  received_by  INT           REFERENCES users(id) ON DELETE SET NULL,
+  created_at   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_payments_kind CHECK (kind IN ('PAYMENT', 'REFUND')),
+  CONSTRAINT chk_payments_method CHECK (method IN ('CASH', 'CARD', 'TRANSFER')),
+  CONSTRAINT chk_payments_amount CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_tenant_id ON payments(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_payments_sale_id ON payments(sale_id);
+CREATE INDEX IF NOT EXISTS idx_payments_method ON payments(method);
+CREATE INDEX IF NOT EXISTS idx_payments_created_at ON payments(created_at);
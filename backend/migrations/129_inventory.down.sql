@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS stock_thresholds;
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+DROP TABLE IF EXISTS stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
+ALTER TABLE sale_items DROP CONSTRAINT IF EXISTS fk_sale_items_product;
+DROP TABLE IF EXISTS products;
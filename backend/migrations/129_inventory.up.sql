@@ -0,0 +1,87 @@
+-- สินค้าขายปลีก ราคา/ต้นทุนเป็นบาท ทศนิยม 2 ตำแหน่ง
+CREATE TABLE IF NOT EXISTS products (
+  id           SERIAL PRIMARY KEY,
+  tenant_id    INT           NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id    INT           REFERENCES branches(id) ON DELETE CASCADE,
+  sku          VARCHAR(64)   NOT NULL,
+  barcode      VARCHAR(64)   NOT NULL DEFAULT '',
+  name         VARCHAR(200)  NOT NULL,
+  description  TEXT,
+  cost         NUMERIC(12,2) NOT NULL DEFAULT 0,
+  price        NUMERIC(12,2) NOT NULL DEFAULT 0,
+  is_active    BOOLEAN       NOT NULL DEFAULT TRUE,
+  created_at   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+  updated_at   TIMESTAMPTZ   NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_products_amounts CHECK (cost >= 0 AND price >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_products_tenant_sku ON products(tenant_id, sku);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_products_tenant_barcode ON products(tenant_id, barcode) WHERE barcode <> '';
+CREATE INDEX IF NOT EXISTS idx_products_branch_id ON products(branch_id);
+
+-- รายการขายสินค้าอ้างอิง products
+ALTER TABLE sale_items
+  ADD CONSTRAINT fk_sale_items_product FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
+
+-- สมุดบัญชีสต๊อก เพิ่มได้อย่างเดียว ยอดคงเหลือ = SUM(quantity) ต่อสาขาต่อสินค้า
+CREATE TABLE IF NOT EXISTS stock_movements (
+  id                      SERIAL PRIMARY KEY,
+  tenant_id               INT           NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id               INT           NOT NULL REFERENCES branches(id),
+  product_id              INT           NOT NULL REFERENCES products(id),
+  type                    VARCHAR(20)   NOT NULL,
+  quantity                INT           NOT NULL,
+  unit_cost               NUMERIC(12,2),
+  sale_id                 INT           REFERENCES sales(id),
+  sale_item_id            INT,
+  counterparty_branch_id  INT           REFERENCES branches(id),
+  transfer_ref            VARCHAR(36),
+  reason                  TEXT,
+  created_by              INT           REFERENCES users(id) ON DELETE SET NULL,
+  created_at              TIMESTAMPTZ   NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_stock_movements_type CHECK (type IN ('RECEIVE', 'SALE', 'RETURN', 'ADJUSTMENT', 'TRANSFER_OUT', 'TRANSFER_IN')),
+  CONSTRAINT chk_stock_movements_quantity CHECK (quantity <> 0),
+  CONSTRAINT chk_stock_movements_sign CHECK (
+    (type IN ('RECEIVE', 'RETURN', 'TRANSFER_IN') AND quantity > 0) OR
+    (type IN ('SALE', 'TRANSFER_OUT') AND quantity < 0) OR
+    type = 'ADJUSTMENT'
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_tenant_id ON stock_movements(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_branch_product ON stock_movements(branch_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_sale_id ON stock_movements(sale_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_transfer_ref ON stock_movements(transfer_ref);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements(created_at);
+
+-- กันแก้/ลบประวัติสต๊อก (ลบได้เฉพาะตอนลบ tenant ทั้งก้อน)
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' AND NOT EXISTS (SELECT 1 FROM tenants WHERE id = OLD.tenant_id) THEN
+    RETURN OLD;
+  END IF;
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+CREATE TRIGGER trg_stock_movements_append_only
+  BEFORE UPDATE OR DELETE ON stock_movements
+  FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- จุดสั่งซื้อต่อสาขา
+CREATE TABLE IF NOT EXISTS stock_thresholds (
+  id             SERIAL PRIMARY KEY,
+  tenant_id      INT         NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id      INT         NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+  product_id     INT         NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  reorder_level  INT         NOT NULL DEFAULT 0,
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_stock_thresholds_level CHECK (reorder_level >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_thresholds_tenant_id ON stock_thresholds(tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_thresholds_branch_product ON stock_thresholds(branch_id, product_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_payments_shift_id;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_change_tip;
+ALTER TABLE payments DROP COLUMN IF EXISTS shift_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS tip_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS change_amount;
+ALTER TABLE sales DROP COLUMN IF EXISTS tip_amount;
+DROP TABLE IF EXISTS cash_movements;
+DROP TABLE IF EXISTS cash_shifts;
@@ -0,0 +1,58 @@
+-- กะลิ้นชักเงินสด หนึ่งแคชเชียร์เปิดได้ทีละกะต่อสาขา
+CREATE TABLE IF NOT EXISTS cash_shifts (
+  id             SERIAL PRIMARY KEY,
+  tenant_id      INT           NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id      INT           NOT NULL REFERENCES branches(id),
+  cashier_id     INT           NOT NULL REFERENCES users(id),
+  status         VARCHAR(10)   NOT NULL DEFAULT 'OPEN',
+  opening_float  NUMERIC(12,2) NOT NULL DEFAULT 0,
+  expected_cash  NUMERIC(12,2),
+  counted_cash   NUMERIC(12,2),
+  variance       NUMERIC(12,2),
+  close_note     TEXT,
+  closed_by      INT           REFERENCES users(id) ON DELETE SET NULL,
+  opened_at      TIMESTAMPTZ   NOT NULL DEFAULT now(),
+  closed_at      TIMESTAMPTZ,
+
+  CONSTRAINT chk_cash_shifts_status CHECK (status IN ('OPEN', 'CLOSED')),
+  CONSTRAINT chk_cash_shifts_float CHECK (opening_float >= 0),
+  CONSTRAINT chk_cash_shifts_closed CHECK (status = 'OPEN' OR (closed_at IS NOT NULL AND counted_cash IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_tenant_id ON cash_shifts(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_cashier_id ON cash_shifts(cashier_id);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_opened_at ON cash_shifts(opened_at);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_branch_closed_at ON cash_shifts(branch_id, closed_at);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cash_shifts_open ON cash_shifts(branch_id, cashier_id) WHERE status = 'OPEN';
+
+-- เงินเข้า/ออกลิ้นชักที่ไม่ใช่การขาย
+CREATE TABLE IF NOT EXISTS cash_movements (
+  id          SERIAL PRIMARY KEY,
+  tenant_id   INT           NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  shift_id    INT           NOT NULL REFERENCES cash_shifts(id) ON DELETE CASCADE,
+  type        VARCHAR(10)   NOT NULL,
+  amount      NUMERIC(12,2) NOT NULL,
+  reason      TEXT          NOT NULL,
+  created_by  INT           REFERENCES users(id) ON DELETE SET NULL,
+  created_at  TIMESTAMPTZ   NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_cash_movements_type CHECK (type IN ('CASH_IN', 'CASH_OUT')),
+  CONSTRAINT chk_cash_movements_amount CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_movements_tenant_id ON cash_movements(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_cash_movements_shift_id ON cash_movements(shift_id);
+
+-- ทิปและเงินทอนต่อการรับชำระ และกะที่รับ/คืนเงิน
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS tip_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS change_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tip_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES cash_shifts(id) ON DELETE SET NULL;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_change_tip CHECK (change_amount >= 0 AND tip_amount >= 0);
+CREATE INDEX IF NOT EXISTS idx_payments_shift_id ON payments(shift_id);
+
+-- เงินทอนของบิลที่ชำระแล้วก่อนมีคอลัมน์นี้ ย้ายไปไว้ที่การรับเงินสดครั้งล่าสุดของบิล
+UPDATE payments p SET change_amount = s.change_amount
+FROM sales s
+WHERE s.id = p.sale_id AND s.change_amount > 0
+  AND p.id = (SELECT MAX(id) FROM payments WHERE sale_id = s.id AND kind = 'PAYMENT' AND method = 'CASH');
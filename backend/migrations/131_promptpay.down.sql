@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS promptpay_charges;
+DROP TABLE IF EXISTS promptpay_accounts;
+
+-- การชำระ PROMPTPAY ที่บันทึกไปแล้วยังอยู่ จึงไม่ตรวจแถวเดิม
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method CHECK (method IN ('CASH', 'CARD', 'TRANSFER')) NOT VALID;
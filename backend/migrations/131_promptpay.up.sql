@@ -0,0 +1,58 @@
+-- พร้อมเพย์เป็นช่องทางชำระใหม่ (บันทึกจาก QR ที่ยืนยันแล้วเท่านั้น)
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method CHECK (method IN ('CASH', 'CARD', 'TRANSFER', 'PROMPTPAY'));
+
+-- บัญชีพร้อมเพย์รับเงิน สาขาละหนึ่งบัญชี
+CREATE TABLE IF NOT EXISTS promptpay_accounts (
+  id            SERIAL PRIMARY KEY,
+  tenant_id     INT          NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id     INT          NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+  proxy_type    VARCHAR(20)  NOT NULL,
+  proxy_id      VARCHAR(20)  NOT NULL,
+  account_name  VARCHAR(100),
+  created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+  updated_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_promptpay_accounts_proxy_type CHECK (proxy_type IN ('MOBILE', 'NATIONAL_ID', 'EWALLET'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_promptpay_accounts_tenant_id ON promptpay_accounts(tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promptpay_accounts_branch_id ON promptpay_accounts(branch_id);
+
+-- QR ระบุยอดของบิลหรือมัดจำนัด รอยืนยัน (PENDING) -> PAID
+CREATE TABLE IF NOT EXISTS promptpay_charges (
+  id              SERIAL PRIMARY KEY,
+  tenant_id       INT            NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id       INT            NOT NULL REFERENCES branches(id),
+  sale_id         INT            REFERENCES sales(id),
+  appointment_id  INT            REFERENCES appointments(id),
+  reference       VARCHAR(40)    NOT NULL,
+  amount          NUMERIC(12,2)  NOT NULL,
+  proxy_type      VARCHAR(20)    NOT NULL,
+  proxy_id        VARCHAR(20)    NOT NULL,
+  payload         TEXT           NOT NULL,
+  status          VARCHAR(10)    NOT NULL DEFAULT 'PENDING',
+  confirmed_via   VARCHAR(10),
+  confirmed_by    INT            REFERENCES users(id) ON DELETE SET NULL,
+  trans_ref       VARCHAR(100),
+  payment_id      INT            REFERENCES payments(id),
+  paid_at         TIMESTAMPTZ,
+  created_by      INT            REFERENCES users(id) ON DELETE SET NULL,
+  cancelled_at    TIMESTAMPTZ,
+  created_at      TIMESTAMPTZ    NOT NULL DEFAULT now(),
+  updated_at      TIMESTAMPTZ    NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_promptpay_charges_target CHECK (sale_id IS NOT NULL OR appointment_id IS NOT NULL),
+  CONSTRAINT chk_promptpay_charges_amount CHECK (amount > 0),
+  CONSTRAINT chk_promptpay_charges_status CHECK (status IN ('PENDING', 'PAID', 'CANCELLED')),
+  CONSTRAINT chk_promptpay_charges_paid CHECK (status <> 'PAID' OR (paid_at IS NOT NULL AND confirmed_via IN ('STAFF', 'SLIP')))
+);
+
+CREATE INDEX IF NOT EXISTS idx_promptpay_charges_tenant_id ON promptpay_charges(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_promptpay_charges_branch_id ON promptpay_charges(branch_id);
+CREATE INDEX IF NOT EXISTS idx_promptpay_charges_sale_id ON promptpay_charges(sale_id);
+CREATE INDEX IF NOT EXISTS idx_promptpay_charges_appointment_id ON promptpay_charges(appointment_id);
+CREATE INDEX IF NOT EXISTS idx_promptpay_charges_status ON promptpay_charges(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promptpay_charges_reference ON promptpay_charges(reference);
+-- สลิปเดียวยืนยันได้ครั้งเดียว
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promptpay_charges_trans_ref ON promptpay_charges(trans_ref);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS gateway_events;
+DROP TABLE IF EXISTS gateway_payments;
@@ -0,0 +1,53 @@
+-- การชำระผ่าน payment gateway ของบิลหรือมัดจำนัด
+-- PENDING -> AUTHORIZED -> CAPTURED -> REFUNDED หรือ FAILED ตามผลจากผู้ให้บริการ
+CREATE TABLE IF NOT EXISTS gateway_payments (
+  id               SERIAL PRIMARY KEY,
+  tenant_id        INT            NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  branch_id        INT            NOT NULL REFERENCES branches(id),
+  sale_id          INT            REFERENCES sales(id),
+  appointment_id   INT            REFERENCES appointments(id),
+  provider         VARCHAR(30)    NOT NULL,
+  provider_txn_id  VARCHAR(100),
+  reference        VARCHAR(40)    NOT NULL,
+  amount           NUMERIC(12,2)  NOT NULL,
+  refunded_amount  NUMERIC(12,2)  NOT NULL DEFAULT 0,
+  status           VARCHAR(12)    NOT NULL DEFAULT 'PENDING',
+  failure_reason   TEXT,
+  note             TEXT,
+  payment_id       INT            REFERENCES payments(id),
+  created_by       INT            REFERENCES users(id) ON DELETE SET NULL,
+  authorized_at    TIMESTAMPTZ,
+  captured_at      TIMESTAMPTZ,
+  failed_at        TIMESTAMPTZ,
+  refunded_at      TIMESTAMPTZ,
+  created_at       TIMESTAMPTZ    NOT NULL DEFAULT now(),
+  updated_at       TIMESTAMPTZ    NOT NULL DEFAULT now(),
+
+  CONSTRAINT chk_gateway_payments_target CHECK (sale_id IS NOT NULL OR appointment_id IS NOT NULL),
+  CONSTRAINT chk_gateway_payments_amount CHECK (amount > 0 AND refunded_amount >= 0 AND refunded_amount <= amount),
+  CONSTRAINT chk_gateway_payments_status CHECK (status IN ('PENDING', 'AUTHORIZED', 'CAPTURED', 'REFUNDED', 'FAILED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_gateway_payments_tenant_id ON gateway_payments(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_gateway_payments_branch_id ON gateway_payments(branch_id);
+CREATE INDEX IF NOT EXISTS idx_gateway_payments_sale_id ON gateway_payments(sale_id);
+CREATE INDEX IF NOT EXISTS idx_gateway_payments_appointment_id ON gateway_payments(appointment_id);
+CREATE INDEX IF NOT EXISTS idx_gateway_payments_status ON gateway_payments(status);
+CREATE INDEX IF NOT EXISTS idx_gateway_payments_updated_at ON gateway_payments(updated_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gateway_payments_reference ON gateway_payments(reference);
+-- กระทบยอดด้วยเลขธุรกรรมของผู้ให้บริการ
+CREATE UNIQUE INDEX IF NOT EXISTS uq_gateway_payments_provider_txn ON gateway_payments(provider, provider_txn_id);
+
+-- webhook ที่ประมวลผลแล้ว กันการส่งซ้ำ
+CREATE TABLE IF NOT EXISTS gateway_events (
+  id                  SERIAL PRIMARY KEY,
+  provider            VARCHAR(30)   NOT NULL,
+  event_id            VARCHAR(100)  NOT NULL,
+  provider_txn_id     VARCHAR(100)  NOT NULL,
+  status              VARCHAR(12)   NOT NULL,
+  gateway_payment_id  INT           NOT NULL REFERENCES gateway_payments(id) ON DELETE CASCADE,
+  received_at         TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gateway_events_gateway_payment_id ON gateway_events(gateway_payment_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_gateway_events_provider_event ON gateway_events(provider, event_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uq_telegram_bots_username;
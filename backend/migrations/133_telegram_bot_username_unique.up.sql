@@ -0,0 +1,2 @@
+-- บอทหนึ่งตัวตั้ง webhook ได้ที่เดียว จึงใช้ได้เพียงร้านเดียว
+CREATE UNIQUE INDEX IF NOT EXISTS uq_telegram_bots_username ON telegram_bots (bot_username);
@@ -0,0 +1,8 @@
+-- การผูกผ่านบอทร้านหายไป เหลือเฉพาะของบอทกลาง
+DELETE FROM telegram_links WHERE bot_id <> 0;
+
+ALTER TABLE telegram_links DROP CONSTRAINT IF EXISTS uq_telegram_links_bot_user;
+ALTER TABLE telegram_links DROP CONSTRAINT IF EXISTS uq_telegram_links_bot_chat;
+ALTER TABLE telegram_links ADD CONSTRAINT uq_telegram_links_user UNIQUE (user_id);
+ALTER TABLE telegram_links ADD CONSTRAINT uq_telegram_links_chat UNIQUE (chat_id);
+ALTER TABLE telegram_links DROP COLUMN IF EXISTS bot_id;
@@ -0,0 +1,7 @@
+-- การผูกบัญชีแยกตามบอท (bot_id = 0 คือบอทกลางของระบบ) บอทร้านไม่ตอบผู้ใช้ที่ผูกผ่านบอทอื่น
+ALTER TABLE telegram_links ADD COLUMN IF NOT EXISTS bot_id INT NOT NULL DEFAULT 0;
+
+ALTER TABLE telegram_links DROP CONSTRAINT IF EXISTS uq_telegram_links_user;
+ALTER TABLE telegram_links DROP CONSTRAINT IF EXISTS uq_telegram_links_chat;
+ALTER TABLE telegram_links ADD CONSTRAINT uq_telegram_links_bot_user UNIQUE (bot_id, user_id);
+ALTER TABLE telegram_links ADD CONSTRAINT uq_telegram_links_bot_chat UNIQUE (bot_id, chat_id);
@@ -0,0 +1,2 @@
+-- เวลาที่ worker จองส่ง delivery (ล้างเมื่อบันทึกผล) กันงานที่ถูกรันซ้ำส่งพร้อมกัน
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+ALTER TABLE promptpay_charges DROP COLUMN IF EXISTS overpaid_amount;
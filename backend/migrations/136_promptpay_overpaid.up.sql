@@ -0,0 +1,2 @@
+-- ยอดโอนพร้อมเพย์ที่เกินยอดค้างของบิลตอนยืนยัน (ต้องคืนลูกค้านอกระบบ)
+ALTER TABLE promptpay_charges ADD COLUMN IF NOT EXISTS overpaid_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
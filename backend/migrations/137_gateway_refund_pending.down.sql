@@ -0,0 +1,2 @@
+ALTER TABLE gateway_payments DROP CONSTRAINT IF EXISTS chk_gateway_payments_refund_pending;
+ALTER TABLE gateway_payments DROP COLUMN IF EXISTS refund_pending;
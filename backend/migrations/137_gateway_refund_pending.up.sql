@@ -0,0 +1,3 @@
+-- ยอดคืนที่จองไว้ระหว่างรอผู้ให้บริการตอบ กันคำขอคืนเงินพร้อมกันคืนเกินยอดที่ตัดได้
+ALTER TABLE gateway_payments ADD COLUMN IF NOT EXISTS refund_pending NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE gateway_payments ADD CONSTRAINT chk_gateway_payments_refund_pending CHECK (refund_pending >= 0);
@@ -0,0 +1,4 @@
+UPDATE waitlist_entries SET status = 'CANCELLED' WHERE status = 'EXPIRED';
+ALTER TABLE waitlist_entries DROP CONSTRAINT IF EXISTS chk_waitlist_entries_status;
+ALTER TABLE waitlist_entries ADD CONSTRAINT chk_waitlist_entries_status
+  CHECK (status IN ('WAITING', 'OFFERED', 'PROMOTED', 'CANCELLED'));
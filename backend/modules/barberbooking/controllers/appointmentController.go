@@ -97,7 +97,7 @@ func (ctrl *AppointmentController) CheckBarberAvailability(c *fiber.Ctx) error {
 
 // CreateAppointment godoc
 // @Summary      สร้างนัดหมายใหม่ (Create Appointment)
-// @Description  สร้าง Appointment ภายใต้ Tenant ที่ระบุ พร้อมกรอก branch, service, customer, optional barber, start_time (RFC3339) และ notes
+// @Description  สร้าง Appointment ภายใต้ Tenant ที่ระบุ พร้อมกรอก branch, service, customer, optional barber, start_time (RFC3339), notes และ lock_id (ถ้าถือ lock ไว้)
 // @Tags         Appointment
 // @Accept       json
 // @Produce      json
@@ -105,6 +105,8 @@ func (ctrl *AppointmentController) CheckBarberAvailability(c *fiber.Ctx) error {
 // @Param body body barberBookingPort.CreateAppointmentRequest true "Payload สำหรับสร้างนัดหมาย"
 // @Success      201         {object}  barberBookingModels.Appointment            "คืนค่า status success พร้อมข้อมูล Appointment ที่สร้าง"
 // @Failure      400         {object}  map[string]string                          "Missing required fields หรือ Invalid format"
+// @Failure      404         {object}  map[string]string                          "ไม่พบ lock_id ที่แนบมา"
+// @Failure      409         {object}  map[string]string                          "lock หมดอายุ/ไม่ใช่ของลูกค้าคนนี้ ช่วงเวลาถูกลูกค้าอื่น lock ไว้ หรือ slot taken"
 // @Failure      500         {object}  map[string]string                          "Internal Server Error"
 // @Router       /tenants/{tenant_id}/appointments [post]
 // @Security     ApiKeyAuth
@@ -123,11 +125,13 @@ func (ctrl *AppointmentController) CreateAppointment(c *fiber.Ctx) error {
 	var payload struct {
 		BranchID   uint                             `json:"branch_id"`
 		ServiceID  uint                             `json:"service_id"`
+		ServiceIDs []uint                           `json:"service_ids,omitempty"`
 		BarberID   uint                             `json:"barber_id,omitempty"`
 		CustomerID uint                             `json:"customer_id"`
 		StartTime  string                           `json:"start_time"`
 		Notes      string                           `json:"notes,omitempty"`
 		Customer   *barberBookingPort.CustomerInput `json:"customer,omitempty"`
+		LockID     *uint                            `json:"lock_id,omitempty"`
 	}
 
 	if err := c.BodyParser(&payload); err != nil {
@@ -175,6 +179,12 @@ func (ctrl *AppointmentController) CreateAppointment(c *fiber.Ctx) error {
 		CustomerID: payload.CustomerID,
 		StartTime:  startTime,
 		Notes:      payload.Notes,
+		LockID:     payload.LockID,
+	}
+
+	// หลายบริการในนัดเดียว → ส่งตามลำดับที่ลูกค้าเลือก
+	for _, id := range payload.ServiceIDs {
+		appt.Items = append(appt.Items, barberBookingModels.AppointmentItem{ServiceID: id})
 	}
 
 	// ถ้า guest → แนบข้อมูล guest ไปให้ service ใช้สร้าง customer
@@ -188,7 +198,16 @@ func (ctrl *AppointmentController) CreateAppointment(c *fiber.Ctx) error {
 	// 6. Call service
 	createdDTO, err := ctrl.Service.CreateAppointment(c.Context(), appt)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		status := fiber.StatusInternalServerError
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "lock with ID") && strings.Contains(msg, "not found"):
+			status = fiber.StatusNotFound
+		case strings.Contains(msg, "appointment lock"),
+			strings.Contains(msg, "locked by another customer"),
+			strings.Contains(msg, "slot taken"):
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"status":  "error",
 			"message": err.Error(),
 		})
@@ -276,7 +295,9 @@ func (ctrl *AppointmentController) GetAvailableBarbers(c *fiber.Ctx) error {
 // @Param        appointment_id   path      uint                                         true  "รหัส Appointment"
 // @Param        body             body      barberBookingModels.Appointment              true  "ข้อมูล Appointment ที่ต้องการอัปเดต"
 // @Success      200              {object}  barberBookingModels.Appointment              "คืนค่า status success และข้อมูล Appointment ที่อัปเดต"
-// @Failure      400              {object}  map[string]string                             "Invalid tenant_id, appointment_id หรือ JSON body"
+// @Failure      400              {object}  map[string]string                             "Invalid tenant_id, appointment_id หรือ JSON body, เปลี่ยนสถานะ/เวลาของนัดที่เริ่มแล้ว"
+// @Failure      404              {object}  map[string]string                             "ไม่พบนัดหมาย ลูกค้า หรือช่าง"
+// @Failure      409              {object}  map[string]string                             "ช่างไม่ว่าง ช่วงเวลาถูก lock หรือ slot taken"
 // @Failure      500              {object}  map[string]string                             "Internal Server Error"
 // @Router       /tenants/:tenant_id/appointments/:appointment_id [put]
 // @Security     ApiKeyAuth
@@ -303,7 +324,19 @@ func (ctrl *AppointmentController) UpdateAppointment(c *fiber.Ctx) error {
 	updated, err := ctrl.Service.UpdateAppointment(context.Background(), apptID, tenantID, &input)
 	if err != nil {
 		// service returns generic fmt.Errorf with message
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		status := fiber.StatusInternalServerError
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "cannot change"),
+			strings.Contains(msg, "mismatched branch"):
+			status = fiber.StatusBadRequest
+		case strings.Contains(msg, "not found"):
+			status = fiber.StatusNotFound
+		case strings.Contains(msg, "not available"),
+			strings.Contains(msg, "locked by another customer"),
+			strings.Contains(msg, "slot taken"):
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"status":  "error",
 			"message": err.Error(),
 		})
@@ -570,12 +603,14 @@ type RescheduleRequest struct {
 // @Success      200             {object}  map[string]string  "คืนค่า status success และข้อความยืนยันการเลื่อนนัดหมาย"
 // @Failure      400             {object}  map[string]string  "Missing or invalid parameters หรือ cannot reschedule"
 // @Failure      404             {object}  map[string]string  "Appointment not found"
+// @Failure      409             {object}  map[string]string  "ช่วงเวลาถูกลูกค้าอื่น lock ไว้ หรือ slot taken"
 // @Failure      500             {object}  map[string]string  "Internal Server Error"
 // @Router       /tenants/:tenant_id/appointments/:appointment_id/reschedule [post]
 // @Security     ApiKeyAuth
 func (ctrl *AppointmentController) RescheduleAppointment(c *fiber.Ctx) error {
 	// 1. Parse path params
-	if _, err := helperFunc.ParseUintParam(c, "tenant_id"); err != nil {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Invalid tenant_id",
@@ -625,6 +660,7 @@ func (ctrl *AppointmentController) RescheduleAppointment(c *fiber.Ctx) error {
 	// 5. Call service
 	err = ctrl.Service.RescheduleAppointment(
 		c.Context(),
+		tenantID,
 		apptID,
 		newStart,
 		req.ActorUserID,
@@ -638,6 +674,12 @@ func (ctrl *AppointmentController) RescheduleAppointment(c *fiber.Ctx) error {
 				"status":  "error",
 				"message": msg,
 			})
+		case strings.Contains(msg, "slot taken"),
+			strings.Contains(msg, "locked by another customer"):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": msg,
+			})
 		case strings.Contains(msg, "cannot reschedule"):
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"status":  "error",
@@ -931,3 +973,130 @@ func (ctrl *AppointmentController) GetAppointmentsByPhone(c *fiber.Ctx) error {
 
 
 
+
+type StatusTransitionRequest struct {
+	Notes string `json:"notes,omitempty" example:"ลูกค้ามาถึงแล้ว"`
+}
+
+// transitionStatus ใช้ร่วมกันใน endpoint เปลี่ยนสถานะ; role และ user มาจาก token
+func (ctrl *AppointmentController) transitionStatus(c *fiber.Ctx, to barberBookingModels.AppointmentStatus) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	apptID, err := helperFunc.ParseUintParam(c, "appointment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid appointment_id"})
+	}
+
+	var req StatusTransitionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid JSON body"})
+		}
+	}
+
+	input := barberBookingPort.StatusTransitionInput{Notes: req.Notes}
+	input.ActorRole, _ = c.Locals("role").(string)
+	if userID, ok := c.Locals("user_id").(uint); ok {
+		input.ActorUserID = &userID
+	}
+
+	appt, err := ctrl.Service.TransitionStatus(c.Context(), tenantID, apptID, to, input)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "permission denied"):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": msg})
+		case strings.Contains(msg, "not found"):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": msg})
+		case strings.Contains(msg, "cannot change appointment status"),
+			strings.Contains(msg, "slot taken"):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": msg})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": msg})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": appt})
+}
+
+// ConfirmAppointment godoc
+// @Summary      ยืนยันนัดหมาย
+// @Description  PENDING/RESCHEDULED → CONFIRMED
+// @Tags         Appointment
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                     true   "รหัส Tenant"
+// @Param        appointment_id  path      uint                     true   "รหัส Appointment"
+// @Param        body            body      StatusTransitionRequest  false  "หมายเหตุ"
+// @Success      200             {object}  barberBookingModels.Appointment
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      409             {object}  map[string]string  "สถานะปัจจุบันเปลี่ยนไปสถานะนี้ไม่ได้"
+// @Router       /tenants/{tenant_id}/appointments/{appointment_id}/confirm [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentController) ConfirmAppointment(c *fiber.Ctx) error {
+	return ctrl.transitionStatus(c, barberBookingModels.StatusConfirmed)
+}
+
+// StartService godoc
+// @Summary      เริ่มให้บริการ
+// @Description  CONFIRMED/RESCHEDULED → IN_SERVICE
+// @Tags         Appointment
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                     true   "รหัส Tenant"
+// @Param        appointment_id  path      uint                     true   "รหัส Appointment"
+// @Param        body            body      StatusTransitionRequest  false  "หมายเหตุ"
+// @Success      200             {object}  barberBookingModels.Appointment
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      409             {object}  map[string]string  "สถานะปัจจุบันเปลี่ยนไปสถานะนี้ไม่ได้"
+// @Router       /tenants/{tenant_id}/appointments/{appointment_id}/start [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentController) StartService(c *fiber.Ctx) error {
+	return ctrl.transitionStatus(c, barberBookingModels.StatusInService)
+}
+
+// CompleteAppointment godoc
+// @Summary      จบงาน
+// @Description  IN_SERVICE → COMPLETED
+// @Tags         Appointment
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                     true   "รหัส Tenant"
+// @Param        appointment_id  path      uint                     true   "รหัส Appointment"
+// @Param        body            body      StatusTransitionRequest  false  "หมายเหตุ"
+// @Success      200             {object}  barberBookingModels.Appointment
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      409             {object}  map[string]string  "สถานะปัจจุบันเปลี่ยนไปสถานะนี้ไม่ได้"
+// @Router       /tenants/{tenant_id}/appointments/{appointment_id}/complete [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentController) CompleteAppointment(c *fiber.Ctx) error {
+	return ctrl.transitionStatus(c, barberBookingModels.StatusComplete)
+}
+
+// MarkNoShow godoc
+// @Summary      บันทึกว่าลูกค้าไม่มา
+// @Description  PENDING/CONFIRMED/RESCHEDULED → NO_SHOW (เฉพาะผู้จัดการขึ้นไป) ช่วงเวลาที่เหลือจะถูกเสนอให้ waitlist
+// @Tags         Appointment
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                     true   "รหัส Tenant"
+// @Param        appointment_id  path      uint                     true   "รหัส Appointment"
+// @Param        body            body      StatusTransitionRequest  false  "หมายเหตุ"
+// @Success      200             {object}  barberBookingModels.Appointment
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      409             {object}  map[string]string  "สถานะปัจจุบันเปลี่ยนไปสถานะนี้ไม่ได้"
+// @Router       /tenants/{tenant_id}/appointments/{appointment_id}/no-show [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentController) MarkNoShow(c *fiber.Ctx) error {
+	return ctrl.transitionStatus(c, barberBookingModels.StatusNoShow)
+}
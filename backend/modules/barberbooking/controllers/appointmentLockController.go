@@ -7,6 +7,7 @@ import (
 
 	barberBookingPort "myapp/modules/barberbooking/port"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -77,6 +78,35 @@ func (ctl *AppointmentLockController) ReleaseAppointmentLock(c *fiber.Ctx) error
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+type ExtendAppointmentLockRequest struct {
+	CustomerID uint `json:"customer_id" validate:"required"`
+}
+
+// POST /appointment-locks/:lock_id/heartbeat
+// ต่ออายุ lock ระหว่างที่ลูกค้ายังอยู่หน้าจอง
+func (ctl *AppointmentLockController) ExtendAppointmentLock(c *fiber.Ctx) error {
+	idParam := c.Params("lock_id")
+	lockID, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid lock ID"})
+	}
+
+	var req ExtendAppointmentLockRequest
+	if err := c.BodyParser(&req); err != nil || req.CustomerID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	lock, err := ctl.Service.ExtendAppointmentLock(context.Background(), uint(lockID), req.CustomerID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(lock)
+}
+
 // GET /appointment-locks?branch_id=1&barber_id=2&date=2025-07-13
 func (ctl *AppointmentLockController) GetAppointmentLocks(c *fiber.Ctx) error {
 	branchID, _ := strconv.Atoi(c.Query("branch_id"))
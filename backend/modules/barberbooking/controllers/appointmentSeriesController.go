@@ -0,0 +1,242 @@
+package barberBookingController
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+)
+
+type AppointmentSeriesController struct {
+	Service barberBookingPort.IAppointmentSeries
+}
+
+func NewAppointmentSeriesController(service barberBookingPort.IAppointmentSeries) *AppointmentSeriesController {
+	return &AppointmentSeriesController{Service: service}
+}
+
+type CreateAppointmentSeriesRequest struct {
+	barberBookingPort.CreateAppointmentRequest
+	Recurrence barberBookingPort.RecurrenceRule `json:"recurrence"`
+}
+
+type CancelSeriesRequest struct {
+	Scope           barberBookingPort.SeriesScope `json:"scope" example:"following"`
+	ActorUserID     *uint                         `json:"actor_user_id,omitempty"`
+	ActorCustomerID *uint                         `json:"actor_customer_id,omitempty"`
+}
+
+type RescheduleSeriesRequest struct {
+	NewStartTime    string                        `json:"new_start_time"`
+	Scope           barberBookingPort.SeriesScope `json:"scope" example:"all"`
+	ActorUserID     *uint                         `json:"actor_user_id,omitempty"`
+	ActorCustomerID *uint                         `json:"actor_customer_id,omitempty"`
+}
+
+func seriesErrorStatus(msg string) int {
+	switch {
+	case strings.Contains(msg, "not found"):
+		return fiber.StatusNotFound
+	case strings.Contains(msg, "invalid"),
+		strings.Contains(msg, "recurrence"),
+		strings.Contains(msg, "cannot"),
+		strings.Contains(msg, "missing required fields"),
+		strings.Contains(msg, "no occurrence could be booked"):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// CreateSeries godoc
+// @Summary      สร้างนัดหมายแบบประจำ (Recurring Appointment)
+// @Description  สร้าง series ตามกฎ WEEKLY/MONTHLY ทุก N ครั้ง จนถึง count หรือ until แล้วสร้าง Appointment ทีละครั้ง ครั้งที่ชนจะถูกรายงานใน occurrences โดยไม่ทำให้ทั้ง series ล้ม
+// @Tags         AppointmentSeries
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                            true  "รหัส Tenant"
+// @Param        body       body      CreateAppointmentSeriesRequest  true  "ข้อมูลนัดหมาย + recurrence"
+// @Success      201        {object}  barberBookingPort.SeriesResult
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/appointment-series [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentSeriesController) CreateSeries(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var req CreateAppointmentSeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+	if req.CustomerID == 0 && (req.Customer == nil || req.Customer.Name == "" || req.Customer.Phone == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Guest appointment requires customer name and phone",
+		})
+	}
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid start_time format. Expect RFC3339",
+		})
+	}
+
+	appt := &barberBookingModels.Appointment{
+		TenantID:   tenantID,
+		BranchID:   req.BranchID,
+		ServiceID:  req.ServiceID,
+		CustomerID: req.CustomerID,
+		StartTime:  startTime,
+		Notes:      req.Notes,
+	}
+	if req.BarberID != nil {
+		appt.BarberID = *req.BarberID
+	}
+	for _, id := range req.ServiceIDs {
+		appt.Items = append(appt.Items, barberBookingModels.AppointmentItem{ServiceID: id})
+	}
+	if req.CustomerID == 0 {
+		appt.Customer = &barberBookingModels.Customer{Name: req.Customer.Name, Phone: req.Customer.Phone}
+	}
+
+	result, err := ctrl.Service.CreateSeries(c.Context(), appt, req.Recurrence)
+	if err != nil {
+		return c.Status(seriesErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": result})
+}
+
+// GetSeries godoc
+// @Summary      ดึงข้อมูล series พร้อมนัดหมายทุกครั้ง
+// @Tags         AppointmentSeries
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        series_id  path      uint  true  "รหัส Series"
+// @Success      200        {object}  barberBookingModels.AppointmentSeries
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/appointment-series/{series_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentSeriesController) GetSeries(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	seriesID, err := helperFunc.ParseUintParam(c, "series_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid series_id"})
+	}
+
+	series, err := ctrl.Service.GetSeries(c.Context(), tenantID, seriesID)
+	if err != nil {
+		return c.Status(seriesErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": series})
+}
+
+// CancelOccurrences godoc
+// @Summary      ยกเลิกนัดหมายใน series
+// @Description  scope: this = เฉพาะครั้งนี้, following = ครั้งนี้และครั้งถัดไป, all = ทั้ง series
+// @Tags         AppointmentSeries
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                 true  "รหัส Tenant"
+// @Param        appointment_id  path      uint                 true  "รหัส Appointment"
+// @Param        body            body      CancelSeriesRequest  true  "scope และ actor"
+// @Success      200             {object}  barberBookingPort.SeriesResult
+// @Failure      400             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      500             {object}  map[string]string
+// @Router       /tenants/{tenant_id}/appointments/{appointment_id}/series/cancel [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentSeriesController) CancelOccurrences(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	apptID, err := helperFunc.ParseUintParam(c, "appointment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid appointment_id"})
+	}
+
+	var req CancelSeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid JSON body"})
+	}
+	if (req.ActorUserID == nil && req.ActorCustomerID == nil) ||
+		(req.ActorUserID != nil && req.ActorCustomerID != nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Either actor_user_id or actor_customer_id must be provided, but not both",
+		})
+	}
+
+	result, err := ctrl.Service.CancelOccurrences(c.Context(), tenantID, apptID, req.Scope, req.ActorUserID, req.ActorCustomerID)
+	if err != nil {
+		return c.Status(seriesErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": result})
+}
+
+// RescheduleOccurrences godoc
+// @Summary      เลื่อนนัดหมายใน series
+// @Description  ครั้งอื่นใน scope จะถูกเลื่อนด้วยระยะเวลาเท่ากับครั้งที่ระบุ (this, following, all)
+// @Tags         AppointmentSeries
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                     true  "รหัส Tenant"
+// @Param        appointment_id  path      uint                     true  "รหัส Appointment"
+// @Param        body            body      RescheduleSeriesRequest  true  "new_start_time, scope และ actor"
+// @Success      200             {object}  barberBookingPort.SeriesResult
+// @Failure      400             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      500             {object}  map[string]string
+// @Router       /tenants/{tenant_id}/appointments/{appointment_id}/series/reschedule [post]
+// @Security     ApiKeyAuth
+func (ctrl *AppointmentSeriesController) RescheduleOccurrences(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	apptID, err := helperFunc.ParseUintParam(c, "appointment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid appointment_id"})
+	}
+
+	var req RescheduleSeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid JSON body"})
+	}
+	newStart, err := time.Parse(time.RFC3339, req.NewStartTime)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid new_start_time format, expect RFC3339",
+		})
+	}
+	if (req.ActorUserID == nil && req.ActorCustomerID == nil) ||
+		(req.ActorUserID != nil && req.ActorCustomerID != nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Either actor_user_id or actor_customer_id must be provided, but not both",
+		})
+	}
+
+	result, err := ctrl.Service.RescheduleOccurrences(c.Context(), tenantID, apptID, newStart, req.Scope, req.ActorUserID, req.ActorCustomerID)
+	if err != nil {
+		return c.Status(seriesErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": result})
+}
@@ -0,0 +1,216 @@
+package barberBookingController
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingPort "myapp/modules/barberbooking/port"
+)
+
+type BarberScheduleController struct {
+	Service barberBookingPort.IBarberSchedule
+}
+
+func NewBarberScheduleController(service barberBookingPort.IBarberSchedule) *BarberScheduleController {
+	return &BarberScheduleController{Service: service}
+}
+
+func barberScheduleErrorStatus(msg string) int {
+	switch {
+	case strings.Contains(msg, "not found"):
+		return fiber.StatusNotFound
+	case strings.Contains(msg, "invalid"),
+		strings.Contains(msg, "is required"),
+		strings.Contains(msg, "are required"):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func canManageBarberSchedule(c *fiber.Ctx) bool {
+	roleStr, ok := c.Locals("role").(string)
+	return ok && helperFunc.IsAuthorizedRole(roleStr, RolesCanManageBarber)
+}
+
+// GetBarberSchedule godoc
+// @Summary      ดึงตารางงานของช่าง
+// @Description  ตารางรายสัปดาห์และ override ตั้งแต่วันนี้ ถ้า weekly_hours ว่าง ช่างทำงานตามเวลาทำการของสาขา
+// @Tags         WorkingHour
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        barber_id  path      uint  true  "รหัสช่าง"
+// @Success      200        {object}  barberBookingPort.BarberSchedule
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/workinghour/barbers/{barber_id} [get]
+func (ctrl *BarberScheduleController) GetBarberSchedule(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	barberID, err := helperFunc.ParseUintParam(c, "barber_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid barber_id"})
+	}
+
+	schedule, err := ctrl.Service.GetSchedule(c.Context(), tenantID, barberID)
+	if err != nil {
+		return c.Status(barberScheduleErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": schedule})
+}
+
+// ReplaceBarberWeeklyHours godoc
+// @Summary      ตั้งตารางงานรายสัปดาห์ของช่าง
+// @Description  แทนที่ทั้งชุด วันที่ไม่ได้ส่งมาถือว่าช่างไม่เข้างาน เวลางานจริงคือช่วงที่ซ้อนกับเวลาทำการของสาขา
+// @Tags         WorkingHour
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                        true  "รหัส Tenant"
+// @Param        barber_id  path      uint                                        true  "รหัสช่าง"
+// @Param        body       body      []barberBookingPort.BarberWorkingHourInput  true  "ตารางรายสัปดาห์"
+// @Success      200        {array}   barberBookingModels.BarberWorkingHour
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/workinghour/barbers/{barber_id} [put]
+// @Security     ApiKeyAuth
+func (ctrl *BarberScheduleController) ReplaceBarberWeeklyHours(c *fiber.Ctx) error {
+	if !canManageBarberSchedule(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	barberID, err := helperFunc.ParseUintParam(c, "barber_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid barber_id"})
+	}
+
+	var input []barberBookingPort.BarberWorkingHourInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	hours, err := ctrl.Service.ReplaceWeeklyHours(c.Context(), tenantID, barberID, input)
+	if err != nil {
+		return c.Status(barberScheduleErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": hours})
+}
+
+// ClearBarberWeeklyHours godoc
+// @Summary      ลบตารางงานรายสัปดาห์ของช่าง
+// @Description  ช่างกลับไปทำงานตามเวลาทำการของสาขา (override รายวันยังมีผล)
+// @Tags         WorkingHour
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        barber_id  path      uint  true  "รหัสช่าง"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/workinghour/barbers/{barber_id} [delete]
+// @Security     ApiKeyAuth
+func (ctrl *BarberScheduleController) ClearBarberWeeklyHours(c *fiber.Ctx) error {
+	if !canManageBarberSchedule(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	barberID, err := helperFunc.ParseUintParam(c, "barber_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid barber_id"})
+	}
+
+	if err := ctrl.Service.ClearWeeklyHours(c.Context(), tenantID, barberID); err != nil {
+		return c.Status(barberScheduleErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "barber weekly hours cleared"})
+}
+
+// UpsertBarberOverride godoc
+// @Summary      ตั้งเวลางานของช่างเฉพาะวัน
+// @Description  สร้างหรือแก้ไข override ของวันนั้น is_off=true คือหยุดทั้งวัน
+// @Tags         WorkingHour
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                             true  "รหัส Tenant"
+// @Param        barber_id  path      uint                                             true  "รหัสช่าง"
+// @Param        body       body      barberBookingPort.BarberWorkingDayOverrideInput  true  "override รายวัน"
+// @Success      200        {object}  barberBookingModels.BarberWorkingDayOverride
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/workinghour/barbers/{barber_id}/overrides [put]
+// @Security     ApiKeyAuth
+func (ctrl *BarberScheduleController) UpsertBarberOverride(c *fiber.Ctx) error {
+	if !canManageBarberSchedule(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	barberID, err := helperFunc.ParseUintParam(c, "barber_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid barber_id"})
+	}
+
+	var input barberBookingPort.BarberWorkingDayOverrideInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	override, err := ctrl.Service.UpsertOverride(c.Context(), tenantID, barberID, input)
+	if err != nil {
+		return c.Status(barberScheduleErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": override})
+}
+
+// DeleteBarberOverride godoc
+// @Summary      ลบเวลางานของช่างเฉพาะวัน
+// @Tags         WorkingHour
+// @Produce      json
+// @Param        tenant_id    path      uint  true  "รหัส Tenant"
+// @Param        barber_id    path      uint  true  "รหัสช่าง"
+// @Param        override_id  path      uint  true  "รหัส override"
+// @Success      200          {object}  map[string]string
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /tenants/{tenant_id}/workinghour/barbers/{barber_id}/overrides/{override_id} [delete]
+// @Security     ApiKeyAuth
+func (ctrl *BarberScheduleController) DeleteBarberOverride(c *fiber.Ctx) error {
+	if !canManageBarberSchedule(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	barberID, err := helperFunc.ParseUintParam(c, "barber_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid barber_id"})
+	}
+	overrideID, err := helperFunc.ParseUintParam(c, "override_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid override_id"})
+	}
+
+	if err := ctrl.Service.DeleteOverride(c.Context(), tenantID, barberID, overrideID); err != nil {
+		return c.Status(barberScheduleErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "barber working day override deleted"})
+}
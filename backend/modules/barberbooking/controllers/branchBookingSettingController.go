@@ -0,0 +1,102 @@
+package barberBookingController
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+)
+
+type BranchBookingSettingController struct {
+	Service barberBookingPort.IBranchBookingSetting
+}
+
+func NewBranchBookingSettingController(service barberBookingPort.IBranchBookingSetting) *BranchBookingSettingController {
+	return &BranchBookingSettingController{Service: service}
+}
+
+var RolesCanManageBookingSetting = []coreModels.RoleName{
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+}
+
+// GetSetting godoc
+// @Summary      ดึงการตั้งค่าการจองของสาขา
+// @Description  คืนค่า strategy การเลือกช่างอัตโนมัติของสาขา (LEAST_LOADED, ROUND_ROBIN, HIGHEST_RATED) และการตั้งค่าปิดนัดอัตโนมัติ
+// @Tags         BookingSetting
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        branch_id  path      uint  true  "รหัส Branch"
+// @Success      200        {object}  barberBookingModels.BranchBookingSetting
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/branches/{branch_id}/booking-settings [get]
+// @Security     ApiKeyAuth
+func (ctrl *BranchBookingSettingController) GetSetting(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	setting, err := ctrl.Service.GetSetting(c.Context(), tenantID, branchID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": setting})
+}
+
+// UpdateSetting godoc
+// @Summary      แก้ไขการตั้งค่าการจองของสาขา
+// @Description  เปลี่ยน strategy การเลือกช่างอัตโนมัติเมื่อจองแบบไม่ระบุช่าง และการปิดนัดอัตโนมัติ
+// @Description  (NO_SHOW เมื่อเลยเวลาเริ่มเกิน no_show_grace_minutes, COMPLETED เมื่อเลยเวลาจบเกิน auto_complete_grace_minutes)
+// @Description  ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+// @Tags         BookingSetting
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        branch_id  path      uint  true  "รหัส Branch"
+// @Param        body       body      barberBookingPort.UpdateBranchBookingSettingRequest  true  "ค่าที่ต้องการเปลี่ยน"
+// @Success      200        {object}  barberBookingModels.BranchBookingSetting
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/branches/{branch_id}/booking-settings [put]
+// @Security     ApiKeyAuth
+func (ctrl *BranchBookingSettingController) UpdateSetting(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageBookingSetting) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	var req barberBookingPort.UpdateBranchBookingSettingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	setting, err := ctrl.Service.UpdateSetting(c.Context(), tenantID, branchID, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": setting})
+}
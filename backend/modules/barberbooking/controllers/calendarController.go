@@ -1,6 +1,7 @@
 package barberBookingController
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,10 +20,11 @@ func NewCalendarController(calendarService barberBookingPortMix.ICalendarService
 
 // GetAvailableSlots godoc
 // @Summary Get available time slots
-// @Description ดึงช่วงเวลาที่สามารถนัดหมายได้ของสาขาในช่วงวันที่กำหนด
+// @Description ดึงช่วงเวลาที่สามารถนัดหมายได้ของช่างแต่ละคนในสาขา ในช่วงวันที่กำหนด โดยความยาว slot ตาม service ที่เลือก
 // @Tags Calendar
 // @Param tenant_id path int true "Tenant ID"
 // @Param branch_id path int true "Branch ID"
+// @Param service_id query int true "Service ID"
 // @Param start query string true "Start date (format: YYYY-MM-DD)"
 // @Param end query string true "End date (format: YYYY-MM-DD)"
 // @Produce json
@@ -55,21 +57,34 @@ func (h *CalendarController) GetAvailableSlots(c *fiber.Ctx) error {
 	}
 
 	tenantID, err := c.ParamsInt("tenant_id")
-	if err != nil || branchID <= 0 {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid branch ID")
+	if err != nil || tenantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid tenant ID")
+	}
+
+	serviceID := c.QueryInt("service_id", 0)
+	if serviceID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "service_id is required")
+	}
+
+	if endDate.Before(startDate) {
+		return fiber.NewError(fiber.StatusBadRequest, "end date must not be before start date")
 	}
 
-	slots, err := h.calendarService.GetAvailableSlots(c.Context(), uint(branchID),uint(tenantID), startDate, endDate)
+	slots, err := h.calendarService.GetAvailableSlots(c.Context(), uint(branchID), uint(tenantID), uint(serviceID), startDate, endDate)
 	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "failed to get available slots")
 	}
 
 	dtoSlots := make([]barberBookingDto.CalendarSlot, 0, len(slots))
 for _, s := range slots {
     dtoSlots = append(dtoSlots, barberBookingDto.CalendarSlot{
-        Start:  s.Start,
-        End:    s.End,
-        Status: s.Status,
+        BarberID: s.BarberID,
+        Start:    s.Start,
+        End:      s.End,
+        Status:   s.Status,
     })
 }
 return c.JSON(dtoSlots)
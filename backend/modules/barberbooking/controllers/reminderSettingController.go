@@ -0,0 +1,88 @@
+package barberBookingController
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+)
+
+type ReminderSettingController struct {
+	Service barberBookingPort.IReminderSetting
+}
+
+func NewReminderSettingController(service barberBookingPort.IReminderSetting) *ReminderSettingController {
+	return &ReminderSettingController{Service: service}
+}
+
+var RolesCanManageReminderSetting = []coreModels.RoleName{
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+}
+
+// GetSetting godoc
+// @Summary      ดึงการตั้งค่าแจ้งเตือนนัดหมายของร้าน
+// @Description  คืนค่าเวลาแจ้งเตือนลูกค้าก่อนถึงนัด (นาทีก่อนเวลาเริ่ม) ค่าเริ่มต้นคือ 24 ชั่วโมงและ 2 ชั่วโมงก่อน
+// @Tags         ReminderSetting
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Success      200        {object}  barberBookingModels.ReminderSetting
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/reminder-settings [get]
+// @Security     ApiKeyAuth
+func (ctrl *ReminderSettingController) GetSetting(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	setting, err := ctrl.Service.GetSetting(c.Context(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": setting})
+}
+
+// UpdateSetting godoc
+// @Summary      แก้ไขการตั้งค่าแจ้งเตือนนัดหมายของร้าน
+// @Description  offsets_minutes คือจำนวนนาทีก่อนเวลาเริ่มนัด (1 ถึง 10080, สูงสุด 5 ค่า) เช่น [1440, 120]
+// @Description  มีผลกับนัดที่สร้างหรือเลื่อนหลังจากนี้ ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+// @Tags         ReminderSetting
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        body       body      barberBookingPort.UpdateReminderSettingRequest  true  "ค่าที่ต้องการเปลี่ยน"
+// @Success      200        {object}  barberBookingModels.ReminderSetting
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/reminder-settings [put]
+// @Security     ApiKeyAuth
+func (ctrl *ReminderSettingController) UpdateSetting(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageReminderSetting) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var req barberBookingPort.UpdateReminderSettingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	setting, err := ctrl.Service.UpdateSetting(c.Context(), tenantID, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": setting})
+}
@@ -2,6 +2,7 @@
 package barberBookingController
 
 import (
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"net/http"
 	"strconv"
@@ -134,6 +135,8 @@ var RolesCanManageService = []coreModels.RoleName{
 // @Param        description  formData  string  false  "คำอธิบาย"
 // @Param        duration     formData  int     true   "ระยะเวลา (นาที)"
 // @Param        price        formData  number  true   "ราคา"
+// @Param        buffer_before formData int     false  "เวลาเตรียมตัวก่อนเริ่ม (นาที)"
+// @Param        buffer_after formData  int     false  "เวลาทำความสะอาดหลังจบ (นาที)"
 // @Param        file         formData  file    false  "รูปภาพประกอบบริการ (optional)"
 // @Success      201  {object}  map[string]interface{}  "คืนค่า status success พร้อมข้อมูลบริการที่ถูกสร้าง"
 // @Failure      400  {object}  map[string]string       "Invalid request body หรือ tenant ID หรือข้อมูลไม่ถูกต้อง"
@@ -208,6 +211,21 @@ func (ctrl *ServiceController) CreateService(c *fiber.Ctx) error {
 		})
 	}
 
+	bufferBefore, err := parseBufferMinutes(c, "buffer_before")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid buffer_before",
+		})
+	}
+	bufferAfter, err := parseBufferMinutes(c, "buffer_after")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid buffer_after",
+		})
+	}
+
 	// 4) รับไฟล์รูป (optional)
 	file, _ := c.FormFile("file")
 
@@ -218,6 +236,12 @@ func (ctrl *ServiceController) CreateService(c *fiber.Ctx) error {
 		Duration:    duration,
 		Price:       price,
 	}
+	if bufferBefore != nil {
+		payload.BufferBefore = *bufferBefore
+	}
+	if bufferAfter != nil {
+		payload.BufferAfter = *bufferAfter
+	}
 
 	// 6) เรียก service layer
 	created, err := ctrl.ServiceService.CreateService(c.Context(), tenantID, branchID, payload, file)
@@ -248,6 +272,8 @@ func (ctrl *ServiceController) CreateService(c *fiber.Ctx) error {
 // @Param        description  formData  string  false "คำอธิบาย"
 // @Param        duration     formData  int     true  "ระยะเวลา (นาที)"
 // @Param        price        formData  number  true  "ราคา"
+// @Param        buffer_before formData int     false "เวลาเตรียมตัวก่อนเริ่ม (นาที) ไม่ส่ง = ไม่เปลี่ยน"
+// @Param        buffer_after formData  int     false "เวลาทำความสะอาดหลังจบ (นาที) ไม่ส่ง = ไม่เปลี่ยน"
 // @Param        file         formData  file    false "รูปภาพใหม่ (optional)"
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
@@ -305,15 +331,32 @@ func (ctrl *ServiceController) UpdateService(c *fiber.Ctx) error {
 		})
 	}
 
+	bufferBefore, err := parseBufferMinutes(c, "buffer_before")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid buffer_before",
+		})
+	}
+	bufferAfter, err := parseBufferMinutes(c, "buffer_after")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid buffer_after",
+		})
+	}
+
 	// 4. รับไฟล์ (optional)
 	file, _ := c.FormFile("file")
 
 	// 5. สร้าง payload DTO
 	payload := &barberBookingPort.UpdateServiceRequest{
-		Name:        name,
-		Description: description,
-		Duration:    duration,
-		Price:       price,
+		Name:         name,
+		Description:  description,
+		Duration:     duration,
+		Price:        price,
+		BufferBefore: bufferBefore,
+		BufferAfter:  bufferAfter,
 	}
 
 	// 6. เรียก service layer
@@ -382,3 +425,16 @@ func (ctrl *ServiceController) DeleteService(c *fiber.Ctx) error {
 		"message": "Service deleted successfully",
 	})
 }
+
+// parseBufferMinutes อ่านค่า buffer (นาที) จาก form-data; ไม่ส่งมาคืน nil
+func parseBufferMinutes(c *fiber.Ctx, key string) (*int, error) {
+	raw := strings.TrimSpace(c.FormValue(key))
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return &v, nil
+}
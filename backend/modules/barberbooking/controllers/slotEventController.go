@@ -0,0 +1,139 @@
+package barberBookingController
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+)
+
+// slotEventKeepAlive ส่ง comment เปล่าเป็นระยะ กัน proxy ตัด connection ที่เงียบ และใช้ตรวจว่า client ยังอยู่
+const slotEventKeepAlive = 25 * time.Second
+
+type SlotEventController struct {
+	Stream barberBookingPort.ISlotEventStream
+}
+
+func NewSlotEventController(stream barberBookingPort.ISlotEventStream) *SlotEventController {
+	return &SlotEventController{Stream: stream}
+}
+
+// StreamBranchEvents godoc
+// @Summary      รับการเปลี่ยนแปลงของ slot ในสาขาแบบ real-time (Server-Sent Events)
+// @Description  ส่ง event เมื่อมีการสร้าง/ปล่อย/หมดอายุของ lock และเมื่อนัดถูกสร้าง/ยกเลิก/เลื่อน/เปลี่ยนสถานะ
+// @Description  ชื่อ event ตรงกับ type เช่น lock.created, appointment.cancelled ข้อมูลเป็น JSON ของ SlotEvent
+// @Tags         Calendar
+// @Produce      text/event-stream
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        branch_id  path      uint  true  "รหัสสาขา"
+// @Success      200        {object}  barberBookingPort.SlotEvent
+// @Failure      400        {object}  map[string]string
+// @Failure      401        {object}  map[string]string  "ไม่ได้เข้าสู่ระบบ (รับ token จาก cookie ได้ สำหรับ EventSource)"
+// @Failure      403        {object}  map[string]string  "token เป็นของ tenant อื่น"
+// @Failure      404        {object}  map[string]string  "ไม่พบสาขาใน tenant นี้"
+// @Router       /tenants/{tenant_id}/branches/{branch_id}/events [get]
+// @Security     ApiKeyAuth
+func (ctrl *SlotEventController) StreamBranchEvents(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+	// token ที่ผูกกับ tenant ฟังได้เฉพาะ tenant ของตัวเอง
+	role, _ := c.Locals("role").(string)
+	if tokenTenant, ok := c.Locals("tenant_id").(uint); ok && tokenTenant != tenantID &&
+		role != string(coreModels.RoleNameSaaSSuperAdmin) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "permission denied"})
+	}
+
+	return ctrl.stream(c, tenantID, branchID, nil)
+}
+
+// StreamPublicBranchEvents godoc
+// @Summary      รับการเปลี่ยนแปลงของ slot ในสาขาสำหรับหน้าจองสาธารณะ (Server-Sent Events)
+// @Description  ไม่ต้องเข้าสู่ระบบ ส่งเฉพาะช่าง เวลา และชนิดของ event (ไม่มี appointment_id, lock_id และสถานะนัด)
+// @Tags         Calendar
+// @Produce      text/event-stream
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        branch_id  path      uint  true  "รหัสสาขา"
+// @Success      200        {object}  barberBookingPort.SlotEvent
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string  "ไม่พบสาขาใน tenant นี้"
+// @Router       /tenants/{tenant_id}/branches/{branch_id}/public-events [get]
+func (ctrl *SlotEventController) StreamPublicBranchEvents(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+	return ctrl.stream(c, tenantID, branchID, barberBookingPort.SlotEvent.Public)
+}
+
+// stream ส่ง event ของสาขาเป็น SSE; transform (ถ้ามี) แปลง event ก่อนส่ง
+func (ctrl *SlotEventController) stream(
+	c *fiber.Ctx,
+	tenantID, branchID uint,
+	transform func(barberBookingPort.SlotEvent) barberBookingPort.SlotEvent,
+) error {
+	events, cancel, err := ctrl.Stream.SubscribeBranch(c.Context(), tenantID, branchID)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		keepAlive := time.NewTicker(slotEventKeepAlive)
+		defer keepAlive.Stop()
+
+		// บอก client ว่าเชื่อมต่อสำเร็จ
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if transform != nil {
+					event = transform(event)
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			// client ปิด connection → flush error
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	// "context"
 	"errors"
 	"gorm.io/gorm"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,6 +34,7 @@ var RolesCanManageUnavailability = []coreModels.RoleName{
 // CreateUnavailability godoc
 // @Summary      สร้างวันที่ไม่ว่าง
 // @Description  เพิ่ม Unavailability ระบุวันที่และเลือกได้ว่าจะปิดช่างหรือสาขา (ต้องมีสิทธิ์ SaaSSuperAdmin, Tenant, TenantAdmin หรือ BranchAdmin)
+// @Description  ระบุ start_time/end_time (HH:mm) เพื่อปิดเป็นช่วงเวลา และ repeat_weekdays เช่น "1,2,3,4,5" เพื่อทำซ้ำทุกสัปดาห์ตั้งแต่ date จนถึง repeat_until
 // @Tags         Unavailability
 // @Accept       json
 // @Produce      json
@@ -71,12 +73,18 @@ func (ctrl *UnavailabilityController) CreateUnavailability(c *fiber.Ctx) error {
 
 	created, err := ctrl.Service.CreateUnavailability(c.Context(), &input)
 	if err != nil {
-		if errors.Is(err, errors.New("unavailability already exists for this date")) {
+		if strings.Contains(err.Error(), "already exists") {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"status":  "error",
 				"message": err.Error(),
 			})
 		}
+		if strings.Contains(err.Error(), "invalid") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -0,0 +1,218 @@
+package barberBookingController
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+)
+
+type WaitlistController struct {
+	Service barberBookingPort.IWaitlist
+}
+
+func NewWaitlistController(service barberBookingPort.IWaitlist) *WaitlistController {
+	return &WaitlistController{Service: service}
+}
+
+var RolesCanManageWaitlist = []coreModels.RoleName{
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+	coreModels.RoleNameAssistantManager,
+	coreModels.RoleNameStaff,
+}
+
+func waitlistErrorStatus(msg string) int {
+	switch {
+	case strings.Contains(msg, "not found"):
+		return fiber.StatusNotFound
+	case strings.Contains(msg, "missing required fields"),
+		strings.Contains(msg, "invalid"),
+		strings.Contains(msg, "cannot"),
+		strings.Contains(msg, "is required"),
+		strings.Contains(msg, "not available"):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// JoinWaitlist godoc
+// @Summary      เข้าคิวรอ (Waitlist)
+// @Description  บันทึกลูกค้าที่ต้องการจองในช่วงเวลาที่เต็ม เมื่อมีนัดถูกยกเลิก/เลื่อน ระบบจะ hold ช่วงเวลาให้ตามลำดับ FIFO
+// @Tags         Waitlist
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                   true  "รหัส Tenant"
+// @Param        body       body      barberBookingPort.JoinWaitlistRequest  true  "ข้อมูลคิวรอ"
+// @Success      201        {object}  barberBookingModels.WaitlistEntry
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/waitlist [post]
+// @Security     ApiKeyAuth
+func (ctrl *WaitlistController) JoinWaitlist(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var req barberBookingPort.JoinWaitlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+	desiredStart, err := time.Parse(time.RFC3339, req.DesiredStart)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid desired_start format. Expect RFC3339"})
+	}
+	desiredEnd, err := time.Parse(time.RFC3339, req.DesiredEnd)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid desired_end format. Expect RFC3339"})
+	}
+
+	if req.CustomerID == 0 && (req.Customer == nil || req.Customer.Name == "" || req.Customer.Phone == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Guest waitlist requires customer name and phone",
+		})
+	}
+
+	entry, err := ctrl.Service.JoinWaitlist(c.Context(), &barberBookingModels.WaitlistEntry{
+		TenantID:     tenantID,
+		BranchID:     req.BranchID,
+		CustomerID:   req.CustomerID,
+		BarberID:     req.BarberID,
+		ServiceID:    req.ServiceID,
+		DesiredStart: desiredStart,
+		DesiredEnd:   desiredEnd,
+		Notes:        req.Notes,
+	}, req.Customer)
+	if err != nil {
+		return c.Status(waitlistErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": entry})
+}
+
+// ListWaitlist godoc
+// @Summary      ดูรายการคิวรอของสาขา
+// @Description  เรียงตามลำดับ FIFO; ค่าเริ่มต้นแสดงเฉพาะ WAITING และ OFFERED
+// @Tags         Waitlist
+// @Produce      json
+// @Param        tenant_id  path      uint    true   "รหัส Tenant"
+// @Param        branch_id  path      uint    true   "รหัส Branch"
+// @Param        status     query     string  false  "กรองสถานะ คั่นด้วย comma เช่น WAITING,OFFERED"
+// @Success      200        {array}   barberBookingModels.WaitlistEntry
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/branches/{branch_id}/waitlist [get]
+// @Security     ApiKeyAuth
+func (ctrl *WaitlistController) ListWaitlist(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWaitlist) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	var status []barberBookingModels.WaitlistStatus
+	if raw := c.Query("status"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			status = append(status, barberBookingModels.WaitlistStatus(strings.ToUpper(strings.TrimSpace(s))))
+		}
+	}
+
+	entries, err := ctrl.Service.ListWaitlist(c.Context(), tenantID, branchID, status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": entries})
+}
+
+// PromoteWaitlistEntry godoc
+// @Summary      เปลี่ยนคิวรอเป็นนัดหมาย
+// @Description  สร้าง Appointment จาก entry โดยใช้เวลา/ช่างจาก hold ถ้าไม่ได้ระบุ แล้วปล่อย hold
+// @Tags         Waitlist
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                      true   "รหัส Tenant"
+// @Param        entry_id   path      uint                                      true   "รหัส Waitlist Entry"
+// @Param        body       body      barberBookingPort.PromoteWaitlistRequest  false  "เวลาและช่าง (ถ้าไม่ใช้ของ hold)"
+// @Success      200        {object}  barberBookingModels.WaitlistEntry
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/waitlist/{entry_id}/promote [post]
+// @Security     ApiKeyAuth
+func (ctrl *WaitlistController) PromoteWaitlistEntry(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWaitlist) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	entryID, err := helperFunc.ParseUintParam(c, "entry_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid entry_id"})
+	}
+
+	var req barberBookingPort.PromoteWaitlistRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+		}
+	}
+
+	entry, err := ctrl.Service.PromoteWaitlistEntry(c.Context(), tenantID, entryID, req)
+	if err != nil {
+		return c.Status(waitlistErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": entry})
+}
+
+// CancelWaitlistEntry godoc
+// @Summary      ยกเลิกคิวรอ
+// @Tags         Waitlist
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        entry_id   path      uint  true  "รหัส Waitlist Entry"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /tenants/{tenant_id}/waitlist/{entry_id} [delete]
+// @Security     ApiKeyAuth
+func (ctrl *WaitlistController) CancelWaitlistEntry(c *fiber.Ctx) error {
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	entryID, err := helperFunc.ParseUintParam(c, "entry_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid entry_id"})
+	}
+
+	if err := ctrl.Service.CancelWaitlistEntry(c.Context(), tenantID, entryID); err != nil {
+		return c.Status(waitlistErrorStatus(err.Error())).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "waitlist entry cancelled"})
+}
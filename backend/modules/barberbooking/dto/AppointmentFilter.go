@@ -19,11 +19,19 @@ type AppointmentFilter struct {
 }
 
 
+type AppointmentItemDTO struct {
+	ServiceID uint    `json:"service_id"`
+	Position  int     `json:"position"`
+	Duration  int     `json:"duration"`
+	Price     float64 `json:"price"`
+}
+
 type AppointmentResponseDTO struct {
 	ID         uint      `json:"id"`
 	TenantID   uint      `json:"tenant_id"`
 	BranchID   uint      `json:"branch_id"`
 	ServiceID  uint      `json:"service_id"`
+	Items      []AppointmentItemDTO `json:"items"`
 	BarberID   uint      `json:"barber_id"`
 	CustomerID uint      `json:"customer_id"`
 	StartTime  time.Time `json:"start_time"`
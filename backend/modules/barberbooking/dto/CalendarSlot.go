@@ -5,7 +5,8 @@ package barberBookingDto
 import "time"
 
 type CalendarSlot struct {
-	Start  time.Time `json:"start" example:"2025-07-01T09:00:00Z"`
-	End    time.Time `json:"end" example:"2025-07-01T09:30:00Z"`
-	Status string    `json:"status" example:"open"` // "open" or "closed"
+	BarberID uint      `json:"barber_id" example:"3"`
+	Start    time.Time `json:"start" example:"2025-07-01T09:00:00Z"`
+	End      time.Time `json:"end" example:"2025-07-01T09:30:00Z"`
+	Status   string    `json:"status" example:"open"` // "open" or "closed"
 }
@@ -27,6 +27,7 @@ type Appointment struct {
 	
 	ServiceID  uint              `gorm:"not null" json:"service_id"`
 	Service    Service           `gorm:"foreignKey:ServiceID" json:"service,omitempty"`
+	Items      []AppointmentItem `gorm:"foreignKey:AppointmentID" json:"items,omitempty"` // เรียงตาม Position
 
 	BarberID   uint             `gorm:"index" json:"barber_id,omitempty"`
 	Barber     Barber      		`json:"barber"`
@@ -34,13 +35,30 @@ type Appointment struct {
 	CustomerID uint              `gorm:"not null;index" json:"customer_id"`
 	Customer   *Customer 		 `gorm:"-" json:"customer,omitempty"`
 
+	SeriesID   *uint             `gorm:"index" json:"series_id,omitempty"` // นัดที่สร้างจาก AppointmentSeries
+	LockID     *uint             `gorm:"index" json:"lock_id,omitempty"`   // lock ที่ลูกค้าถือไว้และถูกใช้จองนัดนี้
+
 	UserID     *uint             `gorm:"index" json:"user_id,omitempty"` 
 	TenantID   uint 			 `gorm:"not null;index" json:"tenant_id"`
 	StartTime  time.Time         `gorm:"not null" json:"start_time"`
 	EndTime    time.Time         `gorm:"not null" json:"end_time"`
+	// ช่วงที่ช่างไม่ว่างจริง = StartTime-BufferBefore ถึง EndTime+BufferAfter ใช้ตรวจ overlap
+	BlockStart time.Time         `gorm:"index" json:"-"`
+	BlockEnd   time.Time         `gorm:"index" json:"-"`
 	Status     AppointmentStatus `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
 	Notes      string            `gorm:"type:text" json:"notes,omitempty"`
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt    `gorm:"index" json:"deleted_at,omitempty"`
 }
+
+// BeforeSave ถ้ายังไม่ได้กำหนดช่วง block ให้ใช้เวลานัดตรงๆ (ไม่มี buffer)
+func (a *Appointment) BeforeSave(tx *gorm.DB) error {
+	if a.BlockStart.IsZero() {
+		a.BlockStart = a.StartTime
+	}
+	if a.BlockEnd.IsZero() {
+		a.BlockEnd = a.EndTime
+	}
+	return nil
+}
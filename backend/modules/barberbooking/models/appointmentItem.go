@@ -0,0 +1,21 @@
+package barberBookingModels
+
+import (
+	"time"
+)
+
+// AppointmentItem บริการแต่ละรายการภายในนัดหมายเดียว (เช่น ตัดผม + แต่งหนวด + สระ)
+// เก็บ Duration และ Price ณ เวลาจอง เพื่อไม่ให้เปลี่ยนตามราคาบริการในภายหลัง
+type AppointmentItem struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	AppointmentID uint    `gorm:"not null;index" json:"appointment_id"`
+	ServiceID     uint    `gorm:"not null;index" json:"service_id"`
+	Service       Service `gorm:"foreignKey:ServiceID" json:"service,omitempty"`
+
+	Position int     `gorm:"not null;default:0" json:"position"` // ลำดับการให้บริการ เริ่มที่ 0
+	Duration int     `gorm:"not null" json:"duration"`           // นาที
+	Price    float64 `gorm:"not null" json:"price"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,6 +1,8 @@
 package barberBookingModels
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type AppointmentLock struct {
@@ -13,10 +15,28 @@ type AppointmentLock struct {
 	StartTime  time.Time `gorm:"not null;index" json:"start_time"`
 	EndTime    time.Time `gorm:"not null;index" json:"end_time"`
 
+	// ช่วงที่ช่างไม่ว่างจริง (รวม buffer ของ service)
+	BlockStart time.Time `gorm:"index" json:"-"`
+	BlockEnd   time.Time `gorm:"index" json:"-"`
+
 	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
 
 	IsActive   bool      `gorm:"default:true" json:"is_active"`
 
+	// นัดที่สร้างจาก lock นี้ (lock ถูกใช้แล้ว)
+	AppointmentID *uint  `gorm:"index" json:"appointment_id,omitempty"`
+
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
+
+// BeforeSave ถ้ายังไม่ได้กำหนดช่วง block ให้ใช้เวลาของ lock ตรงๆ
+func (l *AppointmentLock) BeforeSave(tx *gorm.DB) error {
+	if l.BlockStart.IsZero() {
+		l.BlockStart = l.StartTime
+	}
+	if l.BlockEnd.IsZero() {
+		l.BlockEnd = l.EndTime
+	}
+	return nil
+}
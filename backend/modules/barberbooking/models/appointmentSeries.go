@@ -0,0 +1,37 @@
+package barberBookingModels
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecurrenceFrequency ความถี่ของนัดหมายแบบประจำ (อิงตาม FREQ ของ RRULE)
+type RecurrenceFrequency string
+
+const (
+	FrequencyWeekly  RecurrenceFrequency = "WEEKLY"
+	FrequencyMonthly RecurrenceFrequency = "MONTHLY"
+)
+
+// AppointmentSeries กฎการนัดซ้ำ เช่น "ทุก 3 สัปดาห์ วันเสาร์ 10:00 ช่างคนเดิม"
+// แต่ละครั้งจะถูกสร้างเป็นแถว Appointment จริงที่อ้างถึง series นี้ผ่าน SeriesID
+type AppointmentSeries struct {
+	ID         uint                `gorm:"primaryKey" json:"id"`
+	TenantID   uint                `gorm:"not null;index" json:"tenant_id"`
+	BranchID   uint                `gorm:"not null;index" json:"branch_id"`
+	CustomerID uint                `gorm:"not null;index" json:"customer_id"`
+	BarberID   uint                `gorm:"index" json:"barber_id"`
+	ServiceID  uint                `gorm:"not null" json:"service_id"`
+	Frequency  RecurrenceFrequency `gorm:"type:varchar(10);not null" json:"frequency"`
+	Interval   int                 `gorm:"column:repeat_interval;not null;default:1" json:"interval"` // ทุก N สัปดาห์/เดือน
+	Count      *int                `json:"count,omitempty"`                                           // จำนวนครั้งทั้งหมด
+	Until      *time.Time          `json:"until,omitempty"`                                           // วันสิ้นสุด (รวมวันนั้น)
+	StartTime  time.Time           `gorm:"not null" json:"start_time"`                                // เวลาของครั้งแรก
+	Notes      string              `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt      `gorm:"index" json:"deleted_at,omitempty"`
+
+	Appointments []Appointment `gorm:"foreignKey:SeriesID" json:"appointments,omitempty"`
+}
@@ -11,6 +11,7 @@ type AppointmentStatusLog struct {
 
 	ChangedByUserID     *uint     `gorm:"index"`                       // Loose FK → ไม่ preload
 	ChangedByCustomerID *uint     `gorm:"index"`                       // Loose FK → ไม่ preload
+	ChangedBySystem     bool      `gorm:"not null;default:false"`      // job อัตโนมัติ (auto no-show/complete)
 	ChangedAt           time.Time `gorm:"autoCreateTime"`
 
 	Notes               string    `gorm:"type:text"`
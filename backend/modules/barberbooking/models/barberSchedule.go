@@ -0,0 +1,35 @@
+package barberBookingModels
+
+import (
+	"time"
+
+	helperFunc "myapp/modules/barberbooking"
+)
+
+// BarberWorkingHour ตารางงานรายสัปดาห์ของช่าง ใช้บีบเวลาทำการของสาขาให้แคบลง
+// ช่างที่ไม่มีแถวใดเลยถือว่าทำงานตามเวลาทำการของสาขา
+// ถ้ามีตารางแล้ว วันที่ไม่มีแถว (หรือ IsOff) ถือว่าช่างไม่เข้างาน
+type BarberWorkingHour struct {
+	ID        uint                `gorm:"primaryKey" json:"id"`
+	TenantID  uint                `gorm:"not null;index" json:"tenant_id"`
+	BarberID  uint                `gorm:"not null;uniqueIndex:idx_bwh_barber_weekday" json:"barber_id"`
+	Weekday   int                 `gorm:"not null;uniqueIndex:idx_bwh_barber_weekday" json:"weekday"` // 0=Sunday
+	StartTime helperFunc.TimeOnly `gorm:"type:time;not null" json:"start_time"`
+	EndTime   helperFunc.TimeOnly `gorm:"type:time;not null" json:"end_time"`
+	IsOff     bool                `gorm:"not null;default:false" json:"is_off"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// BarberWorkingDayOverride เปลี่ยนเวลางานของช่างเฉพาะวัน มีลำดับความสำคัญเหนือตารางรายสัปดาห์
+type BarberWorkingDayOverride struct {
+	ID        uint                `gorm:"primaryKey" json:"id"`
+	TenantID  uint                `gorm:"not null;index" json:"tenant_id"`
+	BarberID  uint                `gorm:"not null;uniqueIndex:idx_bwdo_barber_date" json:"barber_id"`
+	WorkDate  time.Time           `gorm:"type:date;not null;uniqueIndex:idx_bwdo_barber_date" json:"work_date"`
+	StartTime helperFunc.TimeOnly `gorm:"type:time;not null" json:"start_time"`
+	EndTime   helperFunc.TimeOnly `gorm:"type:time;not null" json:"end_time"`
+	IsOff     bool                `gorm:"not null;default:false" json:"is_off"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package barberBookingModels
+
+import (
+	"time"
+)
+
+// AssignmentStrategy วิธีเลือกช่างอัตโนมัติเมื่อลูกค้าจองแบบ "ช่างคนไหนก็ได้"
+type AssignmentStrategy string
+
+const (
+	AssignLeastLoaded  AssignmentStrategy = "LEAST_LOADED"  // ช่างที่มีงานน้อยที่สุดในวันนั้น
+	AssignRoundRobin   AssignmentStrategy = "ROUND_ROBIN"   // วนตามลำดับช่าง
+	AssignHighestRated AssignmentStrategy = "HIGHEST_RATED" // ช่างที่คะแนนรีวิวเฉลี่ยสูงสุด
+)
+
+// BranchBookingSetting การตั้งค่าการจองคิวรายสาขา
+type BranchBookingSetting struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	TenantID uint `gorm:"not null;index" json:"tenant_id"`
+	BranchID uint `gorm:"not null;uniqueIndex" json:"branch_id"`
+
+	AssignmentStrategy   AssignmentStrategy `gorm:"type:varchar(20);not null;default:'LEAST_LOADED'" json:"assignment_strategy"`
+	LastAssignedBarberID *uint              `json:"last_assigned_barber_id,omitempty"` // ใช้กับ ROUND_ROBIN
+
+	// ปิดนัดอัตโนมัติ: CONFIRMED ที่เลยเวลาเริ่มเกิน grace → NO_SHOW, IN_SERVICE ที่เลยเวลาจบเกิน grace → COMPLETED
+	AutoNoShowEnabled        bool `gorm:"not null;default:true" json:"auto_no_show_enabled"`
+	NoShowGraceMinutes       int  `gorm:"not null;default:15" json:"no_show_grace_minutes"`
+	AutoCompleteEnabled      bool `gorm:"not null;default:true" json:"auto_complete_enabled"`
+	AutoCompleteGraceMinutes int  `gorm:"not null;default:30" json:"auto_complete_grace_minutes"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
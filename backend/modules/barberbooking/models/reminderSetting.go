@@ -0,0 +1,17 @@
+package barberBookingModels
+
+import (
+	"time"
+)
+
+// ReminderSetting การแจ้งเตือนลูกค้าก่อนถึงเวลานัด (รายร้าน)
+// OffsetsMinutes คือจำนวนนาทีก่อนเวลาเริ่มนัด เช่น [1440, 120] = 24 ชั่วโมงและ 2 ชั่วโมงก่อน
+type ReminderSetting struct {
+	ID             uint  `gorm:"primaryKey" json:"id"`
+	TenantID       uint  `gorm:"not null;uniqueIndex" json:"tenant_id"`
+	Enabled        bool  `gorm:"not null;default:true" json:"enabled"`
+	OffsetsMinutes []int `gorm:"serializer:json;type:jsonb;not null" json:"offsets_minutes"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
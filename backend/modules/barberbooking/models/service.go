@@ -16,6 +16,8 @@ type Service struct {
 	Description     string      	`gorm:"type:varchar(100);not null" json:"description"`
 	Duration    	int            `gorm:"not null" json:"duration"`   
 	Price       	float64        `gorm:"not null" json:"price"`  
+	BufferBefore	int            `gorm:"not null;default:0" json:"buffer_before"` // นาทีเตรียมตัวก่อนเริ่ม (ไม่นับในเวลาที่ลูกค้าเห็น)
+	BufferAfter 	int            `gorm:"not null;default:0" json:"buffer_after"`  // นาทีทำความสะอาดหลังจบ
 	Img_path  		string 			`gorm:"column:img_path" json:"Img_path,omitempty"`
     Img_name 		string 			`gorm:"column:img_name" json:"Img_name,omitempty"`
 
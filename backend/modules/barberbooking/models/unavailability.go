@@ -2,6 +2,7 @@ package barberBookingModels
 import (
 	"time"
 	"gorm.io/gorm"
+	helperFunc "myapp/modules/barberbooking"
 )
 
 
@@ -13,6 +14,15 @@ import (
 	Date      time.Time      `gorm:"not null;index" json:"date"`
 	Reason    string         `gorm:"type:text" json:"reason,omitempty"`
 
+	// ช่วงเวลาในวันนั้น (ไม่ระบุ = ทั้งวัน) เช่น พักกลางวัน 12:00–13:00
+	StartTime *helperFunc.TimeOnly `gorm:"type:time" json:"start_time,omitempty"`
+	EndTime   *helperFunc.TimeOnly `gorm:"type:time" json:"end_time,omitempty"`
+
+	// ทำซ้ำทุกสัปดาห์ตามวันที่ระบุ (0=Sunday) คั่นด้วย comma เช่น "1,2,3,4,5"
+	// Date คือวันเริ่มต้นของการทำซ้ำ, RepeatUntil ว่าง = ไม่มีวันสิ้นสุด
+	RepeatWeekdays string     `gorm:"type:varchar(20)" json:"repeat_weekdays,omitempty"`
+	RepeatUntil    *time.Time `gorm:"type:date" json:"repeat_until,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -0,0 +1,43 @@
+package barberBookingModels
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "WAITING"   // รอคิวว่าง
+	WaitlistOffered   WaitlistStatus = "OFFERED"   // มีช่วงเวลาว่างและถูก hold ไว้ให้แล้ว
+	WaitlistPromoted  WaitlistStatus = "PROMOTED"  // ถูกเปลี่ยนเป็นนัดหมายจริงแล้ว
+	WaitlistCancelled WaitlistStatus = "CANCELLED" // ลูกค้า/พนักงานยกเลิก
+	WaitlistExpired   WaitlistStatus = "EXPIRED"   // hold หมดอายุก่อนยืนยัน ช่วงเวลาถูกเสนอให้คิวถัดไป
+)
+
+// WaitlistEntry ลูกค้าที่รอคิวในช่วงเวลาที่ต้องการ (สาขาเต็มหรือช่างที่ต้องการไม่ว่าง)
+// เมื่อมีนัดถูกยกเลิก/เลื่อนออก ช่วงเวลาที่ว่างจะถูกจับคู่กับ entry ตามลำดับ FIFO
+type WaitlistEntry struct {
+	ID         uint  `gorm:"primaryKey" json:"id"`
+	TenantID   uint  `gorm:"not null;index" json:"tenant_id"`
+	BranchID   uint  `gorm:"not null;index" json:"branch_id"`
+	CustomerID uint  `gorm:"not null;index" json:"customer_id"`
+	BarberID   *uint `gorm:"index" json:"barber_id,omitempty"` // nil = ช่างคนไหนก็ได้
+	ServiceID  uint  `gorm:"not null" json:"service_id"`
+
+	DesiredStart time.Time `gorm:"not null" json:"desired_start"`
+	DesiredEnd   time.Time `gorm:"not null" json:"desired_end"`
+
+	Status        WaitlistStatus `gorm:"type:varchar(20);not null;default:'WAITING';index" json:"status"`
+	LockID        *uint          `json:"lock_id,omitempty"` // hold ที่สร้างให้ตอนจับคู่ได้
+	OfferedAt     *time.Time     `json:"offered_at,omitempty"`
+	AppointmentID *uint          `json:"appointment_id,omitempty"` // นัดที่สร้างตอน promote
+
+	Notes     string         `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	Lock *AppointmentLock `gorm:"foreignKey:LockID" json:"lock,omitempty"`
+}
@@ -0,0 +1,14 @@
+package barberBookingPort
+
+import (
+	"context"
+	"time"
+)
+
+type IAppointmentAutoStatus interface {
+	// MarkNoShows เปลี่ยน CONFIRMED ที่เลยเวลาเริ่มเกิน grace ของสาขา (และยังไม่ check-in) เป็น NO_SHOW
+	MarkNoShows(ctx context.Context, now time.Time) (int, error)
+
+	// CompleteFinished เปลี่ยน IN_SERVICE ที่เลยเวลาจบเกิน grace ของสาขาเป็น COMPLETED
+	CompleteFinished(ctx context.Context, now time.Time) (int, error)
+}
@@ -13,12 +13,16 @@ type AppointmentLockInput struct {
 	CustomerID  uint      `json:"customer_id"`
 	StartTime   time.Time `json:"start_time"`
 	EndTime     time.Time `json:"end_time"`
+	ServiceID   uint      `json:"service_id,omitempty"` // ถ้าระบุ จะ block buffer ก่อน/หลังของ service ด้วย
 }
 
 type IAppointmentLock interface {
 	// 🔒 สร้าง lock ชั่วคราว
 	CreateAppointmentLock(ctx context.Context, input AppointmentLockInput) (*barberBookingModels.AppointmentLock, error)
 
+	// 💓 ต่ออายุ lock ที่ลูกค้ายังถืออยู่ (heartbeat) ไม่เกินเวลาถือสูงสุด
+	ExtendAppointmentLock(ctx context.Context, lockID, customerID uint) (*barberBookingModels.AppointmentLock, error)
+
 	// 🧹 ปล่อย lock (เช่น ปิด modal)
 	ReleaseAppointmentLock(ctx context.Context, lockID uint) error
 
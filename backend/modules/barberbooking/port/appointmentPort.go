@@ -15,7 +15,7 @@ type IAppointment interface {
 	ListAppointments(ctx context.Context, filter barberBookingDto.AppointmentFilter) ([]barberBookingModels.Appointment, error)
 	ListAppointmentsResponse(ctx context.Context, filter barberBookingDto.AppointmentFilter) ([]AppointmentResponse, error)
 	CancelAppointment(ctx context.Context,appointmentID uint,actorUserID *uint,actorCustomerID *uint,) error
-	RescheduleAppointment( ctx context.Context,appointmentID uint,newStartTime time.Time,actorUserID *uint, actorCustomerID *uint,) error
+	RescheduleAppointment( ctx context.Context,tenantID uint,appointmentID uint,newStartTime time.Time,actorUserID *uint, actorCustomerID *uint,) error
 	CalculateAppointmentEndTime(ctx context.Context, serviceID uint, startTime time.Time) (time.Time, error)
 	DeleteAppointment(ctx context.Context, appointmentID uint) error
 	GetUpcomingAppointmentsByCustomer(ctx context.Context, customerID uint) (*barberBookingModels.Appointment, error)
@@ -32,6 +32,16 @@ type IAppointment interface {
 		ctx context.Context,
 		phone string,
 	) ([]AppointmentBrief, error)
+
+	// เปลี่ยนสถานะตามตาราง transition (confirm, start service, complete, no-show) พร้อมตรวจ role ของผู้ทำรายการ
+	TransitionStatus(ctx context.Context, tenantID, appointmentID uint, to barberBookingModels.AppointmentStatus, input StatusTransitionInput) (*barberBookingModels.Appointment, error)
+}
+
+// StatusTransitionInput ผู้ทำรายการ; ActorRole ใช้ตรวจสิทธิ์ของแต่ละ transition
+type StatusTransitionInput struct {
+	ActorUserID *uint  `json:"actor_user_id,omitempty"`
+	ActorRole   string `json:"-"`
+	Notes       string `json:"notes,omitempty"`
 }
 
 // CreateAppointmentRequest is the payload for creating a new appointment
@@ -53,11 +63,13 @@ type CustomerInput struct {
 type CreateAppointmentRequest struct {
     BranchID   uint   `json:"branch_id" example:"1"`
     ServiceID  uint   `json:"service_id" example:"2"`
+    ServiceIDs []uint `json:"service_ids,omitempty" example:"2,5"` // หลายบริการตามลำดับ (ถ้ามีจะใช้แทน service_id)
     BarberID   *uint  `json:"barber_id,omitempty" example:"3"`
     CustomerID uint   `json:"customer_id" example:"4"`
     StartTime  string `json:"start_time" example:"2025-05-30T10:00:00Z"`
     Notes      string `json:"notes,omitempty" example:"Preferred barber: John"`
     Customer   *CustomerInput  `json:"customer,omitempty"`
+    LockID     *uint  `json:"lock_id,omitempty" example:"12"` // lock ที่ลูกค้าคนเดียวกันถือไว้ (appointments-lock)
 }
 
 type AppointmentResponse struct {
@@ -101,6 +113,14 @@ type ServiceBrief struct {
 	Price       int     `json:"price"`
 }
 
+type AppointmentItemBrief struct {
+	ServiceID uint   `json:"service_id"`
+	Name      string `json:"name"`
+	Position  int    `json:"position"`
+	Duration  int    `json:"duration"`
+	Price     int    `json:"price"`
+}
+
 type BarberBrief struct {
 	Username string `json:"username"`
 }
@@ -123,6 +143,7 @@ type AppointmentBrief struct {
 	BranchID   uint          `json:"branch_id"`
 	ServiceID  uint          `json:"service_id"`
 	Service    ServiceBrief  `json:"service"`
+	Items      []AppointmentItemBrief `json:"items"`
 	BarberID   uint          `json:"barber_id"`
 	Barber     BarberBrief   `json:"barber"`
 	CustomerID uint          `json:"customer_id"`
@@ -0,0 +1,50 @@
+package barberBookingPort
+
+import (
+	"context"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+)
+
+// SeriesScope ขอบเขตของการยกเลิก/เลื่อนนัดที่อยู่ใน series
+type SeriesScope string
+
+const (
+	ScopeThisOccurrence SeriesScope = "this"      // เฉพาะครั้งนี้
+	ScopeThisAndAfter   SeriesScope = "following" // ครั้งนี้และครั้งถัดไปทั้งหมด
+	ScopeEntireSeries   SeriesScope = "all"       // ทั้ง series
+)
+
+// RecurrenceRule กฎการนัดซ้ำแบบ RRULE (FREQ, INTERVAL, COUNT/UNTIL)
+type RecurrenceRule struct {
+	Frequency barberBookingModels.RecurrenceFrequency `json:"frequency" example:"WEEKLY"`
+	Interval  int                                     `json:"interval" example:"3"`
+	Count     *int                                    `json:"count,omitempty" example:"6"`
+	Until     *time.Time                              `json:"until,omitempty" example:"2025-12-31T23:59:59Z"`
+}
+
+// SeriesOccurrenceResult ผลของแต่ละครั้งใน series; ถ้าไม่สำเร็จ Error จะบอกเหตุผล
+type SeriesOccurrenceResult struct {
+	StartTime     time.Time `json:"start_time"`
+	AppointmentID *uint     `json:"appointment_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+type SeriesResult struct {
+	SeriesID    uint                     `json:"series_id"`
+	Occurrences []SeriesOccurrenceResult `json:"occurrences"`
+}
+
+type IAppointmentSeries interface {
+	// สร้าง series และนัดหมายทุกครั้งตามกฎ ครั้งที่ชนจะถูกรายงานแยก ไม่ทำให้ทั้ง series ล้ม
+	CreateSeries(ctx context.Context, input *barberBookingModels.Appointment, rule RecurrenceRule) (*SeriesResult, error)
+
+	GetSeries(ctx context.Context, tenantID, seriesID uint) (*barberBookingModels.AppointmentSeries, error)
+
+	// ยกเลิกนัดตามขอบเขต โดยใช้ CancelAppointment กับแต่ละครั้ง
+	CancelOccurrences(ctx context.Context, tenantID, appointmentID uint, scope SeriesScope, actorUserID *uint, actorCustomerID *uint) (*SeriesResult, error)
+
+	// เลื่อนนัดตามขอบเขต ครั้งอื่นใน scope จะถูกเลื่อนด้วยระยะเวลาเท่ากัน ผ่าน RescheduleAppointment
+	RescheduleOccurrences(ctx context.Context, tenantID, appointmentID uint, newStartTime time.Time, scope SeriesScope, actorUserID *uint, actorCustomerID *uint) (*SeriesResult, error)
+}
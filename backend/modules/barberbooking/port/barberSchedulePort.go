@@ -0,0 +1,44 @@
+package barberBookingPort
+
+import (
+	"context"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+)
+
+type BarberWorkingHourInput struct {
+	Weekday   int    `json:"weekday" example:"2"` // 0=Sunday … 6=Saturday
+	StartTime string `json:"start_time" example:"13:00"`
+	EndTime   string `json:"end_time" example:"18:00"`
+	IsOff     bool   `json:"is_off"`
+}
+
+type BarberWorkingDayOverrideInput struct {
+	WorkDate  string `json:"work_date" example:"2025-06-03"`
+	StartTime string `json:"start_time" example:"10:00"`
+	EndTime   string `json:"end_time" example:"14:00"`
+	IsOff     bool   `json:"is_off"`
+}
+
+// BarberSchedule ตารางงานของช่าง; WeeklyHours ว่าง = ทำงานตามเวลาทำการของสาขา
+type BarberSchedule struct {
+	BarberID    uint                                           `json:"barber_id"`
+	WeeklyHours []barberBookingModels.BarberWorkingHour        `json:"weekly_hours"`
+	Overrides   []barberBookingModels.BarberWorkingDayOverride `json:"overrides"`
+}
+
+type IBarberSchedule interface {
+	// ตารางรายสัปดาห์ + override ตั้งแต่วันนี้เป็นต้นไป
+	GetSchedule(ctx context.Context, tenantID, barberID uint) (*BarberSchedule, error)
+
+	// แทนที่ตารางรายสัปดาห์ทั้งชุด วันที่ไม่ได้ส่งมาถือว่าช่างไม่เข้างาน
+	ReplaceWeeklyHours(ctx context.Context, tenantID, barberID uint, input []BarberWorkingHourInput) ([]barberBookingModels.BarberWorkingHour, error)
+
+	// ลบตารางรายสัปดาห์ ช่างกลับไปทำงานตามเวลาทำการของสาขา
+	ClearWeeklyHours(ctx context.Context, tenantID, barberID uint) error
+
+	// สร้างหรือแก้ไข override ของวันนั้น (barber + work_date ไม่ซ้ำ)
+	UpsertOverride(ctx context.Context, tenantID, barberID uint, input BarberWorkingDayOverrideInput) (*barberBookingModels.BarberWorkingDayOverride, error)
+
+	DeleteOverride(ctx context.Context, tenantID, barberID, overrideID uint) error
+}
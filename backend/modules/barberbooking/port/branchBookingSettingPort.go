@@ -0,0 +1,23 @@
+package barberBookingPort
+
+import (
+	"context"
+	barberBookingModels "myapp/modules/barberbooking/models"
+)
+
+// UpdateBranchBookingSettingRequest ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+type UpdateBranchBookingSettingRequest struct {
+	AssignmentStrategy       barberBookingModels.AssignmentStrategy `json:"assignment_strategy,omitempty" example:"ROUND_ROBIN"`
+	AutoNoShowEnabled        *bool                                  `json:"auto_no_show_enabled,omitempty" example:"true"`
+	NoShowGraceMinutes       *int                                   `json:"no_show_grace_minutes,omitempty" example:"15"`
+	AutoCompleteEnabled      *bool                                  `json:"auto_complete_enabled,omitempty" example:"true"`
+	AutoCompleteGraceMinutes *int                                   `json:"auto_complete_grace_minutes,omitempty" example:"30"`
+}
+
+type IBranchBookingSetting interface {
+	// ดึง setting ของสาขา (ถ้ายังไม่เคยตั้งจะได้ค่า default)
+	GetSetting(ctx context.Context, tenantID, branchID uint) (*barberBookingModels.BranchBookingSetting, error)
+
+	// ตั้งค่า strategy การเลือกช่างอัตโนมัติ และการปิดนัดอัตโนมัติ (no-show/complete)
+	UpdateSetting(ctx context.Context, tenantID, branchID uint, input UpdateBranchBookingSettingRequest) (*barberBookingModels.BranchBookingSetting, error)
+}
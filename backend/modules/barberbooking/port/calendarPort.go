@@ -12,6 +12,7 @@ type ICalendarService interface {
 		ctx context.Context,
 		branchID uint,
 		tenantID uint,
+		serviceID uint,
 		startDate time.Time,
 		endDate time.Time,
 	) ([]barberBookingDto.CalendarSlot, error)
@@ -0,0 +1,20 @@
+package barberBookingPort
+
+import (
+	"context"
+	barberBookingModels "myapp/modules/barberbooking/models"
+)
+
+// UpdateReminderSettingRequest ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+type UpdateReminderSettingRequest struct {
+	Enabled        *bool `json:"enabled,omitempty" example:"true"`
+	OffsetsMinutes []int `json:"offsets_minutes,omitempty" example:"1440,120"`
+}
+
+type IReminderSetting interface {
+	// ดึง setting ของร้าน (ถ้ายังไม่เคยตั้งจะได้ค่า default: 24 ชั่วโมงและ 2 ชั่วโมงก่อนนัด)
+	GetSetting(ctx context.Context, tenantID uint) (*barberBookingModels.ReminderSetting, error)
+
+	// เปลี่ยนเวลาแจ้งเตือน มีผลกับนัดที่สร้างหรือเลื่อนหลังจากนี้
+	UpdateSetting(ctx context.Context, tenantID uint, input UpdateReminderSettingRequest) (*barberBookingModels.ReminderSetting, error)
+}
@@ -7,17 +7,21 @@ import (
 )
 
 type CreateServiceRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Duration    int     `json:"duration"`
-	Price       float64 `json:"price"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Duration     int     `json:"duration"`
+	Price        float64 `json:"price"`
+	BufferBefore int     `json:"buffer_before"`
+	BufferAfter  int     `json:"buffer_after"`
 }
 
 type UpdateServiceRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Duration    int     `json:"duration"`
-	Price       float64 `json:"price"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Duration     int     `json:"duration"`
+	Price        float64 `json:"price"`
+	BufferBefore *int    `json:"buffer_before,omitempty"` // nil = ไม่เปลี่ยน
+	BufferAfter  *int    `json:"buffer_after,omitempty"`
 }
 
 type IServiceService interface {
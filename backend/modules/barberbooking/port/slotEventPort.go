@@ -0,0 +1,58 @@
+package barberBookingPort
+
+import (
+	"context"
+	"time"
+)
+
+type SlotEventType string
+
+const (
+	SlotEventLockCreated            SlotEventType = "lock.created"
+	SlotEventLockReleased           SlotEventType = "lock.released"
+	SlotEventLockExpired            SlotEventType = "lock.expired"
+	SlotEventAppointmentCreated     SlotEventType = "appointment.created"
+	SlotEventAppointmentCancelled   SlotEventType = "appointment.cancelled"
+	SlotEventAppointmentRescheduled SlotEventType = "appointment.rescheduled"
+	SlotEventAppointmentStatus      SlotEventType = "appointment.status_changed"
+)
+
+// SlotEvent การเปลี่ยนแปลงที่ทำให้ slot ของช่างว่าง/ไม่ว่าง ส่งให้หน้าจองและปฏิทินพนักงานของสาขา
+// ไม่มีข้อมูลลูกค้า; หน้าจองสาธารณะได้รับเฉพาะส่วนที่ Public คืนให้
+type SlotEvent struct {
+	Type          SlotEventType `json:"type"`
+	TenantID      uint          `json:"tenant_id"`
+	BranchID      uint          `json:"branch_id"`
+	BarberID      uint          `json:"barber_id"`
+	AppointmentID *uint         `json:"appointment_id,omitempty"`
+	LockID        *uint         `json:"lock_id,omitempty"`
+	Status        string        `json:"status,omitempty"`
+	StartTime     time.Time     `json:"start_time"`
+	EndTime       time.Time     `json:"end_time"`
+	// เวลาและช่างเดิมก่อนเลื่อน/แก้ไขนัด (เฉพาะ appointment.rescheduled)
+	PreviousStartTime *time.Time `json:"previous_start_time,omitempty"`
+	PreviousEndTime   *time.Time `json:"previous_end_time,omitempty"`
+	PreviousBarberID  *uint      `json:"previous_barber_id,omitempty"`
+	OccurredAt        time.Time  `json:"occurred_at"`
+}
+
+// Public ตัดรหัสนัด/lock และสถานะนัดออก เหลือเฉพาะช่างและช่วงเวลาที่ว่าง/ไม่ว่าง สำหรับ stream ที่ไม่ต้องเข้าสู่ระบบ
+func (e SlotEvent) Public() SlotEvent {
+	e.AppointmentID = nil
+	e.LockID = nil
+	e.Status = ""
+	return e
+}
+
+type ISlotEvents interface {
+	// Publish ส่ง event ให้ทุก subscriber ของสาขา (ไม่ block ถ้า subscriber รับไม่ทัน event จะถูกทิ้ง)
+	Publish(event SlotEvent)
+
+	// Subscribe รับ event ของสาขา; เรียก cancel เมื่อเลิกฟัง
+	Subscribe(tenantID, branchID uint) (events <-chan SlotEvent, cancel func())
+}
+
+type ISlotEventStream interface {
+	// SubscribeBranch รับ event ของสาขาที่มีอยู่จริงใน tenant (error "branch not found" ถ้าไม่มี)
+	SubscribeBranch(ctx context.Context, tenantID, branchID uint) (events <-chan SlotEvent, cancel func(), err error)
+}
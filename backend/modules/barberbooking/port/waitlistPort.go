@@ -0,0 +1,39 @@
+package barberBookingPort
+
+import (
+	"context"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+)
+
+type JoinWaitlistRequest struct {
+	BranchID     uint           `json:"branch_id" example:"1"`
+	ServiceID    uint           `json:"service_id" example:"2"`
+	BarberID     *uint          `json:"barber_id,omitempty" example:"3"`
+	CustomerID   uint           `json:"customer_id" example:"4"`
+	Customer     *CustomerInput `json:"customer,omitempty"`
+	DesiredStart string         `json:"desired_start" example:"2025-05-30T10:00:00Z"`
+	DesiredEnd   string         `json:"desired_end" example:"2025-05-30T14:00:00Z"`
+	Notes        string         `json:"notes,omitempty"`
+}
+
+// PromoteWaitlistRequest ถ้าไม่ระบุ ใช้เวลาและช่างจาก hold ที่จับคู่ไว้
+type PromoteWaitlistRequest struct {
+	StartTime   *time.Time `json:"start_time,omitempty" example:"2025-05-30T10:30:00Z"`
+	BarberID    *uint      `json:"barber_id,omitempty" example:"3"`
+	ActorUserID *uint      `json:"actor_user_id,omitempty"`
+}
+
+type IWaitlist interface {
+	// เพิ่มลูกค้าเข้าคิวรอ (guest ที่ยังไม่มี CustomerID จะถูกสร้างจาก name/phone)
+	JoinWaitlist(ctx context.Context, entry *barberBookingModels.WaitlistEntry, guest *CustomerInput) (*barberBookingModels.WaitlistEntry, error)
+
+	// รายการรอของสาขา เรียงตามลำดับ FIFO (status ว่าง = WAITING และ OFFERED)
+	ListWaitlist(ctx context.Context, tenantID, branchID uint, status []barberBookingModels.WaitlistStatus) ([]barberBookingModels.WaitlistEntry, error)
+
+	// เปลี่ยน entry เป็นนัดหมายจริงผ่าน CreateAppointment แล้วปล่อย hold
+	PromoteWaitlistEntry(ctx context.Context, tenantID, entryID uint, input PromoteWaitlistRequest) (*barberBookingModels.WaitlistEntry, error)
+
+	CancelWaitlistEntry(ctx context.Context, tenantID, entryID uint) error
+}
@@ -10,6 +10,7 @@ func RegisterAppointmentLockRoute(router fiber.Router, ctrl *barberBookingContro
 	group := router.Group("/tenants/:tenant_id/branches/:branch_id/appointments-lock")
 
 	group.Post("/", ctrl.CreateAppointmentLock)
+	group.Post("/:lock_id/heartbeat", ctrl.ExtendAppointmentLock)
 	group.Delete("/:lock_id", ctrl.ReleaseAppointmentLock)
 	group.Get("/", ctrl.GetAppointmentLocks)
 }
\ No newline at end of file
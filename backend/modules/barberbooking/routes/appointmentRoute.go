@@ -27,4 +27,10 @@ func RegisterAppointmentRoute(router fiber.Router ,ctrl *barberBookingController
 	group.Use(middlewares.RequireAuth())
 	group.Delete("/:appointment_id", barberbookingMiddlewares.RequireTenant(), ctrl.DeleteAppointment)//
 
+	// เปลี่ยนสถานะตามตาราง transition (role ตรวจใน service)
+	group.Post("/:appointment_id/confirm", barberbookingMiddlewares.RequireTenant(), ctrl.ConfirmAppointment)
+	group.Post("/:appointment_id/start", barberbookingMiddlewares.RequireTenant(), ctrl.StartService)
+	group.Post("/:appointment_id/complete", barberbookingMiddlewares.RequireTenant(), ctrl.CompleteAppointment)
+	group.Post("/:appointment_id/no-show", barberbookingMiddlewares.RequireTenant(), ctrl.MarkNoShow)
+
 }
\ No newline at end of file
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	barberBookingController "myapp/modules/barberbooking/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterAppointmentSeriesRoute(router fiber.Router, ctrl *barberBookingController.AppointmentSeriesController) {
+	series := router.Group("/tenants/:tenant_id/appointment-series")
+	series.Post("/", ctrl.CreateSeries)
+	series.Get("/:series_id", ctrl.GetSeries)
+
+	appointments := router.Group("/tenants/:tenant_id/appointments/:appointment_id/series")
+	appointments.Post("/cancel", ctrl.CancelOccurrences)
+	appointments.Post("/reschedule", ctrl.RescheduleOccurrences)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	middlewares "myapp/middlewares"
+	barberBookingController "myapp/modules/barberbooking/controllers"
+	barberbookingMiddlewares "myapp/modules/barberbooking/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ต้อง register ก่อน RegisterWorkingHourRoute เพราะ group นั้น Use(RequireAuth) ทั้ง prefix /workinghour
+func RegisterBarberScheduleRoute(router fiber.Router, ctrl *barberBookingController.BarberScheduleController) {
+	group := router.Group("/tenants/:tenant_id/workinghour/barbers/:barber_id")
+	group.Get("/", ctrl.GetBarberSchedule)
+
+	group.Use(middlewares.RequireAuth(), barberbookingMiddlewares.RequireTenant())
+	group.Put("/", ctrl.ReplaceBarberWeeklyHours)
+	group.Delete("/", ctrl.ClearBarberWeeklyHours)
+	group.Put("/overrides", ctrl.UpsertBarberOverride)
+	group.Delete("/overrides/:override_id", ctrl.DeleteBarberOverride)
+}
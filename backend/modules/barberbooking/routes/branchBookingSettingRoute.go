@@ -0,0 +1,17 @@
+package routes
+
+import (
+	middlewares "myapp/middlewares"
+	barberBookingController "myapp/modules/barberbooking/controllers"
+	barberbookingMiddlewares "myapp/modules/barberbooking/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterBranchBookingSettingRoute(router fiber.Router, ctrl *barberBookingController.BranchBookingSettingController) {
+	group := router.Group("/tenants/:tenant_id/branches/:branch_id/booking-settings")
+	group.Get("/", ctrl.GetSetting)
+
+	group.Use(middlewares.RequireAuth())
+	group.Put("/", barberbookingMiddlewares.RequireTenant(), ctrl.UpdateSetting)
+}
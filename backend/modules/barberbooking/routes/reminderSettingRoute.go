@@ -0,0 +1,16 @@
+package routes
+
+import (
+	middlewares "myapp/middlewares"
+	barberBookingController "myapp/modules/barberbooking/controllers"
+	barberbookingMiddlewares "myapp/modules/barberbooking/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterReminderSettingRoute(router fiber.Router, ctrl *barberBookingController.ReminderSettingController) {
+	group := router.Group("/tenants/:tenant_id/reminder-settings")
+	group.Use(middlewares.RequireAuth(), barberbookingMiddlewares.RequireTenant())
+	group.Get("/", ctrl.GetSetting)
+	group.Put("/", ctrl.UpdateSetting)
+}
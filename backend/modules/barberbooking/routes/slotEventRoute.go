@@ -0,0 +1,16 @@
+package routes
+
+import (
+	middlewares "myapp/middlewares"
+	barberBookingController "myapp/modules/barberbooking/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterSlotEventRoute stream ของสาขา (ไม่มีข้อมูลลูกค้าใน event)
+//   - /events สำหรับพนักงานที่เข้าสู่ระบบแล้ว; EventSource ตั้ง header ไม่ได้ RequireAuth จึงรับ token จาก cookie แทน Authorization header
+//   - /public-events สำหรับหน้าจองสาธารณะ ไม่ต้องเข้าสู่ระบบ และไม่มีรหัสนัด/lock ใน event
+func RegisterSlotEventRoute(router fiber.Router, ctrl *barberBookingController.SlotEventController) {
+	router.Get("/tenants/:tenant_id/branches/:branch_id/events", middlewares.RequireAuth(), ctrl.StreamBranchEvents)
+	router.Get("/tenants/:tenant_id/branches/:branch_id/public-events", ctrl.StreamPublicBranchEvents)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	middlewares "myapp/middlewares"
+	barberBookingController "myapp/modules/barberbooking/controllers"
+	barberbookingMiddlewares "myapp/modules/barberbooking/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterWaitlistRoute(router fiber.Router, ctrl *barberBookingController.WaitlistController) {
+	group := router.Group("/tenants/:tenant_id/waitlist")
+	group.Post("/", ctrl.JoinWaitlist)
+	group.Delete("/:entry_id", ctrl.CancelWaitlistEntry)
+
+	// สำหรับพนักงาน
+	group.Post("/:entry_id/promote", middlewares.RequireAuth(), barberbookingMiddlewares.RequireTenant(), ctrl.PromoteWaitlistEntry)
+	router.Get("/tenants/:tenant_id/branches/:branch_id/waitlist",
+		middlewares.RequireAuth(), barberbookingMiddlewares.RequireTenant(), ctrl.ListWaitlist)
+}
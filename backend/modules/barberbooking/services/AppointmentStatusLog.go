@@ -19,6 +19,11 @@ func NewAppointmentStatusLogService(db *gorm.DB) barberBookingPort.IAppointmentS
 }
 
 func (s *appointmentStatusLogService) LogStatusChange(ctx context.Context, appointmentID uint, oldStatus, newStatus string, userID *uint, customerID *uint, notes string) error {
+	return logStatusChangeTx(s.DB.WithContext(ctx), appointmentID, oldStatus, newStatus, userID, customerID, notes)
+}
+
+// logStatusChangeTx เขียน log ใน transaction เดียวกับการเปลี่ยนสถานะ
+func logStatusChangeTx(tx *gorm.DB, appointmentID uint, oldStatus, newStatus string, userID *uint, customerID *uint, notes string) error {
 	log := barberBookingModels.AppointmentStatusLog{
 		AppointmentID:       appointmentID,
 		OldStatus:           oldStatus,
@@ -28,7 +33,26 @@ func (s *appointmentStatusLogService) LogStatusChange(ctx context.Context, appoi
 		ChangedAt:           time.Now().UTC(),
 		Notes:               notes,
 	}
-	return s.DB.WithContext(ctx).Create(&log).Error
+	if err := tx.Create(&log).Error; err != nil {
+		return fmt.Errorf("failed to log status change: %w", err)
+	}
+	return nil
+}
+
+// logSystemStatusChangeTx บันทึกการเปลี่ยนสถานะโดย job ของระบบ (ไม่มี user/customer เป็นผู้เปลี่ยน)
+func logSystemStatusChangeTx(tx *gorm.DB, appointmentID uint, oldStatus, newStatus string, notes string) error {
+	log := barberBookingModels.AppointmentStatusLog{
+		AppointmentID:   appointmentID,
+		OldStatus:       oldStatus,
+		NewStatus:       newStatus,
+		ChangedBySystem: true,
+		ChangedAt:       time.Now().UTC(),
+		Notes:           notes,
+	}
+	if err := tx.Create(&log).Error; err != nil {
+		return fmt.Errorf("failed to log status change: %w", err)
+	}
+	return nil
 }
 
 func (s *appointmentStatusLogService) GetLogsForAppointment(
@@ -0,0 +1,193 @@
+package barberBookingService
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"myapp/jobs"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	webhookModels "myapp/modules/webhook/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type appointmentAutoStatusService struct {
+	DB *gorm.DB
+}
+
+func NewAppointmentAutoStatusService(db *gorm.DB) barberBookingPort.IAppointmentAutoStatus {
+	return &appointmentAutoStatusService{DB: db}
+}
+
+// JobAppointmentAutoStatus งานตามรอบของ jobworker: ปิดนัดที่เลยเวลา (auto no-show แล้ว auto complete)
+const JobAppointmentAutoStatus = "booking.appointment_auto_status"
+
+// autoStatusBatchSize จำนวนนัดต่อหนึ่ง transaction (วนทำจนกว่าจะไม่เหลือนัดที่ถึงกำหนด)
+const autoStatusBatchSize = 200
+
+// autoStatusRule นัดสถานะ from ที่เวลาอ้างอิง (column) + grace ของสาขาผ่านไปแล้ว → to
+// enabledColumn/graceColumn คือคอลัมน์ของ branch_booking_settings ที่ใช้กรองใน SQL
+type autoStatusRule struct {
+	from, to      barberBookingModels.AppointmentStatus
+	column        string
+	enabledColumn string
+	graceColumn   string
+	grace         func(setting barberBookingModels.BranchBookingSetting) (time.Duration, bool)
+	notes         string
+}
+
+var noShowRule = autoStatusRule{
+	from:          barberBookingModels.StatusConfirmed,
+	to:            barberBookingModels.StatusNoShow,
+	column:        "start_time",
+	enabledColumn: "auto_no_show_enabled",
+	graceColumn:   "no_show_grace_minutes",
+	grace: func(setting barberBookingModels.BranchBookingSetting) (time.Duration, bool) {
+		return time.Duration(setting.NoShowGraceMinutes) * time.Minute, setting.AutoNoShowEnabled
+	},
+	notes: "auto no-show: not checked in after start time",
+}
+
+var autoCompleteRule = autoStatusRule{
+	from:          barberBookingModels.StatusInService,
+	to:            barberBookingModels.StatusComplete,
+	column:        "end_time",
+	enabledColumn: "auto_complete_enabled",
+	graceColumn:   "auto_complete_grace_minutes",
+	grace: func(setting barberBookingModels.BranchBookingSetting) (time.Duration, bool) {
+		return time.Duration(setting.AutoCompleteGraceMinutes) * time.Minute, setting.AutoCompleteEnabled
+	},
+	notes: "auto complete: service ended",
+}
+
+func (s *appointmentAutoStatusService) MarkNoShows(ctx context.Context, now time.Time) (int, error) {
+	return s.apply(ctx, now, noShowRule)
+}
+
+func (s *appointmentAutoStatusService) CompleteFinished(ctx context.Context, now time.Time) (int, error) {
+	return s.apply(ctx, now, autoCompleteRule)
+}
+
+func (s *appointmentAutoStatusService) apply(ctx context.Context, now time.Time, rule autoStatusRule) (int, error) {
+	total := 0
+	for {
+		n, err := s.applyBatch(ctx, now, rule)
+		total += n
+		if err != nil || n < autoStatusBatchSize {
+			return total, err
+		}
+	}
+}
+
+// applyBatch เปลี่ยนสถานะนัดที่ถึงกำหนดไม่เกิน autoStatusBatchSize รายการใน transaction เดียว
+func (s *appointmentAutoStatusService) applyBatch(ctx context.Context, now time.Time, rule autoStatusRule) (int, error) {
+	var changed []barberBookingModels.Appointment
+	var holds []*barberBookingModels.WaitlistEntry
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// grace แต่ละค่าที่ใช้อยู่ (สาขาที่เปิดใช้ + ค่า default ของสาขาที่ยังไม่มี setting) → เงื่อนไขเวลาตัดของค่านั้น
+		defaultGrace, defaultEnabled := rule.grace(defaultBranchBookingSetting(0, 0))
+		defaultMinutes := int(defaultGrace / time.Minute)
+		var graces []int
+		if err := tx.Model(&barberBookingModels.BranchBookingSetting{}).
+			Where(rule.enabledColumn+" = ?", true).
+			Distinct().
+			Pluck(rule.graceColumn, &graces).Error; err != nil {
+			return fmt.Errorf("failed to load branch booking settings: %w", err)
+		}
+		graces = append(graces, defaultMinutes)
+		cutoffs := make([]string, 0, len(graces))
+		args := make([]interface{}, 0, 3*len(graces))
+		for _, g := range graces {
+			cutoffs = append(cutoffs, "(COALESCE(bs."+rule.graceColumn+", ?) = ? AND appointments."+rule.column+" <= ?)")
+			args = append(args, defaultMinutes, g, now.Add(-time.Duration(g)*time.Minute))
+		}
+
+		// SKIP LOCKED: นัดที่พนักงานกำลังเปลี่ยนสถานะอยู่ (TransitionStatus lock แถวไว้) ข้ามไปรอบหน้า
+		var due []barberBookingModels.Appointment
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "appointments"}, Options: "SKIP LOCKED"}).
+			Select("appointments.*").
+			Joins("LEFT JOIN branch_booking_settings bs ON bs.tenant_id = appointments.tenant_id AND bs.branch_id = appointments.branch_id").
+			Where("appointments.status = ? AND appointments.deleted_at IS NULL AND appointments."+rule.column+" <= ?", rule.from, now).
+			Where("COALESCE(bs."+rule.enabledColumn+", ?) = ?", defaultEnabled, true).
+			Where(strings.Join(cutoffs, " OR "), args...).
+			Order("appointments." + rule.column + " ASC").
+			Limit(autoStatusBatchSize).
+			Find(&due).Error; err != nil {
+			return fmt.Errorf("failed to fetch %s appointments: %w", rule.from, err)
+		}
+
+		for _, ap := range due {
+			if err := checkStatusTransition(ap.Status, rule.to); err != nil {
+				continue
+			}
+			ap.Status = rule.to
+			ap.UpdatedAt = time.Now().UTC()
+			if err := tx.Model(&ap).Updates(map[string]interface{}{
+				"status":     ap.Status,
+				"updated_at": ap.UpdatedAt,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update appointment status: %w", err)
+			}
+			if err := logSystemStatusChangeTx(tx, ap.ID, string(rule.from), string(rule.to), rule.notes); err != nil {
+				return err
+			}
+
+			if rule.to == barberBookingModels.StatusComplete {
+				if err := publishAppointmentWebhookTx(tx, webhookModels.EventAppointmentCompleted, &ap, nil); err != nil {
+					return err
+				}
+			}
+
+			// ลูกค้าไม่มา → ช่วงเวลาที่เหลือว่างลง เสนอให้ waitlist (เหมือน TransitionStatus)
+			if rule.to == barberBookingModels.StatusNoShow && blocksSlot(rule.from) {
+				hold, err := offerFreedSlotTx(tx, ap.TenantID, ap.BranchID, ap.BarberID, ap.BlockStart, ap.BlockEnd)
+				if err != nil {
+					return err
+				}
+				holds = append(holds, hold)
+			}
+			changed = append(changed, ap)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range changed {
+		SlotEvents.Publish(appointmentEvent(barberBookingPort.SlotEventAppointmentStatus, &changed[i]))
+	}
+	for _, hold := range holds {
+		publishWaitlistHold(hold)
+	}
+	return len(changed), nil
+}
+
+// RegisterAppointmentStatusJobs ผูก handler ปิดนัดอัตโนมัติกับ worker (รอบการรันตั้งด้วย jobs.RegisterSchedule)
+func RegisterAppointmentStatusJobs(w *jobs.Worker, db *gorm.DB) {
+	auto := NewAppointmentAutoStatusService(db)
+	w.Register(JobAppointmentAutoStatus, jobs.Typed(func(ctx context.Context, _ struct{}) error {
+		now := time.Now()
+		n, err := auto.MarkNoShows(ctx, now)
+		if err != nil {
+			return fmt.Errorf("auto no-show job: %w", err)
+		}
+		if n > 0 {
+			log.Printf("auto no-show job: marked %d appointments as NO_SHOW", n)
+		}
+		if n, err = auto.CompleteFinished(ctx, now); err != nil {
+			return fmt.Errorf("auto complete job: %w", err)
+		}
+		if n > 0 {
+			log.Printf("auto complete job: completed %d appointments", n)
+		}
+		return nil
+	}))
+}
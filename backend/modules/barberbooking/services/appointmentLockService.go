@@ -3,6 +3,7 @@ package barberBookingService
 import (
 	"context"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"errors"
 	barberBookingModels "myapp/modules/barberbooking/models"
 	barberBookingPort "myapp/modules/barberbooking/port"
@@ -15,45 +16,140 @@ type appointmentLockService struct {
 	db *gorm.DB
 }
 
+const (
+	// lockTTL อายุของ lock ต่อครั้ง (สร้างใหม่หรือ heartbeat)
+	lockTTL = 7 * time.Minute
+	// lockMaxHold ระยะเวลาสูงสุดที่ถือ lock ได้นับจากตอนสร้าง ไม่ว่าจะ heartbeat กี่ครั้ง
+	lockMaxHold = 20 * time.Minute
+)
+
 func NewAppointmentLockService(db *gorm.DB) barberBookingPort.IAppointmentLock {
 	return &appointmentLockService{db}
 }
 
 // CleanupExpiredLocks implements barberBookingPort.IAppointmentLock.
+// ปิด lock ที่หมดอายุแล้วแต่ยัง is_active = true
+// hold ของ waitlist ที่หมดอายุ: entry เป็น EXPIRED แล้วเสนอช่วงเวลานั้นให้คิวถัดไป
 func (a *appointmentLockService) CleanupExpiredLocks(ctx context.Context) error {
-	panic("unimplemented")
+	var expired []barberBookingModels.AppointmentLock
+	var offers []barberBookingModels.WaitlistEntry
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("is_active = ? AND expires_at <= ?", true, time.Now()).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(expired))
+		for i, l := range expired {
+			ids[i] = l.ID
+		}
+		if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+			Where("id IN ?", ids).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("lock_id IN ? AND status = ?", ids, barberBookingModels.WaitlistOffered).
+			Find(&offers).Error; err != nil {
+			return err
+		}
+		if len(offers) == 0 {
+			return nil
+		}
+		return tx.Model(&barberBookingModels.WaitlistEntry{}).
+			Where("lock_id IN ? AND status = ?", ids, barberBookingModels.WaitlistOffered).
+			Update("status", barberBookingModels.WaitlistExpired).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired locks: %w", err)
+	}
+	if len(expired) > 0 {
+		log.Printf("appointment lock sweeper: deactivated %d expired locks", len(expired))
+	}
+	for i := range expired {
+		SlotEvents.Publish(lockEvent(barberBookingPort.SlotEventLockExpired, &expired[i]))
+	}
+
+	// เสนอใหม่แยก transaction ต่อ hold: offerFreedSlotTx lock แถวช่างก่อนแถว lock เหมือนการจองทั่วไป
+	byID := make(map[uint]*barberBookingModels.AppointmentLock, len(expired))
+	for i := range expired {
+		byID[expired[i].ID] = &expired[i]
+	}
+	for _, offer := range offers {
+		lock := byID[*offer.LockID]
+		var hold *barberBookingModels.WaitlistEntry
+		err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			hold, err = offerFreedSlotTx(tx, lock.TenantID, lock.BranchID, lock.BarberID, lock.BlockStart, lock.BlockEnd)
+			return err
+		})
+		if err != nil {
+			log.Printf("appointment lock sweeper: re-offer waitlist hold %d: %v", lock.ID, err)
+			continue
+		}
+		publishWaitlistHold(hold)
+	}
+	return nil
 }
 
-// CreateAppointmentLock implements barberBookingPort.IAppointmentLock.
-func (a *appointmentLockService) CreateAppointmentLock(
+// ExtendAppointmentLock implements barberBookingPort.IAppointmentLock.
+// ต่ออายุ lock ที่ลูกค้ายังถืออยู่ได้ครั้งละ lockTTL แต่ไม่เกิน lockMaxHold นับจากตอนสร้าง
+func (a *appointmentLockService) ExtendAppointmentLock(
 	ctx context.Context,
-	input barberBookingPort.AppointmentLockInput,
+	lockID, customerID uint,
 ) (*barberBookingModels.AppointmentLock, error) {
+	var lock barberBookingModels.AppointmentLock
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&lock, "id = ?", lockID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("lock not found")
+			}
+			return err
+		}
 
-	db := a.db.WithContext(ctx)
-	var count int64
+		now := time.Now()
+		if !lock.IsActive || lock.AppointmentID != nil {
+			return fmt.Errorf("lock is no longer active")
+		}
+		if !lock.ExpiresAt.After(now) {
+			return fmt.Errorf("lock has expired")
+		}
+		if lock.CustomerID != customerID {
+			return fmt.Errorf("lock belongs to another customer")
+		}
 
-	if err := db.Model(&barberBookingModels.Appointment{}).
-		Where("tenant_id = ? AND branch_id = ? AND barber_id = ? AND start_time < ? AND end_time > ? AND status IN ?",
-			input.TenantID, input.BranchID, input.BarberID, input.EndTime, input.StartTime,
-			[]string{"PENDING", "CONFIRMED"}).
-		Count(&count).Error; err != nil {
-		return nil, err
-	}
-	if count > 0 {
-		return nil, errors.New("slot is already booked")
-	}
+		maxExpiry := lock.CreatedAt.Add(lockMaxHold)
+		if !maxExpiry.After(now) {
+			return fmt.Errorf("lock has reached its maximum hold time")
+		}
+		expiresAt := earliest(now.Add(lockTTL), maxExpiry)
+		if !expiresAt.After(lock.ExpiresAt) {
+			return nil
+		}
 
-	if err := db.Model(&barberBookingModels.AppointmentLock{}).
-		Where("tenant_id = ? AND branch_id = ? AND barber_id = ? AND start_time < ? AND end_time > ? AND is_active = ? AND expires_at > ?",
-			input.TenantID, input.BranchID, input.BarberID, input.EndTime, input.StartTime,
-			true, time.Now()).
-		Count(&count).Error; err != nil {
+		lock.ExpiresAt = expiresAt
+		if err := tx.Model(&lock).Update("expires_at", expiresAt).Error; err != nil {
+			return fmt.Errorf("failed to extend lock: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	if count > 0 {
-		return nil, errors.New("slot is currently being locked by another user")
-	}
+	return &lock, nil
+}
+
+// CreateAppointmentLock implements barberBookingPort.IAppointmentLock.
+func (a *appointmentLockService) CreateAppointmentLock(
+	ctx context.Context,
+	input barberBookingPort.AppointmentLockInput,
+) (*barberBookingModels.AppointmentLock, error) {
+
 	lock := &barberBookingModels.AppointmentLock{
 		TenantID:   input.TenantID,
 		BranchID:   input.BranchID,
@@ -61,13 +157,92 @@ func (a *appointmentLockService) CreateAppointmentLock(
 		CustomerID: input.CustomerID,
 		StartTime:  input.StartTime,
 		EndTime:    input.EndTime,
-		ExpiresAt:  time.Now().Add(7 * time.Minute),
 		IsActive:   true,
 	}
 
-	if err := db.Create(lock).Error; err != nil {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock แถวช่างก่อนตรวจ เหมือน checkBarberAvailabilityTx → การจองและการ lock ของช่างคนเดียวกันเข้าคิวกัน
+		var barber barberBookingModels.Barber
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ? AND branch_id = ? AND deleted_at IS NULL", input.BarberID, input.TenantID, input.BranchID).
+			First(&barber).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("barber not found or mismatched branch")
+			}
+			return err
+		}
+
+		// ช่วงที่ช่างไม่ว่างจริง รวม buffer ของ service (ถ้าระบุ)
+		blockStart, blockEnd := input.StartTime, input.EndTime
+		if input.ServiceID != 0 {
+			var service barberBookingModels.Service
+			if err := tx.
+				Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.ServiceID, input.TenantID).
+				First(&service).Error; err != nil {
+				return fmt.Errorf("service not found or access denied")
+			}
+			span := serviceSpan(service)
+			blockStart = input.StartTime.Add(-span.BufferBefore)
+			blockEnd = input.EndTime.Add(span.BufferAfter)
+		}
+		lock.BlockStart, lock.BlockEnd = blockStart, blockEnd
+
+		works, err := barberWorksDuringTx(tx, input.TenantID, input.BranchID, input.BarberID, blockStart, blockEnd)
+		if err != nil {
+			return err
+		}
+		if !works {
+			return errors.New("barber is not working during this time")
+		}
+
+		blocked, err := barberUnavailableTx(tx, input.BranchID, input.BarberID, blockStart, blockEnd)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return errors.New("barber is unavailable during this time")
+		}
+
+		var count int64
+		if err := tx.Model(&barberBookingModels.Appointment{}).
+			Where("tenant_id = ? AND branch_id = ? AND barber_id = ? AND block_start < ? AND block_end > ? AND status IN ?",
+				input.TenantID, input.BranchID, input.BarberID, blockEnd, blockStart, slotBlockingStatuses).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("slot is already booked")
+		}
+
+		if err := releaseExpiredLocksTx(tx, input.BarberID, blockStart, blockEnd); err != nil {
+			return err
+		}
+		if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+			Where("tenant_id = ? AND branch_id = ? AND barber_id = ? AND block_start < ? AND block_end > ? AND is_active = ? AND expires_at > ?",
+				input.TenantID, input.BranchID, input.BarberID, blockEnd, blockStart,
+				true, time.Now()).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("slot is currently being locked by another user")
+		}
+
+		lock.ExpiresAt = time.Now().Add(lockTTL)
+		if err := tx.Create(lock).Error; err != nil {
+			// อีก transaction สร้าง lock ทับช่วงเดียวกันไปก่อน
+			if isSlotTaken(err) {
+				return errSlotTaken
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	SlotEvents.Publish(lockEvent(barberBookingPort.SlotEventLockCreated, lock))
 
 	return lock, nil
 }
@@ -103,8 +278,31 @@ func (a *appointmentLockService) GetAppointmentLocks(
 
 
 // IsSlotAvailable implements barberBookingPort.IAppointmentLock.
+// slot ว่างเมื่อไม่มีนัด PENDING/CONFIRMED และไม่มี lock ที่ยังไม่หมดอายุทับช่วงเวลา
 func (a *appointmentLockService) IsSlotAvailable(ctx context.Context, tenantID uint, branchID uint, barberID uint, start time.Time, end time.Time) (bool, error) {
-	panic("unimplemented")
+	if !start.Before(end) {
+		return false, errors.New("invalid time range: start must be before end")
+	}
+	db := a.db.WithContext(ctx)
+
+	var count int64
+	if err := db.Model(&barberBookingModels.Appointment{}).
+		Where("tenant_id = ? AND branch_id = ? AND barber_id = ? AND block_start < ? AND block_end > ? AND status IN ? AND deleted_at IS NULL",
+			tenantID, branchID, barberID, end, start, slotBlockingStatuses).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if err := db.Model(&barberBookingModels.AppointmentLock{}).
+		Where("tenant_id = ? AND branch_id = ? AND barber_id = ? AND block_start < ? AND block_end > ? AND is_active = ? AND expires_at > ?",
+			tenantID, branchID, barberID, end, start, true, time.Now()).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
 }
 
 func (a *appointmentLockService) ReleaseAppointmentLock(ctx context.Context, lockID uint) error {
@@ -123,7 +321,65 @@ func (a *appointmentLockService) ReleaseAppointmentLock(ctx context.Context, loc
 	if err := db.Model(&lock).Update("is_active", false).Error; err != nil {
 		return err
 	}
+	SlotEvents.Publish(lockEvent(barberBookingPort.SlotEventLockReleased, &lock))
 
 	return nil
 }
 
+
+// claimLockTx ตรวจ lock ที่ลูกค้าแนบมากับการจอง: ต้อง active, ยังไม่หมดอายุ และเป็นของลูกค้าคนเดียวกัน
+// ถ้าไม่ระบุช่างจะใช้ช่างของ lock; lock ถูก lock แถวไว้จนจบ transaction กันใช้ซ้ำ
+func claimLockTx(tx *gorm.DB, appt *barberBookingModels.Appointment) (*barberBookingModels.AppointmentLock, error) {
+	var lock barberBookingModels.AppointmentLock
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", *appt.LockID, appt.TenantID).
+		First(&lock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("appointment lock with ID %d not found", *appt.LockID)
+		}
+		return nil, err
+	}
+
+	if !lock.IsActive || lock.AppointmentID != nil {
+		return nil, fmt.Errorf("appointment lock %d is no longer active", lock.ID)
+	}
+	if !lock.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("appointment lock %d has expired", lock.ID)
+	}
+	if lock.CustomerID != appt.CustomerID {
+		return nil, fmt.Errorf("appointment lock %d belongs to another customer", lock.ID)
+	}
+	if appt.BarberID == 0 {
+		appt.BarberID = lock.BarberID
+	}
+	if lock.BranchID != appt.BranchID || lock.BarberID != appt.BarberID || !lock.StartTime.Equal(appt.StartTime) {
+		return nil, fmt.Errorf("appointment lock %d does not match the requested branch, barber or start time", lock.ID)
+	}
+	return &lock, nil
+}
+
+// lockedByOthersTx ตรวจว่ามี lock ที่ยังไม่หมดอายุของลูกค้าคนอื่นทับช่วง block ของช่างหรือไม่
+func lockedByOthersTx(tx *gorm.DB, tenantID, barberID, customerID uint, start, end time.Time) (bool, error) {
+	var count int64
+	if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+		Where("tenant_id = ? AND barber_id = ? AND customer_id <> ? AND is_active = ? AND expires_at > ? AND block_start < ? AND block_end > ?",
+			tenantID, barberID, customerID, true, time.Now(), end, start).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check appointment locks: %w", err)
+	}
+	return count > 0, nil
+}
+
+// consumeLockTx ปิด lock และผูกกับนัดที่สร้างขึ้น (เรียกใน transaction เดียวกับการสร้างนัด)
+func consumeLockTx(tx *gorm.DB, lockID, appointmentID uint) error {
+	if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+		Where("id = ?", lockID).
+		Updates(map[string]interface{}{
+			"is_active":      false,
+			"appointment_id": appointmentID,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to consume appointment lock: %w", err)
+	}
+	return nil
+}
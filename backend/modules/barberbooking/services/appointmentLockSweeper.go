@@ -0,0 +1,27 @@
+package barberBookingService
+
+import (
+	"context"
+	"log"
+	"time"
+
+	barberBookingPort "myapp/modules/barberbooking/port"
+)
+
+// StartLockSweeper ปิด lock ที่หมดอายุทุก interval จนกว่า ctx จะถูกยกเลิก
+func StartLockSweeper(ctx context.Context, locks barberBookingPort.IAppointmentLock, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := locks.CleanupExpiredLocks(ctx); err != nil {
+					log.Printf("appointment lock sweeper: %v", err)
+				}
+			}
+		}
+	}()
+}
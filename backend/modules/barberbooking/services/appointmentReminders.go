@@ -0,0 +1,92 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapp/jobs"
+	barberBookingModels "myapp/modules/barberbooking/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobAppointmentReminder งานแจ้งเตือนลูกค้าก่อนถึงนัด (ประมวลผลโดย cmd/jobworker)
+const JobAppointmentReminder = "booking.appointment_reminder"
+
+type reminderPayload struct {
+	AppointmentID uint  `json:"appointment_id"`
+	OffsetMinutes int   `json:"offset_minutes"`
+	StartTime     int64 `json:"start_time"` // unix ของเวลาเริ่มนัดตอนตั้ง reminder
+}
+
+func reminderKeyPrefix(appointmentID uint) string {
+	return fmt.Sprintf("reminder:appointment:%d:", appointmentID)
+}
+
+// scheduleRemindersTx ตั้งงานแจ้งเตือนตาม offset ของร้าน ข้าม offset ที่เลยเวลาไปแล้ว
+// (เช่น จองล่วงหน้าแค่ 1 ชั่วโมง จะไม่มีแจ้งเตือน 24 ชั่วโมงและ 2 ชั่วโมงก่อน)
+func scheduleRemindersTx(tx *gorm.DB, ap *barberBookingModels.Appointment) error {
+	setting, err := getReminderSettingTx(tx, ap.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load reminder setting: %w", err)
+	}
+	if !setting.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	for _, offset := range setting.OffsetsMinutes {
+		runAt := ap.StartTime.Add(-time.Duration(offset) * time.Minute)
+		if !runAt.After(now) {
+			continue
+		}
+		key := fmt.Sprintf("%s%d:%d", reminderKeyPrefix(ap.ID), ap.StartTime.Unix(), offset)
+		if _, err := jobs.Enqueue(tx, JobAppointmentReminder,
+			reminderPayload{AppointmentID: ap.ID, OffsetMinutes: offset, StartTime: ap.StartTime.Unix()},
+			jobs.RunAt(runAt),
+			jobs.UniqueKey(key),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelRemindersTx ลบแจ้งเตือนที่ยังไม่ถึงเวลาของนัดนี้ (ยกเลิกหรือก่อนตั้งเวลาใหม่)
+func cancelRemindersTx(tx *gorm.DB, appointmentID uint) error {
+	_, err := jobs.CancelPending(tx, reminderKeyPrefix(appointmentID))
+	return err
+}
+
+// RegisterReminderJobs ผูก handler แจ้งเตือนนัดกับ worker
+func RegisterReminderJobs(w *jobs.Worker, db *gorm.DB) {
+	w.Register(JobAppointmentReminder, jobs.Typed(func(ctx context.Context, p reminderPayload) error {
+		return sendAppointmentReminder(ctx, db, p)
+	}))
+}
+
+// sendAppointmentReminder ตรวจว่านัดยังรออยู่และยังเป็นเวลาเดิม แล้วสร้างข้อความใน outbox
+// dedup key ของ outbox กันการส่งซ้ำ แม้งานเดียวกันจะถูกรันมากกว่าหนึ่งครั้ง
+func sendAppointmentReminder(ctx context.Context, db *gorm.DB, p reminderPayload) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ap barberBookingModels.Appointment
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", p.AppointmentID).
+			First(&ap).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !blocksSlot(ap.Status) || ap.StartTime.Unix() != p.StartTime || !ap.StartTime.After(time.Now()) {
+			return nil
+		}
+		return remindCustomerTx(tx, &ap, p.OffsetMinutes)
+	})
+}
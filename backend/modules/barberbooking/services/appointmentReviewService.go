@@ -62,9 +62,15 @@ func (s *appointmentReviewService) CreateReview(ctx context.Context, review *bar
 	review.CreatedAt = now
 	review.UpdatedAt = now
 
-	// 4. Persist the review
-	if err := s.DB.WithContext(ctx).Create(review).Error; err != nil {
-		return nil, fmt.Errorf("failed to create review: %w", err)
+	// 4. Persist the review and notify tenant webhooks in the same transaction
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return fmt.Errorf("failed to create review: %w", err)
+		}
+		return publishReviewWebhookTx(tx, appt.TenantID, review)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return review, nil
@@ -0,0 +1,314 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+
+	"gorm.io/gorm"
+)
+
+// จำนวนครั้งสูงสุดที่สร้างได้ใน series เดียว
+const maxSeriesOccurrences = 52
+
+type appointmentSeriesService struct {
+	DB           *gorm.DB
+	Appointments barberBookingPort.IAppointment
+}
+
+func NewAppointmentSeriesService(db *gorm.DB, appointments barberBookingPort.IAppointment) barberBookingPort.IAppointmentSeries {
+	return &appointmentSeriesService{DB: db, Appointments: appointments}
+}
+
+// seriesOccurrences คำนวณเวลาเริ่มของทุกครั้งตามกฎ
+// แบบรายเดือน วันที่ไม่มีในเดือนนั้น (เช่น 31) จะถูกข้ามเหมือน RRULE
+func seriesOccurrences(start time.Time, rule barberBookingPort.RecurrenceRule) ([]time.Time, error) {
+	if rule.Interval <= 0 {
+		rule.Interval = 1
+	}
+	if rule.Count == nil && rule.Until == nil {
+		return nil, errors.New("recurrence requires count or until")
+	}
+	if rule.Count != nil && (*rule.Count <= 0 || *rule.Count > maxSeriesOccurrences) {
+		return nil, fmt.Errorf("recurrence count must be between 1 and %d", maxSeriesOccurrences)
+	}
+	if rule.Until != nil && rule.Until.Before(start) {
+		return nil, errors.New("recurrence until must not be before start time")
+	}
+
+	var out []time.Time
+	for i := 0; ; i++ {
+		var t time.Time
+		switch rule.Frequency {
+		case barberBookingModels.FrequencyWeekly:
+			t = start.AddDate(0, 0, 7*rule.Interval*i)
+		case barberBookingModels.FrequencyMonthly:
+			t = start.AddDate(0, rule.Interval*i, 0)
+			if t.Day() != start.Day() {
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("invalid recurrence frequency: %s", rule.Frequency)
+		}
+
+		if rule.Until != nil && t.After(*rule.Until) {
+			break
+		}
+		if rule.Count != nil && len(out) >= *rule.Count {
+			break
+		}
+		if len(out) >= maxSeriesOccurrences {
+			return nil, fmt.Errorf("recurrence produces more than %d occurrences", maxSeriesOccurrences)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (s *appointmentSeriesService) CreateSeries(
+	ctx context.Context,
+	input *barberBookingModels.Appointment,
+	rule barberBookingPort.RecurrenceRule,
+) (*barberBookingPort.SeriesResult, error) {
+	if input == nil {
+		return nil, errors.New("input appointment data is required")
+	}
+	if input.TenantID == 0 || input.BranchID == 0 || (input.ServiceID == 0 && len(input.Items) == 0) || input.StartTime.IsZero() {
+		return nil, errors.New("missing required fields")
+	}
+
+	starts, err := seriesOccurrences(input.StartTime, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID := input.ServiceID
+	if len(input.Items) > 0 {
+		serviceID = input.Items[0].ServiceID
+	}
+
+	// series, ลูกค้า guest และนัดทุกครั้งอยู่ใน transaction เดียว
+	// ไม่มีครั้งไหนจองได้เลย → rollback ทั้งหมด (ไม่เหลือลูกค้า guest หรือ customer.created ค้าง)
+	var result *barberBookingPort.SeriesResult
+	var booked []*barberBookingModels.Appointment
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if input.CustomerID == 0 {
+			if input.Customer == nil {
+				return fmt.Errorf("guest customer requires 'Customer' field with name and phone")
+			}
+			customer, err := (&appointmentService{DB: tx}).getOrCreateGuestCustomerTx(tx,
+				input.TenantID, input.BranchID, input.Customer.Name, input.Customer.Phone,
+			)
+			if err != nil {
+				return err
+			}
+			input.CustomerID = customer.ID
+		}
+
+		series := barberBookingModels.AppointmentSeries{
+			TenantID:   input.TenantID,
+			BranchID:   input.BranchID,
+			CustomerID: input.CustomerID,
+			BarberID:   input.BarberID,
+			ServiceID:  serviceID,
+			Frequency:  rule.Frequency,
+			Interval:   max(rule.Interval, 1),
+			Count:      rule.Count,
+			Until:      rule.Until,
+			StartTime:  input.StartTime,
+			Notes:      input.Notes,
+		}
+		if err := tx.Create(&series).Error; err != nil {
+			return fmt.Errorf("failed to create appointment series: %w", err)
+		}
+
+		// สร้างนัดทีละครั้งใน savepoint; ครั้งที่ชนจะถูกบันทึกไว้ในผลลัพธ์โดยไม่กระทบครั้งอื่น
+		result = &barberBookingPort.SeriesResult{SeriesID: series.ID}
+		for _, start := range starts {
+			occ := &barberBookingModels.Appointment{
+				TenantID:   input.TenantID,
+				BranchID:   input.BranchID,
+				ServiceID:  input.ServiceID,
+				BarberID:   input.BarberID,
+				CustomerID: input.CustomerID,
+				SeriesID:   &series.ID,
+				UserID:     input.UserID,
+				StartTime:  start,
+				Notes:      input.Notes,
+			}
+			for _, it := range input.Items {
+				occ.Items = append(occ.Items, barberBookingModels.AppointmentItem{ServiceID: it.ServiceID})
+			}
+
+			res := barberBookingPort.SeriesOccurrenceResult{StartTime: start}
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				if err := (&appointmentService{DB: sp}).createAppointmentTx(ctx, sp, occ); err != nil {
+					return err
+				}
+				var userID, custID *uint
+				if occ.UserID != nil {
+					userID = occ.UserID
+				} else {
+					custID = &occ.CustomerID
+				}
+				return logStatusChangeTx(sp, occ.ID, "", string(occ.Status), userID, custID, "initial creation")
+			})
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				res.AppointmentID = &occ.ID
+				booked = append(booked, occ)
+			}
+			result.Occurrences = append(result.Occurrences, res)
+		}
+
+		if len(booked) == 0 {
+			return fmt.Errorf("no occurrence could be booked: %s", result.Occurrences[0].Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, ap := range booked {
+		SlotEvents.Publish(appointmentEvent(barberBookingPort.SlotEventAppointmentCreated, ap))
+	}
+
+	return result, nil
+}
+
+func (s *appointmentSeriesService) GetSeries(ctx context.Context, tenantID, seriesID uint) (*barberBookingModels.AppointmentSeries, error) {
+	var series barberBookingModels.AppointmentSeries
+	if err := s.DB.WithContext(ctx).
+		Preload("Appointments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("start_time ASC")
+		}).
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", seriesID, tenantID).
+		First(&series).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("appointment series with ID %d not found", seriesID)
+		}
+		return nil, fmt.Errorf("failed to fetch appointment series: %w", err)
+	}
+	return &series, nil
+}
+
+// occurrencesInScope คืนนัดที่ได้รับผลตามขอบเขต เรียงตามเวลา
+// ครั้งที่จบหรือยกเลิกไปแล้วจะไม่ถูกแตะ (ยกเว้นครั้งที่ระบุเอง เพื่อให้ service เดิมตอบ error ตามปกติ)
+func (s *appointmentSeriesService) occurrencesInScope(
+	ctx context.Context,
+	tenantID, appointmentID uint,
+	scope barberBookingPort.SeriesScope,
+) (barberBookingModels.Appointment, []barberBookingModels.Appointment, error) {
+	var ap barberBookingModels.Appointment
+	if err := s.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", appointmentID, tenantID).
+		First(&ap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ap, nil, fmt.Errorf("appointment with ID %d not found", appointmentID)
+		}
+		return ap, nil, err
+	}
+
+	switch scope {
+	case "", barberBookingPort.ScopeThisOccurrence:
+		return ap, []barberBookingModels.Appointment{ap}, nil
+	case barberBookingPort.ScopeThisAndAfter, barberBookingPort.ScopeEntireSeries:
+	default:
+		return ap, nil, fmt.Errorf("invalid scope: %s", scope)
+	}
+	if ap.SeriesID == nil {
+		return ap, []barberBookingModels.Appointment{ap}, nil
+	}
+
+	q := s.DB.WithContext(ctx).
+		Where("series_id = ? AND tenant_id = ? AND deleted_at IS NULL", *ap.SeriesID, tenantID).
+		Where("id = ? OR status NOT IN ?", ap.ID, []barberBookingModels.AppointmentStatus{
+			barberBookingModels.StatusComplete,
+			barberBookingModels.StatusCancelled,
+		})
+	if scope == barberBookingPort.ScopeThisAndAfter {
+		q = q.Where("start_time >= ?", ap.StartTime)
+	}
+
+	var targets []barberBookingModels.Appointment
+	if err := q.Order("start_time ASC").Find(&targets).Error; err != nil {
+		return ap, nil, fmt.Errorf("failed to fetch series appointments: %w", err)
+	}
+	return ap, targets, nil
+}
+
+func (s *appointmentSeriesService) CancelOccurrences(
+	ctx context.Context,
+	tenantID, appointmentID uint,
+	scope barberBookingPort.SeriesScope,
+	actorUserID *uint,
+	actorCustomerID *uint,
+) (*barberBookingPort.SeriesResult, error) {
+	ap, targets, err := s.occurrencesInScope(ctx, tenantID, appointmentID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &barberBookingPort.SeriesResult{}
+	if ap.SeriesID != nil {
+		result.SeriesID = *ap.SeriesID
+	}
+	for _, t := range targets {
+		res := barberBookingPort.SeriesOccurrenceResult{StartTime: t.StartTime, AppointmentID: ptr(t.ID)}
+		if err := s.Appointments.CancelAppointment(ctx, t.ID, actorUserID, actorCustomerID); err != nil {
+			if len(targets) == 1 {
+				return nil, err
+			}
+			res.Error = err.Error()
+		}
+		result.Occurrences = append(result.Occurrences, res)
+	}
+	return result, nil
+}
+
+func (s *appointmentSeriesService) RescheduleOccurrences(
+	ctx context.Context,
+	tenantID, appointmentID uint,
+	newStartTime time.Time,
+	scope barberBookingPort.SeriesScope,
+	actorUserID *uint,
+	actorCustomerID *uint,
+) (*barberBookingPort.SeriesResult, error) {
+	ap, targets, err := s.occurrencesInScope(ctx, tenantID, appointmentID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &barberBookingPort.SeriesResult{
+		Occurrences: make([]barberBookingPort.SeriesOccurrenceResult, len(targets)),
+	}
+	if ap.SeriesID != nil {
+		result.SeriesID = *ap.SeriesID
+	}
+
+	// ทุกครั้งเลื่อนด้วยระยะเท่ากัน; ถ้าเลื่อนไปข้างหน้าให้ทำจากครั้งสุดท้ายก่อน
+	// จะได้ไม่ชนกับครั้งถัดไปของ series เดียวกันที่ยังไม่ได้เลื่อน
+	delta := newStartTime.Sub(ap.StartTime)
+	for n := range targets {
+		i := n
+		if delta > 0 {
+			i = len(targets) - 1 - n
+		}
+		t := targets[i]
+		newStart := t.StartTime.Add(delta)
+		res := barberBookingPort.SeriesOccurrenceResult{StartTime: newStart, AppointmentID: ptr(t.ID)}
+		if err := s.Appointments.RescheduleAppointment(ctx, t.TenantID, t.ID, newStart, actorUserID, actorCustomerID); err != nil {
+			if len(targets) == 1 {
+				return nil, err
+			}
+			res.Error = err.Error()
+		}
+		result.Occurrences[i] = res
+	}
+	return result, nil
+}
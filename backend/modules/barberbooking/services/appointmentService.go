@@ -11,6 +11,7 @@ import (
 	barberBookingModels "myapp/modules/barberbooking/models"
 	barberBookingPort "myapp/modules/barberbooking/port"
 	coreModels "myapp/modules/core/models"
+	webhookModels "myapp/modules/webhook/models"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -32,10 +33,12 @@ func NewAppointmentService(
 }
 
 // checkBarberAvailabilityTx...
+// excludeAppointmentID คือนัดที่กำลังแก้ไข (ไม่นับว่าทับตัวเอง) ใช้ 0 เมื่อจองใหม่
 func (s *appointmentService) checkBarberAvailabilityTx(
 	tx *gorm.DB,
 	tenantID, barberID uint,
 	start, end time.Time,
+	excludeAppointmentID uint,
 ) (bool, error) {
 	// 1) Normalize to UTC
 	start = start.UTC()
@@ -55,20 +58,36 @@ func (s *appointmentService) checkBarberAvailabilityTx(
 		return false, err
 	}
 
-	// 3) ตรวจสอบ overlap กับ existing appointments
-	var count int64
-	if err := tx.
+	// 3) ช่วงเวลาต้องอยู่ในเวลาทำการของสาขาและเวลางานของช่าง
+	works, err := barberWorksDuringTx(tx, tenantID, barber.BranchID, barberID, start, end)
+	if err != nil {
+		return false, err
+	}
+	if !works {
+		return false, nil
+	}
+
+	// 4) ช่วงเวลาต้องไม่ชนกับวันหยุด/เวลาพักของช่างหรือของสาขา
+	blocked, err := barberUnavailableTx(tx, barber.BranchID, barberID, start, end)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, nil
+	}
+
+	// 5) ตรวจสอบ overlap กับ existing appointments
+	q := tx.
 		Model(&barberBookingModels.Appointment{}).
 		Where("tenant_id = ? AND barber_id = ? AND status IN ? AND deleted_at IS NULL",
-			tenantID, barberID,
-			[]string{
-				string(barberBookingModels.StatusPending),
-				string(barberBookingModels.StatusConfirmed),
-			},
-		).
-		// Time comparisons in UTC
-		Where("start_time < ? AND end_time > ?", end, start).
-		Count(&count).Error; err != nil {
+			tenantID, barberID, slotBlockingStatuses).
+		// Time comparisons in UTC (ใช้ช่วง block ที่รวม buffer แล้ว)
+		Where("block_start < ? AND block_end > ?", end, start)
+	if excludeAppointmentID != 0 {
+		q = q.Where("id <> ?", excludeAppointmentID)
+	}
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
 		return false, err
 	}
 
@@ -108,9 +127,170 @@ func (s *appointmentService) getOrCreateGuestCustomerTx(
 	if err := tx.Create(&customer).Error; err != nil {
 		return nil, fmt.Errorf("failed to create guest customer: %w", err)
 	}
+	if err := publishCustomerWebhookTx(tx, &customer); err != nil {
+		return nil, err
+	}
 	return &customer, nil
 }
 
+// bookingSpan ระยะเวลาของนัด (ที่ลูกค้าเห็น) และ buffer ก่อน/หลังที่ช่างต้องว่างด้วย
+type bookingSpan struct {
+	Duration     time.Duration
+	BufferBefore time.Duration
+	BufferAfter  time.Duration
+}
+
+func serviceSpan(svc barberBookingModels.Service) bookingSpan {
+	return bookingSpan{
+		Duration:     time.Duration(svc.Duration) * time.Minute,
+		BufferBefore: time.Duration(svc.BufferBefore) * time.Minute,
+		BufferAfter:  time.Duration(svc.BufferAfter) * time.Minute,
+	}
+}
+
+// block คืนช่วงที่ช่างไม่ว่างจริง เมื่อนัดเริ่มที่ start
+func (b bookingSpan) block(start time.Time) (time.Time, time.Time) {
+	return start.Add(-b.BufferBefore), start.Add(b.Duration + b.BufferAfter)
+}
+
+// resolveAppointmentItemsTx โหลด service ตามลำดับที่เลือก แล้วสร้าง line item
+// พร้อม snapshot ของ duration/price และคืนระยะเวลารวมของทุกรายการ
+// buffer ก่อนเริ่มใช้ของบริการแรก, buffer หลังจบใช้ของบริการสุดท้าย
+func (s *appointmentService) resolveAppointmentItemsTx(
+	tx *gorm.DB,
+	tenantID uint,
+	serviceIDs []uint,
+) ([]barberBookingModels.AppointmentItem, bookingSpan, error) {
+	var span bookingSpan
+	if len(serviceIDs) == 0 {
+		return nil, span, errors.New("at least one service is required")
+	}
+
+	var services []barberBookingModels.Service
+	if err := tx.
+		Where("id IN ? AND tenant_id = ? AND deleted_at IS NULL", serviceIDs, tenantID).
+		Find(&services).Error; err != nil {
+		return nil, span, fmt.Errorf("failed to fetch services: %w", err)
+	}
+	byID := make(map[uint]barberBookingModels.Service, len(services))
+	for _, svc := range services {
+		byID[svc.ID] = svc
+	}
+
+	items := make([]barberBookingModels.AppointmentItem, 0, len(serviceIDs))
+	for i, id := range serviceIDs {
+		svc, ok := byID[id]
+		if !ok {
+			return nil, span, fmt.Errorf("service not found or access denied")
+		}
+		if svc.Duration <= 0 {
+			return nil, span, fmt.Errorf("duration must be > 0")
+		}
+		if i == 0 {
+			span.BufferBefore = time.Duration(svc.BufferBefore) * time.Minute
+		}
+		span.BufferAfter = time.Duration(svc.BufferAfter) * time.Minute
+		items = append(items, barberBookingModels.AppointmentItem{
+			ServiceID: svc.ID,
+			Position:  i,
+			Duration:  svc.Duration,
+			Price:     svc.Price,
+		})
+		span.Duration += time.Duration(svc.Duration) * time.Minute
+	}
+	return items, span, nil
+}
+
+// appointmentDuration ระยะเวลารวมของนัดหมาย จาก line items
+// (นัดหมายเก่าที่ยังไม่มี line item ใช้ Duration ของ Service หลัก)
+func appointmentDuration(ap barberBookingModels.Appointment) time.Duration {
+	if len(ap.Items) == 0 {
+		return time.Duration(ap.Service.Duration) * time.Minute
+	}
+	var total time.Duration
+	for _, it := range ap.Items {
+		total += time.Duration(it.Duration) * time.Minute
+	}
+	return total
+}
+
+// slotBlockingStatuses สถานะที่ถือว่าช่างไม่ว่างในช่วงเวลาของนัด (ต้องตรงกับ excl_appointments_barber_block)
+var slotBlockingStatuses = []barberBookingModels.AppointmentStatus{
+	barberBookingModels.StatusPending,
+	barberBookingModels.StatusConfirmed,
+	barberBookingModels.StatusInService,
+}
+
+// blocksSlot สถานะที่ถือว่าช่างไม่ว่างในช่วงเวลาของนัด
+func blocksSlot(status barberBookingModels.AppointmentStatus) bool {
+	for _, s := range slotBlockingStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+func preloadAppointmentItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+func toAppointmentItemDTOs(items []barberBookingModels.AppointmentItem) []barberBookingDto.AppointmentItemDTO {
+	out := make([]barberBookingDto.AppointmentItemDTO, 0, len(items))
+	for _, it := range items {
+		out = append(out, barberBookingDto.AppointmentItemDTO{
+			ServiceID: it.ServiceID,
+			Position:  it.Position,
+			Duration:  it.Duration,
+			Price:     it.Price,
+		})
+	}
+	return out
+}
+
+func toAppointmentItemBriefs(items []barberBookingModels.AppointmentItem) []barberBookingPort.AppointmentItemBrief {
+	out := make([]barberBookingPort.AppointmentItemBrief, 0, len(items))
+	for _, it := range items {
+		out = append(out, barberBookingPort.AppointmentItemBrief{
+			ServiceID: it.ServiceID,
+			Name:      it.Service.Name,
+			Position:  it.Position,
+			Duration:  it.Duration,
+			Price:     int(it.Price),
+		})
+	}
+	return out
+}
+
+// ensureSlotBookableTx ตรวจว่าช่วง block ของนัด (ช่าง/เวลา/บริการที่จะบันทึก) จองได้:
+// ช่างอยู่สาขาเดียวกัน, ว่างตาม checkBarberAvailabilityTx (lock แถวช่าง, เวลางาน, วันหยุด, นัดอื่น) และไม่มี lock ของลูกค้าอื่น
+func (s *appointmentService) ensureSlotBookableTx(tx *gorm.DB, ap *barberBookingModels.Appointment) error {
+	var barber barberBookingModels.Barber
+	if err := tx.
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", ap.BarberID, ap.TenantID).
+		First(&barber).Error; err != nil {
+		return fmt.Errorf("barber not found or mismatched branch")
+	}
+	if barber.BranchID != ap.BranchID {
+		return fmt.Errorf("barber not found or mismatched branch")
+	}
+	available, err := s.checkBarberAvailabilityTx(tx, ap.TenantID, ap.BarberID, ap.BlockStart, ap.BlockEnd, ap.ID)
+	if err != nil {
+		return fmt.Errorf("check barber availability failed: %w", err)
+	}
+	if !available {
+		return fmt.Errorf("barber is not available during this time")
+	}
+	locked, err := lockedByOthersTx(tx, ap.TenantID, ap.BarberID, ap.CustomerID, ap.BlockStart, ap.BlockEnd)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return fmt.Errorf("slot is currently locked by another customer")
+	}
+	return nil
+}
+
 func (s *appointmentService) CheckBarberAvailability(
 	ctx context.Context,
 	tenantID, barberID uint,
@@ -120,7 +300,7 @@ func (s *appointmentService) CheckBarberAvailability(
 	var available bool
 	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var err error
-		available, err = s.checkBarberAvailabilityTx(tx, tenantID, barberID, start, end)
+		available, err = s.checkBarberAvailabilityTx(tx, tenantID, barberID, start, end, 0)
 		return err
 	})
 	return available, err
@@ -134,7 +314,7 @@ func (s *appointmentService) CreateAppointment(
 	if input == nil {
 		return nil, errors.New("input appointment data is required")
 	}
-	if input.TenantID == 0 || input.BranchID == 0 || input.ServiceID == 0 || input.StartTime.IsZero() {
+	if input.TenantID == 0 || input.BranchID == 0 || (input.ServiceID == 0 && len(input.Items) == 0) || input.StartTime.IsZero() {
 		return nil, errors.New("missing required fields")
 	}
 
@@ -142,77 +322,9 @@ func (s *appointmentService) CreateAppointment(
 
 	// 1. สร้าง appointment ภายใน transaction
 	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 0. ตรวจว่า branch มีอยู่และสังกัด tenant เดียวกัน
-		var branch coreModels.Branch
-		if err := tx.
-			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.BranchID, input.TenantID).
-			First(&branch).Error; err != nil {
-			return fmt.Errorf("branch not found or access denied")
-		}
-
-		// 1. ดึง service + ตรวจ tenant
-		var service barberBookingModels.Service
-		if err := tx.
-			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.ServiceID, input.TenantID).
-			First(&service).Error; err != nil {
-			return fmt.Errorf("service not found or access denied")
-		}
-		if service.Duration <= 0 {
-			return fmt.Errorf("duration must be > 0")
-		}
-
-		// 2. คำนวณ EndTime
-		startTime := input.StartTime
-		endTime := startTime.Add(time.Duration(service.Duration) * time.Minute)
-		input.EndTime = endTime
-
-		// 3. ตรวจ availability ถ้ามี barber_id
-		if input.BarberID != 0 {
-			var barber barberBookingModels.Barber
-			if err := tx.
-				Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.BarberID, input.TenantID).
-				First(&barber).Error; err != nil {
-				return fmt.Errorf("barber not found or mismatched branch")
-			}
-			if barber.BranchID != input.BranchID {
-				return fmt.Errorf("barber not found or mismatched branch")
-			}
-			available, err := s.checkBarberAvailabilityTx(
-				tx, input.TenantID, input.BarberID, startTime, endTime,
-			)
-			if err != nil {
-				return fmt.Errorf("check barber availability failed: %w", err)
-			}
-			if !available {
-				return fmt.Errorf("barber is not available during this time")
-			}
-
-		}
-
-		if input.CustomerID == 0 && input.Customer != nil {
-			customer, err := s.getOrCreateGuestCustomerTx(tx,
-				input.TenantID, input.BranchID, input.Customer.Name, input.Customer.Phone,
-			)
-			if err != nil {
-				return err
-			}
-			input.CustomerID = customer.ID
-		} else if input.CustomerID == 0 {
-			return fmt.Errorf("guest customer requires 'Customer' field with name and phone")
-		}
-
-		// 4. ตั้งค่า Status/Timestamps แล้วสร้างแถว appointment
-		if input.Status == "" {
-			input.Status = barberBookingModels.StatusConfirmed
-		}
-		now := time.Now().UTC()
-		input.CreatedAt = now
-		input.UpdatedAt = now
-
-		if err := tx.Create(input).Error; err != nil {
-			return fmt.Errorf("failed to create appointment: %w", err)
+		if err := s.createAppointmentTx(ctx, tx, input); err != nil {
+			return err
 		}
-
 		// เซ็ตผลลัพธ์เพื่อคืนค่าหลัง transaction
 		appt = input
 		return nil
@@ -220,6 +332,7 @@ func (s *appointmentService) CreateAppointment(
 	if err != nil {
 		return nil, err
 	}
+	SlotEvents.Publish(appointmentEvent(barberBookingPort.SlotEventAppointmentCreated, appt))
 
 	// 2. นอก transaction: เขียน status log
 	var userID *uint
@@ -248,6 +361,7 @@ func (s *appointmentService) CreateAppointment(
 		TenantID:   appt.TenantID,
 		BranchID:   appt.BranchID,
 		ServiceID:  appt.ServiceID,
+		Items:      toAppointmentItemDTOs(appt.Items),
 		BarberID:   appt.BarberID,
 		CustomerID: appt.CustomerID,
 		StartTime:  appt.StartTime,
@@ -261,31 +375,152 @@ func (s *appointmentService) CreateAppointment(
 
 }
 
-func (s *appointmentService) GetAvailableBarbers(ctx context.Context, tenantID, branchID uint, start, end time.Time) ([]barberBookingModels.Barber, error) {
-	var barbers []barberBookingModels.Barber
+// createAppointmentTx ตรวจและสร้างนัดใน tx ที่ผู้เรียกถืออยู่ (รวม outbox แจ้งลูกค้า, webhook และงานแจ้งเตือน)
+// ผู้เรียกต้อง publish SlotEvents หลัง commit เอง
+func (s *appointmentService) createAppointmentTx(ctx context.Context, tx *gorm.DB, input *barberBookingModels.Appointment) error {
+	// 0. ตรวจว่า branch มีอยู่และสังกัด tenant เดียวกัน
+	var branch coreModels.Branch
+	if err := tx.
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.BranchID, input.TenantID).
+		First(&branch).Error; err != nil {
+		return fmt.Errorf("branch not found or access denied")
+	}
+
+	// 1. ดึง service ทุกรายการ (ตามลำดับ) + ตรวจ tenant
+	serviceIDs := make([]uint, 0, len(input.Items))
+	for _, it := range input.Items {
+		serviceIDs = append(serviceIDs, it.ServiceID)
+	}
+	if len(serviceIDs) == 0 {
+		serviceIDs = append(serviceIDs, input.ServiceID)
+	}
+	items, span, err := s.resolveAppointmentItemsTx(tx, input.TenantID, serviceIDs)
+	if err != nil {
+		return err
+	}
+	input.ServiceID = items[0].ServiceID
+	input.Items = items
+
+	// 2. คำนวณ EndTime จากระยะเวลารวมของทุกบริการ และช่วง block ที่รวม buffer
+	startTime := input.StartTime
+	endTime := startTime.Add(span.Duration)
+	input.EndTime = endTime
+	blockStart, blockEnd := span.block(startTime)
+	input.BlockStart, input.BlockEnd = blockStart, blockEnd
+
+	// 3. ระบุลูกค้า (guest → สร้าง customer ใหม่) ก่อนตรวจความเป็นเจ้าของ lock
+	if input.CustomerID == 0 && input.Customer != nil {
+		customer, err := s.getOrCreateGuestCustomerTx(tx,
+			input.TenantID, input.BranchID, input.Customer.Name, input.Customer.Phone,
+		)
+		if err != nil {
+			return err
+		}
+		input.CustomerID = customer.ID
+	} else if input.CustomerID == 0 {
+		return fmt.Errorf("guest customer requires 'Customer' field with name and phone")
+	}
+
+	// 4. แนบ lock มา → ต้องเป็น lock ของลูกค้าคนนี้ที่ยังไม่หมดอายุและตรงกับช่าง/เวลา
+	if input.LockID != nil {
+		if _, err := claimLockTx(tx, input); err != nil {
+			return err
+		}
+	}
+
+	// 5. ไม่ระบุช่าง → เลือกช่างตาม strategy ของสาขา, ระบุช่าง → ตรวจ availability และ lock ของลูกค้าอื่น
+	if input.BarberID == 0 {
+		barberID, err := s.assignBarberTx(ctx, tx, input.TenantID, input.BranchID, input.CustomerID, blockStart, blockEnd)
+		if err != nil {
+			return err
+		}
+		input.BarberID = barberID
+	} else if err := s.ensureSlotBookableTx(tx, input); err != nil {
+		return err
+	}
+
+	// 6. ตั้งค่า Status/Timestamps แล้วสร้างแถว appointment
+	if input.Status == "" {
+		input.Status = barberBookingModels.StatusConfirmed
+	}
+	if input.Status != barberBookingModels.StatusPending && input.Status != barberBookingModels.StatusConfirmed {
+		return fmt.Errorf("invalid initial status %s: new appointments must be PENDING or CONFIRMED", input.Status)
+	}
+	now := time.Now().UTC()
+	input.CreatedAt = now
+	input.UpdatedAt = now
+
+	if err := tx.Create(input).Error; err != nil {
+		if isSlotTaken(err) {
+			return errSlotTaken
+		}
+		return fmt.Errorf("failed to create appointment: %w", err)
+	}
+
+	// 7. ใช้ lock แล้ว → ปิดใน transaction เดียวกัน
+	if input.LockID != nil {
+		if err := consumeLockTx(tx, *input.LockID, input.ID); err != nil {
+			return err
+		}
+	}
 
-	activeStatuses := []barberBookingModels.AppointmentStatus{
-		barberBookingModels.StatusPending,
-		barberBookingModels.StatusConfirmed,
-		barberBookingModels.StatusRescheduled,
+	// 8. แจ้งลูกค้า, webhook ของร้าน และตั้งแจ้งเตือนก่อนนัด (outbox/jobs ใน transaction เดียวกัน ส่งจริงโดย jobworker)
+	if err := notifyCustomerTx(tx, NotifyAppointmentCreated, input, nil); err != nil {
+		return err
+	}
+	if err := publishAppointmentWebhookTx(tx, webhookModels.EventAppointmentCreated, input, nil); err != nil {
+		return err
+	}
+	if err := scheduleRemindersTx(tx, input); err != nil {
+		return err
 	}
 
+	return nil
+}
+
+func (s *appointmentService) GetAvailableBarbers(ctx context.Context, tenantID, branchID uint, start, end time.Time) ([]barberBookingModels.Barber, error) {
+	var barbers []barberBookingModels.Barber
+
 	tx := s.DB.WithContext(ctx)
 
-	subQuery := tx.Model(&barberBookingModels.Appointment{}).
+	// ช่างที่มีนัดกันเวลาอยู่ (ช่วง block รวม buffer) หรือมี lock ที่ยังไม่หมดอายุทับช่วงที่ขอ
+	booked := tx.Model(&barberBookingModels.Appointment{}).
+		Select("barber_id").
+		Where("tenant_id = ? AND barber_id IS NOT NULL AND status IN ? AND deleted_at IS NULL",
+			tenantID, slotBlockingStatuses).
+		Where("block_start < ? AND block_end > ?", end, start)
+	locked := tx.Model(&barberBookingModels.AppointmentLock{}).
 		Select("barber_id").
-		Where(`
-			tenant_id = ?
-			AND barber_id IS NOT NULL
-			AND status IN ?
-			AND NOT (end_time <= ? OR start_time >= ?)
-		`, tenantID, activeStatuses, start, end)
-
-	err := tx.Model(&barberBookingModels.Barber{}).
-		Where("tenant_id = ? AND branch_id = ? AND id NOT IN (?)", tenantID, branchID, subQuery).
-		Find(&barbers).Error
-
-	return barbers, err
+		Where("tenant_id = ? AND is_active = ? AND expires_at > ?", tenantID, true, time.Now()).
+		Where("block_start < ? AND block_end > ?", end, start)
+
+	if err := tx.Model(&barberBookingModels.Barber{}).
+		Where("tenant_id = ? AND branch_id = ? AND deleted_at IS NULL", tenantID, branchID).
+		Where("id NOT IN (?) AND id NOT IN (?)", booked, locked).
+		Find(&barbers).Error; err != nil {
+		return nil, err
+	}
+
+	// ตัดช่างที่อยู่นอกเวลาทำการของสาขา/เวลางานของตัวเอง หรือติดวันหยุด/เวลาพักออก
+	loc := time.Now().Location()
+	from, to := start.In(loc), end.In(loc)
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	lastDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	schedules, err := loadBarberSchedulesTx(tx, tenantID, branchID, barberIDsOf(barbers), firstDay, lastDay)
+	if err != nil {
+		return nil, err
+	}
+	unavailabilities, err := loadUnavailabilitiesTx(tx, branchID, barberIDsOf(barbers), firstDay, lastDay)
+	if err != nil {
+		return nil, err
+	}
+	available := make([]barberBookingModels.Barber, 0, len(barbers))
+	for _, b := range barbers {
+		if schedules.covers(b.ID, start, end) && !unavailableDuring(unavailabilities, b.ID, start, end) {
+			available = append(available, b)
+		}
+	}
+	return available, nil
 }
 
 func (s *appointmentService) UpdateAppointment(
@@ -299,53 +534,92 @@ func (s *appointmentService) UpdateAppointment(
 	}
 
 	var updatedAppt *barberBookingModels.Appointment
+	var slotChanged bool
+	var oldStart, oldEnd time.Time
+	var oldBarberID uint
+	var hold *barberBookingModels.WaitlistEntry
 
 	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1. โหลด appointment ปัจจุบัน
+		// 1. โหลด appointment ปัจจุบัน (lock แถวกันแก้ไขพร้อมกับ reschedule/เปลี่ยนสถานะ)
 		var ap barberBookingModels.Appointment
 		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Service").
+			Preload("Items", preloadAppointmentItems).
 			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", id, tenantID).
 			First(&ap).Error; err != nil {
 			return fmt.Errorf("appointment not found")
 		}
-		oldStatus := ap.Status
+		before, after := ap.StartTime.Sub(ap.BlockStart), ap.BlockEnd.Sub(ap.EndTime)
+		oldStart, oldEnd, oldBarberID = ap.StartTime, ap.EndTime, ap.BarberID
+		oldBlockStart, oldBlockEnd := ap.BlockStart, ap.BlockEnd
+		if input.Status != "" && input.Status != ap.Status {
+			return fmt.Errorf("cannot change status via update: use the status transition endpoints")
+		}
 
-		// 2. อัปเดต ServiceID และคำนวณ EndTime ใหม่ถ้า service เปลี่ยน
-		if input.ServiceID != 0 && input.ServiceID != ap.ServiceID {
-			// ตรวจ service + ดึง duration
-			var svc barberBookingModels.Service
+		// 2. ถ้าเปลี่ยนรายการบริการ ให้แทนที่ line items ทั้งหมด
+		var serviceIDs []uint
+		for _, it := range input.Items {
+			serviceIDs = append(serviceIDs, it.ServiceID)
+		}
+		if len(serviceIDs) == 0 && input.ServiceID != 0 && input.ServiceID != ap.ServiceID {
+			serviceIDs = []uint{input.ServiceID}
+		}
+		startChanged := !input.StartTime.IsZero() && !input.StartTime.Equal(ap.StartTime)
+		barberChanged := input.BarberID != 0 && input.BarberID != ap.BarberID
+		slotChanged = len(serviceIDs) > 0 || startChanged || barberChanged
+		// เปลี่ยนช่าง/เวลา/บริการได้เฉพาะนัดที่ยังไม่เริ่ม และต้องผ่านการตรวจเดียวกับการจอง
+		if slotChanged && ap.Status != barberBookingModels.StatusPending && ap.Status != barberBookingModels.StatusConfirmed {
+			return fmt.Errorf("cannot change the time, services or barber of an appointment in status %s", ap.Status)
+		}
+		if len(serviceIDs) > 0 {
+			items, span, err := s.resolveAppointmentItemsTx(tx, tenantID, serviceIDs)
+			if err != nil {
+				return err
+			}
+			before, after = span.BufferBefore, span.BufferAfter
 			if err := tx.
-				Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.ServiceID, tenantID).
-				First(&svc).Error; err != nil {
-				return fmt.Errorf("service not found or access denied")
+				Where("appointment_id = ?", ap.ID).
+				Delete(&barberBookingModels.AppointmentItem{}).Error; err != nil {
+				return fmt.Errorf("failed to replace appointment items: %w", err)
+			}
+			for i := range items {
+				items[i].AppointmentID = ap.ID
+			}
+			if err := tx.Create(&items).Error; err != nil {
+				return fmt.Errorf("failed to replace appointment items: %w", err)
 			}
-			ap.ServiceID = input.ServiceID
-			// recalc end time บน startTime ปัจจุบัน
-			ap.EndTime = ap.StartTime.Add(time.Duration(svc.Duration) * time.Minute)
+			ap.ServiceID = items[0].ServiceID
+			ap.Items = items
 		}
 
-		// 3. ถ้าเปลี่ยน startTime ให้ recalc EndTime ด้วยเช่นกัน
-		if !input.StartTime.IsZero() && !input.StartTime.Equal(ap.StartTime) {
+		// 3. ถ้าเปลี่ยน startTime หรือรายการบริการ ให้ recalc EndTime จากระยะเวลารวม
+		if startChanged {
 			ap.StartTime = input.StartTime
-			// ดึง duration ของ service เดิม
-			var svc barberBookingModels.Service
-			if err := tx.
-				Where("id = ?", ap.ServiceID).
-				First(&svc).Error; err != nil {
-				return fmt.Errorf("failed fetching service for recalc end time")
-			}
-			ap.EndTime = ap.StartTime.Add(time.Duration(svc.Duration) * time.Minute)
 		}
+		ap.EndTime = ap.StartTime.Add(appointmentDuration(ap))
+		ap.BlockStart, ap.BlockEnd = ap.StartTime.Add(-before), ap.EndTime.Add(after)
 
-		// 4. อัปเดต BarberID, CustomerID, Status, Notes ตาม input
-		if input.BarberID != 0 {
+		// 4. อัปเดต BarberID, CustomerID, Notes ตาม input (สถานะเปลี่ยนผ่าน TransitionStatus/Cancel/Reschedule เท่านั้น)
+		if barberChanged {
 			ap.BarberID = input.BarberID
 		}
 		if input.CustomerID != 0 && input.CustomerID != ap.CustomerID {
+			var n int64
+			if err := tx.Model(&barberBookingModels.Customer{}).
+				Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", input.CustomerID, tenantID).
+				Count(&n).Error; err != nil {
+				return fmt.Errorf("failed to fetch customer: %w", err)
+			}
+			if n == 0 {
+				return fmt.Errorf("customer with ID %d not found", input.CustomerID)
+			}
 			ap.CustomerID = input.CustomerID
 		}
-		if input.Status != "" && input.Status != ap.Status {
-			ap.Status = input.Status
+		if slotChanged {
+			if err := s.ensureSlotBookableTx(tx, &ap); err != nil {
+				return err
+			}
 		}
 		if input.Notes != "" {
 			ap.Notes = input.Notes
@@ -353,36 +627,41 @@ func (s *appointmentService) UpdateAppointment(
 		ap.UpdatedAt = time.Now().UTC()
 
 		// 5. Save appointment
-		if err := tx.Save(&ap).Error; err != nil {
+		if err := tx.Omit(clause.Associations).Save(&ap).Error; err != nil {
+			if isSlotTaken(err) {
+				return errSlotTaken
+			}
 			return fmt.Errorf("failed to update appointment: %w", err)
 		}
 
-		// 6. Log status change ถ้ามีการเปลี่ยนสถานะ
-		if oldStatus != ap.Status {
-			var userID *uint
-			var custID *uint
-			if input.UserID != nil {
-				userID = input.UserID
-			} else {
-				custID = &ap.CustomerID
+		if slotChanged {
+			// ช่วงเวลาเดิมของช่างเดิมที่ว่างลง → เสนอให้ลูกค้าใน waitlist (เหมือน RescheduleAppointment)
+			var err error
+			if hold, err = offerFreedSlotTx(tx, ap.TenantID, ap.BranchID, oldBarberID, oldBlockStart, oldBlockEnd); err != nil {
+				return err
 			}
-			if err := s.LogService.LogStatusChange(
-				ctx,
-				ap.ID,
-				string(oldStatus),
-				string(ap.Status),
-				userID,
-				custID,
-				"status updated via API",
-			); err != nil {
-				return fmt.Errorf("failed to log status change: %w", err)
+		}
+		if !ap.StartTime.Equal(oldStart) {
+			// เวลาเริ่มเปลี่ยน → แจ้งลูกค้า/webhook และตั้งแจ้งเตือนก่อนนัดตามเวลาใหม่แทนของเดิม
+			if err := notifyCustomerTx(tx, NotifyAppointmentRescheduled, &ap, &oldStart); err != nil {
+				return err
+			}
+			if err := publishAppointmentWebhookTx(tx, webhookModels.EventAppointmentRescheduled, &ap, &oldStart); err != nil {
+				return err
+			}
+			if err := cancelRemindersTx(tx, ap.ID); err != nil {
+				return err
+			}
+			if err := scheduleRemindersTx(tx, &ap); err != nil {
+				return err
 			}
 		}
 
-		// 7. ดึงข้อมูลใหม่พร้อม Preload relations
+		// 6. ดึงข้อมูลใหม่พร้อม Preload relations
 		var out barberBookingModels.Appointment
 		if err := tx.
 			Preload("Service").
+			Preload("Items", preloadAppointmentItems).
 			// Preload("Customer").
 			First(&out, ap.ID).Error; err != nil {
 			return fmt.Errorf("failed to fetch updated appointment: %w", err)
@@ -390,8 +669,20 @@ func (s *appointmentService) UpdateAppointment(
 		updatedAppt = &out
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return updatedAppt, err
+	if slotChanged {
+		event := appointmentEvent(barberBookingPort.SlotEventAppointmentRescheduled, updatedAppt)
+		event.PreviousStartTime, event.PreviousEndTime = &oldStart, &oldEnd
+		if oldBarberID != updatedAppt.BarberID {
+			event.PreviousBarberID = &oldBarberID
+		}
+		SlotEvents.Publish(event)
+	}
+	publishWaitlistHold(hold)
+	return updatedAppt, nil
 }
 
 func (s *appointmentService) GetAppointmentByID(ctx context.Context, id uint) (*barberBookingModels.Appointment, error) {
@@ -518,9 +809,12 @@ func (s *appointmentService) CancelAppointment(
 	actorUserID *uint,
 	actorCustomerID *uint,
 ) error {
-	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var ap barberBookingModels.Appointment
+	var ap barberBookingModels.Appointment
+	var hold *barberBookingModels.WaitlistEntry
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock แถวก่อนตรวจสถานะ กันยกเลิกซ้ำพร้อมกัน (log/แจ้งเตือน/เสนอ waitlist ซ้ำ)
 		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
 			Where("id = ? AND deleted_at IS NULL", appointmentID).
 			First(&ap).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -530,8 +824,7 @@ func (s *appointmentService) CancelAppointment(
 		}
 
 		oldStatus := ap.Status
-		if oldStatus == barberBookingModels.StatusComplete ||
-			oldStatus == barberBookingModels.StatusCancelled {
+		if err := checkStatusTransition(oldStatus, barberBookingModels.StatusCancelled); err != nil {
 			return errors.New("appointment cannot be cancelled in its current status")
 		}
 
@@ -550,9 +843,16 @@ func (s *appointmentService) CancelAppointment(
 			return err
 		}
 
+		// ช่วงเวลาที่ว่างลง → เสนอให้ลูกค้าใน waitlist
+		if blocksSlot(oldStatus) {
+			var err error
+			if hold, err = offerFreedSlotTx(tx, ap.TenantID, ap.BranchID, ap.BarberID, ap.BlockStart, ap.BlockEnd); err != nil {
+				return err
+			}
+		}
+
 		// เขียน log
-		if err := s.LogService.LogStatusChange(
-			ctx,
+		if err := logStatusChangeTx(tx,
 			appointmentID,
 			string(oldStatus),
 			string(ap.Status),
@@ -563,23 +863,44 @@ func (s *appointmentService) CancelAppointment(
 			return err
 		}
 
+		if err := notifyCustomerTx(tx, NotifyAppointmentCancelled, &ap, nil); err != nil {
+			return err
+		}
+		if err := publishAppointmentWebhookTx(tx, webhookModels.EventAppointmentCancelled, &ap, nil); err != nil {
+			return err
+		}
+		if err := cancelRemindersTx(tx, ap.ID); err != nil {
+			return err
+		}
+
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	SlotEvents.Publish(appointmentEvent(barberBookingPort.SlotEventAppointmentCancelled, &ap))
+	publishWaitlistHold(hold)
+	return nil
 }
 
 func (s *appointmentService) RescheduleAppointment(
 	ctx context.Context,
+	tenantID uint,
 	appointmentID uint,
 	newStartTime time.Time,
 	actorUserID *uint,
 	actorCustomerID *uint,
 ) error {
-	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1) Load appointment + Service
-		var ap barberBookingModels.Appointment
+	var ap barberBookingModels.Appointment
+	var oldStart, oldEnd time.Time
+	var hold *barberBookingModels.WaitlistEntry
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1) Load appointment + Service (lock แถวกันเลื่อน/แก้ไขพร้อมกัน)
 		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
 			Preload("Service").
-			Where("id = ? AND deleted_at IS NULL", appointmentID).
+			Preload("Items", preloadAppointmentItems).
+			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", appointmentID, tenantID).
 			First(&ap).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return fmt.Errorf("appointment with ID %d not found", appointmentID)
@@ -587,40 +908,42 @@ func (s *appointmentService) RescheduleAppointment(
 			return err
 		}
 
-		// 2) Only PENDING/CONFIRMED may be rescheduled
-		if ap.Status == barberBookingModels.StatusComplete ||
-			ap.Status == barberBookingModels.StatusCancelled {
-			return fmt.Errorf("cannot reschedule a completed or cancelled appointment")
+		// 2) เลื่อนนัดแล้วสถานะจะเป็น CONFIRMED จึงต้องเปลี่ยนไป CONFIRMED ได้ (หรือเป็นอยู่แล้ว)
+		if ap.Status != barberBookingModels.StatusConfirmed &&
+			checkStatusTransition(ap.Status, barberBookingModels.StatusConfirmed) != nil {
+			return fmt.Errorf("cannot reschedule an appointment in status %s", ap.Status)
 		}
 
 		// remember old values
-		oldStart := ap.StartTime
-		oldEnd := ap.EndTime
+		oldStart = ap.StartTime
+		oldEnd = ap.EndTime
+		oldBlockStart, oldBlockEnd := ap.BlockStart, ap.BlockEnd
 		oldStatus := ap.Status
 
-		// 3) Conflict check
-		newEndTime := newStartTime.Add(time.Duration(ap.Service.Duration) * time.Minute)
-		var conflict int64
-		if err := tx.Model(&barberBookingModels.Appointment{}).
-			Where(`barber_id = ? AND branch_id = ? AND id != ? 
-                   AND status IN ? AND start_time < ? AND end_time > ?`,
-				ap.BarberID, ap.BranchID, ap.ID,
-				[]barberBookingModels.AppointmentStatus{
-					barberBookingModels.StatusPending,
-					barberBookingModels.StatusConfirmed,
-				},
-				newEndTime, newStartTime,
-			).
-			Count(&conflict).Error; err != nil {
+		// 3) ช่วงใหม่ (ครอบคลุมทุกบริการในนัดหมาย รวม buffer เดิม) ต้องผ่านการตรวจเดียวกับการจอง:
+		// lock แถวช่าง, อยู่ในเวลางาน, ไม่ชนวันหยุด/เวลาพัก และไม่ทับนัดอื่น (ไม่นับตัวเอง)
+		newEndTime := newStartTime.Add(appointmentDuration(ap))
+		newBlockStart := newStartTime.Add(-oldStart.Sub(oldBlockStart))
+		newBlockEnd := newEndTime.Add(oldBlockEnd.Sub(oldEnd))
+		available, err := s.checkBarberAvailabilityTx(tx, ap.TenantID, ap.BarberID, newBlockStart, newBlockEnd, ap.ID)
+		if err != nil {
+			return fmt.Errorf("check barber availability failed: %w", err)
+		}
+		if !available {
+			return fmt.Errorf("cannot reschedule: time slot conflicts with another appointment or the barber is unavailable")
+		}
+		locked, err := lockedByOthersTx(tx, ap.TenantID, ap.BarberID, ap.CustomerID, newBlockStart, newBlockEnd)
+		if err != nil {
 			return err
 		}
-		if conflict > 0 {
-			return fmt.Errorf("cannot reschedule: time slot conflicts with another appointment")
+		if locked {
+			return fmt.Errorf("cannot reschedule: slot is currently locked by another customer")
 		}
 
 		// 4) Apply new times & updater
 		ap.StartTime = newStartTime
 		ap.EndTime = newEndTime
+		ap.BlockStart, ap.BlockEnd = newBlockStart, newBlockEnd
 		ap.UserID = actorUserID // may be nil
 		ap.UpdatedAt = time.Now().UTC()
 		ap.Status = barberBookingModels.StatusConfirmed
@@ -630,14 +953,23 @@ func (s *appointmentService) RescheduleAppointment(
 			ap.UserID = nil
 		}
 
-		if err := tx.Save(&ap).Error; err != nil {
+		if err := tx.Omit(clause.Associations).Save(&ap).Error; err != nil {
+			if isSlotTaken(err) {
+				return errSlotTaken
+			}
 			return fmt.Errorf("failed to save rescheduled appointment: %w", err)
 		}
 
+		// ช่วงเวลาเดิมที่ว่างลง → เสนอให้ลูกค้าใน waitlist
+		if blocksSlot(oldStatus) {
+			if hold, err = offerFreedSlotTx(tx, ap.TenantID, ap.BranchID, ap.BarberID, oldBlockStart, oldBlockEnd); err != nil {
+				return err
+			}
+		}
+
 		// 5) Log status-change (if it actually changed)
 		if oldStatus != ap.Status {
-			if err := s.LogService.LogStatusChange(
-				ctx,
+			if err := logStatusChangeTx(tx,
 				ap.ID,
 				string(oldStatus),
 				string(ap.Status),
@@ -645,7 +977,7 @@ func (s *appointmentService) RescheduleAppointment(
 				actorCustomerID,
 				"status updated via reschedule",
 			); err != nil {
-				return fmt.Errorf("failed to log status change: %w", err)
+				return err
 			}
 		}
 
@@ -655,8 +987,7 @@ func (s *appointmentService) RescheduleAppointment(
 			oldStart.Format(time.RFC3339), oldEnd.Format(time.RFC3339),
 			ap.StartTime.Format(time.RFC3339), ap.EndTime.Format(time.RFC3339),
 		)
-		if err := s.LogService.LogStatusChange(
-			ctx,
+		if err := logStatusChangeTx(tx,
 			ap.ID,
 			"", // no status change
 			"",
@@ -664,11 +995,33 @@ func (s *appointmentService) RescheduleAppointment(
 			actorCustomerID,
 			note,
 		); err != nil {
-			return fmt.Errorf("failed to log reschedule detail: %w", err)
+			return err
+		}
+
+		// 7) แจ้งลูกค้าเวลาใหม่ และตั้งแจ้งเตือนก่อนนัดตามเวลาใหม่แทนของเดิม
+		if err := notifyCustomerTx(tx, NotifyAppointmentRescheduled, &ap, &oldStart); err != nil {
+			return err
+		}
+		if err := publishAppointmentWebhookTx(tx, webhookModels.EventAppointmentRescheduled, &ap, &oldStart); err != nil {
+			return err
+		}
+		if err := cancelRemindersTx(tx, ap.ID); err != nil {
+			return err
+		}
+		if err := scheduleRemindersTx(tx, &ap); err != nil {
+			return err
 		}
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	event := appointmentEvent(barberBookingPort.SlotEventAppointmentRescheduled, &ap)
+	event.PreviousStartTime, event.PreviousEndTime = &oldStart, &oldEnd
+	SlotEvents.Publish(event)
+	publishWaitlistHold(hold)
+	return nil
 }
 
 func (s *appointmentService) GetAppointmentsByBranch(
@@ -729,6 +1082,10 @@ func (s *appointmentService) GetAppointmentsByBranch(
 		Preload("Service", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "description", "duration", "price")
 		}).
+		Preload("Items", preloadAppointmentItems).
+		Preload("Items.Service", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name")
+		}).
 		Preload("Barber.User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "username")
 		}).
@@ -784,6 +1141,7 @@ func (s *appointmentService) GetAppointmentsByBranch(
 				Duration:    a.Service.Duration,
 				Price:       int(a.Service.Price),
 			},
+			Items:    toAppointmentItemBriefs(a.Items),
 			BarberID: a.BarberID,
 			Barber: barberBookingPort.BarberBrief{
 				Username: a.Barber.User.Username,
@@ -927,6 +1285,10 @@ func (s *appointmentService) GetAppointmentsByBarber(
 		Preload("Service", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "description", "duration", "price")
 		}).
+		Preload("Items", preloadAppointmentItems).
+		Preload("Items.Service", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name")
+		}).
 		Preload("Barber.User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "username")
 		}).
@@ -987,6 +1349,7 @@ func (s *appointmentService) GetAppointmentsByBarber(
 				Duration:    a.Service.Duration,
 				Price:       int(a.Service.Price),
 			},
+			Items:    toAppointmentItemBriefs(a.Items),
 			BarberID: a.BarberID,
 			Barber: barberBookingPort.BarberBrief{
 				Username: a.Barber.User.Username,
@@ -1043,6 +1406,10 @@ func (s *appointmentService) GetAppointmentsByPhone(
 		Preload("Service", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "description", "duration", "price")
 		}).
+		Preload("Items", preloadAppointmentItems).
+		Preload("Items.Service", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name")
+		}).
 		Preload("Barber.User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "username")
 		}).
@@ -1065,6 +1432,7 @@ func (s *appointmentService) GetAppointmentsByPhone(
 				Duration:    a.Service.Duration,
 				Price:       int(a.Service.Price),
 			},
+			Items:    toAppointmentItemBriefs(a.Items),
 			BarberID: a.BarberID,
 			Barber: barberBookingPort.BarberBrief{
 				Username: a.Barber.User.Username,
@@ -0,0 +1,147 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+	webhookModels "myapp/modules/webhook/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appointmentTransitions ตารางการเปลี่ยนสถานะที่อนุญาต (from → to)
+// COMPLETED, NO_SHOW และ CANCELLED เป็นสถานะสุดท้าย
+var appointmentTransitions = map[barberBookingModels.AppointmentStatus][]barberBookingModels.AppointmentStatus{
+	barberBookingModels.StatusPending: {
+		barberBookingModels.StatusConfirmed,
+		barberBookingModels.StatusCancelled,
+		barberBookingModels.StatusNoShow,
+	},
+	barberBookingModels.StatusConfirmed: {
+		barberBookingModels.StatusInService,
+		barberBookingModels.StatusCancelled,
+		barberBookingModels.StatusNoShow,
+	},
+	barberBookingModels.StatusRescheduled: {
+		barberBookingModels.StatusConfirmed,
+		barberBookingModels.StatusInService,
+		barberBookingModels.StatusCancelled,
+		barberBookingModels.StatusNoShow,
+	},
+	barberBookingModels.StatusInService: {
+		barberBookingModels.StatusComplete,
+	},
+}
+
+var frontDeskRoles = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+	coreModels.RoleNameAssistantManager,
+	coreModels.RoleNameStaff,
+}
+
+// transitionRoles role ที่เปลี่ยนไปยังสถานะนั้นผ่าน TransitionStatus ได้
+// (ยกเลิก/เลื่อนนัดมี flow ของตัวเองและลูกค้าทำเองได้)
+var transitionRoles = map[barberBookingModels.AppointmentStatus][]coreModels.RoleName{
+	barberBookingModels.StatusConfirmed: frontDeskRoles,
+	barberBookingModels.StatusInService: frontDeskRoles,
+	barberBookingModels.StatusComplete:  frontDeskRoles,
+	barberBookingModels.StatusNoShow: {
+		coreModels.RoleNameSaaSSuperAdmin,
+		coreModels.RoleNameTenant,
+		coreModels.RoleNameTenantAdmin,
+		coreModels.RoleNameBranchAdmin,
+		coreModels.RoleNameAssistantManager,
+	},
+}
+
+// checkStatusTransition ตรวจกับตาราง appointmentTransitions
+func checkStatusTransition(from, to barberBookingModels.AppointmentStatus) error {
+	for _, next := range appointmentTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot change appointment status from %s to %s", from, to)
+}
+
+func (s *appointmentService) TransitionStatus(
+	ctx context.Context,
+	tenantID, appointmentID uint,
+	to barberBookingModels.AppointmentStatus,
+	input barberBookingPort.StatusTransitionInput,
+) (*barberBookingModels.Appointment, error) {
+	roles, ok := transitionRoles[to]
+	if !ok {
+		return nil, fmt.Errorf("invalid transition target: %s", to)
+	}
+	if !helperFunc.IsAuthorizedRole(input.ActorRole, roles) {
+		return nil, fmt.Errorf("permission denied: role %q cannot set status %s", input.ActorRole, to)
+	}
+
+	var ap barberBookingModels.Appointment
+	var hold *barberBookingModels.WaitlistEntry
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", appointmentID, tenantID).
+			First(&ap).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("appointment with ID %d not found", appointmentID)
+			}
+			return err
+		}
+
+		from := ap.Status
+		if err := checkStatusTransition(from, to); err != nil {
+			return err
+		}
+
+		ap.Status = to
+		if input.ActorUserID != nil {
+			ap.UserID = input.ActorUserID
+		}
+		ap.UpdatedAt = time.Now().UTC()
+		if err := tx.Omit(clause.Associations).Save(&ap).Error; err != nil {
+			if isSlotTaken(err) {
+				return errSlotTaken
+			}
+			return fmt.Errorf("failed to update appointment status: %w", err)
+		}
+
+		// ลูกค้าไม่มา → ช่วงเวลาที่เหลือว่างลง เสนอให้ waitlist
+		if to == barberBookingModels.StatusNoShow && blocksSlot(from) {
+			var err error
+			if hold, err = offerFreedSlotTx(tx, ap.TenantID, ap.BranchID, ap.BarberID, ap.BlockStart, ap.BlockEnd); err != nil {
+				return err
+			}
+		}
+
+		if to == barberBookingModels.StatusComplete {
+			if err := publishAppointmentWebhookTx(tx, webhookModels.EventAppointmentCompleted, &ap, nil); err != nil {
+				return err
+			}
+		}
+
+		notes := input.Notes
+		if notes == "" {
+			notes = fmt.Sprintf("status changed to %s", to)
+		}
+		return logStatusChangeTx(tx, ap.ID, string(from), string(to), input.ActorUserID, nil, notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	SlotEvents.Publish(appointmentEvent(barberBookingPort.SlotEventAppointmentStatus, &ap))
+	publishWaitlistHold(hold)
+	return &ap, nil
+}
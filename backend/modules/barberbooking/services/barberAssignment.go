@@ -0,0 +1,250 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BarberAssignmentStrategy เรียงลำดับช่างที่เป็นตัวเลือก สำหรับการจองแบบไม่ระบุช่าง
+// ช่างคนแรกในผลลัพธ์ที่ว่างจริง (ตรวจด้วย checkBarberAvailabilityTx) จะถูกเลือก
+type BarberAssignmentStrategy interface {
+	Rank(
+		ctx context.Context,
+		tx *gorm.DB,
+		setting barberBookingModels.BranchBookingSetting,
+		candidates []barberBookingModels.Barber,
+		start time.Time,
+	) ([]barberBookingModels.Barber, error)
+}
+
+var assignmentStrategies = map[barberBookingModels.AssignmentStrategy]BarberAssignmentStrategy{
+	barberBookingModels.AssignLeastLoaded:  leastLoadedStrategy{},
+	barberBookingModels.AssignRoundRobin:   roundRobinStrategy{},
+	barberBookingModels.AssignHighestRated: highestRatedStrategy{},
+}
+
+// RegisterAssignmentStrategy เพิ่มหรือแทนที่ strategy สำหรับชื่อที่กำหนด
+func RegisterAssignmentStrategy(name barberBookingModels.AssignmentStrategy, strategy BarberAssignmentStrategy) {
+	assignmentStrategies[name] = strategy
+}
+
+func IsValidAssignmentStrategy(name barberBookingModels.AssignmentStrategy) bool {
+	_, ok := assignmentStrategies[name]
+	return ok
+}
+
+// leastLoadedStrategy เลือกช่างที่มี BarberWorkload ของวันนั้นน้อยที่สุด
+type leastLoadedStrategy struct{}
+
+func (leastLoadedStrategy) Rank(
+	ctx context.Context,
+	tx *gorm.DB,
+	_ barberBookingModels.BranchBookingSetting,
+	candidates []barberBookingModels.Barber,
+	start time.Time,
+) ([]barberBookingModels.Barber, error) {
+	ids := barberIDsOf(candidates)
+
+	var workloads []barberBookingModels.BarberWorkload
+	if err := tx.WithContext(ctx).
+		Where("barber_id IN ? AND DATE(date) = ?", ids, start.Format("2006-01-02")).
+		Find(&workloads).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch barber workloads: %w", err)
+	}
+	load := make(map[uint]int, len(workloads))
+	for _, w := range workloads {
+		load[w.BarberID] = w.TotalAppointments
+	}
+
+	ranked := append([]barberBookingModels.Barber(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if load[ranked[i].ID] != load[ranked[j].ID] {
+			return load[ranked[i].ID] < load[ranked[j].ID]
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	return ranked, nil
+}
+
+// roundRobinStrategy เริ่มจากช่างคนถัดไปจากคนที่ได้รับมอบหมายล่าสุดของสาขา
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Rank(
+	_ context.Context,
+	_ *gorm.DB,
+	setting barberBookingModels.BranchBookingSetting,
+	candidates []barberBookingModels.Barber,
+	_ time.Time,
+) ([]barberBookingModels.Barber, error) {
+	ranked := append([]barberBookingModels.Barber(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].ID < ranked[j].ID })
+
+	if setting.LastAssignedBarberID == nil {
+		return ranked, nil
+	}
+	next := sort.Search(len(ranked), func(i int) bool { return ranked[i].ID > *setting.LastAssignedBarberID })
+	rotated := make([]barberBookingModels.Barber, 0, len(ranked))
+	rotated = append(rotated, ranked[next:]...)
+	rotated = append(rotated, ranked[:next]...)
+	return rotated, nil
+}
+
+// highestRatedStrategy เลือกช่างที่มีคะแนนรีวิวเฉลี่ยสูงสุด (ช่างที่ยังไม่มีรีวิวนับเป็น 0)
+type highestRatedStrategy struct{}
+
+func (highestRatedStrategy) Rank(
+	ctx context.Context,
+	tx *gorm.DB,
+	_ barberBookingModels.BranchBookingSetting,
+	candidates []barberBookingModels.Barber,
+	_ time.Time,
+) ([]barberBookingModels.Barber, error) {
+	var rows []struct {
+		BarberID uint
+		Rating   float64
+	}
+	if err := tx.WithContext(ctx).
+		Table("appointment_reviews").
+		Select("appointments.barber_id AS barber_id, AVG(appointment_reviews.rating) AS rating").
+		Joins("JOIN appointments ON appointment_reviews.appointment_id = appointments.id").
+		Where("appointments.barber_id IN ? AND appointment_reviews.deleted_at IS NULL AND appointments.deleted_at IS NULL",
+			barberIDsOf(candidates)).
+		Group("appointments.barber_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch barber ratings: %w", err)
+	}
+	rating := make(map[uint]float64, len(rows))
+	for _, r := range rows {
+		rating[r.BarberID] = r.Rating
+	}
+
+	ranked := append([]barberBookingModels.Barber(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if rating[ranked[i].ID] != rating[ranked[j].ID] {
+			return rating[ranked[i].ID] > rating[ranked[j].ID]
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	return ranked, nil
+}
+
+// getBranchBookingSettingTx คืนค่า setting ของสาขา หรือค่า default ถ้ายังไม่เคยตั้ง
+func getBranchBookingSettingTx(tx *gorm.DB, tenantID, branchID uint) (barberBookingModels.BranchBookingSetting, error) {
+	var setting barberBookingModels.BranchBookingSetting
+	err := tx.
+		Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).
+		First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultBranchBookingSetting(tenantID, branchID), nil
+	}
+	return setting, err
+}
+
+// defaultBranchBookingSetting ค่าที่ใช้กับสาขาที่ยังไม่มีแถว setting
+func defaultBranchBookingSetting(tenantID, branchID uint) barberBookingModels.BranchBookingSetting {
+	return barberBookingModels.BranchBookingSetting{
+		TenantID:                 tenantID,
+		BranchID:                 branchID,
+		AssignmentStrategy:       barberBookingModels.AssignLeastLoaded,
+		AutoNoShowEnabled:        true,
+		NoShowGraceMinutes:       15,
+		AutoCompleteEnabled:      true,
+		AutoCompleteGraceMinutes: 30,
+	}
+}
+
+// lockBranchBookingSettingTx lock แถว setting ของสาขา (สร้างแถว default ก่อนถ้ายังไม่เคยตั้ง)
+// ให้การจองแบบไม่ระบุช่างที่มาพร้อมกันอ่านและเลื่อน cursor ของ ROUND_ROBIN ทีละรายการ
+func lockBranchBookingSettingTx(tx *gorm.DB, tenantID, branchID uint) (barberBookingModels.BranchBookingSetting, error) {
+	setting, err := getBranchBookingSettingTx(tx, tenantID, branchID)
+	if err != nil {
+		return setting, err
+	}
+	if setting.ID == 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&setting).Error; err != nil {
+			return setting, err
+		}
+	}
+	var locked barberBookingModels.BranchBookingSetting
+	err = tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).
+		First(&locked).Error
+	return locked, err
+}
+
+// assignBarberTx เลือกช่างให้การจองที่ไม่ระบุช่าง ตาม strategy ของสาขา
+// lock แถว setting ของสาขาและแถวช่างทุกคนเรียงตาม id ก่อน (ลำดับเดียวกันทุก transaction จึงไม่ deadlock)
+// แล้วจัดอันดับในหน่วยความจำ ตรวจ overlap ผ่าน checkBarberAvailabilityTx และข้ามช่างที่มี lock ของลูกค้าคนอื่นทับช่วงเวลานั้น
+func (s *appointmentService) assignBarberTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	tenantID, branchID, customerID uint,
+	start, end time.Time,
+) (uint, error) {
+	setting, err := lockBranchBookingSettingTx(tx, tenantID, branchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load branch booking setting: %w", err)
+	}
+	strategy, ok := assignmentStrategies[setting.AssignmentStrategy]
+	if !ok {
+		return 0, fmt.Errorf("unknown assignment strategy: %s", setting.AssignmentStrategy)
+	}
+
+	var candidates []barberBookingModels.Barber
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND branch_id = ? AND deleted_at IS NULL", tenantID, branchID).
+		Order("id").
+		Find(&candidates).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch barbers: %w", err)
+	}
+	if len(candidates) == 0 {
+		return 0, errors.New("no barber is available during this time")
+	}
+
+	ranked, err := strategy.Rank(ctx, tx, setting, candidates, start)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, b := range ranked {
+		available, err := s.checkBarberAvailabilityTx(tx, tenantID, b.ID, start, end, 0)
+		if err != nil {
+			return 0, fmt.Errorf("check barber availability failed: %w", err)
+		}
+		if !available {
+			continue
+		}
+		locked, err := lockedByOthersTx(tx, tenantID, b.ID, customerID, start, end)
+		if err != nil {
+			return 0, err
+		}
+		if locked {
+			continue
+		}
+
+		if err := tx.Model(&setting).Update("last_assigned_barber_id", b.ID).Error; err != nil {
+			return 0, fmt.Errorf("failed to save branch booking setting: %w", err)
+		}
+		return b.ID, nil
+	}
+
+	return 0, errors.New("no barber is available during this time")
+}
+
+func barberIDsOf(barbers []barberBookingModels.Barber) []uint {
+	ids := make([]uint, 0, len(barbers))
+	for _, b := range barbers {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
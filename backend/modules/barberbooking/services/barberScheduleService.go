@@ -0,0 +1,347 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+
+	"gorm.io/gorm"
+)
+
+type barberScheduleService struct {
+	DB *gorm.DB
+}
+
+func NewBarberScheduleService(db *gorm.DB) barberBookingPort.IBarberSchedule {
+	return &barberScheduleService{DB: db}
+}
+
+func (s *barberScheduleService) GetSchedule(ctx context.Context, tenantID, barberID uint) (*barberBookingPort.BarberSchedule, error) {
+	db := s.DB.WithContext(ctx)
+	if err := findBarberTx(db, tenantID, barberID); err != nil {
+		return nil, err
+	}
+
+	schedule := &barberBookingPort.BarberSchedule{
+		BarberID:    barberID,
+		WeeklyHours: []barberBookingModels.BarberWorkingHour{},
+		Overrides:   []barberBookingModels.BarberWorkingDayOverride{},
+	}
+	if err := db.
+		Where("tenant_id = ? AND barber_id = ?", tenantID, barberID).
+		Order("weekday ASC").
+		Find(&schedule.WeeklyHours).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch barber working hours: %w", err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if err := db.
+		Where("tenant_id = ? AND barber_id = ? AND work_date >= ?", tenantID, barberID, today).
+		Order("work_date ASC").
+		Find(&schedule.Overrides).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch barber working day overrides: %w", err)
+	}
+	return schedule, nil
+}
+
+func (s *barberScheduleService) ReplaceWeeklyHours(
+	ctx context.Context,
+	tenantID, barberID uint,
+	input []barberBookingPort.BarberWorkingHourInput,
+) ([]barberBookingModels.BarberWorkingHour, error) {
+	if len(input) == 0 {
+		return nil, errors.New("weekly hours are required (use clear to follow branch hours)")
+	}
+
+	hours := make([]barberBookingModels.BarberWorkingHour, 0, len(input))
+	seen := make(map[int]bool, len(input))
+	for _, in := range input {
+		if in.Weekday < 0 || in.Weekday > 6 {
+			return nil, fmt.Errorf("invalid weekday: %d", in.Weekday)
+		}
+		if seen[in.Weekday] {
+			return nil, fmt.Errorf("invalid weekly hours: weekday %d is duplicated", in.Weekday)
+		}
+		seen[in.Weekday] = true
+
+		start, end, err := parseShift(in.StartTime, in.EndTime, in.IsOff)
+		if err != nil {
+			return nil, err
+		}
+		hours = append(hours, barberBookingModels.BarberWorkingHour{
+			TenantID:  tenantID,
+			BarberID:  barberID,
+			Weekday:   in.Weekday,
+			StartTime: start,
+			EndTime:   end,
+			IsOff:     in.IsOff,
+		})
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findBarberTx(tx, tenantID, barberID); err != nil {
+			return err
+		}
+		if err := tx.
+			Where("tenant_id = ? AND barber_id = ?", tenantID, barberID).
+			Delete(&barberBookingModels.BarberWorkingHour{}).Error; err != nil {
+			return fmt.Errorf("failed to replace barber working hours: %w", err)
+		}
+		if err := tx.Create(&hours).Error; err != nil {
+			return fmt.Errorf("failed to replace barber working hours: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+func (s *barberScheduleService) ClearWeeklyHours(ctx context.Context, tenantID, barberID uint) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findBarberTx(tx, tenantID, barberID); err != nil {
+			return err
+		}
+		if err := tx.
+			Where("tenant_id = ? AND barber_id = ?", tenantID, barberID).
+			Delete(&barberBookingModels.BarberWorkingHour{}).Error; err != nil {
+			return fmt.Errorf("failed to clear barber working hours: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *barberScheduleService) UpsertOverride(
+	ctx context.Context,
+	tenantID, barberID uint,
+	input barberBookingPort.BarberWorkingDayOverrideInput,
+) (*barberBookingModels.BarberWorkingDayOverride, error) {
+	workDate, err := time.ParseInLocation("2006-01-02", input.WorkDate, time.Now().Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid work_date format (expected YYYY-MM-DD): %w", err)
+	}
+	start, end, err := parseShift(input.StartTime, input.EndTime, input.IsOff)
+	if err != nil {
+		return nil, err
+	}
+
+	var override barberBookingModels.BarberWorkingDayOverride
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findBarberTx(tx, tenantID, barberID); err != nil {
+			return err
+		}
+
+		err := tx.
+			Where("tenant_id = ? AND barber_id = ? AND work_date = ?", tenantID, barberID, workDate).
+			First(&override).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		override.TenantID = tenantID
+		override.BarberID = barberID
+		override.WorkDate = workDate
+		override.StartTime = start
+		override.EndTime = end
+		override.IsOff = input.IsOff
+		if err := tx.Save(&override).Error; err != nil {
+			return fmt.Errorf("failed to save barber working day override: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (s *barberScheduleService) DeleteOverride(ctx context.Context, tenantID, barberID, overrideID uint) error {
+	res := s.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ? AND barber_id = ?", overrideID, tenantID, barberID).
+		Delete(&barberBookingModels.BarberWorkingDayOverride{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete barber working day override: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("barber working day override with ID %d not found", overrideID)
+	}
+	return nil
+}
+
+func findBarberTx(tx *gorm.DB, tenantID, barberID uint) error {
+	var barber barberBookingModels.Barber
+	if err := tx.
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", barberID, tenantID).
+		First(&barber).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("barber with ID %d not found", barberID)
+		}
+		return err
+	}
+	return nil
+}
+
+// parseShift แปลงเวลา HH:mm; วันหยุด (isOff) ไม่ต้องระบุเวลา
+func parseShift(startStr, endStr string, isOff bool) (helperFunc.TimeOnly, helperFunc.TimeOnly, error) {
+	if isOff && startStr == "" && endStr == "" {
+		return helperFunc.TimeOnly{}, helperFunc.TimeOnly{}, nil
+	}
+	start, err := time.Parse("15:04", startStr)
+	if err != nil {
+		return helperFunc.TimeOnly{}, helperFunc.TimeOnly{}, fmt.Errorf("invalid start_time format (expected HH:mm): %w", err)
+	}
+	end, err := time.Parse("15:04", endStr)
+	if err != nil {
+		return helperFunc.TimeOnly{}, helperFunc.TimeOnly{}, fmt.Errorf("invalid end_time format (expected HH:mm): %w", err)
+	}
+	if !isOff && !start.Before(end) {
+		return helperFunc.TimeOnly{}, helperFunc.TimeOnly{}, errors.New("invalid shift: start_time must be before end_time")
+	}
+	return helperFunc.TimeOnly{Time: start}, helperFunc.TimeOnly{Time: end}, nil
+}
+
+// barberSchedules ตารางงานของช่างหลายคนในสาขาเดียวกันที่โหลดมาครั้งเดียว ใช้ทั้งตอนสร้าง slot และตอนตรวจการจอง
+type barberSchedules struct {
+	weekly    map[uint]map[int]barberBookingModels.BarberWorkingHour
+	overrides map[uint]map[string]barberBookingModels.BarberWorkingDayOverride
+
+	// เวลาทำการของสาขา + override รายวัน
+	branchHours     map[int]barberBookingModels.WorkingHour
+	branchOverrides map[string]barberBookingModels.WorkingDayOverride
+}
+
+func loadBarberSchedulesTx(
+	tx *gorm.DB,
+	tenantID, branchID uint,
+	barberIDs []uint,
+	firstDay, lastDay time.Time,
+) (barberSchedules, error) {
+	schedules := barberSchedules{
+		weekly:          make(map[uint]map[int]barberBookingModels.BarberWorkingHour),
+		overrides:       make(map[uint]map[string]barberBookingModels.BarberWorkingDayOverride),
+		branchHours:     make(map[int]barberBookingModels.WorkingHour),
+		branchOverrides: make(map[string]barberBookingModels.WorkingDayOverride),
+	}
+
+	var branchHours []barberBookingModels.WorkingHour
+	if err := tx.
+		Where("branch_id = ? AND tenant_id = ? AND deleted_at IS NULL", branchID, tenantID).
+		Find(&branchHours).Error; err != nil {
+		return schedules, fmt.Errorf("failed to fetch working hours: %w", err)
+	}
+	for _, h := range branchHours {
+		schedules.branchHours[h.Weekday] = h
+	}
+
+	var branchOverrides []barberBookingModels.WorkingDayOverride
+	if err := tx.
+		Where("branch_id = ? AND work_date BETWEEN ? AND ? AND deleted_at IS NULL", branchID, firstDay, lastDay).
+		Find(&branchOverrides).Error; err != nil {
+		return schedules, fmt.Errorf("failed to fetch working day overrides: %w", err)
+	}
+	for _, o := range branchOverrides {
+		schedules.branchOverrides[o.WorkDate.Format("2006-01-02")] = o
+	}
+
+	if len(barberIDs) == 0 {
+		return schedules, nil
+	}
+
+	var hours []barberBookingModels.BarberWorkingHour
+	if err := tx.
+		Where("tenant_id = ? AND barber_id IN ?", tenantID, barberIDs).
+		Find(&hours).Error; err != nil {
+		return schedules, fmt.Errorf("failed to fetch barber working hours: %w", err)
+	}
+	for _, h := range hours {
+		if schedules.weekly[h.BarberID] == nil {
+			schedules.weekly[h.BarberID] = make(map[int]barberBookingModels.BarberWorkingHour)
+		}
+		schedules.weekly[h.BarberID][h.Weekday] = h
+	}
+
+	var overrides []barberBookingModels.BarberWorkingDayOverride
+	if err := tx.
+		Where("tenant_id = ? AND barber_id IN ? AND work_date BETWEEN ? AND ?", tenantID, barberIDs, firstDay, lastDay).
+		Find(&overrides).Error; err != nil {
+		return schedules, fmt.Errorf("failed to fetch barber working day overrides: %w", err)
+	}
+	for _, o := range overrides {
+		if schedules.overrides[o.BarberID] == nil {
+			schedules.overrides[o.BarberID] = make(map[string]barberBookingModels.BarberWorkingDayOverride)
+		}
+		schedules.overrides[o.BarberID][o.WorkDate.Format("2006-01-02")] = o
+	}
+	return schedules, nil
+}
+
+// shift คืนเวลาเข้า-ออกงานของช่างในวันนั้น (day เป็นเที่ยงคืนตามเวลาท้องถิ่น)
+// scheduled=false หมายถึงช่างไม่มีตารางของตัวเอง ให้ใช้เวลาทำการของสาขา
+func (s barberSchedules) shift(barberID uint, day time.Time) (start, end time.Time, scheduled, working bool) {
+	if o, ok := s.overrides[barberID][day.Format("2006-01-02")]; ok {
+		if o.IsOff {
+			return time.Time{}, time.Time{}, true, false
+		}
+		return o.StartTime.ToTime(day), o.EndTime.ToTime(day), true, true
+	}
+
+	weekly, ok := s.weekly[barberID]
+	if !ok {
+		return time.Time{}, time.Time{}, false, true
+	}
+	wh, ok := weekly[getWeekday(day)]
+	if !ok || wh.IsOff {
+		return time.Time{}, time.Time{}, true, false
+	}
+	return wh.StartTime.ToTime(day), wh.EndTime.ToTime(day), true, true
+}
+
+// window คืนช่วงที่ช่างรับงานได้ในวันนั้น: เวลาทำการของสาขา ตัดด้วยเวลางานของช่าง (ถ้ามีตารางของตัวเอง)
+// ok=false เมื่อสาขาปิดหรือช่างหยุด
+func (s barberSchedules) window(barberID uint, day time.Time) (start, end time.Time, ok bool) {
+	start, end, ok = branchWindow(day, s.branchHours, s.branchOverrides)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	shiftStart, shiftEnd, scheduled, working := s.shift(barberID, day)
+	if scheduled {
+		if !working {
+			return time.Time{}, time.Time{}, false
+		}
+		start, end = latest(start, shiftStart), earliest(end, shiftEnd)
+	}
+	return start, end, start.Before(end)
+}
+
+// covers ตรวจว่าช่วง start-end อยู่ในเวลาทำการของสาขาและเวลางานของช่างทั้งหมด
+func (s barberSchedules) covers(barberID uint, start, end time.Time) bool {
+	local := start.In(time.Now().Location())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	from, to, ok := s.window(barberID, day)
+	return ok && !start.Before(from) && !end.After(to)
+}
+
+// barberWorksDuringTx ตรวจเวลางานของช่างคนเดียวสำหรับช่วงเวลาที่จะจอง (รวม buffer)
+func barberWorksDuringTx(tx *gorm.DB, tenantID, branchID, barberID uint, start, end time.Time) (bool, error) {
+	local := start.In(time.Now().Location())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	schedules, err := loadBarberSchedulesTx(tx, tenantID, branchID, []uint{barberID}, day, day)
+	if err != nil {
+		return false, err
+	}
+	return schedules.covers(barberID, start, end), nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
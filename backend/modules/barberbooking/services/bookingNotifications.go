@@ -0,0 +1,134 @@
+package barberBookingService
+
+import (
+	"fmt"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	coreModels "myapp/modules/core/models"
+	notificationPort "myapp/modules/notification/port"
+	notificationServices "myapp/modules/notification/services"
+
+	"gorm.io/gorm"
+)
+
+// ชื่อ event ของการแจ้งเตือนลูกค้า (Notification.Event)
+const (
+	NotifyAppointmentCreated     = "appointment.created"
+	NotifyAppointmentCancelled   = "appointment.cancelled"
+	NotifyAppointmentRescheduled = "appointment.rescheduled"
+	NotifyAppointmentReminder    = "appointment.reminder"
+	NotifyWaitlistOffered        = "waitlist.offered"
+)
+
+// เวลาในข้อความแจ้งเตือนแสดงเป็นเวลาไทย
+var notificationLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Bangkok"); err == nil {
+		return loc
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}()
+
+func formatNotificationTime(t time.Time) string {
+	return t.In(notificationLocation).Format("02/01/2006 เวลา 15:04 น.")
+}
+
+// notifyCustomerTx สร้างข้อความแจ้งลูกค้าใน outbox ภายใน tx ของการจอง
+// ถ้า tenant ยังไม่ตั้งช่องทางหรือลูกค้าไม่มีที่อยู่ติดต่อ จะไม่สร้างอะไร
+// previousStart ใช้เฉพาะ appointment.rescheduled
+func notifyCustomerTx(tx *gorm.DB, event string, ap *barberBookingModels.Appointment, previousStart *time.Time) error {
+	customer, branch, err := notificationTargetTx(tx, ap.CustomerID, ap.BranchID)
+	if err != nil || customer == nil {
+		return err
+	}
+
+	var msg notificationPort.Message
+	dedupKey := fmt.Sprintf("appointment:%d:%s", ap.ID, event)
+	when := formatNotificationTime(ap.StartTime)
+
+	switch event {
+	case NotifyAppointmentCreated:
+		msg.Subject = "ยืนยันการจอง"
+		msg.Body = fmt.Sprintf("คุณ%s จองคิวที่ %s วันที่ %s เรียบร้อยแล้ว (หมายเลขนัด #%d)", customer.Name, branch.Name, when, ap.ID)
+	case NotifyAppointmentCancelled:
+		msg.Subject = "ยกเลิกการจอง"
+		msg.Body = fmt.Sprintf("คุณ%s นัดหมาย #%d ที่ %s วันที่ %s ถูกยกเลิกแล้ว", customer.Name, ap.ID, branch.Name, when)
+	case NotifyAppointmentRescheduled:
+		msg.Subject = "เลื่อนนัดหมาย"
+		msg.Body = fmt.Sprintf("คุณ%s นัดหมาย #%d ที่ %s ถูกเลื่อนเป็นวันที่ %s", customer.Name, ap.ID, branch.Name, when)
+		if previousStart != nil {
+			msg.Body = fmt.Sprintf("คุณ%s นัดหมาย #%d ที่ %s ถูกเลื่อนจากวันที่ %s เป็นวันที่ %s",
+				customer.Name, ap.ID, branch.Name, formatNotificationTime(*previousStart), when)
+		}
+		// เลื่อนได้หลายครั้ง แต่ละเวลาใหม่แจ้งครั้งเดียว
+		dedupKey = fmt.Sprintf("%s:%d", dedupKey, ap.StartTime.Unix())
+	default:
+		return fmt.Errorf("unknown notification event: %s", event)
+	}
+
+	return enqueueCustomerNotificationTx(tx, ap.TenantID, event, customer, msg, dedupKey)
+}
+
+// remindCustomerTx แจ้งเตือนก่อนถึงเวลานัด หนึ่งครั้งต่อ (นัด, เวลาเริ่ม, offset)
+func remindCustomerTx(tx *gorm.DB, ap *barberBookingModels.Appointment, offsetMinutes int) error {
+	customer, branch, err := notificationTargetTx(tx, ap.CustomerID, ap.BranchID)
+	if err != nil || customer == nil {
+		return err
+	}
+
+	msg := notificationPort.Message{
+		Subject: "แจ้งเตือนนัดหมาย",
+		Body: fmt.Sprintf("คุณ%s มีนัดที่ %s วันที่ %s (หมายเลขนัด #%d)",
+			customer.Name, branch.Name, formatNotificationTime(ap.StartTime), ap.ID),
+	}
+	dedupKey := fmt.Sprintf("appointment:%d:%s:%d:%d", ap.ID, NotifyAppointmentReminder, ap.StartTime.Unix(), offsetMinutes)
+	return enqueueCustomerNotificationTx(tx, ap.TenantID, NotifyAppointmentReminder, customer, msg, dedupKey)
+}
+
+// notifyWaitlistOfferTx แจ้งลูกค้าใน waitlist ว่ามีช่วงเวลาว่างที่ hold ไว้ให้ หนึ่งครั้งต่อ hold
+func notifyWaitlistOfferTx(tx *gorm.DB, entry *barberBookingModels.WaitlistEntry, hold *barberBookingModels.AppointmentLock) error {
+	customer, branch, err := notificationTargetTx(tx, entry.CustomerID, entry.BranchID)
+	if err != nil || customer == nil {
+		return err
+	}
+
+	msg := notificationPort.Message{
+		Subject: "มีคิวว่างให้คุณ",
+		Body: fmt.Sprintf("คุณ%s มีคิวว่างที่ %s วันที่ %s เราจองไว้ให้ถึง %s กรุณาติดต่อร้านเพื่อยืนยัน",
+			customer.Name, branch.Name, formatNotificationTime(hold.StartTime), hold.ExpiresAt.In(notificationLocation).Format("15:04 น.")),
+	}
+	dedupKey := fmt.Sprintf("waitlist:%d:%s:%d", entry.ID, NotifyWaitlistOffered, hold.ID)
+	return enqueueCustomerNotificationTx(tx, entry.TenantID, NotifyWaitlistOffered, customer, msg, dedupKey)
+}
+
+// notificationTargetTx คืน customer = nil ถ้าลูกค้าไม่มีที่อยู่ติดต่อ
+func notificationTargetTx(tx *gorm.DB, customerID, branchID uint) (*barberBookingModels.Customer, *coreModels.Branch, error) {
+	var customer barberBookingModels.Customer
+	if err := tx.Unscoped().First(&customer, customerID).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load customer for notification: %w", err)
+	}
+	if customer.Email == "" && customer.Phone == "" {
+		return nil, nil, nil
+	}
+
+	var branch coreModels.Branch
+	if err := tx.Unscoped().Select("id", "name").First(&branch, branchID).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load branch for notification: %w", err)
+	}
+	return &customer, &branch, nil
+}
+
+func enqueueCustomerNotificationTx(
+	tx *gorm.DB,
+	tenantID uint,
+	event string,
+	customer *barberBookingModels.Customer,
+	msg notificationPort.Message,
+	dedupKey string,
+) error {
+	_, err := notificationServices.EnqueueTx(tx, tenantID, event, notificationPort.Recipient{
+		Email: customer.Email,
+		Phone: customer.Phone,
+	}, msg, dedupKey)
+	return err
+}
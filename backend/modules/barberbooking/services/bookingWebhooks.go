@@ -0,0 +1,82 @@
+package barberBookingService
+
+import (
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	webhookModels "myapp/modules/webhook/models"
+	webhookServices "myapp/modules/webhook/services"
+
+	"gorm.io/gorm"
+)
+
+// ข้อมูลใน data ของ webhook แยกจาก model เพื่อไม่ให้ field ภายในหลุดไปยังระบบของ tenant
+
+type appointmentWebhookData struct {
+	ID                uint       `json:"id"`
+	BranchID          uint       `json:"branch_id"`
+	BarberID          uint       `json:"barber_id,omitempty"`
+	CustomerID        uint       `json:"customer_id"`
+	ServiceID         uint       `json:"service_id"`
+	SeriesID          *uint      `json:"series_id,omitempty"`
+	Status            string     `json:"status"`
+	StartTime         time.Time  `json:"start_time"`
+	EndTime           time.Time  `json:"end_time"`
+	PreviousStartTime *time.Time `json:"previous_start_time,omitempty"`
+	Notes             string     `json:"notes,omitempty"`
+}
+
+type reviewWebhookData struct {
+	ID            uint      `json:"id"`
+	AppointmentID uint      `json:"appointment_id"`
+	CustomerID    *uint     `json:"customer_id,omitempty"`
+	Rating        int       `json:"rating"`
+	Comment       string    `json:"comment,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type customerWebhookData struct {
+	ID       uint   `json:"id"`
+	BranchID uint   `json:"branch_id"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// publishAppointmentWebhookTx previousStart ใช้เฉพาะ appointment.rescheduled
+func publishAppointmentWebhookTx(tx *gorm.DB, event string, ap *barberBookingModels.Appointment, previousStart *time.Time) error {
+	return webhookServices.EnqueueEventTx(tx, ap.TenantID, event, appointmentWebhookData{
+		ID:                ap.ID,
+		BranchID:          ap.BranchID,
+		BarberID:          ap.BarberID,
+		CustomerID:        ap.CustomerID,
+		ServiceID:         ap.ServiceID,
+		SeriesID:          ap.SeriesID,
+		Status:            string(ap.Status),
+		StartTime:         ap.StartTime,
+		EndTime:           ap.EndTime,
+		PreviousStartTime: previousStart,
+		Notes:             ap.Notes,
+	})
+}
+
+func publishReviewWebhookTx(tx *gorm.DB, tenantID uint, review *barberBookingModels.AppointmentReview) error {
+	return webhookServices.EnqueueEventTx(tx, tenantID, webhookModels.EventReviewCreated, reviewWebhookData{
+		ID:            review.ID,
+		AppointmentID: review.AppointmentID,
+		CustomerID:    review.CustomerID,
+		Rating:        review.Rating,
+		Comment:       review.Comment,
+		CreatedAt:     review.CreatedAt,
+	})
+}
+
+func publishCustomerWebhookTx(tx *gorm.DB, customer *barberBookingModels.Customer) error {
+	return webhookServices.EnqueueEventTx(tx, customer.TenantID, webhookModels.EventCustomerCreated, customerWebhookData{
+		ID:       customer.ID,
+		BranchID: customer.BranchID,
+		Name:     customer.Name,
+		Phone:    customer.Phone,
+		Email:    customer.Email,
+	})
+}
@@ -0,0 +1,85 @@
+package barberBookingService
+
+import (
+	"context"
+	"fmt"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+
+	"gorm.io/gorm"
+)
+
+type branchBookingSettingService struct {
+	DB *gorm.DB
+}
+
+func NewBranchBookingSettingService(db *gorm.DB) barberBookingPort.IBranchBookingSetting {
+	return &branchBookingSettingService{DB: db}
+}
+
+func (s *branchBookingSettingService) GetSetting(ctx context.Context, tenantID, branchID uint) (*barberBookingModels.BranchBookingSetting, error) {
+	setting, err := getBranchBookingSettingTx(s.DB.WithContext(ctx), tenantID, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch branch booking setting: %w", err)
+	}
+	return &setting, nil
+}
+
+func (s *branchBookingSettingService) UpdateSetting(
+	ctx context.Context,
+	tenantID, branchID uint,
+	input barberBookingPort.UpdateBranchBookingSettingRequest,
+) (*barberBookingModels.BranchBookingSetting, error) {
+	if input.AssignmentStrategy != "" && !IsValidAssignmentStrategy(input.AssignmentStrategy) {
+		return nil, fmt.Errorf("invalid assignment strategy: %s", input.AssignmentStrategy)
+	}
+	if input.NoShowGraceMinutes != nil && *input.NoShowGraceMinutes < 0 {
+		return nil, fmt.Errorf("invalid no_show_grace_minutes: must not be negative")
+	}
+	if input.AutoCompleteGraceMinutes != nil && *input.AutoCompleteGraceMinutes < 0 {
+		return nil, fmt.Errorf("invalid auto_complete_grace_minutes: must not be negative")
+	}
+
+	var out barberBookingModels.BranchBookingSetting
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		setting, err := getBranchBookingSettingTx(tx, tenantID, branchID)
+		if err != nil {
+			return err
+		}
+		// แถวใหม่: สร้างด้วยค่า default ก่อน แล้วค่อย Save ทับ
+		// (ตอน Create gorm จะแทน false/0 ด้วย default ของคอลัมน์ ทำให้ปิด auto no-show ไม่ได้)
+		if setting.ID == 0 {
+			if err := tx.Create(&setting).Error; err != nil {
+				return fmt.Errorf("failed to save branch booking setting: %w", err)
+			}
+		}
+		if input.AssignmentStrategy != "" {
+			if setting.AssignmentStrategy != input.AssignmentStrategy {
+				setting.LastAssignedBarberID = nil
+			}
+			setting.AssignmentStrategy = input.AssignmentStrategy
+		}
+		if input.AutoNoShowEnabled != nil {
+			setting.AutoNoShowEnabled = *input.AutoNoShowEnabled
+		}
+		if input.NoShowGraceMinutes != nil {
+			setting.NoShowGraceMinutes = *input.NoShowGraceMinutes
+		}
+		if input.AutoCompleteEnabled != nil {
+			setting.AutoCompleteEnabled = *input.AutoCompleteEnabled
+		}
+		if input.AutoCompleteGraceMinutes != nil {
+			setting.AutoCompleteGraceMinutes = *input.AutoCompleteGraceMinutes
+		}
+		if err := tx.Save(&setting).Error; err != nil {
+			return fmt.Errorf("failed to save branch booking setting: %w", err)
+		}
+		out = setting
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
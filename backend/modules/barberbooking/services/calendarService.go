@@ -2,33 +2,225 @@ package barberBookingService
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	helperFunc "myapp/modules/barberbooking"
 	barberBookingDto "myapp/modules/barberbooking/dto"
+	barberBookingModels "myapp/modules/barberbooking/models"
 	barberBookingPort "myapp/modules/barberbooking/port"
 
 	"gorm.io/gorm"
 )
 
+// ระยะห่างระหว่างเวลาเริ่มของแต่ละ slot
+const slotInterval = 30 * time.Minute
+
+const (
+	SlotStatusOpen   = "open"
+	SlotStatusClosed = "closed"
+)
+
 type calendarService struct {
-	DB                 *gorm.DB
-	workingHourService barberBookingPort.IWorkingHourService
-	overrideService    barberBookingPort.IWorkingDayOverrideService
+	DB *gorm.DB
+}
+
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func (r timeRange) overlaps(start, end time.Time) bool {
+	return r.start.Before(end) && r.end.After(start)
 }
 
 // GetAvailableSlots implements barberBookingPort.ICalendarService.
-func (c *calendarService) GetAvailableSlots(ctx context.Context, branchID uint, tenantID uint, startDate time.Time, endDate time.Time) ([]barberBookingDto.CalendarSlot, error) {
-	panic("unimplemented")
+// คืน slot ของช่างแต่ละคนในสาขา ตั้งแต่ startDate ถึง endDate (รวมวันสุดท้าย)
+// โดยความยาวของ slot เท่ากับ Duration ของ service ที่เลือก
+// buffer ก่อน/หลังของ service ไม่นับในเวลา slot แต่ต้องอยู่ในเวลาทำการและไม่ชนกับช่วงที่ช่างไม่ว่าง
+func (c *calendarService) GetAvailableSlots(
+	ctx context.Context,
+	branchID uint,
+	tenantID uint,
+	serviceID uint,
+	startDate time.Time,
+	endDate time.Time,
+) ([]barberBookingDto.CalendarSlot, error) {
+	loc := time.Now().Location()
+	firstDay := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	lastDay := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc)
+	if lastDay.Before(firstDay) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	rangeEnd := lastDay.AddDate(0, 0, 1)
+
+	db := c.DB.WithContext(ctx)
+
+	// 1) service → ความยาวของ slot
+	var service barberBookingModels.Service
+	if err := db.
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", serviceID, tenantID).
+		First(&service).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("service not found or access denied")
+		}
+		return nil, fmt.Errorf("failed to fetch service: %w", err)
+	}
+	if service.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be > 0")
+	}
+	span := serviceSpan(service)
+
+	// 2) ช่างทั้งหมดในสาขา
+	var barbers []barberBookingModels.Barber
+	if err := db.
+		Where("tenant_id = ? AND branch_id = ? AND deleted_at IS NULL", tenantID, branchID).
+		Order("id ASC").
+		Find(&barbers).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch barbers: %w", err)
+	}
+	if len(barbers) == 0 {
+		return []barberBookingDto.CalendarSlot{}, nil
+	}
+	barberIDs := make([]uint, 0, len(barbers))
+	for _, b := range barbers {
+		barberIDs = append(barberIDs, b.ID)
+	}
+
+	// 3) วันหยุด/เวลาพักของสาขาและของช่าง (รวมรายการทำซ้ำรายสัปดาห์)
+	unavailabilities, err := loadUnavailabilitiesTx(db, branchID, barberIDs, firstDay, lastDay)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4) ช่วงเวลาที่ช่างไม่ว่าง: นัดหมายที่ยัง active และ lock ที่ยังไม่หมดอายุ
+	busy := make(map[uint][]timeRange)
+
+	var appointments []barberBookingModels.Appointment
+	if err := db.
+		Select("id", "barber_id", "block_start", "block_end").
+		Where("tenant_id = ? AND barber_id IN ? AND status IN ? AND deleted_at IS NULL",
+			tenantID, barberIDs, slotBlockingStatuses).
+		Where("block_start < ? AND block_end > ?", rangeEnd, firstDay).
+		Find(&appointments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch appointments: %w", err)
+	}
+	for _, a := range appointments {
+		busy[a.BarberID] = append(busy[a.BarberID], timeRange{a.BlockStart, a.BlockEnd})
+	}
+
+	now := time.Now()
+	var locks []barberBookingModels.AppointmentLock
+	if err := db.
+		Where("tenant_id = ? AND barber_id IN ? AND is_active = ? AND expires_at > ?",
+			tenantID, barberIDs, true, now).
+		Where("block_start < ? AND block_end > ?", rangeEnd, firstDay).
+		Find(&locks).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch appointment locks: %w", err)
+	}
+	for _, l := range locks {
+		busy[l.BarberID] = append(busy[l.BarberID], timeRange{l.BlockStart, l.BlockEnd})
+	}
+
+	// 5) เวลาทำการของสาขา + override รายวัน และตารางงานของช่าง (ชุดเดียวกับที่ใช้ตรวจตอนจอง)
+	schedules, err := loadBarberSchedulesTx(db, tenantID, branchID, barberIDs, firstDay, lastDay)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6) สร้าง slot ทีละวัน ทีละช่าง
+	slots := make([]barberBookingDto.CalendarSlot, 0)
+	for day := firstDay; day.Before(rangeEnd); day = day.AddDate(0, 0, 1) {
+		// ช่วงที่ปิดในวันนั้น; ปิดทั้งวันจะไม่มี slot เลย ส่วนปิดบางช่วงจะทำให้ slot ที่ชนเป็น closed
+		var branchBlocks []timeRange
+		barberBlocks := make(map[uint][]timeRange)
+		branchClosed := false
+		barberOff := make(map[uint]bool)
+		for _, u := range unavailabilities {
+			r, ok := unavailableRange(u, day)
+			if !ok {
+				continue
+			}
+			fullDay := u.StartTime == nil
+			if u.BarberID == nil {
+				branchClosed = branchClosed || fullDay
+				branchBlocks = append(branchBlocks, r)
+				continue
+			}
+			barberOff[*u.BarberID] = barberOff[*u.BarberID] || fullDay
+			barberBlocks[*u.BarberID] = append(barberBlocks[*u.BarberID], r)
+		}
+		if branchClosed {
+			continue
+		}
+
+		for _, b := range barbers {
+			if barberOff[b.ID] {
+				continue
+			}
+			opensAt, closesAt, ok := schedules.window(b.ID, day)
+			if !ok {
+				continue
+			}
+			// ช่วง block (รวม buffer) ต้องอยู่ในเวลาทำการของสาขาและเวลางานของช่าง เหมือนตอนจอง
+			from := opensAt.Add(span.BufferBefore)
+			to := closesAt.Add(-span.BufferAfter)
+			for start := from; !start.Add(span.Duration).After(to); start = start.Add(slotInterval) {
+				end := start.Add(span.Duration)
+				blockStart, blockEnd := span.block(start)
+				status := SlotStatusOpen
+				if start.Before(now) ||
+					overlapsAny(busy[b.ID], blockStart, blockEnd) ||
+					overlapsAny(branchBlocks, blockStart, blockEnd) ||
+					overlapsAny(barberBlocks[b.ID], blockStart, blockEnd) {
+					status = SlotStatusClosed
+				}
+				slots = append(slots, barberBookingDto.CalendarSlot{
+					BarberID: b.ID,
+					Start:    start,
+					End:      end,
+					Status:   status,
+				})
+			}
+		}
+	}
+
+	return slots, nil
 }
 
-func NewCalendarService(
-	db *gorm.DB,
-	workingHourSvc barberBookingPort.IWorkingHourService,
-	overrideSvc barberBookingPort.IWorkingDayOverrideService,
-) barberBookingPort.ICalendarService {
-	return &calendarService{
-		DB:                 db,
-		workingHourService: workingHourSvc,
-		overrideService:    overrideSvc,
+// branchWindow คืนเวลาเปิด-ปิดของสาขาในวันนั้น โดย override มีลำดับความสำคัญเหนือเวลาทำการปกติ
+func branchWindow(
+	day time.Time,
+	hoursByWeekday map[int]barberBookingModels.WorkingHour,
+	overrideByDate map[string]barberBookingModels.WorkingDayOverride,
+) (time.Time, time.Time, bool) {
+	if o, ok := overrideByDate[day.Format("2006-01-02")]; ok {
+		if o.IsClosed {
+			return time.Time{}, time.Time{}, false
+		}
+		opensAt, closesAt := o.StartTime.ToTime(day), o.EndTime.ToTime(day)
+		return opensAt, closesAt, opensAt.Before(closesAt)
 	}
+
+	wh, ok := hoursByWeekday[getWeekday(day)]
+	if !ok || wh.IsClosed {
+		return time.Time{}, time.Time{}, false
+	}
+	opensAt := helperFunc.TimeOnly{Time: wh.StartTime}.ToTime(day)
+	closesAt := helperFunc.TimeOnly{Time: wh.EndTime}.ToTime(day)
+	return opensAt, closesAt, opensAt.Before(closesAt)
+}
+
+func overlapsAny(ranges []timeRange, start, end time.Time) bool {
+	for _, r := range ranges {
+		if r.overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewCalendarService(db *gorm.DB) barberBookingPort.ICalendarService {
+	return &calendarService{DB: db}
 }
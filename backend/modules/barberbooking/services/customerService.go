@@ -67,7 +67,12 @@ func (s *customerService) CreateCustomer(ctx context.Context, customer *barberBo
 		return err
 	}
 
-	return s.db.WithContext(ctx).Create(customer).Error
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(customer).Error; err != nil {
+			return err
+		}
+		return publishCustomerWebhookTx(tx, customer)
+	})
 }
 
 
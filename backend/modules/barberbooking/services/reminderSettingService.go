@@ -0,0 +1,111 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxReminderOffsets       = 5
+	maxReminderOffsetMinutes = 7 * 24 * 60
+)
+
+type reminderSettingService struct {
+	DB *gorm.DB
+}
+
+func NewReminderSettingService(db *gorm.DB) barberBookingPort.IReminderSetting {
+	return &reminderSettingService{DB: db}
+}
+
+// getReminderSettingTx คืนค่า setting ของร้าน หรือค่า default ถ้ายังไม่เคยตั้ง
+func getReminderSettingTx(tx *gorm.DB, tenantID uint) (barberBookingModels.ReminderSetting, error) {
+	var setting barberBookingModels.ReminderSetting
+	err := tx.Where("tenant_id = ?", tenantID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return barberBookingModels.ReminderSetting{
+			TenantID:       tenantID,
+			Enabled:        true,
+			OffsetsMinutes: []int{24 * 60, 2 * 60},
+		}, nil
+	}
+	return setting, err
+}
+
+func (s *reminderSettingService) GetSetting(ctx context.Context, tenantID uint) (*barberBookingModels.ReminderSetting, error) {
+	setting, err := getReminderSettingTx(s.DB.WithContext(ctx), tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reminder setting: %w", err)
+	}
+	return &setting, nil
+}
+
+func (s *reminderSettingService) UpdateSetting(
+	ctx context.Context,
+	tenantID uint,
+	input barberBookingPort.UpdateReminderSettingRequest,
+) (*barberBookingModels.ReminderSetting, error) {
+	var offsets []int
+	if input.OffsetsMinutes != nil {
+		var err error
+		if offsets, err = normalizeReminderOffsets(input.OffsetsMinutes); err != nil {
+			return nil, err
+		}
+	}
+
+	var out barberBookingModels.ReminderSetting
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		setting, err := getReminderSettingTx(tx, tenantID)
+		if err != nil {
+			return err
+		}
+		// แถวใหม่: สร้างด้วยค่า default ก่อน แล้วค่อย Save ทับ (Create จะแทน false ด้วย default ของคอลัมน์)
+		if setting.ID == 0 {
+			if err := tx.Create(&setting).Error; err != nil {
+				return fmt.Errorf("failed to save reminder setting: %w", err)
+			}
+		}
+		if input.Enabled != nil {
+			setting.Enabled = *input.Enabled
+		}
+		if offsets != nil {
+			setting.OffsetsMinutes = offsets
+		}
+		if err := tx.Save(&setting).Error; err != nil {
+			return fmt.Errorf("failed to save reminder setting: %w", err)
+		}
+		out = setting
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// normalizeReminderOffsets ตัดค่าซ้ำและเรียงจากไกลไปใกล้เวลานัด
+func normalizeReminderOffsets(in []int) ([]int, error) {
+	seen := make(map[int]bool, len(in))
+	out := make([]int, 0, len(in))
+	for _, m := range in {
+		if m <= 0 || m > maxReminderOffsetMinutes {
+			return nil, fmt.Errorf("invalid offsets_minutes: %d must be between 1 and %d", m, maxReminderOffsetMinutes)
+		}
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	if len(out) > maxReminderOffsets {
+		return nil, fmt.Errorf("invalid offsets_minutes: at most %d reminders are allowed", maxReminderOffsets)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(out)))
+	return out, nil
+}
@@ -64,7 +64,8 @@ func (s *ServiceService) CreateService(
 	file *multipart.FileHeader, 
 ) (*barberBookingModels.Service, error) {
 	// 1. Validate ข้อมูลเบื้องต้น
-	if payload.Name == "" || payload.Duration <= 0 || payload.Price < 0 {
+	if payload.Name == "" || payload.Duration <= 0 || payload.Price < 0 ||
+		payload.BufferBefore < 0 || payload.BufferAfter < 0 {
 		return nil, fmt.Errorf("invalid service data")
 	}
 	if tenantID == 0 || branchID == 0 {
@@ -72,14 +73,16 @@ func (s *ServiceService) CreateService(
 	}
 
 	service := &barberBookingModels.Service{
-		TenantID:    tenantID,
-		BranchID:    branchID,
-		Name:        payload.Name,
-		Description: payload.Description,
-		Duration:    payload.Duration,
-		Price:       payload.Price,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		TenantID:     tenantID,
+		BranchID:     branchID,
+		Name:         payload.Name,
+		Description:  payload.Description,
+		Duration:     payload.Duration,
+		Price:        payload.Price,
+		BufferBefore: payload.BufferBefore,
+		BufferAfter:  payload.BufferAfter,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
 	}
 
 	// 3. ถ้ามีไฟล์ → อัปโหลดขึ้น S3
@@ -107,7 +110,9 @@ func (s *ServiceService) UpdateService(
 	file *multipart.FileHeader, // ✅ รูปภาพใหม่ (optional)
 ) (*barberBookingModels.Service, error) {
 	// 1. ตรวจสอบค่าเบื้องต้น
-	if payload.Name == "" || payload.Duration <= 0 || payload.Price < 0 {
+	if payload.Name == "" || payload.Duration <= 0 || payload.Price < 0 ||
+		(payload.BufferBefore != nil && *payload.BufferBefore < 0) ||
+		(payload.BufferAfter != nil && *payload.BufferAfter < 0) {
 		return nil, fmt.Errorf("invalid service input")
 	}
 
@@ -132,6 +137,12 @@ func (s *ServiceService) UpdateService(
 	service.Description = payload.Description
 	service.Duration = payload.Duration
 	service.Price = payload.Price
+	if payload.BufferBefore != nil {
+		service.BufferBefore = *payload.BufferBefore
+	}
+	if payload.BufferAfter != nil {
+		service.BufferAfter = *payload.BufferAfter
+	}
 	service.UpdatedAt = time.Now()
 
 	// 5. Save
@@ -0,0 +1,203 @@
+package barberBookingService
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// slotEventBuffer จำนวน event ที่ค้างได้ต่อ subscriber ก่อนเริ่มทิ้ง
+const slotEventBuffer = 32
+
+// slotEventChannel ช่อง LISTEN/NOTIFY ของ Postgres ที่ใช้กระจาย event ข้าม process (server หลาย instance, jobworker)
+const slotEventChannel = "booking_slot_events"
+
+// slotEventRetry เวลารอก่อนต่อ LISTEN ใหม่เมื่อ connection หลุด
+const slotEventRetry = 5 * time.Second
+
+type branchKey struct {
+	tenantID, branchID uint
+}
+
+type slotEventHub struct {
+	mu   sync.RWMutex
+	subs map[branchKey]map[chan barberBookingPort.SlotEvent]struct{}
+	// notify ส่ง event ผ่าน NOTIFY (ตั้งด้วย UsePostgresSlotEvents) nil = ส่งให้ subscriber ใน process นี้เท่านั้น
+	notify func(payload []byte) error
+}
+
+func NewSlotEventHub() barberBookingPort.ISlotEvents {
+	return newSlotEventHub()
+}
+
+func newSlotEventHub() *slotEventHub {
+	return &slotEventHub{subs: make(map[branchKey]map[chan barberBookingPort.SlotEvent]struct{})}
+}
+
+var slotEventHubDefault = newSlotEventHub()
+
+// SlotEvents hub ของ process นี้ services ต่างๆ publish หลัง commit แล้ว และ controller ใช้ subscribe
+// เมื่อเรียก UsePostgresSlotEvents แล้ว event จะถูกกระจายผ่าน Postgres ให้ทุก process
+var SlotEvents barberBookingPort.ISlotEvents = slotEventHubDefault
+
+// UsePostgresSlotEvents ให้ SlotEvents publish ผ่าน pg_notify และฟังช่องเดียวกัน (กัน connection ไว้ 1 เส้นจาก pool)
+// event จาก process อื่น (server instance อื่น, jobworker) จึงถึง subscriber ของ process นี้ด้วย
+// ระหว่างที่ LISTEN หลุดและกำลังต่อใหม่ event จะหายได้ (client ควรโหลด slot ใหม่เมื่อ stream ต่อใหม่)
+func UsePostgresSlotEvents(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	h := slotEventHubDefault
+	h.mu.Lock()
+	h.notify = func(payload []byte) error {
+		return db.Exec("SELECT pg_notify(?, ?)", slotEventChannel, string(payload)).Error
+	}
+	h.mu.Unlock()
+
+	go func() {
+		for {
+			if err := h.listen(ctx, sqlDB); err != nil && ctx.Err() == nil {
+				log.Printf("slot events: listen failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(slotEventRetry):
+			}
+		}
+	}()
+	return nil
+}
+
+// listen ถือ connection หนึ่งเส้นไว้ LISTEN แล้วส่ง event ที่ได้ให้ subscriber ใน process นี้ จนกว่า connection จะหลุด
+func (h *slotEventHub) listen(ctx context.Context, sqlDB *sql.DB) error {
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unsupported driver connection %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+slotEventChannel); err != nil {
+			return err
+		}
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			var event barberBookingPort.SlotEvent
+			if err := json.Unmarshal([]byte(n.Payload), &event); err != nil {
+				continue
+			}
+			h.deliver(event)
+		}
+	})
+}
+
+func (h *slotEventHub) Publish(event barberBookingPort.SlotEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	h.mu.RLock()
+	notify := h.notify
+	h.mu.RUnlock()
+	if notify != nil {
+		// listener ของทุก process (รวม process นี้) จะได้ event กลับมาและส่งต่อให้ subscriber เอง
+		payload, err := json.Marshal(event)
+		if err == nil {
+			if err = notify(payload); err == nil {
+				return
+			}
+		}
+		log.Printf("slot events: notify failed, delivering locally: %v", err)
+	}
+	h.deliver(event)
+}
+
+// deliver ส่ง event ให้ subscriber ของสาขาใน process นี้
+func (h *slotEventHub) deliver(event barberBookingPort.SlotEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[branchKey{event.TenantID, event.BranchID}] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (h *slotEventHub) Subscribe(tenantID, branchID uint) (<-chan barberBookingPort.SlotEvent, func()) {
+	key := branchKey{tenantID, branchID}
+	ch := make(chan barberBookingPort.SlotEvent, slotEventBuffer)
+
+	h.mu.Lock()
+	if h.subs[key] == nil {
+		h.subs[key] = make(map[chan barberBookingPort.SlotEvent]struct{})
+	}
+	h.subs[key][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[key], ch)
+			if len(h.subs[key]) == 0 {
+				delete(h.subs, key)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+func appointmentEvent(t barberBookingPort.SlotEventType, ap *barberBookingModels.Appointment) barberBookingPort.SlotEvent {
+	id := ap.ID
+	return barberBookingPort.SlotEvent{
+		Type:          t,
+		TenantID:      ap.TenantID,
+		BranchID:      ap.BranchID,
+		BarberID:      ap.BarberID,
+		AppointmentID: &id,
+		Status:        string(ap.Status),
+		StartTime:     ap.StartTime,
+		EndTime:       ap.EndTime,
+	}
+}
+
+func lockEvent(t barberBookingPort.SlotEventType, lock *barberBookingModels.AppointmentLock) barberBookingPort.SlotEvent {
+	id := lock.ID
+	return barberBookingPort.SlotEvent{
+		Type:      t,
+		TenantID:  lock.TenantID,
+		BranchID:  lock.BranchID,
+		BarberID:  lock.BarberID,
+		LockID:    &id,
+		StartTime: lock.StartTime,
+		EndTime:   lock.EndTime,
+	}
+}
+
+// publishWaitlistHold แจ้ง hold ที่ offerFreedSlotTx สร้างให้ลูกค้าใน waitlist (ถ้ามี)
+func publishWaitlistHold(entry *barberBookingModels.WaitlistEntry) {
+	if entry != nil && entry.Lock != nil {
+		SlotEvents.Publish(lockEvent(barberBookingPort.SlotEventLockCreated, entry.Lock))
+	}
+}
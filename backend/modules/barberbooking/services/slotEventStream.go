@@ -0,0 +1,35 @@
+package barberBookingService
+
+import (
+	"context"
+	"fmt"
+
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+
+	"gorm.io/gorm"
+)
+
+type slotEventStreamService struct {
+	DB     *gorm.DB
+	Events barberBookingPort.ISlotEvents
+}
+
+func NewSlotEventStreamService(db *gorm.DB, events barberBookingPort.ISlotEvents) barberBookingPort.ISlotEventStream {
+	return &slotEventStreamService{DB: db, Events: events}
+}
+
+func (s *slotEventStreamService) SubscribeBranch(ctx context.Context, tenantID, branchID uint) (<-chan barberBookingPort.SlotEvent, func(), error) {
+	var count int64
+	if err := s.DB.WithContext(ctx).
+		Model(&coreModels.Branch{}).
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", branchID, tenantID).
+		Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch branch: %w", err)
+	}
+	if count == 0 {
+		return nil, nil, fmt.Errorf("branch not found")
+	}
+	events, cancel := s.Events.Subscribe(tenantID, branchID)
+	return events, cancel, nil
+}
@@ -0,0 +1,30 @@
+package barberBookingService
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+)
+
+// errSlotTaken ช่วงเวลาถูกจอง/lock ไปแล้ว (ตรวจพบโดย exclusion constraint ของฐานข้อมูล)
+var errSlotTaken = errors.New("slot taken: barber already has an appointment or lock during this time")
+
+// isSlotTaken ตรวจว่าเป็น exclusion_violation (23P01) จาก excl_appointments_barber_block
+// หรือ excl_appointment_locks_barber_block
+func isSlotTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23P01"
+}
+
+// releaseExpiredLocksTx ปิด lock ที่หมดอายุแต่ยัง active ซึ่งทับช่วงเวลาของช่าง
+// ต้องทำก่อนสร้าง lock ใหม่ ไม่งั้น exclusion constraint จะนับ lock ที่รอ sweeper อยู่
+func releaseExpiredLocksTx(tx *gorm.DB, barberID uint, start, end time.Time) error {
+	return tx.Model(&barberBookingModels.AppointmentLock{}).
+		Where("barber_id = ? AND is_active = ? AND expires_at <= ? AND block_start < ? AND block_end > ?",
+			barberID, true, time.Now(), end, start).
+		Update("is_active", false).Error
+}
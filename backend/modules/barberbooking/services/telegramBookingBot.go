@@ -0,0 +1,280 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	helperFunc "myapp/modules/barberbooking"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	coreModels "myapp/modules/core/models"
+	corePort "myapp/modules/core/port"
+
+	"gorm.io/gorm"
+)
+
+// ปุ่มยืนยัน/ปฏิเสธต่อข้อความ (Telegram จำกัดขนาด inline keyboard)
+const telegramMaxButtons = 10
+
+// role ที่ดูตารางของทั้งสาขาได้ (ผู้ใช้ที่เป็นช่างจะเห็นเฉพาะตารางของตัวเอง)
+var telegramBranchRoles = []coreModels.RoleName{
+	coreModels.RoleNameBranchAdmin,
+	coreModels.RoleNameAssistantManager,
+}
+
+var telegramTenantRoles = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+}
+
+type telegramBookingBot struct {
+	DB           *gorm.DB
+	Appointments barberBookingPort.IAppointment
+}
+
+// NewTelegramBookingBot คำสั่ง Telegram สำหรับช่างและผู้ดูแลสาขา
+func NewTelegramBookingBot(db *gorm.DB, appointments barberBookingPort.IAppointment) corePort.ITelegramCommandHandler {
+	return &telegramBookingBot{DB: db, Appointments: appointments}
+}
+
+func (b *telegramBookingBot) Help() string {
+	return "/today - นัดวันนี้\n/next - นัดถัดไป\n/week - นัดสัปดาห์นี้\n/cancel <id> - ยกเลิกนัด"
+}
+
+func (b *telegramBookingBot) HandleCommand(ctx context.Context, user coreModels.User, command string, args []string) (corePort.TelegramReply, error) {
+	switch command {
+	case "today":
+		return b.schedule(ctx, user, "today", "นัดวันนี้")
+	case "week":
+		return b.schedule(ctx, user, "week", "นัดสัปดาห์นี้")
+	case "next":
+		return b.next(ctx, user)
+	case "cancel":
+		if len(args) != 1 {
+			return corePort.TelegramReply{Text: "ใช้คำสั่ง /cancel <หมายเลขนัด>"}, nil
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			return corePort.TelegramReply{Text: "หมายเลขนัดไม่ถูกต้อง"}, nil
+		}
+		return b.cancel(ctx, user, uint(id), "ยกเลิกนัด")
+	}
+	return corePort.TelegramReply{Text: "ไม่รู้จักคำสั่งนี้\n\n" + b.Help()}, nil
+}
+
+// HandleCallback ปุ่ม "confirm:<id>" / "decline:<id>" ของนัดที่ยัง PENDING
+func (b *telegramBookingBot) HandleCallback(ctx context.Context, user coreModels.User, data string) (corePort.TelegramReply, error) {
+	action, idStr, ok := strings.Cut(data, ":")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if !ok || err != nil {
+		return corePort.TelegramReply{}, fmt.Errorf("invalid action")
+	}
+
+	ap, err := b.authorizedAppointment(ctx, user, uint(id))
+	if err != nil {
+		return corePort.TelegramReply{}, err
+	}
+	if ap.Status != barberBookingModels.StatusPending {
+		return corePort.TelegramReply{Text: fmt.Sprintf("นัด #%d อยู่ในสถานะ %s แล้ว", ap.ID, ap.Status)}, nil
+	}
+
+	switch action {
+	case "confirm":
+		if _, err := b.Appointments.TransitionStatus(ctx, ap.TenantID, ap.ID, barberBookingModels.StatusConfirmed,
+			barberBookingPort.StatusTransitionInput{
+				ActorUserID: &user.ID,
+				ActorRole:   b.actorRole(ctx, user, ap),
+				Notes:       "confirmed via Telegram",
+			}); err != nil {
+			return corePort.TelegramReply{}, err
+		}
+		return corePort.TelegramReply{Text: fmt.Sprintf("✅ ยืนยันนัด #%d แล้ว", ap.ID)}, nil
+	case "decline":
+		return b.cancel(ctx, user, ap.ID, "ปฏิเสธนัด")
+	}
+	return corePort.TelegramReply{}, fmt.Errorf("invalid action")
+}
+
+func (b *telegramBookingBot) schedule(ctx context.Context, user coreModels.User, mode, title string) (corePort.TelegramReply, error) {
+	appts, err := b.appointmentsFor(ctx, user, mode)
+	if err != nil {
+		return corePort.TelegramReply{}, err
+	}
+
+	active := appts[:0]
+	for _, a := range appts {
+		if a.Status != string(barberBookingModels.StatusCancelled) {
+			active = append(active, a)
+		}
+	}
+	if len(active) == 0 {
+		return corePort.TelegramReply{Text: title + ": ไม่มีนัด"}, nil
+	}
+
+	lines := []string{fmt.Sprintf("%s (%d นัด)", title, len(active))}
+	var buttons [][]corePort.TelegramButton
+	for _, a := range active {
+		lines = append(lines, formatTelegramAppointment(a, mode == "week"))
+		if a.Status == string(barberBookingModels.StatusPending) && len(buttons) < telegramMaxButtons {
+			buttons = append(buttons, pendingButtons(a.ID))
+		}
+	}
+	return corePort.TelegramReply{Text: strings.Join(lines, "\n"), Buttons: buttons}, nil
+}
+
+func (b *telegramBookingBot) next(ctx context.Context, user coreModels.User) (corePort.TelegramReply, error) {
+	appts, err := b.appointmentsFor(ctx, user, "next")
+	if err != nil {
+		return corePort.TelegramReply{}, err
+	}
+	now := time.Now()
+	for _, a := range appts {
+		if a.StartTime.After(now) && blocksSlot(barberBookingModels.AppointmentStatus(a.Status)) {
+			reply := corePort.TelegramReply{Text: "นัดถัดไป\n" + formatTelegramAppointment(a, true)}
+			if a.Status == string(barberBookingModels.StatusPending) {
+				reply.Buttons = [][]corePort.TelegramButton{pendingButtons(a.ID)}
+			}
+			return reply, nil
+		}
+	}
+	return corePort.TelegramReply{Text: "ไม่มีนัดถัดไป"}, nil
+}
+
+func (b *telegramBookingBot) cancel(ctx context.Context, user coreModels.User, appointmentID uint, verb string) (corePort.TelegramReply, error) {
+	if _, err := b.authorizedAppointment(ctx, user, appointmentID); err != nil {
+		return corePort.TelegramReply{}, err
+	}
+	if err := b.Appointments.CancelAppointment(ctx, appointmentID, &user.ID, nil); err != nil {
+		return corePort.TelegramReply{}, err
+	}
+	return corePort.TelegramReply{Text: fmt.Sprintf("❌ %s #%d แล้ว", verb, appointmentID)}, nil
+}
+
+// appointmentsFor ช่างเห็นตารางของตัวเอง (GetAppointmentsByBarber) ผู้ดูแลสาขาเห็นทั้งสาขา
+// mode: today, week หรือ next (ตั้งแต่ตอนนี้ 30 วันข้างหน้า)
+func (b *telegramBookingBot) appointmentsFor(ctx context.Context, user coreModels.User, mode string) ([]barberBookingPort.AppointmentBrief, error) {
+	barber, err := b.barberOf(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// "วันนี้"/"สัปดาห์นี้" นับตามเวลาไทย ไม่ใช่เวลาเครื่อง server
+	start, end := telegramRange(mode, time.Now().In(notificationLocation))
+	if barber != nil {
+		return b.Appointments.GetAppointmentsByBarber(ctx, barber.ID, barberBookingPort.AppointmentFilter{Start: &start, End: &end})
+	}
+
+	if helperFunc.IsAuthorizedRole(user.Role.Name, telegramBranchRoles) && user.BranchID != nil {
+		return b.Appointments.GetAppointmentsByBranch(ctx, *user.BranchID, &start, &end, "", nil)
+	}
+	return nil, fmt.Errorf("permission denied: this account is not a barber or branch admin")
+}
+
+func telegramRange(mode string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch mode {
+	case "week":
+		weekday := int(now.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		start := today.AddDate(0, 0, -weekday+1)
+		return start, start.AddDate(0, 0, 7)
+	case "next":
+		return now, now.AddDate(0, 0, 30)
+	}
+	return today, today.AddDate(0, 0, 1)
+}
+
+func (b *telegramBookingBot) barberOf(ctx context.Context, userID uint) (*barberBookingModels.Barber, error) {
+	var barber barberBookingModels.Barber
+	err := b.DB.WithContext(ctx).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		First(&barber).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch barber: %w", err)
+	}
+	return &barber, nil
+}
+
+// authorizedAppointment ช่างจัดการได้เฉพาะนัดของตัวเอง ผู้ดูแลสาขาเฉพาะสาขาตัวเอง ผู้ดูแลร้านเฉพาะ tenant ที่สังกัด
+func (b *telegramBookingBot) authorizedAppointment(ctx context.Context, user coreModels.User, appointmentID uint) (*barberBookingModels.Appointment, error) {
+	var ap barberBookingModels.Appointment
+	if err := b.DB.WithContext(ctx).
+		Where("id = ? AND deleted_at IS NULL", appointmentID).
+		First(&ap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("appointment with ID %d not found", appointmentID)
+		}
+		return nil, err
+	}
+
+	barber, err := b.barberOf(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if barber != nil && barber.ID == ap.BarberID {
+		return &ap, nil
+	}
+	if helperFunc.IsAuthorizedRole(user.Role.Name, telegramBranchRoles) && user.BranchID != nil && *user.BranchID == ap.BranchID {
+		return &ap, nil
+	}
+	if helperFunc.IsAuthorizedRole(user.Role.Name, telegramTenantRoles) {
+		var count int64
+		if err := b.DB.WithContext(ctx).Model(&coreModels.TenantUser{}).
+			Where("tenant_id = ? AND user_id = ?", ap.TenantID, user.ID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return &ap, nil
+		}
+	}
+	return nil, fmt.Errorf("permission denied: appointment #%d is not yours", appointmentID)
+}
+
+// actorRole ช่างเจ้าของนัดยืนยันนัดของตัวเองได้แม้ role ในระบบจะไม่ใช่ front desk
+func (b *telegramBookingBot) actorRole(ctx context.Context, user coreModels.User, ap *barberBookingModels.Appointment) string {
+	if helperFunc.IsAuthorizedRole(user.Role.Name, frontDeskRoles) {
+		return user.Role.Name
+	}
+	if barber, err := b.barberOf(ctx, user.ID); err == nil && barber != nil && barber.ID == ap.BarberID {
+		return string(coreModels.RoleNameStaff)
+	}
+	return user.Role.Name
+}
+
+func pendingButtons(id uint) []corePort.TelegramButton {
+	return []corePort.TelegramButton{
+		{Text: fmt.Sprintf("✅ ยืนยัน #%d", id), CallbackData: fmt.Sprintf("confirm:%d", id)},
+		{Text: fmt.Sprintf("❌ ปฏิเสธ #%d", id), CallbackData: fmt.Sprintf("decline:%d", id)},
+	}
+}
+
+func formatTelegramAppointment(a barberBookingPort.AppointmentBrief, withDate bool) string {
+	layout := "15:04"
+	if withDate {
+		layout = "02/01 15:04"
+	}
+	service := a.Service.Name
+	if len(a.Items) > 1 {
+		names := make([]string, 0, len(a.Items))
+		for _, it := range a.Items {
+			names = append(names, it.Name)
+		}
+		service = strings.Join(names, " + ")
+	}
+	return fmt.Sprintf("#%d %s–%s %s — %s [%s]",
+		a.ID,
+		a.StartTime.In(notificationLocation).Format(layout),
+		a.EndTime.In(notificationLocation).Format("15:04"),
+		service, a.Customer.Name, a.Status)
+}
@@ -2,6 +2,8 @@ package barberBookingService
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 	"strings"
 	"gorm.io/gorm"
@@ -22,18 +24,25 @@ func NewUnavailabilityService(db *gorm.DB) barberBookingPort.IUnavailabilitySeri
 
 
 func (s *UnavailabilityService) CreateUnavailability(ctx context.Context, input *barberBookingModels.Unavailability) (*barberBookingModels.Unavailability, error) {
-	var existing barberBookingModels.Unavailability
-	err := s.DB.WithContext(ctx).
-		Where("date = ? AND barber_id = ? AND branch_id = ?", input.Date, input.BarberID, input.BranchID).
-		First(&existing).Error
-
-	if err == nil {
-		return nil, errors.New("unavailability already exists for this date")
-	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := validateUnavailability(input); err != nil {
 		return nil, err
 	}
 
+	// ปิดทั้งวันซ้ำวันเดิมไม่ได้ ส่วนช่วงเวลา (พัก) มีหลายช่วงในวันเดียวกันได้
+	if input.StartTime == nil && input.RepeatWeekdays == "" {
+		var existing barberBookingModels.Unavailability
+		err := s.DB.WithContext(ctx).
+			Where("date = ? AND barber_id = ? AND branch_id = ? AND start_time IS NULL", input.Date, input.BarberID, input.BranchID).
+			First(&existing).Error
+
+		if err == nil {
+			return nil, errors.New("unavailability already exists for this date")
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
 	if err := s.DB.WithContext(ctx).Create(&input).Error; err != nil {
 		if strings.Contains(err.Error(), "duplicate") {
 			return nil, errors.New("unavailability already exists for this date")
@@ -98,3 +107,111 @@ func (s *UnavailabilityService) DeleteUnavailability(ctx context.Context, id uin
 	}
 	return nil
 }
+
+func validateUnavailability(u *barberBookingModels.Unavailability) error {
+	if (u.StartTime == nil) != (u.EndTime == nil) {
+		return errors.New("invalid time range: start_time and end_time must be given together")
+	}
+	if u.StartTime != nil && !u.StartTime.Before(u.EndTime.Time) {
+		return errors.New("invalid time range: start_time must be before end_time")
+	}
+	if _, err := parseRepeatWeekdays(u.RepeatWeekdays); err != nil {
+		return err
+	}
+	if u.RepeatUntil != nil && u.RepeatUntil.Before(u.Date) {
+		return errors.New("invalid repeat_until: must not be before date")
+	}
+	return nil
+}
+
+// parseRepeatWeekdays แปลง "1,2,3,4,5" เป็นชุดของวันในสัปดาห์ (ค่าว่าง = ไม่ทำซ้ำ)
+func parseRepeatWeekdays(raw string) (map[int]bool, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	days := make(map[int]bool)
+	for _, part := range strings.Split(raw, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || d < 0 || d > 6 {
+			return nil, fmt.Errorf("invalid repeat_weekdays: %q", raw)
+		}
+		days[d] = true
+	}
+	return days, nil
+}
+
+// loadUnavailabilitiesTx โหลดรายการปิดของสาขาและของช่างที่อาจมีผลในช่วงวันที่ firstDay–lastDay
+// รวมรายการทำซ้ำที่เริ่มก่อนช่วงนั้นและยังไม่สิ้นสุด
+func loadUnavailabilitiesTx(
+	tx *gorm.DB,
+	branchID uint,
+	barberIDs []uint,
+	firstDay, lastDay time.Time,
+) ([]barberBookingModels.Unavailability, error) {
+	if len(barberIDs) == 0 {
+		barberIDs = []uint{0}
+	}
+	var list []barberBookingModels.Unavailability
+	if err := tx.
+		Where("((barber_id IS NULL AND branch_id = ?) OR barber_id IN ?)", branchID, barberIDs).
+		Where("((COALESCE(repeat_weekdays, '') = '' AND date BETWEEN ? AND ?) OR "+
+			"(COALESCE(repeat_weekdays, '') <> '' AND date <= ? AND (repeat_until IS NULL OR repeat_until >= ?)))",
+			firstDay, lastDay, lastDay, firstDay).
+		Where("deleted_at IS NULL").
+		Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch unavailabilities: %w", err)
+	}
+	return list, nil
+}
+
+// unavailableRange คืนช่วงที่ปิดของรายการนี้ในวันนั้น (day เป็นเที่ยงคืนตามเวลาท้องถิ่น)
+func unavailableRange(u barberBookingModels.Unavailability, day time.Time) (timeRange, bool) {
+	dayKey := day.Format("2006-01-02")
+	if u.RepeatWeekdays == "" {
+		if u.Date.Format("2006-01-02") != dayKey {
+			return timeRange{}, false
+		}
+	} else {
+		days, err := parseRepeatWeekdays(u.RepeatWeekdays)
+		if err != nil || !days[getWeekday(day)] ||
+			dayKey < u.Date.Format("2006-01-02") ||
+			(u.RepeatUntil != nil && dayKey > u.RepeatUntil.Format("2006-01-02")) {
+			return timeRange{}, false
+		}
+	}
+
+	if u.StartTime == nil || u.EndTime == nil {
+		return timeRange{day, day.AddDate(0, 0, 1)}, true
+	}
+	return timeRange{u.StartTime.ToTime(day), u.EndTime.ToTime(day)}, true
+}
+
+// unavailableDuring ตรวจว่าช่วง start-end ชนกับรายการปิดของสาขาหรือของช่างคนนี้หรือไม่
+func unavailableDuring(list []barberBookingModels.Unavailability, barberID uint, start, end time.Time) bool {
+	loc := time.Now().Location()
+	from, to := start.In(loc), end.In(loc)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, u := range list {
+			if u.BarberID != nil && *u.BarberID != barberID {
+				continue
+			}
+			if r, ok := unavailableRange(u, day); ok && r.overlaps(start, end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// barberUnavailableTx ตรวจรายการปิดของช่างคนเดียว (รวมของสาขา) สำหรับช่วงเวลาที่จะจอง
+func barberUnavailableTx(tx *gorm.DB, branchID, barberID uint, start, end time.Time) (bool, error) {
+	loc := time.Now().Location()
+	from, to := start.In(loc), end.In(loc)
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	lastDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	list, err := loadUnavailabilitiesTx(tx, branchID, []uint{barberID}, firstDay, lastDay)
+	if err != nil {
+		return false, err
+	}
+	return unavailableDuring(list, barberID, start, end), nil
+}
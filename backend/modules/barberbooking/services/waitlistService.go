@@ -0,0 +1,358 @@
+package barberBookingService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ระยะเวลาที่ hold ช่วงเวลาไว้ให้ลูกค้าใน waitlist ก่อนจะปล่อยให้คนอื่นจองได้
+const waitlistHoldDuration = 30 * time.Minute
+
+type waitlistService struct {
+	DB           *gorm.DB
+	Appointments barberBookingPort.IAppointment
+}
+
+func NewWaitlistService(db *gorm.DB, appointments barberBookingPort.IAppointment) barberBookingPort.IWaitlist {
+	return &waitlistService{DB: db, Appointments: appointments}
+}
+
+func (s *waitlistService) JoinWaitlist(
+	ctx context.Context,
+	entry *barberBookingModels.WaitlistEntry,
+	guest *barberBookingPort.CustomerInput,
+) (*barberBookingModels.WaitlistEntry, error) {
+	if entry == nil {
+		return nil, errors.New("input waitlist entry is required")
+	}
+	if entry.TenantID == 0 || entry.BranchID == 0 || entry.ServiceID == 0 ||
+		entry.DesiredStart.IsZero() || entry.DesiredEnd.IsZero() {
+		return nil, errors.New("missing required fields")
+	}
+	if entry.CustomerID == 0 && guest == nil {
+		return nil, errors.New("guest customer requires 'Customer' field with name and phone")
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if entry.CustomerID == 0 {
+			customer, err := (&appointmentService{DB: tx}).getOrCreateGuestCustomerTx(tx,
+				entry.TenantID, entry.BranchID, guest.Name, guest.Phone,
+			)
+			if err != nil {
+				return err
+			}
+			entry.CustomerID = customer.ID
+		}
+
+		var service barberBookingModels.Service
+		if err := tx.
+			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", entry.ServiceID, entry.TenantID).
+			First(&service).Error; err != nil {
+			return fmt.Errorf("service not found or access denied")
+		}
+		duration := time.Duration(service.Duration) * time.Minute
+		if entry.DesiredEnd.Sub(entry.DesiredStart) < duration {
+			return fmt.Errorf("invalid desired window: must be at least %d minutes", service.Duration)
+		}
+
+		if entry.BarberID != nil {
+			var barber barberBookingModels.Barber
+			if err := tx.
+				Where("id = ? AND tenant_id = ? AND branch_id = ? AND deleted_at IS NULL",
+					*entry.BarberID, entry.TenantID, entry.BranchID).
+				First(&barber).Error; err != nil {
+				return fmt.Errorf("barber not found or mismatched branch")
+			}
+		}
+
+		entry.Status = barberBookingModels.WaitlistWaiting
+		if err := tx.Create(entry).Error; err != nil {
+			return fmt.Errorf("failed to create waitlist entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *waitlistService) ListWaitlist(
+	ctx context.Context,
+	tenantID, branchID uint,
+	status []barberBookingModels.WaitlistStatus,
+) ([]barberBookingModels.WaitlistEntry, error) {
+	if len(status) == 0 {
+		status = []barberBookingModels.WaitlistStatus{
+			barberBookingModels.WaitlistWaiting,
+			barberBookingModels.WaitlistOffered,
+		}
+	}
+
+	var entries []barberBookingModels.WaitlistEntry
+	if err := s.DB.WithContext(ctx).
+		Preload("Lock").
+		Where("tenant_id = ? AND branch_id = ? AND status IN ? AND deleted_at IS NULL", tenantID, branchID, status).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch waitlist: %w", err)
+	}
+	return entries, nil
+}
+
+// PromoteWaitlistEntry lock entry แล้วสร้างนัดและปิด entry ใน transaction เดียว
+// promote ซ้ำพร้อมกันจึงได้นัดเดียว และนัดไม่ค้างถ้าอัปเดต entry ไม่สำเร็จ
+func (s *waitlistService) PromoteWaitlistEntry(
+	ctx context.Context,
+	tenantID, entryID uint,
+	input barberBookingPort.PromoteWaitlistRequest,
+) (*barberBookingModels.WaitlistEntry, error) {
+	var entry barberBookingModels.WaitlistEntry
+	var appt *barberBookingModels.Appointment
+	var released *barberBookingModels.AppointmentLock
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", entryID, tenantID).
+			First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("waitlist entry with ID %d not found", entryID)
+			}
+			return err
+		}
+		if entry.Status != barberBookingModels.WaitlistWaiting && entry.Status != barberBookingModels.WaitlistOffered {
+			return fmt.Errorf("waitlist entry cannot be promoted in status %s", entry.Status)
+		}
+		if entry.LockID != nil {
+			var lock barberBookingModels.AppointmentLock
+			if err := tx.First(&lock, *entry.LockID).Error; err != nil {
+				return fmt.Errorf("failed to fetch waitlist hold: %w", err)
+			}
+			entry.Lock = &lock
+		}
+
+		// เวลาและช่าง: ใช้ค่าที่ส่งมา ถ้าไม่มีใช้จาก hold
+		appt = &barberBookingModels.Appointment{
+			TenantID:   entry.TenantID,
+			BranchID:   entry.BranchID,
+			ServiceID:  entry.ServiceID,
+			CustomerID: entry.CustomerID,
+			UserID:     input.ActorUserID,
+			Notes:      entry.Notes,
+		}
+		if entry.BarberID != nil {
+			appt.BarberID = *entry.BarberID
+		}
+		if entry.Lock != nil {
+			appt.StartTime = entry.Lock.StartTime
+			appt.BarberID = entry.Lock.BarberID
+		}
+		if input.StartTime != nil {
+			appt.StartTime = *input.StartTime
+		}
+		if input.BarberID != nil {
+			appt.BarberID = *input.BarberID
+		}
+		if appt.StartTime.IsZero() {
+			return errors.New("start_time is required when the entry has no hold")
+		}
+		// จองตรงกับ hold → ใช้ hold นั้นจอง
+		if entry.Lock != nil && entry.Lock.IsActive &&
+			appt.BarberID == entry.Lock.BarberID && appt.StartTime.Equal(entry.Lock.StartTime) {
+			appt.LockID = entry.LockID
+		}
+
+		// สร้างนัดก่อนแล้วจึงปล่อย hold (กรณีย้ายไปช่วงอื่น hold ยัง active อยู่)
+		if err := (&appointmentService{DB: tx}).createAppointmentTx(ctx, tx, appt); err != nil {
+			return err
+		}
+		var userID, custID *uint
+		if appt.UserID != nil {
+			userID = appt.UserID
+		} else {
+			custID = &appt.CustomerID
+		}
+		if err := logStatusChangeTx(tx, appt.ID, "", string(appt.Status), userID, custID, "promoted from waitlist"); err != nil {
+			return err
+		}
+
+		if appt.LockID == nil && entry.Lock != nil && entry.Lock.IsActive {
+			if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+				Where("id = ?", entry.Lock.ID).
+				Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("failed to release waitlist hold: %w", err)
+			}
+			released = entry.Lock
+		}
+		entry.Status = barberBookingModels.WaitlistPromoted
+		entry.AppointmentID = &appt.ID
+		if err := tx.Omit("Lock").Save(&entry).Error; err != nil {
+			return fmt.Errorf("failed to update waitlist entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	SlotEvents.Publish(appointmentEvent(barberBookingPort.SlotEventAppointmentCreated, appt))
+	// hold ที่ไม่ได้ใช้จอง (ย้ายไปช่วงอื่น) ถูกปล่อย → ช่วงเดิมว่าง
+	if released != nil {
+		SlotEvents.Publish(lockEvent(barberBookingPort.SlotEventLockReleased, released))
+	}
+	return &entry, nil
+}
+
+func (s *waitlistService) CancelWaitlistEntry(ctx context.Context, tenantID, entryID uint) error {
+	var entry barberBookingModels.WaitlistEntry
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Preload("Lock").
+			Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", entryID, tenantID).
+			First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("waitlist entry with ID %d not found", entryID)
+			}
+			return err
+		}
+		if entry.Status == barberBookingModels.WaitlistPromoted {
+			return errors.New("waitlist entry cannot be cancelled after promotion")
+		}
+
+		if entry.LockID != nil {
+			if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+				Where("id = ?", *entry.LockID).
+				Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("failed to release waitlist hold: %w", err)
+			}
+		}
+		entry.Status = barberBookingModels.WaitlistCancelled
+		if err := tx.Omit("Lock").Save(&entry).Error; err != nil {
+			return fmt.Errorf("failed to cancel waitlist entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if entry.Lock != nil && entry.Lock.IsActive {
+		SlotEvents.Publish(lockEvent(barberBookingPort.SlotEventLockReleased, entry.Lock))
+	}
+	return nil
+}
+
+// offerFreedSlotTx จับคู่ช่วงเวลาที่ว่างลง (ช่วง block ของนัดที่ยกเลิก/เลื่อน) กับ waitlist ของสาขาแบบ FIFO
+// entry แรกที่บริการ (รวม buffer) พอดีกับช่วงนั้นจะได้ hold (AppointmentLock) และเปลี่ยนเป็น OFFERED
+// คืน nil ถ้าไม่มี entry ที่จับคู่ได้ หรือช่วงเวลาถูกจอง/lock ไปก่อน
+func offerFreedSlotTx(
+	tx *gorm.DB,
+	tenantID, branchID, barberID uint,
+	freedStart, freedEnd time.Time,
+) (*barberBookingModels.WaitlistEntry, error) {
+	var entries []barberBookingModels.WaitlistEntry
+	if err := tx.
+		Where("tenant_id = ? AND branch_id = ? AND status = ? AND deleted_at IS NULL",
+			tenantID, branchID, barberBookingModels.WaitlistWaiting).
+		Where("barber_id IS NULL OR barber_id = ?", barberID).
+		Where("desired_start < ? AND desired_end > ?", freedEnd, freedStart).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch waitlist: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	// lock แถวช่างก่อนตรวจ ลำดับเดียวกับ CreateAppointmentLock/checkBarberAvailabilityTx
+	var barber barberBookingModels.Barber
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&barber, barberID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock barber: %w", err)
+	}
+
+	now := time.Now()
+	for i := range entries {
+		entry := &entries[i]
+
+		var service barberBookingModels.Service
+		if err := tx.Where("id = ?", entry.ServiceID).First(&service).Error; err != nil {
+			continue
+		}
+		span := serviceSpan(service)
+		start := latest(freedStart.Add(span.BufferBefore), entry.DesiredStart)
+		end := start.Add(span.Duration)
+		blockStart, blockEnd := span.block(start)
+		if blockEnd.After(freedEnd) || end.After(entry.DesiredEnd) || start.Before(now) {
+			continue
+		}
+
+		// ช่วงที่ว่างอาจถูกจองบางส่วนไปแล้ว (เช่น เลื่อนนัดไปทับช่วงเดิม)
+		var busy int64
+		if err := tx.Model(&barberBookingModels.Appointment{}).
+			Where("tenant_id = ? AND barber_id = ? AND status IN ? AND block_start < ? AND block_end > ? AND deleted_at IS NULL",
+				tenantID, barberID, slotBlockingStatuses,
+				blockEnd, blockStart).
+			Count(&busy).Error; err != nil {
+			return nil, err
+		}
+		if busy == 0 {
+			if err := tx.Model(&barberBookingModels.AppointmentLock{}).
+				Where("tenant_id = ? AND barber_id = ? AND is_active = ? AND expires_at > ? AND block_start < ? AND block_end > ?",
+					tenantID, barberID, true, now, blockEnd, blockStart).
+				Count(&busy).Error; err != nil {
+				return nil, err
+			}
+		}
+		if busy > 0 {
+			continue
+		}
+		if err := releaseExpiredLocksTx(tx, barberID, blockStart, blockEnd); err != nil {
+			return nil, fmt.Errorf("failed to release expired locks: %w", err)
+		}
+
+		lock := barberBookingModels.AppointmentLock{
+			TenantID:   tenantID,
+			BranchID:   branchID,
+			BarberID:   barberID,
+			CustomerID: entry.CustomerID,
+			StartTime:  start,
+			EndTime:    end,
+			BlockStart: blockStart,
+			BlockEnd:   blockEnd,
+			ExpiresAt:  now.Add(waitlistHoldDuration),
+			IsActive:   true,
+		}
+		// savepoint: ชนกับ lock/นัดอื่นแค่ข้ามไป ไม่ให้การยกเลิก/ไม่มาที่เรียกอยู่ล้มทั้ง transaction
+		if err := tx.Transaction(func(sp *gorm.DB) error { return sp.Create(&lock).Error }); err != nil {
+			if isSlotTaken(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to create waitlist hold: %w", err)
+		}
+
+		entry.Status = barberBookingModels.WaitlistOffered
+		entry.LockID = &lock.ID
+		entry.OfferedAt = &now
+		if err := tx.Save(entry).Error; err != nil {
+			return nil, fmt.Errorf("failed to update waitlist entry: %w", err)
+		}
+		entry.Lock = &lock
+		if err := notifyWaitlistOfferTx(tx, entry, &lock); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+	return nil, nil
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -94,6 +94,19 @@ func (m *MockAppointmentService) UpdateAppointment(
 	return args.Get(0).(*barberBookingModels.Appointment), args.Error(1)
 }
 
+func (m *MockAppointmentService) TransitionStatus(
+	ctx context.Context,
+	tenantID, appointmentID uint,
+	to barberBookingModels.AppointmentStatus,
+	input barberBookingPort.StatusTransitionInput,
+) (*barberBookingModels.Appointment, error) {
+	args := m.Called(ctx, tenantID, appointmentID, to, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*barberBookingModels.Appointment), args.Error(1)
+}
+
 func (m *MockAppointmentService) GetAppointmentByID(
 	ctx context.Context,
 	id uint,
@@ -128,12 +141,13 @@ func (m *MockAppointmentService) CancelAppointment(
 
 func (m *MockAppointmentService) RescheduleAppointment(
 	ctx context.Context,
+	tenantID uint,
 	appointmentID uint,
 	newStartTime time.Time,
 	actorUserID *uint,
 	actorCustomerID *uint,
 ) error {
-	args := m.Called(ctx, appointmentID, newStartTime, actorUserID, actorCustomerID)
+	args := m.Called(ctx, tenantID, appointmentID, newStartTime, actorUserID, actorCustomerID)
 	return args.Error(0)
 }
 
@@ -1308,6 +1322,7 @@ func TestRescheduleAppointment_Controller(t *testing.T) {
 		svc.
 			On("RescheduleAppointment",
 				mock.Anything,
+				uint(1),
 				uint(42),
 				newTime,
 				&userID,
@@ -1341,6 +1356,7 @@ func TestRescheduleAppointment_Controller(t *testing.T) {
 		svc.
 			On("RescheduleAppointment",
 				mock.Anything,
+				uint(1),
 				uint(100),
 				newTime,
 				(*uint)(nil),
@@ -1374,6 +1390,7 @@ func TestRescheduleAppointment_Controller(t *testing.T) {
 		svc.
 			On("RescheduleAppointment",
 				mock.Anything,
+				uint(1),
 				uint(200),
 				newTime,
 				&userID,
@@ -1407,6 +1424,7 @@ func TestRescheduleAppointment_Controller(t *testing.T) {
 		svc.
 			On("RescheduleAppointment",
 				mock.Anything,
+				uint(1),
 				uint(300),
 				newTime,
 				&userID,
@@ -1441,6 +1459,7 @@ func TestRescheduleAppointment_Controller(t *testing.T) {
 		svc.
 			On("RescheduleAppointment",
 				mock.Anything,
+				uint(1),
 				uint(400),
 				newTime,
 				(*uint)(nil),
@@ -0,0 +1,117 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	coreModels "myapp/modules/core/models"
+)
+
+func TestAppointmentAutoStatus(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 2)
+	jobs := barberBookingServices.NewAppointmentAutoStatusService(db)
+
+	seed := func(barberID uint, start time.Time, status barberBookingModels.AppointmentStatus) *barberBookingModels.Appointment {
+		ap := f.newAppointment(barberID, start)
+		ap.EndTime = start.Add(30 * time.Minute)
+		ap.Status = status
+		assert.NoError(t, db.Create(ap).Error)
+		return ap
+	}
+	statusOf := func(ap *barberBookingModels.Appointment) barberBookingModels.AppointmentStatus {
+		var out barberBookingModels.Appointment
+		assert.NoError(t, db.First(&out, ap.ID).Error)
+		return out.Status
+	}
+
+	t.Run("NoShow_AfterDefaultGrace", func(t *testing.T) {
+		overdue := seed(f.Barbers[0].ID, now.Add(-20*time.Minute), barberBookingModels.StatusConfirmed)
+		withinGrace := seed(f.Barbers[1].ID, now.Add(-10*time.Minute), barberBookingModels.StatusConfirmed)
+		pending := seed(f.Barbers[0].ID, now.Add(-3*time.Hour), barberBookingModels.StatusPending)
+
+		n, err := jobs.MarkNoShows(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, barberBookingModels.StatusNoShow, statusOf(overdue))
+		assert.Equal(t, barberBookingModels.StatusConfirmed, statusOf(withinGrace))
+		assert.Equal(t, barberBookingModels.StatusPending, statusOf(pending))
+
+		var logEntry barberBookingModels.AppointmentStatusLog
+		assert.NoError(t, db.Where("appointment_id = ?", overdue.ID).First(&logEntry).Error)
+		assert.True(t, logEntry.ChangedBySystem)
+		assert.Nil(t, logEntry.ChangedByUserID)
+		assert.Equal(t, string(barberBookingModels.StatusConfirmed), logEntry.OldStatus)
+		assert.Equal(t, string(barberBookingModels.StatusNoShow), logEntry.NewStatus)
+	})
+
+	t.Run("Complete_AfterEndTimeGrace", func(t *testing.T) {
+		finished := seed(f.Barbers[0].ID, now.Add(-2*time.Hour), barberBookingModels.StatusInService)
+		running := seed(f.Barbers[1].ID, now.Add(-40*time.Minute), barberBookingModels.StatusInService)
+
+		n, err := jobs.CompleteFinished(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, barberBookingModels.StatusComplete, statusOf(finished))
+		// จบไปแล้ว 10 นาที ยังไม่เกิน grace 30 นาที
+		assert.Equal(t, barberBookingModels.StatusInService, statusOf(running))
+	})
+
+	t.Run("BranchSetting_DisablesAndChangesGrace", func(t *testing.T) {
+		settings := barberBookingServices.NewBranchBookingSettingService(db)
+		disabled, zero := false, 0
+		_, err := settings.UpdateSetting(ctx, f.TenantID, f.BranchID, barberBookingPort.UpdateBranchBookingSettingRequest{
+			AutoNoShowEnabled:        &disabled,
+			AutoCompleteGraceMinutes: &zero,
+		})
+		assert.NoError(t, err)
+
+		overdue := seed(f.Barbers[1].ID, now.Add(-4*time.Hour), barberBookingModels.StatusConfirmed)
+		n, err := jobs.MarkNoShows(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		assert.Equal(t, barberBookingModels.StatusConfirmed, statusOf(overdue))
+
+		// grace 0 → ปิดทันทีที่ถึงเวลาจบ
+		n, err = jobs.CompleteFinished(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("NoShow_GracePerBranch", func(t *testing.T) {
+		other := coreModels.Branch{TenantID: f.TenantID, Name: "Branch 2"}
+		assert.NoError(t, db.Create(&other).Error)
+		barber := barberBookingModels.Barber{TenantID: f.TenantID, BranchID: other.ID, UserID: 200}
+		assert.NoError(t, db.Create(&barber).Error)
+		sixty := 60
+		_, err := barberBookingServices.NewBranchBookingSettingService(db).UpdateSetting(ctx, f.TenantID, other.ID,
+			barberBookingPort.UpdateBranchBookingSettingRequest{NoShowGraceMinutes: &sixty})
+		assert.NoError(t, err)
+
+		seedAt := func(start time.Time) *barberBookingModels.Appointment {
+			ap := f.newAppointment(barber.ID, start)
+			ap.BranchID = other.ID
+			ap.EndTime = start.Add(30 * time.Minute)
+			ap.Status = barberBookingModels.StatusConfirmed
+			assert.NoError(t, db.Create(ap).Error)
+			return ap
+		}
+		withinGrace := seedAt(now.Add(-30 * time.Minute))
+		overdue := seedAt(now.Add(-90 * time.Minute))
+
+		n, err := jobs.MarkNoShows(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, barberBookingModels.StatusNoShow, statusOf(overdue))
+		assert.Equal(t, barberBookingModels.StatusConfirmed, statusOf(withinGrace))
+	})
+}
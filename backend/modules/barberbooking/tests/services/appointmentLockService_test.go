@@ -0,0 +1,85 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingServices "myapp/modules/barberbooking/services"
+)
+
+func TestAppointmentLockService_SweepAndHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	svc := barberBookingServices.NewAppointmentLockService(db)
+	barberID := f.Barbers[0].ID
+
+	newLock := func(at time.Time, createdAt, expiresAt time.Time) barberBookingModels.AppointmentLock {
+		lock := barberBookingModels.AppointmentLock{
+			TenantID: f.TenantID, BranchID: f.BranchID, BarberID: barberID, CustomerID: f.Customer.ID,
+			StartTime: at, EndTime: at.Add(30 * time.Minute),
+			CreatedAt: createdAt, ExpiresAt: expiresAt, IsActive: true,
+		}
+		assert.NoError(t, db.Create(&lock).Error)
+		return lock
+	}
+
+	t.Run("IsSlotAvailable_CombinesAppointmentsAndLocks", func(t *testing.T) {
+		appt := f.newAppointment(barberID, start)
+		appt.EndTime = start.Add(30 * time.Minute)
+		appt.Status = barberBookingModels.StatusConfirmed
+		assert.NoError(t, db.Create(appt).Error)
+		newLock(start.Add(time.Hour), time.Now(), time.Now().Add(5*time.Minute))
+		newLock(start.Add(2*time.Hour), time.Now().Add(-10*time.Minute), time.Now().Add(-time.Minute))
+
+		ok, err := svc.IsSlotAvailable(ctx, f.TenantID, f.BranchID, barberID, start, start.Add(30*time.Minute))
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = svc.IsSlotAvailable(ctx, f.TenantID, f.BranchID, barberID, start.Add(time.Hour), start.Add(90*time.Minute))
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		// lock หมดอายุแล้วไม่นับ แม้ยังไม่ถูก sweep
+		ok, err = svc.IsSlotAvailable(ctx, f.TenantID, f.BranchID, barberID, start.Add(2*time.Hour), start.Add(150*time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("CleanupExpiredLocks_DeactivatesOnlyExpired", func(t *testing.T) {
+		assert.NoError(t, svc.CleanupExpiredLocks(ctx))
+
+		var active []barberBookingModels.AppointmentLock
+		assert.NoError(t, db.Where("is_active = ?", true).Find(&active).Error)
+		if assert.Len(t, active, 1) {
+			assert.True(t, active[0].StartTime.Equal(start.Add(time.Hour)))
+		}
+	})
+
+	t.Run("Heartbeat_ExtendsUpToMaxHold", func(t *testing.T) {
+		createdAt := time.Now().Add(-18 * time.Minute)
+		lock := newLock(start.Add(3*time.Hour), createdAt, time.Now().Add(time.Minute))
+
+		extended, err := svc.ExtendAppointmentLock(ctx, lock.ID, f.Customer.ID)
+		assert.NoError(t, err)
+		// ต่อได้ไม่เกิน 20 นาทีนับจากตอนสร้าง
+		assert.WithinDuration(t, createdAt.Add(20*time.Minute), extended.ExpiresAt, time.Second)
+
+		_, err = svc.ExtendAppointmentLock(ctx, lock.ID, f.Customer.ID+1)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "belongs to another customer")
+	})
+
+	t.Run("Heartbeat_ExpiredLock_Fail", func(t *testing.T) {
+		lock := newLock(start.Add(4*time.Hour), time.Now().Add(-10*time.Minute), time.Now().Add(-time.Second))
+		_, err := svc.ExtendAppointmentLock(ctx, lock.ID, f.Customer.ID)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "expired")
+	})
+}
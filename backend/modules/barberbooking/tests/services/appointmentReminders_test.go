@@ -0,0 +1,164 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"myapp/jobs"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	notificationModels "myapp/modules/notification/models"
+)
+
+func pendingReminders(t *testing.T, db *gorm.DB) []jobs.Job {
+	var list []jobs.Job
+	require.NoError(t, db.
+		Where("type = ? AND status = ?", barberBookingServices.JobAppointmentReminder, jobs.StatusPending).
+		Order("run_at").
+		Find(&list).Error)
+	return list
+}
+
+func TestAppointmentService_Reminders(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+
+	db := setupTestAppointmentDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	f := seedAppointmentFixture(t, db, 1)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+	require.NoError(t, db.Create(&notificationModels.NotificationChannelConfig{
+		TenantID: f.TenantID, Channel: notificationModels.ChannelSMS, Enabled: true, Settings: `{"endpoint":"https://sms.example.com"}`,
+	}).Error)
+
+	t.Run("Created appointment schedules default 24h and 2h reminders", func(t *testing.T) {
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		require.NoError(t, err)
+
+		list := pendingReminders(t, db)
+		require.Len(t, list, 2)
+		assert.WithinDuration(t, start.Add(-24*time.Hour), list[0].RunAt, time.Second)
+		assert.WithinDuration(t, start.Add(-2*time.Hour), list[1].RunAt, time.Second)
+
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+		assert.Empty(t, pendingReminders(t, db))
+	})
+
+	t.Run("Reschedule replaces reminders; offsets already passed are skipped", func(t *testing.T) {
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(time.Hour)))
+		require.NoError(t, err)
+
+		soon := time.Now().Add(3 * time.Hour).Truncate(time.Minute)
+		require.NoError(t, svc.RescheduleAppointment(ctx, f.TenantID, resp.ID, soon, nil, nil))
+
+		list := pendingReminders(t, db)
+		require.Len(t, list, 1)
+		assert.WithinDuration(t, soon.Add(-2*time.Hour), list[0].RunAt, time.Second)
+
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+	})
+
+	t.Run("Update of start time replaces reminders and notifies customer", func(t *testing.T) {
+		rescheduled := func() int64 {
+			var n int64
+			require.NoError(t, db.Model(&notificationModels.Notification{}).
+				Where("event = ?", barberBookingServices.NotifyAppointmentRescheduled).Count(&n).Error)
+			return n
+		}
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(time.Hour)))
+		require.NoError(t, err)
+		before := rescheduled()
+
+		soon := time.Now().Add(3 * time.Hour).Truncate(time.Minute)
+		_, err = svc.UpdateAppointment(ctx, resp.ID, f.TenantID, &barberBookingModels.Appointment{StartTime: soon})
+		require.NoError(t, err)
+
+		list := pendingReminders(t, db)
+		require.Len(t, list, 1)
+		assert.WithinDuration(t, soon.Add(-2*time.Hour), list[0].RunAt, time.Second)
+		assert.Equal(t, before+1, rescheduled())
+
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+	})
+
+	t.Run("Tenant offsets are used and reminders can be disabled", func(t *testing.T) {
+		settings := barberBookingServices.NewReminderSettingService(db)
+		_, err := settings.UpdateSetting(ctx, f.TenantID, barberBookingPort.UpdateReminderSettingRequest{OffsetsMinutes: []int{30, 60, 30}})
+		require.NoError(t, err)
+		got, err := settings.GetSetting(ctx, f.TenantID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{60, 30}, got.OffsetsMinutes)
+
+		_, err = settings.UpdateSetting(ctx, f.TenantID, barberBookingPort.UpdateReminderSettingRequest{OffsetsMinutes: []int{0}})
+		assert.ErrorContains(t, err, "invalid offsets_minutes")
+
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(2*time.Hour)))
+		require.NoError(t, err)
+		list := pendingReminders(t, db)
+		require.Len(t, list, 2)
+		assert.WithinDuration(t, start.Add(time.Hour), list[0].RunAt, time.Second)
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+
+		disabled := false
+		_, err = settings.UpdateSetting(ctx, f.TenantID, barberBookingPort.UpdateReminderSettingRequest{Enabled: &disabled})
+		require.NoError(t, err)
+		resp, err = svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(3*time.Hour)))
+		require.NoError(t, err)
+		assert.Empty(t, pendingReminders(t, db))
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+
+		enabled := true
+		_, err = settings.UpdateSetting(ctx, f.TenantID, barberBookingPort.UpdateReminderSettingRequest{
+			Enabled: &enabled, OffsetsMinutes: []int{24 * 60, 2 * 60},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Worker sends each reminder once and skips stale ones", func(t *testing.T) {
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(4*time.Hour)))
+		require.NoError(t, err)
+		list := pendingReminders(t, db)
+		require.Len(t, list, 2)
+
+		w := jobs.NewWorker(db, "test")
+		barberBookingServices.RegisterReminderJobs(w, db)
+		// ให้ 24h reminder ถึงเวลา
+		w.Now = func() time.Time { return list[0].RunAt.Add(time.Second) }
+
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+
+		// รันงานเดิมซ้ำ (เช่น lease หมดแล้วถูกรันใหม่) ต้องไม่เกิดข้อความซ้ำ
+		require.NoError(t, db.Model(&jobs.Job{}).Where("id = ?", list[0].ID).
+			Updates(map[string]interface{}{"status": jobs.StatusPending}).Error)
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+
+		var reminders []notificationModels.Notification
+		require.NoError(t, db.Where("event = ?", barberBookingServices.NotifyAppointmentReminder).Find(&reminders).Error)
+		require.Len(t, reminders, 1)
+		assert.Equal(t, f.Customer.Phone, reminders[0].Recipient)
+
+		// นัดถูกทำเครื่องหมาย NO_SHOW ก่อน 2h reminder → ไม่ส่ง
+		require.NoError(t, db.Model(&barberBookingModels.Appointment{}).Where("id = ?", resp.ID).
+			Update("status", barberBookingModels.StatusNoShow).Error)
+		w.Now = func() time.Time { return list[1].RunAt.Add(time.Second) }
+		_, err = w.RunOnce(ctx)
+		require.NoError(t, err)
+
+		var count int64
+		db.Model(&notificationModels.Notification{}).Where("event = ?", barberBookingServices.NotifyAppointmentReminder).Count(&count)
+		assert.Equal(t, int64(1), count)
+		var job jobs.Job
+		require.NoError(t, db.First(&job, list[1].ID).Error)
+		assert.Equal(t, jobs.StatusDone, job.Status)
+	})
+}
@@ -11,9 +11,11 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"myapp/jobs"
 	barberBookingModels "myapp/modules/barberbooking/models"
 	barberBookingServices "myapp/modules/barberbooking/services"
 	coreModels "myapp/modules/core/models"
+	webhookModels "myapp/modules/webhook/models"
 )
 
 func setupTestReviewDB(t *testing.T) *gorm.DB {
@@ -44,6 +46,9 @@ func setupTestReviewDB(t *testing.T) *gorm.DB {
         &coreModels.Branch{},
         &barberBookingModels.Appointment{},
         &barberBookingModels.AppointmentReview{},
+        &webhookModels.WebhookEndpoint{},
+        &webhookModels.WebhookDelivery{},
+        &jobs.Job{},
     ); err != nil {
         t.Fatalf("migrate failed: %v", err)
     }
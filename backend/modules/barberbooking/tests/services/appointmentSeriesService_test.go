@@ -0,0 +1,154 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+)
+
+// stubStatusLog เก็บ log ไว้ในหน่วยความจำ เพราะ log ตอนสร้างนัดเขียนผ่าน connection อื่น (นอก transaction)
+// ระหว่าง transaction ซึ่ง sqlite :memory: จะมองไม่เห็นตาราง
+type stubStatusLog struct{ notes []string }
+
+func (s *stubStatusLog) LogStatusChange(_ context.Context, _ uint, _, _ string, _ *uint, _ *uint, notes string) error {
+	s.notes = append(s.notes, notes)
+	return nil
+}
+
+func (s *stubStatusLog) GetLogsForAppointment(context.Context, uint) ([]barberBookingModels.AppointmentStatusLog, error) {
+	return nil, nil
+}
+
+func (s *stubStatusLog) DeleteLogsByAppointmentID(context.Context, uint) error { return nil }
+
+func TestAppointmentSeriesService(t *testing.T) {
+	ctx := context.Background()
+	// วันเสาร์ 10:00
+	start := time.Date(2030, 1, 5, 10, 0, 0, 0, time.UTC)
+	actor := uint(1)
+
+	setup := func(t *testing.T) (appointmentFixture, barberBookingPort.IAppointment, barberBookingPort.IAppointmentSeries, func(uint) barberBookingModels.Appointment) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		apps := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		load := func(id uint) barberBookingModels.Appointment {
+			var ap barberBookingModels.Appointment
+			assert.NoError(t, db.First(&ap, id).Error)
+			return ap
+		}
+		return f, apps, barberBookingServices.NewAppointmentSeriesService(db, apps), load
+	}
+
+	every3Weeks := func(count int) barberBookingPort.RecurrenceRule {
+		return barberBookingPort.RecurrenceRule{Frequency: barberBookingModels.FrequencyWeekly, Interval: 3, Count: &count}
+	}
+
+	t.Run("Create_ReportsConflictPerOccurrence", func(t *testing.T) {
+		f, apps, svc, load := setup(t)
+
+		// ครั้งที่ 2 (26 ม.ค.) ช่างติดนัดอื่นอยู่แล้ว
+		_, err := apps.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.AddDate(0, 0, 21)))
+		assert.NoError(t, err)
+
+		res, err := svc.CreateSeries(ctx, f.newAppointment(f.Barbers[0].ID, start), every3Weeks(3))
+		assert.NoError(t, err)
+		if assert.Len(t, res.Occurrences, 3) {
+			assert.NotNil(t, res.Occurrences[0].AppointmentID)
+			assert.Nil(t, res.Occurrences[1].AppointmentID)
+			assert.Contains(t, res.Occurrences[1].Error, "not available")
+			assert.Equal(t, start.AddDate(0, 0, 42), res.Occurrences[2].StartTime)
+
+			ap := load(*res.Occurrences[2].AppointmentID)
+			assert.Equal(t, res.SeriesID, *ap.SeriesID)
+		}
+	})
+
+	t.Run("GuestSeries_NothingBooked_RollsBackCustomer", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		apps := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		svc := barberBookingServices.NewAppointmentSeriesService(db, apps)
+		for i := 0; i < 2; i++ {
+			_, err := apps.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.AddDate(0, 0, 21*i)))
+			assert.NoError(t, err)
+		}
+
+		input := f.newAppointment(f.Barbers[0].ID, start)
+		input.CustomerID = 0
+		input.Customer = &barberBookingModels.Customer{Name: "Walk-in", Phone: "0899999999"}
+		_, err := svc.CreateSeries(ctx, input, every3Weeks(2))
+		assert.ErrorContains(t, err, "no occurrence could be booked")
+
+		var customers, series int64
+		assert.NoError(t, db.Model(&barberBookingModels.Customer{}).Where("phone = ?", "0899999999").Count(&customers).Error)
+		assert.NoError(t, db.Model(&barberBookingModels.AppointmentSeries{}).Count(&series).Error)
+		assert.Zero(t, customers)
+		assert.Zero(t, series)
+	})
+
+	t.Run("Monthly_SkipsMissingDays", func(t *testing.T) {
+		f, _, svc, _ := setup(t)
+		count := 3
+		jan31 := time.Date(2030, 1, 31, 10, 0, 0, 0, time.UTC)
+
+		res, err := svc.CreateSeries(ctx, f.newAppointment(f.Barbers[0].ID, jan31),
+			barberBookingPort.RecurrenceRule{Frequency: barberBookingModels.FrequencyMonthly, Interval: 1, Count: &count})
+		assert.NoError(t, err)
+		if assert.Len(t, res.Occurrences, 3) {
+			assert.Equal(t, time.March, res.Occurrences[1].StartTime.Month())
+			assert.Equal(t, time.May, res.Occurrences[2].StartTime.Month())
+		}
+	})
+
+	t.Run("InvalidRule_Fail", func(t *testing.T) {
+		f, _, svc, _ := setup(t)
+		_, err := svc.CreateSeries(ctx, f.newAppointment(f.Barbers[0].ID, start),
+			barberBookingPort.RecurrenceRule{Frequency: barberBookingModels.FrequencyWeekly, Interval: 1})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "count or until")
+	})
+
+	t.Run("CancelThisAndFollowing", func(t *testing.T) {
+		f, _, svc, load := setup(t)
+		res, err := svc.CreateSeries(ctx, f.newAppointment(f.Barbers[0].ID, start), every3Weeks(4))
+		assert.NoError(t, err)
+
+		out, err := svc.CancelOccurrences(ctx, f.TenantID, *res.Occurrences[1].AppointmentID,
+			barberBookingPort.ScopeThisAndAfter, &actor, nil)
+		assert.NoError(t, err)
+		assert.Len(t, out.Occurrences, 3)
+
+		assert.Equal(t, barberBookingModels.StatusConfirmed, load(*res.Occurrences[0].AppointmentID).Status)
+		for _, occ := range res.Occurrences[1:] {
+			assert.Equal(t, barberBookingModels.StatusCancelled, load(*occ.AppointmentID).Status)
+		}
+	})
+
+	t.Run("RescheduleEntireSeries_ShiftsEveryOccurrence", func(t *testing.T) {
+		f, _, svc, load := setup(t)
+		res, err := svc.CreateSeries(ctx, f.newAppointment(f.Barbers[0].ID, start), every3Weeks(3))
+		assert.NoError(t, err)
+
+		out, err := svc.RescheduleOccurrences(ctx, f.TenantID, *res.Occurrences[2].AppointmentID,
+			start.AddDate(0, 0, 42).Add(2*time.Hour), barberBookingPort.ScopeEntireSeries, &actor, nil)
+		assert.NoError(t, err)
+		for i, occ := range out.Occurrences {
+			assert.Empty(t, occ.Error)
+			ap := load(*res.Occurrences[i].AppointmentID)
+			assert.Equal(t, res.Occurrences[i].StartTime.Add(2*time.Hour), ap.StartTime.UTC())
+		}
+	})
+
+	t.Run("CancelThis_UnknownAppointment_Fail", func(t *testing.T) {
+		f, _, svc, _ := setup(t)
+		_, err := svc.CancelOccurrences(ctx, f.TenantID, 999, barberBookingPort.ScopeThisOccurrence, &actor, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
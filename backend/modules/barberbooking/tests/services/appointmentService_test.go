@@ -0,0 +1,520 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	"myapp/jobs"
+	coreModels "myapp/modules/core/models"
+	notificationModels "myapp/modules/notification/models"
+	webhookModels "myapp/modules/webhook/models"
+)
+
+func setupTestAppointmentDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(
+		&coreModels.Branch{},
+		&barberBookingModels.Service{},
+		&barberBookingModels.Barber{},
+		&barberBookingModels.Customer{},
+		&barberBookingModels.Appointment{},
+		&barberBookingModels.AppointmentStatusLog{},
+		&barberBookingModels.BarberWorkload{},
+		&barberBookingModels.BranchBookingSetting{},
+		&barberBookingModels.AppointmentItem{},
+		&barberBookingModels.AppointmentSeries{},
+		&barberBookingModels.AppointmentLock{},
+		&barberBookingModels.WaitlistEntry{},
+		&barberBookingModels.WorkingHour{},
+		&barberBookingModels.WorkingDayOverride{},
+		&barberBookingModels.BarberWorkingHour{},
+		&barberBookingModels.BarberWorkingDayOverride{},
+		&barberBookingModels.Unavailability{},
+		&barberBookingModels.ReminderSetting{},
+		&notificationModels.NotificationChannelConfig{},
+		&notificationModels.Notification{},
+		&webhookModels.WebhookEndpoint{},
+		&webhookModels.WebhookDelivery{},
+		&jobs.Job{},
+	))
+	return db
+}
+
+type appointmentFixture struct {
+	TenantID uint
+	BranchID uint
+	Service  barberBookingModels.Service
+	Barbers  []barberBookingModels.Barber
+	Customer barberBookingModels.Customer
+}
+
+// seedAppointmentFixture สร้างสาขา, service 30 นาที, ช่าง n คน และลูกค้า 1 คน
+func seedAppointmentFixture(t *testing.T, db *gorm.DB, numBarbers int) appointmentFixture {
+	f := appointmentFixture{TenantID: 1}
+
+	branch := coreModels.Branch{TenantID: f.TenantID, Name: "Branch 1"}
+	assert.NoError(t, db.Create(&branch).Error)
+	f.BranchID = branch.ID
+
+	// สาขาเปิดทั้งวันทุกวัน เทสต์ที่ตรวจเวลาทำการจะแก้ค่าเอง
+	for weekday := 0; weekday < 7; weekday++ {
+		assert.NoError(t, db.Create(&barberBookingModels.WorkingHour{
+			TenantID: f.TenantID, BranchID: f.BranchID, Weekday: weekday,
+			StartTime: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2000, 1, 1, 23, 59, 59, 0, time.UTC),
+		}).Error)
+	}
+
+	f.Service = barberBookingModels.Service{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Cut", Duration: 30, Price: 200}
+	assert.NoError(t, db.Create(&f.Service).Error)
+
+	for i := 0; i < numBarbers; i++ {
+		b := barberBookingModels.Barber{TenantID: f.TenantID, BranchID: f.BranchID, UserID: uint(100 + i)}
+		assert.NoError(t, db.Create(&b).Error)
+		f.Barbers = append(f.Barbers, b)
+	}
+
+	f.Customer = barberBookingModels.Customer{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Alice", Phone: "0800000000"}
+	assert.NoError(t, db.Create(&f.Customer).Error)
+	return f
+}
+
+func (f appointmentFixture) newAppointment(barberID uint, start time.Time) *barberBookingModels.Appointment {
+	return &barberBookingModels.Appointment{
+		TenantID:   f.TenantID,
+		BranchID:   f.BranchID,
+		ServiceID:  f.Service.ID,
+		BarberID:   barberID,
+		CustomerID: f.Customer.ID,
+		StartTime:  start,
+	}
+}
+
+func TestAppointmentService_AutoAssignBarber(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	t.Run("LeastLoaded_PicksBarberWithFewestAppointments", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 2)
+		svc := barberBookingServices.NewAppointmentService(db, barberBookingServices.NewAppointmentStatusLogService(db))
+
+		assert.NoError(t, db.Create(&barberBookingModels.BarberWorkload{BarberID: f.Barbers[0].ID, Date: start, TotalAppointments: 5}).Error)
+		assert.NoError(t, db.Create(&barberBookingModels.BarberWorkload{BarberID: f.Barbers[1].ID, Date: start, TotalAppointments: 1}).Error)
+
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(0, start))
+		assert.NoError(t, err)
+		assert.Equal(t, f.Barbers[1].ID, resp.BarberID)
+	})
+
+	t.Run("RoundRobin_RotatesAndSkipsBusyBarber", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 3)
+		svc := barberBookingServices.NewAppointmentService(db, barberBookingServices.NewAppointmentStatusLogService(db))
+		assert.NoError(t, db.Create(&barberBookingModels.BranchBookingSetting{
+			TenantID: f.TenantID, BranchID: f.BranchID, AssignmentStrategy: barberBookingModels.AssignRoundRobin,
+		}).Error)
+
+		first, err := svc.CreateAppointment(ctx, f.newAppointment(0, start))
+		assert.NoError(t, err)
+		assert.Equal(t, f.Barbers[0].ID, first.BarberID)
+
+		// ช่างคนที่ 2 ติดนัดอื่นอยู่แล้ว → ข้ามไปคนที่ 3
+		_, err = svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[1].ID, start.Add(time.Hour)))
+		assert.NoError(t, err)
+
+		second, err := svc.CreateAppointment(ctx, f.newAppointment(0, start.Add(time.Hour)))
+		assert.NoError(t, err)
+		assert.Equal(t, f.Barbers[2].ID, second.BarberID)
+	})
+
+	t.Run("HighestRated_PicksBestReviewedBarber", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 2)
+		svc := barberBookingServices.NewAppointmentService(db, barberBookingServices.NewAppointmentStatusLogService(db))
+		assert.NoError(t, db.Create(&barberBookingModels.BranchBookingSetting{
+			TenantID: f.TenantID, BranchID: f.BranchID, AssignmentStrategy: barberBookingModels.AssignHighestRated,
+		}).Error)
+
+		// AppointmentReview ใช้ default:now() ซึ่ง sqlite ไม่รองรับ จึงสร้างตารางเอง
+		assert.NoError(t, db.Exec(`CREATE TABLE appointment_reviews (
+			id integer PRIMARY KEY AUTOINCREMENT, appointment_id integer NOT NULL, customer_id integer,
+			rating integer NOT NULL, comment text, created_at datetime, updated_at datetime, deleted_at datetime)`).Error)
+
+		past := f.newAppointment(f.Barbers[1].ID, start.AddDate(0, 0, -7))
+		past.EndTime = past.StartTime.Add(30 * time.Minute)
+		past.Status = barberBookingModels.StatusComplete
+		assert.NoError(t, db.Create(past).Error)
+		assert.NoError(t, db.Create(&barberBookingModels.AppointmentReview{AppointmentID: past.ID, Rating: 5}).Error)
+
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(0, start))
+		assert.NoError(t, err)
+		assert.Equal(t, f.Barbers[1].ID, resp.BarberID)
+	})
+
+	t.Run("NoBarberAvailable_Fail", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		svc := barberBookingServices.NewAppointmentService(db, barberBookingServices.NewAppointmentStatusLogService(db))
+
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		assert.NoError(t, err)
+
+		_, err = svc.CreateAppointment(ctx, f.newAppointment(0, start))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no barber is available")
+	})
+}
+
+func TestAppointmentService_MultiService(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	svc := barberBookingServices.NewAppointmentService(db, barberBookingServices.NewAppointmentStatusLogService(db))
+
+	beard := barberBookingModels.Service{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Beard", Duration: 20, Price: 100}
+	assert.NoError(t, db.Create(&beard).Error)
+
+	withItems := func(barberID uint, start time.Time, serviceIDs ...uint) *barberBookingModels.Appointment {
+		ap := f.newAppointment(barberID, start)
+		ap.ServiceID = 0
+		for _, id := range serviceIDs {
+			ap.Items = append(ap.Items, barberBookingModels.AppointmentItem{ServiceID: id})
+		}
+		return ap
+	}
+
+	t.Run("EndTimeIsSumOfItems", func(t *testing.T) {
+		resp, err := svc.CreateAppointment(ctx, withItems(f.Barbers[0].ID, start, beard.ID, f.Service.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(50*time.Minute), resp.EndTime)
+		assert.Equal(t, beard.ID, resp.ServiceID)
+		if assert.Len(t, resp.Items, 2) {
+			assert.Equal(t, beard.ID, resp.Items[0].ServiceID)
+			assert.Equal(t, f.Service.ID, resp.Items[1].ServiceID)
+			assert.Equal(t, 1, resp.Items[1].Position)
+		}
+	})
+
+	t.Run("OverlapWithSecondItem_Fail", func(t *testing.T) {
+		// 10:40 ชนกับบริการที่ 2 (10:20–10:50) ของนัดก่อนหน้า
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(40*time.Minute)))
+		assert.Error(t, err)
+	})
+
+	t.Run("UnknownService_Fail", func(t *testing.T) {
+		_, err := svc.CreateAppointment(ctx, withItems(f.Barbers[0].ID, start.Add(2*time.Hour), f.Service.ID, 999))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "service not found")
+	})
+}
+
+func TestAppointmentService_ServiceBuffers(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	assert.NoError(t, db.Model(&f.Service).Updates(map[string]interface{}{"buffer_before": 5, "buffer_after": 15}).Error)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+
+	first, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+	assert.NoError(t, err)
+	// เวลาที่ลูกค้าเห็นไม่รวม buffer
+	assert.Equal(t, start, first.StartTime)
+	assert.Equal(t, start.Add(30*time.Minute), first.EndTime)
+
+	t.Run("WithinCleanupBuffer_Fail", func(t *testing.T) {
+		// 10:30 + buffer_before 5 นาที ชนกับช่วงทำความสะอาด 10:30–10:45
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(45*time.Minute)))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not available")
+	})
+
+	t.Run("AfterBothBuffers_Success", func(t *testing.T) {
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(50*time.Minute)))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Reschedule_KeepsBuffers", func(t *testing.T) {
+		actor := uint(1)
+		// เลื่อนนัดแรกเป็น 10:20–10:50 ช่วงทำความสะอาดจะทับกับนัด 10:50
+		err := svc.RescheduleAppointment(ctx, f.TenantID, first.ID, start.Add(20*time.Minute), &actor, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "conflicts")
+	})
+
+	t.Run("Reschedule_OtherTenant_NotFound", func(t *testing.T) {
+		actor := uint(1)
+		err := svc.RescheduleAppointment(ctx, f.TenantID+1, first.ID, start.Add(2*time.Hour), &actor, nil)
+		assert.ErrorContains(t, err, "not found")
+	})
+}
+
+func TestAppointmentService_GetAvailableBarbers(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 3)
+	assert.NoError(t, db.Model(&f.Service).Updates(map[string]interface{}{"buffer_after": 15}).Error)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+	staff := barberBookingPort.StatusTransitionInput{ActorRole: string(coreModels.RoleNameStaff)}
+
+	// ช่าง 0: ช่วงทำความสะอาดหลังนัด (10:00–10:45) ทับช่วงที่ถาม
+	_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+	assert.NoError(t, err)
+	// ช่าง 1: กำลังให้บริการอยู่ก็ยังไม่ว่าง
+	inService, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[1].ID, start.Add(20*time.Minute)))
+	assert.NoError(t, err)
+	_, err = svc.TransitionStatus(ctx, f.TenantID, inService.ID, barberBookingModels.StatusInService, staff)
+	assert.NoError(t, err)
+
+	barbers, err := svc.GetAvailableBarbers(ctx, f.TenantID, f.BranchID, start.Add(35*time.Minute), start.Add(65*time.Minute))
+	assert.NoError(t, err)
+	if assert.Len(t, barbers, 1) {
+		assert.Equal(t, f.Barbers[2].ID, barbers[0].ID)
+	}
+
+	// ช่าง 2: มี lock ที่ยังไม่หมดอายุทับช่วงเวลา
+	lock := barberBookingModels.AppointmentLock{
+		TenantID: f.TenantID, BranchID: f.BranchID, BarberID: f.Barbers[2].ID, CustomerID: f.Customer.ID,
+		StartTime: start.Add(40 * time.Minute), EndTime: start.Add(70 * time.Minute),
+		ExpiresAt: time.Now().Add(5 * time.Minute), IsActive: true,
+	}
+	assert.NoError(t, db.Create(&lock).Error)
+	barbers, err = svc.GetAvailableBarbers(ctx, f.TenantID, f.BranchID, start.Add(35*time.Minute), start.Add(65*time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, barbers)
+}
+
+func TestAppointmentService_StatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+
+	staff := barberBookingPort.StatusTransitionInput{ActorRole: string(coreModels.RoleNameStaff)}
+	manager := barberBookingPort.StatusTransitionInput{ActorRole: string(coreModels.RoleNameBranchAdmin)}
+
+	logged := func(apptID uint, newStatus barberBookingModels.AppointmentStatus) bool {
+		var n int64
+		assert.NoError(t, db.Model(&barberBookingModels.AppointmentStatusLog{}).
+			Where("appointment_id = ? AND new_status = ?", apptID, newStatus).
+			Count(&n).Error)
+		return n == 1
+	}
+
+	t.Run("HappyPath_ConfirmStartComplete", func(t *testing.T) {
+		pending := f.newAppointment(f.Barbers[0].ID, start)
+		pending.Status = barberBookingModels.StatusPending
+		appt, err := svc.CreateAppointment(ctx, pending)
+		assert.NoError(t, err)
+
+		for _, to := range []barberBookingModels.AppointmentStatus{
+			barberBookingModels.StatusConfirmed,
+			barberBookingModels.StatusInService,
+			barberBookingModels.StatusComplete,
+		} {
+			out, err := svc.TransitionStatus(ctx, f.TenantID, appt.ID, to, staff)
+			assert.NoError(t, err)
+			assert.Equal(t, to, out.Status)
+			assert.True(t, logged(appt.ID, to), "transition to %s must be logged", to)
+		}
+
+		// COMPLETED เป็นสถานะสุดท้าย
+		actor := uint(1)
+		assert.ErrorContains(t, svc.CancelAppointment(ctx, appt.ID, &actor, nil), "cannot be cancelled")
+		assert.ErrorContains(t, svc.RescheduleAppointment(ctx, f.TenantID, appt.ID, start.Add(2*time.Hour), &actor, nil), "cannot reschedule")
+	})
+
+	t.Run("CancelTwice_LoggedOnce", func(t *testing.T) {
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(4*time.Hour)))
+		assert.NoError(t, err)
+
+		actor := uint(1)
+		assert.NoError(t, svc.CancelAppointment(ctx, appt.ID, &actor, nil))
+		assert.ErrorContains(t, svc.CancelAppointment(ctx, appt.ID, &actor, nil), "cannot be cancelled")
+		assert.True(t, logged(appt.ID, barberBookingModels.StatusCancelled))
+	})
+
+	t.Run("SkippingStates_Fail", func(t *testing.T) {
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(time.Hour)))
+		assert.NoError(t, err)
+
+		_, err = svc.TransitionStatus(ctx, f.TenantID, appt.ID, barberBookingModels.StatusComplete, staff)
+		assert.ErrorContains(t, err, "cannot change appointment status from CONFIRMED to COMPLETED")
+		assert.False(t, logged(appt.ID, barberBookingModels.StatusComplete))
+	})
+
+	t.Run("NoShow_RequiresManagerRole", func(t *testing.T) {
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(2*time.Hour)))
+		assert.NoError(t, err)
+
+		_, err = svc.TransitionStatus(ctx, f.TenantID, appt.ID, barberBookingModels.StatusNoShow, staff)
+		assert.ErrorContains(t, err, "permission denied")
+
+		out, err := svc.TransitionStatus(ctx, f.TenantID, appt.ID, barberBookingModels.StatusNoShow, manager)
+		assert.NoError(t, err)
+		assert.Equal(t, barberBookingModels.StatusNoShow, out.Status)
+		assert.True(t, logged(appt.ID, barberBookingModels.StatusNoShow))
+	})
+
+	t.Run("InService_KeepsSlotBlocked", func(t *testing.T) {
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(4*time.Hour)))
+		assert.NoError(t, err)
+		_, err = svc.TransitionStatus(ctx, f.TenantID, appt.ID, barberBookingModels.StatusInService, staff)
+		assert.NoError(t, err)
+
+		_, err = svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(4*time.Hour+15*time.Minute)))
+		assert.ErrorContains(t, err, "not available")
+	})
+
+	t.Run("CancelViaTransition_Rejected", func(t *testing.T) {
+		_, err := svc.TransitionStatus(ctx, f.TenantID, 1, barberBookingModels.StatusCancelled, manager)
+		assert.ErrorContains(t, err, "invalid transition target")
+	})
+
+	t.Run("UpdateCannotWriteStatus", func(t *testing.T) {
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(3*time.Hour)))
+		assert.NoError(t, err)
+
+		_, err = svc.UpdateAppointment(ctx, appt.ID, f.TenantID, &barberBookingModels.Appointment{
+			Status: barberBookingModels.StatusComplete,
+		})
+		assert.ErrorContains(t, err, "cannot change status via update")
+	})
+
+	t.Run("UpdateChecksAvailability", func(t *testing.T) {
+		first, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(5*time.Hour)))
+		assert.NoError(t, err)
+		second, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(6*time.Hour)))
+		assert.NoError(t, err)
+
+		// ย้ายเวลาไปทับนัดอื่นไม่ได้
+		_, err = svc.UpdateAppointment(ctx, second.ID, f.TenantID, &barberBookingModels.Appointment{
+			StartTime: first.StartTime.Add(10 * time.Minute),
+		})
+		assert.ErrorContains(t, err, "not available")
+
+		// ขยับเวลาทับช่วงเดิมของตัวเองได้
+		updated, err := svc.UpdateAppointment(ctx, second.ID, f.TenantID, &barberBookingModels.Appointment{
+			StartTime: second.StartTime.Add(10 * time.Minute),
+		})
+		assert.NoError(t, err)
+		assert.True(t, updated.StartTime.Equal(second.StartTime.Add(10*time.Minute)))
+
+		// นัดที่เริ่มให้บริการแล้วเปลี่ยนเวลาไม่ได้
+		_, err = svc.TransitionStatus(ctx, f.TenantID, first.ID, barberBookingModels.StatusInService, staff)
+		assert.NoError(t, err)
+		_, err = svc.UpdateAppointment(ctx, first.ID, f.TenantID, &barberBookingModels.Appointment{
+			StartTime: first.StartTime.Add(-time.Hour),
+		})
+		assert.ErrorContains(t, err, "cannot change the time")
+	})
+}
+
+func TestAppointmentService_BookWithLock(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 2)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+
+	other := barberBookingModels.Customer{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Bob", Phone: "0811111111"}
+	assert.NoError(t, db.Create(&other).Error)
+
+	newLock := func(customerID uint, expiresAt time.Time) barberBookingModels.AppointmentLock {
+		lock := barberBookingModels.AppointmentLock{
+			TenantID: f.TenantID, BranchID: f.BranchID, BarberID: f.Barbers[0].ID, CustomerID: customerID,
+			StartTime: start, EndTime: start.Add(30 * time.Minute), ExpiresAt: expiresAt, IsActive: true,
+		}
+		assert.NoError(t, db.Create(&lock).Error)
+		return lock
+	}
+	lock := newLock(other.ID, time.Now().Add(5*time.Minute))
+
+	t.Run("OtherCustomersLock_BlocksBooking", func(t *testing.T) {
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "locked by another customer")
+
+		// ไม่ระบุช่าง → ข้ามช่างที่ถูก lock ไว้
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(0, start))
+		assert.NoError(t, err)
+		assert.Equal(t, f.Barbers[1].ID, resp.BarberID)
+	})
+
+	t.Run("OtherCustomersLock_BlocksReschedule", func(t *testing.T) {
+		actor := uint(1)
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(3*time.Hour)))
+		assert.NoError(t, err)
+		err = svc.RescheduleAppointment(ctx, f.TenantID, appt.ID, start, &actor, nil)
+		assert.ErrorContains(t, err, "locked by another customer")
+	})
+
+	t.Run("ForeignLock_Fail", func(t *testing.T) {
+		appt := f.newAppointment(f.Barbers[0].ID, start)
+		appt.LockID = &lock.ID
+		_, err := svc.CreateAppointment(ctx, appt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "belongs to another customer")
+	})
+
+	t.Run("MismatchedStart_Fail", func(t *testing.T) {
+		appt := f.newAppointment(f.Barbers[0].ID, start.Add(time.Hour))
+		appt.CustomerID = other.ID
+		appt.LockID = &lock.ID
+		_, err := svc.CreateAppointment(ctx, appt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+
+	t.Run("OwnerBooksWithLock_ConsumesLock", func(t *testing.T) {
+		appt := f.newAppointment(0, start)
+		appt.CustomerID = other.ID
+		appt.LockID = &lock.ID
+		resp, err := svc.CreateAppointment(ctx, appt)
+		assert.NoError(t, err)
+		// ไม่ระบุช่าง → ใช้ช่างของ lock
+		assert.Equal(t, f.Barbers[0].ID, resp.BarberID)
+
+		var consumed barberBookingModels.AppointmentLock
+		assert.NoError(t, db.First(&consumed, lock.ID).Error)
+		assert.False(t, consumed.IsActive)
+		if assert.NotNil(t, consumed.AppointmentID) {
+			assert.Equal(t, resp.ID, *consumed.AppointmentID)
+		}
+
+		// ใช้ lock ซ้ำไม่ได้
+		again := f.newAppointment(f.Barbers[0].ID, start)
+		again.CustomerID = other.ID
+		again.LockID = &lock.ID
+		_, err = svc.CreateAppointment(ctx, again)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no longer active")
+	})
+
+	t.Run("ExpiredLock_Fail", func(t *testing.T) {
+		expired := newLock(f.Customer.ID, time.Now().Add(-time.Minute))
+		appt := f.newAppointment(f.Barbers[0].ID, start.Add(2*time.Hour))
+		appt.LockID = &expired.ID
+		_, err := svc.CreateAppointment(ctx, appt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "has expired")
+	})
+}
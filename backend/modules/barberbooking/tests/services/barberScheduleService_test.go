@@ -0,0 +1,145 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	helperFunc "myapp/modules/barberbooking"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+)
+
+func TestBarberScheduleService_NarrowsAvailability(t *testing.T) {
+	ctx := context.Background()
+	loc := time.Now().Location()
+	// 2030-01-08 เป็นวันอังคาร
+	tue := func(hour, min int) time.Time { return time.Date(2030, 1, 8, hour, min, 0, 0, loc) }
+	wed := func(hour, min int) time.Time { return time.Date(2030, 1, 9, hour, min, 0, 0, loc) }
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 2)
+	partTime, fullTime := f.Barbers[0].ID, f.Barbers[1].ID
+
+	schedules := barberBookingServices.NewBarberScheduleService(db)
+	appointments := barberBookingServices.NewAppointmentService(db, barberBookingServices.NewAppointmentStatusLogService(db))
+
+	available := func(barberID uint, start time.Time) bool {
+		ok, err := appointments.CheckBarberAvailability(ctx, f.TenantID, barberID, start, start.Add(30*time.Minute))
+		assert.NoError(t, err)
+		return ok
+	}
+
+	t.Run("InvalidWeeklyHours_Fail", func(t *testing.T) {
+		_, err := schedules.ReplaceWeeklyHours(ctx, f.TenantID, partTime, []barberBookingPort.BarberWorkingHourInput{
+			{Weekday: 7, StartTime: "13:00", EndTime: "18:00"},
+		})
+		assert.ErrorContains(t, err, "invalid weekday")
+
+		_, err = schedules.ReplaceWeeklyHours(ctx, f.TenantID, partTime, []barberBookingPort.BarberWorkingHourInput{
+			{Weekday: 2, StartTime: "18:00", EndTime: "13:00"},
+		})
+		assert.ErrorContains(t, err, "invalid shift")
+
+		_, err = schedules.ReplaceWeeklyHours(ctx, f.TenantID, 999, []barberBookingPort.BarberWorkingHourInput{
+			{Weekday: 2, StartTime: "13:00", EndTime: "18:00"},
+		})
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("WeeklyHours_OnlyTuesdayAfternoon", func(t *testing.T) {
+		hours, err := schedules.ReplaceWeeklyHours(ctx, f.TenantID, partTime, []barberBookingPort.BarberWorkingHourInput{
+			{Weekday: int(time.Tuesday), StartTime: "13:00", EndTime: "18:00"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, hours, 1)
+
+		assert.False(t, available(partTime, tue(10, 0)))
+		assert.True(t, available(partTime, tue(13, 0)))
+		assert.False(t, available(partTime, tue(17, 45)), "must end before the shift ends")
+		assert.False(t, available(partTime, wed(14, 0)))
+		assert.True(t, available(fullTime, wed(14, 0)), "barber without schedule follows branch hours")
+
+		barbers, err := appointments.GetAvailableBarbers(ctx, f.TenantID, f.BranchID, tue(10, 0), tue(10, 30))
+		assert.NoError(t, err)
+		if assert.Len(t, barbers, 1) {
+			assert.Equal(t, fullTime, barbers[0].ID)
+		}
+	})
+
+	t.Run("AutoAssign_SkipsOffShiftBarber", func(t *testing.T) {
+		resp, err := appointments.CreateAppointment(ctx, f.newAppointment(0, wed(10, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, fullTime, resp.BarberID)
+
+		_, err = appointments.CreateAppointment(ctx, f.newAppointment(partTime, wed(11, 0)))
+		assert.Error(t, err)
+	})
+
+	t.Run("Override_TakesPrecedence", func(t *testing.T) {
+		off, err := schedules.UpsertOverride(ctx, f.TenantID, partTime, barberBookingPort.BarberWorkingDayOverrideInput{
+			WorkDate: "2030-01-08", IsOff: true,
+		})
+		assert.NoError(t, err)
+		assert.False(t, available(partTime, tue(13, 0)))
+
+		// วันเดียวกันถูกแก้ไข ไม่สร้างซ้ำ
+		morning, err := schedules.UpsertOverride(ctx, f.TenantID, partTime, barberBookingPort.BarberWorkingDayOverrideInput{
+			WorkDate: "2030-01-08", StartTime: "09:00", EndTime: "12:00",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, off.ID, morning.ID)
+		assert.True(t, available(partTime, tue(10, 0)))
+		assert.False(t, available(partTime, tue(13, 0)))
+
+		assert.NoError(t, schedules.DeleteOverride(ctx, f.TenantID, partTime, morning.ID))
+		assert.ErrorContains(t, schedules.DeleteOverride(ctx, f.TenantID, partTime, morning.ID), "not found")
+		assert.True(t, available(partTime, tue(13, 0)))
+	})
+
+	t.Run("ClearWeeklyHours_FollowsBranchAgain", func(t *testing.T) {
+		assert.NoError(t, schedules.ClearWeeklyHours(ctx, f.TenantID, partTime))
+		assert.True(t, available(partTime, wed(14, 0)))
+
+		schedule, err := schedules.GetSchedule(ctx, f.TenantID, partTime)
+		assert.NoError(t, err)
+		assert.Empty(t, schedule.WeeklyHours)
+	})
+
+	t.Run("BranchHours_LimitBarberWithoutSchedule", func(t *testing.T) {
+		thu := func(hour, min int) time.Time { return time.Date(2030, 1, 10, hour, min, 0, 0, loc) }
+		assert.NoError(t, db.Model(&barberBookingModels.WorkingHour{}).
+			Where("branch_id = ? AND weekday = ?", f.BranchID, int(time.Thursday)).
+			Updates(map[string]interface{}{
+				"start_time": time.Date(2000, 1, 1, 9, 0, 0, 0, time.UTC),
+				"end_time":   time.Date(2000, 1, 1, 18, 0, 0, 0, time.UTC),
+			}).Error)
+
+		assert.False(t, available(fullTime, thu(8, 30)))
+		assert.True(t, available(fullTime, thu(9, 0)))
+		assert.False(t, available(fullTime, thu(17, 45)), "must end before the branch closes")
+
+		locks := barberBookingServices.NewAppointmentLockService(db)
+		_, err := locks.CreateAppointmentLock(ctx, barberBookingPort.AppointmentLockInput{
+			TenantID: f.TenantID, BranchID: f.BranchID, BarberID: fullTime, CustomerID: f.Customer.ID,
+			StartTime: thu(8, 0), EndTime: thu(8, 30),
+		})
+		assert.ErrorContains(t, err, "not working")
+
+		// override ปิดร้านทั้งวัน
+		assert.NoError(t, db.Create(&barberBookingModels.WorkingDayOverride{
+			BranchID: f.BranchID, WorkDate: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC),
+			StartTime: helperFunc.TimeOnly{Time: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)},
+			EndTime:   helperFunc.TimeOnly{Time: time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC)},
+			IsClosed:  true,
+		}).Error)
+		assert.False(t, available(fullTime, thu(10, 0)))
+		barbers, err := appointments.GetAvailableBarbers(ctx, f.TenantID, f.BranchID, thu(10, 0), thu(10, 30))
+		assert.NoError(t, err)
+		assert.Empty(t, barbers)
+	})
+}
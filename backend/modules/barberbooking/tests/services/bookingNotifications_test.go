@@ -0,0 +1,66 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/jobs"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	notificationModels "myapp/modules/notification/models"
+)
+
+func TestAppointmentService_BookingNotifications(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 3, 0, 0, 0, time.UTC) // 10:00 เวลาไทย
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+
+	t.Run("No channel configured: booking works without notifications", func(t *testing.T) {
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(-24*time.Hour)))
+		require.NoError(t, err)
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+
+		var count int64
+		db.Model(&notificationModels.Notification{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	require.NoError(t, db.Create(&notificationModels.NotificationChannelConfig{
+		TenantID: f.TenantID, Channel: notificationModels.ChannelSMS, Enabled: true, Settings: `{"endpoint":"https://sms.example.com"}`,
+	}).Error)
+
+	t.Run("Created, rescheduled and cancelled are queued for the customer", func(t *testing.T) {
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		require.NoError(t, err)
+		require.NoError(t, svc.RescheduleAppointment(ctx, f.TenantID, resp.ID, start.Add(2*time.Hour), nil, nil))
+		require.NoError(t, svc.CancelAppointment(ctx, resp.ID, nil, nil))
+
+		var list []notificationModels.Notification
+		require.NoError(t, db.Order("id").Find(&list).Error)
+		require.Len(t, list, 3)
+
+		events := []string{list[0].Event, list[1].Event, list[2].Event}
+		assert.Equal(t, []string{
+			barberBookingServices.NotifyAppointmentCreated,
+			barberBookingServices.NotifyAppointmentRescheduled,
+			barberBookingServices.NotifyAppointmentCancelled,
+		}, events)
+		for _, n := range list {
+			assert.Equal(t, f.Customer.Phone, n.Recipient)
+			assert.Equal(t, notificationModels.DeliveryPending, n.Status)
+		}
+		assert.True(t, strings.Contains(list[0].Body, "07/01/2030 เวลา 10:00 น."), list[0].Body)
+		assert.Contains(t, list[1].Body, "เวลา 10:00 น. เป็นวันที่ 07/01/2030 เวลา 12:00 น.")
+
+		var jobCount int64
+		db.Model(&jobs.Job{}).Count(&jobCount)
+		assert.Equal(t, int64(3), jobCount)
+	})
+}
@@ -0,0 +1,96 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	coreModels "myapp/modules/core/models"
+	webhookModels "myapp/modules/webhook/models"
+)
+
+func TestAppointmentService_BookingWebhooks(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 3, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+
+	require.NoError(t, db.Create(&webhookModels.WebhookEndpoint{
+		TenantID:        f.TenantID,
+		URL:             "https://crm.example.com/hooks",
+		Events:          webhookModels.SupportedEvents,
+		SecretEncrypted: "v1:unused",
+		Enabled:         true,
+	}).Error)
+
+	deliveries := func() []webhookModels.WebhookDelivery {
+		var list []webhookModels.WebhookDelivery
+		require.NoError(t, db.Order("id").Find(&list).Error)
+		return list
+	}
+
+	t.Run("Guest booking emits customer.created then appointment.created", func(t *testing.T) {
+		input := f.newAppointment(f.Barbers[0].ID, start)
+		input.CustomerID = 0
+		input.Customer = &barberBookingModels.Customer{Name: "Guest", Phone: "0899999999"}
+		resp, err := svc.CreateAppointment(ctx, input)
+		require.NoError(t, err)
+
+		list := deliveries()
+		require.Len(t, list, 2)
+		assert.Equal(t, webhookModels.EventCustomerCreated, list[0].Event)
+		assert.Equal(t, webhookModels.EventAppointmentCreated, list[1].Event)
+
+		var body struct {
+			Event string `json:"event"`
+			Data  struct {
+				ID        uint      `json:"id"`
+				Status    string    `json:"status"`
+				StartTime time.Time `json:"start_time"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(list[1].Payload), &body))
+		assert.Equal(t, resp.ID, body.Data.ID)
+		assert.True(t, start.Equal(body.Data.StartTime))
+	})
+
+	t.Run("Reschedule, complete and cancel are published", func(t *testing.T) {
+		before := len(deliveries())
+
+		resp, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(24*time.Hour)))
+		require.NoError(t, err)
+		require.NoError(t, svc.RescheduleAppointment(ctx, f.TenantID, resp.ID, start.Add(26*time.Hour), nil, nil))
+		input := barberBookingPort.StatusTransitionInput{ActorRole: string(coreModels.RoleNameTenant)}
+		for _, to := range []barberBookingModels.AppointmentStatus{
+			barberBookingModels.StatusInService, barberBookingModels.StatusComplete,
+		} {
+			_, err := svc.TransitionStatus(ctx, f.TenantID, resp.ID, to, input)
+			require.NoError(t, err)
+		}
+
+		other, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start.Add(48*time.Hour)))
+		require.NoError(t, err)
+		require.NoError(t, svc.CancelAppointment(ctx, other.ID, nil, nil))
+
+		var events []string
+		for _, d := range deliveries()[before:] {
+			events = append(events, d.Event)
+		}
+		assert.Equal(t, []string{
+			webhookModels.EventAppointmentCreated,
+			webhookModels.EventAppointmentRescheduled,
+			webhookModels.EventAppointmentCompleted,
+			webhookModels.EventAppointmentCreated,
+			webhookModels.EventAppointmentCancelled,
+		}, events)
+	})
+}
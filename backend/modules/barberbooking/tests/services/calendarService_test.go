@@ -0,0 +1,153 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+)
+
+func setupTestCalendarDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(
+		&barberBookingModels.Service{},
+		&barberBookingModels.Barber{},
+		&barberBookingModels.WorkingHour{},
+		&barberBookingModels.WorkingDayOverride{},
+		&barberBookingModels.Unavailability{},
+		&barberBookingModels.Appointment{},
+		&barberBookingModels.AppointmentLock{},
+		&barberBookingModels.BarberWorkingHour{},
+		&barberBookingModels.BarberWorkingDayOverride{},
+	))
+	return db
+}
+
+func TestCalendarService_GetAvailableSlots(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestCalendarDB(t)
+	loc := time.Now().Location()
+
+	// วันจันทร์ในอนาคต เพื่อไม่ให้ slot ถูกปิดเพราะเป็นเวลาที่ผ่านไปแล้ว
+	day := time.Date(2030, 1, 7, 0, 0, 0, 0, loc)
+	at := func(hour, min int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, min, 0, 0, loc)
+	}
+
+	tenantID, branchID := uint(1), uint(1)
+	service := barberBookingModels.Service{TenantID: tenantID, BranchID: branchID, Name: "Cut", Duration: 60, Price: 200}
+	assert.NoError(t, db.Create(&service).Error)
+
+	barbers := []barberBookingModels.Barber{
+		{TenantID: tenantID, BranchID: branchID, UserID: 101},
+		{TenantID: tenantID, BranchID: branchID, UserID: 102},
+		{TenantID: tenantID, BranchID: branchID, UserID: 103},
+	}
+	assert.NoError(t, db.Create(&barbers).Error)
+
+	assert.NoError(t, db.Create(&barberBookingModels.WorkingHour{
+		TenantID:  tenantID,
+		BranchID:  branchID,
+		Weekday:   int(time.Monday),
+		StartTime: time.Date(0, 1, 1, 9, 0, 0, 0, loc),
+		EndTime:   time.Date(0, 1, 1, 11, 0, 0, 0, loc),
+	}).Error)
+
+	// ช่างคนที่ 1 มีนัด 09:30–10:30
+	assert.NoError(t, db.Create(&barberBookingModels.Appointment{
+		TenantID: tenantID, BranchID: branchID, ServiceID: service.ID, BarberID: barbers[0].ID,
+		CustomerID: 1, StartTime: at(9, 30), EndTime: at(10, 30), Status: barberBookingModels.StatusConfirmed,
+	}).Error)
+
+	// ช่างคนที่ 2 ถูก lock ไว้ 10:00–11:00
+	assert.NoError(t, db.Create(&barberBookingModels.AppointmentLock{
+		TenantID: tenantID, BranchID: branchID, BarberID: barbers[1].ID, CustomerID: 2,
+		StartTime: at(10, 0), EndTime: at(11, 0), ExpiresAt: time.Now().Add(5 * time.Minute), IsActive: true,
+	}).Error)
+
+	// ช่างคนที่ 3 ลาทั้งวัน
+	assert.NoError(t, db.Create(&barberBookingModels.Unavailability{
+		BarberID: &barbers[2].ID, Date: day,
+	}).Error)
+
+	svc := barberBookingServices.NewCalendarService(db)
+
+	t.Run("PerBarberSlots", func(t *testing.T) {
+		slots, err := svc.GetAvailableSlots(ctx, branchID, tenantID, service.ID, day, day)
+		assert.NoError(t, err)
+
+		status := map[uint]map[string]string{}
+		for _, s := range slots {
+			if status[s.BarberID] == nil {
+				status[s.BarberID] = map[string]string{}
+			}
+			status[s.BarberID][s.Start.Format("15:04")] = s.Status
+			assert.Equal(t, time.Hour, s.End.Sub(s.Start))
+		}
+
+		assert.Equal(t, map[string]string{"09:00": "closed", "09:30": "closed", "10:00": "closed"}, status[barbers[0].ID])
+		assert.Equal(t, map[string]string{"09:00": "open", "09:30": "closed", "10:00": "closed"}, status[barbers[1].ID])
+		assert.Empty(t, status[barbers[2].ID])
+	})
+
+	t.Run("BarberShift_NarrowsBranchHours", func(t *testing.T) {
+		schedules := barberBookingServices.NewBarberScheduleService(db)
+		_, err := schedules.ReplaceWeeklyHours(ctx, tenantID, barbers[1].ID, []barberBookingPort.BarberWorkingHourInput{
+			{Weekday: int(time.Monday), StartTime: "08:00", EndTime: "10:00"},
+		})
+		assert.NoError(t, err)
+		defer func() { assert.NoError(t, schedules.ClearWeeklyHours(ctx, tenantID, barbers[1].ID)) }()
+
+		slots, err := svc.GetAvailableSlots(ctx, branchID, tenantID, service.ID, day, day)
+		assert.NoError(t, err)
+
+		var starts []string
+		for _, s := range slots {
+			if s.BarberID == barbers[1].ID {
+				starts = append(starts, s.Start.Format("15:04"))
+			}
+		}
+		// เริ่มไม่ก่อนสาขาเปิด (09:00) และจบไม่เกินเวลาออกงานของช่าง (10:00)
+		assert.Equal(t, []string{"09:00"}, starts)
+	})
+
+	t.Run("BufferBefore_StaysInsideBranchHours", func(t *testing.T) {
+		buffered := barberBookingModels.Service{TenantID: tenantID, BranchID: branchID, Name: "Color", Duration: 60, BufferBefore: 15, Price: 500}
+		assert.NoError(t, db.Create(&buffered).Error)
+
+		slots, err := svc.GetAvailableSlots(ctx, branchID, tenantID, buffered.ID, day, day)
+		assert.NoError(t, err)
+		var starts []string
+		for _, s := range slots {
+			if s.BarberID == barbers[1].ID {
+				starts = append(starts, s.Start.Format("15:04"))
+			}
+		}
+		// block เริ่ม 09:00 พอดีกับเวลาเปิดสาขา ไม่ใช่ตัว slot
+		assert.Equal(t, []string{"09:15", "09:45"}, starts)
+	})
+
+	t.Run("ClosedOverride_NoSlots", func(t *testing.T) {
+		assert.NoError(t, db.Create(&barberBookingModels.WorkingDayOverride{
+			BranchID: branchID, WorkDate: day, IsClosed: true,
+		}).Error)
+
+		slots, err := svc.GetAvailableSlots(ctx, branchID, tenantID, service.ID, day, day)
+		assert.NoError(t, err)
+		assert.Empty(t, slots)
+	})
+
+	t.Run("UnknownService_Fail", func(t *testing.T) {
+		_, err := svc.GetAvailableSlots(ctx, branchID, tenantID, 999, day, day)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "service not found")
+	})
+}
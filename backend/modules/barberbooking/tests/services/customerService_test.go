@@ -10,14 +10,21 @@ import (
 	"gorm.io/gorm"
 
 	bookingModels "myapp/modules/barberbooking/models"
+	"myapp/jobs"
 	bookingServices "myapp/modules/barberbooking/services"
+	webhookModels "myapp/modules/webhook/models"
 )
 
 func setupTestCustomerDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	assert.NoError(t, err)
 
-	err = db.AutoMigrate(&bookingModels.Customer{})
+	err = db.AutoMigrate(
+		&bookingModels.Customer{},
+		&webhookModels.WebhookEndpoint{},
+		&webhookModels.WebhookDelivery{},
+		&jobs.Job{},
+	)
 	assert.NoError(t, err)
 
 	return db
@@ -0,0 +1,112 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+)
+
+func TestSlotEvents_PublishedAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	appointments := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+	locks := barberBookingServices.NewAppointmentLockService(db)
+
+	events, cancel := barberBookingServices.SlotEvents.Subscribe(f.TenantID, f.BranchID)
+	defer cancel()
+	other, cancelOther := barberBookingServices.SlotEvents.Subscribe(f.TenantID, f.BranchID+1)
+	defer cancelOther()
+
+	next := func() barberBookingPort.SlotEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("expected slot event")
+			return barberBookingPort.SlotEvent{}
+		}
+	}
+
+	lock, err := locks.CreateAppointmentLock(ctx, barberBookingPort.AppointmentLockInput{
+		TenantID: f.TenantID, BranchID: f.BranchID, BarberID: f.Barbers[0].ID, CustomerID: f.Customer.ID,
+		StartTime: start, EndTime: start.Add(30 * time.Minute),
+	})
+	assert.NoError(t, err)
+	e := next()
+	assert.Equal(t, barberBookingPort.SlotEventLockCreated, e.Type)
+	if assert.NotNil(t, e.LockID) {
+		assert.Equal(t, lock.ID, *e.LockID)
+	}
+	// stream สาธารณะเห็นแค่ช่างและช่วงเวลา
+	public := e.Public()
+	assert.Nil(t, public.LockID)
+	assert.Equal(t, e.BarberID, public.BarberID)
+	assert.True(t, public.StartTime.Equal(start))
+
+	assert.NoError(t, locks.ReleaseAppointmentLock(ctx, lock.ID))
+	assert.Equal(t, barberBookingPort.SlotEventLockReleased, next().Type)
+
+	created, err := appointments.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+	assert.NoError(t, err)
+	e = next()
+	assert.Equal(t, barberBookingPort.SlotEventAppointmentCreated, e.Type)
+	assert.Equal(t, f.Barbers[0].ID, e.BarberID)
+
+	// ไม่สำเร็จ → ไม่มี event
+	_, err = appointments.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+	assert.Error(t, err)
+
+	actor := uint(1)
+	assert.NoError(t, appointments.RescheduleAppointment(ctx, f.TenantID, created.ID, start.Add(time.Hour), &actor, nil))
+	e = next()
+	assert.Equal(t, barberBookingPort.SlotEventAppointmentRescheduled, e.Type)
+	assert.True(t, e.StartTime.Equal(start.Add(time.Hour)))
+	if assert.NotNil(t, e.PreviousStartTime) {
+		assert.True(t, e.PreviousStartTime.Equal(start))
+	}
+
+	_, err = appointments.UpdateAppointment(ctx, created.ID, f.TenantID, &barberBookingModels.Appointment{
+		StartTime: start.Add(2 * time.Hour),
+	})
+	assert.NoError(t, err)
+	e = next()
+	assert.Equal(t, barberBookingPort.SlotEventAppointmentRescheduled, e.Type)
+	if assert.NotNil(t, e.PreviousStartTime) {
+		assert.True(t, e.PreviousStartTime.Equal(start.Add(time.Hour)))
+	}
+
+	// แก้เฉพาะหมายเหตุ → slot ไม่เปลี่ยน ไม่มี event
+	_, err = appointments.UpdateAppointment(ctx, created.ID, f.TenantID, &barberBookingModels.Appointment{Notes: "walk-in"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, appointments.CancelAppointment(ctx, created.ID, &actor, nil))
+	assert.Equal(t, barberBookingPort.SlotEventAppointmentCancelled, next().Type)
+
+	assert.Empty(t, events)
+	assert.Empty(t, other, "events must stay within their branch")
+}
+
+func TestSlotEventStream_RequiresBranchInTenant(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	stream := barberBookingServices.NewSlotEventStreamService(db, barberBookingServices.NewSlotEventHub())
+
+	_, _, err := stream.SubscribeBranch(ctx, f.TenantID+1, f.BranchID)
+	assert.ErrorContains(t, err, "branch not found")
+
+	events, cancel, err := stream.SubscribeBranch(ctx, f.TenantID, f.BranchID)
+	assert.NoError(t, err)
+	cancel()
+	_, open := <-events
+	assert.False(t, open)
+}
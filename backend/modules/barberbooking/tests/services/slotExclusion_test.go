@@ -0,0 +1,181 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"myapp/jobs"
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	coreModels "myapp/modules/core/models"
+	notificationModels "myapp/modules/notification/models"
+	webhookModels "myapp/modules/webhook/models"
+)
+
+// setupTestSlotExclusionDB ต้องใช้ Postgres จริง (exclusion constraint ไม่มีใน sqlite)
+func setupTestSlotExclusionDB(t *testing.T) *gorm.DB {
+	_ = godotenv.Load("../../../../.env.test")
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("could not connect to test DB: %v", err)
+	}
+	if err := db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;").Error; err != nil {
+		t.Fatalf("reset schema failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&coreModels.Tenant{},
+		&coreModels.Branch{},
+		&barberBookingModels.Service{},
+		&barberBookingModels.Barber{},
+		&barberBookingModels.Customer{},
+		&barberBookingModels.Appointment{},
+		&barberBookingModels.AppointmentStatusLog{},
+		&barberBookingModels.BarberWorkload{},
+		&barberBookingModels.BranchBookingSetting{},
+		&barberBookingModels.AppointmentItem{},
+		&barberBookingModels.AppointmentLock{},
+		&barberBookingModels.WaitlistEntry{},
+		&barberBookingModels.BarberWorkingHour{},
+		&barberBookingModels.BarberWorkingDayOverride{},
+		&barberBookingModels.Unavailability{},
+		&barberBookingModels.ReminderSetting{},
+		&notificationModels.NotificationChannelConfig{},
+		&notificationModels.Notification{},
+		&webhookModels.WebhookEndpoint{},
+		&webhookModels.WebhookDelivery{},
+		&jobs.Job{},
+	); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	migration, err := os.ReadFile("../../../../migrations/120_booking_slot_exclusion.up.sql")
+	if err != nil {
+		t.Fatalf("read migration failed: %v", err)
+	}
+	if err := db.Exec(string(migration)).Error; err != nil {
+		t.Fatalf("apply exclusion constraints failed: %v", err)
+	}
+
+	assert.NoError(t, db.Create(&coreModels.Tenant{ID: 1, Name: "Tenant ทดสอบ"}).Error)
+	return db
+}
+
+func TestSlotExclusion_NoDoubleBookingUnderConcurrency(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	const workers = 10
+
+	db := setupTestSlotExclusionDB(t)
+	f := seedAppointmentFixture(t, db, 1)
+	barberID := f.Barbers[0].ID
+
+	customers := make([]barberBookingModels.Customer, workers)
+	for i := range customers {
+		customers[i] = barberBookingModels.Customer{
+			TenantID: f.TenantID, BranchID: f.BranchID,
+			Name: fmt.Sprintf("Customer %d", i), Phone: fmt.Sprintf("08000000%02d", i),
+		}
+		assert.NoError(t, db.Create(&customers[i]).Error)
+	}
+
+	// race: ทุกคนยิงพร้อมกัน ต้องสำเร็จได้คนเดียว
+	race := func(fn func(i int) error) (succeeded int) {
+		var (
+			wg    sync.WaitGroup
+			mu    sync.Mutex
+			ready = make(chan struct{})
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-ready
+				if err := fn(i); err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}(i)
+		}
+		close(ready)
+		wg.Wait()
+		return succeeded
+	}
+
+	t.Run("ConcurrentLocks_OneWins", func(t *testing.T) {
+		locks := barberBookingServices.NewAppointmentLockService(db)
+		succeeded := race(func(i int) error {
+			_, err := locks.CreateAppointmentLock(ctx, barberBookingPort.AppointmentLockInput{
+				TenantID: f.TenantID, BranchID: f.BranchID, BarberID: barberID, CustomerID: customers[i].ID,
+				StartTime: start, EndTime: start.Add(30 * time.Minute),
+			})
+			return err
+		})
+		assert.Equal(t, 1, succeeded)
+
+		var active int64
+		assert.NoError(t, db.Model(&barberBookingModels.AppointmentLock{}).
+			Where("barber_id = ? AND is_active", barberID).Count(&active).Error)
+		assert.EqualValues(t, 1, active)
+	})
+
+	t.Run("ConcurrentBookings_OneWins", func(t *testing.T) {
+		svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		slot := start.Add(2 * time.Hour)
+		succeeded := race(func(i int) error {
+			appt := f.newAppointment(barberID, slot)
+			appt.CustomerID = customers[i].ID
+			_, err := svc.CreateAppointment(ctx, appt)
+			return err
+		})
+		assert.Equal(t, 1, succeeded)
+
+		var booked int64
+		assert.NoError(t, db.Model(&barberBookingModels.Appointment{}).
+			Where("barber_id = ? AND start_time = ? AND status IN ?", barberID, slot,
+				[]barberBookingModels.AppointmentStatus{barberBookingModels.StatusPending, barberBookingModels.StatusConfirmed}).
+			Count(&booked).Error)
+		assert.EqualValues(t, 1, booked)
+	})
+
+	t.Run("ConstraintViolation_MapsToSlotTaken", func(t *testing.T) {
+		svc := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		slot := start.Add(4 * time.Hour)
+
+		confirmed := f.newAppointment(barberID, slot)
+		confirmed.EndTime = slot.Add(30 * time.Minute)
+		confirmed.Status = barberBookingModels.StatusConfirmed
+		assert.NoError(t, db.Create(confirmed).Error)
+
+		// ตรงเข้าฐานข้อมูลโดยไม่ผ่าน service ก็ซ้อนไม่ได้
+		dup := f.newAppointment(barberID, slot)
+		dup.EndTime = slot.Add(30 * time.Minute)
+		dup.Status = barberBookingModels.StatusPending
+		assert.Error(t, db.Create(dup).Error)
+
+		// RESCHEDULED ไม่กันเวลา จึงบันทึกได้ แต่ยืนยันทับนัดเดิมไม่ได้
+		rescheduled := f.newAppointment(barberID, slot)
+		rescheduled.EndTime = slot.Add(30 * time.Minute)
+		rescheduled.Status = barberBookingModels.StatusRescheduled
+		assert.NoError(t, db.Create(rescheduled).Error)
+
+		_, err := svc.TransitionStatus(ctx, f.TenantID, rescheduled.ID, barberBookingModels.StatusConfirmed,
+			barberBookingPort.StatusTransitionInput{ActorRole: string(coreModels.RoleNameBranchAdmin)})
+		assert.ErrorContains(t, err, "slot taken")
+	})
+}
@@ -0,0 +1,215 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	coreModels "myapp/modules/core/models"
+	coreServices "myapp/modules/core/services"
+)
+
+// fakeBotAPI เก็บข้อความที่ส่งผ่าน sendMessage
+type fakeBotAPI struct {
+	mu       sync.Mutex
+	messages []map[string]interface{}
+}
+
+func (f *fakeBotAPI) handler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.messages = append(f.messages, body)
+		f.mu.Unlock()
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *fakeBotAPI) last(t *testing.T) (string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NotEmpty(t, f.messages)
+	m := f.messages[len(f.messages)-1]
+	markup, _ := json.Marshal(m["reply_markup"])
+	return m["text"].(string), string(markup)
+}
+
+func telegramText(chatID int64, text string) coreModels.TelegramWebhookRequest {
+	var req coreModels.TelegramWebhookRequest
+	req.Message.Chat.ID = chatID
+	req.Message.Text = text
+	return req
+}
+
+func telegramCallback(chatID int64, data string) coreModels.TelegramWebhookRequest {
+	var req coreModels.TelegramWebhookRequest
+	req.CallbackQuery = &struct {
+		ID   string `json:"id"`
+		Data string `json:"data"`
+		From struct {
+			ID       int64  `json:"id"`
+			Username string `json:"username"`
+		} `json:"from"`
+		Message struct {
+			MessageID int64 `json:"message_id"`
+			Chat      struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+		} `json:"message"`
+	}{ID: "cb", Data: data}
+	req.CallbackQuery.Message.Chat.ID = chatID
+	return req
+}
+
+func TestTelegramBookingBot(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestAppointmentDB(t)
+	require.NoError(t, db.AutoMigrate(&coreModels.Role{}, &coreModels.User{}, &coreModels.TenantUser{},
+		&coreModels.TelegramLink{}, &coreModels.TelegramLinkCode{}))
+	f := seedAppointmentFixture(t, db, 2)
+
+	role := coreModels.Role{Name: string(coreModels.RoleNameUser)}
+	require.NoError(t, db.Create(&role).Error)
+	barberUser := coreModels.User{ID: f.Barbers[0].UserID, Username: "barber-one", Email: "b1@example.com", Password: "x", PhoneNumber: "0811111111", RoleID: role.ID}
+	require.NoError(t, db.Create(&barberUser).Error)
+
+	api := &fakeBotAPI{}
+	srv := httptest.NewServer(http.HandlerFunc(api.handler))
+	defer srv.Close()
+
+	appointments := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+	tg := coreServices.NewTelegramService(db)
+	tg.BaseURL = srv.URL
+	tg.SetCommandHandler(barberBookingServices.NewTelegramBookingBot(db, appointments))
+
+	const chatID = int64(5551234)
+
+	t.Run("Unlinked chat is asked to link first", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(chatID, "/today")))
+		text, _ := api.last(t)
+		assert.Contains(t, text, "ยังไม่ได้เชื่อมต่อบัญชี")
+	})
+
+	t.Run("One-time code links chat to user", func(t *testing.T) {
+		code, err := tg.CreateLinkCode(ctx, barberUser.ID, 0)
+		require.NoError(t, err)
+		assert.Len(t, code.Code, 8)
+
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(chatID, "/start "+strings.ToLower(code.Code))))
+		text, _ := api.last(t)
+		assert.Contains(t, text, "เชื่อมต่อกับบัญชี barber-one เรียบร้อยแล้ว")
+
+		var link coreModels.TelegramLink
+		require.NoError(t, db.Where("chat_id = ?", chatID).First(&link).Error)
+		assert.Equal(t, barberUser.ID, link.UserID)
+
+		// ใช้รหัสซ้ำจาก chat อื่นไม่ได้
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(999, "/start "+code.Code)))
+		text, _ = api.last(t)
+		assert.Contains(t, text, "รหัสไม่ถูกต้องหรือหมดอายุแล้ว")
+	})
+
+	// "วันนี้" ของบอทคือวันตามเวลาไทย ไม่ว่า server จะตั้ง timezone อะไร
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	require.NoError(t, err)
+	now := time.Now()
+	local := now.In(bangkok)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 30, 0, 0, bangkok)
+	mine := barberBookingModels.Appointment{
+		TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, BarberID: f.Barbers[0].ID, CustomerID: f.Customer.ID,
+		StartTime: today, EndTime: today.Add(30 * time.Minute), BlockStart: today, BlockEnd: today.Add(30 * time.Minute),
+		Status: barberBookingModels.StatusPending,
+	}
+	require.NoError(t, db.Create(&mine).Error)
+	upcoming := now.Add(48 * time.Hour).Truncate(time.Hour)
+	next := barberBookingModels.Appointment{
+		TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, BarberID: f.Barbers[0].ID, CustomerID: f.Customer.ID,
+		StartTime: upcoming, EndTime: upcoming.Add(30 * time.Minute), BlockStart: upcoming, BlockEnd: upcoming.Add(30 * time.Minute),
+		Status: barberBookingModels.StatusConfirmed,
+	}
+	require.NoError(t, db.Create(&next).Error)
+	others := barberBookingModels.Appointment{
+		TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, BarberID: f.Barbers[1].ID, CustomerID: f.Customer.ID,
+		StartTime: today, EndTime: today.Add(30 * time.Minute), BlockStart: today, BlockEnd: today.Add(30 * time.Minute),
+		Status: barberBookingModels.StatusPending,
+	}
+	require.NoError(t, db.Create(&others).Error)
+	// ปลายวันเดียวกันตามเวลาไทย (คนละวันกับ 00:30 ถ้านับตาม UTC)
+	lateToday := today.Add(23 * time.Hour)
+	late := barberBookingModels.Appointment{
+		TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, BarberID: f.Barbers[0].ID, CustomerID: f.Customer.ID,
+		StartTime: lateToday, EndTime: lateToday.Add(30 * time.Minute), BlockStart: lateToday, BlockEnd: lateToday.Add(30 * time.Minute),
+		Status: barberBookingModels.StatusComplete,
+	}
+	require.NoError(t, db.Create(&late).Error)
+
+	t.Run("/today lists own appointments with confirm buttons for PENDING", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(chatID, "/today")))
+		text, markup := api.last(t)
+		assert.Contains(t, text, "นัดวันนี้ (2 นัด)")
+		assert.Contains(t, text, "#"+itoa(late.ID)+" ")
+		assert.Contains(t, text, "Cut — Alice [PENDING]")
+		assert.Contains(t, markup, `"callback_data":"confirm:`)
+		assert.NotContains(t, text, "#"+itoa(others.ID)+" ")
+	})
+
+	t.Run("/next shows the next upcoming appointment", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(chatID, "/next@my_bot")))
+		text, _ := api.last(t)
+		assert.Contains(t, text, "นัดถัดไป")
+		assert.Contains(t, text, "#"+itoa(next.ID)+" ")
+	})
+
+	t.Run("Confirm button confirms own PENDING appointment", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramCallback(chatID, "confirm:"+itoa(mine.ID))))
+		text, _ := api.last(t)
+		assert.Contains(t, text, "ยืนยันนัด #"+itoa(mine.ID))
+
+		var ap barberBookingModels.Appointment
+		require.NoError(t, db.First(&ap, mine.ID).Error)
+		assert.Equal(t, barberBookingModels.StatusConfirmed, ap.Status)
+	})
+
+	t.Run("Cannot act on another barber's appointment", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramCallback(chatID, "decline:"+itoa(others.ID))))
+		text, _ := api.last(t)
+		assert.Contains(t, text, "permission denied")
+
+		var ap barberBookingModels.Appointment
+		require.NoError(t, db.First(&ap, others.ID).Error)
+		assert.Equal(t, barberBookingModels.StatusPending, ap.Status)
+	})
+
+	t.Run("/cancel cancels own appointment", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(chatID, "/cancel "+itoa(next.ID))))
+		text, _ := api.last(t)
+		assert.Contains(t, text, "ยกเลิกนัด #"+itoa(next.ID))
+
+		var ap barberBookingModels.Appointment
+		require.NoError(t, db.First(&ap, next.ID).Error)
+		assert.Equal(t, barberBookingModels.StatusCancelled, ap.Status)
+	})
+
+	t.Run("/unlink removes the link", func(t *testing.T) {
+		require.NoError(t, tg.HandleUpdate(ctx, telegramText(chatID, "/unlink")))
+		var count int64
+		db.Model(&coreModels.TelegramLink{}).Where("chat_id = ?", chatID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -14,7 +14,9 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	helperFunc "myapp/modules/barberbooking"
 	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
 	barberBookingService "myapp/modules/barberbooking/services"
 )
 
@@ -251,3 +253,96 @@ func TestUnavailabilityService_CRUD(t *testing.T) {
 func ptrUint(v uint) *uint {
 	return &v
 }
+
+func TestUnavailability_TimeRangesBlockBookings(t *testing.T) {
+	ctx := context.Background()
+	loc := time.Now().Location()
+	// 2030-01-07 เป็นวันจันทร์
+	at := func(day, hour, min int) time.Time { return time.Date(2030, 1, day, hour, min, 0, 0, loc) }
+	clock := func(hour, min int) *helperFunc.TimeOnly {
+		return &helperFunc.TimeOnly{Time: time.Date(0, 1, 1, hour, min, 0, 0, time.UTC)}
+	}
+
+	db := setupTestAppointmentDB(t)
+	f := seedAppointmentFixture(t, db, 2)
+	unavailability := barberBookingService.NewUnavailabilityService(db)
+	svc := barberBookingService.NewAppointmentService(db, &stubStatusLog{})
+	locks := barberBookingService.NewAppointmentLockService(db)
+
+	// พักกลางวันของช่างคนแรก ทุกวันจันทร์–ศุกร์
+	_, err := unavailability.CreateUnavailability(ctx, &barberBookingModels.Unavailability{
+		BarberID: &f.Barbers[0].ID, Date: at(7, 0, 0),
+		StartTime: clock(12, 0), EndTime: clock(13, 0), RepeatWeekdays: "1,2,3,4,5",
+	})
+	assert.NoError(t, err)
+
+	// สาขาปิดบางช่วงเฉพาะวันพฤหัส
+	_, err = unavailability.CreateUnavailability(ctx, &barberBookingModels.Unavailability{
+		BranchID: &f.BranchID, Date: at(10, 0, 0), StartTime: clock(15, 0), EndTime: clock(16, 0),
+	})
+	assert.NoError(t, err)
+
+	t.Run("InvalidRange_Fail", func(t *testing.T) {
+		_, err := unavailability.CreateUnavailability(ctx, &barberBookingModels.Unavailability{
+			BarberID: &f.Barbers[0].ID, Date: at(7, 0, 0), StartTime: clock(12, 0),
+		})
+		assert.ErrorContains(t, err, "invalid time range")
+
+		_, err = unavailability.CreateUnavailability(ctx, &barberBookingModels.Unavailability{
+			BarberID: &f.Barbers[0].ID, Date: at(7, 0, 0), RepeatWeekdays: "1,9",
+		})
+		assert.ErrorContains(t, err, "invalid repeat_weekdays")
+	})
+
+	t.Run("RecurringLunch_BlocksCreate", func(t *testing.T) {
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, at(9, 12, 0)))
+		assert.ErrorContains(t, err, "not available")
+
+		// buffer/นัดที่จบตอนพักเริ่มพอดีไม่ชน
+		_, err = svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, at(9, 11, 30)))
+		assert.NoError(t, err)
+
+		// วันเสาร์ไม่อยู่ในรายการทำซ้ำ
+		_, err = svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, at(12, 12, 0)))
+		assert.NoError(t, err)
+	})
+
+	t.Run("RecurringLunch_BlocksReschedule", func(t *testing.T) {
+		appt, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, at(8, 14, 0)))
+		assert.NoError(t, err)
+
+		actor := uint(1)
+		assert.Error(t, svc.RescheduleAppointment(ctx, f.TenantID, appt.ID, at(8, 12, 30), &actor, nil))
+		assert.NoError(t, svc.RescheduleAppointment(ctx, f.TenantID, appt.ID, at(8, 13, 0), &actor, nil))
+	})
+
+	t.Run("AvailableBarbers_ExcludesBarberOnBreak", func(t *testing.T) {
+		barbers, err := svc.GetAvailableBarbers(ctx, f.TenantID, f.BranchID, at(7, 12, 15), at(7, 12, 45))
+		assert.NoError(t, err)
+		if assert.Len(t, barbers, 1) {
+			assert.Equal(t, f.Barbers[1].ID, barbers[0].ID)
+		}
+	})
+
+	t.Run("BranchPartialClosure_BlocksAllBarbers", func(t *testing.T) {
+		_, err := svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[1].ID, at(10, 15, 30)))
+		assert.ErrorContains(t, err, "not available")
+
+		_, err = svc.CreateAppointment(ctx, f.newAppointment(f.Barbers[1].ID, at(10, 16, 0)))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Lock_DuringBreak_Fail", func(t *testing.T) {
+		_, err := locks.CreateAppointmentLock(ctx, barberBookingPort.AppointmentLockInput{
+			TenantID: f.TenantID, BranchID: f.BranchID, BarberID: f.Barbers[0].ID, CustomerID: f.Customer.ID,
+			StartTime: at(11, 12, 30), EndTime: at(11, 13, 0),
+		})
+		assert.ErrorContains(t, err, "unavailable")
+
+		_, err = locks.CreateAppointmentLock(ctx, barberBookingPort.AppointmentLockInput{
+			TenantID: f.TenantID, BranchID: f.BranchID, BarberID: f.Barbers[0].ID, CustomerID: f.Customer.ID,
+			StartTime: at(11, 13, 0), EndTime: at(11, 13, 30),
+		})
+		assert.NoError(t, err)
+	})
+}
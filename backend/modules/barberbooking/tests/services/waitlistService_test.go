@@ -0,0 +1,201 @@
+package barberbookingServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	barberBookingPort "myapp/modules/barberbooking/port"
+	barberBookingServices "myapp/modules/barberbooking/services"
+	notificationModels "myapp/modules/notification/models"
+)
+
+func TestWaitlistService(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	actor := uint(1)
+
+	t.Run("CancelledSlot_OfferedToFirstMatchingEntry", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 2)
+		apps := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		svc := barberBookingServices.NewWaitlistService(db, apps)
+
+		booked, err := apps.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		assert.NoError(t, err)
+
+		join := func(barberID *uint, phone string) *barberBookingModels.WaitlistEntry {
+			e, err := svc.JoinWaitlist(ctx, &barberBookingModels.WaitlistEntry{
+				TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, BarberID: barberID,
+				DesiredStart: start.Add(-time.Hour), DesiredEnd: start.Add(2 * time.Hour),
+			}, &barberBookingPort.CustomerInput{Name: "Guest " + phone, Phone: phone})
+			assert.NoError(t, err)
+			return e
+		}
+		otherBarber := join(&f.Barbers[1].ID, "0811111111") // ต้องการช่างอีกคน → ไม่ match
+		first := join(nil, "0822222222")
+		second := join(nil, "0833333333")
+
+		assert.NoError(t, apps.CancelAppointment(ctx, booked.ID, &actor, nil))
+
+		entries, err := svc.ListWaitlist(ctx, f.TenantID, f.BranchID, nil)
+		assert.NoError(t, err)
+		status := map[uint]barberBookingModels.WaitlistStatus{}
+		for _, e := range entries {
+			status[e.ID] = e.Status
+			if e.ID == first.ID && assert.NotNil(t, e.Lock) {
+				assert.Equal(t, f.Barbers[0].ID, e.Lock.BarberID)
+				assert.True(t, e.Lock.StartTime.Equal(start))
+				assert.True(t, e.Lock.IsActive)
+			}
+		}
+		assert.Equal(t, barberBookingModels.WaitlistWaiting, status[otherBarber.ID])
+		assert.Equal(t, barberBookingModels.WaitlistOffered, status[first.ID])
+		assert.Equal(t, barberBookingModels.WaitlistWaiting, status[second.ID])
+
+		t.Run("Promote_CreatesAppointmentAndReleasesHold", func(t *testing.T) {
+			promoted, err := svc.PromoteWaitlistEntry(ctx, f.TenantID, first.ID, barberBookingPort.PromoteWaitlistRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, barberBookingModels.WaitlistPromoted, promoted.Status)
+
+			var ap barberBookingModels.Appointment
+			assert.NoError(t, db.First(&ap, *promoted.AppointmentID).Error)
+			assert.Equal(t, f.Barbers[0].ID, ap.BarberID)
+			assert.True(t, ap.StartTime.Equal(start))
+
+			var lock barberBookingModels.AppointmentLock
+			assert.NoError(t, db.First(&lock, *promoted.LockID).Error)
+			assert.False(t, lock.IsActive)
+
+			_, err = svc.PromoteWaitlistEntry(ctx, f.TenantID, first.ID, barberBookingPort.PromoteWaitlistRequest{})
+			assert.ErrorContains(t, err, "cannot be promoted")
+			var count int64
+			assert.NoError(t, db.Model(&barberBookingModels.Appointment{}).Where("customer_id = ?", first.CustomerID).Count(&count).Error)
+			assert.EqualValues(t, 1, count)
+		})
+	})
+
+	t.Run("Offer_QueuesNotificationForCustomer", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		apps := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		svc := barberBookingServices.NewWaitlistService(db, apps)
+		assert.NoError(t, db.Create(&notificationModels.NotificationChannelConfig{
+			TenantID: f.TenantID, Channel: notificationModels.ChannelSMS, Enabled: true, Settings: `{"endpoint":"https://sms.example.com"}`,
+		}).Error)
+
+		booked, err := apps.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		assert.NoError(t, err)
+		_, err = svc.JoinWaitlist(ctx, &barberBookingModels.WaitlistEntry{
+			TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID,
+			DesiredStart: start, DesiredEnd: start.Add(time.Hour),
+		}, &barberBookingPort.CustomerInput{Name: "Guest", Phone: "0844444444"})
+		assert.NoError(t, err)
+		assert.NoError(t, apps.CancelAppointment(ctx, booked.ID, &actor, nil))
+
+		var offers []notificationModels.Notification
+		assert.NoError(t, db.Where("event = ?", barberBookingServices.NotifyWaitlistOffered).Find(&offers).Error)
+		if assert.Len(t, offers, 1) {
+			assert.Equal(t, "0844444444", offers[0].Recipient)
+			assert.Contains(t, offers[0].Body, "07/01/2030 เวลา 17:00 น.")
+		}
+	})
+
+	t.Run("ExpiredHold_OfferedToNextEntry", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		apps := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		svc := barberBookingServices.NewWaitlistService(db, apps)
+
+		booked, err := apps.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		assert.NoError(t, err)
+		join := func(phone string) *barberBookingModels.WaitlistEntry {
+			e, err := svc.JoinWaitlist(ctx, &barberBookingModels.WaitlistEntry{
+				TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID,
+				DesiredStart: start, DesiredEnd: start.Add(time.Hour),
+			}, &barberBookingPort.CustomerInput{Name: "Guest " + phone, Phone: phone})
+			assert.NoError(t, err)
+			return e
+		}
+		first := join("0822222222")
+		second := join("0833333333")
+		assert.NoError(t, apps.CancelAppointment(ctx, booked.ID, &actor, nil))
+
+		var offered barberBookingModels.WaitlistEntry
+		assert.NoError(t, db.First(&offered, first.ID).Error)
+		if !assert.NotNil(t, offered.LockID) {
+			return
+		}
+		// ลูกค้าคนแรกไม่ยืนยันภายในเวลา hold
+		assert.NoError(t, db.Model(&barberBookingModels.AppointmentLock{}).Where("id = ?", *offered.LockID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+		assert.NoError(t, barberBookingServices.NewAppointmentLockService(db).CleanupExpiredLocks(ctx))
+
+		var got barberBookingModels.WaitlistEntry
+		assert.NoError(t, db.First(&got, first.ID).Error)
+		assert.Equal(t, barberBookingModels.WaitlistExpired, got.Status)
+		_, err = svc.PromoteWaitlistEntry(ctx, f.TenantID, first.ID, barberBookingPort.PromoteWaitlistRequest{})
+		assert.ErrorContains(t, err, "cannot be promoted")
+
+		var next barberBookingModels.WaitlistEntry
+		assert.NoError(t, db.Preload("Lock").First(&next, second.ID).Error)
+		assert.Equal(t, barberBookingModels.WaitlistOffered, next.Status)
+		if assert.NotNil(t, next.Lock) {
+			assert.True(t, next.Lock.IsActive)
+			assert.True(t, next.Lock.StartTime.Equal(start))
+		}
+	})
+
+	t.Run("RescheduledAway_OffersOldSlot", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		apps := barberBookingServices.NewAppointmentService(db, &stubStatusLog{})
+		svc := barberBookingServices.NewWaitlistService(db, apps)
+
+		booked, err := apps.CreateAppointment(ctx, f.newAppointment(f.Barbers[0].ID, start))
+		assert.NoError(t, err)
+		entry, err := svc.JoinWaitlist(ctx, &barberBookingModels.WaitlistEntry{
+			TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, CustomerID: f.Customer.ID,
+			DesiredStart: start, DesiredEnd: start.Add(30 * time.Minute),
+		}, nil)
+		assert.NoError(t, err)
+
+		assert.NoError(t, apps.RescheduleAppointment(ctx, f.TenantID, booked.ID, start.Add(3*time.Hour), &actor, nil))
+
+		var got barberBookingModels.WaitlistEntry
+		assert.NoError(t, db.First(&got, entry.ID).Error)
+		assert.Equal(t, barberBookingModels.WaitlistOffered, got.Status)
+	})
+
+	t.Run("Promote_WithoutHoldOrStartTime_Fail", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		svc := barberBookingServices.NewWaitlistService(db, barberBookingServices.NewAppointmentService(db, &stubStatusLog{}))
+
+		entry, err := svc.JoinWaitlist(ctx, &barberBookingModels.WaitlistEntry{
+			TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, CustomerID: f.Customer.ID,
+			DesiredStart: start, DesiredEnd: start.Add(time.Hour),
+		}, nil)
+		assert.NoError(t, err)
+
+		_, err = svc.PromoteWaitlistEntry(ctx, f.TenantID, entry.ID, barberBookingPort.PromoteWaitlistRequest{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "start_time is required")
+	})
+
+	t.Run("WindowShorterThanService_Fail", func(t *testing.T) {
+		db := setupTestAppointmentDB(t)
+		f := seedAppointmentFixture(t, db, 1)
+		svc := barberBookingServices.NewWaitlistService(db, barberBookingServices.NewAppointmentService(db, &stubStatusLog{}))
+
+		_, err := svc.JoinWaitlist(ctx, &barberBookingModels.WaitlistEntry{
+			TenantID: f.TenantID, BranchID: f.BranchID, ServiceID: f.Service.ID, CustomerID: f.Customer.ID,
+			DesiredStart: start, DesiredEnd: start.Add(10 * time.Minute),
+		}, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid desired window")
+	})
+}
@@ -1,7 +1,12 @@
 package Core_controllers
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
 	corePort "myapp/modules/core/port"
 )
 
@@ -17,6 +22,11 @@ func (ctl *TelegramController) HandleWebhook(c *fiber.Ctx) error {
 	return ctl.Service.ProcessWebhook(c)
 }
 
+// HandleTenantWebhook รับ update จากบอทของร้าน (Telegram เรียกเอง ไม่ต้อง login)
+func (ctl *TelegramController) HandleTenantWebhook(c *fiber.Ctx) error {
+	return ctl.Service.ProcessTenantWebhook(c)
+}
+
 
 func (ctl *TelegramController) HandleSendMessage(c *fiber.Ctx) error {
 	var req corePort.TelegramSendRequest
@@ -44,3 +54,176 @@ func (ctl *TelegramController) HandleSendMessage(c *fiber.Ctx) error {
 		"message": "sent",
 	})
 }
+
+// CreateLinkCode godoc
+// @Summary      สร้างรหัสเชื่อมต่อ Telegram
+// @Description  รหัสใช้ได้ครั้งเดียว อายุ 10 นาที ส่งให้บอทด้วย /start <code> เพื่อผูก chat กับบัญชีของผู้ใช้ที่ login อยู่
+// @Tags         Telegram
+// @Produce      json
+// @Param        tenant_id  query     int  false  "ร้านที่จะผูกผ่านบอทของร้าน (ค่าเริ่มต้นจาก token)"
+// @Success      200  {object}  corePort.TelegramLinkCodeResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /core/telegram/link-code [post]
+// @Security     ApiKeyAuth
+func (ctl *TelegramController) CreateLinkCode(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+
+	tenantID, _ := c.Locals("tenant_id").(uint)
+	if tid := c.Query("tenant_id", ""); tid != "" {
+		parsed, err := strconv.ParseUint(tid, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+		}
+		tenantID = uint(parsed)
+	}
+
+	resp, err := ctl.Service.CreateLinkCode(c.Context(), userID, tenantID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not a member") {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": resp})
+}
+
+// Unlink godoc
+// @Summary      ยกเลิกการเชื่อมต่อ Telegram
+// @Tags         Telegram
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /core/telegram/link [delete]
+// @Security     ApiKeyAuth
+func (ctl *TelegramController) Unlink(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+
+	if err := ctl.Service.Unlink(c.Context(), userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Telegram unlinked"})
+}
+
+var RolesCanManageTelegramBot = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+}
+
+// GetTenantBot godoc
+// @Summary      ดูบอท Telegram ของร้าน
+// @Tags         Telegram
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Success      200        {object}  corePort.TelegramBotResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /core/telegram/tenants/{tenant_id}/bot [get]
+// @Security     ApiKeyAuth
+func (ctl *TelegramController) GetTenantBot(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageTelegramBot) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	bot, err := ctl.Service.GetTenantBot(c.Context(), tenantID)
+	if err != nil {
+		return telegramBotError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": bot})
+}
+
+// UpsertTenantBot godoc
+// @Summary      ตั้งบอท Telegram ของร้าน
+// @Description  ส่ง bot_token จาก BotFather ระบบจะตรวจ token, เก็บแบบเข้ารหัส และตั้ง webhook พร้อม secret ให้อัตโนมัติ
+// @Description  ครั้งต่อไปส่งเฉพาะ enabled เพื่อเปิด/ปิดบอทได้
+// @Tags         Telegram
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                               true  "รหัส Tenant"
+// @Param        body       body      corePort.UpsertTelegramBotRequest  true  "token และสถานะ"
+// @Success      200        {object}  corePort.TelegramBotResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /core/telegram/tenants/{tenant_id}/bot [put]
+// @Security     ApiKeyAuth
+func (ctl *TelegramController) UpsertTenantBot(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageTelegramBot) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req corePort.UpsertTelegramBotRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	bot, err := ctl.Service.UpsertTenantBot(c.Context(), tenantID, req)
+	if err != nil {
+		return telegramBotError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": bot})
+}
+
+// DeleteTenantBot godoc
+// @Summary      ลบบอท Telegram ของร้าน
+// @Description  ลบ webhook ที่ Telegram และลบ token ออกจากระบบ
+// @Tags         Telegram
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Success      200        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /core/telegram/tenants/{tenant_id}/bot [delete]
+// @Security     ApiKeyAuth
+func (ctl *TelegramController) DeleteTenantBot(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageTelegramBot) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	if err := ctl.Service.DeleteTenantBot(c.Context(), tenantID); err != nil {
+		return telegramBotError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Telegram bot deleted"})
+}
+
+func telegramBotError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	coreModels "myapp/modules/core/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RequireModule อนุญาตเฉพาะ tenant ที่เปิดใช้ module นี้ใน tenant_modules
+// ต้องวางหลัง RequireTenant เพราะอ่าน tenant_id จาก Locals
+func RequireModule(db *gorm.DB, moduleName string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID, ok := c.Locals("tenant_id").(uint)
+		if !ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Tenant ID is required in the path",
+			})
+		}
+
+		var count int64
+		err := db.WithContext(c.Context()).
+			Model(&coreModels.TenantModule{}).
+			Joins("JOIN modules ON modules.id = tenant_modules.module_id").
+			Where("tenant_modules.tenant_id = ? AND modules.name = ?", tenantID, moduleName).
+			Count(&count).Error
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to check tenant modules",
+			})
+		}
+		if count == 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Module " + moduleName + " is not enabled for this tenant",
+			})
+		}
+		return c.Next()
+	}
+}
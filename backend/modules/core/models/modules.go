@@ -4,9 +4,16 @@ import (
 	"time"
 )
 
+// ชื่อ module (Module.Name) ที่ระบบรู้จัก tenant ใช้ module ไหนได้ดูจาก tenant_modules
+const (
+	ModuleBarberBooking = "barber_booking"
+	ModulePOS           = "pos"
+	ModuleInventory     = "inventory"
+)
+
 type Module struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`   // เช่น barber_booking
+	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"` // เช่น barber_booking
 	Description string    `gorm:"type:text" json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 }
@@ -0,0 +1,25 @@
+package coreModels
+
+import "time"
+
+// TelegramBot บอทของร้าน (หนึ่งบอทต่อ tenant) รับ update ผ่าน /telegram/webhook/:tenant_id
+// token เก็บแบบเข้ารหัส ส่วน webhook secret เก็บเฉพาะ hash
+type TelegramBot struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	TenantID          uint      `gorm:"not null;uniqueIndex" json:"tenant_id"`
+	BotUsername       string    `gorm:"type:text;uniqueIndex:uq_telegram_bots_username" json:"bot_username"`
+	TokenEncrypted    string    `gorm:"type:text;not null" json:"-"`
+	WebhookSecretHash string    `gorm:"type:varchar(64);not null" json:"-"`
+	Enabled           bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TelegramUpdate update_id ที่ประมวลผลแล้ว กัน Telegram ส่ง update เดิมซ้ำ
+// BotID = 0 คือบอทกลางของระบบ (TELEGRAM_TOKEN)
+type TelegramUpdate struct {
+	ID        uint      `gorm:"primaryKey"`
+	BotID     uint      `gorm:"not null;uniqueIndex:uq_telegram_updates_bot_update"`
+	UpdateID  int64     `gorm:"not null;uniqueIndex:uq_telegram_updates_bot_update"`
+	CreatedAt time.Time `gorm:"index"`
+}
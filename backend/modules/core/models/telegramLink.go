@@ -0,0 +1,25 @@
+package coreModels
+
+import "time"
+
+// TelegramLink ผูก Telegram chat กับผู้ใช้ในระบบ (หนึ่ง chat ต่อหนึ่งผู้ใช้ ในแต่ละบอท)
+// BotID = 0 คือบอทกลางของระบบ (TELEGRAM_TOKEN) บอทร้านใช้ได้เฉพาะการผูกที่ทำผ่านบอทนั้น
+type TelegramLink struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BotID     uint      `gorm:"not null;default:0;uniqueIndex:uq_telegram_links_bot_user;uniqueIndex:uq_telegram_links_bot_chat" json:"bot_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:uq_telegram_links_bot_user" json:"user_id"`
+	ChatID    int64     `gorm:"not null;uniqueIndex:uq_telegram_links_bot_chat" json:"chat_id"`
+	Username  string    `gorm:"type:text" json:"username,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TelegramLinkCode รหัสใช้ครั้งเดียวสำหรับผูกบัญชี เก็บเฉพาะ hash ของรหัส
+type TelegramLinkCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -1,9 +1,12 @@
 package coreModels
 
+// TelegramWebhookRequest update จาก Telegram (รองรับข้อความและการกดปุ่ม inline keyboard)
 type TelegramWebhookRequest struct {
-	Message struct {
-		Text string `json:"text"`
-		Chat struct {
+	UpdateID int64 `json:"update_id"`
+	Message  struct {
+		MessageID int64  `json:"message_id"`
+		Text      string `json:"text"`
+		Chat      struct {
 			ID int64 `json:"id"`
 		} `json:"chat"`
 		From struct {
@@ -11,4 +14,18 @@ type TelegramWebhookRequest struct {
 			Username string `json:"username"`
 		} `json:"from"`
 	} `json:"message"`
+	CallbackQuery *struct {
+		ID   string `json:"id"`
+		Data string `json:"data"`
+		From struct {
+			ID       int64  `json:"id"`
+			Username string `json:"username"`
+		} `json:"from"`
+		Message struct {
+			MessageID int64 `json:"message_id"`
+			Chat      struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+		} `json:"message"`
+	} `json:"callback_query,omitempty"`
 }
@@ -1,15 +1,77 @@
 package corePort
 
 import (
+	"context"
+	"time"
+
+	coreModels "myapp/modules/core/models"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 type ITelegramService interface {
+	// webhook ของบอทกลาง ต้องส่ง header secret ตรงกับ TELEGRAM_WEBHOOK_SECRET
 	ProcessWebhook(c *fiber.Ctx) error
+	// webhook ของบอทร้าน /webhook/:tenant_id ตรวจ header secret ที่ตั้งไว้ตอน setWebhook
+	ProcessTenantWebhook(c *fiber.Ctx) error
 	SendTelegramMessage(chatID int64, message string) error
+
+	// สร้างรหัสใช้ครั้งเดียวให้ผู้ใช้ส่งให้บอท (/start <code>) เพื่อผูก chat กับบัญชี
+	// tenantID != 0 → ผู้ใช้ต้องอยู่ในร้านนั้น และ DeepLink ชี้ไปที่บอทของร้าน (ถ้าเปิดไว้)
+	CreateLinkCode(ctx context.Context, userID, tenantID uint) (*TelegramLinkCodeResponse, error)
+	// ยกเลิกการผูก Telegram ของผู้ใช้
+	Unlink(ctx context.Context, userID uint) error
+
+	// บอทของร้าน: token ถูกเข้ารหัสก่อนเก็บ และไม่ถูกส่งกลับใน response
+	GetTenantBot(ctx context.Context, tenantID uint) (*TelegramBotResponse, error)
+	UpsertTenantBot(ctx context.Context, tenantID uint, req UpsertTelegramBotRequest) (*TelegramBotResponse, error)
+	DeleteTenantBot(ctx context.Context, tenantID uint) error
 }
 
 type TelegramSendRequest struct {
 	ChatID  int64  `json:"chat_id"`
 	Message string `json:"message"`
 }
+
+type TelegramLinkCodeResponse struct {
+	Code      string    `json:"code" example:"K7M2Q9XP"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// ลิงก์เปิดบอทพร้อมรหัส: บอทของร้าน หรือบอทกลางถ้าตั้ง TELEGRAM_BOT_USERNAME
+	DeepLink string `json:"deep_link,omitempty" example:"https://t.me/my_barber_bot?start=K7M2Q9XP"`
+}
+
+// UpsertTelegramBotRequest ตั้งครั้งแรกต้องส่ง bot_token; ส่ง token ใหม่เมื่อต้องการเปลี่ยนบอท
+type UpsertTelegramBotRequest struct {
+	BotToken string `json:"bot_token,omitempty" example:"123456:ABC-DEF"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+type TelegramBotResponse struct {
+	TenantID    uint      `json:"tenant_id"`
+	BotUsername string    `json:"bot_username" example:"my_barber_bot"`
+	Enabled     bool      `json:"enabled"`
+	WebhookURL  string    `json:"webhook_url" example:"https://api.example.com/api/v1/core/telegram/webhook/1"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TelegramReply ข้อความตอบกลับ พร้อมปุ่ม inline keyboard (ถ้ามี)
+type TelegramReply struct {
+	Text    string
+	Buttons [][]TelegramButton
+}
+
+type TelegramButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// ITelegramCommandHandler คำสั่งของบอทสำหรับผู้ใช้ที่ผูกบัญชีแล้ว (module อื่นเป็นผู้ implement เช่น barberbooking)
+// user ถูก preload Role มาแล้ว
+type ITelegramCommandHandler interface {
+	// command ไม่มี "/" นำหน้า เช่น "today", args คือคำที่ตามมา
+	HandleCommand(ctx context.Context, user coreModels.User, command string, args []string) (TelegramReply, error)
+	// data คือ callback_data ของปุ่มที่ถูกกด
+	HandleCallback(ctx context.Context, user coreModels.User, data string) (TelegramReply, error)
+	// คำอธิบายคำสั่งสำหรับ /help
+	Help() string
+}
@@ -3,6 +3,8 @@ package coreRoutes
 import (
     "github.com/gofiber/fiber/v2"
 
+    middlewares "myapp/middlewares"
+    coremiddlewares "myapp/modules/core/middlewares"
     coreControllers "myapp/modules/core/controllers"
 )
 
@@ -10,6 +12,14 @@ func RegisterTelegramRoutes(router fiber.Router, ctrl *coreControllers.TelegramC
     
     telegramGroup := router.Group("/telegram")
 	telegramGroup.Post("/webhook", ctrl.HandleWebhook)
+	telegramGroup.Post("/webhook/:tenant_id", ctrl.HandleTenantWebhook)
 	telegramGroup.Post("/send", ctrl.HandleSendMessage)
+	telegramGroup.Post("/link-code", middlewares.RequireAuth(), ctrl.CreateLinkCode)
+	telegramGroup.Delete("/link", middlewares.RequireAuth(), ctrl.Unlink)
+
+	botGroup := telegramGroup.Group("/tenants/:tenant_id/bot", middlewares.RequireAuth(), coremiddlewares.RequireTenant())
+	botGroup.Get("/", ctrl.GetTenantBot)
+	botGroup.Put("/", ctrl.UpsertTenantBot)
+	botGroup.Delete("/", ctrl.DeleteTenantBot)
 
 }
\ No newline at end of file
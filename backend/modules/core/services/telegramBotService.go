@@ -0,0 +1,258 @@
+package coreServices
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	coreModels "myapp/modules/core/models"
+	corePort "myapp/modules/core/port"
+	"myapp/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// header ที่ Telegram แนบมากับทุก webhook เมื่อตั้ง secret_token ไว้ตอน setWebhook
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// ProcessTenantWebhook webhook ของบอทร้าน ตรวจ secret ก่อนอ่าน payload
+// บอทที่ปิดไว้ตอบ 200 เพื่อให้ Telegram ทิ้ง update นั้น
+func (s *TelegramService) ProcessTenantWebhook(c *fiber.Ctx) error {
+	tenantID, err := strconv.ParseUint(c.Params("tenant_id"), 10, 64)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	var bot coreModels.TelegramBot
+	if err := s.DB.WithContext(c.Context()).Where("tenant_id = ?", tenantID).First(&bot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		log.Printf("⚠️ failed to fetch telegram bot of tenant %d: %v", tenantID, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	if !webhookSecretMatches(c, bot.WebhookSecretHash) {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	if !bot.Enabled {
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	token, err := utils.DecryptSecret(bot.TokenEncrypted)
+	if err != nil {
+		log.Printf("⚠️ telegram bot of tenant %d: %v", tenantID, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return s.serveUpdate(c, bot.ID, token)
+}
+
+func (s *TelegramService) GetTenantBot(ctx context.Context, tenantID uint) (*corePort.TelegramBotResponse, error) {
+	var bot coreModels.TelegramBot
+	if err := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID).First(&bot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("telegram bot for tenant %d not found", tenantID)
+		}
+		return nil, fmt.Errorf("failed to fetch telegram bot: %w", err)
+	}
+	return telegramBotResponse(&bot), nil
+}
+
+// UpsertTenantBot ส่ง bot_token มา → ตรวจกับ getMe, สุ่ม secret ใหม่ แล้ว setWebhook มาที่ /webhook/:tenant_id
+// เรียก Bot API ให้เสร็จก่อนเปิด transaction ถ้า setWebhook ไม่สำเร็จจะไม่บันทึกอะไรเลย
+func (s *TelegramService) UpsertTenantBot(ctx context.Context, tenantID uint, req corePort.UpsertTelegramBotRequest) (*corePort.TelegramBotResponse, error) {
+	token := strings.TrimSpace(req.BotToken)
+	db := s.DB.WithContext(ctx)
+
+	var current coreModels.TelegramBot
+	err := db.Where("tenant_id = ?", tenantID).First(&current).Error
+	exists := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch telegram bot: %w", err)
+	}
+	if !exists && token == "" {
+		return nil, errors.New("invalid request: bot_token is required")
+	}
+
+	var username, encrypted, secret, oldToken string
+	if token != "" {
+		var me struct {
+			Username string `json:"username"`
+		}
+		if err := s.callBotAPI(ctx, token, "getMe", map[string]interface{}{}, &me); err != nil {
+			return nil, fmt.Errorf("invalid bot_token: %v", err)
+		}
+		username = me.Username
+
+		// Telegram ตั้ง webhook ได้ที่เดียวต่อบอท จึงห้ามร้านอื่นใช้บอทตัวเดียวกัน
+		// (ตรวจก่อน setWebhook ไม่ให้ย้าย webhook ของร้านอื่น, unique index กันกรณีพร้อมกัน)
+		var taken int64
+		if err := db.Model(&coreModels.TelegramBot{}).
+			Where("bot_username = ? AND tenant_id <> ?", username, tenantID).
+			Count(&taken).Error; err != nil {
+			return nil, err
+		}
+		if taken > 0 {
+			return nil, errBotUsedByAnotherTenant
+		}
+
+		if encrypted, err = utils.EncryptSecret(token); err != nil {
+			return nil, err
+		}
+		if secret, err = randomWebhookSecret(); err != nil {
+			return nil, err
+		}
+		webhookURL, err := tenantWebhookURL(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.callBotAPI(ctx, token, "setWebhook", map[string]interface{}{
+			"url":             webhookURL,
+			"secret_token":    secret,
+			"allowed_updates": []string{"message", "callback_query"},
+		}, nil); err != nil {
+			return nil, fmt.Errorf("failed to set telegram webhook: %w", err)
+		}
+		if exists && current.BotUsername != username {
+			oldToken, _ = utils.DecryptSecret(current.TokenEncrypted)
+		}
+	}
+
+	var bot coreModels.TelegramBot
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("tenant_id = ?", tenantID).First(&bot).Error
+		found := err == nil
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to fetch telegram bot: %w", err)
+		}
+		if !found && token == "" {
+			return errors.New("invalid request: bot_token is required")
+		}
+
+		if token != "" {
+			bot.TenantID = tenantID
+			bot.BotUsername = username
+			bot.TokenEncrypted = encrypted
+			bot.WebhookSecretHash = hashWebhookSecret(secret)
+		}
+		if req.Enabled != nil {
+			bot.Enabled = *req.Enabled
+		} else if !found {
+			bot.Enabled = true
+		}
+
+		if !found {
+			// Create แทนค่า false ด้วย default ของคอลัมน์ จึง Save ซ้ำ
+			if err := tx.Create(&bot).Error; err != nil {
+				return saveTelegramBotError(err)
+			}
+		}
+		if err := tx.Save(&bot).Error; err != nil {
+			return saveTelegramBotError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// เปลี่ยนบอท → บอทเดิมไม่ต้องส่ง update มาอีก
+	if oldToken != "" {
+		if err := s.callBotAPI(ctx, oldToken, "deleteWebhook", map[string]interface{}{}, nil); err != nil {
+			log.Printf("⚠️ failed to delete webhook of previous bot (tenant %d): %v", tenantID, err)
+		}
+	}
+	return telegramBotResponse(&bot), nil
+}
+
+var errBotUsedByAnotherTenant = errors.New("invalid bot_token: bot is already used by another tenant")
+
+// saveTelegramBotError แปลง unique violation ของ bot_username เป็นข้อความเดียวกับที่ตรวจไว้ก่อนหน้า
+func saveTelegramBotError(err error) error {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "duplicate key") || strings.Contains(msg, "unique constraint") {
+		return errBotUsedByAnotherTenant
+	}
+	return fmt.Errorf("failed to save telegram bot: %w", err)
+}
+
+func (s *TelegramService) DeleteTenantBot(ctx context.Context, tenantID uint) error {
+	var bot coreModels.TelegramBot
+	if err := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID).First(&bot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("telegram bot for tenant %d not found", tenantID)
+		}
+		return fmt.Errorf("failed to fetch telegram bot: %w", err)
+	}
+
+	if token, err := utils.DecryptSecret(bot.TokenEncrypted); err == nil {
+		if err := s.callBotAPI(ctx, token, "deleteWebhook", map[string]interface{}{}, nil); err != nil {
+			log.Printf("⚠️ failed to delete telegram webhook (tenant %d): %v", tenantID, err)
+		}
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bot_id = ?", bot.ID).Delete(&coreModels.TelegramUpdate{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bot_id = ?", bot.ID).Delete(&coreModels.TelegramLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&bot).Error; err != nil {
+			return fmt.Errorf("failed to delete telegram bot: %w", err)
+		}
+		return nil
+	})
+}
+
+func telegramBotResponse(bot *coreModels.TelegramBot) *corePort.TelegramBotResponse {
+	resp := &corePort.TelegramBotResponse{
+		TenantID:    bot.TenantID,
+		BotUsername: bot.BotUsername,
+		Enabled:     bot.Enabled,
+		UpdatedAt:   bot.UpdatedAt,
+	}
+	resp.WebhookURL, _ = tenantWebhookURL(bot.TenantID)
+	return resp
+}
+
+// tenantWebhookURL TELEGRAM_WEBHOOK_BASE_URL คือ URL สาธารณะของกลุ่ม /core/telegram
+// เช่น https://api.example.com/api/v1/core/telegram
+func tenantWebhookURL(tenantID uint) (string, error) {
+	base := strings.TrimRight(os.Getenv("TELEGRAM_WEBHOOK_BASE_URL"), "/")
+	if base == "" {
+		return "", errors.New("TELEGRAM_WEBHOOK_BASE_URL is not set")
+	}
+	return fmt.Sprintf("%s/webhook/%d", base, tenantID), nil
+}
+
+func webhookSecretMatches(c *fiber.Ctx, expectedHash string) bool {
+	got := c.Get(telegramSecretHeader)
+	if got == "" || expectedHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashWebhookSecret(got)), []byte(expectedHash)) == 1
+}
+
+func hashWebhookSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// secret_token ของ Telegram รับได้เฉพาะ A-Z a-z 0-9 _ - ยาวไม่เกิน 256
+func randomWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -1,51 +1,383 @@
 package coreServices
 
 import (
-	coreModels "myapp/modules/core/models"
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
+
+	coreModels "myapp/modules/core/models"
+	corePort "myapp/modules/core/port"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type TelegramService struct{}
+const (
+	telegramLinkCodeTTL    = 10 * time.Minute
+	telegramLinkCodeLength = 8
+	// ไม่มีตัวที่สับสนง่าย (0/O, 1/I/L)
+	telegramLinkCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+type TelegramService struct {
+	DB       *gorm.DB
+	Commands corePort.ITelegramCommandHandler
+
+	Client  *http.Client
+	BaseURL string
+}
+
+func NewTelegramService(db *gorm.DB) *TelegramService {
+	return &TelegramService{
+		DB:      db,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		BaseURL: "https://api.telegram.org",
+	}
+}
 
-func NewTelegramService() *TelegramService {
-	return &TelegramService{}
+// SetCommandHandler ผูกคำสั่งของบอท (/today, /next, ...) ที่ module อื่นจัดการ
+func (s *TelegramService) SetCommandHandler(h corePort.ITelegramCommandHandler) {
+	s.Commands = h
 }
 
+// ProcessWebhook webhook ของบอทกลาง (TELEGRAM_TOKEN)
+// ถ้ายังไม่ได้ตั้ง TELEGRAM_WEBHOOK_SECRET จะปฏิเสธทุก request
 func (s *TelegramService) ProcessWebhook(c *fiber.Ctx) error {
+	secret := os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("⚠️ TELEGRAM_WEBHOOK_SECRET is not set, rejecting telegram webhook")
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+	if !webhookSecretMatches(c, hashWebhookSecret(secret)) {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	return s.serveUpdate(c, 0, os.Getenv("TELEGRAM_TOKEN"))
+}
+
+// serveUpdate ตอบ 200 เสมอเมื่อ payload ถูกต้อง เพื่อไม่ให้ Telegram ส่ง update เดิมซ้ำ
+// update_id ที่เคยรับแล้วจะถูกข้าม
+func (s *TelegramService) serveUpdate(c *fiber.Ctx, botID uint, token string) error {
 	var req coreModels.TelegramWebhookRequest
 	if err := c.BodyParser(&req); err != nil {
 		log.Println("Invalid telegram payload:", err)
 		return c.SendStatus(fiber.StatusBadRequest)
 	}
 
+	first, err := s.claimUpdate(c.Context(), botID, req.UpdateID)
+	if err != nil {
+		// ให้ Telegram ส่งซ้ำภายหลัง
+		log.Printf("⚠️ telegram update %d: %v", req.UpdateID, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	if !first {
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	if err := s.handleUpdate(c.Context(), botID, token, req); err != nil {
+		log.Printf("⚠️ telegram update %d failed: %v", req.UpdateID, err)
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// claimUpdate บันทึก update_id ของบอท คืน false ถ้าเคยประมวลผลแล้ว
+func (s *TelegramService) claimUpdate(ctx context.Context, botID uint, updateID int64) (bool, error) {
+	if updateID == 0 {
+		return true, nil
+	}
+	res := s.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&coreModels.TelegramUpdate{BotID: botID, UpdateID: updateID})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to record telegram update: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// PurgeTelegramUpdates ลบ update_id ที่เก่ากว่า olderThan (Telegram ไม่ส่งซ้ำเกิน 24 ชั่วโมง)
+func PurgeTelegramUpdates(db *gorm.DB, olderThan time.Duration) (int64, error) {
+	res := db.Where("created_at < ?", time.Now().Add(-olderThan)).Delete(&coreModels.TelegramUpdate{})
+	return res.RowsAffected, res.Error
+}
+
+// HandleUpdate ประมวลผล update ของบอทกลาง แล้วตอบกลับผ่าน Bot API
+func (s *TelegramService) HandleUpdate(ctx context.Context, req coreModels.TelegramWebhookRequest) error {
+	return s.handleUpdate(ctx, 0, os.Getenv("TELEGRAM_TOKEN"), req)
+}
+
+// handleUpdate ตอบกลับด้วย token ของบอทที่รับ update นั้นมา และใช้เฉพาะการผูกบัญชีที่ทำผ่านบอทนั้น (botID)
+func (s *TelegramService) handleUpdate(ctx context.Context, botID uint, token string, req coreModels.TelegramWebhookRequest) error {
+	if cb := req.CallbackQuery; cb != nil {
+		reply := s.handleCallback(ctx, botID, cb.Message.Chat.ID, cb.Data)
+		if err := s.callBotAPI(ctx, token, "answerCallbackQuery", map[string]interface{}{"callback_query_id": cb.ID}, nil); err != nil {
+			log.Printf("⚠️ telegram answerCallbackQuery failed: %v", err)
+		}
+		return s.sendReply(ctx, token, cb.Message.Chat.ID, reply)
+	}
+
 	chatID := req.Message.Chat.ID
-	username := req.Message.From.Username
-	text := req.Message.Text
+	text := strings.TrimSpace(req.Message.Text)
+	if chatID == 0 || text == "" {
+		return nil
+	}
+	log.Printf("📨 %s (chat_id: %d): %s", req.Message.From.Username, chatID, text)
 
-	log.Printf("📨 %s (chat_id: %d): %s", username, chatID, text)
+	reply := s.handleText(ctx, botID, chatID, req.Message.From.Username, text)
+	return s.sendReply(ctx, token, chatID, reply)
+}
 
-	go s.SendTelegramMessage(chatID, "เชื่อมต่อกับระบบเรียบร้อยแล้ว")
+func (s *TelegramService) handleText(ctx context.Context, botID uint, chatID int64, username, text string) corePort.TelegramReply {
+	command, args := parseTelegramCommand(text)
 
-	return c.SendStatus(fiber.StatusOK)
+	switch command {
+	case "start", "link":
+		if len(args) > 0 {
+			return s.linkChat(ctx, botID, chatID, username, args[0])
+		}
+	case "":
+		// ส่งรหัสมาเปล่า ๆ โดยไม่มีคำสั่ง
+		if len(text) == telegramLinkCodeLength && !strings.Contains(text, " ") {
+			return s.linkChat(ctx, botID, chatID, username, text)
+		}
+	case "unlink":
+		res := s.DB.WithContext(ctx).Where("bot_id = ? AND chat_id = ?", botID, chatID).Delete(&coreModels.TelegramLink{})
+		if res.Error != nil {
+			return errorReply(res.Error)
+		}
+		return corePort.TelegramReply{Text: "ยกเลิกการเชื่อมต่อบัญชีแล้ว"}
+	}
+
+	user, err := s.linkedUser(ctx, botID, chatID)
+	if err != nil {
+		return errorReply(err)
+	}
+	if user == nil {
+		return corePort.TelegramReply{Text: "ยังไม่ได้เชื่อมต่อบัญชี\nสร้างรหัสเชื่อมต่อจากหน้าโปรไฟล์ในระบบ แล้วส่ง /start <รหัส> มาที่นี่"}
+	}
+
+	if command == "" || command == "start" || command == "help" || s.Commands == nil {
+		return corePort.TelegramReply{Text: s.helpText(user)}
+	}
+	reply, err := s.Commands.HandleCommand(ctx, *user, command, args)
+	if err != nil {
+		return errorReply(err)
+	}
+	return reply
+}
+
+func (s *TelegramService) handleCallback(ctx context.Context, botID uint, chatID int64, data string) corePort.TelegramReply {
+	user, err := s.linkedUser(ctx, botID, chatID)
+	if err != nil {
+		return errorReply(err)
+	}
+	if user == nil || s.Commands == nil {
+		return corePort.TelegramReply{Text: "ยังไม่ได้เชื่อมต่อบัญชี"}
+	}
+	reply, err := s.Commands.HandleCallback(ctx, *user, data)
+	if err != nil {
+		return errorReply(err)
+	}
+	return reply
+}
+
+func (s *TelegramService) helpText(user *coreModels.User) string {
+	text := fmt.Sprintf("เชื่อมต่อกับบัญชี %s แล้ว", user.Username)
+	if s.Commands != nil {
+		text += "\n\n" + s.Commands.Help()
+	}
+	return text + "\n/unlink - ยกเลิกการเชื่อมต่อ"
+}
+
+// linkedUser คืน nil ถ้า chat นี้ยังไม่ได้ผูกกับผู้ใช้ผ่านบอท botID
+func (s *TelegramService) linkedUser(ctx context.Context, botID uint, chatID int64) (*coreModels.User, error) {
+	var link coreModels.TelegramLink
+	err := s.DB.WithContext(ctx).Where("bot_id = ? AND chat_id = ?", botID, chatID).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch telegram link: %w", err)
+	}
+
+	var user coreModels.User
+	err = s.DB.WithContext(ctx).Preload("Role").First(&user, link.UserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return &user, nil
+}
+
+// linkChat ใช้รหัสแบบครั้งเดียว ผูก chat กับผู้ใช้ผ่านบอท botID (แทนที่การผูกเดิมของทั้ง chat และผู้ใช้ในบอทนั้น)
+// บอทของร้าน (botID != 0) ผูกได้เฉพาะผู้ใช้ที่มี TenantUser ของร้านนั้น
+func (s *TelegramService) linkChat(ctx context.Context, botID uint, chatID int64, username, code string) corePort.TelegramReply {
+	var user coreModels.User
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var lc coreModels.TelegramLinkCode
+		if err := tx.
+			Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", hashLinkCode(code), now).
+			First(&lc).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("รหัสไม่ถูกต้องหรือหมดอายุแล้ว")
+			}
+			return err
+		}
+
+		// ใช้รหัสได้ครั้งเดียว แม้มีสอง request มาพร้อมกัน
+		res := tx.Model(&coreModels.TelegramLinkCode{}).
+			Where("id = ? AND used_at IS NULL", lc.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("รหัสไม่ถูกต้องหรือหมดอายุแล้ว")
+		}
+
+		if err := tx.First(&user, lc.UserID).Error; err != nil {
+			return fmt.Errorf("user not found")
+		}
+		// บอทของร้านผูกได้เฉพาะผู้ใช้ที่อยู่ในร้านนั้น
+		if botID != 0 {
+			var bot coreModels.TelegramBot
+			if err := tx.Select("id", "tenant_id").First(&bot, botID).Error; err != nil {
+				return fmt.Errorf("failed to fetch telegram bot: %w", err)
+			}
+			var member int64
+			if err := tx.Model(&coreModels.TenantUser{}).
+				Where("tenant_id = ? AND user_id = ?", bot.TenantID, lc.UserID).
+				Count(&member).Error; err != nil {
+				return err
+			}
+			if member == 0 {
+				return errors.New("บัญชีนี้ไม่ได้อยู่ในร้านของบอทนี้")
+			}
+		}
+		if err := tx.Where("bot_id = ? AND (chat_id = ? OR user_id = ?)", botID, chatID, lc.UserID).Delete(&coreModels.TelegramLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&coreModels.TelegramLink{BotID: botID, UserID: lc.UserID, ChatID: chatID, Username: username}).Error
+	})
+	if err != nil {
+		return errorReply(err)
+	}
+
+	text := fmt.Sprintf("เชื่อมต่อกับบัญชี %s เรียบร้อยแล้ว", user.Username)
+	if s.Commands != nil {
+		text += "\n\n" + s.Commands.Help()
+	}
+	return corePort.TelegramReply{Text: text}
+}
+
+func (s *TelegramService) CreateLinkCode(ctx context.Context, userID, tenantID uint) (*corePort.TelegramLinkCodeResponse, error) {
+	code, err := randomLinkCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate link code: %w", err)
+	}
+	expiresAt := time.Now().Add(telegramLinkCodeTTL)
+	var botUsername string
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&coreModels.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("user not found")
+		}
+		if tenantID != 0 {
+			if err := tx.Model(&coreModels.TenantUser{}).Where("tenant_id = ? AND user_id = ?", tenantID, userID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("user is not a member of this tenant")
+			}
+			// ร้านที่เปิดบอทของตัวเอง → ลิงก์ไปที่บอทร้าน (linkChat ตรวจสมาชิกซ้ำอีกครั้งตอนใช้รหัส)
+			var bot coreModels.TelegramBot
+			err := tx.Select("bot_username").Where("tenant_id = ? AND enabled = ?", tenantID, true).Limit(1).Find(&bot).Error
+			if err != nil {
+				return fmt.Errorf("failed to fetch telegram bot: %w", err)
+			}
+			botUsername = bot.BotUsername
+		}
+		// รหัสเก่าที่ยังไม่ได้ใช้ของผู้ใช้นี้ใช้ไม่ได้อีก
+		if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&coreModels.TelegramLinkCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&coreModels.TelegramLinkCode{
+			UserID:    userID,
+			CodeHash:  hashLinkCode(code),
+			ExpiresAt: expiresAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &corePort.TelegramLinkCodeResponse{Code: code, ExpiresAt: expiresAt}
+	if botUsername == "" {
+		botUsername = os.Getenv("TELEGRAM_BOT_USERNAME")
+	}
+	if botUsername != "" {
+		resp.DeepLink = fmt.Sprintf("https://t.me/%s?start=%s", botUsername, code)
+	}
+	return resp, nil
+}
+
+func (s *TelegramService) Unlink(ctx context.Context, userID uint) error {
+	res := s.DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&coreModels.TelegramLink{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to unlink telegram: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("telegram link not found")
+	}
+	return nil
 }
 
 func (s *TelegramService) SendTelegramMessage(chatID int64, message string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", os.Getenv("TELEGRAM_TOKEN"))
+	return s.sendReply(context.Background(), os.Getenv("TELEGRAM_TOKEN"), chatID, corePort.TelegramReply{Text: message})
+}
 
+func (s *TelegramService) sendReply(ctx context.Context, token string, chatID int64, reply corePort.TelegramReply) error {
+	if reply.Text == "" {
+		return nil
+	}
 	payload := map[string]interface{}{
 		"chat_id": chatID,
-		"text":    message,
+		"text":    reply.Text,
 	}
+	if len(reply.Buttons) > 0 {
+		payload["reply_markup"] = map[string]interface{}{"inline_keyboard": reply.Buttons}
+	}
+	return s.callBotAPI(ctx, token, "sendMessage", payload, nil)
+}
+
+// callBotAPI เรียก Bot API; ถ้า out ไม่เป็น nil จะ decode ฟิลด์ result ลงไป
+func (s *TelegramService) callBotAPI(ctx context.Context, token, method string, payload map[string]interface{}, out interface{}) error {
+	url := fmt.Sprintf("%s/bot%s/%s", s.BaseURL, token, method)
 	body, _ := json.Marshal(payload)
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -54,5 +386,52 @@ func (s *TelegramService) SendTelegramMessage(chatID int64, message string) erro
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("telegram error: %s", resp.Status)
 	}
-	return nil
+	if out == nil {
+		return nil
+	}
+	var envelope struct {
+		OK     bool            `json:"ok"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("invalid telegram response: %w", err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram error: %s returned not ok", method)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+// parseTelegramCommand "/cancel@my_bot 12" → ("cancel", ["12"]); ข้อความที่ไม่ใช่คำสั่งได้ command ว่าง
+func parseTelegramCommand(text string) (string, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", fields
+	}
+	command := strings.TrimPrefix(fields[0], "/")
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
+	}
+	return strings.ToLower(command), fields[1:]
+}
+
+func errorReply(err error) corePort.TelegramReply {
+	return corePort.TelegramReply{Text: "❌ " + err.Error()}
+}
+
+func randomLinkCode() (string, error) {
+	buf := make([]byte, telegramLinkCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = telegramLinkCodeAlphabet[int(b)%len(telegramLinkCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// รับรหัสได้ทั้งตัวพิมพ์เล็กและใหญ่
+func hashLinkCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
 }
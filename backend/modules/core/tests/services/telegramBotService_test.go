@@ -0,0 +1,262 @@
+package coreServicesTest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	coreModels "myapp/modules/core/models"
+	corePort "myapp/modules/core/port"
+	coreServices "myapp/modules/core/services"
+	"myapp/utils"
+)
+
+// fakeBotAPI จำลอง Bot API: getMe คืน username ตาม token, เก็บ setWebhook และ sendMessage ที่ถูกเรียก
+type fakeBotAPI struct {
+	mu       sync.Mutex
+	secrets  map[string]string // token → secret_token ล่าสุด
+	webhooks map[string]string // token → url
+	sent     []string          // "<token>:<text>"
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/bot"), "/", 2)
+	token, method := parts[0], parts[1]
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch method {
+	case "getMe":
+		if token == "bad" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"username":"` + strings.Split(token, ":")[0] + `_bot"}}`))
+		return
+	case "setWebhook":
+		f.secrets[token] = body["secret_token"].(string)
+		f.webhooks[token] = body["url"].(string)
+	case "deleteWebhook":
+		delete(f.webhooks, token)
+	case "sendMessage":
+		f.sent = append(f.sent, token+":"+body["text"].(string))
+	}
+	_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+}
+
+func setupTelegramBotTest(t *testing.T) (*gorm.DB, *coreServices.TelegramService, *fakeBotAPI) {
+	t.Helper()
+	t.Setenv("APP_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	t.Setenv("TELEGRAM_WEBHOOK_BASE_URL", "https://api.example.com/api/v1/core/telegram/")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(
+		&coreModels.Role{}, &coreModels.User{}, &coreModels.TenantUser{},
+		&coreModels.TelegramLink{}, &coreModels.TelegramLinkCode{},
+		&coreModels.TelegramBot{}, &coreModels.TelegramUpdate{},
+	))
+
+	api := &fakeBotAPI{secrets: map[string]string{}, webhooks: map[string]string{}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	svc := coreServices.NewTelegramService(db)
+	svc.BaseURL = srv.URL
+	return db, svc, api
+}
+
+func postUpdate(t *testing.T, app *fiber.App, path, secret string, updateID int64) int {
+	t.Helper()
+	return postText(t, app, path, secret, updateID, "/today")
+}
+
+func postText(t *testing.T, app *fiber.App, path, secret string, updateID int64, text string) int {
+	t.Helper()
+	body := `{"update_id":` + jsonInt(updateID) + `,"message":{"message_id":1,"text":` + jsonString(text) + `,"chat":{"id":42},"from":{"username":"someone"}}}`
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func jsonInt(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func jsonString(v string) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestTelegramTenantBot(t *testing.T) {
+	ctx := context.Background()
+	db, svc, api := setupTelegramBotTest(t)
+
+	app := fiber.New()
+	app.Post("/webhook", svc.ProcessWebhook)
+	app.Post("/webhook/:tenant_id", svc.ProcessTenantWebhook)
+
+	const token = "shop1:SECRET-TOKEN"
+
+	t.Run("Requires bot_token on first setup", func(t *testing.T) {
+		_, err := svc.UpsertTenantBot(ctx, 1, corePort.UpsertTelegramBotRequest{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bot_token is required")
+
+		_, err = svc.UpsertTenantBot(ctx, 1, corePort.UpsertTelegramBotRequest{BotToken: "bad"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid bot_token")
+	})
+
+	t.Run("Stores token encrypted and registers tenant webhook", func(t *testing.T) {
+		resp, err := svc.UpsertTenantBot(ctx, 1, corePort.UpsertTelegramBotRequest{BotToken: token})
+		require.NoError(t, err)
+		assert.Equal(t, "shop1_bot", resp.BotUsername)
+		assert.True(t, resp.Enabled)
+		assert.Equal(t, "https://api.example.com/api/v1/core/telegram/webhook/1", resp.WebhookURL)
+		assert.Equal(t, resp.WebhookURL, api.webhooks[token])
+
+		var bot coreModels.TelegramBot
+		require.NoError(t, db.Where("tenant_id = ?", 1).First(&bot).Error)
+		assert.NotContains(t, bot.TokenEncrypted, "SECRET-TOKEN")
+		plain, err := utils.DecryptSecret(bot.TokenEncrypted)
+		require.NoError(t, err)
+		assert.Equal(t, token, plain)
+		assert.NotEqual(t, api.secrets[token], bot.WebhookSecretHash)
+	})
+
+	t.Run("Same bot cannot be used by another tenant", func(t *testing.T) {
+		_, err := svc.UpsertTenantBot(ctx, 2, corePort.UpsertTelegramBotRequest{BotToken: token})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already used by another tenant")
+		// ไม่ย้าย webhook ของบอทไปที่ร้านอื่น
+		assert.Equal(t, "https://api.example.com/api/v1/core/telegram/webhook/1", api.webhooks[token])
+
+		// unique index กันกรณีที่ผ่านการตรวจมาพร้อมกัน
+		err = db.Create(&coreModels.TelegramBot{TenantID: 3, BotUsername: "shop1_bot", TokenEncrypted: "x", WebhookSecretHash: "x"}).Error
+		require.Error(t, err)
+	})
+
+	t.Run("Webhook verifies secret header", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusUnauthorized, postUpdate(t, app, "/webhook/1", "", 100))
+		assert.Equal(t, fiber.StatusUnauthorized, postUpdate(t, app, "/webhook/1", "wrong", 100))
+		assert.Equal(t, fiber.StatusNotFound, postUpdate(t, app, "/webhook/9", api.secrets[token], 100))
+		assert.Empty(t, api.sent)
+
+		assert.Equal(t, fiber.StatusOK, postUpdate(t, app, "/webhook/1", api.secrets[token], 100))
+		require.Len(t, api.sent, 1)
+		assert.True(t, strings.HasPrefix(api.sent[0], token+":"), "reply must be sent with the tenant bot token")
+	})
+
+	t.Run("Duplicate update_id is processed once", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusOK, postUpdate(t, app, "/webhook/1", api.secrets[token], 100))
+		assert.Len(t, api.sent, 1)
+
+		assert.Equal(t, fiber.StatusOK, postUpdate(t, app, "/webhook/1", api.secrets[token], 101))
+		assert.Len(t, api.sent, 2)
+	})
+
+	t.Run("Tenant bot only sees links made through it", func(t *testing.T) {
+		role := coreModels.Role{Name: string(coreModels.RoleNameUser)}
+		require.NoError(t, db.Create(&role).Error)
+		user := coreModels.User{Username: "barber-one", Email: "b1@example.com", Password: "x", PhoneNumber: "0811111111", RoleID: role.ID}
+		require.NoError(t, db.Create(&user).Error)
+		var bot coreModels.TelegramBot
+		require.NoError(t, db.Where("tenant_id = ?", 1).First(&bot).Error)
+
+		// ผูกผ่านบอทกลาง → บอทร้านยังไม่รู้จัก chat นี้
+		require.NoError(t, db.Create(&coreModels.TelegramLink{BotID: 0, UserID: user.ID, ChatID: 42}).Error)
+		assert.Equal(t, fiber.StatusOK, postUpdate(t, app, "/webhook/1", api.secrets[token], 110))
+		require.Len(t, api.sent, 3)
+		assert.Contains(t, api.sent[2], "ยังไม่ได้เชื่อมต่อบัญชี")
+
+		require.NoError(t, db.Create(&coreModels.TelegramLink{BotID: bot.ID, UserID: user.ID, ChatID: 42}).Error)
+		assert.Equal(t, fiber.StatusOK, postUpdate(t, app, "/webhook/1", api.secrets[token], 111))
+		require.Len(t, api.sent, 4)
+		assert.Contains(t, api.sent[3], "เชื่อมต่อกับบัญชี barber-one")
+	})
+
+	t.Run("Tenant bot links only members of its tenant", func(t *testing.T) {
+		user := coreModels.User{Username: "outsider", Email: "o@example.com", Password: "x", PhoneNumber: "0822222222", RoleID: 1}
+		require.NoError(t, db.Create(&user).Error)
+
+		_, err := svc.CreateLinkCode(ctx, user.ID, 1)
+		assert.ErrorContains(t, err, "not a member")
+		// รหัสจากบอทกลางถูกนำมาใช้กับบอทร้านที่ผู้ใช้ไม่ได้อยู่
+		code, err := svc.CreateLinkCode(ctx, user.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, postText(t, app, "/webhook/1", api.secrets[token], 120, "/start "+code.Code))
+		require.Len(t, api.sent, 5)
+		assert.Contains(t, api.sent[4], "ไม่ได้อยู่ในร้านของบอทนี้")
+		var links int64
+		db.Model(&coreModels.TelegramLink{}).Where("user_id = ?", user.ID).Count(&links)
+		assert.Zero(t, links)
+
+		require.NoError(t, db.Create(&coreModels.TenantUser{TenantID: 1, UserID: user.ID}).Error)
+		assert.Equal(t, fiber.StatusOK, postText(t, app, "/webhook/1", api.secrets[token], 121, "/start "+code.Code))
+		require.Len(t, api.sent, 6)
+		assert.Contains(t, api.sent[5], "เชื่อมต่อกับบัญชี outsider")
+
+		t.Setenv("TELEGRAM_BOT_USERNAME", "central_bot")
+		tenantCode, err := svc.CreateLinkCode(ctx, user.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "https://t.me/shop1_bot?start="+tenantCode.Code, tenantCode.DeepLink)
+		centralCode, err := svc.CreateLinkCode(ctx, user.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "https://t.me/central_bot?start="+centralCode.Code, centralCode.DeepLink)
+	})
+
+	t.Run("Disabled bot ignores updates", func(t *testing.T) {
+		resp, err := svc.UpsertTenantBot(ctx, 1, corePort.UpsertTelegramBotRequest{Enabled: ptrBool(false)})
+		require.NoError(t, err)
+		assert.False(t, resp.Enabled)
+
+		assert.Equal(t, fiber.StatusOK, postUpdate(t, app, "/webhook/1", api.secrets[token], 102))
+		assert.Len(t, api.sent, 6)
+	})
+
+	t.Run("Global webhook is rejected without configured secret", func(t *testing.T) {
+		assert.Equal(t, fiber.StatusForbidden, postUpdate(t, app, "/webhook", "anything", 1))
+
+		t.Setenv("TELEGRAM_WEBHOOK_SECRET", "global-secret")
+		assert.Equal(t, fiber.StatusUnauthorized, postUpdate(t, app, "/webhook", "anything", 1))
+	})
+
+	t.Run("Delete removes bot and webhook", func(t *testing.T) {
+		require.NoError(t, svc.DeleteTenantBot(ctx, 1))
+		assert.NotContains(t, api.webhooks, token)
+
+		_, err := svc.GetTenantBot(ctx, 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+		assert.Equal(t, fiber.StatusNotFound, postUpdate(t, app, "/webhook/1", api.secrets[token], 103))
+
+		var links int64
+		db.Model(&coreModels.TelegramLink{}).Where("bot_id <> 0").Count(&links)
+		assert.Zero(t, links)
+	})
+}
+
+func ptrBool(b bool) *bool { return &b }
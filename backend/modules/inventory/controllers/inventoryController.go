@@ -0,0 +1,458 @@
+package inventoryControllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
+	inventoryModels "myapp/modules/inventory/models"
+	inventoryPort "myapp/modules/inventory/port"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InventoryController struct {
+	Service inventoryPort.IInventoryService
+}
+
+func NewInventoryController(service inventoryPort.IInventoryService) *InventoryController {
+	return &InventoryController{Service: service}
+}
+
+// ดูสินค้าและยอดคงเหลือได้ทุกคนที่ใช้ POS
+var RolesCanViewInventory = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+	coreModels.RoleNameAssistantManager,
+	coreModels.RoleNameStaff,
+}
+
+// จัดการแคตตาล็อกและเคลื่อนไหวสต๊อก (รับของ ปรับยอด โอน) เฉพาะผู้จัดการ
+var RolesCanManageInventory = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+	coreModels.RoleNameAssistantManager,
+}
+
+func inventoryError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "already exists"):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+}
+
+func currentUserID(c *fiber.Ctx) *uint {
+	if id, ok := c.Locals("user_id").(uint); ok {
+		return &id
+	}
+	return nil
+}
+
+func optionalUintQuery(c *fiber.Ctx, name string) (*uint, error) {
+	qs := c.Query(name, "")
+	if qs == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseUint(qs, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	u := uint(v)
+	return &u, nil
+}
+
+// ListProducts godoc
+// @Summary      ดูแคตตาล็อกสินค้า
+// @Description  branch_id = สินค้าที่ขายได้ในสาขานั้น (รวมสินค้าทุกสาขา) q ค้นจากชื่อหรือ SKU barcode ค้นแบบตรงตัว
+// @Tags         Inventory
+// @Produce      json
+// @Param        tenant_id    path      uint    true   "รหัส Tenant"
+// @Param        branch_id    query     uint    false  "กรองตามสาขา"
+// @Param        q            query     string  false  "ชื่อหรือ SKU"
+// @Param        barcode      query     string  false  "บาร์โค้ด"
+// @Param        active_only  query     bool    false  "เฉพาะสินค้าที่เปิดขาย"
+// @Success      200          {array}   inventoryModels.Product
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/products [get]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) ListProducts(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanViewInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := optionalUintQuery(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	products, err := ctrl.Service.ListProducts(c.Context(), tenantID, inventoryPort.ProductFilter{
+		BranchID:   branchID,
+		Query:      c.Query("q", ""),
+		Barcode:    c.Query("barcode", ""),
+		ActiveOnly: c.QueryBool("active_only", false),
+	})
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": products})
+}
+
+// CreateProduct godoc
+// @Summary      เพิ่มสินค้า
+// @Description  SKU และบาร์โค้ดต้องไม่ซ้ำใน tenant ไม่ระบุ branch_id = ขายได้ทุกสาขา
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                true  "รหัส Tenant"
+// @Param        body       body      inventoryPort.CreateProductRequest  true  "ข้อมูลสินค้า"
+// @Success      201        {object}  inventoryModels.Product
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      409        {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/products [post]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) CreateProduct(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req inventoryPort.CreateProductRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	product, err := ctrl.Service.CreateProduct(c.Context(), tenantID, req)
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": product})
+}
+
+// GetProduct godoc
+// @Summary      ดูสินค้า
+// @Tags         Inventory
+// @Produce      json
+// @Param        tenant_id   path      uint  true  "รหัส Tenant"
+// @Param        product_id  path      uint  true  "รหัสสินค้า"
+// @Success      200         {object}  inventoryModels.Product
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/products/{product_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) GetProduct(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanViewInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	productID, err := helperFunc.ParseUintParam(c, "product_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid product_id"})
+	}
+
+	product, err := ctrl.Service.GetProduct(c.Context(), tenantID, productID)
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": product})
+}
+
+// UpdateProduct godoc
+// @Summary      แก้ไขสินค้า
+// @Description  ส่งเฉพาะฟิลด์ที่ต้องการแก้ ปิดขายด้วย is_active=false (ไม่ลบเพราะมีประวัติสต๊อก)
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id   path      uint                                true  "รหัส Tenant"
+// @Param        product_id  path      uint                                true  "รหัสสินค้า"
+// @Param        body        body      inventoryPort.UpdateProductRequest  true  "ฟิลด์ที่แก้"
+// @Success      200         {object}  inventoryModels.Product
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/products/{product_id} [put]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) UpdateProduct(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	productID, err := helperFunc.ParseUintParam(c, "product_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid product_id"})
+	}
+	var req inventoryPort.UpdateProductRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	product, err := ctrl.Service.UpdateProduct(c.Context(), tenantID, productID, req)
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": product})
+}
+
+// ReceiveStock godoc
+// @Summary      รับสินค้าเข้าสต๊อก
+// @Description  บันทึก movement RECEIVE ถ้าส่ง unit_cost จะอัปเดตต้นทุนสินค้าด้วย
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                               true  "รหัส Tenant"
+// @Param        body       body      inventoryPort.ReceiveStockRequest  true  "สาขา สินค้า และจำนวน"
+// @Success      201        {object}  inventoryModels.StockMovement
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/stock/receive [post]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) ReceiveStock(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req inventoryPort.ReceiveStockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	m, err := ctrl.Service.ReceiveStock(c.Context(), tenantID, req, currentUserID(c))
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": m})
+}
+
+// AdjustStock godoc
+// @Summary      ปรับยอดสต๊อก
+// @Description  quantity เป็นส่วนต่าง (+/-) ต้องระบุเหตุผล ยอดคงเหลือต้องไม่ติดลบ
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                              true  "รหัส Tenant"
+// @Param        body       body      inventoryPort.AdjustStockRequest  true  "ส่วนต่างและเหตุผล"
+// @Success      201        {object}  inventoryModels.StockMovement
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/stock/adjust [post]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) AdjustStock(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req inventoryPort.AdjustStockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	m, err := ctrl.Service.AdjustStock(c.Context(), tenantID, req, currentUserID(c))
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": m})
+}
+
+// TransferStock godoc
+// @Summary      โอนสต๊อกระหว่างสาขา
+// @Description  บันทึก TRANSFER_OUT ที่สาขาต้นทางและ TRANSFER_IN ที่สาขาปลายทาง (transfer_ref เดียวกัน)
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                true  "รหัส Tenant"
+// @Param        body       body      inventoryPort.TransferStockRequest  true  "สาขาต้นทาง ปลายทาง และจำนวน"
+// @Success      201        {array}   inventoryModels.StockMovement
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/stock/transfer [post]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) TransferStock(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req inventoryPort.TransferStockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	list, err := ctrl.Service.TransferStock(c.Context(), tenantID, req, currentUserID(c))
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": list})
+}
+
+// ListMovements godoc
+// @Summary      ดูประวัติการเคลื่อนไหวสต๊อก
+// @Tags         Inventory
+// @Produce      json
+// @Param        tenant_id   path      uint    true   "รหัส Tenant"
+// @Param        branch_id   query     uint    false  "กรองตามสาขา"
+// @Param        product_id  query     uint    false  "กรองตามสินค้า"
+// @Param        type        query     string  false  "RECEIVE, SALE, RETURN, ADJUSTMENT, TRANSFER_OUT หรือ TRANSFER_IN"
+// @Param        from        query     string  false  "ตั้งแต่ (RFC3339)"
+// @Param        to          query     string  false  "ถึงก่อน (RFC3339)"
+// @Param        limit       query     int     false  "จำนวนสูงสุด (ค่าเริ่มต้น 100 สูงสุด 500)"
+// @Param        offset      query     int     false  "ข้ามกี่รายการ"
+// @Success      200         {array}   inventoryModels.StockMovement
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/stock/movements [get]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) ListMovements(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var filter inventoryPort.MovementFilter
+	if filter.BranchID, err = optionalUintQuery(c, "branch_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+	if filter.ProductID, err = optionalUintQuery(c, "product_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid product_id"})
+	}
+	filter.Type = inventoryModels.MovementType(strings.ToUpper(c.Query("type", "")))
+	if qs := c.Query("from", ""); qs != "" {
+		t, err := time.Parse(time.RFC3339, qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid from (expected RFC3339)"})
+		}
+		filter.From = &t
+	}
+	if qs := c.Query("to", ""); qs != "" {
+		t, err := time.Parse(time.RFC3339, qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid to (expected RFC3339)"})
+		}
+		filter.To = &t
+	}
+	filter.Limit = c.QueryInt("limit", 0)
+	filter.Offset = c.QueryInt("offset", 0)
+
+	list, err := ctrl.Service.ListMovements(c.Context(), tenantID, filter)
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": list})
+}
+
+// GetStockLevels godoc
+// @Summary      ดูยอดคงเหลือของสาขา
+// @Description  คำนวณจากประวัติการเคลื่อนไหว low_only=true แสดงเฉพาะสินค้าที่คงเหลือ <= จุดสั่งซื้อ
+// @Tags         Inventory
+// @Produce      json
+// @Param        tenant_id  path      uint  true   "รหัส Tenant"
+// @Param        branch_id  path      uint  true   "รหัสสาขา"
+// @Param        low_only   query     bool  false  "เฉพาะสต๊อกต่ำ"
+// @Success      200        {array}   inventoryModels.StockLevel
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/branches/{branch_id}/stock [get]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) GetStockLevels(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanViewInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	levels, err := ctrl.Service.GetStockLevels(c.Context(), tenantID, branchID, c.QueryBool("low_only", false))
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": levels})
+}
+
+// SetThreshold godoc
+// @Summary      ตั้งจุดสั่งซื้อของสินค้าในสาขา
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                               true  "รหัส Tenant"
+// @Param        body       body      inventoryPort.SetThresholdRequest  true  "สาขา สินค้า และจุดสั่งซื้อ"
+// @Success      200        {object}  inventoryModels.StockThreshold
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /inventory/tenants/{tenant_id}/stock/thresholds [put]
+// @Security     ApiKeyAuth
+func (ctrl *InventoryController) SetThreshold(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageInventory) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req inventoryPort.SetThresholdRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	t, err := ctrl.Service.SetThreshold(c.Context(), tenantID, req)
+	if err != nil {
+		return inventoryError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": t})
+}
@@ -0,0 +1,79 @@
+package inventoryModels
+
+import (
+	"time"
+
+	posModels "myapp/modules/pos/models"
+)
+
+// Product สินค้าขายปลีก (เช่น pomade แชมพู หวี) ของ tenant
+// BranchID ว่าง = ขายได้ทุกสาขา ไม่ว่างคือเฉพาะสาขานั้น
+// SKU ไม่ซ้ำภายใน tenant, Barcode (ถ้ามี) ไม่ซ้ำภายใน tenant
+type Product struct {
+	ID       uint  `gorm:"primaryKey" json:"id"`
+	TenantID uint  `gorm:"not null;uniqueIndex:uq_products_tenant_sku;index:uq_products_tenant_barcode,unique,where:barcode <> ''" json:"tenant_id"`
+	BranchID *uint `gorm:"index" json:"branch_id,omitempty"`
+
+	SKU         string          `gorm:"type:varchar(64);not null;uniqueIndex:uq_products_tenant_sku" json:"sku"`
+	Barcode     string          `gorm:"type:varchar(64);index:uq_products_tenant_barcode,unique,where:barcode <> ''" json:"barcode,omitempty"`
+	Name        string          `gorm:"type:varchar(200);not null" json:"name"`
+	Description string          `gorm:"type:text" json:"description,omitempty"`
+	Cost        posModels.Money `gorm:"type:numeric(12,2);not null;default:0" json:"cost"`  // ต้นทุนต่อหน่วย (ล่าสุด)
+	Price       posModels.Money `gorm:"type:numeric(12,2);not null;default:0" json:"price"` // ราคาขายต่อหน่วย
+	IsActive    bool            `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MovementType string
+
+const (
+	MovementReceive     MovementType = "RECEIVE"      // รับของเข้า
+	MovementSale        MovementType = "SALE"         // ขายผ่าน POS (ติดลบ)
+	MovementReturn      MovementType = "RETURN"       // คืนเข้าสต๊อกเมื่อลบรายการ/VOID บิล
+	MovementAdjustment  MovementType = "ADJUSTMENT"   // ปรับยอดหลังนับสต๊อก ของเสีย/หาย
+	MovementTransferOut MovementType = "TRANSFER_OUT" // โอนออกไปสาขาอื่น (ติดลบ)
+	MovementTransferIn  MovementType = "TRANSFER_IN"  // รับโอนจากสาขาอื่น
+)
+
+// StockMovement สมุดบัญชีสต๊อกแบบเพิ่มอย่างเดียว (ห้ามแก้/ลบ แก้ด้วยการบันทึก ADJUSTMENT)
+// ยอดคงเหลือของสาขา = ผลรวม Quantity ของสินค้านั้นในสาขา
+type StockMovement struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	TenantID  uint         `gorm:"not null;index" json:"tenant_id"`
+	BranchID  uint         `gorm:"not null;index:idx_stock_movements_branch_product" json:"branch_id"`
+	ProductID uint         `gorm:"not null;index:idx_stock_movements_branch_product" json:"product_id"`
+	Type      MovementType `gorm:"type:varchar(20);not null" json:"type"`
+	Quantity  int          `gorm:"not null" json:"quantity"` // บวก = เข้า, ลบ = ออก
+
+	UnitCost             *posModels.Money `gorm:"type:numeric(12,2)" json:"unit_cost,omitempty"`        // เฉพาะ RECEIVE
+	SaleID               *uint            `gorm:"index" json:"sale_id,omitempty"`                       // SALE/RETURN
+	SaleItemID           *uint            `json:"sale_item_id,omitempty"`                               // SALE/RETURN
+	CounterpartyBranchID *uint            `json:"counterparty_branch_id,omitempty"`                     // สาขาปลายทาง/ต้นทางของการโอน
+	TransferRef          string           `gorm:"type:varchar(36);index" json:"transfer_ref,omitempty"` // คู่ TRANSFER_OUT/TRANSFER_IN ใช้ค่าเดียวกัน
+	Reason               string           `gorm:"type:text" json:"reason,omitempty"`
+	CreatedBy            *uint            `json:"created_by,omitempty"`
+	CreatedAt            time.Time        `gorm:"index" json:"created_at"`
+}
+
+// StockThreshold จุดสั่งซื้อของสินค้าในแต่ละสาขา คงเหลือ <= ReorderLevel ถือว่าสต๊อกต่ำ
+type StockThreshold struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TenantID     uint      `gorm:"not null;index" json:"tenant_id"`
+	BranchID     uint      `gorm:"not null;uniqueIndex:uq_stock_thresholds_branch_product" json:"branch_id"`
+	ProductID    uint      `gorm:"not null;uniqueIndex:uq_stock_thresholds_branch_product" json:"product_id"`
+	ReorderLevel int       `gorm:"not null;default:0" json:"reorder_level"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// StockLevel ยอดคงเหลือที่คำนวณจาก StockMovement (ไม่ได้เก็บเป็นตาราง)
+type StockLevel struct {
+	BranchID     uint   `json:"branch_id"`
+	ProductID    uint   `json:"product_id"`
+	SKU          string `json:"sku"`
+	Name         string `json:"name"`
+	OnHand       int    `json:"on_hand"`
+	ReorderLevel *int   `json:"reorder_level,omitempty"`
+	LowStock     bool   `json:"low_stock"`
+}
@@ -0,0 +1,94 @@
+package inventoryPort
+
+import (
+	"context"
+	"time"
+
+	inventoryModels "myapp/modules/inventory/models"
+	posModels "myapp/modules/pos/models"
+)
+
+type CreateProductRequest struct {
+	BranchID    *uint           `json:"branch_id,omitempty" example:"1"` // ว่าง = ทุกสาขา
+	SKU         string          `json:"sku" example:"POM-001"`
+	Barcode     string          `json:"barcode,omitempty" example:"8850000000011"`
+	Name        string          `json:"name" example:"Pomade Strong Hold"`
+	Description string          `json:"description,omitempty"`
+	Cost        posModels.Money `json:"cost" swaggertype:"number" example:"120"`
+	Price       posModels.Money `json:"price" swaggertype:"number" example:"250"`
+}
+
+// UpdateProductRequest ส่งเฉพาะฟิลด์ที่ต้องการแก้
+type UpdateProductRequest struct {
+	SKU         *string          `json:"sku,omitempty"`
+	Barcode     *string          `json:"barcode,omitempty"`
+	Name        *string          `json:"name,omitempty"`
+	Description *string          `json:"description,omitempty"`
+	Cost        *posModels.Money `json:"cost,omitempty" swaggertype:"number"`
+	Price       *posModels.Money `json:"price,omitempty" swaggertype:"number"`
+	IsActive    *bool            `json:"is_active,omitempty"`
+}
+
+type ProductFilter struct {
+	BranchID   *uint  // สินค้าที่ขายได้ในสาขานี้ (รวมสินค้าทุกสาขา)
+	Query      string // ค้นจากชื่อหรือ SKU
+	Barcode    string
+	ActiveOnly bool
+}
+
+type ReceiveStockRequest struct {
+	BranchID  uint             `json:"branch_id" example:"1"`
+	ProductID uint             `json:"product_id" example:"1"`
+	Quantity  int              `json:"quantity" example:"24"`
+	UnitCost  *posModels.Money `json:"unit_cost,omitempty" swaggertype:"number" example:"115"` // ถ้าส่งจะอัปเดตต้นทุนสินค้า
+	Reason    string           `json:"reason,omitempty" example:"PO-2026-001"`
+}
+
+// AdjustStockRequest quantity เป็นส่วนต่าง (+/-) เช่น -2 คือของเสีย 2 ชิ้น
+type AdjustStockRequest struct {
+	BranchID  uint   `json:"branch_id" example:"1"`
+	ProductID uint   `json:"product_id" example:"1"`
+	Quantity  int    `json:"quantity" example:"-2"`
+	Reason    string `json:"reason" example:"ของเสียหาย"`
+}
+
+type TransferStockRequest struct {
+	FromBranchID uint   `json:"from_branch_id" example:"1"`
+	ToBranchID   uint   `json:"to_branch_id" example:"2"`
+	ProductID    uint   `json:"product_id" example:"1"`
+	Quantity     int    `json:"quantity" example:"5"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+type SetThresholdRequest struct {
+	BranchID     uint `json:"branch_id" example:"1"`
+	ProductID    uint `json:"product_id" example:"1"`
+	ReorderLevel int  `json:"reorder_level" example:"5"`
+}
+
+type MovementFilter struct {
+	BranchID  *uint
+	ProductID *uint
+	Type      inventoryModels.MovementType
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
+}
+
+type IInventoryService interface {
+	CreateProduct(ctx context.Context, tenantID uint, req CreateProductRequest) (*inventoryModels.Product, error)
+	UpdateProduct(ctx context.Context, tenantID, productID uint, req UpdateProductRequest) (*inventoryModels.Product, error)
+	GetProduct(ctx context.Context, tenantID, productID uint) (*inventoryModels.Product, error)
+	ListProducts(ctx context.Context, tenantID uint, filter ProductFilter) ([]inventoryModels.Product, error)
+
+	ReceiveStock(ctx context.Context, tenantID uint, req ReceiveStockRequest, actorID *uint) (*inventoryModels.StockMovement, error)
+	AdjustStock(ctx context.Context, tenantID uint, req AdjustStockRequest, actorID *uint) (*inventoryModels.StockMovement, error)
+	// โอนระหว่างสาขา บันทึก TRANSFER_OUT และ TRANSFER_IN คู่กันใน transaction เดียว
+	TransferStock(ctx context.Context, tenantID uint, req TransferStockRequest, actorID *uint) ([]inventoryModels.StockMovement, error)
+	ListMovements(ctx context.Context, tenantID uint, filter MovementFilter) ([]inventoryModels.StockMovement, error)
+
+	// ยอดคงเหลือต่อสาขา lowOnly = เฉพาะที่ต่ำกว่าหรือเท่ากับจุดสั่งซื้อ
+	GetStockLevels(ctx context.Context, tenantID, branchID uint, lowOnly bool) ([]inventoryModels.StockLevel, error)
+	SetThreshold(ctx context.Context, tenantID uint, req SetThresholdRequest) (*inventoryModels.StockThreshold, error)
+}
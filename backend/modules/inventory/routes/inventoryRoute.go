@@ -0,0 +1,28 @@
+package inventoryRoutes
+
+import (
+	middlewares "myapp/middlewares"
+	coremiddlewares "myapp/modules/core/middlewares"
+	coreModels "myapp/modules/core/models"
+	inventoryControllers "myapp/modules/inventory/controllers"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterInventoryRoutes(router fiber.Router, db *gorm.DB, ctrl *inventoryControllers.InventoryController) {
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant(), coremiddlewares.RequireModule(db, coreModels.ModuleInventory))
+
+	group.Get("/products", ctrl.ListProducts)
+	group.Post("/products", ctrl.CreateProduct)
+	group.Get("/products/:product_id", ctrl.GetProduct)
+	group.Put("/products/:product_id", ctrl.UpdateProduct)
+
+	group.Post("/stock/receive", ctrl.ReceiveStock)
+	group.Post("/stock/adjust", ctrl.AdjustStock)
+	group.Post("/stock/transfer", ctrl.TransferStock)
+	group.Get("/stock/movements", ctrl.ListMovements)
+	group.Put("/stock/thresholds", ctrl.SetThreshold)
+	group.Get("/branches/:branch_id/stock", ctrl.GetStockLevels)
+}
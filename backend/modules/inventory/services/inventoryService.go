@@ -0,0 +1,430 @@
+package inventoryServices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	coreModels "myapp/modules/core/models"
+	inventoryModels "myapp/modules/inventory/models"
+	inventoryPort "myapp/modules/inventory/port"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMovementListLimit = 100
+	maxMovementListLimit     = 500
+)
+
+type InventoryService struct {
+	DB *gorm.DB
+}
+
+func NewInventoryService(db *gorm.DB) *InventoryService {
+	return &InventoryService{DB: db}
+}
+
+func (s *InventoryService) CreateProduct(ctx context.Context, tenantID uint, req inventoryPort.CreateProductRequest) (*inventoryModels.Product, error) {
+	p := inventoryModels.Product{
+		TenantID:    tenantID,
+		BranchID:    req.BranchID,
+		SKU:         strings.TrimSpace(req.SKU),
+		Barcode:     strings.TrimSpace(req.Barcode),
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Cost:        req.Cost,
+		Price:       req.Price,
+		IsActive:    true,
+	}
+	if err := validateProduct(&p); err != nil {
+		return nil, err
+	}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if p.BranchID != nil {
+			if err := ensureBranch(tx, tenantID, *p.BranchID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&p).Error; err != nil {
+			return productWriteError(err, &p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *InventoryService) UpdateProduct(ctx context.Context, tenantID, productID uint, req inventoryPort.UpdateProductRequest) (*inventoryModels.Product, error) {
+	var p inventoryModels.Product
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockProductTx(tx, tenantID, productID)
+		if err != nil {
+			return err
+		}
+		p = *locked
+		if req.SKU != nil {
+			p.SKU = strings.TrimSpace(*req.SKU)
+		}
+		if req.Barcode != nil {
+			p.Barcode = strings.TrimSpace(*req.Barcode)
+		}
+		if req.Name != nil {
+			p.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Description != nil {
+			p.Description = *req.Description
+		}
+		if req.Cost != nil {
+			p.Cost = *req.Cost
+		}
+		if req.Price != nil {
+			p.Price = *req.Price
+		}
+		if req.IsActive != nil {
+			p.IsActive = *req.IsActive
+		}
+		if err := validateProduct(&p); err != nil {
+			return err
+		}
+		if err := tx.Model(&p).Select("SKU", "Barcode", "Name", "Description", "Cost", "Price", "IsActive").Updates(&p).Error; err != nil {
+			return productWriteError(err, &p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *InventoryService) GetProduct(ctx context.Context, tenantID, productID uint) (*inventoryModels.Product, error) {
+	var p inventoryModels.Product
+	if err := s.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", productID, tenantID).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("product with ID %d not found", productID)
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+	return &p, nil
+}
+
+func (s *InventoryService) ListProducts(ctx context.Context, tenantID uint, filter inventoryPort.ProductFilter) ([]inventoryModels.Product, error) {
+	q := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filter.BranchID != nil {
+		q = q.Where("branch_id IS NULL OR branch_id = ?", *filter.BranchID)
+	}
+	if filter.Barcode != "" {
+		q = q.Where("barcode = ?", filter.Barcode)
+	}
+	if qs := strings.TrimSpace(filter.Query); qs != "" {
+		like := "%" + strings.ToLower(qs) + "%"
+		q = q.Where("LOWER(name) LIKE ? OR LOWER(sku) LIKE ?", like, like)
+	}
+	if filter.ActiveOnly {
+		q = q.Where("is_active = ?", true)
+	}
+	var products []inventoryModels.Product
+	if err := q.Order("name, id").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	return products, nil
+}
+
+func (s *InventoryService) ReceiveStock(ctx context.Context, tenantID uint, req inventoryPort.ReceiveStockRequest, actorID *uint) (*inventoryModels.StockMovement, error) {
+	if req.Quantity <= 0 {
+		return nil, errors.New("invalid quantity: must be greater than 0")
+	}
+	if req.UnitCost != nil && *req.UnitCost < 0 {
+		return nil, errors.New("invalid unit_cost: must not be negative")
+	}
+	var m inventoryModels.StockMovement
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, req.BranchID); err != nil {
+			return err
+		}
+		p, err := lockProductTx(tx, tenantID, req.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := ensureStockedAt(p, req.BranchID); err != nil {
+			return err
+		}
+		m = inventoryModels.StockMovement{
+			TenantID:  tenantID,
+			BranchID:  req.BranchID,
+			ProductID: p.ID,
+			Type:      inventoryModels.MovementReceive,
+			Quantity:  req.Quantity,
+			UnitCost:  req.UnitCost,
+			Reason:    strings.TrimSpace(req.Reason),
+			CreatedBy: actorID,
+		}
+		if err := createMovement(tx, &m); err != nil {
+			return err
+		}
+		if req.UnitCost != nil && *req.UnitCost != p.Cost {
+			if err := tx.Model(p).Update("cost", *req.UnitCost).Error; err != nil {
+				return fmt.Errorf("failed to update product cost: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *InventoryService) AdjustStock(ctx context.Context, tenantID uint, req inventoryPort.AdjustStockRequest, actorID *uint) (*inventoryModels.StockMovement, error) {
+	if req.Quantity == 0 {
+		return nil, errors.New("invalid quantity: must not be 0")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("invalid reason: required")
+	}
+	var m inventoryModels.StockMovement
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, req.BranchID); err != nil {
+			return err
+		}
+		p, err := lockProductTx(tx, tenantID, req.ProductID)
+		if err != nil {
+			return err
+		}
+		if req.Quantity < 0 {
+			onHand, err := OnHandTx(tx, req.BranchID, p.ID)
+			if err != nil {
+				return err
+			}
+			if onHand+req.Quantity < 0 {
+				return fmt.Errorf("invalid quantity: adjustment would make stock negative (on hand %d)", onHand)
+			}
+		}
+		m = inventoryModels.StockMovement{
+			TenantID:  tenantID,
+			BranchID:  req.BranchID,
+			ProductID: p.ID,
+			Type:      inventoryModels.MovementAdjustment,
+			Quantity:  req.Quantity,
+			Reason:    reason,
+			CreatedBy: actorID,
+		}
+		return createMovement(tx, &m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *InventoryService) TransferStock(ctx context.Context, tenantID uint, req inventoryPort.TransferStockRequest, actorID *uint) ([]inventoryModels.StockMovement, error) {
+	if req.Quantity <= 0 {
+		return nil, errors.New("invalid quantity: must be greater than 0")
+	}
+	if req.FromBranchID == req.ToBranchID {
+		return nil, errors.New("invalid transfer: source and destination branch are the same")
+	}
+	var out []inventoryModels.StockMovement
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, req.FromBranchID); err != nil {
+			return err
+		}
+		if err := ensureBranch(tx, tenantID, req.ToBranchID); err != nil {
+			return err
+		}
+		p, err := lockProductTx(tx, tenantID, req.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := ensureStockedAt(p, req.ToBranchID); err != nil {
+			return err
+		}
+		onHand, err := OnHandTx(tx, req.FromBranchID, p.ID)
+		if err != nil {
+			return err
+		}
+		if onHand < req.Quantity {
+			return fmt.Errorf("invalid quantity: insufficient stock for %s (on hand %d, requested %d)", p.SKU, onHand, req.Quantity)
+		}
+
+		ref := uuid.NewString()
+		reason := strings.TrimSpace(req.Reason)
+		from, to := req.FromBranchID, req.ToBranchID
+		out = []inventoryModels.StockMovement{
+			{TenantID: tenantID, BranchID: from, ProductID: p.ID, Type: inventoryModels.MovementTransferOut, Quantity: -req.Quantity, CounterpartyBranchID: &to, TransferRef: ref, Reason: reason, CreatedBy: actorID},
+			{TenantID: tenantID, BranchID: to, ProductID: p.ID, Type: inventoryModels.MovementTransferIn, Quantity: req.Quantity, CounterpartyBranchID: &from, TransferRef: ref, Reason: reason, CreatedBy: actorID},
+		}
+		for i := range out {
+			if err := createMovement(tx, &out[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *InventoryService) ListMovements(ctx context.Context, tenantID uint, filter inventoryPort.MovementFilter) ([]inventoryModels.StockMovement, error) {
+	q := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filter.BranchID != nil {
+		q = q.Where("branch_id = ?", *filter.BranchID)
+	}
+	if filter.ProductID != nil {
+		q = q.Where("product_id = ?", *filter.ProductID)
+	}
+	if filter.Type != "" {
+		q = q.Where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		q = q.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("created_at < ?", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultMovementListLimit
+	}
+	if limit > maxMovementListLimit {
+		limit = maxMovementListLimit
+	}
+	var list []inventoryModels.StockMovement
+	if err := q.Order("created_at DESC, id DESC").Limit(limit).Offset(filter.Offset).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch stock movements: %w", err)
+	}
+	return list, nil
+}
+
+func (s *InventoryService) GetStockLevels(ctx context.Context, tenantID, branchID uint, lowOnly bool) ([]inventoryModels.StockLevel, error) {
+	db := s.DB.WithContext(ctx)
+	if err := ensureBranch(db, tenantID, branchID); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ProductID    uint
+		SKU          string
+		Name         string
+		OnHand       int
+		ReorderLevel *int
+	}
+	if err := db.Table("products p").
+		Select(`p.id AS product_id, p.sku, p.name,
+			COALESCE((SELECT SUM(m.quantity) FROM stock_movements m WHERE m.branch_id = ? AND m.product_id = p.id), 0) AS on_hand,
+			t.reorder_level`, branchID).
+		Joins("LEFT JOIN stock_thresholds t ON t.product_id = p.id AND t.branch_id = ?", branchID).
+		Where("p.tenant_id = ? AND p.is_active = ? AND (p.branch_id IS NULL OR p.branch_id = ?)", tenantID, true, branchID).
+		Order("p.name, p.id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute stock levels: %w", err)
+	}
+
+	levels := make([]inventoryModels.StockLevel, 0, len(rows))
+	for _, r := range rows {
+		low := r.ReorderLevel != nil && r.OnHand <= *r.ReorderLevel
+		if lowOnly && !low {
+			continue
+		}
+		levels = append(levels, inventoryModels.StockLevel{
+			BranchID:     branchID,
+			ProductID:    r.ProductID,
+			SKU:          r.SKU,
+			Name:         r.Name,
+			OnHand:       r.OnHand,
+			ReorderLevel: r.ReorderLevel,
+			LowStock:     low,
+		})
+	}
+	return levels, nil
+}
+
+func (s *InventoryService) SetThreshold(ctx context.Context, tenantID uint, req inventoryPort.SetThresholdRequest) (*inventoryModels.StockThreshold, error) {
+	if req.ReorderLevel < 0 {
+		return nil, errors.New("invalid reorder_level: must not be negative")
+	}
+	var t inventoryModels.StockThreshold
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, req.BranchID); err != nil {
+			return err
+		}
+		p, err := lockProductTx(tx, tenantID, req.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := ensureStockedAt(p, req.BranchID); err != nil {
+			return err
+		}
+		err = tx.Where("branch_id = ? AND product_id = ?", req.BranchID, p.ID).First(&t).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			t = inventoryModels.StockThreshold{TenantID: tenantID, BranchID: req.BranchID, ProductID: p.ID, ReorderLevel: req.ReorderLevel}
+			if err := tx.Create(&t).Error; err != nil {
+				return fmt.Errorf("failed to create stock threshold: %w", err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to fetch stock threshold: %w", err)
+		default:
+			t.ReorderLevel = req.ReorderLevel
+			if err := tx.Model(&t).Update("reorder_level", req.ReorderLevel).Error; err != nil {
+				return fmt.Errorf("failed to update stock threshold: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func validateProduct(p *inventoryModels.Product) error {
+	if p.SKU == "" {
+		return errors.New("invalid sku: required")
+	}
+	if p.Name == "" {
+		return errors.New("invalid name: required")
+	}
+	if p.Cost < 0 || p.Price < 0 {
+		return errors.New("invalid price: cost and price must not be negative")
+	}
+	return nil
+}
+
+func productWriteError(err error, p *inventoryModels.Product) error {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "duplicate key") || strings.Contains(msg, "unique constraint") {
+		if strings.Contains(msg, "barcode") {
+			return fmt.Errorf("product with barcode %s already exists", p.Barcode)
+		}
+		return fmt.Errorf("product with SKU %s already exists", p.SKU)
+	}
+	return fmt.Errorf("failed to save product: %w", err)
+}
+
+// ensureStockedAt สินค้าเฉพาะสาขาเก็บสต๊อกได้เฉพาะสาขาของมัน
+func ensureStockedAt(p *inventoryModels.Product, branchID uint) error {
+	if p.BranchID != nil && *p.BranchID != branchID {
+		return fmt.Errorf("invalid product: %s is not sold at branch %d", p.SKU, branchID)
+	}
+	return nil
+}
+
+func ensureBranch(tx *gorm.DB, tenantID, branchID uint) error {
+	var count int64
+	if err := tx.Model(&coreModels.Branch{}).Where("id = ? AND tenant_id = ?", branchID, tenantID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to fetch branch: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("branch with ID %d not found", branchID)
+	}
+	return nil
+}
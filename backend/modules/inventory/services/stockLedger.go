@@ -0,0 +1,113 @@
+package inventoryServices
+
+import (
+	"errors"
+	"fmt"
+
+	inventoryModels "myapp/modules/inventory/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ฟังก์ชัน *Tx ในไฟล์นี้ใช้ร่วมกับ module อื่น (POS) ภายใน transaction ของผู้เรียก
+// ทุกการเขียน movement ล็อกแถว products ก่อน เพื่อให้การตรวจยอดคงเหลือกับการบันทึกไม่แทรกกัน
+
+// SellableProductTx สินค้าที่ active และขายได้ในสาขานี้ (สินค้าทุกสาขาหรือของสาขานี้)
+func SellableProductTx(tx *gorm.DB, tenantID, branchID, productID uint) (*inventoryModels.Product, error) {
+	var p inventoryModels.Product
+	if err := tx.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("product with ID %d not found", productID)
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+	if !p.IsActive {
+		return nil, fmt.Errorf("invalid product: %s is inactive", p.SKU)
+	}
+	if err := ensureStockedAt(&p, branchID); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// RecordSaleTx ตัดสต๊อกจากการขาย ไม่ยอมให้ยอดคงเหลือติดลบ
+func RecordSaleTx(tx *gorm.DB, tenantID, branchID, productID uint, quantity int, saleID, saleItemID uint, actorID *uint) error {
+	if quantity <= 0 {
+		return errors.New("invalid quantity: must be greater than 0")
+	}
+	p, err := lockProductTx(tx, tenantID, productID)
+	if err != nil {
+		return err
+	}
+	onHand, err := OnHandTx(tx, branchID, productID)
+	if err != nil {
+		return err
+	}
+	if onHand < quantity {
+		return fmt.Errorf("invalid quantity: insufficient stock for %s (on hand %d, requested %d)", p.SKU, onHand, quantity)
+	}
+	return createMovement(tx, &inventoryModels.StockMovement{
+		TenantID:   tenantID,
+		BranchID:   branchID,
+		ProductID:  productID,
+		Type:       inventoryModels.MovementSale,
+		Quantity:   -quantity,
+		SaleID:     &saleID,
+		SaleItemID: &saleItemID,
+		CreatedBy:  actorID,
+	})
+}
+
+// RecordReturnTx คืนสต๊อกของรายการขายที่ถูกลบหรือบิลที่ VOID
+func RecordReturnTx(tx *gorm.DB, tenantID, branchID, productID uint, quantity int, saleID, saleItemID uint, reason string, actorID *uint) error {
+	if quantity <= 0 {
+		return errors.New("invalid quantity: must be greater than 0")
+	}
+	if _, err := lockProductTx(tx, tenantID, productID); err != nil {
+		return err
+	}
+	return createMovement(tx, &inventoryModels.StockMovement{
+		TenantID:   tenantID,
+		BranchID:   branchID,
+		ProductID:  productID,
+		Type:       inventoryModels.MovementReturn,
+		Quantity:   quantity,
+		SaleID:     &saleID,
+		SaleItemID: &saleItemID,
+		Reason:     reason,
+		CreatedBy:  actorID,
+	})
+}
+
+// OnHandTx ยอดคงเหลือของสินค้าในสาขา = ผลรวม movement
+func OnHandTx(tx *gorm.DB, branchID, productID uint) (int, error) {
+	var onHand int
+	if err := tx.Model(&inventoryModels.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("branch_id = ? AND product_id = ?", branchID, productID).
+		Scan(&onHand).Error; err != nil {
+		return 0, fmt.Errorf("failed to compute stock on hand: %w", err)
+	}
+	return onHand, nil
+}
+
+func lockProductTx(tx *gorm.DB, tenantID, productID uint) (*inventoryModels.Product, error) {
+	var p inventoryModels.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", productID, tenantID).
+		First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("product with ID %d not found", productID)
+		}
+		return nil, fmt.Errorf("failed to fetch product: %w", err)
+	}
+	return &p, nil
+}
+
+func createMovement(tx *gorm.DB, m *inventoryModels.StockMovement) error {
+	if err := tx.Create(m).Error; err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	return nil
+}
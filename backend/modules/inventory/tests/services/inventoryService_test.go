@@ -0,0 +1,195 @@
+package inventoryServiceTest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	coreModels "myapp/modules/core/models"
+	inventoryModels "myapp/modules/inventory/models"
+	inventoryPort "myapp/modules/inventory/port"
+	inventoryServices "myapp/modules/inventory/services"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+)
+
+func setupInventoryDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// :memory: แยกฐานข้อมูลต่อ connection
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(
+		&coreModels.Branch{},
+		&barberBookingModels.Service{},
+		&barberBookingModels.Barber{},
+		&barberBookingModels.Customer{},
+		&posModels.Sale{},
+		&posModels.SaleItem{},
+		&posModels.Payment{},
+		&posModels.GatewayPayment{},
+		&inventoryModels.Product{},
+		&inventoryModels.StockMovement{},
+		&inventoryModels.StockThreshold{},
+	))
+	return db
+}
+
+func TestInventoryService(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uint(1)
+	actor := uint(9)
+
+	setup := func(t *testing.T) (*gorm.DB, *inventoryServices.InventoryService, uint, uint, *inventoryModels.Product) {
+		db := setupInventoryDB(t)
+		b1 := coreModels.Branch{TenantID: tenantID, Name: "Siam"}
+		b2 := coreModels.Branch{TenantID: tenantID, Name: "Ari"}
+		require.NoError(t, db.Create(&b1).Error)
+		require.NoError(t, db.Create(&b2).Error)
+		svc := inventoryServices.NewInventoryService(db)
+		p, err := svc.CreateProduct(ctx, tenantID, inventoryPort.CreateProductRequest{
+			SKU: "POM-001", Barcode: "8850000000011", Name: "Pomade", Cost: 12000, Price: 25000,
+		})
+		require.NoError(t, err)
+		return db, svc, b1.ID, b2.ID, p
+	}
+	onHand := func(t *testing.T, db *gorm.DB, branchID, productID uint) int {
+		n, err := inventoryServices.OnHandTx(db, branchID, productID)
+		require.NoError(t, err)
+		return n
+	}
+
+	t.Run("Product_UniqueSKUAndBarcodePerTenant", func(t *testing.T) {
+		_, svc, _, _, _ := setup(t)
+		_, err := svc.CreateProduct(ctx, tenantID, inventoryPort.CreateProductRequest{SKU: "POM-001", Name: "Dup"})
+		assert.ErrorContains(t, err, "already exists")
+		_, err = svc.CreateProduct(ctx, tenantID, inventoryPort.CreateProductRequest{SKU: "POM-002", Barcode: "8850000000011", Name: "Dup"})
+		assert.ErrorContains(t, err, "barcode")
+		// บาร์โค้ดว่างซ้ำได้
+		_, err = svc.CreateProduct(ctx, tenantID, inventoryPort.CreateProductRequest{SKU: "COMB-1", Name: "Comb"})
+		assert.NoError(t, err)
+		_, err = svc.CreateProduct(ctx, tenantID, inventoryPort.CreateProductRequest{SKU: "COMB-2", Name: "Comb 2"})
+		assert.NoError(t, err)
+
+		found, err := svc.ListProducts(ctx, tenantID, inventoryPort.ProductFilter{Barcode: "8850000000011"})
+		require.NoError(t, err)
+		assert.Len(t, found, 1)
+	})
+
+	t.Run("Ledger_ReceiveAdjustTransfer", func(t *testing.T) {
+		db, svc, b1, b2, p := setup(t)
+		cost := posModels.Money(11500)
+		_, err := svc.ReceiveStock(ctx, tenantID, inventoryPort.ReceiveStockRequest{BranchID: b1, ProductID: p.ID, Quantity: 10, UnitCost: &cost}, &actor)
+		require.NoError(t, err)
+		updated, err := svc.GetProduct(ctx, tenantID, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cost, updated.Cost)
+
+		_, err = svc.AdjustStock(ctx, tenantID, inventoryPort.AdjustStockRequest{BranchID: b1, ProductID: p.ID, Quantity: -2, Reason: "damaged"}, &actor)
+		require.NoError(t, err)
+		_, err = svc.AdjustStock(ctx, tenantID, inventoryPort.AdjustStockRequest{BranchID: b1, ProductID: p.ID, Quantity: -20, Reason: "count"}, &actor)
+		assert.ErrorContains(t, err, "negative")
+
+		moves, err := svc.TransferStock(ctx, tenantID, inventoryPort.TransferStockRequest{FromBranchID: b1, ToBranchID: b2, ProductID: p.ID, Quantity: 3}, &actor)
+		require.NoError(t, err)
+		if assert.Len(t, moves, 2) {
+			assert.Equal(t, moves[0].TransferRef, moves[1].TransferRef)
+		}
+		_, err = svc.TransferStock(ctx, tenantID, inventoryPort.TransferStockRequest{FromBranchID: b2, ToBranchID: b1, ProductID: p.ID, Quantity: 4}, &actor)
+		assert.ErrorContains(t, err, "insufficient stock")
+
+		assert.Equal(t, 5, onHand(t, db, b1, p.ID))
+		assert.Equal(t, 3, onHand(t, db, b2, p.ID))
+	})
+
+	t.Run("LowStock_UsesBranchThreshold", func(t *testing.T) {
+		_, svc, b1, b2, p := setup(t)
+		_, err := svc.ReceiveStock(ctx, tenantID, inventoryPort.ReceiveStockRequest{BranchID: b1, ProductID: p.ID, Quantity: 4}, &actor)
+		require.NoError(t, err)
+		_, err = svc.SetThreshold(ctx, tenantID, inventoryPort.SetThresholdRequest{BranchID: b1, ProductID: p.ID, ReorderLevel: 5})
+		require.NoError(t, err)
+
+		low, err := svc.GetStockLevels(ctx, tenantID, b1, true)
+		require.NoError(t, err)
+		if assert.Len(t, low, 1) {
+			assert.Equal(t, 4, low[0].OnHand)
+			assert.True(t, low[0].LowStock)
+		}
+		// สาขาที่ไม่ได้ตั้งจุดสั่งซื้อไม่นับว่าต่ำ
+		low, err = svc.GetStockLevels(ctx, tenantID, b2, true)
+		require.NoError(t, err)
+		assert.Empty(t, low)
+
+		_, err = svc.SetThreshold(ctx, tenantID, inventoryPort.SetThresholdRequest{BranchID: b1, ProductID: p.ID, ReorderLevel: 2})
+		require.NoError(t, err)
+		low, err = svc.GetStockLevels(ctx, tenantID, b1, true)
+		require.NoError(t, err)
+		assert.Empty(t, low)
+	})
+
+	t.Run("Checkout_DeductsAndVoidRestocks", func(t *testing.T) {
+		db, svc, b1, _, p := setup(t)
+		_, err := svc.ReceiveStock(ctx, tenantID, inventoryPort.ReceiveStockRequest{BranchID: b1, ProductID: p.ID, Quantity: 3}, &actor)
+		require.NoError(t, err)
+		sales := posServices.NewSaleService(db)
+
+		sale, err := sales.CreateSale(ctx, tenantID, posPort.CreateSaleRequest{
+			BranchID: b1,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemProduct, ProductID: &p.ID, Quantity: 2}},
+		}, &actor)
+		require.NoError(t, err)
+		assert.Equal(t, "Pomade", sale.Items[0].Name)
+		assert.Equal(t, posModels.Money(50000), sale.Total)
+		assert.Equal(t, 1, onHand(t, db, b1, p.ID))
+
+		var movement inventoryModels.StockMovement
+		require.NoError(t, db.Where("sale_id = ?", sale.ID).First(&movement).Error)
+		assert.Equal(t, inventoryModels.MovementSale, movement.Type)
+		assert.Equal(t, -2, movement.Quantity)
+
+		_, err = sales.AddItem(ctx, tenantID, sale.ID, posPort.SaleItemInput{ItemType: posModels.ItemProduct, ProductID: &p.ID, Quantity: 2})
+		assert.ErrorContains(t, err, "insufficient stock")
+		assert.Equal(t, 1, onHand(t, db, b1, p.ID))
+
+		_, err = sales.VoidSale(ctx, tenantID, sale.ID, "mistake", &actor)
+		require.NoError(t, err)
+		assert.Equal(t, 3, onHand(t, db, b1, p.ID))
+	})
+
+	t.Run("Checkout_RemoveItemRestocks", func(t *testing.T) {
+		db, svc, b1, _, p := setup(t)
+		_, err := svc.ReceiveStock(ctx, tenantID, inventoryPort.ReceiveStockRequest{BranchID: b1, ProductID: p.ID, Quantity: 5}, &actor)
+		require.NoError(t, err)
+		sales := posServices.NewSaleService(db)
+
+		sale, err := sales.CreateSale(ctx, tenantID, posPort.CreateSaleRequest{BranchID: b1}, &actor)
+		require.NoError(t, err)
+		sale, err = sales.AddItem(ctx, tenantID, sale.ID, posPort.SaleItemInput{ItemType: posModels.ItemProduct, ProductID: &p.ID, Quantity: 4})
+		require.NoError(t, err)
+		assert.Equal(t, 1, onHand(t, db, b1, p.ID))
+
+		_, err = sales.RemoveItem(ctx, tenantID, sale.ID, sale.Items[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, 5, onHand(t, db, b1, p.ID))
+	})
+
+	t.Run("Checkout_RejectsInactiveProduct", func(t *testing.T) {
+		db, svc, b1, _, p := setup(t)
+		inactive := false
+		_, err := svc.UpdateProduct(ctx, tenantID, p.ID, inventoryPort.UpdateProductRequest{IsActive: &inactive})
+		require.NoError(t, err)
+
+		_, err = posServices.NewSaleService(db).CreateSale(ctx, tenantID, posPort.CreateSaleRequest{
+			BranchID: b1,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemProduct, ProductID: &p.ID, Quantity: 1}},
+		}, &actor)
+		assert.ErrorContains(t, err, "inactive")
+	})
+}
@@ -0,0 +1,135 @@
+package notificationControllers
+
+import (
+	"strings"
+
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
+	notificationModels "myapp/modules/notification/models"
+	notificationPort "myapp/modules/notification/port"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	Service notificationPort.INotificationService
+}
+
+func NewNotificationController(service notificationPort.INotificationService) *NotificationController {
+	return &NotificationController{Service: service}
+}
+
+var RolesCanManageNotification = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+}
+
+// ListChannelConfigs godoc
+// @Summary      ดูช่องทางแจ้งเตือนของ tenant
+// @Description  คืนรายการช่องทาง (telegram, email, sms) และสถานะเปิด/ปิด ไม่รวมค่า settings ที่เป็นความลับ
+// @Tags         Notification
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Success      200        {array}   notificationModels.NotificationChannelConfig
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /notifications/tenants/{tenant_id}/channels [get]
+// @Security     ApiKeyAuth
+func (ctrl *NotificationController) ListChannelConfigs(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageNotification) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	configs, err := ctrl.Service.ListChannelConfigs(c.Context(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": configs})
+}
+
+// UpsertChannelConfig godoc
+// @Summary      ตั้งค่าช่องทางแจ้งเตือนของ tenant
+// @Description  settings ตามช่องทาง: telegram {bot_token}, email {host, port, username, password, from}, sms {endpoint, api_key, sender}
+// @Description  ตั้งครั้งแรกต้องส่ง settings, ครั้งต่อไปฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint    true  "รหัส Tenant"
+// @Param        channel    path      string  true  "ช่องทาง (telegram, email, sms)"
+// @Param        body       body      notificationPort.UpsertChannelConfigRequest  true  "ค่าที่ต้องการตั้ง"
+// @Success      200        {object}  notificationModels.NotificationChannelConfig
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /notifications/tenants/{tenant_id}/channels/{channel} [put]
+// @Security     ApiKeyAuth
+func (ctrl *NotificationController) UpsertChannelConfig(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageNotification) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var req notificationPort.UpsertChannelConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	channel := notificationModels.ChannelType(c.Params("channel"))
+	config, err := ctrl.Service.UpsertChannelConfig(c.Context(), tenantID, channel, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": config})
+}
+
+// ListNotifications godoc
+// @Summary      ประวัติการแจ้งเตือนของ tenant
+// @Description  ข้อความใน outbox เรียงจากใหม่ไปเก่า พร้อมสถานะการส่ง (PENDING, SENT, FAILED)
+// @Tags         Notification
+// @Produce      json
+// @Param        tenant_id  path      uint    true   "รหัส Tenant"
+// @Param        status     query     string  false  "กรองตามสถานะ"
+// @Param        limit      query     int     false  "จำนวนสูงสุด (ค่าเริ่มต้น 50, ไม่เกิน 200)"
+// @Success      200        {array}   notificationModels.Notification
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /notifications/tenants/{tenant_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *NotificationController) ListNotifications(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageNotification) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	status := notificationModels.DeliveryStatus(strings.ToUpper(c.Query("status")))
+	switch status {
+	case "", notificationModels.DeliveryPending, notificationModels.DeliverySent, notificationModels.DeliveryFailed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid status"})
+	}
+
+	list, err := ctrl.Service.ListNotifications(c.Context(), tenantID, status, c.QueryInt("limit", 50))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": list})
+}
@@ -0,0 +1,51 @@
+package notificationModels
+
+import "time"
+
+type ChannelType string
+
+const (
+	ChannelTelegram ChannelType = "telegram"
+	ChannelEmail    ChannelType = "email"
+	ChannelSMS      ChannelType = "sms"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "PENDING" // รอส่ง หรือรอ retry
+	DeliverySent    DeliveryStatus = "SENT"
+	DeliveryFailed  DeliveryStatus = "FAILED" // ส่งไม่สำเร็จครบจำนวนครั้ง หรือช่องทางถูกปิด
+)
+
+// NotificationChannelConfig การตั้งค่าช่องทางแจ้งเตือนของ tenant (หนึ่งแถวต่อช่องทาง)
+// Settings เก็บค่าเฉพาะของแต่ละช่องทาง เช่น SMTP host/password, bot token ไม่ส่งออกทาง API
+type NotificationChannelConfig struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	TenantID  uint        `gorm:"not null;uniqueIndex:idx_notification_channel_tenant" json:"tenant_id"`
+	Channel   ChannelType `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_channel_tenant" json:"channel"`
+	Enabled   bool        `gorm:"not null;default:true" json:"enabled"`
+	Settings  string      `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Notification ข้อความใน outbox หนึ่งแถวต่อผู้รับต่อช่องทาง
+// ถูกสร้างใน transaction เดียวกับข้อมูลต้นทาง แล้ว cmd/jobworker เป็นผู้ส่ง
+type Notification struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TenantID  uint           `gorm:"not null;index" json:"tenant_id"`
+	Channel   ChannelType    `gorm:"type:varchar(20);not null" json:"channel"`
+	Event     string         `gorm:"type:varchar(100);not null;index" json:"event"`
+	Recipient string         `gorm:"type:text;not null" json:"recipient"`
+	Subject   string         `gorm:"type:text" json:"subject,omitempty"`
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	Status    DeliveryStatus `gorm:"type:varchar(20);not null;default:'PENDING';index" json:"status"`
+	Attempts  int            `gorm:"not null;default:0" json:"attempts"`
+	LastError string         `gorm:"type:text" json:"last_error,omitempty"`
+	// กันการแจ้งเตือนซ้ำ เช่น reminder ของนัดเดียวกัน (ต่อช่องทาง)
+	DedupKey  *string    `gorm:"type:varchar(200);uniqueIndex" json:"dedup_key,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
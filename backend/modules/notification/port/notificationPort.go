@@ -0,0 +1,51 @@
+package notificationPort
+
+import (
+	"context"
+	"encoding/json"
+
+	notificationModels "myapp/modules/notification/models"
+)
+
+// Message เนื้อหาที่จะส่ง (Subject ใช้เฉพาะช่องทางที่รองรับ เช่นอีเมล)
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Recipient ที่อยู่ของผู้รับในแต่ละช่องทาง ช่องทางที่ไม่มีที่อยู่จะถูกข้าม
+type Recipient struct {
+	Email          string
+	Phone          string
+	TelegramChatID *int64
+}
+
+// IChannel ช่องทางส่งข้อความ settings คือค่า NotificationChannelConfig.Settings ของ tenant
+type IChannel interface {
+	Type() notificationModels.ChannelType
+	Validate(settings json.RawMessage) error
+	Send(ctx context.Context, settings json.RawMessage, to string, msg Message) error
+}
+
+// ISecretSettings ช่องทางที่มีค่าลับใน settings (เช่นรหัสผ่าน, api key)
+// ค่าในฟิลด์เหล่านี้ถูกเข้ารหัสก่อนบันทึก และถอดรหัสก่อนเรียก Send
+type ISecretSettings interface {
+	SecretFields() []string
+}
+
+// UpsertChannelConfigRequest ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+type UpsertChannelConfigRequest struct {
+	Enabled  *bool           `json:"enabled,omitempty" example:"true"`
+	Settings json.RawMessage `json:"settings,omitempty" swaggertype:"object"`
+}
+
+type INotificationService interface {
+	ListChannelConfigs(ctx context.Context, tenantID uint) ([]notificationModels.NotificationChannelConfig, error)
+	UpsertChannelConfig(ctx context.Context, tenantID uint, channel notificationModels.ChannelType, req UpsertChannelConfigRequest) (*notificationModels.NotificationChannelConfig, error)
+
+	// ประวัติการแจ้งเตือนของ tenant (status ว่าง = ทุกสถานะ) เรียงจากใหม่ไปเก่า
+	ListNotifications(ctx context.Context, tenantID uint, status notificationModels.DeliveryStatus, limit int) ([]notificationModels.Notification, error)
+
+	// ส่งข้อความใน outbox หนึ่งรายการ finalAttempt = true จะบันทึก FAILED เมื่อส่งไม่สำเร็จ
+	Deliver(ctx context.Context, notificationID uint, finalAttempt bool) error
+}
@@ -0,0 +1,18 @@
+package notificationRoutes
+
+import (
+	middlewares "myapp/middlewares"
+	coremiddlewares "myapp/modules/core/middlewares"
+	notificationControllers "myapp/modules/notification/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterNotificationRoutes(router fiber.Router, ctrl *notificationControllers.NotificationController) {
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant())
+
+	group.Get("/", ctrl.ListNotifications)
+	group.Get("/channels", ctrl.ListChannelConfigs)
+	group.Put("/channels/:channel", ctrl.UpsertChannelConfig)
+}
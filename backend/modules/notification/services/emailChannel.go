@@ -0,0 +1,90 @@
+package notificationServices
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	notificationModels "myapp/modules/notification/models"
+	notificationPort "myapp/modules/notification/port"
+)
+
+type emailSettings struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+type EmailChannel struct {
+	// แทนที่ได้ในเทสต์
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmailChannel() *EmailChannel {
+	return &EmailChannel{SendMail: smtp.SendMail}
+}
+
+func (c *EmailChannel) Type() notificationModels.ChannelType {
+	return notificationModels.ChannelEmail
+}
+
+func (c *EmailChannel) SecretFields() []string {
+	return []string{"password"}
+}
+
+func (c *EmailChannel) Validate(settings json.RawMessage) error {
+	var s emailSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	if s.Host == "" || s.Port == 0 || s.From == "" {
+		return fmt.Errorf("host, port and from are required")
+	}
+	return nil
+}
+
+func (c *EmailChannel) Send(ctx context.Context, settings json.RawMessage, to string, msg notificationPort.Message) error {
+	var s emailSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return fmt.Errorf("invalid email settings: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	return c.SendMail(addr, auth, s.From, []string{to}, buildEmail(s.From, to, msg))
+}
+
+// buildEmail ข้อความ MIME แบบ UTF-8 (หัวเรื่องภาษาไทยต้อง encode)
+func buildEmail(from, to string, msg notificationPort.Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,386 @@
+package notificationServices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapp/jobs"
+	coreModels "myapp/modules/core/models"
+	notificationModels "myapp/modules/notification/models"
+	notificationPort "myapp/modules/notification/port"
+	"myapp/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobDeliverNotification งานส่งข้อความใน outbox (ประมวลผลโดย cmd/jobworker)
+const JobDeliverNotification = "notification.deliver"
+
+// จำนวนครั้งที่พยายามส่งต่อข้อความ ก่อนบันทึกเป็น FAILED
+const deliveryMaxAttempts = 5
+
+type deliverPayload struct {
+	NotificationID uint `json:"notification_id"`
+}
+
+type NotificationService struct {
+	DB       *gorm.DB
+	channels map[notificationModels.ChannelType]notificationPort.IChannel
+}
+
+func NewNotificationService(db *gorm.DB, channels ...notificationPort.IChannel) *NotificationService {
+	s := &NotificationService{DB: db, channels: map[notificationModels.ChannelType]notificationPort.IChannel{}}
+	for _, ch := range channels {
+		s.channels[ch.Type()] = ch
+	}
+	return s
+}
+
+// DefaultChannels ช่องทางที่ระบบรองรับ
+func DefaultChannels() []notificationPort.IChannel {
+	return []notificationPort.IChannel{
+		NewTelegramChannel(),
+		NewEmailChannel(),
+		NewSMSChannel(),
+	}
+}
+
+// EnqueueTx สร้างข้อความใน outbox ทุกช่องทางที่ tenant เปิดไว้และผู้รับมีที่อยู่ พร้อมงานส่งใน tx เดียวกัน
+// dedupKey (ถ้ามี) กันไม่ให้สร้างข้อความเดิมซ้ำในช่องทางเดียวกัน
+func EnqueueTx(
+	tx *gorm.DB,
+	tenantID uint,
+	event string,
+	to notificationPort.Recipient,
+	msg notificationPort.Message,
+	dedupKey string,
+) ([]notificationModels.Notification, error) {
+	var configs []notificationModels.NotificationChannelConfig
+	if err := tx.
+		Where("tenant_id = ? AND enabled = ?", tenantID, true).
+		Order("id").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notification channels: %w", err)
+	}
+
+	var out []notificationModels.Notification
+	for _, cfg := range configs {
+		address := recipientAddress(cfg.Channel, to)
+		if address == "" {
+			continue
+		}
+
+		n := notificationModels.Notification{
+			TenantID:  tenantID,
+			Channel:   cfg.Channel,
+			Event:     event,
+			Recipient: address,
+			Subject:   msg.Subject,
+			Body:      msg.Body,
+			Status:    notificationModels.DeliveryPending,
+		}
+		if dedupKey != "" {
+			key := fmt.Sprintf("%s:%s", dedupKey, cfg.Channel)
+			n.DedupKey = &key
+		}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&n)
+		if res.Error != nil {
+			return nil, fmt.Errorf("failed to create notification: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		if _, err := jobs.Enqueue(tx, JobDeliverNotification,
+			deliverPayload{NotificationID: n.ID},
+			jobs.MaxAttempts(deliveryMaxAttempts),
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func recipientAddress(channel notificationModels.ChannelType, to notificationPort.Recipient) string {
+	switch channel {
+	case notificationModels.ChannelEmail:
+		return to.Email
+	case notificationModels.ChannelSMS:
+		return to.Phone
+	case notificationModels.ChannelTelegram:
+		if to.TelegramChatID != nil {
+			return fmt.Sprintf("%d", *to.TelegramChatID)
+		}
+	}
+	return ""
+}
+
+// RegisterJobs ผูก handler ส่งข้อความกับ worker
+func (s *NotificationService) RegisterJobs(w *jobs.Worker) {
+	w.Register(JobDeliverNotification, func(ctx context.Context, job *jobs.Job) error {
+		var p deliverPayload
+		if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid payload for %s: %w", job.Type, err))
+		}
+		return s.Deliver(ctx, p.NotificationID, job.Attempts >= job.MaxAttempts)
+	})
+}
+
+func (s *NotificationService) Deliver(ctx context.Context, notificationID uint, finalAttempt bool) error {
+	db := s.DB.WithContext(ctx)
+
+	var n notificationModels.Notification
+	if err := db.First(&n, notificationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("notification with ID %d not found", notificationID))
+		}
+		return err
+	}
+	// ส่งไปแล้ว หรือยกเลิกไปแล้ว (job ถูกรันซ้ำ)
+	if n.Status != notificationModels.DeliveryPending {
+		return nil
+	}
+
+	var cfg notificationModels.NotificationChannelConfig
+	err := db.Where("tenant_id = ? AND channel = ?", n.TenantID, n.Channel).First(&cfg).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || !cfg.Enabled {
+		return s.markFailed(db, &n, fmt.Sprintf("channel %s is disabled for this tenant", n.Channel))
+	}
+	ch, ok := s.channels[n.Channel]
+	if !ok {
+		return s.markFailed(db, &n, fmt.Sprintf("channel %s is not supported", n.Channel))
+	}
+
+	settings, err := openSettings(ch, cfg.Settings)
+	if err != nil {
+		return s.markFailed(db, &n, err.Error())
+	}
+	if n.Channel == notificationModels.ChannelTelegram {
+		if settings, err = withTenantBotToken(db, n.TenantID, settings); err != nil {
+			if finalAttempt {
+				return s.markFailed(db, &n, err.Error())
+			}
+			return err
+		}
+	}
+
+	sendErr := ch.Send(ctx, settings, n.Recipient, notificationPort.Message{
+		Subject: n.Subject,
+		Body:    n.Body,
+	})
+
+	updates := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+	if sendErr == nil {
+		now := time.Now()
+		updates["status"] = notificationModels.DeliverySent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	} else {
+		updates["last_error"] = sendErr.Error()
+		if finalAttempt {
+			updates["status"] = notificationModels.DeliveryFailed
+		}
+	}
+	if err := db.Model(&n).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update notification %d: %w", n.ID, err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("send %s notification %d failed: %w", n.Channel, n.ID, sendErr)
+	}
+	return nil
+}
+
+// markFailed ปิดข้อความที่ส่งไม่ได้แน่นอน ไม่ต้อง retry
+func (s *NotificationService) markFailed(db *gorm.DB, n *notificationModels.Notification, reason string) error {
+	if err := db.Model(n).Updates(map[string]interface{}{
+		"status":     notificationModels.DeliveryFailed,
+		"last_error": reason,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update notification %d: %w", n.ID, err)
+	}
+	return nil
+}
+
+func (s *NotificationService) ListChannelConfigs(ctx context.Context, tenantID uint) ([]notificationModels.NotificationChannelConfig, error) {
+	var configs []notificationModels.NotificationChannelConfig
+	if err := s.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("channel").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notification channels: %w", err)
+	}
+	return configs, nil
+}
+
+func (s *NotificationService) UpsertChannelConfig(
+	ctx context.Context,
+	tenantID uint,
+	channel notificationModels.ChannelType,
+	req notificationPort.UpsertChannelConfigRequest,
+) (*notificationModels.NotificationChannelConfig, error) {
+	ch, ok := s.channels[channel]
+	if !ok {
+		return nil, fmt.Errorf("invalid channel: %s", channel)
+	}
+
+	var out notificationModels.NotificationChannelConfig
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ? AND channel = ?", tenantID, channel).
+			First(&out).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if len(req.Settings) == 0 {
+				return fmt.Errorf("invalid settings: required when configuring a channel for the first time")
+			}
+			out = notificationModels.NotificationChannelConfig{TenantID: tenantID, Channel: channel, Enabled: true}
+		} else if err != nil {
+			return err
+		}
+
+		if len(req.Settings) > 0 {
+			check := req.Settings
+			if channel == notificationModels.ChannelTelegram {
+				if check, err = withTenantBotToken(tx, tenantID, req.Settings); err != nil {
+					return err
+				}
+			}
+			if err := ch.Validate(check); err != nil {
+				return fmt.Errorf("invalid settings: %w", err)
+			}
+			sealed, err := sealSettings(ch, req.Settings)
+			if err != nil {
+				return err
+			}
+			out.Settings = sealed
+		}
+		if req.Enabled != nil {
+			out.Enabled = *req.Enabled
+		}
+		// Save ไม่แทนค่า false ด้วย default ของคอลัมน์ (ต่างจาก Create)
+		if out.ID == 0 {
+			enabled := out.Enabled
+			if err := tx.Create(&out).Error; err != nil {
+				return fmt.Errorf("failed to save notification channel: %w", err)
+			}
+			out.Enabled = enabled
+		}
+		if err := tx.Save(&out).Error; err != nil {
+			return fmt.Errorf("failed to save notification channel: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// withTenantBotToken ใช้ token ของบอทร้าน (telegram_bots) ถ้าร้านตั้งบอทไว้และเปิดใช้งาน
+// ข้อความจึงส่งจากบอทเดียวกับที่ลูกค้าผูกบัญชีไว้ ไม่มีบอทร้าน → ใช้ bot_token ใน settings หรือ TELEGRAM_TOKEN
+func withTenantBotToken(db *gorm.DB, tenantID uint, settings json.RawMessage) (json.RawMessage, error) {
+	var bot coreModels.TelegramBot
+	err := db.Where("tenant_id = ? AND enabled = ?", tenantID, true).First(&bot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch telegram bot: %w", err)
+	}
+	token, err := utils.DecryptSecret(bot.TokenEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt telegram bot token: %w", err)
+	}
+
+	fields := map[string]json.RawMessage{}
+	if len(settings) > 0 {
+		if err := json.Unmarshal(settings, &fields); err != nil {
+			return nil, fmt.Errorf("invalid settings: %w", err)
+		}
+	}
+	fields["bot_token"], _ = json.Marshal(token)
+	return json.Marshal(fields)
+}
+
+// sealSettings เข้ารหัสฟิลด์ลับของช่องทาง (ISecretSettings) ก่อนบันทึก
+func sealSettings(ch notificationPort.IChannel, settings json.RawMessage) (string, error) {
+	return mapSecretFields(ch, settings, func(value string) (string, error) {
+		if value == "" || utils.IsEncryptedSecret(value) {
+			return value, nil
+		}
+		encrypted, err := utils.EncryptSecret(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt channel settings: %w", err)
+		}
+		return encrypted, nil
+	})
+}
+
+// openSettings ถอดรหัสฟิลด์ลับก่อนส่ง (ค่าเดิมที่ยังเป็น plaintext ใช้ได้ตามเดิม)
+func openSettings(ch notificationPort.IChannel, settings string) (json.RawMessage, error) {
+	opened, err := mapSecretFields(ch, json.RawMessage(settings), func(value string) (string, error) {
+		if !utils.IsEncryptedSecret(value) {
+			return value, nil
+		}
+		plain, err := utils.DecryptSecret(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt channel settings: %w", err)
+		}
+		return plain, nil
+	})
+	return json.RawMessage(opened), err
+}
+
+func mapSecretFields(ch notificationPort.IChannel, settings json.RawMessage, fn func(string) (string, error)) (string, error) {
+	secret, ok := ch.(notificationPort.ISecretSettings)
+	if !ok || len(settings) == 0 {
+		return string(settings), nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(settings, &fields); err != nil {
+		return "", fmt.Errorf("invalid settings: %w", err)
+	}
+	for _, key := range secret.SecretFields() {
+		var value string
+		if raw, ok := fields[key]; !ok || json.Unmarshal(raw, &value) != nil {
+			continue
+		}
+		mapped, err := fn(value)
+		if err != nil {
+			return "", err
+		}
+		fields[key], _ = json.Marshal(mapped)
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (s *NotificationService) ListNotifications(
+	ctx context.Context,
+	tenantID uint,
+	status notificationModels.DeliveryStatus,
+	limit int,
+) ([]notificationModels.Notification, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	q := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var out []notificationModels.Notification
+	if err := q.Order("id DESC").Limit(limit).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	return out, nil
+}
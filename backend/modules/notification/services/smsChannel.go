@@ -0,0 +1,80 @@
+package notificationServices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	notificationModels "myapp/modules/notification/models"
+	notificationPort "myapp/modules/notification/port"
+)
+
+// smsSettings ผู้ให้บริการ SMS แบบ HTTP: POST JSON {to, sender, message} ไปที่ endpoint
+// พร้อม Authorization: Bearer <api_key>
+type smsSettings struct {
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"api_key"`
+	Sender   string `json:"sender"`
+}
+
+type SMSChannel struct {
+	Client *http.Client
+}
+
+func NewSMSChannel() *SMSChannel {
+	return &SMSChannel{Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (c *SMSChannel) Type() notificationModels.ChannelType {
+	return notificationModels.ChannelSMS
+}
+
+func (c *SMSChannel) SecretFields() []string {
+	return []string{"api_key"}
+}
+
+func (c *SMSChannel) Validate(settings json.RawMessage) error {
+	var s smsSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	if s.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	return nil
+}
+
+func (c *SMSChannel) Send(ctx context.Context, settings json.RawMessage, to string, msg notificationPort.Message) error {
+	var s smsSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return fmt.Errorf("invalid sms settings: %w", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"to":      to,
+		"sender":  s.Sender,
+		"message": msg.Body,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms provider error: %s", resp.Status)
+	}
+	return nil
+}
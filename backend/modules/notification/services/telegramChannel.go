@@ -0,0 +1,100 @@
+package notificationServices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	notificationModels "myapp/modules/notification/models"
+	notificationPort "myapp/modules/notification/port"
+)
+
+// telegramSettings ร้านที่มีบอทของตัวเอง (telegram_bots) ใช้ token ของบอทนั้นเสมอ
+// ถ้าไม่มีและไม่ระบุ bot_token จะใช้บอทของระบบ (TELEGRAM_TOKEN)
+type telegramSettings struct {
+	BotToken string `json:"bot_token"`
+}
+
+type TelegramChannel struct {
+	Client  *http.Client
+	BaseURL string
+}
+
+func NewTelegramChannel() *TelegramChannel {
+	return &TelegramChannel{
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		BaseURL: "https://api.telegram.org",
+	}
+}
+
+func (c *TelegramChannel) Type() notificationModels.ChannelType {
+	return notificationModels.ChannelTelegram
+}
+
+func (c *TelegramChannel) SecretFields() []string {
+	return []string{"bot_token"}
+}
+
+func (c *TelegramChannel) Validate(settings json.RawMessage) error {
+	var s telegramSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	if s.BotToken == "" && os.Getenv("TELEGRAM_TOKEN") == "" {
+		return fmt.Errorf("bot_token is required")
+	}
+	return nil
+}
+
+func (c *TelegramChannel) Send(ctx context.Context, settings json.RawMessage, to string, msg notificationPort.Message) error {
+	var s telegramSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return fmt.Errorf("invalid telegram settings: %w", err)
+	}
+	token := s.BotToken
+	if token == "" {
+		token = os.Getenv("TELEGRAM_TOKEN")
+	}
+
+	text := msg.Body
+	if msg.Subject != "" {
+		text = msg.Subject + "\n\n" + msg.Body
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"chat_id": to,
+		"text":    text,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/sendMessage", c.BaseURL, token), bytes.NewReader(body))
+	if err != nil {
+		return stripTokenURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return stripTokenURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram error: %s", resp.Status)
+	}
+	return nil
+}
+
+// stripTokenURL ตัด URL (ที่มี bot token) ออกจาก error ก่อนบันทึกลง last_error ที่ผู้ดูแลร้านเห็นได้
+func stripTokenURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
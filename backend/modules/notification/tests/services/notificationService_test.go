@@ -0,0 +1,319 @@
+package notificationServiceTest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"myapp/jobs"
+	coreModels "myapp/modules/core/models"
+	notificationModels "myapp/modules/notification/models"
+	notificationPort "myapp/modules/notification/port"
+	notificationServices "myapp/modules/notification/services"
+	"myapp/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupNotificationDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(
+		&notificationModels.NotificationChannelConfig{},
+		&notificationModels.Notification{},
+		&jobs.Job{},
+		&coreModels.TelegramBot{},
+	))
+	return db
+}
+
+// fakeChannel บันทึกข้อความที่ส่ง และคืน err ที่กำหนด
+type fakeChannel struct {
+	typ  notificationModels.ChannelType
+	sent []string
+	err  error
+}
+
+func (f *fakeChannel) Type() notificationModels.ChannelType { return f.typ }
+func (f *fakeChannel) Validate(json.RawMessage) error       { return nil }
+func (f *fakeChannel) Send(_ context.Context, _ json.RawMessage, to string, _ notificationPort.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, to)
+	return nil
+}
+
+// secretChannel fakeChannel ที่มีฟิลด์ลับ และเก็บ settings ที่ได้รับตอนส่ง
+type secretChannel struct {
+	fakeChannel
+	settings json.RawMessage
+}
+
+func (c *secretChannel) SecretFields() []string { return []string{"api_key"} }
+func (c *secretChannel) Send(ctx context.Context, settings json.RawMessage, to string, msg notificationPort.Message) error {
+	c.settings = settings
+	return c.fakeChannel.Send(ctx, settings, to, msg)
+}
+
+func TestNotificationService_SecretSettings(t *testing.T) {
+	t.Setenv("APP_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	db := setupNotificationDB(t)
+	ctx := context.Background()
+	sms := &secretChannel{fakeChannel: fakeChannel{typ: notificationModels.ChannelSMS}}
+	svc := notificationServices.NewNotificationService(db, sms)
+
+	cfg, err := svc.UpsertChannelConfig(ctx, 1, notificationModels.ChannelSMS, notificationPort.UpsertChannelConfigRequest{
+		Settings: json.RawMessage(`{"endpoint":"https://sms.example.com/send","api_key":"k-123"}`),
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.Settings, "k-123")
+
+	var stored notificationModels.NotificationChannelConfig
+	require.NoError(t, db.First(&stored, cfg.ID).Error)
+	assert.NotContains(t, stored.Settings, "k-123")
+
+	n := notificationModels.Notification{TenantID: 1, Channel: notificationModels.ChannelSMS, Event: "x", Recipient: "0812345678", Body: "hi", Status: notificationModels.DeliveryPending}
+	require.NoError(t, db.Create(&n).Error)
+	require.NoError(t, svc.Deliver(ctx, n.ID, false))
+	assert.JSONEq(t, `{"endpoint":"https://sms.example.com/send","api_key":"k-123"}`, string(sms.settings))
+
+	// ค่าเดิมที่บันทึกเป็น plaintext ก่อนมีการเข้ารหัสยังส่งได้
+	require.NoError(t, db.Model(&stored).Update("settings", `{"endpoint":"https://sms.example.com/send","api_key":"legacy"}`).Error)
+	n2 := notificationModels.Notification{TenantID: 1, Channel: notificationModels.ChannelSMS, Event: "x", Recipient: "0812345678", Body: "hi", Status: notificationModels.DeliveryPending}
+	require.NoError(t, db.Create(&n2).Error)
+	require.NoError(t, svc.Deliver(ctx, n2.ID, false))
+	assert.JSONEq(t, `{"endpoint":"https://sms.example.com/send","api_key":"legacy"}`, string(sms.settings))
+}
+
+func TestNotificationService_TelegramUsesTenantBot(t *testing.T) {
+	t.Setenv("APP_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	t.Setenv("TELEGRAM_TOKEN", "system:token")
+	db := setupNotificationDB(t)
+	ctx := context.Background()
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	tg := notificationServices.NewTelegramChannel()
+	tg.BaseURL = srv.URL
+	svc := notificationServices.NewNotificationService(db, tg)
+
+	for _, tenantID := range []uint{1, 2} {
+		_, err := svc.UpsertChannelConfig(ctx, tenantID, notificationModels.ChannelTelegram, notificationPort.UpsertChannelConfigRequest{
+			Settings: json.RawMessage(`{}`),
+		})
+		require.NoError(t, err)
+	}
+	token, err := utils.EncryptSecret("111:shop")
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&coreModels.TelegramBot{TenantID: 1, BotUsername: "shop_bot", TokenEncrypted: token, Enabled: true}).Error)
+
+	for _, tenantID := range []uint{1, 2} {
+		n := notificationModels.Notification{TenantID: tenantID, Channel: notificationModels.ChannelTelegram, Event: "x", Recipient: "42", Body: "hi", Status: notificationModels.DeliveryPending}
+		require.NoError(t, db.Create(&n).Error)
+		require.NoError(t, svc.Deliver(ctx, n.ID, false))
+	}
+	assert.Equal(t, []string{"/bot111:shop/sendMessage", "/botsystem:token/sendMessage"}, paths)
+}
+
+func TestNotificationService_TelegramErrorHidesToken(t *testing.T) {
+	t.Setenv("APP_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	db := setupNotificationDB(t)
+	ctx := context.Background()
+
+	// ปลายทางปิดไปแล้ว → Client.Do คืน *url.Error ที่มี URL เต็ม
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close()
+	tg := notificationServices.NewTelegramChannel()
+	tg.BaseURL = srv.URL
+	svc := notificationServices.NewNotificationService(db, tg)
+
+	_, err := svc.UpsertChannelConfig(ctx, 1, notificationModels.ChannelTelegram, notificationPort.UpsertChannelConfigRequest{
+		Settings: json.RawMessage(`{"bot_token":"999:TOP-SECRET"}`),
+	})
+	require.NoError(t, err)
+
+	n := notificationModels.Notification{TenantID: 1, Channel: notificationModels.ChannelTelegram, Event: "x", Recipient: "42", Body: "hi", Status: notificationModels.DeliveryPending}
+	require.NoError(t, db.Create(&n).Error)
+	err = svc.Deliver(ctx, n.ID, false)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "TOP-SECRET")
+
+	require.NoError(t, db.First(&n, n.ID).Error)
+	assert.NotEmpty(t, n.LastError)
+	assert.NotContains(t, n.LastError, "TOP-SECRET")
+}
+
+func TestNotificationService_Outbox(t *testing.T) {
+	db := setupNotificationDB(t)
+	ctx := context.Background()
+	email := &fakeChannel{typ: notificationModels.ChannelEmail}
+	sms := &fakeChannel{typ: notificationModels.ChannelSMS}
+	svc := notificationServices.NewNotificationService(db, email, sms)
+
+	enabled := true
+	_, err := svc.UpsertChannelConfig(ctx, 1, notificationModels.ChannelEmail, notificationPort.UpsertChannelConfigRequest{
+		Settings: json.RawMessage(`{"host":"smtp.example.com","port":587,"from":"shop@example.com"}`),
+	})
+	require.NoError(t, err)
+	_, err = svc.UpsertChannelConfig(ctx, 1, notificationModels.ChannelSMS, notificationPort.UpsertChannelConfigRequest{
+		Enabled:  &enabled,
+		Settings: json.RawMessage(`{"endpoint":"https://sms.example.com/send"}`),
+	})
+	require.NoError(t, err)
+
+	to := notificationPort.Recipient{Email: "a@example.com", Phone: "0812345678"}
+	msg := notificationPort.Message{Subject: "ยืนยันการจอง", Body: "จองแล้ว"}
+
+	t.Run("Enqueue creates one row and one job per enabled channel", func(t *testing.T) {
+		var out []notificationModels.Notification
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			out, err = notificationServices.EnqueueTx(tx, 1, "appointment.created", to, msg, "appointment:1:created")
+			return err
+		})
+		require.NoError(t, err)
+		require.Len(t, out, 2)
+
+		var jobCount int64
+		db.Model(&jobs.Job{}).Where("type = ?", notificationServices.JobDeliverNotification).Count(&jobCount)
+		assert.Equal(t, int64(2), jobCount)
+
+		// dedup key เดิม → ไม่สร้างซ้ำ
+		again, err := notificationServices.EnqueueTx(db, 1, "appointment.created", to, msg, "appointment:1:created")
+		require.NoError(t, err)
+		assert.Empty(t, again)
+
+		// ไม่มีที่อยู่ของช่องทางนั้น → ข้าม, tenant อื่นไม่ได้ตั้งช่องทาง → ไม่สร้างเลย
+		onlyEmail, err := notificationServices.EnqueueTx(db, 1, "appointment.created",
+			notificationPort.Recipient{Email: "b@example.com"}, msg, "")
+		require.NoError(t, err)
+		require.Len(t, onlyEmail, 1)
+		assert.Equal(t, notificationModels.ChannelEmail, onlyEmail[0].Channel)
+		none, err := notificationServices.EnqueueTx(db, 2, "appointment.created", to, msg, "")
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("Worker delivers and records status", func(t *testing.T) {
+		sms.err = errors.New("provider down")
+		w := jobs.NewWorker(db, "test")
+		svc.RegisterJobs(w)
+
+		_, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a@example.com", "b@example.com"}, email.sent)
+
+		var sent, pending []notificationModels.Notification
+		db.Where("status = ?", notificationModels.DeliverySent).Find(&sent)
+		db.Where("status = ?", notificationModels.DeliveryPending).Find(&pending)
+		assert.Len(t, sent, 2)
+		require.Len(t, pending, 1)
+		assert.Equal(t, 1, pending[0].Attempts)
+		assert.Equal(t, "provider down", pending[0].LastError)
+	})
+
+	t.Run("Final failed attempt marks FAILED", func(t *testing.T) {
+		var n notificationModels.Notification
+		require.NoError(t, db.Where("channel = ?", notificationModels.ChannelSMS).First(&n).Error)
+
+		err := svc.Deliver(ctx, n.ID, true)
+		assert.Error(t, err)
+		require.NoError(t, db.First(&n, n.ID).Error)
+		assert.Equal(t, notificationModels.DeliveryFailed, n.Status)
+		assert.Equal(t, 2, n.Attempts)
+	})
+
+	t.Run("Disabled channel fails without sending", func(t *testing.T) {
+		disabled := false
+		cfg, err := svc.UpsertChannelConfig(ctx, 1, notificationModels.ChannelEmail, notificationPort.UpsertChannelConfigRequest{Enabled: &disabled})
+		require.NoError(t, err)
+		assert.False(t, cfg.Enabled)
+
+		n := notificationModels.Notification{TenantID: 1, Channel: notificationModels.ChannelEmail, Event: "x", Recipient: "c@example.com", Body: "hi", Status: notificationModels.DeliveryPending}
+		require.NoError(t, db.Create(&n).Error)
+		require.NoError(t, svc.Deliver(ctx, n.ID, false))
+		require.NoError(t, db.First(&n, n.ID).Error)
+		assert.Equal(t, notificationModels.DeliveryFailed, n.Status)
+		assert.NotContains(t, email.sent, "c@example.com")
+	})
+
+	t.Run("Invalid config is rejected", func(t *testing.T) {
+		_, err := svc.UpsertChannelConfig(ctx, 1, "fax", notificationPort.UpsertChannelConfigRequest{Settings: json.RawMessage(`{}`)})
+		assert.ErrorContains(t, err, "invalid channel")
+		_, err = svc.UpsertChannelConfig(ctx, 3, notificationModels.ChannelSMS, notificationPort.UpsertChannelConfigRequest{})
+		assert.ErrorContains(t, err, "invalid settings")
+
+		real := notificationServices.NewNotificationService(db, notificationServices.DefaultChannels()...)
+		_, err = real.UpsertChannelConfig(ctx, 3, notificationModels.ChannelEmail, notificationPort.UpsertChannelConfigRequest{
+			Settings: json.RawMessage(`{"host":"smtp.example.com"}`),
+		})
+		assert.ErrorContains(t, err, "invalid settings")
+	})
+}
+
+func TestNotificationChannels_Send(t *testing.T) {
+	ctx := context.Background()
+	msg := notificationPort.Message{Subject: "แจ้งเตือนนัดหมาย", Body: "พรุ่งนี้ 10:00"}
+
+	t.Run("SMS posts to provider endpoint", func(t *testing.T) {
+		var got map[string]string
+		var auth string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer srv.Close()
+
+		ch := notificationServices.NewSMSChannel()
+		settings := json.RawMessage(`{"endpoint":"` + srv.URL + `","api_key":"k","sender":"SHOP"}`)
+		require.NoError(t, ch.Send(ctx, settings, "0812345678", msg))
+		assert.Equal(t, "Bearer k", auth)
+		assert.Equal(t, map[string]string{"to": "0812345678", "sender": "SHOP", "message": "พรุ่งนี้ 10:00"}, got)
+	})
+
+	t.Run("Telegram uses tenant bot token", func(t *testing.T) {
+		var path string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer srv.Close()
+
+		ch := notificationServices.NewTelegramChannel()
+		ch.BaseURL = srv.URL
+		err := ch.Send(ctx, json.RawMessage(`{"bot_token":"123:abc"}`), "42", msg)
+		assert.ErrorContains(t, err, "telegram error")
+		assert.Equal(t, "/bot123:abc/sendMessage", path)
+	})
+
+	t.Run("Email goes through SMTP with encoded subject", func(t *testing.T) {
+		var addr string
+		var raw []byte
+		ch := notificationServices.NewEmailChannel()
+		ch.SendMail = func(a string, _ smtp.Auth, _ string, _ []string, m []byte) error {
+			addr, raw = a, m
+			return nil
+		}
+		settings := json.RawMessage(`{"host":"smtp.example.com","port":587,"username":"u","password":"p","from":"shop@example.com"}`)
+		require.NoError(t, ch.Send(ctx, settings, "a@example.com", msg))
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.Contains(t, string(raw), "Subject: =?UTF-8?q?")
+		assert.Contains(t, string(raw), "To: a@example.com")
+	})
+}
@@ -0,0 +1,285 @@
+package posControllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	helperFunc "myapp/modules/core"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GatewayController struct {
+	Service posPort.IGatewayService
+}
+
+func NewGatewayController(service posPort.IGatewayService) *GatewayController {
+	return &GatewayController{Service: service}
+}
+
+// ChargeSaleGateway godoc
+// @Summary      ชำระบิลผ่าน payment gateway
+// @Description  ตัดเงินจาก payment_token ที่ได้จาก SDK ของผู้ให้บริการ ไม่ระบุ amount = ยอดค้างทั้งหมด
+// @Description  ผลลัพธ์อาจเป็น CAPTURED, AUTHORIZED (รอตัดเงิน), PENDING หรือ FAILED สถานะจะอัปเดตตาม webhook ของผู้ให้บริการ
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                true  "รหัส Tenant"
+// @Param        sale_id    path      uint                                true  "รหัสบิล"
+// @Param        body       body      posPort.CreateGatewayChargeRequest  true  "ผู้ให้บริการและ token"
+// @Success      201        {object}  posModels.GatewayPayment
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id}/gateway [post]
+// @Security     ApiKeyAuth
+func (ctrl *GatewayController) ChargeSale(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+	var req posPort.CreateGatewayChargeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	payment, err := ctrl.Service.ChargeSale(c.Context(), tenantID, saleID, req, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": payment})
+}
+
+// ChargeDepositGateway godoc
+// @Summary      รับมัดจำนัดหมายผ่าน payment gateway
+// @Description  เฉพาะนัดที่ยังไม่ถึงเวลารับบริการ ยอดที่ตัดได้จะถูกบันทึกเข้าบิลตอนเปิดบิลจากนัดนี้
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                                true  "รหัส Tenant"
+// @Param        appointment_id  path      uint                                true  "รหัสนัดหมาย"
+// @Param        body            body      posPort.CreateGatewayChargeRequest  true  "ผู้ให้บริการ, token และยอดมัดจำ"
+// @Success      201             {object}  posModels.GatewayPayment
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/appointments/{appointment_id}/gateway-deposit [post]
+// @Security     ApiKeyAuth
+func (ctrl *GatewayController) ChargeDeposit(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	appointmentID, err := helperFunc.ParseUintParam(c, "appointment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid appointment_id"})
+	}
+	var req posPort.CreateGatewayChargeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	payment, err := ctrl.Service.ChargeDeposit(c.Context(), tenantID, appointmentID, req, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": payment})
+}
+
+// ListGatewayPayments godoc
+// @Summary      ดูรายการชำระผ่าน payment gateway
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id       path      uint    true   "รหัส Tenant"
+// @Param        branch_id       query     uint    false  "กรองตามสาขา"
+// @Param        sale_id         query     uint    false  "กรองตามบิล"
+// @Param        appointment_id  query     uint    false  "กรองตามนัดหมาย"
+// @Param        status          query     string  false  "PENDING, AUTHORIZED, CAPTURED, REFUNDED หรือ FAILED"
+// @Param        limit           query     int     false  "จำนวนสูงสุด (ค่าเริ่มต้น 50)"
+// @Param        offset          query     int     false  "ข้าม"
+// @Success      200             {array}   posModels.GatewayPayment
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/gateway-payments [get]
+// @Security     ApiKeyAuth
+func (ctrl *GatewayController) ListPayments(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var filter posPort.GatewayPaymentFilter
+	for _, q := range []struct {
+		name string
+		dst  **uint
+	}{
+		{"branch_id", &filter.BranchID},
+		{"sale_id", &filter.SaleID},
+		{"appointment_id", &filter.AppointmentID},
+	} {
+		qs := c.Query(q.name, "")
+		if qs == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(qs, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid " + q.name})
+		}
+		u := uint(v)
+		*q.dst = &u
+	}
+	filter.Status = posModels.GatewayPaymentStatus(strings.ToUpper(c.Query("status", "")))
+	filter.Limit = c.QueryInt("limit", 0)
+	filter.Offset = c.QueryInt("offset", 0)
+
+	payments, err := ctrl.Service.ListPayments(c.Context(), tenantID, filter)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": payments})
+}
+
+// GetGatewayPayment godoc
+// @Summary      ดูรายการชำระผ่าน payment gateway
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id   path      uint  true  "รหัส Tenant"
+// @Param        payment_id  path      uint  true  "รหัสรายการ"
+// @Success      200         {object}  posModels.GatewayPayment
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/gateway-payments/{payment_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *GatewayController) GetPayment(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	paymentID, err := helperFunc.ParseUintParam(c, "payment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid payment_id"})
+	}
+
+	payment, err := ctrl.Service.GetPayment(c.Context(), tenantID, paymentID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": payment})
+}
+
+// RefundGatewayPayment godoc
+// @Summary      คืนเงินผ่าน payment gateway
+// @Description  เฉพาะรายการ CAPTURED ไม่ระบุ amount = คืนยอดที่เหลือทั้งหมด ยอดคืนจะถูกบันทึกเป็น REFUND ในบิล
+// @Description  บิลที่มีการชำระผ่าน gateway ต้องคืนเงินด้วย endpoint นี้ก่อน VOID
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id   path      uint                                 true   "รหัส Tenant"
+// @Param        payment_id  path      uint                                 true   "รหัสรายการ"
+// @Param        body        body      posPort.RefundGatewayPaymentRequest  false  "ยอดคืน"
+// @Success      200         {object}  posModels.GatewayPayment
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/gateway-payments/{payment_id}/refund [post]
+// @Security     ApiKeyAuth
+func (ctrl *GatewayController) RefundPayment(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanVoidSale) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	paymentID, err := helperFunc.ParseUintParam(c, "payment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid payment_id"})
+	}
+	var req posPort.RefundGatewayPaymentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+		}
+	}
+
+	payment, err := ctrl.Service.RefundPayment(c.Context(), tenantID, paymentID, req)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": payment})
+}
+
+// SyncGatewayPayment godoc
+// @Summary      ดึงสถานะล่าสุดจากผู้ให้บริการ
+// @Description  ใช้เมื่อสงสัยว่า webhook หาย (ระบบตรวจรายการที่ค้างให้อัตโนมัติทุก 5 นาทีอยู่แล้ว)
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id   path      uint  true  "รหัส Tenant"
+// @Param        payment_id  path      uint  true  "รหัสรายการ"
+// @Success      200         {object}  posModels.GatewayPayment
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/gateway-payments/{payment_id}/sync [post]
+// @Security     ApiKeyAuth
+func (ctrl *GatewayController) SyncPayment(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	paymentID, err := helperFunc.ParseUintParam(c, "payment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid payment_id"})
+	}
+
+	payment, err := ctrl.Service.SyncPayment(c.Context(), tenantID, paymentID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": payment})
+}
+
+// Webhook รับเหตุการณ์จากผู้ให้บริการ (เรียกเอง ไม่ต้อง login ตรวจด้วยลายเซ็นของผู้ให้บริการ)
+func (ctrl *GatewayController) Webhook(c *fiber.Ctx) error {
+	payment, err := ctrl.Service.HandleWebhook(c.Context(), c.Params("provider"), posPort.InboundWebhook{
+		Body:   c.Body(),
+		Header: func(key string) string { return c.Get(key) },
+	})
+	if err != nil {
+		if errors.Is(err, posServices.ErrInvalidWebhookSignature) {
+			log.Println("⚠️ payment gateway webhook rejected:", err)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid signature"})
+		}
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": fiber.Map{"reference": payment.Reference, "status": payment.Status}})
+}
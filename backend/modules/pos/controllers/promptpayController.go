@@ -0,0 +1,400 @@
+package posControllers
+
+import (
+	"errors"
+	"log"
+
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromptPayController struct {
+	Service posPort.IPromptPayService
+}
+
+func NewPromptPayController(service posPort.IPromptPayService) *PromptPayController {
+	return &PromptPayController{Service: service}
+}
+
+// ตั้งบัญชีพร้อมเพย์รับเงินของสาขาได้เฉพาะผู้จัดการ
+var RolesCanManagePromptPay = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+}
+
+func sendPNG(c *fiber.Ctx, payload string) error {
+	img, err := posServices.PromptPayPNG(payload)
+	if err != nil {
+		return posError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).Send(img)
+}
+
+// SetPromptPayAccount godoc
+// @Summary      ตั้งบัญชีพร้อมเพย์ของสาขา
+// @Description  proxy_type: MOBILE (เบอร์ 10 หลัก), NATIONAL_ID (13 หลัก) หรือ EWALLET (15 หลัก) ตั้งซ้ำเป็นการแก้ไข
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                true  "รหัส Tenant"
+// @Param        branch_id  path      uint                                true  "รหัสสาขา"
+// @Param        body       body      posPort.SetPromptPayAccountRequest  true  "บัญชีพร้อมเพย์"
+// @Success      200        {object}  posModels.PromptPayAccount
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/branches/{branch_id}/promptpay [put]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) SetAccount(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManagePromptPay) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+	var req posPort.SetPromptPayAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	acc, err := ctrl.Service.SetAccount(c.Context(), tenantID, branchID, req)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": acc})
+}
+
+// GetPromptPayAccount godoc
+// @Summary      ดูบัญชีพร้อมเพย์ของสาขา
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        branch_id  path      uint  true  "รหัสสาขา"
+// @Success      200        {object}  posModels.PromptPayAccount
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/branches/{branch_id}/promptpay [get]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) GetAccount(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	acc, err := ctrl.Service.GetAccount(c.Context(), tenantID, branchID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": acc})
+}
+
+// GetBranchQR godoc
+// @Summary      QR พร้อมเพย์ของสาขา (PNG)
+// @Description  ไม่ระบุ amount = QR แบบ static ให้ลูกค้ากรอกยอดเอง (พิมพ์ติดหน้าร้านได้) ระบุ amount = QR ระบุยอดที่ไม่ผูกกับบิล
+// @Tags         POS
+// @Produce      png
+// @Param        tenant_id  path      uint    true   "รหัส Tenant"
+// @Param        branch_id  path      uint    true   "รหัสสาขา"
+// @Param        amount     query     string  false  "ยอดเงิน (บาท)"
+// @Success      200        {file}    binary
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/branches/{branch_id}/promptpay/qr [get]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) GetBranchQR(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+	var amount *posModels.Money
+	if qs := c.Query("amount", ""); qs != "" {
+		m, err := posModels.ParseMoney(qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid amount"})
+		}
+		amount = &m
+	}
+
+	payload, err := ctrl.Service.BranchPayload(c.Context(), tenantID, branchID, amount)
+	if err != nil {
+		return posError(c, err)
+	}
+	return sendPNG(c, payload)
+}
+
+// CreateSaleCharge godoc
+// @Summary      ออก QR พร้อมเพย์ให้บิล
+// @Description  ไม่ระบุ amount = ยอดค้างทั้งหมด QR เดิมของบิลที่ยังรอยืนยันจะถูกยกเลิก ได้ reference สำหรับจับคู่สลิป
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                                  true   "รหัส Tenant"
+// @Param        sale_id    path      uint                                  true   "รหัสบิล"
+// @Param        body       body      posPort.CreatePromptPayChargeRequest  false  "ยอดที่ต้องการเก็บ"
+// @Success      201        {object}  posModels.PromptPayCharge
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id}/promptpay [post]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) CreateSaleCharge(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+	var req posPort.CreatePromptPayChargeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+		}
+	}
+
+	charge, err := ctrl.Service.CreateSaleCharge(c.Context(), tenantID, saleID, req, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": charge})
+}
+
+// CreateDepositCharge godoc
+// @Summary      ออก QR พร้อมเพย์มัดจำนัดหมาย
+// @Description  เฉพาะนัดที่ยังไม่ถึงเวลารับบริการ เมื่อยืนยันแล้วยอดมัดจำจะถูกบันทึกเข้าบิลตอนเปิดบิลจากนัดนี้
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id       path      uint                                  true  "รหัส Tenant"
+// @Param        appointment_id  path      uint                                  true  "รหัสนัดหมาย"
+// @Param        body            body      posPort.CreatePromptPayChargeRequest  true  "ยอดมัดจำ"
+// @Success      201             {object}  posModels.PromptPayCharge
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/appointments/{appointment_id}/deposit [post]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) CreateDepositCharge(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	appointmentID, err := helperFunc.ParseUintParam(c, "appointment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid appointment_id"})
+	}
+	var req posPort.CreatePromptPayChargeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	charge, err := ctrl.Service.CreateDepositCharge(c.Context(), tenantID, appointmentID, req, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": charge})
+}
+
+// GetCharge godoc
+// @Summary      ดูสถานะ QR พร้อมเพย์
+// @Description  หน้าจอลูกค้าใช้ poll ว่าเป็น PAID แล้วหรือยัง
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        charge_id  path      uint  true  "รหัสรายการ"
+// @Success      200        {object}  posModels.PromptPayCharge
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/promptpay/{charge_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) GetCharge(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	chargeID, err := helperFunc.ParseUintParam(c, "charge_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid charge_id"})
+	}
+
+	charge, err := ctrl.Service.GetCharge(c.Context(), tenantID, chargeID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": charge})
+}
+
+// GetChargeQR godoc
+// @Summary      ภาพ QR พร้อมเพย์ของรายการ (PNG)
+// @Tags         POS
+// @Produce      png
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        charge_id  path      uint  true  "รหัสรายการ"
+// @Success      200        {file}    binary
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/promptpay/{charge_id}/qr [get]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) GetChargeQR(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	chargeID, err := helperFunc.ParseUintParam(c, "charge_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid charge_id"})
+	}
+
+	charge, err := ctrl.Service.GetCharge(c.Context(), tenantID, chargeID)
+	if err != nil {
+		return posError(c, err)
+	}
+	if charge.Status != posModels.PromptPayPending {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "invalid status: charge is " + string(charge.Status)})
+	}
+	return sendPNG(c, charge.Payload)
+}
+
+// ConfirmCharge godoc
+// @Summary      ยืนยันว่าได้รับเงินพร้อมเพย์
+// @Description  พนักงานตรวจยอดเงินเข้าแล้วกดยืนยัน รายการเป็น PAID และบันทึกการชำระ PROMPTPAY เข้าบิล
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                             true   "รหัส Tenant"
+// @Param        charge_id  path      uint                             true   "รหัสรายการ"
+// @Param        body       body      posPort.ConfirmPromptPayRequest  false  "เลขอ้างอิงธุรกรรม"
+// @Success      200        {object}  posModels.PromptPayCharge
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      409        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/promptpay/{charge_id}/confirm [post]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) ConfirmCharge(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	chargeID, err := helperFunc.ParseUintParam(c, "charge_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid charge_id"})
+	}
+	var req posPort.ConfirmPromptPayRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+		}
+	}
+
+	charge, err := ctrl.Service.ConfirmCharge(c.Context(), tenantID, chargeID, req, *userID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": charge})
+}
+
+// CancelCharge godoc
+// @Summary      ยกเลิก QR พร้อมเพย์ที่ยังรอยืนยัน
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        charge_id  path      uint  true  "รหัสรายการ"
+// @Success      200        {object}  posModels.PromptPayCharge
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/promptpay/{charge_id}/cancel [post]
+// @Security     ApiKeyAuth
+func (ctrl *PromptPayController) CancelCharge(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	chargeID, err := helperFunc.ParseUintParam(c, "charge_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid charge_id"})
+	}
+
+	charge, err := ctrl.Service.CancelCharge(c.Context(), tenantID, chargeID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": charge})
+}
+
+// SlipWebhook รับผลตรวจสลิปจากผู้ให้บริการ (เรียกเอง ไม่ต้อง login ตรวจด้วยลายเซ็นของ verifier)
+func (ctrl *PromptPayController) SlipWebhook(c *fiber.Ctx) error {
+	charge, err := ctrl.Service.ConfirmSlip(c.Context(), posPort.InboundWebhook{
+		Body:   c.Body(),
+		Header: func(key string) string { return c.Get(key) },
+	})
+	if err != nil {
+		if errors.Is(err, posServices.ErrInvalidSlipSignature) {
+			log.Println("⚠️ promptpay slip webhook rejected:", err)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid signature"})
+		}
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": fiber.Map{"reference": charge.Reference, "status": charge.Status}})
+}
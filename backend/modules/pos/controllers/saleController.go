@@ -0,0 +1,390 @@
+package posControllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SaleController struct {
+	Service posPort.IPOSService
+}
+
+func NewSaleController(service posPort.IPOSService) *SaleController {
+	return &SaleController{Service: service}
+}
+
+// พนักงานหน้าร้านเปิดบิลและรับชำระได้
+var RolesCanUsePOS = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+	coreModels.RoleNameAssistantManager,
+	coreModels.RoleNameStaff,
+}
+
+// ยกเลิกบิลได้เฉพาะผู้จัดการ
+var RolesCanVoidSale = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+}
+
+func posError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "permission denied"):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "already exists"):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+}
+
+func currentUserID(c *fiber.Ctx) *uint {
+	if id, ok := c.Locals("user_id").(uint); ok {
+		return &id
+	}
+	return nil
+}
+
+// ListSales godoc
+// @Summary      ดูรายการบิลขาย
+// @Description  กรองตาม branch_id, status และช่วงเวลา from/to (RFC3339) เรียงจากใหม่ไปเก่า
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint    true   "รหัส Tenant"
+// @Param        branch_id  query     uint    false  "กรองตามสาขา"
+// @Param        status     query     string  false  "OPEN, PAID หรือ VOID"
+// @Param        from       query     string  false  "ตั้งแต่ (RFC3339)"
+// @Param        to         query     string  false  "ถึงก่อน (RFC3339)"
+// @Param        limit      query     int     false  "จำนวนสูงสุด (ค่าเริ่มต้น 50 สูงสุด 200)"
+// @Param        offset     query     int     false  "ข้ามกี่รายการ"
+// @Success      200        {array}   posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales [get]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) ListSales(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var filter posPort.SaleFilter
+	if qs := c.Query("branch_id", ""); qs != "" {
+		v, err := strconv.ParseUint(qs, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+		}
+		u := uint(v)
+		filter.BranchID = &u
+	}
+	filter.Status = posModels.SaleStatus(strings.ToUpper(c.Query("status", "")))
+	if qs := c.Query("from", ""); qs != "" {
+		t, err := time.Parse(time.RFC3339, qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid from (expected RFC3339)"})
+		}
+		filter.From = &t
+	}
+	if qs := c.Query("to", ""); qs != "" {
+		t, err := time.Parse(time.RFC3339, qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid to (expected RFC3339)"})
+		}
+		filter.To = &t
+	}
+	filter.Limit = c.QueryInt("limit", 0)
+	filter.Offset = c.QueryInt("offset", 0)
+
+	sales, err := ctrl.Service.ListSales(c.Context(), tenantID, filter)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": sales})
+}
+
+// CreateSale godoc
+// @Summary      เปิดบิลขายหน้าร้าน
+// @Description  item_type: SERVICE (ต้องมี service_id ราคาจาก service เสมอ) หรือ PRODUCT (product_id จากแคตตาล็อก ตัดสต๊อกสาขาทันที หรือ name และ unit_price สำหรับสินค้านอกแคตตาล็อก)
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                       true  "รหัส Tenant"
+// @Param        body       body      posPort.CreateSaleRequest  true  "สาขา ลูกค้า และรายการ"
+// @Success      201        {object}  posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales [post]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) CreateSale(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req posPort.CreateSaleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	sale, err := ctrl.Service.CreateSale(c.Context(), tenantID, req, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": sale})
+}
+
+// CreateSaleFromAppointment godoc
+// @Summary      เปิดบิลจากนัดหมาย
+// @Description  ใช้ได้กับนัดที่ IN_SERVICE หรือ COMPLETED รายการและราคาดึงจากนัด (ราคา ณ เวลาจอง) หนึ่งนัดมีบิลที่ยังไม่ VOID ได้ใบเดียว
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id       path      uint  true  "รหัส Tenant"
+// @Param        appointment_id  path      uint  true  "รหัสนัดหมาย"
+// @Success      201             {object}  posModels.Sale
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
+// @Failure      409             {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/appointments/{appointment_id}/sale [post]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) CreateSaleFromAppointment(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	appointmentID, err := helperFunc.ParseUintParam(c, "appointment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid appointment_id"})
+	}
+
+	sale, err := ctrl.Service.CreateSaleFromAppointment(c.Context(), tenantID, appointmentID, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": sale})
+}
+
+// GetSale godoc
+// @Summary      ดูบิลพร้อมรายการและการชำระ
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        sale_id    path      uint  true  "รหัสบิล"
+// @Success      200        {object}  posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) GetSale(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+
+	sale, err := ctrl.Service.GetSale(c.Context(), tenantID, saleID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": sale})
+}
+
+// AddItem godoc
+// @Summary      เพิ่มรายการในบิล
+// @Description  ได้เฉพาะบิล OPEN ที่ยังไม่มีการชำระ
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                   true  "รหัส Tenant"
+// @Param        sale_id    path      uint                   true  "รหัสบิล"
+// @Param        body       body      posPort.SaleItemInput  true  "รายการ"
+// @Success      200        {object}  posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id}/items [post]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) AddItem(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+	var req posPort.SaleItemInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	sale, err := ctrl.Service.AddItem(c.Context(), tenantID, saleID, req)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": sale})
+}
+
+// RemoveItem godoc
+// @Summary      ลบรายการออกจากบิล
+// @Description  ได้เฉพาะบิล OPEN ที่ยังไม่มีการชำระ
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        sale_id    path      uint  true  "รหัสบิล"
+// @Param        item_id    path      uint  true  "รหัสรายการ"
+// @Success      200        {object}  posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id}/items/{item_id} [delete]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) RemoveItem(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+	itemID, err := helperFunc.ParseUintParam(c, "item_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid item_id"})
+	}
+
+	sale, err := ctrl.Service.RemoveItem(c.Context(), tenantID, saleID, itemID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": sale})
+}
+
+// AddPayment godoc
+// @Summary      รับชำระเงิน
+// @Description  method: CASH, CARD หรือ TRANSFER แบ่งจ่ายหลายช่องทางได้โดยเรียกหลายครั้ง
+// @Description  เงินสดรับเกินยอดค้างได้ (คืนเป็น change_amount) บัตร/โอนต้องไม่เกินยอดค้าง บิลเป็น PAID เมื่อชำระครบ
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                       true  "รหัส Tenant"
+// @Param        sale_id    path      uint                       true  "รหัสบิล"
+// @Param        body       body      posPort.AddPaymentRequest  true  "ช่องทางและยอด"
+// @Success      200        {object}  posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id}/payments [post]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) AddPayment(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+	var req posPort.AddPaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+	req.Method = posModels.PaymentMethod(strings.ToUpper(string(req.Method)))
+
+	sale, err := ctrl.Service.AddPayment(c.Context(), tenantID, saleID, req, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": sale})
+}
+
+// VoidSale godoc
+// @Summary      ยกเลิกบิล
+// @Description  เฉพาะผู้จัดการ ต้องระบุเหตุผล บิลที่ VOID ไม่นับยอดขายและเปิดบิลใหม่จากนัดเดิมได้
+// @Description  ถ้ารับเงินแล้วจะบันทึกรายการ REFUND คืนตามช่องทางที่รับ (เงินสดหักเงินทอน)
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                     true  "รหัส Tenant"
+// @Param        sale_id    path      uint                     true  "รหัสบิล"
+// @Param        body       body      posPort.VoidSaleRequest  true  "เหตุผล"
+// @Success      200        {object}  posModels.Sale
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/sales/{sale_id}/void [post]
+// @Security     ApiKeyAuth
+func (ctrl *SaleController) VoidSale(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanVoidSale) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	saleID, err := helperFunc.ParseUintParam(c, "sale_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid sale_id"})
+	}
+	var req posPort.VoidSaleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	sale, err := ctrl.Service.VoidSale(c.Context(), tenantID, saleID, req.Reason, currentUserID(c))
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": sale})
+}
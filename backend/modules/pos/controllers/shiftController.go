@@ -0,0 +1,393 @@
+package posControllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShiftController struct {
+	Service posPort.IShiftService
+}
+
+func NewShiftController(service posPort.IShiftService) *ShiftController {
+	return &ShiftController{Service: service}
+}
+
+// ผู้จัดการบันทึกเงินเข้าออกหรือปิดกะแทนแคชเชียร์คนอื่นได้ และดู Z-report ของสาขา
+var RolesCanManageShifts = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+	coreModels.RoleNameBranchAdmin,
+}
+
+// วันของรายงานนับตามเวลาไทย
+var reportLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Bangkok"); err == nil {
+		return loc
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}()
+
+// OpenShift godoc
+// @Summary      เปิดกะลิ้นชักเงินสด
+// @Description  แคชเชียร์เปิดได้ทีละกะต่อสาขา พร้อมเงินทอนตั้งต้น (opening_float) ต้องเปิดกะก่อนรับหรือคืนเงินสด
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                      true  "รหัส Tenant"
+// @Param        body       body      posPort.OpenShiftRequest  true  "สาขาและเงินตั้งต้น"
+// @Success      201        {object}  posModels.CashShift
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      409        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/shifts [post]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) OpenShift(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req posPort.OpenShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	shift, err := ctrl.Service.OpenShift(c.Context(), tenantID, req, *userID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": shift})
+}
+
+// GetCurrentShift godoc
+// @Summary      ดูกะที่เปิดอยู่ของตัวเอง
+// @Description  สรุปเงินสดที่ควรมีในลิ้นชักและยอดตามช่องทางชำระของกะปัจจุบันในสาขา
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        branch_id  query     uint  true  "รหัสสาขา"
+// @Success      200        {object}  posModels.ShiftReport
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/shifts/current [get]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) GetCurrentShift(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := strconv.ParseUint(c.Query("branch_id", ""), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	report, err := ctrl.Service.GetCurrentShift(c.Context(), tenantID, uint(branchID), *userID)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": report})
+}
+
+// ListShifts godoc
+// @Summary      ดูประวัติกะ
+// @Description  ผู้จัดการดูได้ทุกกะ พนักงานเห็นเฉพาะกะของตัวเอง กรองตาม branch_id, cashier_id, status และช่วงเวลาเปิดกะ from/to (RFC3339)
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id   path      uint    true   "รหัส Tenant"
+// @Param        branch_id   query     uint    false  "กรองตามสาขา"
+// @Param        cashier_id  query     uint    false  "กรองตามแคชเชียร์ (เฉพาะผู้จัดการ)"
+// @Param        status      query     string  false  "OPEN หรือ CLOSED"
+// @Param        from        query     string  false  "ตั้งแต่ (RFC3339)"
+// @Param        to          query     string  false  "ถึงก่อน (RFC3339)"
+// @Param        limit       query     int     false  "จำนวนสูงสุด (ค่าเริ่มต้น 50 สูงสุด 200)"
+// @Param        offset      query     int     false  "ข้ามกี่รายการ"
+// @Success      200         {array}   posModels.CashShift
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/shifts [get]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) ListShifts(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	var filter posPort.ShiftFilter
+	if qs := c.Query("branch_id", ""); qs != "" {
+		v, err := strconv.ParseUint(qs, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+		}
+		u := uint(v)
+		filter.BranchID = &u
+	}
+	if helperFunc.IsAuthorizedRole(roleStr, RolesCanManageShifts) {
+		if qs := c.Query("cashier_id", ""); qs != "" {
+			v, err := strconv.ParseUint(qs, 10, 64)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid cashier_id"})
+			}
+			u := uint(v)
+			filter.CashierID = &u
+		}
+	} else {
+		userID := currentUserID(c)
+		if userID == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+		}
+		filter.CashierID = userID
+	}
+	filter.Status = posModels.ShiftStatus(strings.ToUpper(c.Query("status", "")))
+	if qs := c.Query("from", ""); qs != "" {
+		t, err := time.Parse(time.RFC3339, qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid from (expected RFC3339)"})
+		}
+		filter.From = &t
+	}
+	if qs := c.Query("to", ""); qs != "" {
+		t, err := time.Parse(time.RFC3339, qs)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid to (expected RFC3339)"})
+		}
+		filter.To = &t
+	}
+	filter.Limit = c.QueryInt("limit", 0)
+	filter.Offset = c.QueryInt("offset", 0)
+
+	shifts, err := ctrl.Service.ListShifts(c.Context(), tenantID, filter)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": shifts})
+}
+
+// GetShift godoc
+// @Summary      ดูรายงานกะ
+// @Description  เงินตั้งต้น เงินสดรับ/คืน เงินเข้าออก ยอดที่ควรมี ยอดนับจริงและส่วนต่าง (ถ้าปิดแล้ว) และยอดตามช่องทางชำระ
+// @Tags         POS
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Param        shift_id   path      uint  true  "รหัสกะ"
+// @Success      200        {object}  posModels.ShiftReport
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/shifts/{shift_id} [get]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) GetShift(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	shiftID, err := helperFunc.ParseUintParam(c, "shift_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid shift_id"})
+	}
+
+	report, err := ctrl.Service.GetShiftReport(c.Context(), tenantID, shiftID)
+	if err != nil {
+		return posError(c, err)
+	}
+	if !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageShifts) {
+		if userID := currentUserID(c); userID == nil || *userID != report.Shift.CashierID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": report})
+}
+
+// AddCashMovement godoc
+// @Summary      บันทึกเงินสดเข้า/ออกลิ้นชัก
+// @Description  type: CASH_IN หรือ CASH_OUT ต้องระบุเหตุผล นำออกเกินเงินสดที่ควรมีในลิ้นชักไม่ได้ เจ้าของกะหรือผู้จัดการเท่านั้น
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                         true  "รหัส Tenant"
+// @Param        shift_id   path      uint                         true  "รหัสกะ"
+// @Param        body       body      posPort.CashMovementRequest  true  "ประเภท จำนวน และเหตุผล"
+// @Success      201        {object}  posModels.CashMovement
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/shifts/{shift_id}/cash-movements [post]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) AddCashMovement(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	shiftID, err := helperFunc.ParseUintParam(c, "shift_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid shift_id"})
+	}
+	var req posPort.CashMovementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+	req.Type = posModels.CashMovementType(strings.ToUpper(string(req.Type)))
+
+	asManager := helperFunc.IsAuthorizedRole(roleStr, RolesCanManageShifts)
+	movement, err := ctrl.Service.AddCashMovement(c.Context(), tenantID, shiftID, req, *userID, asManager)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": movement})
+}
+
+// CloseShift godoc
+// @Summary      ปิดกะ
+// @Description  บันทึกเงินสดที่นับได้จริง ระบบคำนวณยอดที่ควรมีและส่วนต่าง (ติดลบ = เงินขาด) เจ้าของกะหรือผู้จัดการเท่านั้น
+// @Tags         POS
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                       true  "รหัส Tenant"
+// @Param        shift_id   path      uint                       true  "รหัสกะ"
+// @Param        body       body      posPort.CloseShiftRequest  true  "ยอดนับจริงและหมายเหตุ"
+// @Success      200        {object}  posModels.ShiftReport
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/shifts/{shift_id}/close [post]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) CloseShift(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanUsePOS) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	shiftID, err := helperFunc.ParseUintParam(c, "shift_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid shift_id"})
+	}
+	var req posPort.CloseShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	asManager := helperFunc.IsAuthorizedRole(roleStr, RolesCanManageShifts)
+	report, err := ctrl.Service.CloseShift(c.Context(), tenantID, shiftID, req, *userID, asManager)
+	if err != nil {
+		return posError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": report})
+}
+
+// GetZReport godoc
+// @Summary      Z-report ของสาขา
+// @Description  สรุปยอดขายตามช่องทางชำระ บริการกับสินค้า ส่วนลด คืนเงิน และทิป พร้อมกะที่ปิดในช่วงนั้น
+// @Description  ระบุ date (YYYY-MM-DD ตามเวลาไทย ค่าเริ่มต้นวันนี้) หรือ from/to (RFC3339) และ format=csv เพื่อดาวน์โหลด
+// @Tags         POS
+// @Produce      json
+// @Produce      text/csv
+// @Param        tenant_id  path      uint    true   "รหัส Tenant"
+// @Param        branch_id  path      uint    true   "รหัสสาขา"
+// @Param        date       query     string  false  "วันที่ (YYYY-MM-DD)"
+// @Param        from       query     string  false  "ตั้งแต่ (RFC3339)"
+// @Param        to         query     string  false  "ถึงก่อน (RFC3339)"
+// @Param        format     query     string  false  "json (ค่าเริ่มต้น) หรือ csv"
+// @Success      200        {object}  posModels.ZReport
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /pos/tenants/{tenant_id}/branches/{branch_id}/z-report [get]
+// @Security     ApiKeyAuth
+func (ctrl *ShiftController) GetZReport(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageShifts) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	branchID, err := helperFunc.ParseUintParam(c, "branch_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid branch_id"})
+	}
+
+	var from, to time.Time
+	if qf, qt := c.Query("from", ""), c.Query("to", ""); qf != "" || qt != "" {
+		if from, err = time.Parse(time.RFC3339, qf); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid from (expected RFC3339)"})
+		}
+		if to, err = time.Parse(time.RFC3339, qt); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid to (expected RFC3339)"})
+		}
+	} else {
+		day := time.Now().In(reportLocation)
+		if qs := c.Query("date", ""); qs != "" {
+			if day, err = time.ParseInLocation("2006-01-02", qs, reportLocation); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid date (expected YYYY-MM-DD)"})
+			}
+		}
+		from = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, reportLocation)
+		to = from.AddDate(0, 0, 1)
+	}
+
+	report, err := ctrl.Service.GetZReport(c.Context(), tenantID, branchID, from, to)
+	if err != nil {
+		return posError(c, err)
+	}
+	if strings.EqualFold(c.Query("format", ""), "csv") {
+		body, err := posServices.ZReportCSV(report)
+		if err != nil {
+			return posError(c, err)
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="z-report-%d-%s.csv"`, branchID, from.In(reportLocation).Format("20060102")))
+		return c.Status(fiber.StatusOK).Send(body)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": report})
+}
@@ -0,0 +1,56 @@
+package posModels
+
+import "time"
+
+type GatewayPaymentStatus string
+
+const (
+	GatewayPending    GatewayPaymentStatus = "PENDING"    // สร้างรายการแล้ว รอผลจากผู้ให้บริการ
+	GatewayAuthorized GatewayPaymentStatus = "AUTHORIZED" // กันวงเงินแล้ว ยังไม่ตัดเงิน
+	GatewayFailed     GatewayPaymentStatus = "FAILED"     // ถูกปฏิเสธหรือผู้ให้บริการไม่พบธุรกรรม
+	GatewayCaptured   GatewayPaymentStatus = "CAPTURED"   // ตัดเงินแล้ว (คืนบางส่วนแล้วยังคงเป็น CAPTURED)
+	GatewayRefunded   GatewayPaymentStatus = "REFUNDED"   // คืนเงินเต็มจำนวนแล้ว
+)
+
+// GatewayPayment การชำระผ่าน payment gateway ของบิลขายหรือมัดจำนัดหมาย
+// สถานะเปลี่ยนตามผลจากผู้ให้บริการ (ผลตอบกลับ, webhook, ตรวจสถานะย้อนหลัง) โดยจับคู่ด้วย ProviderTxnID
+// เมื่อ CAPTURED จะบันทึก Payment (method CARD) เข้าบิล มัดจำนัดที่ยังไม่มีบิลจะบันทึกตอนเปิดบิลจากนัด
+type GatewayPayment struct {
+	ID            uint  `gorm:"primaryKey" json:"id"`
+	TenantID      uint  `gorm:"not null;index" json:"tenant_id"`
+	BranchID      uint  `gorm:"not null;index" json:"branch_id"`
+	SaleID        *uint `gorm:"index" json:"sale_id,omitempty"`
+	AppointmentID *uint `gorm:"index" json:"appointment_id,omitempty"`
+
+	Provider      string  `gorm:"type:varchar(30);not null;uniqueIndex:uq_gateway_payments_provider_txn" json:"provider"`
+	ProviderTxnID *string `gorm:"type:varchar(100);uniqueIndex:uq_gateway_payments_provider_txn" json:"provider_txn_id,omitempty"`
+	Reference     string  `gorm:"type:varchar(40);not null;uniqueIndex" json:"reference"` // ส่งให้ผู้ให้บริการ ใช้จับคู่ก่อนได้เลขธุรกรรม
+
+	Amount         Money                `gorm:"type:numeric(12,2);not null" json:"amount"`
+	RefundedAmount Money                `gorm:"type:numeric(12,2);not null;default:0" json:"refunded_amount"`
+	RefundPending  Money                `gorm:"type:numeric(12,2);not null;default:0" json:"refund_pending,omitempty"` // ยอดคืนที่จองไว้ระหว่างรอผู้ให้บริการตอบ
+	Status         GatewayPaymentStatus `gorm:"type:varchar(12);not null;default:'PENDING';index" json:"status"`
+	FailureReason  string               `gorm:"type:text" json:"failure_reason,omitempty"`
+	// หมายเหตุเมื่อตัดเงินแล้วแต่บันทึกเข้าบิลไม่ได้ (เช่นบิลถูกชำระครบไปแล้ว) ต้องคืนเงินผ่านผู้ให้บริการ
+	Note      string `gorm:"type:text" json:"note,omitempty"`
+	PaymentID *uint  `json:"payment_id,omitempty"`
+
+	CreatedBy    *uint      `json:"created_by,omitempty"`
+	AuthorizedAt *time.Time `json:"authorized_at,omitempty"`
+	CapturedAt   *time.Time `json:"captured_at,omitempty"`
+	FailedAt     *time.Time `json:"failed_at,omitempty"`
+	RefundedAt   *time.Time `json:"refunded_at,omitempty"` // คืนเงินครั้งล่าสุด
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"index" json:"updated_at"`
+}
+
+// GatewayEvent webhook ที่รับแล้ว กันการประมวลผลเหตุการณ์เดิมซ้ำเมื่อผู้ให้บริการส่งซ้ำ
+type GatewayEvent struct {
+	ID               uint                 `gorm:"primaryKey" json:"id"`
+	Provider         string               `gorm:"type:varchar(30);not null;uniqueIndex:uq_gateway_events_provider_event" json:"provider"`
+	EventID          string               `gorm:"type:varchar(100);not null;uniqueIndex:uq_gateway_events_provider_event" json:"event_id"`
+	ProviderTxnID    string               `gorm:"type:varchar(100);not null" json:"provider_txn_id"`
+	Status           GatewayPaymentStatus `gorm:"type:varchar(12);not null" json:"status"`
+	GatewayPaymentID uint                 `gorm:"not null;index" json:"gateway_payment_id"`
+	ReceivedAt       time.Time            `json:"received_at"`
+}
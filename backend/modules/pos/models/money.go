@@ -0,0 +1,125 @@
+package posModels
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money จำนวนเงินหน่วยสตางค์ (1 บาท = 100) คำนวณเป็นจำนวนเต็มเพื่อให้ยอดรวมตรงกับ NUMERIC(12,2) ในฐานข้อมูล
+// ใน JSON และฐานข้อมูลแสดงเป็นบาททศนิยม 2 ตำแหน่ง เช่น 250.50
+type Money int64
+
+// Baht แปลงจากบาท (เช่นราคาใน barberbooking ที่เก็บเป็น float64) ปัดเป็นสตางค์
+func Baht(v float64) Money {
+	return Money(math.Round(v * 100))
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// ParseMoney อ่านจำนวนเงินแบบทศนิยมโดยไม่ผ่าน float ไม่รับเกิน 2 ตำแหน่ง
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("invalid amount: empty")
+	}
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > 2 {
+		// NUMERIC จาก Postgres อาจมีศูนย์ต่อท้าย (เช่น 250.5000)
+		if strings.TrimRight(frac[2:], "0") != "" {
+			return 0, fmt.Errorf("invalid amount %q: more than 2 decimal places", s)
+		}
+		frac = frac[:2]
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	v := w*100 + f
+	if neg {
+		v = -v
+	}
+	return Money(v), nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON รับทั้งตัวเลข (250.5) และสตริง ("250.50")
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" {
+		return nil
+	}
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		// sqlite เก็บ NUMERIC เป็น REAL
+		*m = Baht(v)
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
@@ -0,0 +1,69 @@
+package posModels
+
+import "time"
+
+type PromptPayProxyType string
+
+const (
+	PromptPayMobile     PromptPayProxyType = "MOBILE"      // เบอร์มือถือ
+	PromptPayNationalID PromptPayProxyType = "NATIONAL_ID" // เลขบัตรประชาชนหรือเลขผู้เสียภาษี
+	PromptPayEWallet    PromptPayProxyType = "EWALLET"     // e-Wallet ID
+)
+
+// PromptPayAccount บัญชีพร้อมเพย์รับเงินของสาขา (สาขาละหนึ่งบัญชี)
+type PromptPayAccount struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	TenantID    uint               `gorm:"not null;index" json:"tenant_id"`
+	BranchID    uint               `gorm:"not null;uniqueIndex" json:"branch_id"`
+	ProxyType   PromptPayProxyType `gorm:"type:varchar(20);not null" json:"proxy_type"`
+	ProxyID     string             `gorm:"type:varchar(20);not null" json:"proxy_id"`
+	AccountName string             `gorm:"type:varchar(100)" json:"account_name,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type PromptPayStatus string
+
+const (
+	PromptPayPending   PromptPayStatus = "PENDING"   // แสดง QR แล้ว รอยืนยันว่าได้รับเงิน
+	PromptPayPaid      PromptPayStatus = "PAID"      // ยืนยันแล้ว
+	PromptPayCancelled PromptPayStatus = "CANCELLED" // ยกเลิก หรือถูกแทนด้วย QR ใหม่ของบิลเดียวกัน
+)
+
+type PromptPayConfirmSource string
+
+const (
+	PromptPayByStaff PromptPayConfirmSource = "STAFF" // พนักงานตรวจยอดเงินเข้าเอง
+	PromptPayBySlip  PromptPayConfirmSource = "SLIP"  // webhook ตรวจสลิปจากผู้ให้บริการ
+)
+
+// PromptPayCharge QR พร้อมเพย์แบบระบุยอดที่ออกให้บิลขายหรือมัดจำนัดหมาย
+// เมื่อยืนยันแล้วจะบันทึก Payment (method PROMPTPAY) เข้าบิล
+// มัดจำนัดที่ยังไม่มีบิลจะถูกบันทึกเข้าบิลตอนเปิดบิลจากนัดนั้น (PaymentID ว่างจนกว่าจะบันทึก)
+type PromptPayCharge struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	TenantID      uint               `gorm:"not null;index" json:"tenant_id"`
+	BranchID      uint               `gorm:"not null;index" json:"branch_id"`
+	SaleID        *uint              `gorm:"index" json:"sale_id,omitempty"`
+	AppointmentID *uint              `gorm:"index" json:"appointment_id,omitempty"`
+	Reference     string             `gorm:"type:varchar(40);not null;uniqueIndex" json:"reference"` // ใช้จับคู่กับสลิป
+	Amount        Money              `gorm:"type:numeric(12,2);not null" json:"amount"`
+	ProxyType     PromptPayProxyType `gorm:"type:varchar(20);not null" json:"proxy_type"`
+	ProxyID       string             `gorm:"type:varchar(20);not null" json:"proxy_id"`
+	Payload       string             `gorm:"type:text;not null" json:"payload"` // ข้อความใน QR
+
+	Status       PromptPayStatus        `gorm:"type:varchar(10);not null;default:'PENDING';index" json:"status"`
+	ConfirmedVia PromptPayConfirmSource `gorm:"type:varchar(10)" json:"confirmed_via,omitempty"`
+	ConfirmedBy  *uint                  `json:"confirmed_by,omitempty"`
+	// เลขอ้างอิงธุรกรรมจากสลิป/ธนาคาร ห้ามซ้ำ กันใช้สลิปเดียวยืนยันหลายรายการ
+	TransRef  *string    `gorm:"type:varchar(100);uniqueIndex" json:"trans_ref,omitempty"`
+	PaymentID *uint      `json:"payment_id,omitempty"`
+	PaidAt    *time.Time `json:"paid_at,omitempty"`
+	// ยอดโอนที่เกินยอดค้างของบิลตอนบันทึกเข้าบิล (ชำระช่องทางอื่นไปก่อน) ต้องคืนลูกค้านอกระบบ
+	OverpaidAmount Money `gorm:"type:numeric(12,2);not null;default:0" json:"overpaid_amount"`
+
+	CreatedBy   *uint      `json:"created_by,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
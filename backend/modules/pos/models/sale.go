@@ -0,0 +1,111 @@
+package posModels
+
+import (
+	"time"
+)
+
+type SaleStatus string
+
+const (
+	SaleOpen SaleStatus = "OPEN" // เปิดบิลแล้ว ยังชำระไม่ครบ แก้ไขรายการได้
+	SalePaid SaleStatus = "PAID" // ชำระครบแล้ว
+	SaleVoid SaleStatus = "VOID" // ยกเลิกบิล (เก็บไว้เป็นประวัติ ไม่นับยอดขาย)
+)
+
+type ItemType string
+
+const (
+	ItemService ItemType = "SERVICE" // บริการ อ้างอิง barberbooking services
+	ItemProduct ItemType = "PRODUCT" // สินค้าขายปลีก อ้างอิง inventory products (ถ้ามี product_id จะตัดสต๊อก)
+)
+
+type PaymentMethod string
+
+const (
+	PaymentCash      PaymentMethod = "CASH"
+	PaymentCard      PaymentMethod = "CARD"
+	PaymentTransfer  PaymentMethod = "TRANSFER"
+	PaymentPromptPay PaymentMethod = "PROMPTPAY" // บันทึกผ่าน QR พร้อมเพย์ที่ยืนยันแล้วเท่านั้น
+)
+
+type PaymentKind string
+
+const (
+	PaymentKindPayment PaymentKind = "PAYMENT" // รับเงิน
+	PaymentKindRefund  PaymentKind = "REFUND"  // คืนเงิน (เช่นตอน VOID บิลที่รับเงินแล้ว) ยอดเป็นบวกเสมอ
+)
+
+// Sale บิลขายหนึ่งใบของสาขา อาจสร้างจาก Appointment (หนึ่งนัดมีบิลที่ยังไม่ VOID ได้ใบเดียว)
+// จ่ายหลายช่องทางได้ (split) โดยเพิ่ม Payment หลายรายการ
+// ยอดเงินทั้งหมดเป็น Money (สตางค์)
+type Sale struct {
+	ID            uint  `gorm:"primaryKey" json:"id"`
+	TenantID      uint  `gorm:"not null;index" json:"tenant_id"`
+	BranchID      uint  `gorm:"not null;index" json:"branch_id"`
+	AppointmentID *uint `gorm:"index:uq_sales_appointment_active,unique,where:status <> 'VOID'" json:"appointment_id,omitempty"`
+	CustomerID    *uint `gorm:"index" json:"customer_id,omitempty"`
+	CashierID     *uint `gorm:"index" json:"cashier_id,omitempty"` // user ที่เปิดบิล
+
+	Status         SaleStatus `gorm:"type:varchar(10);not null;default:'OPEN';index" json:"status"`
+	Subtotal       Money      `gorm:"type:numeric(12,2);not null;default:0" json:"subtotal"`
+	Discount       Money      `gorm:"type:numeric(12,2);not null;default:0" json:"discount"` // ส่วนลดท้ายบิล
+	Total          Money      `gorm:"type:numeric(12,2);not null;default:0" json:"total"`
+	PaidAmount     Money      `gorm:"type:numeric(12,2);not null;default:0" json:"paid_amount"`
+	ChangeAmount   Money      `gorm:"type:numeric(12,2);not null;default:0" json:"change_amount"`   // เงินทอน (เฉพาะเงินสดที่รับเกิน)
+	RefundedAmount Money      `gorm:"type:numeric(12,2);not null;default:0" json:"refunded_amount"` // ยอดที่คืนลูกค้า (ตอน VOID หรือคืนผ่าน payment gateway)
+	TipAmount      Money      `gorm:"type:numeric(12,2);not null;default:0" json:"tip_amount"`      // ทิปรวม (ไม่นับใน Total)
+	Notes          string     `gorm:"type:text" json:"notes,omitempty"`
+
+	Items    []SaleItem `gorm:"foreignKey:SaleID" json:"items"`
+	Payments []Payment  `gorm:"foreignKey:SaleID" json:"payments"`
+
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	VoidedAt   *time.Time `json:"voided_at,omitempty"`
+	VoidReason string     `gorm:"type:text" json:"void_reason,omitempty"`
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Balance ยอดที่ยังต้องชำระ
+func (s *Sale) Balance() Money {
+	if s.PaidAmount >= s.Total {
+		return 0
+	}
+	return s.Total - s.PaidAmount
+}
+
+// SaleItem รายการในบิล เก็บชื่อและราคา ณ เวลาขาย
+type SaleItem struct {
+	ID        uint     `gorm:"primaryKey" json:"id"`
+	SaleID    uint     `gorm:"not null;index" json:"sale_id"`
+	ItemType  ItemType `gorm:"type:varchar(10);not null" json:"item_type"`
+	ServiceID *uint    `gorm:"index" json:"service_id,omitempty"`
+	ProductID *uint    `gorm:"index" json:"product_id,omitempty"`
+	BarberID  *uint    `gorm:"index" json:"barber_id,omitempty"` // ช่างที่ให้บริการ/ขาย (ใช้คิดค่าคอม)
+
+	Name      string `gorm:"type:varchar(200);not null" json:"name"`
+	Quantity  int    `gorm:"not null" json:"quantity"`
+	UnitPrice Money  `gorm:"type:numeric(12,2);not null" json:"unit_price"`
+	Discount  Money  `gorm:"type:numeric(12,2);not null;default:0" json:"discount"` // ส่วนลดของทั้งบรรทัด
+	LineTotal Money  `gorm:"type:numeric(12,2);not null" json:"line_total"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Payment การรับชำระหรือคืนเงินหนึ่งครั้ง เงินสดบันทึกยอดที่รับจริง (อาจเกินยอดค้าง ส่วนเกินเป็น Sale.ChangeAmount)
+type Payment struct {
+	ID       uint          `gorm:"primaryKey" json:"id"`
+	TenantID uint          `gorm:"not null;index" json:"tenant_id"`
+	SaleID   uint          `gorm:"not null;index" json:"sale_id"`
+	Kind     PaymentKind   `gorm:"type:varchar(10);not null;default:'PAYMENT'" json:"kind"`
+	Method   PaymentMethod `gorm:"type:varchar(20);not null;index" json:"method"`
+	Amount   Money         `gorm:"type:numeric(12,2);not null" json:"amount"`
+	// เงินทอนที่จ่ายคืนจากการรับครั้งนี้ (เฉพาะเงินสดครั้งที่ทำให้บิลครบ)
+	ChangeAmount Money     `gorm:"type:numeric(12,2);not null;default:0" json:"change_amount"`
+	TipAmount    Money     `gorm:"type:numeric(12,2);not null;default:0" json:"tip_amount"` // ทิปที่จ่ายมาพร้อมกัน ไม่นับเข้ายอดบิล
+	ShiftID      *uint     `gorm:"index" json:"shift_id,omitempty"`                         // กะลิ้นชักที่รับ/คืนเงิน (บังคับสำหรับเงินสด)
+	Reference    string    `gorm:"type:varchar(100)" json:"reference,omitempty"`            // เลขอ้างอิงบัตร/สลิปโอน
+	ReceivedBy   *uint     `json:"received_by,omitempty"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
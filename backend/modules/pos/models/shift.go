@@ -0,0 +1,102 @@
+package posModels
+
+import "time"
+
+type ShiftStatus string
+
+const (
+	ShiftOpen   ShiftStatus = "OPEN"
+	ShiftClosed ShiftStatus = "CLOSED"
+)
+
+// CashShift กะลิ้นชักเงินสดของแคชเชียร์หนึ่งคนในสาขา (เปิดได้ทีละกะต่อคนต่อสาขา)
+// รับหรือคืนเงินสดต้องมีกะที่เปิดอยู่ Payment ที่เกิดในกะจะผูก ShiftID ไว้
+// ตอนปิดกะบันทึกยอดที่ควรมี (ExpectedCash) กับยอดที่นับได้จริง และส่วนต่าง (Variance = นับได้ - ควรมี)
+type CashShift struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	TenantID  uint        `gorm:"not null;index" json:"tenant_id"`
+	BranchID  uint        `gorm:"not null;index:uq_cash_shifts_open,unique,where:status = 'OPEN'" json:"branch_id"`
+	CashierID uint        `gorm:"not null;index;index:uq_cash_shifts_open,unique,where:status = 'OPEN'" json:"cashier_id"`
+	Status    ShiftStatus `gorm:"type:varchar(10);not null;default:'OPEN'" json:"status"`
+
+	OpeningFloat Money  `gorm:"type:numeric(12,2);not null;default:0" json:"opening_float"` // เงินทอนตั้งต้นในลิ้นชัก
+	ExpectedCash *Money `gorm:"type:numeric(12,2)" json:"expected_cash,omitempty"`          // บันทึกตอนปิดกะ
+	CountedCash  *Money `gorm:"type:numeric(12,2)" json:"counted_cash,omitempty"`
+	Variance     *Money `gorm:"type:numeric(12,2)" json:"variance,omitempty"` // ติดลบ = เงินขาด
+	CloseNote    string `gorm:"type:text" json:"close_note,omitempty"`
+	ClosedBy     *uint  `json:"closed_by,omitempty"`
+
+	CashMovements []CashMovement `gorm:"foreignKey:ShiftID" json:"cash_movements,omitempty"`
+
+	OpenedAt time.Time  `gorm:"not null;index" json:"opened_at"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+}
+
+type CashMovementType string
+
+const (
+	CashIn  CashMovementType = "CASH_IN"  // เติมเงินเข้าลิ้นชัก
+	CashOut CashMovementType = "CASH_OUT" // นำเงินออก (ฝากธนาคาร จ่ายค่าของ จ่ายทิป)
+)
+
+// CashMovement เงินสดเข้า/ออกลิ้นชักที่ไม่ใช่การขาย ต้องมีเหตุผล
+type CashMovement struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	TenantID  uint             `gorm:"not null;index" json:"tenant_id"`
+	ShiftID   uint             `gorm:"not null;index" json:"shift_id"`
+	Type      CashMovementType `gorm:"type:varchar(10);not null" json:"type"`
+	Amount    Money            `gorm:"type:numeric(12,2);not null" json:"amount"`
+	Reason    string           `gorm:"type:text;not null" json:"reason"`
+	CreatedBy *uint            `json:"created_by,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// CashSummary ที่มาของยอดเงินสดที่ควรมีในลิ้นชัก
+type CashSummary struct {
+	OpeningFloat Money `json:"opening_float"`
+	CashSales    Money `json:"cash_sales"`   // เงินสดที่รับ (รวมทิป) หักเงินทอน
+	CashRefunds  Money `json:"cash_refunds"` // เงินสดที่คืนลูกค้า
+	CashIn       Money `json:"cash_in"`
+	CashOut      Money `json:"cash_out"`
+	Expected     Money `json:"expected"`
+}
+
+// MethodTotal ยอดรวมตามช่องทางชำระ
+type MethodTotal struct {
+	Method PaymentMethod `json:"method"`
+	Count  int           `json:"count"`
+	Amount Money         `json:"amount"` // ยอดสุทธิ (เงินสดหักเงินทอนแล้ว) ไม่รวมทิป
+	Tips   Money         `json:"tips"`
+}
+
+// ShiftReport สรุปกะ (ใช้ทั้งกะที่เปิดอยู่และที่ปิดแล้ว)
+type ShiftReport struct {
+	Shift    CashShift     `json:"shift"`
+	Cash     CashSummary   `json:"cash"`
+	Payments []MethodTotal `json:"payments"`
+	Refunds  []MethodTotal `json:"refunds"`
+}
+
+// ZReport สรุปยอดขายของสาขาในช่วงเวลา (ปกติคือหนึ่งวัน) คำนวณจากบิลและการชำระทุกครั้งที่เรียก
+// ยอดขายนับจากบิลที่ชำระครบในช่วงนั้น (รวมบิลที่ถูก VOID ภายหลัง ซึ่งไปโผล่ที่ยอดคืนเงิน)
+type ZReport struct {
+	TenantID uint      `json:"tenant_id"`
+	BranchID uint      `json:"branch_id"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+
+	// NetSales = ServiceSales + ProductSales - LineDiscounts - BillDiscounts (ไม่รวมบิลที่ VOID)
+	SalesCount    int   `json:"sales_count"`
+	VoidCount     int   `json:"void_count"` // บิลที่ VOID ในช่วงนี้
+	ServiceSales  Money `json:"service_sales"`
+	ProductSales  Money `json:"product_sales"`
+	LineDiscounts Money `json:"line_discounts"`
+	BillDiscounts Money `json:"bill_discounts"`
+	NetSales      Money `json:"net_sales"` // ยอดบิลหลังส่วนลด
+	Tips          Money `json:"tips"`
+	RefundTotal   Money `json:"refund_total"`
+
+	Payments []MethodTotal `json:"payments"`
+	Refunds  []MethodTotal `json:"refunds"`
+	Shifts   []CashShift   `json:"shifts"` // กะที่ปิดในช่วงนี้ พร้อมยอดนับและส่วนต่าง
+}
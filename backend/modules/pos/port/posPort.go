@@ -0,0 +1,236 @@
+package posPort
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	posModels "myapp/modules/pos/models"
+)
+
+// SaleItemInput รายการที่จะเพิ่มในบิล
+// SERVICE: ต้องมี service_id ถ้าไม่ส่งชื่อ/ราคาจะใช้ของ service
+// PRODUCT: ส่ง product_id (ชื่อ/ราคาจากแคตตาล็อก และตัดสต๊อกสาขา) หรือ name และ unit_price สำหรับสินค้านอกแคตตาล็อก
+// ยอดเงินส่งเป็นบาท ทศนิยมไม่เกิน 2 ตำแหน่ง (ตัวเลขหรือสตริง)
+type SaleItemInput struct {
+	ItemType  posModels.ItemType `json:"item_type" example:"SERVICE"`
+	ServiceID *uint              `json:"service_id,omitempty" example:"3"`
+	ProductID *uint              `json:"product_id,omitempty"`
+	BarberID  *uint              `json:"barber_id,omitempty" example:"2"`
+	Name      string             `json:"name,omitempty" example:"ตัดผมชาย"`
+	Quantity  int                `json:"quantity" example:"1"`
+	UnitPrice *posModels.Money   `json:"unit_price,omitempty" swaggertype:"number" example:"250"` // เฉพาะ PRODUCT (SERVICE ใช้ราคาจาก service เสมอ)
+	Discount  posModels.Money    `json:"discount,omitempty" swaggertype:"number" example:"0"`
+}
+
+type CreateSaleRequest struct {
+	BranchID   uint            `json:"branch_id" example:"1"`
+	CustomerID *uint           `json:"customer_id,omitempty" example:"10"`
+	Items      []SaleItemInput `json:"items"`
+	Discount   posModels.Money `json:"discount,omitempty" swaggertype:"number" example:"0"`
+	Notes      string          `json:"notes,omitempty"`
+}
+
+type AddPaymentRequest struct {
+	Method    posModels.PaymentMethod `json:"method" example:"CASH"`
+	Amount    posModels.Money         `json:"amount" swaggertype:"number" example:"300"`
+	Tip       posModels.Money         `json:"tip,omitempty" swaggertype:"number" example:"20"` // ทิปที่จ่ายเพิ่มด้วยช่องทางเดียวกัน ไม่นับเข้ายอดบิล
+	Reference string                  `json:"reference,omitempty" example:"SLIP-001"`
+}
+
+type VoidSaleRequest struct {
+	Reason string `json:"reason" example:"ลูกค้าเปลี่ยนใจ"`
+}
+
+type SaleFilter struct {
+	BranchID *uint
+	Status   posModels.SaleStatus
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type IPOSService interface {
+	CreateSale(ctx context.Context, tenantID uint, req CreateSaleRequest, cashierID *uint) (*posModels.Sale, error)
+	// เปิดบิลจากนัด (IN_SERVICE หรือ COMPLETED) โดยใช้รายการและราคาที่บันทึกไว้ในนัด
+	CreateSaleFromAppointment(ctx context.Context, tenantID, appointmentID uint, cashierID *uint) (*posModels.Sale, error)
+	GetSale(ctx context.Context, tenantID, saleID uint) (*posModels.Sale, error)
+	ListSales(ctx context.Context, tenantID uint, filter SaleFilter) ([]posModels.Sale, error)
+
+	// แก้รายการได้เฉพาะบิล OPEN ที่ยังไม่มีการชำระ
+	AddItem(ctx context.Context, tenantID, saleID uint, input SaleItemInput) (*posModels.Sale, error)
+	RemoveItem(ctx context.Context, tenantID, saleID, itemID uint) (*posModels.Sale, error)
+
+	// รับชำระ (split ได้) บิลเป็น PAID เมื่อยอดชำระครบ
+	AddPayment(ctx context.Context, tenantID, saleID uint, req AddPaymentRequest, receivedBy *uint) (*posModels.Sale, error)
+	// บิลที่รับเงินแล้วจะบันทึกรายการ REFUND คืนตามช่องทางที่รับมา
+	VoidSale(ctx context.Context, tenantID, saleID uint, reason string, receivedBy *uint) (*posModels.Sale, error)
+}
+
+type OpenShiftRequest struct {
+	BranchID     uint            `json:"branch_id" example:"1"`
+	OpeningFloat posModels.Money `json:"opening_float" swaggertype:"number" example:"1000"`
+}
+
+type CashMovementRequest struct {
+	Type   posModels.CashMovementType `json:"type" example:"CASH_OUT"`
+	Amount posModels.Money            `json:"amount" swaggertype:"number" example:"500"`
+	Reason string                     `json:"reason" example:"นำเงินฝากธนาคาร"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash posModels.Money `json:"counted_cash" swaggertype:"number" example:"3250"`
+	Note        string          `json:"note,omitempty"`
+}
+
+type ShiftFilter struct {
+	BranchID  *uint
+	CashierID *uint
+	Status    posModels.ShiftStatus
+	From      *time.Time // opened_at >= From
+	To        *time.Time // opened_at < To
+	Limit     int
+	Offset    int
+}
+
+type IShiftService interface {
+	// เปิดกะของแคชเชียร์ (ผู้เรียก) ในสาขา เปิดซ้ำระหว่างที่ยังมีกะเปิดอยู่ไม่ได้
+	OpenShift(ctx context.Context, tenantID uint, req OpenShiftRequest, cashierID uint) (*posModels.CashShift, error)
+	GetCurrentShift(ctx context.Context, tenantID, branchID, cashierID uint) (*posModels.ShiftReport, error)
+	GetShiftReport(ctx context.Context, tenantID, shiftID uint) (*posModels.ShiftReport, error)
+	ListShifts(ctx context.Context, tenantID uint, filter ShiftFilter) ([]posModels.CashShift, error)
+
+	// asManager = ผู้จัดการทำรายการแทนแคชเชียร์เจ้าของกะได้
+	AddCashMovement(ctx context.Context, tenantID, shiftID uint, req CashMovementRequest, actorID uint, asManager bool) (*posModels.CashMovement, error)
+	CloseShift(ctx context.Context, tenantID, shiftID uint, req CloseShiftRequest, actorID uint, asManager bool) (*posModels.ShiftReport, error)
+
+	// สรุปยอดของสาขาในช่วง [from, to)
+	GetZReport(ctx context.Context, tenantID, branchID uint, from, to time.Time) (*posModels.ZReport, error)
+}
+
+type SetPromptPayAccountRequest struct {
+	ProxyType   posModels.PromptPayProxyType `json:"proxy_type" example:"MOBILE"`
+	ProxyID     string                       `json:"proxy_id" example:"0812345678"`
+	AccountName string                       `json:"account_name,omitempty" example:"ร้านตัดผม สาขาสยาม"`
+}
+
+type CreatePromptPayChargeRequest struct {
+	// บิลขาย: ไม่ระบุ = ยอดค้างทั้งหมด, มัดจำนัด: ต้องระบุ
+	Amount *posModels.Money `json:"amount,omitempty" swaggertype:"number" example:"280"`
+}
+
+type ConfirmPromptPayRequest struct {
+	TransRef string `json:"trans_ref,omitempty" example:"2024010712345678"` // เลขอ้างอิงจากแอปธนาคาร (ถ้ามี)
+}
+
+// InboundWebhook request ดิบจากผู้ให้บริการภายนอก (ตรวจสลิป, payment gateway)
+type InboundWebhook struct {
+	Body   []byte
+	Header func(key string) string
+}
+
+// VerifiedSlip ข้อมูลสลิปที่ผู้ตรวจยืนยันแล้วว่าเป็นการโอนจริง
+type VerifiedSlip struct {
+	Reference       string          // reference ของ PromptPayCharge ที่ลูกค้าส่งสลิปมา
+	TransRef        string          // เลขอ้างอิงธุรกรรมของธนาคาร
+	Amount          posModels.Money // ยอดโอน
+	ReceiverProxyID string          // พร้อมเพย์ผู้รับตามสลิป (อาจถูกปิดบางหลัก)
+	// ผู้ตรวจสลิปยืนยันผู้รับกับบัญชีของร้านเองแล้ว (ใช้เมื่อสลิปไม่มีข้อมูลผู้รับ)
+	ReceiverVerified bool
+	PaidAt           time.Time
+}
+
+// ISlipVerifier ตรวจสลิปโอนเงินจาก webhook เปลี่ยนผู้ให้บริการได้โดยไม่ต้องแก้ flow การยืนยัน
+type ISlipVerifier interface {
+	VerifySlip(ctx context.Context, hook InboundWebhook) (*VerifiedSlip, error)
+}
+
+type IPromptPayService interface {
+	SetAccount(ctx context.Context, tenantID, branchID uint, req SetPromptPayAccountRequest) (*posModels.PromptPayAccount, error)
+	GetAccount(ctx context.Context, tenantID, branchID uint) (*posModels.PromptPayAccount, error)
+	// payload ของ QR สาขา amount เป็น nil = static
+	BranchPayload(ctx context.Context, tenantID, branchID uint, amount *posModels.Money) (string, error)
+
+	// ออก QR ระบุยอดให้บิล OPEN หรือมัดจำนัด QR เดิมที่ยังรอยืนยันของบิล/นัดเดียวกันจะถูกยกเลิก
+	CreateSaleCharge(ctx context.Context, tenantID, saleID uint, req CreatePromptPayChargeRequest, actorID *uint) (*posModels.PromptPayCharge, error)
+	CreateDepositCharge(ctx context.Context, tenantID, appointmentID uint, req CreatePromptPayChargeRequest, actorID *uint) (*posModels.PromptPayCharge, error)
+	GetCharge(ctx context.Context, tenantID, chargeID uint) (*posModels.PromptPayCharge, error)
+
+	// PENDING -> PAID โดยพนักงาน หรือโดย webhook สลิป แล้วบันทึกการชำระเข้าบิล
+	ConfirmCharge(ctx context.Context, tenantID, chargeID uint, req ConfirmPromptPayRequest, actorID uint) (*posModels.PromptPayCharge, error)
+	ConfirmSlip(ctx context.Context, hook InboundWebhook) (*posModels.PromptPayCharge, error)
+	CancelCharge(ctx context.Context, tenantID, chargeID uint) (*posModels.PromptPayCharge, error)
+}
+
+// ChargeRequest คำขอตัดเงินที่ส่งให้ผู้ให้บริการ
+type ChargeRequest struct {
+	Reference    string // reference ของ GatewayPayment (ผู้ให้บริการควรส่งกลับมาใน webhook)
+	Amount       posModels.Money
+	PaymentToken string // token บัตร/วิธีชำระที่ได้จาก SDK ฝั่งหน้าร้าน
+	Description  string
+}
+
+// ProviderTransaction สถานะธุรกรรมตามผู้ให้บริการ
+type ProviderTransaction struct {
+	TxnID          string
+	Reference      string
+	Status         posModels.GatewayPaymentStatus
+	Amount         posModels.Money
+	RefundedAmount posModels.Money // ยอดคืนสะสม
+	FailureReason  string
+}
+
+// ProviderEvent webhook ที่ผ่านการตรวจลายเซ็นแล้ว
+type ProviderEvent struct {
+	EventID     string
+	Transaction ProviderTransaction
+}
+
+// ErrTransactionNotFound ผู้ให้บริการไม่มีธุรกรรมที่ถาม (เช่น คำขอตัดเงินไปไม่ถึง)
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// IPaymentProvider payment gateway หนึ่งราย เพิ่มผู้ให้บริการได้โดยไม่ต้องแก้ flow การกระทบยอด
+type IPaymentProvider interface {
+	Name() string
+	Charge(ctx context.Context, req ChargeRequest) (*ProviderTransaction, error)
+	Refund(ctx context.Context, txnID string, amount posModels.Money) (*ProviderTransaction, error)
+	Status(ctx context.Context, txnID string) (*ProviderTransaction, error)
+	// ใช้เมื่อ Charge ตอบกลับเป็น error จึงยังไม่มีเลขธุรกรรม ไม่พบต้องคืน error ที่ห่อ ErrTransactionNotFound
+	StatusByReference(ctx context.Context, reference string) (*ProviderTransaction, error)
+	VerifyWebhook(ctx context.Context, hook InboundWebhook) (*ProviderEvent, error)
+}
+
+type CreateGatewayChargeRequest struct {
+	Provider     string           `json:"provider" example:"fake"`
+	PaymentToken string           `json:"payment_token" example:"tok_visa"`
+	Amount       *posModels.Money `json:"amount,omitempty" swaggertype:"number" example:"280"` // บิลขาย: ไม่ระบุ = ยอดค้างทั้งหมด, มัดจำนัด: ต้องระบุ
+}
+
+type RefundGatewayPaymentRequest struct {
+	Amount *posModels.Money `json:"amount,omitempty" swaggertype:"number" example:"100"` // ไม่ระบุ = คืนยอดที่เหลือทั้งหมด
+}
+
+type GatewayPaymentFilter struct {
+	BranchID      *uint
+	SaleID        *uint
+	AppointmentID *uint
+	Status        posModels.GatewayPaymentStatus
+	Limit         int
+	Offset        int
+}
+
+type IGatewayService interface {
+	// ตัดเงินให้บิล OPEN (ไม่เกินยอดค้างที่ยังไม่มีรายการรอผล) หรือมัดจำนัด
+	ChargeSale(ctx context.Context, tenantID, saleID uint, req CreateGatewayChargeRequest, actorID *uint) (*posModels.GatewayPayment, error)
+	ChargeDeposit(ctx context.Context, tenantID, appointmentID uint, req CreateGatewayChargeRequest, actorID *uint) (*posModels.GatewayPayment, error)
+	GetPayment(ctx context.Context, tenantID, paymentID uint) (*posModels.GatewayPayment, error)
+	ListPayments(ctx context.Context, tenantID uint, filter GatewayPaymentFilter) ([]posModels.GatewayPayment, error)
+	// คืนเงินผ่านผู้ให้บริการ แล้วบันทึก REFUND เข้าบิลตามยอดที่ผู้ให้บริการยืนยัน
+	RefundPayment(ctx context.Context, tenantID, paymentID uint, req RefundGatewayPaymentRequest) (*posModels.GatewayPayment, error)
+	// ดึงสถานะล่าสุดจากผู้ให้บริการมากระทบยอด
+	SyncPayment(ctx context.Context, tenantID, paymentID uint) (*posModels.GatewayPayment, error)
+
+	// webhook จากผู้ให้บริการ เหตุการณ์ที่เคยรับแล้วจะไม่ประมวลผลซ้ำ
+	HandleWebhook(ctx context.Context, provider string, hook InboundWebhook) (*posModels.GatewayPayment, error)
+}
@@ -0,0 +1,74 @@
+package posRoutes
+
+import (
+	middlewares "myapp/middlewares"
+	coremiddlewares "myapp/modules/core/middlewares"
+	coreModels "myapp/modules/core/models"
+	posControllers "myapp/modules/pos/controllers"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterSaleRoutes(router fiber.Router, db *gorm.DB, ctrl *posControllers.SaleController) {
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant(), coremiddlewares.RequireModule(db, coreModels.ModulePOS))
+
+	group.Get("/sales", ctrl.ListSales)
+	group.Post("/sales", ctrl.CreateSale)
+	group.Get("/sales/:sale_id", ctrl.GetSale)
+	group.Post("/sales/:sale_id/items", ctrl.AddItem)
+	group.Delete("/sales/:sale_id/items/:item_id", ctrl.RemoveItem)
+	group.Post("/sales/:sale_id/payments", ctrl.AddPayment)
+	group.Post("/sales/:sale_id/void", ctrl.VoidSale)
+
+	group.Post("/appointments/:appointment_id/sale", ctrl.CreateSaleFromAppointment)
+}
+
+func RegisterShiftRoutes(router fiber.Router, db *gorm.DB, ctrl *posControllers.ShiftController) {
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant(), coremiddlewares.RequireModule(db, coreModels.ModulePOS))
+
+	group.Get("/shifts", ctrl.ListShifts)
+	group.Post("/shifts", ctrl.OpenShift)
+	group.Get("/shifts/current", ctrl.GetCurrentShift)
+	group.Get("/shifts/:shift_id", ctrl.GetShift)
+	group.Post("/shifts/:shift_id/cash-movements", ctrl.AddCashMovement)
+	group.Post("/shifts/:shift_id/close", ctrl.CloseShift)
+
+	group.Get("/branches/:branch_id/z-report", ctrl.GetZReport)
+}
+
+func RegisterPromptPayRoutes(router fiber.Router, db *gorm.DB, ctrl *posControllers.PromptPayController) {
+	// ผู้ให้บริการตรวจสลิปเรียกเอง ไม่ต้อง login
+	router.Post("/webhooks/promptpay-slip", ctrl.SlipWebhook)
+
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant(), coremiddlewares.RequireModule(db, coreModels.ModulePOS))
+
+	group.Get("/branches/:branch_id/promptpay", ctrl.GetAccount)
+	group.Put("/branches/:branch_id/promptpay", ctrl.SetAccount)
+	group.Get("/branches/:branch_id/promptpay/qr", ctrl.GetBranchQR)
+
+	group.Post("/sales/:sale_id/promptpay", ctrl.CreateSaleCharge)
+	group.Post("/appointments/:appointment_id/deposit", ctrl.CreateDepositCharge)
+	group.Get("/promptpay/:charge_id", ctrl.GetCharge)
+	group.Get("/promptpay/:charge_id/qr", ctrl.GetChargeQR)
+	group.Post("/promptpay/:charge_id/confirm", ctrl.ConfirmCharge)
+	group.Post("/promptpay/:charge_id/cancel", ctrl.CancelCharge)
+}
+
+func RegisterGatewayRoutes(router fiber.Router, db *gorm.DB, ctrl *posControllers.GatewayController) {
+	// ผู้ให้บริการเรียกเอง ไม่ต้อง login
+	router.Post("/webhooks/gateway/:provider", ctrl.Webhook)
+
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant(), coremiddlewares.RequireModule(db, coreModels.ModulePOS))
+
+	group.Post("/sales/:sale_id/gateway", ctrl.ChargeSale)
+	group.Post("/appointments/:appointment_id/gateway-deposit", ctrl.ChargeDeposit)
+	group.Get("/gateway-payments", ctrl.ListPayments)
+	group.Get("/gateway-payments/:payment_id", ctrl.GetPayment)
+	group.Post("/gateway-payments/:payment_id/refund", ctrl.RefundPayment)
+	group.Post("/gateway-payments/:payment_id/sync", ctrl.SyncPayment)
+}
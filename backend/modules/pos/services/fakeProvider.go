@@ -0,0 +1,222 @@
+package posServices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+
+	"github.com/google/uuid"
+)
+
+// header ลายเซ็น webhook ของ FakeProvider (รูปแบบเดียวกับ webhook ตรวจสลิป)
+const (
+	HeaderGatewayTimestamp = "X-Gateway-Timestamp"
+	HeaderGatewaySignature = "X-Gateway-Signature"
+)
+
+// token ที่ FakeProvider ใช้จำลองผลการตัดเงิน token อื่นจะตัดเงินสำเร็จทันที
+const (
+	FakeTokenDecline   = "tok_decline"   // ถูกปฏิเสธ (FAILED)
+	FakeTokenAuthorize = "tok_authorize" // กันวงเงินไว้ก่อน ตัดเงินด้วย Capture
+	FakeTokenTimeout   = "tok_timeout"   // ตัดเงินได้แต่ตอบกลับเป็น error (ผลจริงมาทาง webhook หรือการถามสถานะ)
+	FakeTokenOffline   = "tok_offline"   // คำขอไปไม่ถึงผู้ให้บริการ ไม่มีธุรกรรมเกิดขึ้น
+)
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// FakeProvider payment gateway จำลองในหน่วยความจำ สำหรับทดสอบและ staging
+// ธุรกรรมหายเมื่อ process จบ และแต่ละ process (server, jobworker) มีชุดของตัวเอง
+type FakeProvider struct {
+	Secret string
+	Now    func() time.Time
+
+	mu   sync.Mutex
+	seq  int
+	txns map[string]*posPort.ProviderTransaction
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{Secret: secret, Now: time.Now, txns: map[string]*posPort.ProviderTransaction{}}
+}
+
+type fakeWebhookBody struct {
+	EventID     string             `json:"event_id"`
+	Transaction fakeTransactionDTO `json:"transaction"`
+}
+
+type fakeTransactionDTO struct {
+	TxnID          string                         `json:"txn_id"`
+	Reference      string                         `json:"reference"`
+	Status         posModels.GatewayPaymentStatus `json:"status"`
+	Amount         posModels.Money                `json:"amount"`
+	RefundedAmount posModels.Money                `json:"refunded_amount"`
+	FailureReason  string                         `json:"failure_reason,omitempty"`
+}
+
+func (p *FakeProvider) Name() string { return "fake" }
+
+func (p *FakeProvider) Charge(_ context.Context, req posPort.ChargeRequest) (*posPort.ProviderTransaction, error) {
+	if req.PaymentToken == FakeTokenOffline {
+		return nil, errors.New("fake provider: connection refused")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seq++
+	txn := &posPort.ProviderTransaction{
+		TxnID:     fmt.Sprintf("fake_txn_%d", p.seq),
+		Reference: req.Reference,
+		Status:    posModels.GatewayCaptured,
+		Amount:    req.Amount,
+	}
+	switch req.PaymentToken {
+	case FakeTokenDecline:
+		txn.Status = posModels.GatewayFailed
+		txn.FailureReason = "card declined"
+	case FakeTokenAuthorize:
+		txn.Status = posModels.GatewayAuthorized
+	}
+	p.txns[txn.TxnID] = txn
+	if req.PaymentToken == FakeTokenTimeout {
+		return nil, errors.New("fake provider: request timed out")
+	}
+	out := *txn
+	return &out, nil
+}
+
+func (p *FakeProvider) Refund(_ context.Context, txnID string, amount posModels.Money) (*posPort.ProviderTransaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	txn, ok := p.txns[txnID]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", txnID)
+	}
+	if txn.Status != posModels.GatewayCaptured {
+		return nil, fmt.Errorf("invalid status: transaction is %s", txn.Status)
+	}
+	if amount <= 0 || txn.RefundedAmount+amount > txn.Amount {
+		return nil, fmt.Errorf("invalid amount: refund exceeds captured amount")
+	}
+	txn.RefundedAmount += amount
+	if txn.RefundedAmount == txn.Amount {
+		txn.Status = posModels.GatewayRefunded
+	}
+	out := *txn
+	return &out, nil
+}
+
+func (p *FakeProvider) Status(_ context.Context, txnID string) (*posPort.ProviderTransaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	txn, ok := p.txns[txnID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", posPort.ErrTransactionNotFound, txnID)
+	}
+	out := *txn
+	return &out, nil
+}
+
+func (p *FakeProvider) StatusByReference(_ context.Context, reference string) (*posPort.ProviderTransaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, txn := range p.txns {
+		if txn.Reference == reference {
+			out := *txn
+			return &out, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: reference %s", posPort.ErrTransactionNotFound, reference)
+}
+
+func (p *FakeProvider) VerifyWebhook(_ context.Context, hook posPort.InboundWebhook) (*posPort.ProviderEvent, error) {
+	if err := verifySignedWebhook(p.Secret, hook, HeaderGatewayTimestamp, HeaderGatewaySignature, p.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+	}
+	var body fakeWebhookBody
+	if err := json.Unmarshal(hook.Body, &body); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	t := body.Transaction
+	return &posPort.ProviderEvent{
+		EventID: body.EventID,
+		Transaction: posPort.ProviderTransaction{
+			TxnID:          t.TxnID,
+			Reference:      t.Reference,
+			Status:         t.Status,
+			Amount:         t.Amount,
+			RefundedAmount: t.RefundedAmount,
+			FailureReason:  t.FailureReason,
+		},
+	}, nil
+}
+
+// Capture ตัดเงินรายการที่กันวงเงินไว้ (จำลองการ capture ฝั่งผู้ให้บริการ)
+func (p *FakeProvider) Capture(txnID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	txn, ok := p.txns[txnID]
+	if !ok {
+		return fmt.Errorf("transaction %s not found", txnID)
+	}
+	if txn.Status != posModels.GatewayAuthorized {
+		return fmt.Errorf("invalid status: transaction is %s", txn.Status)
+	}
+	txn.Status = posModels.GatewayCaptured
+	return nil
+}
+
+// TxnByReference เลขธุรกรรมของ reference (ใช้เมื่อ Charge ตอบกลับเป็น error)
+func (p *FakeProvider) TxnByReference(reference string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, txn := range p.txns {
+		if txn.Reference == reference {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Webhook สร้าง webhook พร้อมลายเซ็นที่แจ้งสถานะปัจจุบันของธุรกรรม แต่ละครั้งได้ event_id ใหม่
+func (p *FakeProvider) Webhook(txnID string) (posPort.InboundWebhook, error) {
+	p.mu.Lock()
+	txn, ok := p.txns[txnID]
+	var body []byte
+	var err error
+	if ok {
+		body, err = json.Marshal(fakeWebhookBody{
+			EventID: "evt_" + uuid.NewString(),
+			Transaction: fakeTransactionDTO{
+				TxnID:          txn.TxnID,
+				Reference:      txn.Reference,
+				Status:         txn.Status,
+				Amount:         txn.Amount,
+				RefundedAmount: txn.RefundedAmount,
+				FailureReason:  txn.FailureReason,
+			},
+		})
+	}
+	p.mu.Unlock()
+	if !ok {
+		return posPort.InboundWebhook{}, fmt.Errorf("transaction %s not found", txnID)
+	}
+	if err != nil {
+		return posPort.InboundWebhook{}, err
+	}
+	return SignedWebhook(p.Secret, body, p.Now(), HeaderGatewayTimestamp, HeaderGatewaySignature), nil
+}
+
+// SignedWebhook สร้าง request ที่ลงลายเซ็นแบบเดียวกับที่ verifySignedWebhook ตรวจ
+func SignedWebhook(secret string, body []byte, at time.Time, timestampHeader, signatureHeader string) posPort.InboundWebhook {
+	headers := map[string]string{
+		timestampHeader: strconv.FormatInt(at.Unix(), 10),
+		signatureHeader: signBody(secret, at.Unix(), body),
+	}
+	return posPort.InboundWebhook{Body: body, Header: func(key string) string { return headers[key] }}
+}
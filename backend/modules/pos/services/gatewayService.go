@@ -0,0 +1,634 @@
+package posServices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"myapp/jobs"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobReconcileGatewayPayments งานถามสถานะรายการที่ค้างรอผลจากผู้ให้บริการ (ประมวลผลโดย cmd/jobworker)
+const JobReconcileGatewayPayments = "pos.gateway_reconcile"
+
+// รายการ PENDING/AUTHORIZED ที่ไม่มีความเคลื่อนไหวนานกว่านี้จะถูกถามสถานะจากผู้ให้บริการ ครั้งละไม่เกิน batch
+const (
+	gatewayReconcileAfter = 5 * time.Minute
+	gatewayReconcileBatch = 100
+)
+
+// gatewayStatusRank ลำดับสถานะ เปลี่ยนได้เฉพาะไปสถานะที่สูงกว่า
+// webhook ที่มาช้ากว่าหรือส่งซ้ำจึงไม่ย้อนสถานะ และ FAILED ยังแก้เป็น CAPTURED ได้เมื่อผู้ให้บริการยืนยัน
+var gatewayStatusRank = map[posModels.GatewayPaymentStatus]int{
+	posModels.GatewayPending:    0,
+	posModels.GatewayAuthorized: 1,
+	posModels.GatewayFailed:     2,
+	posModels.GatewayCaptured:   3,
+	posModels.GatewayRefunded:   4,
+}
+
+type GatewayService struct {
+	DB        *gorm.DB
+	Now       func() time.Time
+	providers map[string]posPort.IPaymentProvider
+}
+
+func NewGatewayService(db *gorm.DB, providers ...posPort.IPaymentProvider) *GatewayService {
+	s := &GatewayService{DB: db, Now: time.Now, providers: map[string]posPort.IPaymentProvider{}}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// DefaultPaymentProviders ผู้ให้บริการที่เปิดใช้ตาม env
+// PAYMENT_FAKE_WEBHOOK_SECRET เปิด provider "fake" (จำลองในหน่วยความจำ ห้ามใช้กับ production)
+func DefaultPaymentProviders() []posPort.IPaymentProvider {
+	var providers []posPort.IPaymentProvider
+	if secret := os.Getenv("PAYMENT_FAKE_WEBHOOK_SECRET"); secret != "" {
+		providers = append(providers, NewFakeProvider(secret))
+	}
+	return providers
+}
+
+// RegisterJobs ผูก handler กระทบยอดรายการค้างกับ worker
+func (s *GatewayService) RegisterJobs(w *jobs.Worker) {
+	w.Register(JobReconcileGatewayPayments, jobs.Typed(func(ctx context.Context, _ struct{}) error {
+		n, err := s.ReconcilePending(ctx)
+		if err == nil && n > 0 {
+			log.Printf("pos: reconciled %d gateway payments", n)
+		}
+		return err
+	}))
+}
+
+func (s *GatewayService) ChargeSale(ctx context.Context, tenantID, saleID uint, req posPort.CreateGatewayChargeRequest, actorID *uint) (*posModels.GatewayPayment, error) {
+	provider, err := s.chargeProvider(req)
+	if err != nil {
+		return nil, err
+	}
+	var payment *posModels.GatewayPayment
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sale, err := lockSale(tx, tenantID, saleID)
+		if err != nil {
+			return err
+		}
+		if sale.Status != posModels.SaleOpen {
+			return fmt.Errorf("invalid status: sale is %s", sale.Status)
+		}
+		if len(sale.Items) == 0 {
+			return errors.New("invalid sale: no items")
+		}
+		// ยอดที่ยังรอผลจากผู้ให้บริการถือว่าจองไว้แล้ว กันตัดเงินเกินยอดบิล
+		var inflight []posModels.GatewayPayment
+		if err := tx.Where("sale_id = ? AND status IN ?", sale.ID, []posModels.GatewayPaymentStatus{posModels.GatewayPending, posModels.GatewayAuthorized}).
+			Find(&inflight).Error; err != nil {
+			return fmt.Errorf("failed to fetch gateway payments: %w", err)
+		}
+		amount := sale.Balance()
+		for _, p := range inflight {
+			amount -= p.Amount
+		}
+		if amount <= 0 {
+			return errors.New("invalid sale: balance due is already being charged")
+		}
+		if req.Amount != nil {
+			if *req.Amount <= 0 || *req.Amount > amount {
+				return fmt.Errorf("invalid amount: must be between 0.01 and %s", amount)
+			}
+			amount = *req.Amount
+		}
+		id := sale.ID
+		payment, err = s.createPaymentTx(tx, tenantID, sale.BranchID, provider.Name(), amount, actorID, func(p *posModels.GatewayPayment) { p.SaleID = &id })
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.charge(ctx, provider, payment, req.PaymentToken, fmt.Sprintf("Sale %d", saleID))
+}
+
+func (s *GatewayService) ChargeDeposit(ctx context.Context, tenantID, appointmentID uint, req posPort.CreateGatewayChargeRequest, actorID *uint) (*posModels.GatewayPayment, error) {
+	if req.Amount == nil || *req.Amount <= 0 {
+		return nil, errors.New("invalid amount: deposit amount is required")
+	}
+	provider, err := s.chargeProvider(req)
+	if err != nil {
+		return nil, err
+	}
+	var payment *posModels.GatewayPayment
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ap, err := lockDepositAppointment(tx, tenantID, appointmentID)
+		if err != nil {
+			return err
+		}
+		id := ap.ID
+		payment, err = s.createPaymentTx(tx, tenantID, ap.BranchID, provider.Name(), *req.Amount, actorID, func(p *posModels.GatewayPayment) { p.AppointmentID = &id })
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.charge(ctx, provider, payment, req.PaymentToken, fmt.Sprintf("Deposit for appointment %d", appointmentID))
+}
+
+func (s *GatewayService) GetPayment(ctx context.Context, tenantID, paymentID uint) (*posModels.GatewayPayment, error) {
+	var p posModels.GatewayPayment
+	if err := s.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", paymentID, tenantID).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("gateway payment with ID %d not found", paymentID)
+		}
+		return nil, fmt.Errorf("failed to fetch gateway payment: %w", err)
+	}
+	return &p, nil
+}
+
+func (s *GatewayService) ListPayments(ctx context.Context, tenantID uint, filter posPort.GatewayPaymentFilter) ([]posModels.GatewayPayment, error) {
+	q := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filter.BranchID != nil {
+		q = q.Where("branch_id = ?", *filter.BranchID)
+	}
+	if filter.SaleID != nil {
+		q = q.Where("sale_id = ?", *filter.SaleID)
+	}
+	if filter.AppointmentID != nil {
+		q = q.Where("appointment_id = ?", *filter.AppointmentID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSaleListLimit
+	}
+	if limit > maxSaleListLimit {
+		limit = maxSaleListLimit
+	}
+
+	var payments []posModels.GatewayPayment
+	if err := q.Order("created_at DESC, id DESC").Limit(limit).Offset(filter.Offset).Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch gateway payments: %w", err)
+	}
+	return payments, nil
+}
+
+// RefundPayment จองยอดคืนไว้ใต้ lock ก่อนเรียกผู้ให้บริการ คำขอคืนเงินพร้อมกันจึงคืนรวมกันเกินยอดที่ตัดได้ไม่ได้
+// ได้ผลแล้ว (สำเร็จหรือไม่) จะปล่อยยอดที่จองในทรานแซกชันเดียวกับการกระทบยอด
+func (s *GatewayService) RefundPayment(ctx context.Context, tenantID, paymentID uint, req posPort.RefundGatewayPaymentRequest) (*posModels.GatewayPayment, error) {
+	var p posModels.GatewayPayment
+	var provider posPort.IPaymentProvider
+	var amount posModels.Money
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND tenant_id = ?", paymentID, tenantID).First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("gateway payment with ID %d not found", paymentID)
+			}
+			return fmt.Errorf("failed to fetch gateway payment: %w", err)
+		}
+		var err error
+		if provider, err = s.provider(p.Provider); err != nil {
+			return err
+		}
+		if p.Status != posModels.GatewayCaptured || p.ProviderTxnID == nil {
+			return fmt.Errorf("invalid status: gateway payment is %s", p.Status)
+		}
+		amount = p.Amount - p.RefundedAmount - p.RefundPending
+		if amount <= 0 {
+			return errors.New("invalid amount: nothing left to refund")
+		}
+		if req.Amount != nil {
+			if *req.Amount <= 0 || *req.Amount > amount {
+				return fmt.Errorf("invalid amount: must be between 0.01 and %s", amount)
+			}
+			amount = *req.Amount
+		}
+		if err := tx.Model(&p).Update("refund_pending", p.RefundPending+amount).Error; err != nil {
+			return fmt.Errorf("failed to reserve refund: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txn, refundErr := provider.Refund(ctx, *p.ProviderTxnID, amount)
+	var payment *posModels.GatewayPayment
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row posModels.GatewayPayment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&row, p.ID).Error; err != nil {
+			return fmt.Errorf("failed to fetch gateway payment: %w", err)
+		}
+		if err := tx.Model(&row).Update("refund_pending", max(0, row.RefundPending-amount)).Error; err != nil {
+			return fmt.Errorf("failed to release refund: %w", err)
+		}
+		if refundErr != nil {
+			return nil
+		}
+		var err error
+		payment, err = s.reconcileTx(tx, p.Provider, *txn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refundErr != nil {
+		return nil, fmt.Errorf("failed to refund gateway payment: %w", refundErr)
+	}
+	return payment, nil
+}
+
+func (s *GatewayService) SyncPayment(ctx context.Context, tenantID, paymentID uint) (*posModels.GatewayPayment, error) {
+	p, err := s.GetPayment(ctx, tenantID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.provider(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := providerStatus(ctx, provider, p)
+	if p.ProviderTxnID == nil && errors.Is(err, posPort.ErrTransactionNotFound) {
+		// ผลการตัดเงินยังไม่มาถึงผู้ให้บริการ ปล่อยให้ ReconcilePending ตัดสินเมื่อพ้นเวลารอ
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction status: %w", err)
+	}
+	return s.reconcile(ctx, p.Provider, *txn)
+}
+
+// HandleWebhook ตรวจลายเซ็นด้วยผู้ให้บริการที่ระบุ แล้วกระทบยอดตามสถานะใน event
+// event_id ที่เคยรับแล้วจะคืนรายการปัจจุบันโดยไม่ประมวลผลซ้ำ
+func (s *GatewayService) HandleWebhook(ctx context.Context, providerName string, hook posPort.InboundWebhook) (*posModels.GatewayPayment, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	event, err := provider.VerifyWebhook(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	if event.EventID == "" || (event.Transaction.TxnID == "" && event.Transaction.Reference == "") {
+		return nil, errors.New("invalid webhook: event_id and transaction are required")
+	}
+
+	var payment *posModels.GatewayPayment
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var seen posModels.GatewayEvent
+		err := tx.Where("provider = ? AND event_id = ?", provider.Name(), event.EventID).First(&seen).Error
+		if err == nil {
+			var p posModels.GatewayPayment
+			if err := tx.First(&p, seen.GatewayPaymentID).Error; err != nil {
+				return fmt.Errorf("failed to fetch gateway payment: %w", err)
+			}
+			payment = &p
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to fetch gateway event: %w", err)
+		}
+
+		if payment, err = s.reconcileTx(tx, provider.Name(), event.Transaction); err != nil {
+			return err
+		}
+		// webhook เดียวกันที่เข้ามาพร้อมกันจะรอ lock ของรายการ แล้วกระทบยอดซ้ำได้ผลเหมือนเดิม
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&posModels.GatewayEvent{
+			Provider:         provider.Name(),
+			EventID:          event.EventID,
+			ProviderTxnID:    event.Transaction.TxnID,
+			Status:           event.Transaction.Status,
+			GatewayPaymentID: payment.ID,
+			ReceivedAt:       s.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to record gateway event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// ReconcilePending ถามสถานะรายการที่ค้าง PENDING/AUTHORIZED จากผู้ให้บริการ (กรณี webhook หาย)
+// รายการที่ยังไม่มีเลขธุรกรรม (Charge ตอบกลับเป็น error) ถามด้วย reference ถ้าผู้ให้บริการไม่พบธุรกรรมถือว่าไม่ได้ตัดเงิน (FAILED)
+// คืนจำนวนรายการที่กระทบยอดสำเร็จ รายการที่ถามไม่สำเร็จจะถูกลองใหม่ในรอบถัดไป
+func (s *GatewayService) ReconcilePending(ctx context.Context) (int, error) {
+	var pending []posModels.GatewayPayment
+	if err := s.DB.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?",
+			[]posModels.GatewayPaymentStatus{posModels.GatewayPending, posModels.GatewayAuthorized}, s.Now().Add(-gatewayReconcileAfter)).
+		Order("updated_at, id").
+		Limit(gatewayReconcileBatch).
+		Find(&pending).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch pending gateway payments: %w", err)
+	}
+	n := 0
+	for _, p := range pending {
+		provider, err := s.provider(p.Provider)
+		if err != nil {
+			continue
+		}
+		txn, err := providerStatus(ctx, provider, &p)
+		if p.ProviderTxnID == nil && errors.Is(err, posPort.ErrTransactionNotFound) {
+			txn, err = &posPort.ProviderTransaction{
+				Reference:     p.Reference,
+				Status:        posModels.GatewayFailed,
+				FailureReason: "charge was not received by the provider",
+			}, nil
+		}
+		if err != nil {
+			log.Printf("pos: gateway payment %d status check failed: %v", p.ID, err)
+			continue
+		}
+		if _, err := s.reconcile(ctx, p.Provider, *txn); err != nil {
+			log.Printf("pos: gateway payment %d reconcile failed: %v", p.ID, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// providerStatus สถานะธุรกรรมของรายการ ยังไม่มีเลขธุรกรรมจะถามด้วย reference
+func providerStatus(ctx context.Context, provider posPort.IPaymentProvider, p *posModels.GatewayPayment) (*posPort.ProviderTransaction, error) {
+	if p.ProviderTxnID != nil {
+		return provider.Status(ctx, *p.ProviderTxnID)
+	}
+	return provider.StatusByReference(ctx, p.Reference)
+}
+
+func (s *GatewayService) provider(name string) (posPort.IPaymentProvider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider %q not found", name)
+	}
+	return p, nil
+}
+
+func (s *GatewayService) chargeProvider(req posPort.CreateGatewayChargeRequest) (posPort.IPaymentProvider, error) {
+	if strings.TrimSpace(req.PaymentToken) == "" {
+		return nil, errors.New("invalid payment_token: required")
+	}
+	return s.provider(req.Provider)
+}
+
+func (s *GatewayService) createPaymentTx(tx *gorm.DB, tenantID, branchID uint, provider string, amount posModels.Money, actorID *uint, link func(*posModels.GatewayPayment)) (*posModels.GatewayPayment, error) {
+	p := posModels.GatewayPayment{
+		TenantID:  tenantID,
+		BranchID:  branchID,
+		Provider:  provider,
+		Reference: "GW" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:18]),
+		Amount:    amount,
+		Status:    posModels.GatewayPending,
+		CreatedBy: actorID,
+	}
+	link(&p)
+	if err := tx.Create(&p).Error; err != nil {
+		return nil, fmt.Errorf("failed to create gateway payment: %w", err)
+	}
+	return &p, nil
+}
+
+// charge เรียกผู้ให้บริการนอก transaction แล้วกระทบยอดตามผลที่ได้
+// เรียกไม่สำเร็จ (timeout/เชื่อมต่อไม่ได้) ไม่รู้ว่าตัดเงินแล้วหรือยัง จึงคง PENDING ไว้และยังนับเป็นยอดรอผล
+// ผลจริงมาทาง webhook ที่อ้าง reference หรือ ReconcilePending ที่ถามสถานะด้วย reference
+func (s *GatewayService) charge(ctx context.Context, provider posPort.IPaymentProvider, p *posModels.GatewayPayment, token, description string) (*posModels.GatewayPayment, error) {
+	txn, err := provider.Charge(ctx, posPort.ChargeRequest{
+		Reference:    p.Reference,
+		Amount:       p.Amount,
+		PaymentToken: strings.TrimSpace(token),
+		Description:  description,
+	})
+	if err != nil {
+		log.Printf("pos: gateway payment %d charge result unknown: %v", p.ID, err)
+		return p, nil
+	}
+	return s.reconcile(ctx, provider.Name(), *txn)
+}
+
+func (s *GatewayService) reconcile(ctx context.Context, provider string, txn posPort.ProviderTransaction) (*posModels.GatewayPayment, error) {
+	var payment *posModels.GatewayPayment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		payment, err = s.reconcileTx(tx, provider, txn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// reconcileTx ปรับรายการให้ตรงกับสถานะของผู้ให้บริการ จับคู่ด้วยเลขธุรกรรม (หรือ reference ถ้ายังไม่เคยได้เลขธุรกรรม)
+// เรียกซ้ำด้วยสถานะเดิมได้ผลเหมือนเดิม: สถานะเปลี่ยนเฉพาะไปทางที่สูงกว่า และบันทึกเฉพาะยอดคืนที่เพิ่มขึ้น
+func (s *GatewayService) reconcileTx(tx *gorm.DB, provider string, txn posPort.ProviderTransaction) (*posModels.GatewayPayment, error) {
+	if _, ok := gatewayStatusRank[txn.Status]; !ok {
+		return nil, fmt.Errorf("invalid transaction status %q", txn.Status)
+	}
+	q := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("provider = ?", provider)
+	key := txn.TxnID
+	if txn.TxnID != "" {
+		q = q.Where("provider_txn_id = ? OR (provider_txn_id IS NULL AND reference = ?)", txn.TxnID, txn.Reference)
+	} else {
+		key = txn.Reference
+		q = q.Where("reference = ?", txn.Reference)
+	}
+	var p posModels.GatewayPayment
+	if err := q.First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("gateway payment for transaction %s not found", key)
+		}
+		return nil, fmt.Errorf("failed to fetch gateway payment: %w", err)
+	}
+	if txn.Amount != 0 && txn.Amount != p.Amount {
+		return nil, fmt.Errorf("invalid transaction: amount %s does not match %s", txn.Amount, p.Amount)
+	}
+	if txn.TxnID != "" && p.ProviderTxnID == nil {
+		id := txn.TxnID
+		p.ProviderTxnID = &id
+	}
+
+	now := s.Now()
+	if gatewayStatusRank[txn.Status] > gatewayStatusRank[p.Status] {
+		p.Status = txn.Status
+		switch txn.Status {
+		case posModels.GatewayAuthorized:
+			p.AuthorizedAt = &now
+		case posModels.GatewayFailed:
+			p.FailedAt = &now
+			p.FailureReason = txn.FailureReason
+		}
+		if (txn.Status == posModels.GatewayCaptured || txn.Status == posModels.GatewayRefunded) && p.CapturedAt == nil {
+			p.CapturedAt = &now
+			if err := recordGatewayCaptureTx(tx, &p, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.CapturedAt != nil && txn.RefundedAmount > p.RefundedAmount {
+		if txn.RefundedAmount > p.Amount {
+			return nil, fmt.Errorf("invalid transaction: refunded %s exceeds %s", txn.RefundedAmount, p.Amount)
+		}
+		previous := p.RefundedAmount
+		p.RefundedAmount = txn.RefundedAmount
+		p.RefundedAt = &now
+		if err := recordGatewayRefundTx(tx, &p, previous, now); err != nil {
+			return nil, err
+		}
+		if p.RefundedAmount == p.Amount {
+			p.Status = posModels.GatewayRefunded
+		}
+	}
+
+	if err := tx.Model(&p).Select(
+		"ProviderTxnID", "Status", "FailureReason", "Note", "SaleID", "PaymentID", "RefundedAmount",
+		"AuthorizedAt", "CapturedAt", "FailedAt", "RefundedAt",
+	).Updates(&p).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("transaction %s already exists", txn.TxnID)
+		}
+		return nil, fmt.Errorf("failed to update gateway payment: %w", err)
+	}
+	return &p, nil
+}
+
+// recordGatewayCaptureTx บันทึกยอดที่ตัดได้เข้าบิลของรายการ มัดจำที่นัดยังไม่มีบิลเปิดอยู่จะรอไปบันทึกตอนเปิดบิล (applyDepositsTx)
+func recordGatewayCaptureTx(tx *gorm.DB, p *posModels.GatewayPayment, now time.Time) error {
+	var sale *posModels.Sale
+	var err error
+	switch {
+	case p.SaleID != nil:
+		if sale, err = lockSale(tx, p.TenantID, *p.SaleID); err != nil {
+			return err
+		}
+	case p.AppointmentID != nil:
+		var open posModels.Sale
+		err := tx.Where("tenant_id = ? AND appointment_id = ? AND status = ?", p.TenantID, *p.AppointmentID, posModels.SaleOpen).
+			First(&open).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch sale: %w", err)
+		}
+		if sale, err = lockSale(tx, p.TenantID, open.ID); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	return recordGatewayPaymentTx(tx, sale, p, now)
+}
+
+// recordGatewayPaymentTx บันทึกยอดสุทธิ (หักยอดคืนแล้ว) เป็นการชำระด้วยบัตร ไม่เกินยอดค้างของบิล
+// เงินถูกตัดไปแล้วจึงไม่ปฏิเสธ ส่วนที่บันทึกไม่ได้จะเขียนไว้ใน Note ให้คืนผ่านผู้ให้บริการ
+func recordGatewayPaymentTx(tx *gorm.DB, sale *posModels.Sale, p *posModels.GatewayPayment, now time.Time) error {
+	net := p.Amount - p.RefundedAmount
+	if sale.Status != posModels.SaleOpen || len(sale.Items) == 0 || sale.Balance() <= 0 {
+		p.Note = fmt.Sprintf("%s not recorded: sale %d is %s with no balance due; refund it through the provider", net, sale.ID, sale.Status)
+		return nil
+	}
+	amount := min(net, sale.Balance())
+	payment := posModels.Payment{
+		Method:     posModels.PaymentCard,
+		Amount:     amount,
+		Reference:  p.Reference,
+		ReceivedBy: p.CreatedBy,
+	}
+	if err := applyPaymentTx(tx, sale, &payment, now); err != nil {
+		return err
+	}
+	if amount < net {
+		p.Note = fmt.Sprintf("%s exceeds the balance due of sale %d; refund it through the provider", net-amount, sale.ID)
+	}
+	saleID := sale.ID
+	p.SaleID = &saleID
+	p.PaymentID = &payment.ID
+	return nil
+}
+
+// recordGatewayRefundTx บันทึก REFUND เข้าบิลเฉพาะส่วนที่เคยบันทึกเป็นการชำระ
+// ยอดคืนนับจากส่วนที่ไม่ได้เข้าบิล (เกินยอดบิลหรือคืนก่อนเปิดบิล) ก่อน
+func recordGatewayRefundTx(tx *gorm.DB, p *posModels.GatewayPayment, previous posModels.Money, now time.Time) error {
+	if p.PaymentID == nil {
+		return nil
+	}
+	var recorded posModels.Payment
+	if err := tx.First(&recorded, *p.PaymentID).Error; err != nil {
+		return fmt.Errorf("failed to fetch payment: %w", err)
+	}
+	outside := p.Amount - recorded.Amount
+	amount := max(0, p.RefundedAmount-outside) - max(0, previous-outside)
+	if amount <= 0 {
+		return nil
+	}
+	sale, err := lockSale(tx, p.TenantID, recorded.SaleID)
+	if err != nil {
+		return err
+	}
+	refund := posModels.Payment{
+		TenantID:  sale.TenantID,
+		SaleID:    sale.ID,
+		Kind:      posModels.PaymentKindRefund,
+		Method:    posModels.PaymentCard,
+		Amount:    amount,
+		Reference: p.Reference,
+		CreatedAt: now,
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return fmt.Errorf("failed to create refund: %w", err)
+	}
+	sale.RefundedAmount += amount
+	if err := tx.Model(sale).Select("RefundedAmount").Updates(sale).Error; err != nil {
+		return fmt.Errorf("failed to update sale: %w", err)
+	}
+	return nil
+}
+
+// applyGatewayDepositsTx บันทึกมัดจำผ่าน gateway ที่ตัดเงินแล้วแต่ยังไม่เข้าบิล ให้เป็นการชำระของบิลที่เพิ่งเปิด
+func applyGatewayDepositsTx(tx *gorm.DB, sale *posModels.Sale, now time.Time) error {
+	var deposits []posModels.GatewayPayment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND appointment_id = ? AND status = ? AND payment_id IS NULL", sale.TenantID, *sale.AppointmentID, posModels.GatewayCaptured).
+		Order("id").
+		Find(&deposits).Error; err != nil {
+		return fmt.Errorf("failed to fetch deposits: %w", err)
+	}
+	for i := range deposits {
+		if sale.Balance() <= 0 {
+			break
+		}
+		if err := recordGatewayPaymentTx(tx, sale, &deposits[i], now); err != nil {
+			return err
+		}
+		if err := tx.Model(&deposits[i]).Select("SaleID", "PaymentID", "Note").Updates(&deposits[i]).Error; err != nil {
+			return fmt.Errorf("failed to link gateway payment: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensureNoCapturedGatewayTx บิลที่มีการชำระผ่าน gateway ต้องคืนเงินผ่านผู้ให้บริการก่อน VOID
+// มิฉะนั้นยอดคืนในบิลจะไม่ตรงกับเงินที่คืนลูกค้าจริง
+func ensureNoCapturedGatewayTx(tx *gorm.DB, sale *posModels.Sale) error {
+	var p posModels.GatewayPayment
+	err := tx.Where("sale_id = ? AND payment_id IS NOT NULL AND status = ?", sale.ID, posModels.GatewayCaptured).First(&p).Error
+	if err == nil {
+		return fmt.Errorf("invalid sale: refund gateway payment %s through the provider before voiding", p.Reference)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to fetch gateway payments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package posServices
+
+import (
+	"fmt"
+	"strings"
+
+	posModels "myapp/modules/pos/models"
+)
+
+// รหัสตาม EMVCo Merchant-Presented QR และ Thai QR Payment (พร้อมเพย์โอนเงิน)
+const (
+	promptPayAID         = "A000000677010111"
+	promptPayCurrencyTHB = "764"
+	promptPayCountry     = "TH"
+	promptPayStaticQR    = "11" // ผู้จ่ายกรอกยอดเอง ใช้ซ้ำได้
+	promptPayDynamicQR   = "12" // ระบุยอด ใช้ครั้งเดียว
+)
+
+// NormalizePromptPayID ตัดขีด/ช่องว่างและตรวจรูปแบบตามประเภท
+// เบอร์มือถือ 10 หลักขึ้นต้น 0, เลขบัตรประชาชน/ผู้เสียภาษี 13 หลัก, e-Wallet 15 หลัก
+func NormalizePromptPayID(proxyType posModels.PromptPayProxyType, id string) (string, error) {
+	var digits strings.Builder
+	for _, r := range id {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '-' || r == ' ':
+		default:
+			return "", fmt.Errorf("invalid proxy_id %q", id)
+		}
+	}
+	d := digits.String()
+	switch proxyType {
+	case posModels.PromptPayMobile:
+		if len(d) != 10 || d[0] != '0' {
+			return "", fmt.Errorf("invalid proxy_id: mobile number must be 10 digits starting with 0")
+		}
+	case posModels.PromptPayNationalID:
+		if len(d) != 13 {
+			return "", fmt.Errorf("invalid proxy_id: national/tax ID must be 13 digits")
+		}
+	case posModels.PromptPayEWallet:
+		if len(d) != 15 {
+			return "", fmt.Errorf("invalid proxy_id: e-wallet ID must be 15 digits")
+		}
+	default:
+		return "", fmt.Errorf("invalid proxy_type %q", proxyType)
+	}
+	return d, nil
+}
+
+// PromptPayPayload สร้างข้อความใน QR พร้อมเพย์ตามมาตรฐาน EMVCo
+// amount เป็น nil = QR แบบ static (ผู้จ่ายกรอกยอดเอง) มิฉะนั้นเป็น dynamic ระบุยอด
+// ท้าย payload คือ CRC16-CCITT (0x1021, เริ่ม 0xFFFF) ของทั้งข้อความรวม "6304"
+func PromptPayPayload(proxyType posModels.PromptPayProxyType, proxyID string, amount *posModels.Money) (string, error) {
+	id, err := NormalizePromptPayID(proxyType, proxyID)
+	if err != nil {
+		return "", err
+	}
+	var subTag string
+	switch proxyType {
+	case posModels.PromptPayMobile:
+		// 0812345678 -> 0066812345678
+		subTag, id = "01", "0066"+id[1:]
+	case posModels.PromptPayNationalID:
+		subTag = "02"
+	case posModels.PromptPayEWallet:
+		subTag = "03"
+	}
+
+	method := promptPayStaticQR
+	if amount != nil {
+		if *amount <= 0 {
+			return "", fmt.Errorf("invalid amount: must be greater than 0")
+		}
+		method = promptPayDynamicQR
+	}
+
+	var b strings.Builder
+	for _, f := range [][2]string{
+		{"00", "01"},
+		{"01", method},
+		{"29", emvField("00", promptPayAID) + emvField(subTag, id)},
+		{"58", promptPayCountry},
+		{"53", promptPayCurrencyTHB},
+	} {
+		b.WriteString(emvField(f[0], f[1]))
+	}
+	if amount != nil {
+		b.WriteString(emvField("54", amount.String()))
+	}
+	b.WriteString("6304")
+	return b.String() + fmt.Sprintf("%04X", crc16CCITT([]byte(b.String()))), nil
+}
+
+// emvField TLV: tag 2 หลัก + ความยาว 2 หลัก + ค่า
+func emvField(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,452 @@
+package posServices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	"myapp/utils/qrcode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// promptPayQRScale ขนาดพิกเซลต่อ module ของภาพ QR (ราว 300-500px)
+const promptPayQRScale = 8
+
+type PromptPayService struct {
+	DB       *gorm.DB
+	Verifier posPort.ISlipVerifier
+	Now      func() time.Time
+}
+
+func NewPromptPayService(db *gorm.DB, verifier posPort.ISlipVerifier) *PromptPayService {
+	return &PromptPayService{DB: db, Verifier: verifier, Now: time.Now}
+}
+
+// PromptPayPNG วาด payload เป็นภาพ QR (PNG)
+func PromptPayPNG(payload string) ([]byte, error) {
+	code, err := qrcode.Encode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr: %w", err)
+	}
+	return code.PNG(promptPayQRScale)
+}
+
+func (s *PromptPayService) SetAccount(ctx context.Context, tenantID, branchID uint, req posPort.SetPromptPayAccountRequest) (*posModels.PromptPayAccount, error) {
+	proxyID, err := NormalizePromptPayID(req.ProxyType, req.ProxyID)
+	if err != nil {
+		return nil, err
+	}
+	var acc posModels.PromptPayAccount
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, branchID); err != nil {
+			return err
+		}
+		if err := tx.Where("branch_id = ?", branchID).First(&acc).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to fetch promptpay account: %w", err)
+		}
+		acc.TenantID = tenantID
+		acc.BranchID = branchID
+		acc.ProxyType = req.ProxyType
+		acc.ProxyID = proxyID
+		acc.AccountName = strings.TrimSpace(req.AccountName)
+		if err := tx.Save(&acc).Error; err != nil {
+			return fmt.Errorf("failed to save promptpay account: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+func (s *PromptPayService) GetAccount(ctx context.Context, tenantID, branchID uint) (*posModels.PromptPayAccount, error) {
+	return promptPayAccountTx(s.DB.WithContext(ctx), tenantID, branchID)
+}
+
+func (s *PromptPayService) BranchPayload(ctx context.Context, tenantID, branchID uint, amount *posModels.Money) (string, error) {
+	acc, err := promptPayAccountTx(s.DB.WithContext(ctx), tenantID, branchID)
+	if err != nil {
+		return "", err
+	}
+	return PromptPayPayload(acc.ProxyType, acc.ProxyID, amount)
+}
+
+func (s *PromptPayService) CreateSaleCharge(ctx context.Context, tenantID, saleID uint, req posPort.CreatePromptPayChargeRequest, actorID *uint) (*posModels.PromptPayCharge, error) {
+	var charge *posModels.PromptPayCharge
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sale, err := lockSale(tx, tenantID, saleID)
+		if err != nil {
+			return err
+		}
+		if sale.Status != posModels.SaleOpen {
+			return fmt.Errorf("invalid status: sale is %s", sale.Status)
+		}
+		if len(sale.Items) == 0 {
+			return errors.New("invalid sale: no items")
+		}
+		amount := sale.Balance()
+		if req.Amount != nil {
+			if *req.Amount <= 0 || *req.Amount > amount {
+				return fmt.Errorf("invalid amount: must be between 0.01 and balance due %s", amount)
+			}
+			amount = *req.Amount
+		}
+		if err := tx.Model(&posModels.PromptPayCharge{}).
+			Where("sale_id = ? AND status = ?", sale.ID, posModels.PromptPayPending).
+			Updates(map[string]any{"status": posModels.PromptPayCancelled, "cancelled_at": s.Now()}).Error; err != nil {
+			return fmt.Errorf("failed to cancel previous charge: %w", err)
+		}
+		id := sale.ID
+		charge, err = s.createChargeTx(tx, tenantID, sale.BranchID, amount, actorID, func(c *posModels.PromptPayCharge) { c.SaleID = &id })
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+func (s *PromptPayService) CreateDepositCharge(ctx context.Context, tenantID, appointmentID uint, req posPort.CreatePromptPayChargeRequest, actorID *uint) (*posModels.PromptPayCharge, error) {
+	if req.Amount == nil || *req.Amount <= 0 {
+		return nil, errors.New("invalid amount: deposit amount is required")
+	}
+	var charge *posModels.PromptPayCharge
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ap, err := lockDepositAppointment(tx, tenantID, appointmentID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&posModels.PromptPayCharge{}).
+			Where("appointment_id = ? AND sale_id IS NULL AND status = ?", ap.ID, posModels.PromptPayPending).
+			Updates(map[string]any{"status": posModels.PromptPayCancelled, "cancelled_at": s.Now()}).Error; err != nil {
+			return fmt.Errorf("failed to cancel previous charge: %w", err)
+		}
+		id := ap.ID
+		charge, err = s.createChargeTx(tx, tenantID, ap.BranchID, *req.Amount, actorID, func(c *posModels.PromptPayCharge) { c.AppointmentID = &id })
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+func (s *PromptPayService) GetCharge(ctx context.Context, tenantID, chargeID uint) (*posModels.PromptPayCharge, error) {
+	var charge posModels.PromptPayCharge
+	if err := s.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", chargeID, tenantID).First(&charge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("promptpay charge with ID %d not found", chargeID)
+		}
+		return nil, fmt.Errorf("failed to fetch promptpay charge: %w", err)
+	}
+	return &charge, nil
+}
+
+func (s *PromptPayService) ConfirmCharge(ctx context.Context, tenantID, chargeID uint, req posPort.ConfirmPromptPayRequest, actorID uint) (*posModels.PromptPayCharge, error) {
+	var charge posModels.PromptPayCharge
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", chargeID, tenantID).
+			First(&charge).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("promptpay charge with ID %d not found", chargeID)
+			}
+			return fmt.Errorf("failed to fetch promptpay charge: %w", err)
+		}
+		if charge.Status != posModels.PromptPayPending {
+			return fmt.Errorf("invalid status: charge is %s", charge.Status)
+		}
+		var transRef *string
+		if ref := strings.TrimSpace(req.TransRef); ref != "" {
+			transRef = &ref
+		}
+		actor := actorID
+		return s.settleTx(tx, &charge, posModels.PromptPayByStaff, &actor, transRef)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &charge, nil
+}
+
+// ConfirmSlip ยืนยันจาก webhook ตรวจสลิป ส่งซ้ำด้วยสลิปเดิมได้ผลเหมือนเดิม
+// ยอดต้องตรงกับ QR พร้อมเพย์ผู้รับต้องตรงกับบัญชีที่ออก QR และโอนหลังออก QR
+func (s *PromptPayService) ConfirmSlip(ctx context.Context, hook posPort.InboundWebhook) (*posModels.PromptPayCharge, error) {
+	if s.Verifier == nil {
+		return nil, errors.New("slip verifier is not configured")
+	}
+	slip, err := s.Verifier.VerifySlip(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	if slip.Reference == "" || slip.TransRef == "" {
+		return nil, errors.New("invalid slip: reference and trans_ref are required")
+	}
+
+	var charge posModels.PromptPayCharge
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ?", slip.Reference).
+			First(&charge).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("promptpay charge %s not found", slip.Reference)
+			}
+			return fmt.Errorf("failed to fetch promptpay charge: %w", err)
+		}
+		if charge.Status == posModels.PromptPayPaid && charge.TransRef != nil && *charge.TransRef == slip.TransRef {
+			return nil
+		}
+		if charge.Status != posModels.PromptPayPending {
+			return fmt.Errorf("invalid status: charge is %s", charge.Status)
+		}
+		if slip.Amount != charge.Amount {
+			return fmt.Errorf("invalid slip: amount %s does not match %s", slip.Amount, charge.Amount)
+		}
+		if !proxyMatches(charge.ProxyID, slip.ReceiverProxyID, slip.ReceiverVerified) {
+			return errors.New("invalid slip: receiver does not match the promptpay account")
+		}
+		if !slip.PaidAt.IsZero() && slip.PaidAt.Before(charge.CreatedAt) {
+			return errors.New("invalid slip: transfer was made before the qr was issued")
+		}
+		ref := slip.TransRef
+		return s.settleTx(tx, &charge, posModels.PromptPayBySlip, nil, &ref)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &charge, nil
+}
+
+func (s *PromptPayService) CancelCharge(ctx context.Context, tenantID, chargeID uint) (*posModels.PromptPayCharge, error) {
+	var charge posModels.PromptPayCharge
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", chargeID, tenantID).
+			First(&charge).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("promptpay charge with ID %d not found", chargeID)
+			}
+			return fmt.Errorf("failed to fetch promptpay charge: %w", err)
+		}
+		if charge.Status != posModels.PromptPayPending {
+			return fmt.Errorf("invalid status: charge is %s", charge.Status)
+		}
+		now := s.Now()
+		charge.Status = posModels.PromptPayCancelled
+		charge.CancelledAt = &now
+		if err := tx.Model(&charge).Select("Status", "CancelledAt").Updates(&charge).Error; err != nil {
+			return fmt.Errorf("failed to cancel promptpay charge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &charge, nil
+}
+
+func (s *PromptPayService) createChargeTx(tx *gorm.DB, tenantID, branchID uint, amount posModels.Money, actorID *uint, link func(*posModels.PromptPayCharge)) (*posModels.PromptPayCharge, error) {
+	acc, err := promptPayAccountTx(tx, tenantID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := PromptPayPayload(acc.ProxyType, acc.ProxyID, &amount)
+	if err != nil {
+		return nil, err
+	}
+	charge := posModels.PromptPayCharge{
+		TenantID:  tenantID,
+		BranchID:  branchID,
+		Reference: "PP" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:18]),
+		Amount:    amount,
+		ProxyType: acc.ProxyType,
+		ProxyID:   acc.ProxyID,
+		Payload:   payload,
+		Status:    posModels.PromptPayPending,
+		CreatedBy: actorID,
+		CreatedAt: s.Now(),
+	}
+	link(&charge)
+	if err := tx.Create(&charge).Error; err != nil {
+		return nil, fmt.Errorf("failed to create promptpay charge: %w", err)
+	}
+	return &charge, nil
+}
+
+// settleTx ทำเครื่องหมาย PAID แล้วบันทึกการชำระเข้าบิล
+// มัดจำที่ยังไม่มีบิลเปิดอยู่จะรอไปบันทึกตอนเปิดบิลจากนัด (applyDepositsTx)
+func (s *PromptPayService) settleTx(tx *gorm.DB, charge *posModels.PromptPayCharge, via posModels.PromptPayConfirmSource, actorID *uint, transRef *string) error {
+	now := s.Now()
+	charge.Status = posModels.PromptPayPaid
+	charge.ConfirmedVia = via
+	charge.ConfirmedBy = actorID
+	charge.TransRef = transRef
+	charge.PaidAt = &now
+	if err := tx.Model(charge).Select("Status", "ConfirmedVia", "ConfirmedBy", "TransRef", "PaidAt").Updates(charge).Error; err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("transaction %s already exists", *transRef)
+		}
+		return fmt.Errorf("failed to confirm promptpay charge: %w", err)
+	}
+
+	var sale *posModels.Sale
+	var err error
+	switch {
+	case charge.SaleID != nil:
+		if sale, err = lockSale(tx, charge.TenantID, *charge.SaleID); err != nil {
+			return err
+		}
+	case charge.AppointmentID != nil:
+		var open posModels.Sale
+		err := tx.Where("tenant_id = ? AND appointment_id = ? AND status = ?", charge.TenantID, *charge.AppointmentID, posModels.SaleOpen).
+			First(&open).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch sale: %w", err)
+		}
+		if sale, err = lockSale(tx, charge.TenantID, open.ID); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	return applyChargeTx(tx, sale, charge, now)
+}
+
+// applyDepositsTx บันทึกมัดจำ (พร้อมเพย์ที่ยืนยันแล้ว และ payment gateway ที่ตัดเงินแล้ว) ที่ยังไม่เข้าบิลของนัด ให้เป็นการชำระของบิลที่เพิ่งเปิด
+// มัดจำพร้อมเพย์ที่เกินยอดบิลบันทึกเท่ายอดบิล ส่วนที่เกินเก็บใน OverpaidAmount เพื่อคืนลูกค้านอกระบบ
+func applyDepositsTx(tx *gorm.DB, sale *posModels.Sale, now time.Time) error {
+	if sale.AppointmentID == nil {
+		return nil
+	}
+	var deposits []posModels.PromptPayCharge
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND appointment_id = ? AND status = ? AND payment_id IS NULL", sale.TenantID, *sale.AppointmentID, posModels.PromptPayPaid).
+		Order("id").
+		Find(&deposits).Error; err != nil {
+		return fmt.Errorf("failed to fetch deposits: %w", err)
+	}
+	for i := range deposits {
+		if err := applyChargeTx(tx, sale, &deposits[i], now); err != nil {
+			return err
+		}
+	}
+	return applyGatewayDepositsTx(tx, sale, now)
+}
+
+// applyChargeTx บันทึกเงินที่โอนเข้ามาแล้วเข้าบิล ไม่เกินยอดค้าง (ยอดค้างอาจลดลงระหว่างรอโอน)
+// ส่วนที่เกินบันทึกเป็น OverpaidAmount แทนการปฏิเสธ เพราะลูกค้าจ่ายเงินไปแล้วจริง
+func applyChargeTx(tx *gorm.DB, sale *posModels.Sale, charge *posModels.PromptPayCharge, now time.Time) error {
+	var amount posModels.Money
+	if sale.Status == posModels.SaleOpen {
+		amount = min(charge.Amount, sale.Balance())
+	}
+	if overpaid := charge.Amount - amount; overpaid != charge.OverpaidAmount {
+		charge.OverpaidAmount = overpaid
+		if err := tx.Model(charge).Select("OverpaidAmount").Updates(charge).Error; err != nil {
+			return fmt.Errorf("failed to record promptpay overpayment: %w", err)
+		}
+	}
+	if amount <= 0 {
+		saleID := sale.ID
+		charge.SaleID = &saleID
+		if err := tx.Model(charge).Select("SaleID").Updates(charge).Error; err != nil {
+			return fmt.Errorf("failed to link promptpay charge: %w", err)
+		}
+		return nil
+	}
+	return recordChargePaymentTx(tx, sale, charge, amount, now)
+}
+
+func recordChargePaymentTx(tx *gorm.DB, sale *posModels.Sale, charge *posModels.PromptPayCharge, amount posModels.Money, now time.Time) error {
+	payment := posModels.Payment{
+		Method:     posModels.PaymentPromptPay,
+		Amount:     amount,
+		Reference:  charge.Reference,
+		ReceivedBy: charge.ConfirmedBy,
+	}
+	if err := applyPaymentTx(tx, sale, &payment, now); err != nil {
+		return err
+	}
+	saleID := sale.ID
+	charge.SaleID = &saleID
+	charge.PaymentID = &payment.ID
+	if err := tx.Model(charge).Select("SaleID", "PaymentID").Updates(charge).Error; err != nil {
+		return fmt.Errorf("failed to link promptpay payment: %w", err)
+	}
+	return nil
+}
+
+// lockDepositAppointment นัดที่รับมัดจำได้: ยังไม่ถึงวันรับบริการ
+func lockDepositAppointment(tx *gorm.DB, tenantID, appointmentID uint) (*barberBookingModels.Appointment, error) {
+	var ap barberBookingModels.Appointment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ? AND deleted_at IS NULL", appointmentID, tenantID).
+		First(&ap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("appointment with ID %d not found", appointmentID)
+		}
+		return nil, fmt.Errorf("failed to fetch appointment: %w", err)
+	}
+	switch ap.Status {
+	case barberBookingModels.StatusPending, barberBookingModels.StatusConfirmed, barberBookingModels.StatusRescheduled:
+	default:
+		return nil, fmt.Errorf("invalid appointment status: deposits are only taken before the visit (current %s)", ap.Status)
+	}
+	return &ap, nil
+}
+
+func promptPayAccountTx(tx *gorm.DB, tenantID, branchID uint) (*posModels.PromptPayAccount, error) {
+	var acc posModels.PromptPayAccount
+	if err := tx.Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).First(&acc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("promptpay account for branch %d not found", branchID)
+		}
+		return nil, fmt.Errorf("failed to fetch promptpay account: %w", err)
+	}
+	return &acc, nil
+}
+
+// proxyMatches เทียบพร้อมเพย์ผู้รับบนสลิปกับบัญชีที่ออก QR โดยชิดขวา
+// สลิปมักปิดบางหลัก (xxx-xxx-5678) หลักที่ปิดไว้ถือว่าตรง และเบอร์มือถืออาจขึ้นต้น 66 แทน 0
+// สลิปที่ไม่มีข้อมูลผู้รับผ่านได้เฉพาะเมื่อผู้ตรวจสลิปแจ้งว่ายืนยันผู้รับเองแล้ว (verified)
+func proxyMatches(proxyID, receiver string, verified bool) bool {
+	var b strings.Builder
+	for _, r := range receiver {
+		switch {
+		case r >= '0' && r <= '9', r == 'x', r == 'X', r == '*':
+			b.WriteRune(r)
+		case r == '-' || r == ' ' || r == '+':
+		default:
+			return false
+		}
+	}
+	got := b.String()
+	if got == "" {
+		return verified
+	}
+	if strings.HasPrefix(got, "66") && len(got) == len(proxyID)+1 && strings.HasPrefix(proxyID, "0") {
+		got = "0" + got[2:]
+	}
+	if len(got) > len(proxyID) {
+		return false
+	}
+	offset := len(proxyID) - len(got)
+	for i := 0; i < len(got); i++ {
+		if c := got[i]; c >= '0' && c <= '9' && c != proxyID[offset+i] {
+			return false
+		}
+	}
+	return true
+}
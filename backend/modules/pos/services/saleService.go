@@ -0,0 +1,636 @@
+package posServices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	coreModels "myapp/modules/core/models"
+	inventoryServices "myapp/modules/inventory/services"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultSaleListLimit = 50
+	maxSaleListLimit     = 200
+)
+
+type SaleService struct {
+	DB  *gorm.DB
+	Now func() time.Time
+}
+
+func NewSaleService(db *gorm.DB) *SaleService {
+	return &SaleService{DB: db, Now: time.Now}
+}
+
+func (s *SaleService) CreateSale(ctx context.Context, tenantID uint, req posPort.CreateSaleRequest, cashierID *uint) (*posModels.Sale, error) {
+	if req.BranchID == 0 {
+		return nil, errors.New("invalid branch_id")
+	}
+	if req.Discount < 0 {
+		return nil, errors.New("invalid discount: must not be negative")
+	}
+
+	var saleID uint
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, req.BranchID); err != nil {
+			return err
+		}
+		if req.CustomerID != nil {
+			if err := ensureCustomer(tx, tenantID, *req.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		items := make([]posModels.SaleItem, 0, len(req.Items))
+		for _, in := range req.Items {
+			item, err := buildItem(tx, tenantID, req.BranchID, in)
+			if err != nil {
+				return err
+			}
+			items = append(items, *item)
+		}
+
+		sale := posModels.Sale{
+			TenantID:   tenantID,
+			BranchID:   req.BranchID,
+			CustomerID: req.CustomerID,
+			CashierID:  cashierID,
+			Status:     posModels.SaleOpen,
+			Discount:   req.Discount,
+			Notes:      req.Notes,
+			Items:      items,
+		}
+		if err := recalculate(&sale); err != nil {
+			return err
+		}
+		if err := tx.Create(&sale).Error; err != nil {
+			return fmt.Errorf("failed to create sale: %w", err)
+		}
+		for _, it := range sale.Items {
+			if err := deductStock(tx, &sale, it); err != nil {
+				return err
+			}
+		}
+		saleID = sale.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSale(ctx, tenantID, saleID)
+}
+
+func (s *SaleService) CreateSaleFromAppointment(ctx context.Context, tenantID, appointmentID uint, cashierID *uint) (*posModels.Sale, error) {
+	var saleID uint
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ap barberBookingModels.Appointment
+		if err := tx.
+			Preload("Service").
+			Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+			Preload("Items.Service").
+			Where("id = ? AND tenant_id = ?", appointmentID, tenantID).
+			First(&ap).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("appointment with ID %d not found", appointmentID)
+			}
+			return fmt.Errorf("failed to fetch appointment: %w", err)
+		}
+		if ap.Status != barberBookingModels.StatusInService && ap.Status != barberBookingModels.StatusComplete {
+			return fmt.Errorf("invalid appointment status: only IN_SERVICE or COMPLETED appointments can be billed (current %s)", ap.Status)
+		}
+
+		var existing int64
+		if err := tx.Model(&posModels.Sale{}).
+			Where("appointment_id = ? AND status <> ?", ap.ID, posModels.SaleVoid).
+			Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check existing sale: %w", err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("sale for appointment %d already exists", ap.ID)
+		}
+
+		var barberID *uint
+		if ap.BarberID != 0 {
+			id := ap.BarberID
+			barberID = &id
+		}
+
+		// ใช้ราคาที่บันทึกไว้ตอนจอง นัดเก่าที่ไม่มี items ใช้ราคาปัจจุบันของ service หลัก
+		var items []posModels.SaleItem
+		for _, it := range ap.Items {
+			items = append(items, serviceLine(it.ServiceID, it.Service.Name, posModels.Baht(it.Price), barberID))
+		}
+		if len(items) == 0 {
+			items = append(items, serviceLine(ap.ServiceID, ap.Service.Name, posModels.Baht(ap.Service.Price), barberID))
+		}
+
+		customerID := ap.CustomerID
+		apID := ap.ID
+		sale := posModels.Sale{
+			TenantID:      tenantID,
+			BranchID:      ap.BranchID,
+			AppointmentID: &apID,
+			CustomerID:    &customerID,
+			CashierID:     cashierID,
+			Status:        posModels.SaleOpen,
+			Items:         items,
+		}
+		if err := recalculate(&sale); err != nil {
+			return err
+		}
+		if err := tx.Create(&sale).Error; err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("sale for appointment %d already exists", ap.ID)
+			}
+			return fmt.Errorf("failed to create sale: %w", err)
+		}
+		saleID = sale.ID
+		// มัดจำพร้อมเพย์ที่ยืนยันแล้วของนัดนี้บันทึกเป็นการชำระของบิล
+		return applyDepositsTx(tx, &sale, s.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSale(ctx, tenantID, saleID)
+}
+
+func (s *SaleService) GetSale(ctx context.Context, tenantID, saleID uint) (*posModels.Sale, error) {
+	var sale posModels.Sale
+	if err := preloadSale(s.DB.WithContext(ctx)).
+		Where("id = ? AND tenant_id = ?", saleID, tenantID).
+		First(&sale).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("sale with ID %d not found", saleID)
+		}
+		return nil, fmt.Errorf("failed to fetch sale: %w", err)
+	}
+	return &sale, nil
+}
+
+func (s *SaleService) ListSales(ctx context.Context, tenantID uint, filter posPort.SaleFilter) ([]posModels.Sale, error) {
+	q := preloadSale(s.DB.WithContext(ctx)).Where("tenant_id = ?", tenantID)
+	if filter.BranchID != nil {
+		q = q.Where("branch_id = ?", *filter.BranchID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		q = q.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("created_at < ?", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSaleListLimit
+	}
+	if limit > maxSaleListLimit {
+		limit = maxSaleListLimit
+	}
+
+	var sales []posModels.Sale
+	if err := q.Order("created_at DESC, id DESC").Limit(limit).Offset(filter.Offset).Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch sales: %w", err)
+	}
+	return sales, nil
+}
+
+func (s *SaleService) AddItem(ctx context.Context, tenantID, saleID uint, input posPort.SaleItemInput) (*posModels.Sale, error) {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sale, err := lockEditableSale(tx, tenantID, saleID)
+		if err != nil {
+			return err
+		}
+		item, err := buildItem(tx, tenantID, sale.BranchID, input)
+		if err != nil {
+			return err
+		}
+		item.SaleID = sale.ID
+		if err := tx.Create(item).Error; err != nil {
+			return fmt.Errorf("failed to add sale item: %w", err)
+		}
+		if err := deductStock(tx, sale, *item); err != nil {
+			return err
+		}
+		sale.Items = append(sale.Items, *item)
+		return saveTotals(tx, sale)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSale(ctx, tenantID, saleID)
+}
+
+func (s *SaleService) RemoveItem(ctx context.Context, tenantID, saleID, itemID uint) (*posModels.Sale, error) {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sale, err := lockEditableSale(tx, tenantID, saleID)
+		if err != nil {
+			return err
+		}
+		kept := sale.Items[:0]
+		var removed *posModels.SaleItem
+		for _, it := range sale.Items {
+			if it.ID == itemID {
+				it := it
+				removed = &it
+				continue
+			}
+			kept = append(kept, it)
+		}
+		if removed == nil {
+			return fmt.Errorf("sale item with ID %d not found", itemID)
+		}
+		if err := restock(tx, sale, *removed, "item removed", sale.CashierID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&posModels.SaleItem{}, itemID).Error; err != nil {
+			return fmt.Errorf("failed to remove sale item: %w", err)
+		}
+		sale.Items = kept
+		return saveTotals(tx, sale)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSale(ctx, tenantID, saleID)
+}
+
+func (s *SaleService) AddPayment(ctx context.Context, tenantID, saleID uint, req posPort.AddPaymentRequest, receivedBy *uint) (*posModels.Sale, error) {
+	switch req.Method {
+	case posModels.PaymentCash, posModels.PaymentCard, posModels.PaymentTransfer:
+	default:
+		return nil, fmt.Errorf("invalid payment method %q", req.Method)
+	}
+	amount := req.Amount
+	if amount <= 0 {
+		return nil, errors.New("invalid amount: must be greater than 0")
+	}
+	if req.Tip < 0 {
+		return nil, errors.New("invalid tip: must not be negative")
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sale, err := lockSale(tx, tenantID, saleID)
+		if err != nil {
+			return err
+		}
+		return applyPaymentTx(tx, sale, &posModels.Payment{
+			Method:     req.Method,
+			Amount:     amount,
+			TipAmount:  req.Tip,
+			Reference:  strings.TrimSpace(req.Reference),
+			ReceivedBy: receivedBy,
+		}, s.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSale(ctx, tenantID, saleID)
+}
+
+func (s *SaleService) VoidSale(ctx context.Context, tenantID, saleID uint, reason string, receivedBy *uint) (*posModels.Sale, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("invalid reason: required")
+	}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sale, err := lockSale(tx, tenantID, saleID)
+		if err != nil {
+			return err
+		}
+		if sale.Status == posModels.SaleVoid {
+			return errors.New("invalid status: sale is already VOID")
+		}
+		if err := ensureNoCapturedGatewayTx(tx, sale); err != nil {
+			return err
+		}
+		refunded, err := refundPayments(tx, sale, receivedBy)
+		if err != nil {
+			return err
+		}
+		for _, it := range sale.Items {
+			if err := restock(tx, sale, it, "sale voided", receivedBy); err != nil {
+				return err
+			}
+		}
+		now := s.Now()
+		sale.Status = posModels.SaleVoid
+		sale.VoidedAt = &now
+		sale.VoidReason = reason
+		sale.RefundedAmount += refunded
+		if err := tx.Model(sale).Select("Status", "VoidedAt", "VoidReason", "RefundedAmount").Updates(sale).Error; err != nil {
+			return fmt.Errorf("failed to void sale: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSale(ctx, tenantID, saleID)
+}
+
+func preloadSale(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+}
+
+func lockSale(tx *gorm.DB, tenantID, saleID uint) (*posModels.Sale, error) {
+	var sale posModels.Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", saleID, tenantID).
+		First(&sale).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("sale with ID %d not found", saleID)
+		}
+		return nil, fmt.Errorf("failed to fetch sale: %w", err)
+	}
+	if err := tx.Where("sale_id = ?", sale.ID).Order("id").Find(&sale.Items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch sale items: %w", err)
+	}
+	return &sale, nil
+}
+
+// lockEditableSale บิลที่แก้รายการได้: OPEN และยังไม่มีการชำระ (กันยอดชำระไม่ตรงกับรายการ)
+func lockEditableSale(tx *gorm.DB, tenantID, saleID uint) (*posModels.Sale, error) {
+	sale, err := lockSale(tx, tenantID, saleID)
+	if err != nil {
+		return nil, err
+	}
+	if sale.Status != posModels.SaleOpen {
+		return nil, fmt.Errorf("invalid status: sale is %s", sale.Status)
+	}
+	if sale.PaidAmount > 0 {
+		return nil, errors.New("invalid sale: items cannot be changed after a payment was taken")
+	}
+	return sale, nil
+}
+
+// applyPaymentTx บันทึกการรับเงินเข้าบิลที่ล็อกไว้แล้ว และปิดบิลเมื่อชำระครบ
+func applyPaymentTx(tx *gorm.DB, sale *posModels.Sale, payment *posModels.Payment, now time.Time) error {
+	if sale.Status != posModels.SaleOpen {
+		return fmt.Errorf("invalid status: sale is %s", sale.Status)
+	}
+	if len(sale.Items) == 0 {
+		return errors.New("invalid sale: no items")
+	}
+	balance := sale.Balance()
+	// รับเงินเกินได้เฉพาะเงินสด (ทอนเงิน) ช่องทางอื่นต้องไม่เกินยอดค้าง
+	if payment.Method != posModels.PaymentCash && payment.Amount > balance {
+		return fmt.Errorf("invalid amount: %s payment exceeds balance due %s", payment.Method, balance)
+	}
+	shiftID, err := paymentShiftTx(tx, sale, payment.Method, payment.ReceivedBy)
+	if err != nil {
+		return err
+	}
+
+	payment.TenantID = sale.TenantID
+	payment.SaleID = sale.ID
+	payment.Kind = posModels.PaymentKindPayment
+	payment.ShiftID = shiftID
+	sale.PaidAmount += payment.Amount
+	sale.TipAmount += payment.TipAmount
+	if sale.PaidAmount >= sale.Total {
+		sale.Status = posModels.SalePaid
+		sale.PaidAt = &now
+		// เกินได้เฉพาะเงินสด เงินทอนจึงมาจากการรับครั้งนี้ทั้งหมด
+		sale.ChangeAmount = sale.PaidAmount - sale.Total
+		payment.ChangeAmount = sale.ChangeAmount
+	}
+	if err := tx.Create(payment).Error; err != nil {
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+	if err := tx.Model(sale).Select("PaidAmount", "ChangeAmount", "TipAmount", "Status", "PaidAt").Updates(sale).Error; err != nil {
+		return fmt.Errorf("failed to update sale: %w", err)
+	}
+	return nil
+}
+
+// refundPayments บันทึก REFUND คืนเงินที่รับไว้ (รวมทิป) ทีละช่องทาง เงินสดคืนเฉพาะส่วนที่เก็บไว้จริง (หักเงินทอนแล้ว)
+// หักยอดที่คืนไปแล้ว (เช่นคืนผ่าน payment gateway) เพื่อให้ยอดรับ - ยอดคืนของบิลที่ VOID เป็นศูนย์เสมอ
+// คืนเงินสดต้องออกจากลิ้นชักของกะที่เปิดอยู่ของผู้ทำรายการ
+func refundPayments(tx *gorm.DB, sale *posModels.Sale, receivedBy *uint) (posModels.Money, error) {
+	var payments []posModels.Payment
+	if err := tx.Where("sale_id = ?", sale.ID).Order("id").Find(&payments).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch payments: %w", err)
+	}
+	net := map[posModels.PaymentMethod]posModels.Money{}
+	tips := map[posModels.PaymentMethod]posModels.Money{}
+	var order []posModels.PaymentMethod
+	for _, p := range payments {
+		if _, ok := net[p.Method]; !ok {
+			order = append(order, p.Method)
+		}
+		if p.Kind == posModels.PaymentKindRefund {
+			net[p.Method] -= p.Amount
+			tips[p.Method] -= p.TipAmount
+			continue
+		}
+		net[p.Method] += p.Amount - p.ChangeAmount
+		tips[p.Method] += p.TipAmount
+	}
+
+	var total posModels.Money
+	for _, method := range order {
+		amount := net[method]
+		if amount <= 0 {
+			continue
+		}
+		shiftID, err := paymentShiftTx(tx, sale, method, receivedBy)
+		if err != nil {
+			return 0, err
+		}
+		refund := posModels.Payment{
+			TenantID:   sale.TenantID,
+			SaleID:     sale.ID,
+			Kind:       posModels.PaymentKindRefund,
+			Method:     method,
+			Amount:     amount,
+			TipAmount:  tips[method],
+			ShiftID:    shiftID,
+			Reference:  fmt.Sprintf("VOID sale %d", sale.ID),
+			ReceivedBy: receivedBy,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return 0, fmt.Errorf("failed to create refund: %w", err)
+		}
+		total += amount
+	}
+	return total, nil
+}
+
+// deductStock ตัดสต๊อกของรายการสินค้าที่อยู่ในแคตตาล็อก ใน transaction เดียวกับบิล
+func deductStock(tx *gorm.DB, sale *posModels.Sale, item posModels.SaleItem) error {
+	if item.ItemType != posModels.ItemProduct || item.ProductID == nil {
+		return nil
+	}
+	return inventoryServices.RecordSaleTx(tx, sale.TenantID, sale.BranchID, *item.ProductID, item.Quantity, sale.ID, item.ID, sale.CashierID)
+}
+
+// restock คืนสต๊อกของรายการสินค้าที่ถูกลบหรือบิลที่ VOID
+func restock(tx *gorm.DB, sale *posModels.Sale, item posModels.SaleItem, reason string, actorID *uint) error {
+	if item.ItemType != posModels.ItemProduct || item.ProductID == nil {
+		return nil
+	}
+	return inventoryServices.RecordReturnTx(tx, sale.TenantID, sale.BranchID, *item.ProductID, item.Quantity, sale.ID, item.ID, reason, actorID)
+}
+
+func saveTotals(tx *gorm.DB, sale *posModels.Sale) error {
+	if err := recalculate(sale); err != nil {
+		return err
+	}
+	if err := tx.Model(sale).Select("Subtotal", "Total").Updates(sale).Error; err != nil {
+		return fmt.Errorf("failed to update sale totals: %w", err)
+	}
+	return nil
+}
+
+// recalculate คำนวณ Subtotal และ Total จากรายการและส่วนลดท้ายบิล
+func recalculate(sale *posModels.Sale) error {
+	var subtotal posModels.Money
+	for _, it := range sale.Items {
+		subtotal += it.LineTotal
+	}
+	sale.Subtotal = subtotal
+	if sale.Discount > sale.Subtotal {
+		return fmt.Errorf("invalid discount: %s exceeds subtotal %s", sale.Discount, sale.Subtotal)
+	}
+	sale.Total = sale.Subtotal - sale.Discount
+	return nil
+}
+
+func serviceLine(serviceID uint, name string, price posModels.Money, barberID *uint) posModels.SaleItem {
+	return posModels.SaleItem{
+		ItemType:  posModels.ItemService,
+		ServiceID: &serviceID,
+		BarberID:  barberID,
+		Name:      name,
+		Quantity:  1,
+		UnitPrice: price,
+		LineTotal: price,
+	}
+}
+
+func buildItem(tx *gorm.DB, tenantID, branchID uint, in posPort.SaleItemInput) (*posModels.SaleItem, error) {
+	if in.Quantity <= 0 {
+		return nil, errors.New("invalid quantity: must be greater than 0")
+	}
+	if in.Discount < 0 {
+		return nil, errors.New("invalid discount: must not be negative")
+	}
+	if in.UnitPrice != nil && *in.UnitPrice < 0 {
+		return nil, errors.New("invalid unit_price: must not be negative")
+	}
+	if in.BarberID != nil {
+		if err := ensureBarber(tx, tenantID, *in.BarberID); err != nil {
+			return nil, err
+		}
+	}
+
+	item := posModels.SaleItem{
+		ItemType:  in.ItemType,
+		BarberID:  in.BarberID,
+		Name:      strings.TrimSpace(in.Name),
+		Quantity:  in.Quantity,
+		Discount:  in.Discount,
+		ProductID: in.ProductID,
+	}
+
+	switch in.ItemType {
+	case posModels.ItemService:
+		if in.ServiceID == nil {
+			return nil, errors.New("invalid item: service_id is required for SERVICE items")
+		}
+		// ราคาบริการมาจาก service เท่านั้น ลดราคาได้ผ่าน discount
+		if in.UnitPrice != nil {
+			return nil, errors.New("invalid unit_price: SERVICE items always use the service price")
+		}
+		var svc barberBookingModels.Service
+		if err := tx.Where("id = ? AND tenant_id = ?", *in.ServiceID, tenantID).First(&svc).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("service with ID %d not found", *in.ServiceID)
+			}
+			return nil, fmt.Errorf("failed to fetch service: %w", err)
+		}
+		item.ServiceID = in.ServiceID
+		item.ProductID = nil
+		if item.Name == "" {
+			item.Name = svc.Name
+		}
+		item.UnitPrice = posModels.Baht(svc.Price)
+	case posModels.ItemProduct:
+		// สินค้าในแคตตาล็อกใช้ชื่อและราคาจาก product (แก้ราคาได้) สินค้านอกแคตตาล็อกต้องส่ง name และ unit_price
+		if in.ProductID != nil {
+			product, err := inventoryServices.SellableProductTx(tx, tenantID, branchID, *in.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			if item.Name == "" {
+				item.Name = product.Name
+			}
+			item.UnitPrice = product.Price
+		} else if item.Name == "" || in.UnitPrice == nil {
+			return nil, errors.New("invalid item: product_id, or name and unit_price, are required for PRODUCT items")
+		}
+		if in.UnitPrice != nil {
+			item.UnitPrice = *in.UnitPrice
+		}
+	default:
+		return nil, fmt.Errorf("invalid item_type %q", in.ItemType)
+	}
+
+	gross := item.UnitPrice * posModels.Money(item.Quantity)
+	if item.Discount > gross {
+		return nil, fmt.Errorf("invalid discount: %s exceeds line amount %s", item.Discount, gross)
+	}
+	item.LineTotal = gross - item.Discount
+	return &item, nil
+}
+
+func ensureBranch(tx *gorm.DB, tenantID, branchID uint) error {
+	var count int64
+	if err := tx.Model(&coreModels.Branch{}).Where("id = ? AND tenant_id = ?", branchID, tenantID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to fetch branch: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("branch with ID %d not found", branchID)
+	}
+	return nil
+}
+
+func ensureCustomer(tx *gorm.DB, tenantID, customerID uint) error {
+	var count int64
+	if err := tx.Model(&barberBookingModels.Customer{}).Where("id = ? AND tenant_id = ?", customerID, tenantID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to fetch customer: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("customer with ID %d not found", customerID)
+	}
+	return nil
+}
+
+func ensureBarber(tx *gorm.DB, tenantID, barberID uint) error {
+	var count int64
+	if err := tx.Model(&barberBookingModels.Barber{}).Where("id = ? AND tenant_id = ?", barberID, tenantID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to fetch barber: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("barber with ID %d not found", barberID)
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "duplicate key") || strings.Contains(msg, "unique constraint")
+}
@@ -0,0 +1,466 @@
+package posServices
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultShiftListLimit = 50
+	maxShiftListLimit     = 200
+)
+
+type ShiftService struct {
+	DB  *gorm.DB
+	Now func() time.Time
+}
+
+func NewShiftService(db *gorm.DB) *ShiftService {
+	return &ShiftService{DB: db, Now: time.Now}
+}
+
+func (s *ShiftService) OpenShift(ctx context.Context, tenantID uint, req posPort.OpenShiftRequest, cashierID uint) (*posModels.CashShift, error) {
+	if req.BranchID == 0 {
+		return nil, errors.New("invalid branch_id")
+	}
+	if req.OpeningFloat < 0 {
+		return nil, errors.New("invalid opening_float: must not be negative")
+	}
+	shift := posModels.CashShift{
+		TenantID:     tenantID,
+		BranchID:     req.BranchID,
+		CashierID:    cashierID,
+		Status:       posModels.ShiftOpen,
+		OpeningFloat: req.OpeningFloat,
+		OpenedAt:     s.Now(),
+	}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureBranch(tx, tenantID, req.BranchID); err != nil {
+			return err
+		}
+		var open int64
+		if err := tx.Model(&posModels.CashShift{}).
+			Where("branch_id = ? AND cashier_id = ? AND status = ?", req.BranchID, cashierID, posModels.ShiftOpen).
+			Count(&open).Error; err != nil {
+			return fmt.Errorf("failed to check open shift: %w", err)
+		}
+		if open > 0 {
+			return errors.New("open shift for this cashier already exists")
+		}
+		if err := tx.Create(&shift).Error; err != nil {
+			if isUniqueViolation(err) {
+				return errors.New("open shift for this cashier already exists")
+			}
+			return fmt.Errorf("failed to open shift: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (s *ShiftService) GetCurrentShift(ctx context.Context, tenantID, branchID, cashierID uint) (*posModels.ShiftReport, error) {
+	var shift posModels.CashShift
+	if err := s.DB.WithContext(ctx).
+		Where("tenant_id = ? AND branch_id = ? AND cashier_id = ? AND status = ?", tenantID, branchID, cashierID, posModels.ShiftOpen).
+		First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("open shift for branch %d not found", branchID)
+		}
+		return nil, fmt.Errorf("failed to fetch shift: %w", err)
+	}
+	return shiftReportTx(s.DB.WithContext(ctx), &shift)
+}
+
+func (s *ShiftService) GetShiftReport(ctx context.Context, tenantID, shiftID uint) (*posModels.ShiftReport, error) {
+	var shift posModels.CashShift
+	if err := s.DB.WithContext(ctx).Where("id = ? AND tenant_id = ?", shiftID, tenantID).First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("shift with ID %d not found", shiftID)
+		}
+		return nil, fmt.Errorf("failed to fetch shift: %w", err)
+	}
+	return shiftReportTx(s.DB.WithContext(ctx), &shift)
+}
+
+func (s *ShiftService) ListShifts(ctx context.Context, tenantID uint, filter posPort.ShiftFilter) ([]posModels.CashShift, error) {
+	q := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filter.BranchID != nil {
+		q = q.Where("branch_id = ?", *filter.BranchID)
+	}
+	if filter.CashierID != nil {
+		q = q.Where("cashier_id = ?", *filter.CashierID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		q = q.Where("opened_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("opened_at < ?", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultShiftListLimit
+	}
+	if limit > maxShiftListLimit {
+		limit = maxShiftListLimit
+	}
+	var shifts []posModels.CashShift
+	if err := q.Order("opened_at DESC, id DESC").Limit(limit).Offset(filter.Offset).Find(&shifts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch shifts: %w", err)
+	}
+	return shifts, nil
+}
+
+func (s *ShiftService) AddCashMovement(ctx context.Context, tenantID, shiftID uint, req posPort.CashMovementRequest, actorID uint, asManager bool) (*posModels.CashMovement, error) {
+	if req.Type != posModels.CashIn && req.Type != posModels.CashOut {
+		return nil, fmt.Errorf("invalid type %q", req.Type)
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("invalid amount: must be greater than 0")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("invalid reason: required")
+	}
+	var m posModels.CashMovement
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		shift, err := lockOpenShift(tx, tenantID, shiftID, actorID, asManager)
+		if err != nil {
+			return err
+		}
+		if req.Type == posModels.CashOut {
+			cash, err := cashSummaryTx(tx, shift)
+			if err != nil {
+				return err
+			}
+			if req.Amount > cash.Expected {
+				return fmt.Errorf("invalid amount: cash out exceeds cash in drawer %s", cash.Expected)
+			}
+		}
+		actor := actorID
+		m = posModels.CashMovement{
+			TenantID:  tenantID,
+			ShiftID:   shift.ID,
+			Type:      req.Type,
+			Amount:    req.Amount,
+			Reason:    reason,
+			CreatedBy: &actor,
+		}
+		if err := tx.Create(&m).Error; err != nil {
+			return fmt.Errorf("failed to create cash movement: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *ShiftService) CloseShift(ctx context.Context, tenantID, shiftID uint, req posPort.CloseShiftRequest, actorID uint, asManager bool) (*posModels.ShiftReport, error) {
+	if req.CountedCash < 0 {
+		return nil, errors.New("invalid counted_cash: must not be negative")
+	}
+	var report *posModels.ShiftReport
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		shift, err := lockOpenShift(tx, tenantID, shiftID, actorID, asManager)
+		if err != nil {
+			return err
+		}
+		cash, err := cashSummaryTx(tx, shift)
+		if err != nil {
+			return err
+		}
+		now := s.Now()
+		expected := cash.Expected
+		counted := req.CountedCash
+		variance := counted - expected
+		actor := actorID
+		shift.Status = posModels.ShiftClosed
+		shift.ExpectedCash = &expected
+		shift.CountedCash = &counted
+		shift.Variance = &variance
+		shift.CloseNote = strings.TrimSpace(req.Note)
+		shift.ClosedBy = &actor
+		shift.ClosedAt = &now
+		if err := tx.Model(shift).
+			Select("Status", "ExpectedCash", "CountedCash", "Variance", "CloseNote", "ClosedBy", "ClosedAt").
+			Updates(shift).Error; err != nil {
+			return fmt.Errorf("failed to close shift: %w", err)
+		}
+		report, err = shiftReportTx(tx, shift)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *ShiftService) GetZReport(ctx context.Context, tenantID, branchID uint, from, to time.Time) (*posModels.ZReport, error) {
+	if !to.After(from) {
+		return nil, errors.New("invalid range: to must be after from")
+	}
+	db := s.DB.WithContext(ctx)
+	if err := ensureBranch(db, tenantID, branchID); err != nil {
+		return nil, err
+	}
+	report := posModels.ZReport{TenantID: tenantID, BranchID: branchID, From: from, To: to}
+
+	var sales []posModels.Sale
+	if err := db.Preload("Items").
+		Where("tenant_id = ? AND branch_id = ? AND status <> ? AND paid_at >= ? AND paid_at < ?", tenantID, branchID, posModels.SaleVoid, from, to).
+		Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch sales: %w", err)
+	}
+	for _, sale := range sales {
+		report.SalesCount++
+		report.BillDiscounts += sale.Discount
+		report.NetSales += sale.Total
+		for _, it := range sale.Items {
+			gross := it.UnitPrice * posModels.Money(it.Quantity)
+			if it.ItemType == posModels.ItemProduct {
+				report.ProductSales += gross
+			} else {
+				report.ServiceSales += gross
+			}
+			report.LineDiscounts += it.Discount
+		}
+	}
+
+	var voids int64
+	if err := db.Model(&posModels.Sale{}).
+		Where("tenant_id = ? AND branch_id = ? AND status = ? AND voided_at >= ? AND voided_at < ?", tenantID, branchID, posModels.SaleVoid, from, to).
+		Count(&voids).Error; err != nil {
+		return nil, fmt.Errorf("failed to count voided sales: %w", err)
+	}
+	report.VoidCount = int(voids)
+
+	var payments []posModels.Payment
+	if err := db.Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, from, to).
+		Where("sale_id IN (?)", db.Model(&posModels.Sale{}).Select("id").Where("tenant_id = ? AND branch_id = ?", tenantID, branchID)).
+		Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch payments: %w", err)
+	}
+	report.Payments, report.Refunds = methodTotals(payments)
+	for _, t := range report.Payments {
+		report.Tips += t.Tips
+	}
+	for _, t := range report.Refunds {
+		report.RefundTotal += t.Amount
+	}
+
+	if err := db.Where("tenant_id = ? AND branch_id = ? AND status = ? AND closed_at >= ? AND closed_at < ?", tenantID, branchID, posModels.ShiftClosed, from, to).
+		Order("closed_at, id").
+		Find(&report.Shifts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch shifts: %w", err)
+	}
+	return &report, nil
+}
+
+// ZReportCSV แปลง Z-report เป็น CSV สามคอลัมน์ (section, key, value) สำหรับส่งออก
+func ZReportCSV(r *posModels.ZReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{
+		{"section", "key", "value"},
+		{"report", "tenant_id", fmt.Sprint(r.TenantID)},
+		{"report", "branch_id", fmt.Sprint(r.BranchID)},
+		{"report", "from", r.From.Format(time.RFC3339)},
+		{"report", "to", r.To.Format(time.RFC3339)},
+		{"sales", "sales_count", fmt.Sprint(r.SalesCount)},
+		{"sales", "void_count", fmt.Sprint(r.VoidCount)},
+		{"sales", "service_sales", r.ServiceSales.String()},
+		{"sales", "product_sales", r.ProductSales.String()},
+		{"sales", "line_discounts", r.LineDiscounts.String()},
+		{"sales", "bill_discounts", r.BillDiscounts.String()},
+		{"sales", "net_sales", r.NetSales.String()},
+		{"sales", "tips", r.Tips.String()},
+		{"sales", "refund_total", r.RefundTotal.String()},
+	}
+	for _, t := range r.Payments {
+		rows = append(rows,
+			[]string{"payments", string(t.Method) + "_count", fmt.Sprint(t.Count)},
+			[]string{"payments", string(t.Method) + "_amount", t.Amount.String()},
+			[]string{"payments", string(t.Method) + "_tips", t.Tips.String()},
+		)
+	}
+	for _, t := range r.Refunds {
+		rows = append(rows,
+			[]string{"refunds", string(t.Method) + "_count", fmt.Sprint(t.Count)},
+			[]string{"refunds", string(t.Method) + "_amount", t.Amount.String()},
+		)
+	}
+	for _, sh := range r.Shifts {
+		key := fmt.Sprintf("shift_%d", sh.ID)
+		rows = append(rows,
+			[]string{"shifts", key + "_cashier_id", fmt.Sprint(sh.CashierID)},
+			[]string{"shifts", key + "_expected_cash", moneyOrEmpty(sh.ExpectedCash)},
+			[]string{"shifts", key + "_counted_cash", moneyOrEmpty(sh.CountedCash)},
+			[]string{"shifts", key + "_variance", moneyOrEmpty(sh.Variance)},
+		)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func moneyOrEmpty(m *posModels.Money) string {
+	if m == nil {
+		return ""
+	}
+	return m.String()
+}
+
+// paymentShiftTx กะที่ใช้ผูกการรับ/คืนเงิน: กะที่เปิดอยู่ของผู้ทำรายการในสาขาของบิล
+// เงินสดต้องมีกะเสมอ ช่องทางอื่นผูกถ้ามี (ใช้สรุปตามช่องทางในรายงานกะ)
+// ล็อกแถวกะแบบ SHARE เพื่อไม่ให้ปิดกะแทรกระหว่างบันทึก
+func paymentShiftTx(tx *gorm.DB, sale *posModels.Sale, method posModels.PaymentMethod, actorID *uint) (*uint, error) {
+	if actorID != nil {
+		var shift posModels.CashShift
+		err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("tenant_id = ? AND branch_id = ? AND cashier_id = ? AND status = ?", sale.TenantID, sale.BranchID, *actorID, posModels.ShiftOpen).
+			First(&shift).Error
+		if err == nil {
+			return &shift.ID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to fetch shift: %w", err)
+		}
+	}
+	if method == posModels.PaymentCash {
+		return nil, errors.New("invalid shift: open a cash drawer shift at this branch before handling cash")
+	}
+	return nil, nil
+}
+
+func lockOpenShift(tx *gorm.DB, tenantID, shiftID, actorID uint, asManager bool) (*posModels.CashShift, error) {
+	var shift posModels.CashShift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", shiftID, tenantID).
+		First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("shift with ID %d not found", shiftID)
+		}
+		return nil, fmt.Errorf("failed to fetch shift: %w", err)
+	}
+	if shift.CashierID != actorID && !asManager {
+		return nil, errors.New("permission denied: shift belongs to another cashier")
+	}
+	if shift.Status != posModels.ShiftOpen {
+		return nil, fmt.Errorf("invalid status: shift is %s", shift.Status)
+	}
+	return &shift, nil
+}
+
+// cashSummaryTx เงินสดที่ควรมีในลิ้นชัก = เงินตั้งต้น + รับเงินสด (รวมทิป หักเงินทอน) - คืนเงินสด + เติม - นำออก
+func cashSummaryTx(tx *gorm.DB, shift *posModels.CashShift) (posModels.CashSummary, error) {
+	sum := posModels.CashSummary{OpeningFloat: shift.OpeningFloat}
+
+	var payments []posModels.Payment
+	if err := tx.Where("shift_id = ? AND method = ?", shift.ID, posModels.PaymentCash).Find(&payments).Error; err != nil {
+		return sum, fmt.Errorf("failed to fetch shift payments: %w", err)
+	}
+	for _, p := range payments {
+		if p.Kind == posModels.PaymentKindRefund {
+			sum.CashRefunds += p.Amount + p.TipAmount
+		} else {
+			sum.CashSales += p.Amount + p.TipAmount - p.ChangeAmount
+		}
+	}
+
+	var moves []posModels.CashMovement
+	if err := tx.Where("shift_id = ?", shift.ID).Find(&moves).Error; err != nil {
+		return sum, fmt.Errorf("failed to fetch cash movements: %w", err)
+	}
+	for _, m := range moves {
+		if m.Type == posModels.CashIn {
+			sum.CashIn += m.Amount
+		} else {
+			sum.CashOut += m.Amount
+		}
+	}
+	sum.Expected = sum.OpeningFloat + sum.CashSales - sum.CashRefunds + sum.CashIn - sum.CashOut
+	return sum, nil
+}
+
+func shiftReportTx(tx *gorm.DB, shift *posModels.CashShift) (*posModels.ShiftReport, error) {
+	cash, err := cashSummaryTx(tx, shift)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("shift_id = ?", shift.ID).Order("id").Find(&shift.CashMovements).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch cash movements: %w", err)
+	}
+	var payments []posModels.Payment
+	if err := tx.Where("shift_id = ?", shift.ID).Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch shift payments: %w", err)
+	}
+	report := posModels.ShiftReport{Shift: *shift, Cash: cash}
+	report.Payments, report.Refunds = methodTotals(payments)
+	return &report, nil
+}
+
+// methodTotals รวมยอดตามช่องทาง แยกรับเงินกับคืนเงิน เรียง CASH, CARD, TRANSFER แล้วช่องทางอื่นตามชื่อ
+func methodTotals(payments []posModels.Payment) (received, refunded []posModels.MethodTotal) {
+	collect := func(kind posModels.PaymentKind) []posModels.MethodTotal {
+		byMethod := map[posModels.PaymentMethod]*posModels.MethodTotal{}
+		for _, p := range payments {
+			if p.Kind != kind {
+				continue
+			}
+			t, ok := byMethod[p.Method]
+			if !ok {
+				t = &posModels.MethodTotal{Method: p.Method}
+				byMethod[p.Method] = t
+			}
+			t.Count++
+			t.Amount += p.Amount - p.ChangeAmount
+			t.Tips += p.TipAmount
+		}
+		out := make([]posModels.MethodTotal, 0, len(byMethod))
+		for _, t := range byMethod {
+			out = append(out, *t)
+		}
+		sort.Slice(out, func(i, j int) bool {
+			ri, rj := methodRank(out[i].Method), methodRank(out[j].Method)
+			if ri != rj {
+				return ri < rj
+			}
+			return out[i].Method < out[j].Method
+		})
+		return out
+	}
+	return collect(posModels.PaymentKindPayment), collect(posModels.PaymentKindRefund)
+}
+
+func methodRank(m posModels.PaymentMethod) int {
+	switch m {
+	case posModels.PaymentCash:
+		return 0
+	case posModels.PaymentCard:
+		return 1
+	case posModels.PaymentTransfer:
+		return 2
+	default:
+		return 3
+	}
+}
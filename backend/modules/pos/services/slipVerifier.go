@@ -0,0 +1,93 @@
+package posServices
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+)
+
+// header ที่ผู้ให้บริการตรวจสลิปแนบมากับ webhook
+const (
+	HeaderSlipTimestamp = "X-Slip-Timestamp"
+	HeaderSlipSignature = "X-Slip-Signature"
+)
+
+// webhookSignatureTolerance อายุของ webhook ที่ยอมรับ กันการส่ง request เดิมซ้ำภายหลัง
+const webhookSignatureTolerance = 5 * time.Minute
+
+var ErrInvalidSlipSignature = errors.New("invalid slip signature")
+
+// SignedSlipVerifier ผู้ตรวจสลิปที่ส่งผลการตรวจมาเป็น JSON พร้อมลายเซ็น
+// X-Slip-Signature: "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")) แบบเดียวกับ webhook ขาออก
+// ถ้าไม่ได้ตั้ง secret จะปฏิเสธทุก request
+type SignedSlipVerifier struct {
+	Secret string
+	Now    func() time.Time
+}
+
+func NewSignedSlipVerifier(secret string) *SignedSlipVerifier {
+	return &SignedSlipVerifier{Secret: secret, Now: time.Now}
+}
+
+type signedSlipBody struct {
+	Reference        string          `json:"reference"`
+	TransRef         string          `json:"trans_ref"`
+	Amount           posModels.Money `json:"amount"`
+	ReceiverProxyID  string          `json:"receiver_proxy_id"`
+	ReceiverVerified bool            `json:"receiver_verified"`
+	PaidAt           time.Time       `json:"paid_at"`
+}
+
+func (v *SignedSlipVerifier) VerifySlip(_ context.Context, hook posPort.InboundWebhook) (*posPort.VerifiedSlip, error) {
+	if err := verifySignedWebhook(v.Secret, hook, HeaderSlipTimestamp, HeaderSlipSignature, v.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSlipSignature, err)
+	}
+
+	var body signedSlipBody
+	if err := json.Unmarshal(hook.Body, &body); err != nil {
+		return nil, fmt.Errorf("invalid slip payload: %w", err)
+	}
+	return &posPort.VerifiedSlip{
+		Reference:        body.Reference,
+		TransRef:         body.TransRef,
+		Amount:           body.Amount,
+		ReceiverProxyID:  body.ReceiverProxyID,
+		ReceiverVerified: body.ReceiverVerified,
+		PaidAt:           body.PaidAt,
+	}, nil
+}
+
+// verifySignedWebhook ตรวจลายเซ็น "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")) และอายุของ timestamp
+func verifySignedWebhook(secret string, hook posPort.InboundWebhook, timestampHeader, signatureHeader string, now time.Time) error {
+	if secret == "" {
+		return errors.New("verifier secret is not set")
+	}
+	ts, err := strconv.ParseInt(hook.Header(timestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("missing timestamp")
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > webhookSignatureTolerance || d < -webhookSignatureTolerance {
+		return errors.New("timestamp out of range")
+	}
+	if !hmac.Equal([]byte(signBody(secret, ts, hook.Body)), []byte(hook.Header(signatureHeader))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func signBody(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,288 @@
+package posServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+)
+
+func TestGatewayService(t *testing.T) {
+	ctx := context.Background()
+	cashier := uint(7)
+	secret := "gateway-secret"
+
+	setup := func(t *testing.T) (posFixture, *posServices.GatewayService, *posServices.FakeProvider, *posServices.SaleService) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		_, err := posServices.NewShiftService(db).OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID}, cashier)
+		require.NoError(t, err)
+		fake := posServices.NewFakeProvider(secret)
+		return f, posServices.NewGatewayService(db, fake), fake, posServices.NewSaleService(db)
+	}
+	newSale := func(t *testing.T, f posFixture, sales *posServices.SaleService) *posModels.Sale {
+		sale, err := sales.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1}},
+		}, &cashier)
+		require.NoError(t, err)
+		return sale
+	}
+	charge := func(token string) posPort.CreateGatewayChargeRequest {
+		return posPort.CreateGatewayChargeRequest{Provider: "fake", PaymentToken: token}
+	}
+
+	t.Run("Capture_RecordsCardPaymentOnSale", func(t *testing.T) {
+		f, svc, _, sales := setup(t)
+		sale := newSale(t, f, sales)
+
+		_, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, posPort.CreateGatewayChargeRequest{Provider: "other", PaymentToken: "tok"}, &cashier)
+		assert.ErrorContains(t, err, "not found")
+
+		p, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, charge("tok_visa"), &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, p.Status)
+		assert.Equal(t, posModels.Money(25000), p.Amount)
+		require.NotNil(t, p.ProviderTxnID)
+		require.NotNil(t, p.PaymentID)
+
+		sale, err = sales.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SalePaid, sale.Status)
+		if assert.Len(t, sale.Payments, 1) {
+			assert.Equal(t, posModels.PaymentCard, sale.Payments[0].Method)
+			assert.Equal(t, p.Reference, sale.Payments[0].Reference)
+		}
+
+		_, err = svc.ChargeSale(ctx, f.TenantID, sale.ID, charge("tok_visa"), &cashier)
+		assert.ErrorContains(t, err, "invalid status")
+	})
+
+	t.Run("Decline_MarksFailedWithoutPayment", func(t *testing.T) {
+		f, svc, _, sales := setup(t)
+		sale := newSale(t, f, sales)
+
+		p, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, charge(posServices.FakeTokenDecline), &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayFailed, p.Status)
+		assert.Equal(t, "card declined", p.FailureReason)
+		assert.Nil(t, p.PaymentID)
+
+		sale, err = sales.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SaleOpen, sale.Status)
+		assert.Empty(t, sale.Payments)
+	})
+
+	t.Run("Webhook_MovesAuthorizedToCapturedIdempotently", func(t *testing.T) {
+		f, svc, fake, sales := setup(t)
+		sale := newSale(t, f, sales)
+
+		p, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, charge(posServices.FakeTokenAuthorize), &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayAuthorized, p.Status)
+		// ยอดที่รอตัดเงินถือว่าจองไว้แล้ว
+		_, err = svc.ChargeSale(ctx, f.TenantID, sale.ID, charge("tok_visa"), &cashier)
+		assert.ErrorContains(t, err, "already being charged")
+
+		authorized, err := fake.Webhook(*p.ProviderTxnID)
+		require.NoError(t, err)
+		require.NoError(t, fake.Capture(*p.ProviderTxnID))
+		captured, err := fake.Webhook(*p.ProviderTxnID)
+		require.NoError(t, err)
+
+		_, err = svc.HandleWebhook(ctx, "fake", posServices.SignedWebhook("wrong", captured.Body, time.Now(), posServices.HeaderGatewayTimestamp, posServices.HeaderGatewaySignature))
+		assert.ErrorIs(t, err, posServices.ErrInvalidWebhookSignature)
+
+		got, err := svc.HandleWebhook(ctx, "fake", captured)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, got.Status)
+		// ส่งซ้ำ และ event เก่าที่มาช้าไม่เปลี่ยนสถานะหรือบันทึกซ้ำ
+		_, err = svc.HandleWebhook(ctx, "fake", captured)
+		require.NoError(t, err)
+		got, err = svc.HandleWebhook(ctx, "fake", authorized)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, got.Status)
+
+		sale, err = sales.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SalePaid, sale.Status)
+		assert.Len(t, sale.Payments, 1)
+	})
+
+	t.Run("Timeout_ReconciledByReferenceWebhook", func(t *testing.T) {
+		f, svc, fake, sales := setup(t)
+		sale := newSale(t, f, sales)
+
+		p, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, charge(posServices.FakeTokenTimeout), &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayPending, p.Status)
+		assert.Nil(t, p.ProviderTxnID)
+		// ยังไม่รู้ผล ยอดนี้ยังถูกจองไว้ ตัดซ้ำไม่ได้
+		_, err = svc.ChargeSale(ctx, f.TenantID, sale.ID, charge("tok_visa"), &cashier)
+		assert.ErrorContains(t, err, "already being charged")
+
+		txnID, ok := fake.TxnByReference(p.Reference)
+		require.True(t, ok)
+		hook, err := fake.Webhook(txnID)
+		require.NoError(t, err)
+		got, err := svc.HandleWebhook(ctx, "fake", hook)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, got.Status)
+		assert.Equal(t, txnID, *got.ProviderTxnID)
+		assert.NotNil(t, got.PaymentID)
+	})
+
+	t.Run("Refund_RecordsRefundAndAllowsVoid", func(t *testing.T) {
+		f, svc, _, sales := setup(t)
+		sale := newSale(t, f, sales)
+		p, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, charge("tok_visa"), &cashier)
+		require.NoError(t, err)
+
+		_, err = sales.VoidSale(ctx, f.TenantID, sale.ID, "customer left", &cashier)
+		assert.ErrorContains(t, err, "refund gateway payment")
+
+		part := posModels.Money(5000)
+		p, err = svc.RefundPayment(ctx, f.TenantID, p.ID, posPort.RefundGatewayPaymentRequest{Amount: &part})
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, p.Status)
+		assert.Equal(t, part, p.RefundedAmount)
+		// ถามสถานะซ้ำไม่บันทึกยอดคืนซ้ำ
+		_, err = svc.SyncPayment(ctx, f.TenantID, p.ID)
+		require.NoError(t, err)
+
+		p, err = svc.RefundPayment(ctx, f.TenantID, p.ID, posPort.RefundGatewayPaymentRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayRefunded, p.Status)
+		assert.Equal(t, p.Amount, p.RefundedAmount)
+
+		sale, err = sales.VoidSale(ctx, f.TenantID, sale.ID, "customer left", &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(25000), sale.RefundedAmount)
+		var refunds posModels.Money
+		for _, pm := range sale.Payments {
+			if pm.Kind == posModels.PaymentKindRefund {
+				refunds += pm.Amount
+			}
+		}
+		assert.Equal(t, posModels.Money(25000), refunds)
+	})
+
+	t.Run("Refund_ReservesAmountWhileProviderIsCalled", func(t *testing.T) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		sales := posServices.NewSaleService(db)
+		provider := &slowRefundProvider{FakeProvider: posServices.NewFakeProvider(secret)}
+		svc := posServices.NewGatewayService(db, provider)
+		p, err := svc.ChargeSale(ctx, f.TenantID, newSale(t, f, sales).ID, charge("tok_visa"), &cashier)
+		require.NoError(t, err)
+
+		// คำขอที่สองเข้ามาระหว่างที่คำขอแรกรอผู้ให้บริการ
+		var concurrentErr error
+		provider.during = func() {
+			_, concurrentErr = svc.RefundPayment(ctx, f.TenantID, p.ID, posPort.RefundGatewayPaymentRequest{})
+		}
+		p, err = svc.RefundPayment(ctx, f.TenantID, p.ID, posPort.RefundGatewayPaymentRequest{})
+		require.NoError(t, err)
+		assert.ErrorContains(t, concurrentErr, "invalid amount")
+		assert.Equal(t, posModels.GatewayRefunded, p.Status)
+		assert.Equal(t, p.Amount, p.RefundedAmount)
+		assert.Zero(t, p.RefundPending)
+	})
+
+	t.Run("Deposit_AppliedWhenSaleOpens", func(t *testing.T) {
+		f, svc, _, sales := setup(t)
+		ap := f.completedAppointment(t, sales.DB)
+		require.NoError(t, sales.DB.Model(&ap).Update("status", barberBookingModels.StatusConfirmed).Error)
+
+		deposit := posModels.Money(10000)
+		p, err := svc.ChargeDeposit(ctx, f.TenantID, ap.ID, posPort.CreateGatewayChargeRequest{Provider: "fake", PaymentToken: "tok_visa", Amount: &deposit}, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, p.Status)
+		assert.Nil(t, p.PaymentID)
+
+		require.NoError(t, sales.DB.Model(&ap).Update("status", barberBookingModels.StatusComplete).Error)
+		sale, err := sales.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(10000), sale.PaidAmount)
+		if assert.Len(t, sale.Payments, 1) {
+			assert.Equal(t, posModels.PaymentCard, sale.Payments[0].Method)
+		}
+
+		p, err = svc.GetPayment(ctx, f.TenantID, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, sale.ID, *p.SaleID)
+		assert.NotNil(t, p.PaymentID)
+	})
+
+	t.Run("ReconcilePending_UsesProviderStatus", func(t *testing.T) {
+		f, svc, fake, sales := setup(t)
+		sale := newSale(t, f, sales)
+		p, err := svc.ChargeSale(ctx, f.TenantID, sale.ID, charge(posServices.FakeTokenAuthorize), &cashier)
+		require.NoError(t, err)
+		require.NoError(t, fake.Capture(*p.ProviderTxnID))
+
+		n, err := svc.ReconcilePending(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		svc.Now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+		n, err = svc.ReconcilePending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		p, err = svc.GetPayment(ctx, f.TenantID, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, p.Status)
+	})
+
+	t.Run("ReconcilePending_LooksUpUnknownChargesByReference", func(t *testing.T) {
+		f, svc, _, sales := setup(t)
+		timedOut, err := svc.ChargeSale(ctx, f.TenantID, newSale(t, f, sales).ID, charge(posServices.FakeTokenTimeout), &cashier)
+		require.NoError(t, err)
+		offline, err := svc.ChargeSale(ctx, f.TenantID, newSale(t, f, sales).ID, charge(posServices.FakeTokenOffline), &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayPending, offline.Status)
+
+		// ก่อนพ้นเวลารอ ถามด้วยมือแล้วไม่พบธุรกรรมยังคง PENDING
+		got, err := svc.SyncPayment(ctx, f.TenantID, offline.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayPending, got.Status)
+
+		svc.Now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+		n, err := svc.ReconcilePending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		got, err = svc.GetPayment(ctx, f.TenantID, timedOut.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayCaptured, got.Status)
+		assert.NotNil(t, got.ProviderTxnID)
+		assert.NotNil(t, got.PaymentID)
+
+		got, err = svc.GetPayment(ctx, f.TenantID, offline.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.GatewayFailed, got.Status)
+		assert.Nil(t, got.PaymentID)
+	})
+}
+
+// slowRefundProvider เรียก during หนึ่งครั้งก่อนคืนเงินจริง จำลองคำขออื่นที่เข้ามาระหว่างรอผู้ให้บริการ
+type slowRefundProvider struct {
+	*posServices.FakeProvider
+	during func()
+}
+
+func (p *slowRefundProvider) Refund(ctx context.Context, txnID string, amount posModels.Money) (*posPort.ProviderTransaction, error) {
+	if during := p.during; during != nil {
+		p.during = nil
+		during()
+	}
+	return p.FakeProvider.Refund(ctx, txnID, amount)
+}
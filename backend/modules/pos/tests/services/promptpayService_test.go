@@ -0,0 +1,215 @@
+package posServiceTest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+	webhookServices "myapp/modules/webhook/services"
+)
+
+func TestPromptPayPayload(t *testing.T) {
+	static, err := posServices.PromptPayPayload(posModels.PromptPayMobile, "000-000-0000", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "00020101021129370016A000000677010111011300660000000005802TH530376463048956", static)
+
+	amount := posModels.Money(422)
+	dynamic, err := posServices.PromptPayPayload(posModels.PromptPayMobile, "0000000000", &amount)
+	require.NoError(t, err)
+	assert.Equal(t, "00020101021229370016A000000677010111011300660000000005802TH530376454044.226304E469", dynamic)
+
+	nid, err := posServices.PromptPayPayload(posModels.PromptPayNationalID, "1-1111-11111-11-1", nil)
+	require.NoError(t, err)
+	assert.Contains(t, nid, "02131111111111111")
+
+	_, err = posServices.PromptPayPayload(posModels.PromptPayMobile, "812345678", nil)
+	assert.ErrorContains(t, err, "invalid proxy_id")
+
+	img, err := posServices.PromptPayPNG(dynamic)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, decoded.Bounds().Dx(), decoded.Bounds().Dy())
+}
+
+func TestPromptPayService(t *testing.T) {
+	ctx := context.Background()
+	cashier := uint(7)
+	secret := "slip-secret"
+
+	setup := func(t *testing.T) (posFixture, *posServices.PromptPayService, *posServices.SaleService) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		svc := posServices.NewPromptPayService(db, posServices.NewSignedSlipVerifier(secret))
+		_, err := svc.SetAccount(ctx, f.TenantID, f.BranchID, posPort.SetPromptPayAccountRequest{ProxyType: posModels.PromptPayMobile, ProxyID: "081-234-5678"})
+		require.NoError(t, err)
+		return f, svc, posServices.NewSaleService(db)
+	}
+	slipHook := func(body string, sign string) posPort.InboundWebhook {
+		ts := time.Now().Unix()
+		sig := webhookServices.Sign(sign, ts, []byte(body))
+		headers := map[string]string{
+			posServices.HeaderSlipTimestamp: strconv.FormatInt(ts, 10),
+			posServices.HeaderSlipSignature: sig,
+		}
+		return posPort.InboundWebhook{Body: []byte(body), Header: func(k string) string { return headers[k] }}
+	}
+	slipBody := func(ref, transRef, amount string) string {
+		return fmt.Sprintf(`{"reference":%q,"trans_ref":%q,"amount":%s,"receiver_proxy_id":"xxx-xxx-5678","paid_at":%q}`,
+			ref, transRef, amount, time.Now().Add(time.Minute).Format(time.RFC3339))
+	}
+	newSale := func(t *testing.T, f posFixture, sales *posServices.SaleService) *posModels.Sale {
+		sale, err := sales.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1}},
+		}, &cashier)
+		require.NoError(t, err)
+		return sale
+	}
+
+	t.Run("StaffConfirm_RecordsPaymentOnSale", func(t *testing.T) {
+		f, svc, sales := setup(t)
+		sale := newSale(t, f, sales)
+
+		first, err := svc.CreateSaleCharge(ctx, f.TenantID, sale.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(25000), first.Amount)
+		assert.Contains(t, first.Payload, "0066812345678")
+		assert.Contains(t, first.Payload, "5406250.00")
+
+		// ออก QR ใหม่ QR เดิมถูกยกเลิก
+		charge, err := svc.CreateSaleCharge(ctx, f.TenantID, sale.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		require.NoError(t, err)
+		_, err = svc.ConfirmCharge(ctx, f.TenantID, first.ID, posPort.ConfirmPromptPayRequest{}, cashier)
+		assert.ErrorContains(t, err, "invalid status")
+
+		charge, err = svc.ConfirmCharge(ctx, f.TenantID, charge.ID, posPort.ConfirmPromptPayRequest{TransRef: "BANK-1"}, cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.PromptPayPaid, charge.Status)
+		assert.Equal(t, posModels.PromptPayByStaff, charge.ConfirmedVia)
+		require.NotNil(t, charge.PaymentID)
+
+		sale, err = sales.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SalePaid, sale.Status)
+		if assert.Len(t, sale.Payments, 1) {
+			assert.Equal(t, posModels.PaymentPromptPay, sale.Payments[0].Method)
+			assert.Equal(t, charge.Reference, sale.Payments[0].Reference)
+		}
+	})
+
+	t.Run("SlipWebhook_VerifiesAndIsIdempotent", func(t *testing.T) {
+		f, svc, sales := setup(t)
+		sale := newSale(t, f, sales)
+		charge, err := svc.CreateSaleCharge(ctx, f.TenantID, sale.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		require.NoError(t, err)
+
+		_, err = svc.ConfirmSlip(ctx, slipHook(slipBody(charge.Reference, "TX-1", "250"), "wrong-secret"))
+		assert.ErrorIs(t, err, posServices.ErrInvalidSlipSignature)
+		_, err = svc.ConfirmSlip(ctx, slipHook(slipBody(charge.Reference, "TX-1", "200"), secret))
+		assert.ErrorContains(t, err, "invalid slip")
+
+		// สลิปไม่มีข้อมูลผู้รับ → ผ่านได้เฉพาะเมื่อผู้ตรวจสลิปยืนยันผู้รับเองแล้ว
+		noReceiver := func(verified bool) string {
+			return fmt.Sprintf(`{"reference":%q,"trans_ref":"TX-NR","amount":250,"receiver_verified":%t,"paid_at":%q}`,
+				charge.Reference, verified, time.Now().Add(time.Minute).Format(time.RFC3339))
+		}
+		_, err = svc.ConfirmSlip(ctx, slipHook(noReceiver(false), secret))
+		assert.ErrorContains(t, err, "receiver does not match")
+
+		paid, err := svc.ConfirmSlip(ctx, slipHook(slipBody(charge.Reference, "TX-1", "250"), secret))
+		require.NoError(t, err)
+		assert.Equal(t, posModels.PromptPayPaid, paid.Status)
+		assert.Equal(t, posModels.PromptPayBySlip, paid.ConfirmedVia)
+
+		// ส่งซ้ำไม่บันทึกการชำระซ้ำ
+		again, err := svc.ConfirmSlip(ctx, slipHook(slipBody(charge.Reference, "TX-1", "250"), secret))
+		require.NoError(t, err)
+		assert.Equal(t, *paid.PaymentID, *again.PaymentID)
+		sale, err = sales.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Len(t, sale.Payments, 1)
+
+		// สลิปเดิมใช้กับรายการอื่นไม่ได้
+		other := newSale(t, f, sales)
+		otherCharge, err := svc.CreateSaleCharge(ctx, f.TenantID, other.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		require.NoError(t, err)
+		_, err = svc.ConfirmSlip(ctx, slipHook(slipBody(otherCharge.Reference, "TX-1", "250"), secret))
+		assert.ErrorContains(t, err, "already exists")
+	})
+
+	t.Run("Confirm_CapsAtBalanceAndKeepsOverpayment", func(t *testing.T) {
+		f, svc, sales := setup(t)
+		sale := newSale(t, f, sales)
+		charge, err := svc.CreateSaleCharge(ctx, f.TenantID, sale.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		require.NoError(t, err)
+
+		// ระหว่างรอโอน ลูกค้าจ่ายบัตรไปบางส่วน
+		_, err = sales.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCard, Amount: 5000}, &cashier)
+		require.NoError(t, err)
+
+		paid, err := svc.ConfirmSlip(ctx, slipHook(slipBody(charge.Reference, "TX-OVER", "250"), secret))
+		require.NoError(t, err)
+		assert.Equal(t, posModels.PromptPayPaid, paid.Status)
+		assert.Equal(t, posModels.Money(5000), paid.OverpaidAmount)
+
+		sale, err = sales.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SalePaid, sale.Status)
+		if assert.Len(t, sale.Payments, 2) {
+			assert.Equal(t, posModels.Money(20000), sale.Payments[1].Amount)
+		}
+
+		// บิลจ่ายครบไปแล้ว → ยืนยันได้ ยอดทั้งหมดเป็นยอดที่ต้องคืน
+		other := newSale(t, f, sales)
+		late, err := svc.CreateSaleCharge(ctx, f.TenantID, other.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		require.NoError(t, err)
+		_, err = sales.AddPayment(ctx, f.TenantID, other.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCard, Amount: 25000}, &cashier)
+		require.NoError(t, err)
+		late, err = svc.ConfirmSlip(ctx, slipHook(slipBody(late.Reference, "TX-LATE", "250"), secret))
+		require.NoError(t, err)
+		assert.Equal(t, posModels.PromptPayPaid, late.Status)
+		assert.Equal(t, posModels.Money(25000), late.OverpaidAmount)
+		assert.Nil(t, late.PaymentID)
+	})
+
+	t.Run("Deposit_AppliedWhenSaleOpens", func(t *testing.T) {
+		f, svc, sales := setup(t)
+		ap := f.completedAppointment(t, sales.DB)
+		require.NoError(t, sales.DB.Model(&ap).Update("status", barberBookingModels.StatusConfirmed).Error)
+
+		_, err := svc.CreateDepositCharge(ctx, f.TenantID, ap.ID, posPort.CreatePromptPayChargeRequest{}, &cashier)
+		assert.ErrorContains(t, err, "invalid amount")
+		deposit := posModels.Money(10000)
+		charge, err := svc.CreateDepositCharge(ctx, f.TenantID, ap.ID, posPort.CreatePromptPayChargeRequest{Amount: &deposit}, &cashier)
+		require.NoError(t, err)
+		charge, err = svc.ConfirmSlip(ctx, slipHook(slipBody(charge.Reference, "TX-DEP", "100"), secret))
+		require.NoError(t, err)
+		assert.Nil(t, charge.PaymentID)
+
+		require.NoError(t, sales.DB.Model(&ap).Update("status", barberBookingModels.StatusComplete).Error)
+		sale, err := sales.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(10000), sale.PaidAmount)
+		assert.Equal(t, posModels.Money(18000), sale.Balance())
+		if assert.Len(t, sale.Payments, 1) {
+			assert.Equal(t, posModels.PaymentPromptPay, sale.Payments[0].Method)
+		}
+
+		charge, err = svc.GetCharge(ctx, f.TenantID, charge.ID)
+		require.NoError(t, err)
+		assert.Equal(t, sale.ID, *charge.SaleID)
+		assert.NotNil(t, charge.PaymentID)
+	})
+}
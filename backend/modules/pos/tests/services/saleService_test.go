@@ -0,0 +1,261 @@
+package posServiceTest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	barberBookingModels "myapp/modules/barberbooking/models"
+	coreModels "myapp/modules/core/models"
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+)
+
+func setupPOSDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// :memory: แยกฐานข้อมูลต่อ connection
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(
+		&coreModels.Branch{},
+		&barberBookingModels.Service{},
+		&barberBookingModels.Barber{},
+		&barberBookingModels.Customer{},
+		&barberBookingModels.Appointment{},
+		&barberBookingModels.AppointmentItem{},
+		&posModels.Sale{},
+		&posModels.SaleItem{},
+		&posModels.Payment{},
+		&posModels.CashShift{},
+		&posModels.CashMovement{},
+		&posModels.PromptPayAccount{},
+		&posModels.PromptPayCharge{},
+		&posModels.GatewayPayment{},
+		&posModels.GatewayEvent{},
+	))
+	return db
+}
+
+type posFixture struct {
+	TenantID uint
+	BranchID uint
+	Cut      barberBookingModels.Service
+	Shave    barberBookingModels.Service
+	Barber   barberBookingModels.Barber
+	Customer barberBookingModels.Customer
+}
+
+func seedPOSFixture(t *testing.T, db *gorm.DB) posFixture {
+	f := posFixture{TenantID: 1}
+	branch := coreModels.Branch{TenantID: f.TenantID, Name: "Branch 1"}
+	require.NoError(t, db.Create(&branch).Error)
+	f.BranchID = branch.ID
+
+	f.Cut = barberBookingModels.Service{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Cut", Duration: 30, Price: 250}
+	require.NoError(t, db.Create(&f.Cut).Error)
+	f.Shave = barberBookingModels.Service{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Shave", Duration: 15, Price: 100}
+	require.NoError(t, db.Create(&f.Shave).Error)
+	f.Barber = barberBookingModels.Barber{TenantID: f.TenantID, BranchID: f.BranchID, UserID: 100}
+	require.NoError(t, db.Create(&f.Barber).Error)
+	f.Customer = barberBookingModels.Customer{TenantID: f.TenantID, BranchID: f.BranchID, Name: "Alice", Phone: "0800000000"}
+	require.NoError(t, db.Create(&f.Customer).Error)
+	return f
+}
+
+// completedAppointment นัด COMPLETED ที่จองไว้ในราคาเดิม (Cut 200 + Shave 80)
+func (f posFixture) completedAppointment(t *testing.T, db *gorm.DB) barberBookingModels.Appointment {
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	ap := barberBookingModels.Appointment{
+		TenantID:   f.TenantID,
+		BranchID:   f.BranchID,
+		ServiceID:  f.Cut.ID,
+		BarberID:   f.Barber.ID,
+		CustomerID: f.Customer.ID,
+		StartTime:  start,
+		EndTime:    start.Add(45 * time.Minute),
+		Status:     barberBookingModels.StatusComplete,
+		Items: []barberBookingModels.AppointmentItem{
+			{ServiceID: f.Cut.ID, Position: 0, Duration: 30, Price: 200},
+			{ServiceID: f.Shave.ID, Position: 1, Duration: 15, Price: 80},
+		},
+	}
+	require.NoError(t, db.Omit("Service", "Barber", "Items.Service").Create(&ap).Error)
+	return ap
+}
+
+func TestSaleService(t *testing.T) {
+	ctx := context.Background()
+	cashier := uint(7)
+
+	setup := func(t *testing.T) (*gorm.DB, posFixture, *posServices.SaleService) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		// รับเงินสดต้องมีกะเปิดอยู่
+		_, err := posServices.NewShiftService(db).OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID}, cashier)
+		require.NoError(t, err)
+		return db, f, posServices.NewSaleService(db)
+	}
+	price := func(v string) *posModels.Money {
+		m, err := posModels.ParseMoney(v)
+		require.NoError(t, err)
+		return &m
+	}
+
+	t.Run("FromAppointment_UsesBookedItemPrices", func(t *testing.T) {
+		db, f, svc := setup(t)
+		ap := f.completedAppointment(t, db)
+
+		sale, err := svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SaleOpen, sale.Status)
+		assert.Equal(t, ap.ID, *sale.AppointmentID)
+		assert.Equal(t, f.Customer.ID, *sale.CustomerID)
+		if assert.Len(t, sale.Items, 2) {
+			assert.Equal(t, "Cut", sale.Items[0].Name)
+			assert.Equal(t, posModels.Money(20000), sale.Items[0].UnitPrice)
+			assert.Equal(t, posModels.Money(8000), sale.Items[1].UnitPrice)
+			assert.Equal(t, f.Barber.ID, *sale.Items[0].BarberID)
+		}
+		assert.Equal(t, posModels.Money(28000), sale.Total)
+	})
+
+	t.Run("FromAppointment_RejectsSecondActiveSale", func(t *testing.T) {
+		db, f, svc := setup(t)
+		ap := f.completedAppointment(t, db)
+
+		first, err := svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+		_, err = svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		assert.ErrorContains(t, err, "already exists")
+
+		// VOID แล้วเปิดบิลใหม่ได้
+		_, err = svc.VoidSale(ctx, f.TenantID, first.ID, "wrong items", &cashier)
+		require.NoError(t, err)
+		_, err = svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		assert.NoError(t, err)
+	})
+
+	t.Run("FromAppointment_RejectsPendingAppointment", func(t *testing.T) {
+		db, f, svc := setup(t)
+		ap := f.completedAppointment(t, db)
+		require.NoError(t, db.Model(&ap).Update("status", barberBookingModels.StatusPending).Error)
+
+		_, err := svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		assert.ErrorContains(t, err, "invalid appointment status")
+	})
+
+	t.Run("SplitPayment_ClosesSaleWithCashChange", func(t *testing.T) {
+		db, f, svc := setup(t)
+		ap := f.completedAppointment(t, db)
+		sale, err := svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+
+		sale, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCard, Amount: 10050}, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SaleOpen, sale.Status)
+		assert.Equal(t, posModels.Money(17950), sale.Balance())
+
+		sale, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 20000}, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SalePaid, sale.Status)
+		assert.NotNil(t, sale.PaidAt)
+		assert.Equal(t, posModels.Money(30050), sale.PaidAmount)
+		assert.Equal(t, posModels.Money(2050), sale.ChangeAmount)
+		assert.Len(t, sale.Payments, 2)
+
+		_, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 100}, &cashier)
+		assert.ErrorContains(t, err, "invalid status")
+	})
+
+	t.Run("NonCashPayment_CannotExceedBalance", func(t *testing.T) {
+		db, f, svc := setup(t)
+		ap := f.completedAppointment(t, db)
+		sale, err := svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+
+		_, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCard, Amount: 28001}, &cashier)
+		assert.ErrorContains(t, err, "exceeds balance")
+		_, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentTransfer, Amount: 30000}, &cashier)
+		assert.ErrorContains(t, err, "exceeds balance")
+
+		sale, err = svc.GetSale(ctx, f.TenantID, sale.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sale.Payments)
+	})
+
+	t.Run("Items_LockedAfterPayment", func(t *testing.T) {
+		_, f, svc := setup(t)
+		sale, err := svc.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items: []posPort.SaleItemInput{
+				{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1},
+				{ItemType: posModels.ItemProduct, Name: "Pomade", Quantity: 2, UnitPrice: price("199.50")},
+			},
+		}, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(64900), sale.Total)
+
+		_, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 10000}, &cashier)
+		require.NoError(t, err)
+
+		_, err = svc.AddItem(ctx, f.TenantID, sale.ID, posPort.SaleItemInput{ItemType: posModels.ItemService, ServiceID: &f.Shave.ID, Quantity: 1})
+		assert.ErrorContains(t, err, "cannot be changed after a payment")
+		_, err = svc.RemoveItem(ctx, f.TenantID, sale.ID, sale.Items[1].ID)
+		assert.ErrorContains(t, err, "cannot be changed after a payment")
+	})
+
+	t.Run("ServiceItem_RejectsPriceOverride", func(t *testing.T) {
+		_, f, svc := setup(t)
+		_, err := svc.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1, UnitPrice: price("1")}},
+		}, &cashier)
+		assert.ErrorContains(t, err, "invalid unit_price")
+	})
+
+	t.Run("VoidPaidSale_WritesRefunds", func(t *testing.T) {
+		db, f, svc := setup(t)
+		ap := f.completedAppointment(t, db)
+		sale, err := svc.CreateSaleFromAppointment(ctx, f.TenantID, ap.ID, &cashier)
+		require.NoError(t, err)
+		_, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentTransfer, Amount: 8000}, &cashier)
+		require.NoError(t, err)
+		_, err = svc.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 50000}, &cashier)
+		require.NoError(t, err)
+
+		sale, err = svc.VoidSale(ctx, f.TenantID, sale.ID, "customer complaint", &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.SaleVoid, sale.Status)
+		assert.Equal(t, posModels.Money(28000), sale.RefundedAmount)
+
+		var refunds []posModels.Payment
+		require.NoError(t, db.Order("id").
+			Where("sale_id = ? AND kind = ?", sale.ID, posModels.PaymentKindRefund).Find(&refunds).Error)
+		if assert.Len(t, refunds, 2) {
+			assert.Equal(t, posModels.PaymentTransfer, refunds[0].Method)
+			assert.Equal(t, posModels.Money(8000), refunds[0].Amount)
+			// เงินสดคืนเฉพาะส่วนที่เก็บไว้ (500 - ทอน 300)
+			assert.Equal(t, posModels.PaymentCash, refunds[1].Method)
+			assert.Equal(t, posModels.Money(20000), refunds[1].Amount)
+		}
+	})
+}
+
+func TestMoney(t *testing.T) {
+	for in, want := range map[string]posModels.Money{"250": 25000, "0.1": 10, "199.50": 19950, "-3.05": -305, "12.3400": 1234} {
+		got, err := posModels.ParseMoney(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := posModels.ParseMoney("1.005")
+	assert.Error(t, err)
+	assert.Equal(t, "-3.05", posModels.Money(-305).String())
+}
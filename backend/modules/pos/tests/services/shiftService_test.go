@@ -0,0 +1,183 @@
+package posServiceTest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	posModels "myapp/modules/pos/models"
+	posPort "myapp/modules/pos/port"
+	posServices "myapp/modules/pos/services"
+)
+
+func TestShiftService(t *testing.T) {
+	ctx := context.Background()
+	cashier := uint(7)
+	other := uint(8)
+
+	t.Run("CashPayment_RequiresOpenShift", func(t *testing.T) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		sales := posServices.NewSaleService(db)
+		sale, err := sales.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1}},
+		}, &cashier)
+		require.NoError(t, err)
+
+		_, err = sales.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 25000}, &cashier)
+		assert.ErrorContains(t, err, "invalid shift")
+		// ช่องทางอื่นไม่ต้องมีกะ
+		_, err = sales.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCard, Amount: 25000}, &cashier)
+		assert.NoError(t, err)
+	})
+
+	t.Run("OpenShift_OnePerCashierPerBranch", func(t *testing.T) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		svc := posServices.NewShiftService(db)
+
+		_, err := svc.OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID, OpeningFloat: 100000}, cashier)
+		require.NoError(t, err)
+		_, err = svc.OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID}, cashier)
+		assert.ErrorContains(t, err, "already exists")
+		_, err = svc.OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID}, other)
+		assert.NoError(t, err)
+		_, err = svc.OpenShift(ctx, 2, posPort.OpenShiftRequest{BranchID: f.BranchID}, 9)
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("CloseShift_ComputesExpectedAndVariance", func(t *testing.T) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		svc := posServices.NewShiftService(db)
+		sales := posServices.NewSaleService(db)
+
+		shift, err := svc.OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID, OpeningFloat: 100000}, cashier)
+		require.NoError(t, err)
+
+		// บิล 250 จ่ายเงินสด 300 ทิป 20 ทอน 50
+		sale, err := sales.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1}},
+		}, &cashier)
+		require.NoError(t, err)
+		sale, err = sales.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 30000, Tip: 2000}, &cashier)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(5000), sale.ChangeAmount)
+		assert.Equal(t, posModels.Money(2000), sale.TipAmount)
+
+		_, err = svc.AddCashMovement(ctx, f.TenantID, shift.ID, posPort.CashMovementRequest{Type: posModels.CashIn, Amount: 50000, Reason: "coins"}, cashier, false)
+		require.NoError(t, err)
+		_, err = svc.AddCashMovement(ctx, f.TenantID, shift.ID, posPort.CashMovementRequest{Type: posModels.CashOut, Amount: 30000, Reason: "supplies"}, cashier, false)
+		require.NoError(t, err)
+		_, err = svc.AddCashMovement(ctx, f.TenantID, shift.ID, posPort.CashMovementRequest{Type: posModels.CashOut, Amount: 1000}, cashier, false)
+		assert.ErrorContains(t, err, "invalid reason")
+		_, err = svc.AddCashMovement(ctx, f.TenantID, shift.ID, posPort.CashMovementRequest{Type: posModels.CashOut, Amount: 1000000, Reason: "bank"}, cashier, false)
+		assert.ErrorContains(t, err, "exceeds cash in drawer")
+		_, err = svc.AddCashMovement(ctx, f.TenantID, shift.ID, posPort.CashMovementRequest{Type: posModels.CashIn, Amount: 1000, Reason: "x"}, other, false)
+		assert.ErrorContains(t, err, "permission denied")
+
+		_, err = svc.CloseShift(ctx, f.TenantID, shift.ID, posPort.CloseShiftRequest{CountedCash: 145000}, other, false)
+		assert.ErrorContains(t, err, "permission denied")
+
+		// 1000 + (300 + 20 - 50) + 500 - 300 = 1470 นับได้ 1450 ขาด 20
+		report, err := svc.CloseShift(ctx, f.TenantID, shift.ID, posPort.CloseShiftRequest{CountedCash: 145000, Note: "short"}, other, true)
+		require.NoError(t, err)
+		assert.Equal(t, posModels.Money(27000), report.Cash.CashSales)
+		assert.Equal(t, posModels.Money(147000), report.Cash.Expected)
+		assert.Equal(t, posModels.ShiftClosed, report.Shift.Status)
+		assert.Equal(t, posModels.Money(-2000), *report.Shift.Variance)
+		assert.Len(t, report.Shift.CashMovements, 2)
+		if assert.Len(t, report.Payments, 1) {
+			assert.Equal(t, posModels.Money(25000), report.Payments[0].Amount)
+			assert.Equal(t, posModels.Money(2000), report.Payments[0].Tips)
+		}
+
+		_, err = svc.CloseShift(ctx, f.TenantID, shift.ID, posPort.CloseShiftRequest{CountedCash: 0}, cashier, false)
+		assert.ErrorContains(t, err, "invalid status")
+		_, err = sales.AddPayment(ctx, f.TenantID, sale.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 100}, &cashier)
+		assert.Error(t, err)
+	})
+
+	t.Run("ZReport_SummarisesBranchDay", func(t *testing.T) {
+		db := setupPOSDB(t)
+		f := seedPOSFixture(t, db)
+		svc := posServices.NewShiftService(db)
+		sales := posServices.NewSaleService(db)
+		from := time.Now().Add(-time.Hour)
+		to := time.Now().Add(time.Hour)
+
+		shift, err := svc.OpenShift(ctx, f.TenantID, posPort.OpenShiftRequest{BranchID: f.BranchID}, cashier)
+		require.NoError(t, err)
+
+		// บิลแรก: Cut 250 ลด 10 + สินค้า 100 ลดท้ายบิล 40 = 300 จ่ายบัตร 200 + เงินสด 100 ทิป 30
+		first, err := sales.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Discount: 4000,
+			Items: []posPort.SaleItemInput{
+				{ItemType: posModels.ItemService, ServiceID: &f.Cut.ID, Quantity: 1, Discount: 1000},
+				{ItemType: posModels.ItemProduct, Name: "Wax", Quantity: 1, UnitPrice: ptrMoney(10000)},
+			},
+		}, &cashier)
+		require.NoError(t, err)
+		require.Equal(t, posModels.Money(30000), first.Total)
+		_, err = sales.AddPayment(ctx, f.TenantID, first.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCard, Amount: 20000}, &cashier)
+		require.NoError(t, err)
+		_, err = sales.AddPayment(ctx, f.TenantID, first.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 10000, Tip: 3000}, &cashier)
+		require.NoError(t, err)
+
+		// บิลที่สอง: Shave 100 เงินสด แล้ว VOID คืนเงินสด
+		second, err := sales.CreateSale(ctx, f.TenantID, posPort.CreateSaleRequest{
+			BranchID: f.BranchID,
+			Items:    []posPort.SaleItemInput{{ItemType: posModels.ItemService, ServiceID: &f.Shave.ID, Quantity: 1}},
+		}, &cashier)
+		require.NoError(t, err)
+		_, err = sales.AddPayment(ctx, f.TenantID, second.ID, posPort.AddPaymentRequest{Method: posModels.PaymentCash, Amount: 10000}, &cashier)
+		require.NoError(t, err)
+		_, err = sales.VoidSale(ctx, f.TenantID, second.ID, "wrong customer", &cashier)
+		require.NoError(t, err)
+
+		_, err = svc.CloseShift(ctx, f.TenantID, shift.ID, posPort.CloseShiftRequest{CountedCash: 13000}, cashier, false)
+		require.NoError(t, err)
+
+		z, err := svc.GetZReport(ctx, f.TenantID, f.BranchID, from, to)
+		require.NoError(t, err)
+		// บิลที่ VOID นับเฉพาะใน VoidCount ไม่รวมในยอดขาย
+		assert.Equal(t, 1, z.SalesCount)
+		assert.Equal(t, 1, z.VoidCount)
+		assert.Equal(t, posModels.Money(25000), z.ServiceSales)
+		assert.Equal(t, posModels.Money(10000), z.ProductSales)
+		assert.Equal(t, posModels.Money(1000), z.LineDiscounts)
+		assert.Equal(t, posModels.Money(4000), z.BillDiscounts)
+		assert.Equal(t, posModels.Money(30000), z.NetSales)
+		assert.Equal(t, posModels.Money(3000), z.Tips)
+		assert.Equal(t, posModels.Money(10000), z.RefundTotal)
+		if assert.Len(t, z.Payments, 2) {
+			assert.Equal(t, posModels.PaymentCash, z.Payments[0].Method)
+			assert.Equal(t, 2, z.Payments[0].Count)
+			assert.Equal(t, posModels.Money(20000), z.Payments[0].Amount)
+			assert.Equal(t, posModels.PaymentCard, z.Payments[1].Method)
+		}
+		if assert.Len(t, z.Shifts, 1) {
+			assert.Equal(t, posModels.Money(0), *z.Shifts[0].Variance)
+		}
+
+		body, err := posServices.ZReportCSV(z)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(body), "section,key,value\n"))
+		assert.Contains(t, string(body), "sales,net_sales,300.00\n")
+		assert.Contains(t, string(body), "payments,CASH_amount,200.00\n")
+
+		_, err = svc.GetZReport(ctx, f.TenantID, f.BranchID, to, from)
+		assert.ErrorContains(t, err, "invalid range")
+	})
+}
+
+func ptrMoney(m posModels.Money) *posModels.Money {
+	return &m
+}
@@ -0,0 +1,288 @@
+package webhookControllers
+
+import (
+	"strings"
+
+	helperFunc "myapp/modules/core"
+	coreModels "myapp/modules/core/models"
+	webhookModels "myapp/modules/webhook/models"
+	webhookPort "myapp/modules/webhook/port"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookController struct {
+	Service webhookPort.IWebhookService
+}
+
+func NewWebhookController(service webhookPort.IWebhookService) *WebhookController {
+	return &WebhookController{Service: service}
+}
+
+var RolesCanManageWebhook = []coreModels.RoleName{
+	coreModels.RoleNameSaaSSuperAdmin,
+	coreModels.RoleNameTenant,
+	coreModels.RoleNameTenantAdmin,
+}
+
+func webhookError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "invalid"):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+}
+
+// ListEndpoints godoc
+// @Summary      ดู webhook endpoint ของ tenant
+// @Tags         Webhook
+// @Produce      json
+// @Param        tenant_id  path      uint  true  "รหัส Tenant"
+// @Success      200        {array}   webhookModels.WebhookEndpoint
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/endpoints [get]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) ListEndpoints(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	list, err := ctrl.Service.ListEndpoints(c.Context(), tenantID)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": list})
+}
+
+// CreateEndpoint godoc
+// @Summary      เพิ่ม webhook endpoint
+// @Description  events ที่รองรับ: appointment.created, appointment.cancelled, appointment.rescheduled, appointment.completed, review.created, customer.created
+// @Description  response มี secret สำหรับตรวจ X-Webhook-Signature ซึ่งจะแสดงครั้งเดียว
+// @Description  signature = "sha256=" + hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body))
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id  path      uint                              true  "รหัส Tenant"
+// @Param        body       body      webhookPort.CreateEndpointRequest  true  "ปลายทางและ event ที่สมัคร"
+// @Success      201        {object}  webhookPort.EndpointSecretResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/endpoints [post]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) CreateEndpoint(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	var req webhookPort.CreateEndpointRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	resp, err := ctrl.Service.CreateEndpoint(c.Context(), tenantID, req)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": resp})
+}
+
+// UpdateEndpoint godoc
+// @Summary      แก้ไข webhook endpoint
+// @Description  ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        tenant_id    path      uint                              true  "รหัส Tenant"
+// @Param        endpoint_id  path      uint                              true  "รหัส endpoint"
+// @Param        body         body      webhookPort.UpdateEndpointRequest  true  "ค่าที่ต้องการแก้"
+// @Success      200          {object}  webhookModels.WebhookEndpoint
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/endpoints/{endpoint_id} [put]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) UpdateEndpoint(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	endpointID, err := helperFunc.ParseUintParam(c, "endpoint_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid endpoint_id"})
+	}
+	var req webhookPort.UpdateEndpointRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid request body"})
+	}
+
+	ep, err := ctrl.Service.UpdateEndpoint(c.Context(), tenantID, endpointID, req)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": ep})
+}
+
+// DeleteEndpoint godoc
+// @Summary      ลบ webhook endpoint
+// @Tags         Webhook
+// @Produce      json
+// @Param        tenant_id    path      uint  true  "รหัส Tenant"
+// @Param        endpoint_id  path      uint  true  "รหัส endpoint"
+// @Success      200          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/endpoints/{endpoint_id} [delete]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) DeleteEndpoint(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	endpointID, err := helperFunc.ParseUintParam(c, "endpoint_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid endpoint_id"})
+	}
+
+	if err := ctrl.Service.DeleteEndpoint(c.Context(), tenantID, endpointID); err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Webhook endpoint deleted"})
+}
+
+// RotateSecret godoc
+// @Summary      เปลี่ยน secret ของ webhook endpoint
+// @Description  secret เดิมใช้ไม่ได้ทันที secret ใหม่แสดงครั้งเดียว
+// @Tags         Webhook
+// @Produce      json
+// @Param        tenant_id    path      uint  true  "รหัส Tenant"
+// @Param        endpoint_id  path      uint  true  "รหัส endpoint"
+// @Success      200          {object}  webhookPort.EndpointSecretResponse
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/endpoints/{endpoint_id}/rotate-secret [post]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) RotateSecret(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	endpointID, err := helperFunc.ParseUintParam(c, "endpoint_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid endpoint_id"})
+	}
+
+	resp, err := ctrl.Service.RotateSecret(c.Context(), tenantID, endpointID)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": resp})
+}
+
+// ListDeliveries godoc
+// @Summary      delivery log ของ webhook
+// @Description  เรียงจากใหม่ไปเก่า พร้อมสถานะ (PENDING, SUCCEEDED, FAILED), จำนวนครั้งที่ส่ง และ response ล่าสุดของปลายทาง
+// @Tags         Webhook
+// @Produce      json
+// @Param        tenant_id    path      uint    true   "รหัส Tenant"
+// @Param        endpoint_id  query     uint    false  "กรองตาม endpoint"
+// @Param        status       query     string  false  "กรองตามสถานะ"
+// @Param        event        query     string  false  "กรองตาม event"
+// @Param        limit        query     int     false  "จำนวนสูงสุด (ค่าเริ่มต้น 50, ไม่เกิน 200)"
+// @Success      200          {array}   webhookModels.WebhookDelivery
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/deliveries [get]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) ListDeliveries(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+
+	status := webhookModels.DeliveryStatus(strings.ToUpper(c.Query("status")))
+	switch status {
+	case "", webhookModels.DeliveryPending, webhookModels.DeliverySucceeded, webhookModels.DeliveryFailed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid status"})
+	}
+	endpointID := c.QueryInt("endpoint_id", 0)
+	if endpointID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid endpoint_id"})
+	}
+
+	list, err := ctrl.Service.ListDeliveries(c.Context(), tenantID, webhookPort.DeliveryFilter{
+		EndpointID: uint(endpointID),
+		Status:     status,
+		Event:      c.Query("event"),
+		Limit:      c.QueryInt("limit", 50),
+	})
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "data": list})
+}
+
+// ReplayDelivery godoc
+// @Summary      ส่ง webhook ที่ล้มเหลวใหม่
+// @Description  ใช้ได้กับ delivery ที่เป็น FAILED เท่านั้น payload และ event id เดิม
+// @Tags         Webhook
+// @Produce      json
+// @Param        tenant_id    path      uint  true  "รหัส Tenant"
+// @Param        delivery_id  path      uint  true  "รหัส delivery"
+// @Success      202          {object}  webhookModels.WebhookDelivery
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Router       /webhooks/tenants/{tenant_id}/deliveries/{delivery_id}/replay [post]
+// @Security     ApiKeyAuth
+func (ctrl *WebhookController) ReplayDelivery(c *fiber.Ctx) error {
+	roleStr, ok := c.Locals("role").(string)
+	if !ok || !helperFunc.IsAuthorizedRole(roleStr, RolesCanManageWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Permission denied"})
+	}
+	tenantID, err := helperFunc.ParseUintParam(c, "tenant_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tenant_id"})
+	}
+	deliveryID, err := helperFunc.ParseUintParam(c, "delivery_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid delivery_id"})
+	}
+
+	d, err := ctrl.Service.ReplayDelivery(c.Context(), tenantID, deliveryID)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"status": "success", "data": d})
+}
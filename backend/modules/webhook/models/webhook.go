@@ -0,0 +1,82 @@
+package webhookModels
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// event ที่ tenant สมัครรับได้
+const (
+	EventAppointmentCreated     = "appointment.created"
+	EventAppointmentCancelled   = "appointment.cancelled"
+	EventAppointmentRescheduled = "appointment.rescheduled"
+	EventAppointmentCompleted   = "appointment.completed"
+	EventReviewCreated          = "review.created"
+	EventCustomerCreated        = "customer.created"
+)
+
+// SupportedEvents รายการ event ทั้งหมดตามลำดับที่แสดงใน API
+var SupportedEvents = []string{
+	EventAppointmentCreated,
+	EventAppointmentCancelled,
+	EventAppointmentRescheduled,
+	EventAppointmentCompleted,
+	EventReviewCreated,
+	EventCustomerCreated,
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING" // รอส่ง หรือรอ retry
+	DeliverySucceeded DeliveryStatus = "SUCCEEDED"
+	DeliveryFailed    DeliveryStatus = "FAILED" // ส่งไม่สำเร็จครบจำนวนครั้ง หรือ endpoint ถูกปิด (replay ได้)
+)
+
+// WebhookEndpoint ปลายทางของ tenant ที่รับ event ตามที่สมัครไว้
+// Secret ใช้เซ็น payload (HMAC-SHA256) เก็บแบบเข้ารหัสและแสดงให้ผู้ใช้เห็นเฉพาะตอนสร้าง/เปลี่ยนใหม่
+type WebhookEndpoint struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	TenantID        uint           `gorm:"not null;index" json:"tenant_id"`
+	URL             string         `gorm:"type:text;not null" json:"url"`
+	Description     string         `gorm:"type:text" json:"description,omitempty"`
+	Events          []string       `gorm:"type:jsonb;serializer:json;not null" json:"events"`
+	SecretEncrypted string         `gorm:"type:text;not null" json:"-"`
+	Enabled         bool           `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Subscribed endpoint นี้สมัครรับ event นั้นหรือไม่
+func (e *WebhookEndpoint) Subscribed(event string) bool {
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery บันทึกการส่ง event หนึ่งครั้งไปยัง endpoint หนึ่ง
+// ถูกสร้างใน transaction เดียวกับข้อมูลต้นทาง แล้ว cmd/jobworker เป็นผู้ส่ง
+type WebhookDelivery struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	TenantID   uint `gorm:"not null;index" json:"tenant_id"`
+	EndpointID uint `gorm:"not null;index" json:"endpoint_id"`
+	// EventID เหมือนกันทุก endpoint ของ event เดียวกัน ปลายทางใช้กันการประมวลผลซ้ำได้
+	EventID        string         `gorm:"type:varchar(40);not null;index" json:"event_id"`
+	Event          string         `gorm:"type:varchar(100);not null;index" json:"event"`
+	Payload        string         `gorm:"type:jsonb;not null" json:"payload"`
+	Status         DeliveryStatus `gorm:"type:varchar(20);not null;default:'PENDING';index" json:"status"`
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`
+	ResponseStatus int            `json:"response_status,omitempty"`
+	ResponseBody   string         `gorm:"type:text" json:"response_body,omitempty"`
+	LastError      string         `gorm:"type:text" json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time     `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	ClaimedAt      *time.Time     `json:"-"` // ตั้งตอนเริ่มส่ง ล้างเมื่อบันทึกผล
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
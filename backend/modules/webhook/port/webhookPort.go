@@ -0,0 +1,52 @@
+package webhookPort
+
+import (
+	"context"
+
+	webhookModels "myapp/modules/webhook/models"
+)
+
+type CreateEndpointRequest struct {
+	URL         string   `json:"url" example:"https://erp.example.com/hooks/booking"`
+	Description string   `json:"description,omitempty" example:"ระบบบัญชี"`
+	Events      []string `json:"events" example:"appointment.created,appointment.cancelled"`
+	Enabled     *bool    `json:"enabled,omitempty" example:"true"`
+}
+
+// UpdateEndpointRequest ฟิลด์ที่ไม่ส่งมาจะคงค่าเดิม
+type UpdateEndpointRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+}
+
+// EndpointSecretResponse คืน secret แบบอ่านได้ครั้งเดียว (ตอนสร้างหรือเปลี่ยน secret)
+type EndpointSecretResponse struct {
+	Endpoint webhookModels.WebhookEndpoint `json:"endpoint"`
+	Secret   string                        `json:"secret" example:"whsec_3f9a..."`
+}
+
+type DeliveryFilter struct {
+	EndpointID uint
+	Status     webhookModels.DeliveryStatus
+	Event      string
+	Limit      int
+}
+
+type IWebhookService interface {
+	ListEndpoints(ctx context.Context, tenantID uint) ([]webhookModels.WebhookEndpoint, error)
+	CreateEndpoint(ctx context.Context, tenantID uint, req CreateEndpointRequest) (*EndpointSecretResponse, error)
+	UpdateEndpoint(ctx context.Context, tenantID, endpointID uint, req UpdateEndpointRequest) (*webhookModels.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, tenantID, endpointID uint) error
+	// สร้าง secret ใหม่ secret เดิมใช้ไม่ได้ทันที
+	RotateSecret(ctx context.Context, tenantID, endpointID uint) (*EndpointSecretResponse, error)
+
+	// delivery log เรียงจากใหม่ไปเก่า
+	ListDeliveries(ctx context.Context, tenantID uint, filter DeliveryFilter) ([]webhookModels.WebhookDelivery, error)
+	// ส่ง delivery ที่ FAILED ใหม่อีกรอบ (ได้จำนวน retry เต็มอีกครั้ง)
+	ReplayDelivery(ctx context.Context, tenantID, deliveryID uint) (*webhookModels.WebhookDelivery, error)
+
+	// ส่ง delivery หนึ่งรายการ finalAttempt = true จะบันทึก FAILED เมื่อส่งไม่สำเร็จ
+	Deliver(ctx context.Context, deliveryID uint, finalAttempt bool) error
+}
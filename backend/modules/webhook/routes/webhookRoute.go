@@ -0,0 +1,23 @@
+package webhookRoutes
+
+import (
+	middlewares "myapp/middlewares"
+	coremiddlewares "myapp/modules/core/middlewares"
+	webhookControllers "myapp/modules/webhook/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterWebhookRoutes(router fiber.Router, ctrl *webhookControllers.WebhookController) {
+	group := router.Group("/tenants/:tenant_id")
+	group.Use(middlewares.RequireAuth(), coremiddlewares.RequireTenant())
+
+	group.Get("/endpoints", ctrl.ListEndpoints)
+	group.Post("/endpoints", ctrl.CreateEndpoint)
+	group.Put("/endpoints/:endpoint_id", ctrl.UpdateEndpoint)
+	group.Delete("/endpoints/:endpoint_id", ctrl.DeleteEndpoint)
+	group.Post("/endpoints/:endpoint_id/rotate-secret", ctrl.RotateSecret)
+
+	group.Get("/deliveries", ctrl.ListDeliveries)
+	group.Post("/deliveries/:delivery_id/replay", ctrl.ReplayDelivery)
+}
@@ -0,0 +1,509 @@
+package webhookServices
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"myapp/jobs"
+	webhookModels "myapp/modules/webhook/models"
+	webhookPort "myapp/modules/webhook/port"
+	"myapp/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobDeliverWebhook งานส่ง webhook หนึ่ง delivery (ประมวลผลโดย cmd/jobworker)
+const JobDeliverWebhook = "webhook.deliver"
+
+// retry ด้วย backoff ของ worker (30 วินาที เพิ่มเท่าตัว สูงสุด 1 ชั่วโมง) รวมราว 1 ชั่วโมงก่อนเป็น FAILED
+const deliveryMaxAttempts = 8
+
+// การจองส่งที่ค้างนานกว่านี้ (process ตายระหว่างส่ง) ถือว่าหมดอายุ ต้องนานกว่า timeout ของ HTTP client
+const deliveryClaimTimeout = time.Minute
+
+// เก็บ response body ของปลายทางไว้ดูใน log ไม่เกินนี้
+const maxResponseBodyBytes = 1024
+
+const maxEndpointsPerTenant = 20
+
+type deliverPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// eventEnvelope body ที่ส่งไปยังปลายทาง
+type eventEnvelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	TenantID  uint        `json:"tenant_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookService struct {
+	DB     *gorm.DB
+	Client *http.Client
+	Now    func() time.Time
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		DB:     db,
+		Client: NewSafeHTTPClient(10 * time.Second),
+		Now:    time.Now,
+	}
+}
+
+// EnqueueEventTx สร้าง delivery ให้ทุก endpoint ของ tenant ที่เปิดอยู่และสมัคร event นี้ พร้อมงานส่งใน tx เดียวกัน
+// ถ้าไม่มี endpoint ที่สมัครไว้จะไม่สร้างอะไร
+func EnqueueEventTx(tx *gorm.DB, tenantID uint, event string, data interface{}) error {
+	var endpoints []webhookModels.WebhookEndpoint
+	if err := tx.
+		Where("tenant_id = ? AND enabled = ?", tenantID, true).
+		Order("id").
+		Find(&endpoints).Error; err != nil {
+		return fmt.Errorf("failed to fetch webhook endpoints: %w", err)
+	}
+
+	var subscribed []webhookModels.WebhookEndpoint
+	for _, ep := range endpoints {
+		if ep.Subscribed(event) {
+			subscribed = append(subscribed, ep)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	eventID, err := randomToken("evt_", 16)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(eventEnvelope{
+		ID:        eventID,
+		Event:     event,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	for _, ep := range subscribed {
+		d := webhookModels.WebhookDelivery{
+			TenantID:   tenantID,
+			EndpointID: ep.ID,
+			EventID:    eventID,
+			Event:      event,
+			Payload:    string(body),
+			Status:     webhookModels.DeliveryPending,
+		}
+		if err := tx.Create(&d).Error; err != nil {
+			return fmt.Errorf("failed to create webhook delivery: %w", err)
+		}
+		if err := enqueueDeliveryTx(tx, d.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func enqueueDeliveryTx(tx *gorm.DB, deliveryID uint) error {
+	_, err := jobs.Enqueue(tx, JobDeliverWebhook,
+		deliverPayload{DeliveryID: deliveryID},
+		jobs.MaxAttempts(deliveryMaxAttempts),
+	)
+	return err
+}
+
+// RegisterJobs ผูก handler ส่ง webhook กับ worker
+func (s *WebhookService) RegisterJobs(w *jobs.Worker) {
+	w.Register(JobDeliverWebhook, func(ctx context.Context, job *jobs.Job) error {
+		var p deliverPayload
+		if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid payload for %s: %w", job.Type, err))
+		}
+		return s.Deliver(ctx, p.DeliveryID, job.Attempts >= job.MaxAttempts)
+	})
+}
+
+func (s *WebhookService) Deliver(ctx context.Context, deliveryID uint, finalAttempt bool) error {
+	db := s.DB.WithContext(ctx)
+
+	var d webhookModels.WebhookDelivery
+	if err := db.First(&d, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(fmt.Errorf("webhook delivery with ID %d not found", deliveryID))
+		}
+		return err
+	}
+	// ส่งสำเร็จไปแล้ว หรือปิดไปแล้ว (job ถูกรันซ้ำ)
+	if d.Status != webhookModels.DeliveryPending {
+		return nil
+	}
+
+	var ep webhookModels.WebhookEndpoint
+	err := db.Unscoped().First(&ep, d.EndpointID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || ep.DeletedAt.Valid || !ep.Enabled {
+		return s.markFailed(db, &d, "endpoint is disabled or deleted")
+	}
+	secret, err := utils.DecryptSecret(ep.SecretEncrypted)
+	if err != nil {
+		return s.markFailed(db, &d, fmt.Sprintf("cannot read endpoint secret: %v", err))
+	}
+
+	// จอง delivery ก่อนส่ง: งานเดิมที่ถูกรันซ้ำ (เช่น requeue หลัง lease หมด) ระหว่างที่กำลังส่งจะจองไม่ได้
+	// ถ้า process ตายระหว่างส่ง การจองหมดอายุหลัง deliveryClaimTimeout ให้ retry ส่งได้
+	claimedAt := s.Now()
+	res := db.Model(&webhookModels.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND (claimed_at IS NULL OR claimed_at < ?)",
+			d.ID, webhookModels.DeliveryPending, claimedAt.Add(-deliveryClaimTimeout)).
+		Update("claimed_at", claimedAt)
+	if res.Error != nil {
+		return fmt.Errorf("failed to claim webhook delivery %d: %w", d.ID, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+
+	status, respBody, sendErr := s.post(ctx, ep.URL, secret, &d)
+
+	now := s.Now()
+	updates := map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"response_status": status,
+		"response_body":   respBody,
+		"last_attempt_at": now,
+		"claimed_at":      nil,
+	}
+	if sendErr == nil {
+		updates["status"] = webhookModels.DeliverySucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	} else {
+		updates["last_error"] = sendErr.Error()
+		if finalAttempt {
+			updates["status"] = webhookModels.DeliveryFailed
+		}
+	}
+	if err := db.Model(&d).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery %d: %w", d.ID, err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("webhook delivery %d failed: %w", d.ID, sendErr)
+	}
+	return nil
+}
+
+// post ส่ง payload ที่เซ็นแล้ว ตอบ 2xx ถือว่าสำเร็จ
+func (s *WebhookService) post(ctx context.Context, target, secret string, d *webhookModels.WebhookDelivery) (int, string, error) {
+	body := []byte(d.Payload)
+	timestamp := s.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "myapp-webhooks/1.0")
+	req.Header.Set(HeaderEventID, d.EventID)
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderTimestamp, fmt.Sprintf("%d", timestamp))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(snippet), fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, string(snippet), nil
+}
+
+// markFailed ปิด delivery ที่ส่งไม่ได้แน่นอน ไม่ต้อง retry
+func (s *WebhookService) markFailed(db *gorm.DB, d *webhookModels.WebhookDelivery, reason string) error {
+	if err := db.Model(d).Updates(map[string]interface{}{
+		"status":     webhookModels.DeliveryFailed,
+		"last_error": reason,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery %d: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, tenantID uint) ([]webhookModels.WebhookEndpoint, error) {
+	var out []webhookModels.WebhookEndpoint
+	if err := s.DB.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("id").
+		Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook endpoints: %w", err)
+	}
+	return out, nil
+}
+
+func (s *WebhookService) CreateEndpoint(ctx context.Context, tenantID uint, req webhookPort.CreateEndpointRequest) (*webhookPort.EndpointSecretResponse, error) {
+	target, err := validateEndpointURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	events, err := normalizeEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, encrypted, err := newEndpointSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	ep := webhookModels.WebhookEndpoint{
+		TenantID:        tenantID,
+		URL:             target,
+		Description:     strings.TrimSpace(req.Description),
+		Events:          events,
+		SecretEncrypted: encrypted,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+	}
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&webhookModels.WebhookEndpoint{}).Where("tenant_id = ?", tenantID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxEndpointsPerTenant {
+			return fmt.Errorf("invalid request: a tenant can have at most %d webhook endpoints", maxEndpointsPerTenant)
+		}
+		// Create แทนค่า false ด้วย default ของคอลัมน์ จึง Save ซ้ำ
+		enabled := ep.Enabled
+		if err := tx.Create(&ep).Error; err != nil {
+			return fmt.Errorf("failed to create webhook endpoint: %w", err)
+		}
+		if !enabled {
+			ep.Enabled = false
+			return tx.Save(&ep).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &webhookPort.EndpointSecretResponse{Endpoint: ep, Secret: secret}, nil
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, tenantID, endpointID uint, req webhookPort.UpdateEndpointRequest) (*webhookModels.WebhookEndpoint, error) {
+	ep, err := s.getEndpoint(ctx, tenantID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		if ep.URL, err = validateEndpointURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.Events != nil {
+		if ep.Events, err = normalizeEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		ep.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Enabled != nil {
+		ep.Enabled = *req.Enabled
+	}
+	if err := s.DB.WithContext(ctx).Save(ep).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return ep, nil
+}
+
+// DeleteEndpoint ลบแบบ soft delete เพื่อให้ delivery log เดิมยังดูได้ delivery ที่ค้างอยู่จะถูกปิดเป็น FAILED ตอนส่ง
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, tenantID, endpointID uint) error {
+	ep, err := s.getEndpoint(ctx, tenantID, endpointID)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Delete(ep).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+func (s *WebhookService) RotateSecret(ctx context.Context, tenantID, endpointID uint) (*webhookPort.EndpointSecretResponse, error) {
+	ep, err := s.getEndpoint(ctx, tenantID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	secret, encrypted, err := newEndpointSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.WithContext(ctx).Model(ep).Update("secret_encrypted", encrypted).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return &webhookPort.EndpointSecretResponse{Endpoint: *ep, Secret: secret}, nil
+}
+
+func (s *WebhookService) getEndpoint(ctx context.Context, tenantID, endpointID uint) (*webhookModels.WebhookEndpoint, error) {
+	var ep webhookModels.WebhookEndpoint
+	if err := s.DB.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", endpointID, tenantID).
+		First(&ep).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook endpoint with ID %d not found", endpointID)
+		}
+		return nil, fmt.Errorf("failed to fetch webhook endpoint: %w", err)
+	}
+	return &ep, nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, tenantID uint, filter webhookPort.DeliveryFilter) ([]webhookModels.WebhookDelivery, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	q := s.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filter.EndpointID != 0 {
+		q = q.Where("endpoint_id = ?", filter.EndpointID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.Event != "" {
+		q = q.Where("event = ?", filter.Event)
+	}
+	var out []webhookModels.WebhookDelivery
+	if err := q.Order("id DESC").Limit(limit).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	return out, nil
+}
+
+func (s *WebhookService) ReplayDelivery(ctx context.Context, tenantID, deliveryID uint) (*webhookModels.WebhookDelivery, error) {
+	var d webhookModels.WebhookDelivery
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", deliveryID, tenantID).
+			First(&d).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("webhook delivery with ID %d not found", deliveryID)
+			}
+			return err
+		}
+		if d.Status != webhookModels.DeliveryFailed {
+			return fmt.Errorf("invalid status: only FAILED deliveries can be replayed (current %s)", d.Status)
+		}
+
+		var ep webhookModels.WebhookEndpoint
+		if err := tx.Where("id = ? AND tenant_id = ?", d.EndpointID, tenantID).First(&ep).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("invalid delivery: webhook endpoint %d was deleted", d.EndpointID)
+			}
+			return err
+		}
+		if !ep.Enabled {
+			return fmt.Errorf("invalid delivery: webhook endpoint %d is disabled", d.EndpointID)
+		}
+
+		// เริ่มรอบส่งใหม่: ล้างผลของรอบก่อน ให้ log แสดงเฉพาะการส่งครั้งนี้
+		if err := tx.Model(&d).Updates(map[string]interface{}{
+			"status":          webhookModels.DeliveryPending,
+			"attempts":        0,
+			"response_status": 0,
+			"response_body":   "",
+			"last_error":      "",
+			"last_attempt_at": nil,
+			"delivered_at":    nil,
+			"claimed_at":      nil,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to replay webhook delivery: %w", err)
+		}
+		return enqueueDeliveryTx(tx, d.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// validateEndpointURL รับเฉพาะ http(s) ที่มี host; IP ภายในถูกกันอีกชั้นตอนเชื่อมต่อ
+func validateEndpointURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid url: must be an absolute http(s) URL")
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return "", fmt.Errorf("invalid url: %s is not allowed", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && isBlockedIP(ip) {
+		return "", fmt.Errorf("invalid url: %s is not allowed", host)
+	}
+	return raw, nil
+}
+
+// normalizeEvents ตัดตัวซ้ำ และต้องเป็น event ที่รองรับเท่านั้น
+func normalizeEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("invalid events: at least one event is required")
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, ev := range events {
+		ev = strings.TrimSpace(ev)
+		supported := false
+		for _, s := range webhookModels.SupportedEvents {
+			if s == ev {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return nil, fmt.Errorf("invalid events: unknown event %q", ev)
+		}
+		if !seen[ev] {
+			seen[ev] = true
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func newEndpointSecret() (string, string, error) {
+	secret, err := randomToken("whsec_", 24)
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, encrypted, nil
+}
+
+func randomToken(prefix string, n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,260 @@
+package webhookServiceTest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"myapp/jobs"
+	webhookModels "myapp/modules/webhook/models"
+	webhookPort "myapp/modules/webhook/port"
+	webhookServices "myapp/modules/webhook/services"
+)
+
+func setupWebhookDB(t *testing.T) *gorm.DB {
+	t.Setenv("APP_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// :memory: แยกฐานข้อมูลต่อ connection
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&webhookModels.WebhookEndpoint{}, &webhookModels.WebhookDelivery{}, &jobs.Job{}))
+	return db
+}
+
+// receiver ปลายทางจำลอง ตรวจ signature ด้วย secret ที่ได้ตอนสร้าง endpoint
+type receiver struct {
+	secret   string
+	status   atomic.Int32
+	received atomic.Int32
+	lastBody []byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	ts, _ := strconv.ParseInt(req.Header.Get(webhookServices.HeaderTimestamp), 10, 64)
+	if req.Header.Get(webhookServices.HeaderSignature) != webhookServices.Sign(r.secret, ts, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.received.Add(1)
+	r.lastBody = body
+	w.WriteHeader(int(r.status.Load()))
+	_, _ = w.Write([]byte("ok"))
+}
+
+func reloadDelivery(t *testing.T, db *gorm.DB, id uint) webhookModels.WebhookDelivery {
+	var d webhookModels.WebhookDelivery
+	require.NoError(t, db.First(&d, id).Error)
+	return d
+}
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+	db := setupWebhookDB(t)
+	svc := webhookServices.NewWebhookService(db)
+	// ปลายทางทดสอบอยู่บน 127.0.0.1 ซึ่ง client จริงไม่ยอมเชื่อมต่อ
+	svc.Client = &http.Client{Timeout: 5 * time.Second}
+
+	t.Run("Validates URL and events", func(t *testing.T) {
+		_, err := svc.CreateEndpoint(ctx, 1, webhookPort.CreateEndpointRequest{URL: "http://localhost:8080/hook", Events: []string{"appointment.created"}})
+		assert.ErrorContains(t, err, "invalid url")
+		_, err = svc.CreateEndpoint(ctx, 1, webhookPort.CreateEndpointRequest{URL: "http://169.254.169.254/latest", Events: []string{"appointment.created"}})
+		assert.ErrorContains(t, err, "invalid url")
+		_, err = svc.CreateEndpoint(ctx, 1, webhookPort.CreateEndpointRequest{URL: "ftp://example.com", Events: []string{"appointment.created"}})
+		assert.ErrorContains(t, err, "invalid url")
+		_, err = svc.CreateEndpoint(ctx, 1, webhookPort.CreateEndpointRequest{URL: "https://example.com/hook", Events: []string{"appointment.deleted"}})
+		assert.ErrorContains(t, err, "invalid events")
+	})
+
+	rcv := &receiver{}
+	rcv.status.Store(http.StatusOK)
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	created, err := svc.CreateEndpoint(ctx, 1, webhookPort.CreateEndpointRequest{
+		URL:    "https://erp.example.com/hooks",
+		Events: []string{"appointment.created", "review.created", "appointment.created"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"appointment.created", "review.created"}, created.Endpoint.Events)
+	assert.NotEmpty(t, created.Secret)
+	assert.NotContains(t, created.Endpoint.SecretEncrypted, created.Secret)
+	rcv.secret = created.Secret
+	// ชี้ไปที่ปลายทางทดสอบ (validateEndpointURL ไม่รับ loopback)
+	require.NoError(t, db.Model(&webhookModels.WebhookEndpoint{}).Where("id = ?", created.Endpoint.ID).Update("url", srv.URL).Error)
+
+	enqueue := func(tenantID uint, event string) []webhookModels.WebhookDelivery {
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			return webhookServices.EnqueueEventTx(tx, tenantID, event, map[string]interface{}{"id": 42})
+		}))
+		var out []webhookModels.WebhookDelivery
+		require.NoError(t, db.Where("event = ? AND tenant_id = ?", event, tenantID).Order("id DESC").Limit(1).Find(&out).Error)
+		return out
+	}
+
+	t.Run("Only subscribed events create deliveries", func(t *testing.T) {
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			if err := webhookServices.EnqueueEventTx(tx, 1, webhookModels.EventCustomerCreated, nil); err != nil {
+				return err
+			}
+			return webhookServices.EnqueueEventTx(tx, 2, webhookModels.EventAppointmentCreated, nil)
+		}))
+		var count int64
+		db.Model(&webhookModels.WebhookDelivery{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Signed delivery succeeds", func(t *testing.T) {
+		ds := enqueue(1, webhookModels.EventAppointmentCreated)
+		require.Len(t, ds, 1)
+		var jobCount int64
+		db.Model(&jobs.Job{}).Where("type = ?", webhookServices.JobDeliverWebhook).Count(&jobCount)
+		assert.Equal(t, int64(1), jobCount)
+
+		require.NoError(t, svc.Deliver(ctx, ds[0].ID, false))
+		d := reloadDelivery(t, db, ds[0].ID)
+		assert.Equal(t, webhookModels.DeliverySucceeded, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Equal(t, http.StatusOK, d.ResponseStatus)
+		assert.NotNil(t, d.DeliveredAt)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rcv.lastBody, &body))
+		assert.Equal(t, d.EventID, body["id"])
+		assert.Equal(t, "appointment.created", body["event"])
+		assert.Equal(t, float64(42), body["data"].(map[string]interface{})["id"])
+
+		// job รันซ้ำ → ไม่ส่งซ้ำ
+		require.NoError(t, svc.Deliver(ctx, ds[0].ID, false))
+		assert.Equal(t, int32(1), rcv.received.Load())
+	})
+
+	t.Run("Failures retry, then FAILED, then replay", func(t *testing.T) {
+		rcv.status.Store(http.StatusInternalServerError)
+		ds := enqueue(1, webhookModels.EventReviewCreated)
+		require.Len(t, ds, 1)
+		id := ds[0].ID
+
+		// ส่งผ่าน worker: ล้มเหลว → job ถูกเลื่อนไปรอบถัดไป
+		w := jobs.NewWorker(db, "webhook-test")
+		w.Now = func() time.Time { return time.Now().Add(time.Second) }
+		svc.RegisterJobs(w)
+		n, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		// รวม job ของ delivery ก่อนหน้าที่ส่งสำเร็จไปแล้ว (ไม่ส่งซ้ำ): ปลายทางได้รับเพิ่มเฉพาะ review.created
+		assert.Equal(t, 2, n)
+		assert.Equal(t, int32(2), rcv.received.Load())
+
+		d := reloadDelivery(t, db, id)
+		assert.Equal(t, webhookModels.DeliveryPending, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, d.ResponseStatus)
+		assert.Contains(t, d.LastError, "500")
+
+		var job jobs.Job
+		require.NoError(t, db.Where("type = ? AND status = ?", webhookServices.JobDeliverWebhook, jobs.StatusPending).First(&job).Error)
+		assert.True(t, job.RunAt.After(time.Now().Add(10*time.Second)), "retry must be delayed by backoff")
+
+		require.Error(t, svc.Deliver(ctx, id, true))
+		d = reloadDelivery(t, db, id)
+		assert.Equal(t, webhookModels.DeliveryFailed, d.Status)
+		assert.Equal(t, 2, d.Attempts)
+
+		_, err = svc.ReplayDelivery(ctx, 2, id)
+		assert.ErrorContains(t, err, "not found")
+
+		rcv.status.Store(http.StatusNoContent)
+		replayed, err := svc.ReplayDelivery(ctx, 1, id)
+		require.NoError(t, err)
+		assert.Equal(t, webhookModels.DeliveryPending, replayed.Status)
+		d = reloadDelivery(t, db, id)
+		assert.Equal(t, 0, d.Attempts)
+		assert.Empty(t, d.LastError)
+		assert.Zero(t, d.ResponseStatus)
+		assert.Nil(t, d.LastAttemptAt)
+
+		require.NoError(t, svc.Deliver(ctx, id, false))
+		d = reloadDelivery(t, db, id)
+		assert.Equal(t, webhookModels.DeliverySucceeded, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+
+		_, err = svc.ReplayDelivery(ctx, 1, id)
+		assert.ErrorContains(t, err, "only FAILED")
+	})
+
+	t.Run("Claimed delivery is not sent twice", func(t *testing.T) {
+		rcv.status.Store(http.StatusNoContent)
+		ds := enqueue(1, webhookModels.EventReviewCreated)
+		require.Len(t, ds, 1)
+		before := rcv.received.Load()
+
+		// งานเดิมถูกรันซ้ำระหว่างที่อีกตัวกำลังส่ง → ข้าม
+		claimed := time.Now()
+		require.NoError(t, db.Model(&ds[0]).Update("claimed_at", claimed).Error)
+		require.NoError(t, svc.Deliver(ctx, ds[0].ID, false))
+		assert.Equal(t, before, rcv.received.Load())
+		assert.Equal(t, webhookModels.DeliveryPending, reloadDelivery(t, db, ds[0].ID).Status)
+
+		// การจองค้างจาก process ที่ตายไปแล้ว → หมดอายุ ส่งได้
+		require.NoError(t, db.Model(&ds[0]).Update("claimed_at", claimed.Add(-2*time.Minute)).Error)
+		require.NoError(t, svc.Deliver(ctx, ds[0].ID, false))
+		assert.Equal(t, before+1, rcv.received.Load())
+		d := reloadDelivery(t, db, ds[0].ID)
+		assert.Equal(t, webhookModels.DeliverySucceeded, d.Status)
+		assert.Nil(t, d.ClaimedAt)
+	})
+
+	t.Run("Rotated secret is used for new signatures", func(t *testing.T) {
+		rotated, err := svc.RotateSecret(ctx, 1, created.Endpoint.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, created.Secret, rotated.Secret)
+
+		ds := enqueue(1, webhookModels.EventAppointmentCreated)
+		require.Error(t, svc.Deliver(ctx, ds[0].ID, false), "receiver still expects the old secret")
+		assert.Equal(t, http.StatusUnauthorized, reloadDelivery(t, db, ds[0].ID).ResponseStatus)
+
+		rcv.secret = rotated.Secret
+		require.NoError(t, svc.Deliver(ctx, ds[0].ID, false))
+	})
+
+	t.Run("Disabled endpoint fails pending deliveries without retry", func(t *testing.T) {
+		ds := enqueue(1, webhookModels.EventAppointmentCreated)
+		_, err := svc.UpdateEndpoint(ctx, 1, created.Endpoint.ID, webhookPort.UpdateEndpointRequest{Enabled: ptrBool(false)})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Deliver(ctx, ds[0].ID, false))
+		d := reloadDelivery(t, db, ds[0].ID)
+		assert.Equal(t, webhookModels.DeliveryFailed, d.Status)
+		assert.Contains(t, d.LastError, "disabled")
+
+		_, err = svc.ReplayDelivery(ctx, 1, ds[0].ID)
+		assert.ErrorContains(t, err, "disabled")
+
+		list, err := svc.ListDeliveries(ctx, 1, webhookPort.DeliveryFilter{Status: webhookModels.DeliveryFailed})
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+	})
+
+	t.Run("Safe client refuses internal addresses", func(t *testing.T) {
+		client := webhookServices.NewSafeHTTPClient(time.Second)
+		_, err := client.Get(srv.URL)
+		assert.ErrorContains(t, err, "not allowed")
+	})
+}
+
+func ptrBool(b bool) *bool { return &b }
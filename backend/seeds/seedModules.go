@@ -9,9 +9,9 @@ import (
 
 func SeedModules(db *gorm.DB) error {
 	modules := []coreModels.Module{
-		{Name: "barber_booking", Description: "ระบบจองคิวตัดผม"},
-		{Name: "pos", Description: "ระบบขายหน้าร้าน"},
-		{Name: "inventory", Description: "ระบบจัดการสต๊อก"},
+		{Name: coreModels.ModuleBarberBooking, Description: "ระบบจองคิวตัดผม"},
+		{Name: coreModels.ModulePOS, Description: "ระบบขายหน้าร้าน"},
+		{Name: coreModels.ModuleInventory, Description: "ระบบจัดการสต๊อก"},
 	}
 
 	now := time.Now()
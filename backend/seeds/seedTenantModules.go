@@ -16,7 +16,7 @@ func SeedTenantModules(db *gorm.DB) error {
 
 	// 2) หา modules ที่เราจะผูกกับ tenant นี้
 	var modules []coreModels.Module
-	if err := db.Where("name IN ?", []string{"barber_booking", "pos"}).Find(&modules).Error; err != nil {
+	if err := db.Where("name IN ?", []string{coreModels.ModuleBarberBooking, coreModels.ModulePOS, coreModels.ModuleInventory}).Find(&modules).Error; err != nil {
 		return errors.New("failed to load modules: " + err.Error())
 	}
 	if len(modules) == 0 {
@@ -0,0 +1,480 @@
+// Package qrcode สร้าง QR Code (ISO/IEC 18004) แบบ byte mode ระดับแก้ข้อผิดพลาด M
+// รองรับ version 1-10 (สูงสุด 213 ไบต์) พอสำหรับ payload พร้อมเพย์และลิงก์สั้น
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// quietZone ขอบว่างรอบ QR ตามมาตรฐาน (หน่วยเป็น module)
+const quietZone = 4
+
+var ErrTooLong = errors.New("qrcode: content too long")
+
+// ตารางของระดับ M ต่อ version: จำนวน EC codeword ต่อ block และจำนวน data codeword ของแต่ละ block
+var versionsM = [...]struct {
+	ecPerBlock int
+	blocks     []int
+}{
+	1:  {10, []int{16}},
+	2:  {16, []int{28}},
+	3:  {26, []int{44}},
+	4:  {18, []int{32, 32}},
+	5:  {24, []int{43, 43}},
+	6:  {16, []int{27, 27, 27, 27}},
+	7:  {18, []int{31, 31, 31, 31}},
+	8:  {22, []int{38, 38, 39, 39}},
+	9:  {22, []int{36, 36, 36, 37, 37}},
+	10: {26, []int{43, 43, 43, 43, 44}},
+}
+
+var alignmentPositions = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+// Code ตาราง module ของ QR ที่สร้างแล้ว
+type Code struct {
+	Version  int
+	Size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode สร้าง QR ของ content โดยเลือก version เล็กที่สุดที่ใส่ได้
+func Encode(content string) (*Code, error) {
+	data := []byte(content)
+	version := 0
+	for v := 1; v < len(versionsM); v++ {
+		if 4+countBits(v)+8*len(data) <= 8*dataCapacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := interleave(version, encodeData(version, data))
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(codewords)
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); best < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // XOR ซ้ำเพื่อคืนค่าเดิม
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return c, nil
+}
+
+// Black คืน true ถ้า module ที่ (x, y) เป็นสีดำ
+func (c *Code) Black(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// PNG วาด QR เป็นภาพขาวดำ scale พิกเซลต่อ module พร้อมขอบว่าง
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*quietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+func dataCapacity(version int) int {
+	n := 0
+	for _, b := range versionsM[version].blocks {
+		n += b
+	}
+	return n
+}
+
+// encodeData ต่อ mode indicator, ความยาว, ข้อมูล, terminator และ pad byte ให้เต็มความจุ
+func encodeData(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4) // byte mode
+	bits.append(uint(len(data)), countBits(version))
+	for _, b := range data {
+		bits.append(uint(b), 8)
+	}
+	capacity := dataCapacity(version) * 8
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := uint(0xEC); len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+	return out
+}
+
+// interleave แบ่ง data เป็น block เติม EC แล้วสลับ codeword ตามลำดับที่มาตรฐานกำหนด
+func interleave(version int, data []byte) []byte {
+	spec := versionsM[version]
+	divisor := rsDivisor(spec.ecPerBlock)
+	dataBlocks := make([][]byte, len(spec.blocks))
+	ecBlocks := make([][]byte, len(spec.blocks))
+	maxLen := 0
+	for i, n := range spec.blocks {
+		dataBlocks[i] = data[:n]
+		data = data[n:]
+		ecBlocks[i] = rsRemainder(dataBlocks[i], divisor)
+		maxLen = max(maxLen, n)
+	}
+	var out []byte
+	for i := 0; i < maxLen; i++ {
+		for _, b := range dataBlocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ecPerBlock; i++ {
+		for _, b := range ecBlocks {
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+func newCode(version int) *Code {
+	size := version*4 + 17
+	c := &Code{Version: version, Size: size}
+	c.modules = make([][]bool, size)
+	c.function = make([][]bool, size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.function[i] = make([]bool, size)
+	}
+	return c
+}
+
+func (c *Code) setFunction(x, y int, black bool) {
+	c.modules[y][x] = black
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	if c.Version >= 2 {
+		pos := alignmentPositions[c.Version]
+		last := len(pos) - 1
+		for i, x := range pos {
+			for j, y := range pos {
+				// ข้ามตำแหน่งที่ทับ finder
+				if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+					continue
+				}
+				c.drawAlignment(x, y)
+			}
+		}
+	}
+
+	// จองพื้นที่ format ไว้ก่อน ค่าจริงวาดหลังเลือก mask
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= c.Size || y < 0 || y >= c.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.setFunction(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits ระดับ M (00) กับ mask เข้ารหัส BCH(15,5) แล้ววางสองชุด
+func (c *Code) drawFormatBits(mask int) {
+	data := uint(mask) // ระดับ M = 0
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true) // dark module
+}
+
+// drawVersion ข้อมูล version (BCH(18,6)) สำหรับ version 7 ขึ้นไป
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := uint(c.Version)
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := uint(c.Version)<<12 | rem
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords วาง bit แบบซิกแซกทีละสองคอลัมน์จากมุมขวาล่าง ข้ามคอลัมน์ timing
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if c.function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = (data[i>>3]>>(7-uint(i&7)))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty คะแนนตามกฎ 4 ข้อของมาตรฐาน ใช้เลือก mask ที่อ่านง่ายที่สุด
+func (c *Code) penalty() int {
+	n := c.Size
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return c.modules[x][y]
+		}
+		return c.modules[y][x]
+	}
+	finder := []bool{true, false, true, true, true, false, true}
+	result := 0
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			// 1:1:3:1:1 ที่มีช่องว่างสี่ module ด้านใดด้านหนึ่ง
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for k, v := range finder {
+					if at(x+k, y, vertical) != v {
+						match = false
+						break
+					}
+				}
+				if match && (lightRun(at, x-4, x, y, vertical, n) || lightRun(at, x+7, x+11, y, vertical, n)) {
+					result += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				v := c.modules[y][x]
+				if c.modules[y][x+1] == v && c.modules[y+1][x] == v && c.modules[y+1][x+1] == v {
+					result += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	result += abs(dark*20-total*10) / total * 10
+	return result
+}
+
+// lightRun ช่วง [from, to) เป็นสีขาวทั้งหมด (นอกขอบนับเป็นขาว)
+func lightRun(at func(x, y int, vertical bool) bool, from, to, y int, vertical bool, n int) bool {
+	for x := from; x < to; x++ {
+		if x >= 0 && x < n && at(x, y, vertical) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reed-Solomon บน GF(256) พหุนาม 0x11D
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(val uint, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (val>>uint(i))&1 == 1)
+	}
+}
+
+func bit(x uint, i int) bool {
+	return (x>>uint(i))&1 == 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
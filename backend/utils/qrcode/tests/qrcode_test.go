@@ -0,0 +1,173 @@
+package qrcodeTest
+
+import (
+	"strings"
+	"testing"
+
+	"myapp/utils/qrcode"
+
+	reference "github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatM ค่า format information 15 bit ของระดับ M ตาม mask 0-7 (ตาราง C.1 ของ ISO/IEC 18004)
+var formatM = [8]uint{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+
+// alignment ตำแหน่งศูนย์กลาง alignment pattern ของ version 2-10 (ภาคผนวก E)
+var alignment = map[int][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+// TestEncode_MatchesReferenceEncoder เทียบกับ encoder อ้างอิง (skip2/go-qrcode ระดับ M)
+// ครอบคลุมทุก version ที่รองรับ: block เดียว, หลาย block, block สองขนาด, alignment หลายจุด และ version info (7+)
+// encoder แต่ละตัวอาจเลือก mask ต่างกันได้ (ถูกต้องทั้งคู่) จึงเทียบ data module หลังถอด mask ของแต่ละฝั่ง
+// เทียบ function pattern ตรงตัว และตรวจ format bits ของเรากับตารางมาตรฐาน
+func TestEncode_MatchesReferenceEncoder(t *testing.T) {
+	// ตัวพิมพ์เล็กล้วน: encoder อ้างอิงเลือก segment ตามชนิดอักขระ ข้อความนี้จึงเป็น byte mode เหมือนกัน
+	payload := "https://pay.example.com/promptpay?ref=abcdefghijklmnopqrstuvwxyz&shop=barber-booking"
+	versions := map[int]bool{}
+	for _, n := range []int{1, 10, 14, 20, 26, 40, 60, 80, 100, 120, 140, 160, 180, 200, 213} {
+		content := strings.Repeat(payload, n/len(payload)+1)[:n]
+
+		got, err := qrcode.Encode(content)
+		require.NoError(t, err, "length %d", n)
+		want, err := reference.New(content, reference.Medium)
+		require.NoError(t, err)
+		want.DisableBorder = true
+		bitmap := want.Bitmap()
+
+		require.Equal(t, len(bitmap), got.Size, "length %d: size", n)
+		require.Equal(t, want.VersionNumber, got.Version, "length %d: version", n)
+		versions[got.Version] = true
+
+		size := got.Size
+		gotMask, ok := formatMask(t, got.Black, size)
+		require.True(t, ok, "length %d: format bits are not a valid level M format", n)
+		refMask, ok := formatMask(t, func(x, y int) bool { return bitmap[y][x] }, size)
+		require.True(t, ok, "length %d: reference format bits", n)
+
+		mismatch := 0
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				switch {
+				case isFormat(x, y, size):
+					continue
+				case isFunction(x, y, got.Version, size):
+					if got.Black(x, y) != bitmap[y][x] {
+						mismatch++
+					}
+				default:
+					if (got.Black(x, y) != maskAt(gotMask, x, y)) != (bitmap[y][x] != maskAt(refMask, x, y)) {
+						mismatch++
+					}
+				}
+			}
+		}
+		assert.Zero(t, mismatch, "length %d (version %d): modules differ from reference", n, got.Version)
+	}
+	assert.Len(t, versions, 10, "every supported version is covered")
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := qrcode.Encode(strings.Repeat("a", 214))
+	assert.ErrorIs(t, err, qrcode.ErrTooLong)
+}
+
+// formatMask อ่าน format information ทั้งสองชุด ตรวจว่าตรงกันและเป็นระดับ M แล้วคืน mask
+func formatMask(t *testing.T, black func(x, y int) bool, size int) (int, bool) {
+	t.Helper()
+	var first, second uint
+	set := func(v *uint, i int, b bool) {
+		if b {
+			*v |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		set(&first, i, black(8, i))
+	}
+	set(&first, 6, black(8, 7))
+	set(&first, 7, black(8, 8))
+	set(&first, 8, black(7, 8))
+	for i := 9; i < 15; i++ {
+		set(&first, i, black(14-i, 8))
+	}
+	for i := 0; i < 8; i++ {
+		set(&second, i, black(size-1-i, 8))
+	}
+	for i := 8; i < 15; i++ {
+		set(&second, i, black(8, size-15+i))
+	}
+	if first != second || !black(8, size-8) {
+		return 0, false
+	}
+	for mask, bits := range formatM {
+		if bits == first {
+			return mask, true
+		}
+	}
+	return 0, false
+}
+
+func isFormat(x, y, size int) bool {
+	if y == 8 && (x <= 8 || x >= size-8) {
+		return true
+	}
+	return x == 8 && (y <= 8 || y >= size-8)
+}
+
+func isFunction(x, y, version, size int) bool {
+	// finder + separator
+	if (x < 9 && y < 9) || (x >= size-8 && y < 9) || (x < 9 && y >= size-8) {
+		return true
+	}
+	if x == 6 || y == 6 {
+		return true
+	}
+	if version >= 7 && ((x >= size-11 && y < 6) || (y >= size-11 && x < 6)) {
+		return true
+	}
+	pos := alignment[version]
+	last := len(pos) - 1
+	for i, cx := range pos {
+		for j, cy := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			if abs(x-cx) <= 2 && abs(y-cy) <= 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maskAt สูตร mask 0-7 ตามมาตรฐาน (x = คอลัมน์, y = แถว)
+func maskAt(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
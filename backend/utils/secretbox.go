@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ค่าที่เข้ารหัสแล้วขึ้นต้นด้วย version เผื่อเปลี่ยนวิธีเข้ารหัสในอนาคต
+const secretBoxPrefix = "v1:"
+
+// encryptionKey อ่านกุญแจ AES-256 จาก APP_ENCRYPTION_KEY (base64 ของ 32 ไบต์)
+func encryptionKey() ([]byte, error) {
+	raw := os.Getenv("APP_ENCRYPTION_KEY")
+	if raw == "" {
+		return nil, errors.New("APP_ENCRYPTION_KEY is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("APP_ENCRYPTION_KEY must be base64 of 32 bytes")
+	}
+	return key, nil
+}
+
+// EncryptSecret เข้ารหัสค่าลับ (เช่น bot token) ด้วย AES-GCM ก่อนเก็บลงฐานข้อมูล
+func EncryptSecret(plaintext string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretBoxPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// IsEncryptedSecret ค่านี้ได้จาก EncryptSecret หรือไม่ (แยกจากค่าเดิมที่ยังเก็บแบบ plaintext)
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, secretBoxPrefix)
+}
+
+// DecryptSecret ถอดรหัสค่าที่ได้จาก EncryptSecret
+func DecryptSecret(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, secretBoxPrefix) {
+		return "", errors.New("unsupported secret format")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, secretBoxPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid secret encoding: %w", err)
+	}
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid secret")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt secret")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}